@@ -128,6 +128,474 @@ func Migrate(db *sql.DB) error {
                     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
                 );`,
 		},
+		{
+			ID:   7,
+			Name: "create_audit_logs_table",
+			SQL: `
+                CREATE TABLE IF NOT EXISTS audit_logs (
+                    id INTEGER PRIMARY KEY AUTOINCREMENT,
+                    actor_id INTEGER NOT NULL,
+                    action TEXT NOT NULL,
+                    target_type TEXT NOT NULL,
+                    target_id INTEGER NOT NULL,
+                    details TEXT NOT NULL DEFAULT '',
+                    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+                );
+                CREATE INDEX IF NOT EXISTS idx_audit_logs_target ON audit_logs (target_type, target_id);`,
+		},
+		{
+			ID:   8,
+			Name: "create_jobs_table",
+			SQL: `
+                CREATE TABLE IF NOT EXISTS jobs (
+                    id INTEGER PRIMARY KEY AUTOINCREMENT,
+                    video_id INTEGER NOT NULL,
+                    stage TEXT NOT NULL DEFAULT '',
+                    status TEXT NOT NULL DEFAULT 'queued',
+                    attempts INTEGER NOT NULL DEFAULT 0,
+                    max_attempts INTEGER NOT NULL,
+                    last_error TEXT NOT NULL DEFAULT '',
+                    run_at DATETIME NOT NULL,
+                    locked_at DATETIME,
+                    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+                    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+                    FOREIGN KEY (video_id) REFERENCES videos(id) ON DELETE CASCADE
+                );
+                CREATE INDEX IF NOT EXISTS idx_jobs_status_run_at ON jobs (status, run_at);
+                CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_active_video ON jobs (video_id) WHERE status IN ('queued', 'running');`,
+		},
+		{
+			ID:   9,
+			Name: "create_video_status_history_table",
+			SQL: `
+                CREATE TABLE IF NOT EXISTS video_status_history (
+                    id INTEGER PRIMARY KEY AUTOINCREMENT,
+                    video_id INTEGER NOT NULL,
+                    from_status TEXT NOT NULL,
+                    to_status TEXT NOT NULL,
+                    actor_id INTEGER NOT NULL DEFAULT 0,
+                    reason TEXT NOT NULL DEFAULT '',
+                    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+                    FOREIGN KEY (video_id) REFERENCES videos(id) ON DELETE CASCADE
+                );
+                CREATE INDEX IF NOT EXISTS idx_video_status_history_video_id ON video_status_history (video_id);`,
+		},
+		{
+			ID:   10,
+			Name: "create_translations_table",
+			SQL: `
+                CREATE TABLE IF NOT EXISTS translations (
+                    id INTEGER PRIMARY KEY AUTOINCREMENT,
+                    video_id INTEGER NOT NULL,
+                    user_id INTEGER NOT NULL,
+                    source_lang TEXT NOT NULL,
+                    target_lang TEXT NOT NULL,
+                    transcription_id INTEGER,
+                    audio_id INTEGER,
+                    output_folder TEXT NOT NULL DEFAULT '',
+                    output_file_name TEXT NOT NULL DEFAULT '',
+                    status TEXT NOT NULL DEFAULT 'pending',
+                    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+                    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+                    FOREIGN KEY (video_id) REFERENCES videos(id) ON DELETE CASCADE,
+                    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+                    FOREIGN KEY (transcription_id) REFERENCES transcriptions(id) ON DELETE SET NULL,
+                    FOREIGN KEY (audio_id) REFERENCES audios(id) ON DELETE SET NULL
+                );
+                CREATE INDEX IF NOT EXISTS idx_translations_video_id ON translations (video_id);
+                CREATE INDEX IF NOT EXISTS idx_translations_user_id ON translations (user_id);`,
+		},
+		{
+			ID:   11,
+			Name: "add_list_pagination_indexes",
+			SQL: `
+                CREATE INDEX IF NOT EXISTS idx_videos_user_created ON videos (user_id, created_at, id);
+                CREATE INDEX IF NOT EXISTS idx_videos_user_title ON videos (user_id, title, id);
+                CREATE INDEX IF NOT EXISTS idx_videos_user_duration ON videos (user_id, duration, id);
+                CREATE INDEX IF NOT EXISTS idx_audios_user_created ON audios (user_id, created_at, id);
+                CREATE INDEX IF NOT EXISTS idx_audios_video_created ON audios (video_id, created_at, id);
+                CREATE INDEX IF NOT EXISTS idx_transcriptions_user_created ON transcriptions (user_id, created_at, id);
+                CREATE INDEX IF NOT EXISTS idx_transcriptions_video_created ON transcriptions (video_id, created_at, id);
+                CREATE INDEX IF NOT EXISTS idx_translations_user_created ON translations (user_id, created_at, id);
+                CREATE INDEX IF NOT EXISTS idx_translations_video_created ON translations (video_id, created_at, id);`,
+		},
+		{
+			// Requires SQLite with FTS5, i.e. building with -tags sqlite_fts5
+			ID:   12,
+			Name: "create_search_index",
+			SQL: `
+                CREATE VIRTUAL TABLE IF NOT EXISTS videos_fts USING fts5(
+                    title, description,
+                    content='videos', content_rowid='id', tokenize='unicode61 remove_diacritics 2'
+                );
+                CREATE VIRTUAL TABLE IF NOT EXISTS transcriptions_fts USING fts5(
+                    text,
+                    content='transcriptions', content_rowid='id', tokenize='unicode61 remove_diacritics 2'
+                );
+
+                CREATE TRIGGER IF NOT EXISTS videos_fts_insert AFTER INSERT ON videos BEGIN
+                    INSERT INTO videos_fts (rowid, title, description) VALUES (new.id, new.title, new.description);
+                END;
+                CREATE TRIGGER IF NOT EXISTS videos_fts_delete AFTER DELETE ON videos BEGIN
+                    INSERT INTO videos_fts (videos_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+                END;
+                CREATE TRIGGER IF NOT EXISTS videos_fts_update AFTER UPDATE OF title, description ON videos BEGIN
+                    INSERT INTO videos_fts (videos_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+                    INSERT INTO videos_fts (rowid, title, description) VALUES (new.id, new.title, new.description);
+                END;
+
+                CREATE TRIGGER IF NOT EXISTS transcriptions_fts_insert AFTER INSERT ON transcriptions BEGIN
+                    INSERT INTO transcriptions_fts (rowid, text) VALUES (new.id, new.text);
+                END;
+                CREATE TRIGGER IF NOT EXISTS transcriptions_fts_delete AFTER DELETE ON transcriptions BEGIN
+                    INSERT INTO transcriptions_fts (transcriptions_fts, rowid, text) VALUES ('delete', old.id, old.text);
+                END;
+                CREATE TRIGGER IF NOT EXISTS transcriptions_fts_update AFTER UPDATE OF text ON transcriptions BEGIN
+                    INSERT INTO transcriptions_fts (transcriptions_fts, rowid, text) VALUES ('delete', old.id, old.text);
+                    INSERT INTO transcriptions_fts (rowid, text) VALUES (new.id, new.text);
+                END;
+
+                INSERT INTO videos_fts (videos_fts) VALUES ('rebuild');
+                INSERT INTO transcriptions_fts (transcriptions_fts) VALUES ('rebuild');`,
+		},
+		{
+			ID:   13,
+			Name: "create_transcription_segments_table",
+			SQL: `
+                CREATE TABLE IF NOT EXISTS transcription_segments (
+                    id INTEGER PRIMARY KEY AUTOINCREMENT,
+                    transcription_id INTEGER NOT NULL,
+                    position INTEGER NOT NULL,
+                    start_ms INTEGER NOT NULL,
+                    end_ms INTEGER NOT NULL,
+                    speaker TEXT NOT NULL DEFAULT '',
+                    text TEXT NOT NULL,
+                    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+                    FOREIGN KEY (transcription_id) REFERENCES transcriptions(id) ON DELETE CASCADE
+                );
+                CREATE UNIQUE INDEX IF NOT EXISTS idx_transcription_segments_position ON transcription_segments (transcription_id, position);`,
+		},
+		{
+			ID:   14,
+			Name: "create_video_uploads_table",
+			SQL: `
+                CREATE TABLE IF NOT EXISTS video_uploads (
+                    id INTEGER PRIMARY KEY AUTOINCREMENT,
+                    user_id INTEGER NOT NULL,
+                    title TEXT NOT NULL,
+                    description TEXT NOT NULL DEFAULT '',
+                    duration INTEGER NOT NULL DEFAULT 0,
+                    image TEXT NOT NULL DEFAULT '',
+                    folder TEXT NOT NULL,
+                    file_name TEXT NOT NULL,
+                    content_type TEXT NOT NULL,
+                    size INTEGER NOT NULL,
+                    checksum_sha256 TEXT NOT NULL DEFAULT '',
+                    status TEXT NOT NULL DEFAULT 'pending',
+                    video_id INTEGER,
+                    expires_at DATETIME NOT NULL,
+                    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+                    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+                    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+                    FOREIGN KEY (video_id) REFERENCES videos(id) ON DELETE SET NULL
+                );
+                CREATE INDEX IF NOT EXISTS idx_video_uploads_status_expires_at ON video_uploads (status, expires_at);`,
+		},
+		{
+			ID:   15,
+			Name: "add_video_uploads_multipart_columns",
+			SQL: `
+                ALTER TABLE video_uploads ADD COLUMN multipart_upload_id TEXT NOT NULL DEFAULT '';
+                ALTER TABLE video_uploads ADD COLUMN part_size INTEGER NOT NULL DEFAULT 0;`,
+		},
+		{
+			ID:   16,
+			Name: "create_storage_deletions_table",
+			SQL: `
+                CREATE TABLE IF NOT EXISTS storage_deletions (
+                    id INTEGER PRIMARY KEY AUTOINCREMENT,
+                    folder TEXT NOT NULL,
+                    file_name TEXT NOT NULL,
+                    reason TEXT NOT NULL DEFAULT '',
+                    status TEXT NOT NULL DEFAULT 'pending',
+                    attempts INTEGER NOT NULL DEFAULT 0,
+                    last_error TEXT NOT NULL DEFAULT '',
+                    next_attempt_at DATETIME NOT NULL,
+                    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+                    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+                );
+                CREATE INDEX IF NOT EXISTS idx_storage_deletions_status_next_attempt_at ON storage_deletions (status, next_attempt_at);`,
+		},
+		{
+			ID:   17,
+			Name: "add_frames_keyframe_columns",
+			SQL: `
+                ALTER TABLE frames ADD COLUMN folder TEXT NOT NULL DEFAULT '';
+                ALTER TABLE frames ADD COLUMN file_name TEXT NOT NULL DEFAULT '';
+                ALTER TABLE frames ADD COLUMN timestamp_ms INTEGER NOT NULL DEFAULT 0;
+                ALTER TABLE frames ADD COLUMN width INTEGER NOT NULL DEFAULT 0;
+                ALTER TABLE frames ADD COLUMN height INTEGER NOT NULL DEFAULT 0;
+                ALTER TABLE frames ADD COLUMN is_primary BOOLEAN NOT NULL DEFAULT FALSE;
+                UPDATE frames SET file_name = link WHERE link NOT LIKE '%://%';
+                CREATE INDEX IF NOT EXISTS idx_frames_video_id_timestamp ON frames (video_id, timestamp_ms);
+                CREATE UNIQUE INDEX IF NOT EXISTS idx_frames_primary ON frames (video_id) WHERE is_primary;`,
+		},
+		{
+			ID:   18,
+			Name: "add_media_info_columns",
+			SQL: `
+                ALTER TABLE videos ADD COLUMN video_codec TEXT NOT NULL DEFAULT '';
+                ALTER TABLE videos ADD COLUMN audio_codec TEXT NOT NULL DEFAULT '';
+                ALTER TABLE videos ADD COLUMN width INTEGER NOT NULL DEFAULT 0;
+                ALTER TABLE videos ADD COLUMN height INTEGER NOT NULL DEFAULT 0;
+                ALTER TABLE videos ADD COLUMN bitrate INTEGER NOT NULL DEFAULT 0;
+                ALTER TABLE videos ADD COLUMN audio_channels INTEGER NOT NULL DEFAULT 0;
+                ALTER TABLE videos ADD COLUMN sample_rate INTEGER NOT NULL DEFAULT 0;
+                ALTER TABLE videos ADD COLUMN probed_at DATETIME;
+                ALTER TABLE audios ADD COLUMN video_codec TEXT NOT NULL DEFAULT '';
+                ALTER TABLE audios ADD COLUMN audio_codec TEXT NOT NULL DEFAULT '';
+                ALTER TABLE audios ADD COLUMN width INTEGER NOT NULL DEFAULT 0;
+                ALTER TABLE audios ADD COLUMN height INTEGER NOT NULL DEFAULT 0;
+                ALTER TABLE audios ADD COLUMN bitrate INTEGER NOT NULL DEFAULT 0;
+                ALTER TABLE audios ADD COLUMN audio_channels INTEGER NOT NULL DEFAULT 0;
+                ALTER TABLE audios ADD COLUMN sample_rate INTEGER NOT NULL DEFAULT 0;
+                ALTER TABLE audios ADD COLUMN probed_at DATETIME;`,
+		},
+		{
+			ID:   19,
+			Name: "create_refresh_tokens_table",
+			SQL: `
+                ALTER TABLE users ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0;
+                CREATE TABLE IF NOT EXISTS refresh_tokens (
+                    id INTEGER PRIMARY KEY AUTOINCREMENT,
+                    user_id INTEGER NOT NULL,
+                    token_hash TEXT NOT NULL UNIQUE,
+                    family_id TEXT NOT NULL,
+                    expires_at DATETIME NOT NULL,
+                    revoked_at DATETIME,
+                    replaced_by_id INTEGER,
+                    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+                    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+                );
+                CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+                CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);`,
+		},
+		{
+			ID:   20,
+			Name: "create_user_tokens_table",
+			SQL: `
+                ALTER TABLE users ADD COLUMN email_verified_at DATETIME;
+                UPDATE users SET email_verified_at = COALESCE(created_at, CURRENT_TIMESTAMP);
+                CREATE TABLE IF NOT EXISTS user_tokens (
+                    id INTEGER PRIMARY KEY AUTOINCREMENT,
+                    user_id INTEGER NOT NULL,
+                    purpose TEXT NOT NULL,
+                    token_hash TEXT NOT NULL UNIQUE,
+                    expires_at DATETIME NOT NULL,
+                    used_at DATETIME,
+                    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+                    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+                );
+                CREATE INDEX IF NOT EXISTS idx_user_tokens_user_id_purpose ON user_tokens (user_id, purpose);`,
+		},
+		{
+			ID:   21,
+			Name: "create_login_throttles_table",
+			SQL: `
+                CREATE TABLE IF NOT EXISTS login_throttles (
+                    scope TEXT NOT NULL,
+                    subject TEXT NOT NULL,
+                    user_id INTEGER,
+                    failures INTEGER NOT NULL DEFAULT 0,
+                    last_failure_at DATETIME NOT NULL,
+                    locked_until DATETIME,
+                    PRIMARY KEY (scope, subject),
+                    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+                );
+                CREATE INDEX IF NOT EXISTS idx_login_throttles_locked_until ON login_throttles (scope, locked_until);`,
+		},
+		{
+			ID:   22,
+			Name: "add_two_factor_authentication",
+			SQL: `
+                ALTER TABLE users ADD COLUMN totp_secret TEXT;
+                ALTER TABLE users ADD COLUMN totp_enabled_at DATETIME;
+                ALTER TABLE users ADD COLUMN totp_last_step INTEGER NOT NULL DEFAULT 0;
+                CREATE TABLE IF NOT EXISTS recovery_codes (
+                    id INTEGER PRIMARY KEY AUTOINCREMENT,
+                    user_id INTEGER NOT NULL,
+                    code_hash TEXT NOT NULL,
+                    used_at DATETIME,
+                    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+                    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+                );
+                CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id, code_hash);`,
+		},
+		{
+			ID:   23,
+			Name: "create_oidc_tables",
+			SQL: `
+                CREATE TABLE IF NOT EXISTS oidc_login_states (
+                    state_hash TEXT PRIMARY KEY,
+                    provider TEXT NOT NULL,
+                    code_verifier TEXT NOT NULL,
+                    nonce TEXT NOT NULL,
+                    expires_at DATETIME NOT NULL,
+                    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+                );
+                CREATE TABLE IF NOT EXISTS user_identities (
+                    id INTEGER PRIMARY KEY AUTOINCREMENT,
+                    user_id INTEGER NOT NULL,
+                    provider TEXT NOT NULL,
+                    subject TEXT NOT NULL,
+                    email TEXT NOT NULL,
+                    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+                    last_login_at DATETIME,
+                    UNIQUE (provider, subject),
+                    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+                );
+                CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities (user_id);`,
+		},
+		{
+			ID:   24,
+			Name: "create_api_keys_table",
+			SQL: `
+                CREATE TABLE IF NOT EXISTS api_keys (
+                    id INTEGER PRIMARY KEY AUTOINCREMENT,
+                    user_id INTEGER NOT NULL,
+                    name TEXT NOT NULL,
+                    prefix TEXT NOT NULL,
+                    key_hash TEXT NOT NULL UNIQUE,
+                    scopes TEXT NOT NULL,
+                    expires_at DATETIME NOT NULL,
+                    last_used_at DATETIME,
+                    last_used_ip TEXT NOT NULL DEFAULT '',
+                    revoked_at DATETIME,
+                    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+                    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+                );
+                CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);`,
+		},
+		{
+			ID:   25,
+			Name: "create_momo_payments_table",
+			SQL: `
+                CREATE TABLE IF NOT EXISTS momo_payments (
+                    id INTEGER PRIMARY KEY AUTOINCREMENT,
+                    user_id INTEGER NOT NULL,
+                    order_id TEXT NOT NULL UNIQUE,
+                    request_id TEXT NOT NULL,
+                    amount INTEGER NOT NULL,
+                    order_info TEXT NOT NULL DEFAULT '',
+                    status TEXT NOT NULL DEFAULT 'pending',
+                    pay_url TEXT NOT NULL DEFAULT '',
+                    trans_id INTEGER NOT NULL DEFAULT 0,
+                    result_code INTEGER,
+                    message TEXT NOT NULL DEFAULT '',
+                    refunded_amount INTEGER NOT NULL DEFAULT 0,
+                    paid_at DATETIME,
+                    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+                    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+                    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+                );
+                CREATE INDEX IF NOT EXISTS idx_momo_payments_user_id ON momo_payments (user_id);`,
+		},
+		{
+			ID:   26,
+			Name: "create_orders_and_ledger_entries_tables",
+			SQL: `
+                CREATE TABLE IF NOT EXISTS orders (
+                    id INTEGER PRIMARY KEY AUTOINCREMENT,
+                    order_id TEXT NOT NULL UNIQUE,
+                    user_id INTEGER NOT NULL,
+                    product_id TEXT NOT NULL,
+                    amount INTEGER NOT NULL,
+                    credits INTEGER NOT NULL DEFAULT 0,
+                    premium_days INTEGER NOT NULL DEFAULT 0,
+                    status TEXT NOT NULL DEFAULT 'pending',
+                    paid_at DATETIME,
+                    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+                    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+                    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+                );
+                CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders (user_id);
+                CREATE TABLE IF NOT EXISTS ledger_entries (
+                    id INTEGER PRIMARY KEY AUTOINCREMENT,
+                    transaction_id TEXT NOT NULL,
+                    account TEXT NOT NULL,
+                    user_id INTEGER NOT NULL,
+                    amount INTEGER NOT NULL,
+                    kind TEXT NOT NULL,
+                    order_id TEXT,
+                    translation_id INTEGER,
+                    description TEXT NOT NULL DEFAULT '',
+                    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+                    UNIQUE (transaction_id, account)
+                );
+                CREATE INDEX IF NOT EXISTS idx_ledger_entries_user_id ON ledger_entries (user_id, account);
+                ALTER TABLE users ADD COLUMN premium_until DATETIME;`,
+		},
+		{
+			ID:   27,
+			Name: "make_transaction_logs_append_only",
+			SQL: `
+                CREATE TABLE transaction_logs_new (
+                    id INTEGER PRIMARY KEY AUTOINCREMENT,
+                    order_id TEXT NOT NULL,
+                    payment_method TEXT NOT NULL,
+                    action TEXT NOT NULL,
+                    status TEXT NOT NULL,
+                    details TEXT,
+                    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+                );
+                INSERT INTO transaction_logs_new (id, order_id, payment_method, action, status, details, created_at)
+                    SELECT id, order_id, payment_method, action, status, details, created_at FROM transaction_logs;
+                DROP TABLE transaction_logs;
+                ALTER TABLE transaction_logs_new RENAME TO transaction_logs;
+                CREATE INDEX IF NOT EXISTS idx_transaction_logs_order_id ON transaction_logs (order_id, id);
+                CREATE TRIGGER IF NOT EXISTS transaction_logs_no_update BEFORE UPDATE ON transaction_logs
+                BEGIN
+                    SELECT RAISE(ABORT, 'transaction logs are append-only');
+                END;
+                CREATE TRIGGER IF NOT EXISTS transaction_logs_no_delete BEFORE DELETE ON transaction_logs
+                BEGIN
+                    SELECT RAISE(ABORT, 'transaction logs are append-only');
+                END;`,
+		},
+		{
+			ID:   28,
+			Name: "index_users_email_ignoring_case",
+			SQL: `
+                CREATE INDEX IF NOT EXISTS idx_users_email_nocase ON users (email COLLATE NOCASE);`,
+		},
+		{
+			ID:   29,
+			Name: "unique_users_email_ignoring_case",
+			// Only the oldest of several accounts sharing an address could sign in; the others keep
+			// their data under an address marked #duplicate-<id> until an admin merges or renames them
+			SQL: `
+                DROP INDEX IF EXISTS idx_users_email_nocase;
+                UPDATE users SET email = LOWER(TRIM(email)) || '#duplicate-' || id
+                WHERE EXISTS (
+                    SELECT 1 FROM users AS older
+                    WHERE LOWER(TRIM(older.email)) = LOWER(TRIM(users.email)) AND older.id < users.id
+                );
+                UPDATE users SET email = LOWER(TRIM(email)) WHERE email != LOWER(TRIM(email));
+                CREATE UNIQUE INDEX idx_users_email_nocase ON users (email COLLATE NOCASE);`,
+		},
+		{
+			ID:   30,
+			Name: "store_list_created_at_in_utc",
+			// Lists compare created_at as text, so every row must be written in UTC and in the form the
+			// driver writes times in; older rows were written in the server's local time
+			SQL: `
+                UPDATE videos SET created_at = rtrim(rtrim(strftime('%Y-%m-%d %H:%M:%f', created_at), '0'), '.') || '+00:00' WHERE created_at NOT LIKE '%+00:00';
+                UPDATE audios SET created_at = rtrim(rtrim(strftime('%Y-%m-%d %H:%M:%f', created_at), '0'), '.') || '+00:00' WHERE created_at NOT LIKE '%+00:00';
+                UPDATE transcriptions SET created_at = rtrim(rtrim(strftime('%Y-%m-%d %H:%M:%f', created_at), '0'), '.') || '+00:00' WHERE created_at NOT LIKE '%+00:00';
+                UPDATE translations SET created_at = rtrim(rtrim(strftime('%Y-%m-%d %H:%M:%f', created_at), '0'), '.') || '+00:00' WHERE created_at NOT LIKE '%+00:00';
+                UPDATE orders SET created_at = rtrim(rtrim(strftime('%Y-%m-%d %H:%M:%f', created_at), '0'), '.') || '+00:00' WHERE created_at NOT LIKE '%+00:00';
+                UPDATE ledger_entries SET created_at = rtrim(rtrim(strftime('%Y-%m-%d %H:%M:%f', created_at), '0'), '.') || '+00:00' WHERE created_at NOT LIKE '%+00:00';`,
+		},
 	}
 
 	// Apply pending migrations
@@ -174,10 +642,10 @@ func insertSampleData(db *sql.DB) error {
 
 		// Insert user with hashed password
 		query := `
-            INSERT INTO users (first_name, last_name, username, email, password, status, premium, role, avatar, avatar_folder, created_at, updated_at)
-            VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+            INSERT INTO users (first_name, last_name, username, email, password, status, premium, role, avatar, avatar_folder, created_at, updated_at, email_verified_at)
+            VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
             ON CONFLICT(email) DO NOTHING;` // Prevent duplicate email entries
-		_, err = db.Exec(query, user.FirstName, user.LastName, user.UserName, user.Email, string(hashedPassword), user.Status, user.Premium, user.Role, user.Avatar, user.AvatarFolder, time.Now(), time.Now())
+		_, err = db.Exec(query, user.FirstName, user.LastName, user.UserName, user.Email, string(hashedPassword), user.Status, user.Premium, user.Role, user.Avatar, user.AvatarFolder, time.Now(), time.Now(), time.Now())
 		if err != nil {
 			return fmt.Errorf("failed to insert user '%s': %v", user.Email, err)
 		}
@@ -209,7 +677,7 @@ func insertSampleData(db *sql.DB) error {
 		query := `
             INSERT INTO videos (user_id, title, duration, description, file_name, folder, image, status, created_at, updated_at)
             VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`
-		_, err := db.Exec(query, userID, title, duration, description, fileName, folder, image, status, time.Now(), time.Now())
+		_, err := db.Exec(query, userID, title, duration, description, fileName, folder, image, status, time.Now().UTC(), time.Now().UTC())
 		if err != nil {
 			return fmt.Errorf("failed to insert video '%s': %v", title, err)
 		}
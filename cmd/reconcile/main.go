@@ -0,0 +1,102 @@
+// Command reconcile compares object storage with the database. It reports objects that no row
+// references (orphans) and rows whose object is missing (dangling), and can queue the orphans
+// for deletion by the server's storage janitor.
+//
+//	go run ./cmd/reconcile [-min-age 24h] [-delete-orphans] [-json]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/db"
+	"mlvt/internal/infra/env"
+	"mlvt/internal/infra/storage"
+	"mlvt/internal/infra/storage/driver"
+	"mlvt/internal/infra/zap-logging/log"
+	"mlvt/internal/infra/zap-logging/zap"
+	"mlvt/internal/repo"
+	"mlvt/internal/service"
+	"os"
+	"time"
+)
+
+var (
+	// minAge keeps objects uploaded recently, whose row may not be written yet, out of the orphans
+	minAge time.Duration
+	// deleteOrphans queues the orphans for deletion
+	deleteOrphans bool
+	// jsonOutput prints the report as JSON
+	jsonOutput bool
+)
+
+func init() {
+	flag.DurationVar(&minAge, "min-age", 24*time.Hour, "only report objects last modified longer ago than this")
+	flag.BoolVar(&deleteOrphans, "delete-orphans", false, "queue orphaned objects for deletion")
+	flag.BoolVar(&jsonOutput, "json", false, "print the report as JSON")
+}
+
+func main() {
+	flag.Parse()
+
+	if env.EnvConfig == nil {
+		fmt.Println("EnvConfig not loaded")
+		os.Exit(1)
+	}
+	log.SetLogger(zap.NewLogger(
+		log.ParseLevel(env.EnvConfig.LogLevel), zap.WithName("mlvt-reconcile"), zap.WithPath(env.EnvConfig.LogPath)))
+
+	dbConn, err := db.InitializeDB()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize the database: %v\n", err)
+		os.Exit(1)
+	}
+	defer dbConn.Close()
+
+	store, err := driver.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize object storage: %v\n", err)
+		os.Exit(1)
+	}
+
+	cleanupService := service.NewStorageCleanupService(repo.NewStorageObjectRepository(dbConn), store, service.StorageCleanupSettings)
+	report, err := cleanupService.Reconcile(time.Now().Add(-minAge), deleteOrphans)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Reconciliation failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to print the report: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	printReport(report)
+}
+
+func printReport(report *entity.ReconcileReport) {
+	fmt.Printf("Scanned %d objects\n", report.ScannedObjects)
+
+	fmt.Printf("\nOrphaned objects (%d):\n", len(report.Orphans))
+	for _, object := range report.Orphans {
+		key, _ := storage.ObjectKey(object.Folder, object.FileName)
+		fmt.Printf("  %s\n", key)
+	}
+
+	fmt.Printf("\nDangling rows (%d):\n", len(report.Dangling))
+	for _, reference := range report.Dangling {
+		key, _ := storage.ObjectKey(reference.Folder, reference.FileName)
+		fmt.Printf("  %s %d -> %s\n", reference.Table, reference.RowID, key)
+	}
+
+	if report.Enqueued > 0 {
+		fmt.Printf("\nQueued %d orphaned objects for deletion\n", report.Enqueued)
+	} else if len(report.Orphans) > 0 {
+		fmt.Println("\nRun with -delete-orphans to queue the orphaned objects for deletion")
+	}
+}
@@ -1,14 +1,21 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"mlvt/cmd/migration"
-	"mlvt/internal/infra/aws"
 	"mlvt/internal/infra/db"
 	"mlvt/internal/infra/env"
+	"mlvt/internal/infra/jwtkeys"
+	"mlvt/internal/infra/mail"
+	"mlvt/internal/infra/media"
+	"mlvt/internal/infra/momo"
+	"mlvt/internal/infra/oidc"
 	"mlvt/internal/infra/reason"
 	"mlvt/internal/infra/server/http"
+	"mlvt/internal/infra/storage/driver"
+	"mlvt/internal/infra/storage/local"
 	"mlvt/internal/infra/zap-logging/log"
 	"mlvt/internal/infra/zap-logging/zap"
 	"os"
@@ -42,7 +49,8 @@ func main() {
 	flag.Parse()
 
 	if env.EnvConfig == nil {
-		fmt.Errorf("EnvConfig not loaded")
+		fmt.Println("EnvConfig not loaded")
+		os.Exit(1)
 	}
 
 	// Read environment variables
@@ -74,19 +82,111 @@ func main() {
 
 	log.Info(reason.MigrationsApplied.Message())
 
-	// Initialize AWS S3 client
-	s3Client, err := aws.NewS3Client()
+	// Initialize the object storage (S3, S3-compatible or local disk)
+	store, err := driver.New()
 	if err != nil {
-		log.Errorf("Failed to initialize AWS S3 client: %v", err)
+		log.Errorf("Failed to initialize object storage: %v", err)
 		os.Exit(1)
 	}
 
-	appRouter, err := InitializeApp(dbConn, s3Client)
+	// Measure uploaded files with ffprobe; without it the durations supplied by clients are kept
+	var prober media.Prober
+	if ffprobe := media.NewFFprobe(env.EnvConfig.FFprobePath); ffprobe.Available() {
+		prober = ffprobe
+	} else {
+		log.Warnf("ffprobe not found; media probing is disabled")
+	}
+
+	// Send account emails (verification, password reset) through SMTP, or write them to files or the console
+	mailer, err := mail.New(mail.Config{
+		Driver:   env.EnvConfig.MailDriver,
+		From:     env.EnvConfig.MailFrom,
+		FileDir:  env.EnvConfig.MailFileDir,
+		SMTPHost: env.EnvConfig.SMTPHost,
+		SMTPPort: env.EnvConfig.SMTPPort,
+		Username: env.EnvConfig.SMTPUsername,
+		Password: env.EnvConfig.SMTPPassword,
+	})
+	if err != nil {
+		log.Errorf("Failed to initialize the mailer: %v", err)
+		os.Exit(1)
+	}
+
+	// Identity providers users can sign in with besides email and password
+	oidcConfigs := make([]oidc.Config, 0, len(env.EnvConfig.OIDCProviders))
+	for _, provider := range env.EnvConfig.OIDCProviders {
+		oidcConfigs = append(oidcConfigs, oidc.Config{
+			Name:         provider.Name,
+			Kind:         provider.Kind,
+			Issuer:       provider.Issuer,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			RedirectURL:  provider.RedirectURL,
+			Scopes:       provider.Scopes,
+		})
+	}
+	providers, err := oidc.New(oidcConfigs, nil)
+	if err != nil {
+		log.Errorf("Failed to initialize the identity providers: %v", err)
+		os.Exit(1)
+	}
+
+	// Sign access tokens with the rotating keys in JWT_KEY_DIR; without them JWT_SECRET signs
+	var keys *jwtkeys.KeySet
+	if env.EnvConfig.JWTKeyDir != "" {
+		keys, err = jwtkeys.Load(jwtkeys.Config{
+			Dir:              env.EnvConfig.JWTKeyDir,
+			Algorithm:        env.EnvConfig.JWTSigningAlgorithm,
+			RotationInterval: env.EnvConfig.JWTKeyRotationInterval,
+			Retention:        env.EnvConfig.AccessTokenTTL, // Replaced keys verify until the tokens they signed expire
+		})
+		if err != nil {
+			log.Errorf("Failed to load the JWT signing keys: %v", err)
+			os.Exit(1)
+		}
+		log.Infof("Signing access tokens with key %s", keys.SigningKey().ID)
+	} else {
+		log.Warn("JWT_KEY_DIR is not set; access tokens are signed with JWT_SECRET and cannot be verified by other services")
+	}
+
+	// Take payments through MoMo when its credentials are set
+	var momoClient *momo.Client
+	if env.EnvConfig.MoMoPartnerCode != "" {
+		momoClient, err = momo.New(momo.Config{
+			Endpoint:    env.EnvConfig.MoMoEndpoint,
+			PartnerCode: env.EnvConfig.MoMoPartnerCode,
+			AccessKey:   env.EnvConfig.MoMoAccessKey,
+			SecretKey:   env.EnvConfig.MoMoSecretKey,
+			RedirectURL: env.EnvConfig.MoMoRedirectURL,
+			IPNURL:      env.EnvConfig.MoMoIPNURL,
+			RequestType: env.EnvConfig.MoMoRequestType,
+			Lang:        env.EnvConfig.MoMoLang,
+		}, nil)
+		if err != nil {
+			log.Errorf("Failed to initialize the MoMo client: %v", err)
+			os.Exit(1)
+		}
+	} else {
+		log.Warn("MOMO_PARTNER_CODE is not set; MoMo payments are disabled")
+	}
+
+	appRouter, err := InitializeApp(dbConn, store, prober, mailer, providers, keys, momoClient)
 	if err != nil {
 		log.Errorf("Failed to initialize app: %v", err)
 		os.Exit(1)
 	}
 
+	// Start the video processing pool, the janitors of uploads and storage objects and the key rotator
+	workers, err := InitializeWorkers(dbConn, store, prober, keys)
+	if err != nil {
+		log.Errorf("Failed to initialize the workers: %v", err)
+		os.Exit(1)
+	}
+	if workers.Pool == nil {
+		log.Warn("WORKER_ENABLED is not set; uploaded videos are not processed")
+	}
+	workers.Start(context.Background())
+
 	// Create a new Gin router
 	r := gin.Default()
 	r.Use(cors.New(cors.Config{
@@ -102,9 +202,22 @@ func main() {
 	appRouter.RegisterVideoRoutes(api)
 	appRouter.RegisterAudioRoutes(api)
 	appRouter.RegisterTranscriptionRoutes(api)
+	appRouter.RegisterTranslationRoutes(api)
+	appRouter.RegisterSearchRoutes(api)
 	appRouter.RegisterPaymentRoutes(api)
+	appRouter.RegisterBillingRoutes(api)
+	appRouter.RegisterAdminRoutes(api)
+	appRouter.RegisterWellKnownRoutes(r.Group("/"))
 	appRouter.RegisterSwaggerRoutes(r.Group("/"))
 
+	// The local storage driver serves its presigned URLs itself
+	if localStore, ok := store.(*local.Storage); ok {
+		route := localStore.MountPath() + "/*key"
+		r.GET(route, gin.WrapH(localStore))
+		r.HEAD(route, gin.WrapH(localStore))
+		r.PUT(route, gin.WrapH(localStore))
+	}
+
 	// Create the http server
 	addr := ":" + env.EnvConfig.ServerPort
 	server := http.NewServer(r, addr)
@@ -118,6 +231,7 @@ func main() {
 		if err := server.Shutdown(); err != nil {
 			log.Warnf("Server forced to shutdown: %v", err)
 		}
+		workers.Stop()
 		log.Info("Server exiting")
 	}()
 
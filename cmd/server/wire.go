@@ -7,16 +7,22 @@ package main
 import (
 	"database/sql"
 	handler "mlvt/internal/handler/rest/v1"
-	"mlvt/internal/infra/aws"
+	"mlvt/internal/infra/jwtkeys"
+	"mlvt/internal/infra/mail"
+	"mlvt/internal/infra/media"
+	"mlvt/internal/infra/momo"
+	"mlvt/internal/infra/oidc"
+	"mlvt/internal/infra/storage"
 	"mlvt/internal/pkg/middleware"
 	"mlvt/internal/repo"
 	"mlvt/internal/router"
 	"mlvt/internal/service"
+	"mlvt/internal/worker"
 
 	"github.com/google/wire"
 )
 
-func InitializeApp(db *sql.DB, s3Client *aws.S3Client) (*router.AppRouter, error) {
+func InitializeApp(db *sql.DB, store storage.Storage, prober media.Prober, mailer mail.Mailer, providers oidc.Providers, keys *jwtkeys.KeySet, momoClient *momo.Client) (*router.AppRouter, error) {
 	wire.Build(
 		repo.ProviderSetRepository,
 		service.ProviderSetService,
@@ -26,3 +32,12 @@ func InitializeApp(db *sql.DB, s3Client *aws.S3Client) (*router.AppRouter, error
 	)
 	return &router.AppRouter{}, nil
 }
+
+func InitializeWorkers(db *sql.DB, store storage.Storage, prober media.Prober, keys *jwtkeys.KeySet) (*worker.Workers, error) {
+	wire.Build(
+		repo.ProviderSetRepository,
+		service.ProviderSetService,
+		worker.ProviderSetWorker,
+	)
+	return &worker.Workers{}, nil
+}
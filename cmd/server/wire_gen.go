@@ -9,39 +9,125 @@ package main
 import (
 	"database/sql"
 	"mlvt/internal/handler/rest/v1"
-	"mlvt/internal/infra/aws"
+	"mlvt/internal/infra/jwtkeys"
+	"mlvt/internal/infra/mail"
+	"mlvt/internal/infra/media"
+	"mlvt/internal/infra/momo"
+	"mlvt/internal/infra/oidc"
+	"mlvt/internal/infra/storage"
 	"mlvt/internal/pkg/middleware"
 	"mlvt/internal/repo"
 	"mlvt/internal/router"
 	"mlvt/internal/service"
+	"mlvt/internal/worker"
 )
 
 // Injectors from wire.go:
 
-func InitializeApp(db *sql.DB, s3Client *aws.S3Client) (*router.AppRouter, error) {
+func InitializeApp(db *sql.DB, store storage.Storage, prober media.Prober, mailer mail.Mailer, providers oidc.Providers, keys *jwtkeys.KeySet, momoClient *momo.Client) (*router.AppRouter, error) {
 	userRepository := repo.NewUserRepo(db)
+	refreshTokenRepository := repo.NewRefreshTokenRepository(db)
 	string2 := _wireStringValue
-	authService := service.NewAuthService(userRepository, string2)
-	userService := service.NewUserService(userRepository, s3Client, authService)
-	userController := handler.NewUserController(userService)
+	authConfig := _wireAuthConfigValue
+	loginThrottleRepository := repo.NewLoginThrottleRepository(db)
+	twoFactorRepository := repo.NewTwoFactorRepository(db)
+	authService := service.NewAuthService(userRepository, refreshTokenRepository, loginThrottleRepository, twoFactorRepository, keys, string2, authConfig)
+	userService := service.NewUserService(userRepository, store, authService)
+	userTokenRepository := repo.NewUserTokenRepository(db)
+	accountConfig := _wireAccountConfigValue
+	accountService := service.NewAccountService(userRepository, userTokenRepository, authService, mailer, accountConfig)
+	userController := handler.NewUserController(userService, accountService)
+	twoFactorConfig := _wireTwoFactorConfigValue
+	twoFactorService := service.NewTwoFactorService(userRepository, twoFactorRepository, twoFactorConfig)
+	twoFactorController := handler.NewTwoFactorController(twoFactorService)
+	oidcRepository := repo.NewOIDCRepository(db)
+	oidcConfig := _wireOIDCConfigValue
+	oidcService := service.NewOIDCService(providers, userRepository, oidcRepository, authService, oidcConfig)
+	oidcController := handler.NewOIDCController(oidcService)
 	videoRepository := repo.NewVideoRepo(db)
-	videoService := service.NewVideoService(videoRepository, s3Client)
-	videoController := handler.NewVideoController(videoService)
+	frameRepository := repo.NewFrameRepository(db)
 	audioRepository := repo.NewAudioRepository(db)
-	audioService := service.NewAudioService(audioRepository, s3Client)
+	mediaProbeConfig := _wireMediaProbeConfigValue
+	mediaProbeService := service.NewMediaProbeService(videoRepository, audioRepository, store, prober, mediaProbeConfig)
+	videoService := service.NewVideoService(videoRepository, frameRepository, store)
+	videoController := handler.NewVideoController(videoService)
+	audioService := service.NewAudioService(audioRepository, store, mediaProbeService)
 	audioController := handler.NewAudioController(audioService)
 	transcriptionRepository := repo.NewTranscriptionRepository(db)
-	transcriptionService := service.NewTranscriptionService(transcriptionRepository, s3Client)
+	transcriptionSegmentRepository := repo.NewTranscriptionSegmentRepository(db)
+	transcriptionService := service.NewTranscriptionService(transcriptionRepository, transcriptionSegmentRepository, store)
 	transcriptionController := handler.NewTranscriptionController(transcriptionService)
-	authUserMiddleware := middleware.NewAuthUserMiddleware(authService)
-	moMoRepo := repo.NewMoMoRepo()
-	moMoPaymentService := service.NewMoMoPaymentService(moMoRepo)
+	translationRepository := repo.NewTranslationRepository(db)
+	videoUploadRepository := repo.NewVideoUploadRepository(db)
+	apiKeyRepository := repo.NewAPIKeyRepository(db)
+	apiKeyConfig := _wireAPIKeyConfigValue
+	apiKeyService := service.NewAPIKeyService(userRepository, apiKeyRepository, apiKeyConfig)
+	authUserMiddleware := middleware.NewAuthUserMiddleware(authService, apiKeyService)
+	ownershipMiddleware := middleware.NewOwnershipMiddleware(videoRepository, audioRepository, transcriptionRepository, translationRepository, videoUploadRepository)
+	moMoPaymentRepository := repo.NewMoMoPaymentRepository(db)
+	transactionLogRepo := repo.NewTransactionLogRepo(db)
+	billingRepository := repo.NewBillingRepository(db)
+	billingService := service.NewBillingService(billingRepository)
+	moMoPaymentService := service.NewMoMoPaymentService(momoClient, moMoPaymentRepository, transactionLogRepo, billingService)
 	moMoPaymentController := handler.NewMoMoPaymentHandler(moMoPaymentService)
+	billingController := handler.NewBillingController(billingService)
+	auditLogRepository := repo.NewAuditLogRepository(db)
+	adminService := service.NewAdminService(userRepository, auditLogRepository, authService)
+	adminController := handler.NewAdminController(adminService)
+	translationService := service.NewTranslationService(translationRepository, videoRepository, transcriptionRepository, audioRepository, store, billingService, mediaProbeService)
+	translationController := handler.NewTranslationController(translationService)
+	searchRepository := repo.NewSearchRepository(db)
+	searchService := service.NewSearchService(searchRepository)
+	searchController := handler.NewSearchController(searchService)
+	uploadConfig := _wireUploadConfigValue
+	uploadService := service.NewUploadService(videoUploadRepository, videoRepository, store, mediaProbeService, uploadConfig)
+	uploadController := handler.NewUploadController(uploadService)
+	frameService := service.NewFrameService(frameRepository, videoRepository, store)
+	frameController := handler.NewFrameController(frameService)
+	mediaController := handler.NewMediaController(mediaProbeService)
+	apiKeyController := handler.NewAPIKeyController(apiKeyService)
+	jwksController := handler.NewJWKSController(authService)
 	swaggerRouter := router.NewSwaggerRouter()
-	appRouter := router.NewAppRouter(userController, videoController, audioController, transcriptionController, authUserMiddleware, moMoPaymentController, swaggerRouter)
+	appRouter := router.NewAppRouter(userController, videoController, audioController, transcriptionController, authUserMiddleware, ownershipMiddleware, moMoPaymentController, billingController, adminController, translationController, searchController, uploadController, frameController, mediaController, twoFactorController, oidcController, apiKeyController, jwksController, swaggerRouter)
 	return appRouter, nil
 }
 
 var (
-	_wireStringValue = service.SecretKey
+	_wireStringValue           = service.SecretKey
+	_wireAuthConfigValue       = service.AuthSettings
+	_wireAccountConfigValue    = service.AccountSettings
+	_wireTwoFactorConfigValue  = service.TwoFactorSettings
+	_wireOIDCConfigValue       = service.OIDCSettings
+	_wireAPIKeyConfigValue     = service.APIKeySettings
+	_wireUploadConfigValue     = service.UploadSettings
+	_wireMediaProbeConfigValue = service.MediaProbeSettings
+)
+
+func InitializeWorkers(db *sql.DB, store storage.Storage, prober media.Prober, keys *jwtkeys.KeySet) (*worker.Workers, error) {
+	jobRepository := repo.NewJobRepository(db)
+	videoRepository := repo.NewVideoRepo(db)
+	frameRepository := repo.NewFrameRepository(db)
+	audioRepository := repo.NewAudioRepository(db)
+	mediaProbeConfig := _wireMediaProbeConfigValue
+	mediaProbeService := service.NewMediaProbeService(videoRepository, audioRepository, store, prober, mediaProbeConfig)
+	videoService := service.NewVideoService(videoRepository, frameRepository, store)
+	v := worker.DefaultStages()
+	config := _wireConfigValue
+	pool := worker.ProvidePool(jobRepository, videoRepository, videoService, v, config)
+	videoUploadRepository := repo.NewVideoUploadRepository(db)
+	uploadConfig := _wireUploadConfigValue
+	uploadService := service.NewUploadService(videoUploadRepository, videoRepository, store, mediaProbeService, uploadConfig)
+	uploadJanitor := worker.ProvideUploadJanitor(uploadService)
+	storageObjectRepository := repo.NewStorageObjectRepository(db)
+	storageCleanupConfig := _wireStorageCleanupConfigValue
+	storageCleanupService := service.NewStorageCleanupService(storageObjectRepository, store, storageCleanupConfig)
+	storageJanitor := worker.ProvideStorageJanitor(storageCleanupService)
+	keyRotator := worker.ProvideKeyRotator(keys)
+	workers := worker.NewWorkers(pool, uploadJanitor, storageJanitor, keyRotator)
+	return workers, nil
+}
+
+var (
+	_wireConfigValue               = worker.PoolSettings
+	_wireStorageCleanupConfigValue = service.StorageCleanupSettings
 )
@@ -1197,52 +1197,6 @@ const docTemplate = `{
                 }
             }
         },
-        "/videos": {
-            "post": {
-                "description": "Creates a new video record in the system",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Videos"
-                ],
-                "summary": "Add a new video",
-                "parameters": [
-                    {
-                        "description": "Video data",
-                        "name": "video",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/entity.Video"
-                        }
-                    }
-                ],
-                "responses": {
-                    "201": {
-                        "description": "Created",
-                        "schema": {
-                            "$ref": "#/definitions/response.MessageResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
-                        }
-                    }
-                }
-            }
-        },
         "/videos/generate-upload-url/image": {
             "post": {
                 "description": "Generates a presigned URL to upload an image (e.g., thumbnail) to S3",
@@ -1288,51 +1242,6 @@ const docTemplate = `{
                 }
             }
         },
-        "/videos/generate-upload-url/video": {
-            "post": {
-                "description": "Generates a presigned URL to upload a video file to S3",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Videos"
-                ],
-                "summary": "Generate presigned upload URL for a video",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Name of the video file",
-                        "name": "file_name",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Type of the video file (e.g., video/mp4)",
-                        "name": "file_type",
-                        "in": "query",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "upload_url",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
-                        }
-                    }
-                }
-            }
-        },
         "/videos/user/{user_id}": {
             "get": {
                 "description": "Fetches all videos for a specific user along with presigned image URLs",
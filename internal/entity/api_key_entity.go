@@ -0,0 +1,52 @@
+package entity
+
+import "time"
+
+// APIKeyPrefix starts every API key, which tells them apart from JWTs in the Authorization header
+const APIKeyPrefix = "mlvt_"
+
+// API key scopes
+const (
+	APIKeyScopeVideosRead          = "videos:read"          // Read the owner's videos
+	APIKeyScopeTranscriptionsWrite = "transcriptions:write" // Read, create and change the owner's transcriptions
+	APIKeyScopePayments            = "payments"             // Create and check payments
+)
+
+// APIKeyScopes lists the scopes an API key can be granted
+var APIKeyScopes = []string{APIKeyScopeVideosRead, APIKeyScopeTranscriptionsWrite, APIKeyScopePayments}
+
+// IsValidAPIKeyScope reports whether scope is one of the known API key scopes
+func IsValidAPIKeyScope(scope string) bool {
+	for _, known := range APIKeyScopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey lets a user's scripts call the API without signing in. Only the SHA-256 hash of the key is
+// stored; the key itself is shown once, when it is created.
+type APIKey struct {
+	ID         uint64     `json:"id"`
+	UserID     uint64     `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // Start of the key, to recognise it in lists
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// HasScope reports whether the key was granted scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, granted := range k.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
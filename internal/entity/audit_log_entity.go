@@ -0,0 +1,27 @@
+package entity
+
+import "time"
+
+// AuditAction constants describe the admin actions that are audited
+const (
+	AuditActionSuspendUser   = "suspend_user"
+	AuditActionReinstateUser = "reinstate_user"
+	AuditActionChangeRole    = "change_role"
+	AuditActionUnlockUser    = "unlock_user"
+)
+
+// AuditTarget constants describe the kinds of resources an audit record can refer to
+const (
+	AuditTargetUser = "user"
+)
+
+// AuditLog records an administrative action performed on a resource
+type AuditLog struct {
+	ID         uint64    `json:"id"`
+	ActorID    uint64    `json:"actor_id"`    // ID of the admin who performed the action
+	Action     string    `json:"action"`      // What was done (e.g., "suspend_user")
+	TargetType string    `json:"target_type"` // Kind of resource acted on (e.g., "user")
+	TargetID   uint64    `json:"target_id"`   // ID of the resource acted on
+	Details    string    `json:"details"`     // Free-form context such as the reason or old/new values
+	CreatedAt  time.Time `json:"created_at"`  // Timestamp of when the action was performed
+}
@@ -1,6 +1,17 @@
 package entity
 
+import "time"
+
+// Frame is a keyframe image of a video kept in storage. One frame per video may be the primary thumbnail.
 type Frame struct {
-	VideoID uint64 `json:"video_id"`
-	Link    string `json:"link"`
+	ID          uint64    `json:"id"`
+	VideoID     uint64    `json:"video_id"`
+	Folder      string    `json:"folder"`
+	FileName    string    `json:"file_name"`
+	TimestampMs int64     `json:"timestamp_ms"` // Position of the frame in the video
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	IsPrimary   bool      `json:"is_primary"` // Shown as the video's thumbnail
+	Link        string    `json:"link"`       // Presigned download URL, set when frames are returned to clients
+	CreatedAt   time.Time `json:"created_at"`
 }
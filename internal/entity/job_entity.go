@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// JobStatus is the lifecycle state of a processing job
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued"    // Waiting for a worker, either new or scheduled for a retry
+	JobStatusRunning   JobStatus = "running"   // Claimed by a worker
+	JobStatusSucceeded JobStatus = "succeeded" // Every stage completed
+	JobStatusFailed    JobStatus = "failed"    // Gave up after the last attempt
+)
+
+// Job tracks the processing of a single video through the pipeline stages
+type Job struct {
+	ID          uint64     `json:"id"`
+	VideoID     uint64     `json:"video_id"`
+	Stage       string     `json:"stage"`        // Stage currently (or last) being run; retries resume from here
+	Status      JobStatus  `json:"status"`       // Status of the job
+	Attempts    int        `json:"attempts"`     // Number of times the job has been claimed
+	MaxAttempts int        `json:"max_attempts"` // Attempts allowed before the job is marked failed
+	LastError   string     `json:"last_error"`   // Error from the most recent failed attempt
+	RunAt       time.Time  `json:"run_at"`       // Earliest time the job may be claimed
+	LockedAt    *time.Time `json:"locked_at"`    // When a worker claimed the job, nil while queued
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,34 @@
+package entity
+
+import "time"
+
+// Ledger accounts. Every user has a credits account; the others are shared and only balance it.
+const (
+	LedgerAccountCredits = "credits" // A user's translation minutes
+	LedgerAccountSales   = "sales"   // Minutes sold
+	LedgerAccountUsage   = "usage"   // Minutes spent on translations
+	LedgerAccountRefunds = "refunds" // Minutes taken back when a payment is refunded
+)
+
+// Kinds of ledger transactions
+const (
+	LedgerKindPurchase          = "purchase"           // Minutes bought with a paid order
+	LedgerKindTranslation       = "translation"        // Minutes charged for a translation
+	LedgerKindTranslationRefund = "translation_refund" // Minutes given back for a failed translation
+	LedgerKindPaymentRefund     = "payment_refund"     // Minutes taken back with a refunded payment
+)
+
+// LedgerEntry is one line of a double-entry transaction in translation minutes. The lines of a
+// transaction add up to zero: whatever one account gains, another loses.
+type LedgerEntry struct {
+	ID            uint64    `json:"id"`
+	TransactionID string    `json:"transaction_id"` // Shared by the lines of one transaction
+	Account       string    `json:"account"`
+	UserID        uint64    `json:"user_id"` // The user the transaction concerns
+	Amount        int64     `json:"amount"`  // Minutes; positive adds to the account, negative takes from it
+	Kind          string    `json:"kind"`
+	OrderID       string    `json:"order_id,omitempty"`
+	TranslationID *uint64   `json:"translation_id,omitempty"`
+	Description   string    `json:"description"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+// Sort fields accepted by list endpoints. Not every list supports every field.
+const (
+	SortByCreatedAt = "created_at"
+	SortByTitle     = "title"
+	SortByDuration  = "duration"
+)
+
+// Sort orders accepted by list endpoints
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// ListOptions controls paging, ordering and filtering of list queries.
+// The zero value returns the first page of the default size, newest first, unfiltered.
+type ListOptions struct {
+	Limit         int        // Page size; 0 means the default, values above the maximum are capped
+	Cursor        string     // Opaque cursor returned as next_cursor by the previous page
+	SortBy        string     // One of the SortBy constants; defaults to created_at
+	Order         string     // SortAsc or SortDesc; defaults to SortDesc
+	Status        string     // Only rows with this status
+	Lang          string     // Only rows in this language
+	CreatedAfter  *time.Time // Only rows created at or after this time
+	CreatedBefore *time.Time // Only rows created before this time
+}
@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+// LoginThrottle scopes: failed sign-ins are counted per account and per client IP
+const (
+	LoginThrottleAccount = "account"
+	LoginThrottleIP      = "ip"
+)
+
+// LoginThrottle counts recent failed sign-ins for an account or a client IP. While LockedUntil is in
+// the future every sign-in for it is refused, even with the right password.
+type LoginThrottle struct {
+	Scope         string     `json:"scope"`
+	Subject       string     `json:"subject"`           // Lower-cased email for accounts, the client IP otherwise
+	UserID        *uint64    `json:"user_id,omitempty"` // Account the email belongs to; nil for IPs and unknown emails
+	Failures      int        `json:"failures"`          // Failed attempts within the counting window
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+}
+
+// LockedAt reports whether sign-ins are refused at the given time
+func (t *LoginThrottle) LockedAt(now time.Time) bool {
+	return t.LockedUntil != nil && now.Before(*t.LockedUntil)
+}
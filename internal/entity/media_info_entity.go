@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+// MediaInfo holds the stream details read from a stored audio or video file by probing it.
+// All fields are zero until the file has been probed.
+type MediaInfo struct {
+	VideoCodec    string     `json:"video_codec,omitempty"` // e.g. "h264"; empty for audio files
+	AudioCodec    string     `json:"audio_codec,omitempty"` // e.g. "aac"
+	Width         int        `json:"width,omitempty"`       // Video width in pixels
+	Height        int        `json:"height,omitempty"`      // Video height in pixels
+	Bitrate       int64      `json:"bitrate,omitempty"`     // Overall bitrate in bits per second
+	AudioChannels int        `json:"audio_channels,omitempty"`
+	SampleRate    int        `json:"sample_rate,omitempty"` // Audio sample rate in Hz
+	ProbedAt      *time.Time `json:"probed_at,omitempty"`   // When the file was probed; the duration is measured from then on
+}
@@ -1,47 +1,38 @@
 package entity
 
-import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
-)
-
-type MoMoRequest struct {
-	PartnerCode string
-	AccessKey   string
-	RequestID   string
-	Amount      string
-	OrderID     string
-	Signature   string
-}
+import "time"
 
-// NewMoMoRequest is a factory function to create a MoMoRequest with the necessary fields
-func NewMoMoRequest(partnerCode, accessKey, requestID, amount, orderID string) *MoMoRequest {
-	return &MoMoRequest{
-		PartnerCode: partnerCode,
-		AccessKey:   accessKey,
-		RequestID:   requestID,
-		Amount:      amount,
-		OrderID:     orderID,
-	}
-}
+// MoMoPayment statuses
+const (
+	PaymentStatusPending  = "pending"  // Waiting for the user to pay
+	PaymentStatusPaid     = "paid"     // Paid; may be partly refunded
+	PaymentStatusFailed   = "failed"   // Declined, cancelled or expired
+	PaymentStatusRefunded = "refunded" // Paid and refunded in full
+)
 
-// GenerateSignature generates an HMAC SHA256 signature for the MoMo payment
-func (r *MoMoRequest) GenerateSignature(secrectKey string) {
-	rawSignature := r.PartnerCode + r.RequestID + r.Amount + r.AccessKey
-	h := hmac.New(sha256.New, []byte(secrectKey))
-	h.Write([]byte(rawSignature))
-	r.Signature = hex.EncodeToString(h.Sum(nil))
+// MoMoPayment is a payment made through the MoMo gateway
+type MoMoPayment struct {
+	ID             uint64     `json:"id"`
+	UserID         uint64     `json:"user_id"`
+	OrderID        string     `json:"order_id"`
+	RequestID      string     `json:"-"`
+	Amount         int64      `json:"amount"` // VND
+	OrderInfo      string     `json:"order_info"`
+	Status         string     `json:"status"`
+	PayURL         string     `json:"pay_url"`               // MoMo page the user pays on
+	TransID        int64      `json:"trans_id,omitempty"`    // MoMo's ID of the paid transaction
+	ResultCode     *int       `json:"result_code,omitempty"` // Last result code MoMo reported for the payment
+	Message        string     `json:"message,omitempty"`     // MoMo's description of the result code
+	RefundedAmount int64      `json:"refunded_amount"`       // VND returned to the user so far
+	PaidAt         *time.Time `json:"paid_at,omitempty"`     // When MoMo reported the payment paid
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
 }
 
-// ToMap converts the MoMoRequest to a map of strings, which can be used to send to the MoMo API
-func (r *MoMoRequest) ToMap() map[string]string {
-	return map[string]string{
-		"partnerCode": r.PartnerCode,
-		"accessKey":   r.AccessKey,
-		"requestId":   r.RequestID,
-		"amount":      r.Amount,
-		"orderId":     r.OrderID,
-		"signature":   r.Signature,
+// Refundable returns how much of the payment can still be refunded
+func (p *MoMoPayment) Refundable() int64 {
+	if p.Status != PaymentStatusPaid {
+		return 0
 	}
+	return p.Amount - p.RefundedAmount
 }
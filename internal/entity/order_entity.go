@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+// Order statuses
+const (
+	OrderStatusPending  = "pending"  // Waiting for payment
+	OrderStatusPaid     = "paid"     // Paid and delivered
+	OrderStatusFailed   = "failed"   // The payment failed; a new order is needed to try again
+	OrderStatusRefunded = "refunded" // Paid and refunded in full
+)
+
+// Product is something users can buy: translation minutes, premium time, or both
+type Product struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Price       int64  `json:"price"`        // VND
+	Credits     int64  `json:"credits"`      // Translation minutes added to the balance
+	PremiumDays int    `json:"premium_days"` // Days of premium added to the user's subscription
+}
+
+// Order is a user's purchase of a product. The price and what it buys are copied from the product,
+// so later price changes do not affect existing orders.
+type Order struct {
+	ID          uint64     `json:"id"`
+	OrderID     string     `json:"order_id"` // Generated by the server; also identifies the order's payment
+	UserID      uint64     `json:"user_id"`
+	ProductID   string     `json:"product_id"`
+	Amount      int64      `json:"amount"` // VND
+	Credits     int64      `json:"credits"`
+	PremiumDays int        `json:"premium_days"`
+	Status      string     `json:"status"`
+	PaidAt      *time.Time `json:"paid_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,34 @@
+package entity
+
+import "time"
+
+// RefreshToken is a long-lived credential exchanged for new access tokens. Only the SHA-256 hash
+// of the token is stored. Each use replaces it with a new token of the same family, so a family
+// traces one login session.
+type RefreshToken struct {
+	ID           uint64     `json:"id"`
+	UserID       uint64     `json:"user_id"`
+	TokenHash    string     `json:"-"`
+	FamilyID     string     `json:"family_id"` // Shared by every token rotated from the same login
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`     // Set when the token is used, logged out or revoked
+	ReplacedByID *uint64    `json:"replaced_by_id,omitempty"` // Token issued when this one was used
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// AuthTokens is the pair of tokens issued on login and on refresh. When the user has two-factor
+// authentication on, login issues only a challenge token that is exchanged for the pair once a code is confirmed.
+type AuthTokens struct {
+	UserID             uint64
+	AccessToken        string
+	AccessExpiresAt    time.Time
+	RefreshToken       string
+	RefreshExpiresAt   time.Time
+	ChallengeToken     string
+	ChallengeExpiresAt time.Time
+}
+
+// TwoFactorRequired reports whether a two-factor code has to be confirmed before the tokens are issued
+func (t *AuthTokens) TwoFactorRequired() bool {
+	return t.ChallengeToken != ""
+}
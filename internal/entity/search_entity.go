@@ -0,0 +1,18 @@
+package entity
+
+// Kinds of search results
+const (
+	SearchKindVideo         = "video"
+	SearchKindTranscription = "transcription"
+)
+
+// SearchResult is a video or transcription matching a search query
+type SearchResult struct {
+	Kind       string  `json:"kind"`        // SearchKindVideo or SearchKindTranscription
+	ID         uint64  `json:"id"`          // ID of the matching video or transcription
+	VideoID    uint64  `json:"video_id"`    // ID of the video the match belongs to
+	VideoTitle string  `json:"video_title"` // Title of that video
+	Lang       string  `json:"lang"`        // Language of the transcription; empty for videos
+	Snippet    string  `json:"snippet"`     // Matching excerpt with the matched terms wrapped in <mark> tags
+	Rank       float64 `json:"rank"`        // Relevance; lower is more relevant
+}
@@ -0,0 +1,48 @@
+package entity
+
+import "time"
+
+// StoredObject identifies an object in storage by its folder and file name
+type StoredObject struct {
+	Folder   string `json:"folder"`
+	FileName string `json:"file_name"`
+}
+
+// StorageDeletionStatus is the state of a queued object deletion
+type StorageDeletionStatus string
+
+const (
+	StorageDeletionPending StorageDeletionStatus = "pending" // Waiting to be deleted, either new or scheduled for a retry
+	StorageDeletionFailed  StorageDeletionStatus = "failed"  // Gave up after the last attempt
+)
+
+// StorageDeletion is an outbox entry for an object whose record was deleted.
+// It is written in the same transaction as the delete and removed once the object is gone.
+type StorageDeletion struct {
+	ID            uint64                `json:"id"`
+	Folder        string                `json:"folder"`
+	FileName      string                `json:"file_name"`
+	Reason        string                `json:"reason"`     // The deleted record, e.g. "video 42"
+	Status        StorageDeletionStatus `json:"status"`     // Status of the deletion
+	Attempts      int                   `json:"attempts"`   // Number of failed attempts so far
+	LastError     string                `json:"last_error"` // Error from the most recent failed attempt
+	NextAttemptAt time.Time             `json:"next_attempt_at"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+}
+
+// ObjectReference is a database row that points at an object in storage
+type ObjectReference struct {
+	Table    string `json:"table"`  // Table of the referencing row, e.g. "videos"
+	RowID    uint64 `json:"row_id"` // ID of the referencing row
+	Folder   string `json:"folder"`
+	FileName string `json:"file_name"`
+}
+
+// ReconcileReport lists the differences between the database and storage found by a reconciliation
+type ReconcileReport struct {
+	ScannedObjects int               `json:"scanned_objects"` // Objects listed in the scanned folders
+	Orphans        []StoredObject    `json:"orphans"`         // Objects no row references
+	Dangling       []ObjectReference `json:"dangling"`        // Rows whose object does not exist
+	Enqueued       int               `json:"enqueued"`        // Orphans queued for deletion
+}
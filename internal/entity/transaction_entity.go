@@ -1,10 +1,35 @@
 package entity
 
-// TransactionLog represents a log entry for a transaction
+import "time"
+
+// Payment methods whose events are logged
+const (
+	PaymentMethodMoMo = "momo"
+)
+
+// Payment events recorded in the transaction log
+const (
+	TransactionActionCreate      = "create"       // Payment created at the gateway
+	TransactionActionStatusCheck = "status_check" // Gateway asked for the result of a payment
+	TransactionActionIPN         = "ipn"          // Notification received from the gateway
+	TransactionActionRefund      = "refund"       // Refund requested from the gateway
+)
+
+// Outcomes of a payment event
+const (
+	TransactionStatusSucceeded = "succeeded"
+	TransactionStatusFailed    = "failed"   // The gateway refused the request or could not be reached
+	TransactionStatusRejected  = "rejected" // A notification we did not accept, such as one with a bad signature
+)
+
+// TransactionLog represents a log entry for a transaction. Entries are never changed or deleted,
+// so the entries of an order are the history of its payment.
 type TransactionLog struct {
-	OrderID       string
-	PaymentMethod string
-	Action        string
-	Status        string
-	Details       string
+	ID            uint64    `json:"id"`
+	OrderID       string    `json:"order_id"`
+	PaymentMethod string    `json:"payment_method"`
+	Action        string    `json:"action"`
+	Status        string    `json:"status"`
+	Details       string    `json:"details"` // JSON with the request and response payloads, secrets redacted
+	CreatedAt     time.Time `json:"created_at"`
 }
@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+// TranscriptionSegment is a timed piece of a transcription, such as one subtitle cue
+type TranscriptionSegment struct {
+	ID              uint64    `json:"id"`
+	TranscriptionID uint64    `json:"transcription_id"`
+	Position        int       `json:"position"` // Zero-based order of the segment within its transcription
+	StartMs         int64     `json:"start_ms"` // Start time in milliseconds from the beginning of the media
+	EndMs           int64     `json:"end_ms"`   // End time in milliseconds from the beginning of the media
+	Speaker         string    `json:"speaker"`  // Optional speaker label
+	Text            string    `json:"text"`
+	CreatedAt       time.Time `json:"created_at"`
+}
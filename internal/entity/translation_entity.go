@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+// TranslationStatus is the progress of a translation request
+type TranslationStatus string
+
+const (
+	TranslationStatusPending    TranslationStatus = "pending"
+	TranslationStatusProcessing TranslationStatus = "processing"
+	TranslationStatusCompleted  TranslationStatus = "completed"
+	TranslationStatusFailed     TranslationStatus = "failed"
+)
+
+// Translation ties a source video to its output in one target language and to every artifact produced on the way
+type Translation struct {
+	ID              uint64            `json:"id"`
+	VideoID         uint64            `json:"video_id"`         // ID of the source video
+	UserID          uint64            `json:"user_id"`          // ID of the user who requested the translation
+	SourceLang      string            `json:"source_lang"`      // Language spoken in the source video (e.g., "en")
+	TargetLang      string            `json:"target_lang"`      // Language to translate into (e.g., "vi")
+	TranscriptionID *uint64           `json:"transcription_id"` // Transcription produced for this translation, nil until available
+	AudioID         *uint64           `json:"audio_id"`         // Synthesized audio in the target language, nil until available
+	OutputFolder    string            `json:"output_folder"`    // S3 folder of the translated video
+	OutputFileName  string            `json:"output_file_name"` // File name of the translated video in S3
+	Status          TranslationStatus `json:"status"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
@@ -0,0 +1,8 @@
+package entity
+
+// TOTPEnrollment is what a user needs to add their account to an authenticator app
+type TOTPEnrollment struct {
+	Secret string // Base32 secret for entering by hand
+	URI    string // otpauth:// provisioning URI
+	QRCode []byte // PNG image of URI
+}
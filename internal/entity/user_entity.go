@@ -9,6 +9,17 @@ const (
 	UserStatusDeleted   = 10
 )
 
+// UserRole constants
+const (
+	RoleUser  = "User"
+	RoleAdmin = "Admin"
+)
+
+// IsValidRole reports whether role is one of the known roles
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleAdmin
+}
+
 // User represents the schema for user data
 type User struct {
 	ID           uint64    `json:"id"`         // Unique identifier for the user
@@ -24,4 +35,19 @@ type User struct {
 	AvatarFolder string    `json:"avatar_folder"` // Folder that contain the avatar image on s3
 	CreatedAt    time.Time `json:"created_at"`    // Timestamp of when the user was created
 	UpdatedAt    time.Time `json:"updated_at"`    // Timestamp of the last update to the user's data
+	TokenVersion int       `json:"-"`             // Access tokens carrying an older version are rejected
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"` // When the user confirmed their email address; nil until then
+	TOTPEnabledAt   *time.Time `json:"totp_enabled_at,omitempty"`   // When two-factor authentication was turned on; nil while off
+	PremiumUntil    *time.Time `json:"premium_until,omitempty"`     // When premium ends, after which Premium reads false; nil if it does not
+}
+
+// EmailVerified reports whether the user has confirmed their email address
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// TwoFactorEnabled reports whether signing in also requires a code from an authenticator app
+func (u *User) TwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != nil
 }
@@ -0,0 +1,27 @@
+package entity
+
+import "time"
+
+// UserIdentity links an account at an external identity provider (Google, GitHub or an OpenID Connect
+// issuer) to a user, so signing in with that account signs in as the user
+type UserIdentity struct {
+	ID          uint64     `json:"id"`
+	UserID      uint64     `json:"user_id"`
+	Provider    string     `json:"provider"` // Name of the configured provider
+	Subject     string     `json:"subject"`  // ID of the account at the provider
+	Email       string     `json:"email"`    // Email the provider reported when the identity was linked
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+// OIDCLoginState is a sign-in started at an identity provider and not yet completed. The state
+// parameter sent to the provider is only stored as its SHA-256 hash; the PKCE code verifier and
+// the nonce are needed in plain text to redeem the code and check the ID token.
+type OIDCLoginState struct {
+	StateHash    string    `json:"-"`
+	Provider     string    `json:"provider"`
+	CodeVerifier string    `json:"-"`
+	Nonce        string    `json:"-"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+// UserToken purposes
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+)
+
+// UserToken is a single-use secret emailed to a user to prove ownership of their address,
+// either to reset the password or to verify the address. Only its SHA-256 hash is stored.
+type UserToken struct {
+	ID        uint64     `json:"id"`
+	UserID    uint64     `json:"user_id"`
+	Purpose   string     `json:"purpose"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"` // Set when the token is used or replaced by a newer one
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -15,7 +15,7 @@ const (
 type Video struct {
 	ID          uint64      `json:"id"`
 	Title       string      `json:"title"`
-	Duration    int         `json:"duration"` // Duration of the video in seconds
+	Duration    int         `json:"duration"` // Duration of the video in seconds; measured from the file once it is probed
 	Description string      `json:"description"`
 	FileName    string      `json:"file_name"` //
 	Folder      string      `json:"folder"`
@@ -24,4 +24,5 @@ type Video struct {
 	UserID      uint64      `json:"user_id"`    // ID of the user who uploaded the video
 	CreatedAt   time.Time   `json:"created_at"` // Timestamp of when the video was created
 	UpdatedAt   time.Time   `json:"updated_at"` // Timestamp of the last update to the video
+	MediaInfo
 }
@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// SystemActorID is recorded as the actor of status changes made by the server itself (e.g. the worker pool)
+const SystemActorID uint64 = 0
+
+// VideoStatusHistory records a single status transition of a video
+type VideoStatusHistory struct {
+	ID         uint64      `json:"id"`
+	VideoID    uint64      `json:"video_id"`
+	FromStatus VideoStatus `json:"from_status"`
+	ToStatus   VideoStatus `json:"to_status"`
+	ActorID    uint64      `json:"actor_id"`   // ID of the user who made the change, SystemActorID for the server
+	Reason     string      `json:"reason"`     // Why the status changed
+	CreatedAt  time.Time   `json:"created_at"` // Timestamp of the transition
+}
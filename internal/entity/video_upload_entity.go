@@ -0,0 +1,64 @@
+package entity
+
+import "time"
+
+// UploadStatus is the state of a direct-to-storage video upload
+type UploadStatus string
+
+const (
+	UploadStatusPending   UploadStatus = "pending"   // Upload URL issued, object not yet verified
+	UploadStatusCompleted UploadStatus = "completed" // Object verified and the video created
+	UploadStatusExpired   UploadStatus = "expired"   // Never finalized; the object was removed
+	UploadStatusAborted   UploadStatus = "aborted"   // Cancelled by the client; the object was removed
+)
+
+// VideoUpload tracks a video file uploaded straight to storage until the server has verified the object
+type VideoUpload struct {
+	ID             uint64       `json:"id"`
+	UserID         uint64       `json:"user_id"`
+	Title          string       `json:"title"`
+	Description    string       `json:"description"`
+	Duration       int          `json:"duration"`
+	Image          string       `json:"image"`
+	Folder         string       `json:"folder"`
+	FileName       string       `json:"file_name"`                     // Server-generated object name within Folder
+	ContentType    string       `json:"content_type"`                  // MIME type the object must be stored with
+	Size           int64        `json:"size"`                          // Exact object size in bytes
+	ChecksumSHA256 string       `json:"checksum_sha256,omitempty"`     // Optional base64 SHA-256 of the object
+	MultipartID    string       `json:"multipart_upload_id,omitempty"` // S3 upload ID when the file is sent in parts
+	PartSize       int64        `json:"part_size,omitempty"`           // Size of every part but the last
+	Status         UploadStatus `json:"status"`
+	VideoID        *uint64      `json:"video_id,omitempty"` // Set once the upload is completed
+	ExpiresAt      time.Time    `json:"expires_at"`         // Pending uploads are removed after this time
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// IsMultipart reports whether the file is uploaded in parts
+func (u *VideoUpload) IsMultipart() bool {
+	return u.MultipartID != ""
+}
+
+// PartCount returns the number of parts a multipart upload is split into, or 0 for a single upload
+func (u *VideoUpload) PartCount() int {
+	if !u.IsMultipart() || u.PartSize <= 0 {
+		return 0
+	}
+	return int((u.Size + u.PartSize - 1) / u.PartSize)
+}
+
+// PartLength returns the size in bytes of the given one-based part
+func (u *VideoUpload) PartLength(partNumber int) int64 {
+	if partNumber < u.PartCount() {
+		return u.PartSize
+	}
+	return u.Size - int64(u.PartCount()-1)*u.PartSize
+}
+
+// UploadPart is one part of a multipart video upload
+type UploadPart struct {
+	PartNumber int    `json:"part_number"`
+	Size       int64  `json:"size"`
+	ETag       string `json:"etag,omitempty"`       // Set once S3 has received the part
+	UploadURL  string `json:"upload_url,omitempty"` // Presigned URL to PUT the part to
+}
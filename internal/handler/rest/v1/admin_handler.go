@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"mlvt/internal/pkg/middleware"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminController struct {
+	adminService service.AdminService
+}
+
+func NewAdminController(adminService service.AdminService) *AdminController {
+	return &AdminController{adminService: adminService}
+}
+
+// ListUsers godoc
+// @Summary List all users
+// @Description Retrieves every user in the system (admin only)
+// @Tags admin
+// @Produce json
+// @Success 200 {object} response.UsersResponse "users"
+// @Failure 403 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /admin/users [get]
+func (h *AdminController) ListUsers(c *gin.Context) {
+	users, err := h.adminService.ListUsers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.UsersResponse{Users: users})
+}
+
+// SearchUsers godoc
+// @Summary Search users
+// @Description Searches users by name, username or email, optionally filtered by role and status (admin only)
+// @Tags admin
+// @Produce json
+// @Param q query string false "Text contained in the name, username or email"
+// @Param role query string false "Role filter (User, Admin)"
+// @Param status query int false "Status filter (1 available, 9 suspended, 10 deleted)"
+// @Success 200 {object} response.UsersResponse "users"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 403 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /admin/users/search [get]
+func (h *AdminController) SearchUsers(c *gin.Context) {
+	status := 0
+	if statusStr := c.Query("status"); statusStr != "" {
+		parsed, err := strconv.Atoi(statusStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid status"})
+			return
+		}
+		status = parsed
+	}
+
+	users, err := h.adminService.SearchUsers(c.Query("q"), c.Query("role"), status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.UsersResponse{Users: users})
+}
+
+// SuspendUser godoc
+// @Summary Suspend user
+// @Description Suspends a user so they can no longer sign in (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param user_id path uint64 true "User ID"
+// @Param body body object false "Reason for the suspension"
+// @Success 200 {object} response.MessageResponse "message"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 403 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /admin/users/{user_id}/suspend [put]
+func (h *AdminController) SuspendUser(c *gin.Context) {
+	userID, reason, ok := bindStatusChange(c)
+	if !ok {
+		return
+	}
+
+	if err := h.adminService.SuspendUser(middleware.CurrentUser(c).ID, userID, reason); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.MessageResponse{Message: "User suspended successfully"})
+}
+
+// ReinstateUser godoc
+// @Summary Reinstate user
+// @Description Restores a suspended user to the available status (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param user_id path uint64 true "User ID"
+// @Param body body object false "Reason for the reinstatement"
+// @Success 200 {object} response.MessageResponse "message"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 403 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "error"
+// @Failure 409 {object} response.ErrorResponse "The user is not suspended"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /admin/users/{user_id}/reinstate [put]
+func (h *AdminController) ReinstateUser(c *gin.Context) {
+	userID, reason, ok := bindStatusChange(c)
+	if !ok {
+		return
+	}
+
+	if err := h.adminService.ReinstateUser(middleware.CurrentUser(c).ID, userID, reason); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.MessageResponse{Message: "User reinstated successfully"})
+}
+
+// ChangeUserRole godoc
+// @Summary Change user role
+// @Description Assigns a new role to a user (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param user_id path uint64 true "User ID"
+// @Param body body object true "New role (User, Admin)"
+// @Success 200 {object} response.MessageResponse "message"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 403 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /admin/users/{user_id}/role [put]
+func (h *AdminController) ChangeUserRole(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid user ID"})
+		return
+	}
+
+	var request struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid input"})
+		return
+	}
+
+	if err := h.adminService.ChangeUserRole(middleware.CurrentUser(c).ID, userID, request.Role); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.MessageResponse{Message: "User role updated successfully"})
+}
+
+// ListLockedAccounts godoc
+// @Summary List locked accounts
+// @Description Retrieves the accounts that refuse sign-ins after repeated failed attempts, with the time each lock ends (admin only)
+// @Tags admin
+// @Produce json
+// @Success 200 {object} response.LockedAccountsResponse "accounts"
+// @Failure 403 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /admin/users/locked [get]
+func (h *AdminController) ListLockedAccounts(c *gin.Context) {
+	accounts, err := h.adminService.ListLockedAccounts()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.LockedAccountsResponse{Accounts: accounts})
+}
+
+// UnlockUser godoc
+// @Summary Unlock user
+// @Description Clears the failed sign-ins of a user so a locked account can sign in again right away (admin only)
+// @Tags admin
+// @Produce json
+// @Param user_id path uint64 true "User ID"
+// @Success 200 {object} response.MessageResponse "message"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 403 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /admin/users/{user_id}/unlock [put]
+func (h *AdminController) UnlockUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid user ID"})
+		return
+	}
+
+	if err := h.adminService.UnlockUser(middleware.CurrentUser(c).ID, userID); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.MessageResponse{Message: "User unlocked successfully"})
+}
+
+// ListAuditLogs godoc
+// @Summary List audit logs
+// @Description Retrieves the most recent admin actions, optionally for a single user (admin only)
+// @Tags admin
+// @Produce json
+// @Param user_id query uint64 false "Only return actions performed on this user"
+// @Param limit query int false "Maximum number of records (default 100)"
+// @Success 200 {object} response.AuditLogsResponse "audit_logs"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 403 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /admin/audit-logs [get]
+func (h *AdminController) ListAuditLogs(c *gin.Context) {
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		userID, err := strconv.ParseUint(userIDStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid user ID"})
+			return
+		}
+
+		auditLogs, err := h.adminService.ListUserAuditLogs(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+			return
+		}
+		c.JSON(http.StatusOK, response.AuditLogsResponse{AuditLogs: auditLogs})
+		return
+	}
+
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+
+	auditLogs, err := h.adminService.ListAuditLogs(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.AuditLogsResponse{AuditLogs: auditLogs})
+}
+
+// bindStatusChange reads the target user ID and the optional reason for a suspend or reinstate request
+func bindStatusChange(c *gin.Context) (uint64, string, bool) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid user ID"})
+		return 0, "", false
+	}
+
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid input"})
+			return 0, "", false
+		}
+	}
+	return userID, request.Reason, true
+}
+
+// respondAdminError maps admin service errors to HTTP responses
+func respondAdminError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrInvalidRole), errors.Is(err, service.ErrSelfAdminAction), errors.Is(err, service.ErrUserDeleted):
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrUserNotSuspended):
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+	}
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/pkg/middleware"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupAdminRouter(mockService *service.MockAdminService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	controller := NewAdminController(mockService)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(middleware.UserInfoKey, &entity.User{ID: 1, Role: entity.RoleAdmin})
+		c.Next()
+	})
+	router.GET("/admin/users/search", controller.SearchUsers)
+	router.PUT("/admin/users/:user_id/suspend", controller.SuspendUser)
+	router.PUT("/admin/users/:user_id/reinstate", controller.ReinstateUser)
+	router.PUT("/admin/users/:user_id/role", controller.ChangeUserRole)
+	router.GET("/admin/users/locked", controller.ListLockedAccounts)
+	router.PUT("/admin/users/:user_id/unlock", controller.UnlockUser)
+	return router
+}
+
+func TestAdminSearchUsers_Success(t *testing.T) {
+	mockService := new(service.MockAdminService)
+	router := setupAdminRouter(mockService)
+
+	mockService.On("SearchUsers", "jane", entity.RoleAdmin, entity.UserStatusAvailable).
+		Return([]entity.User{{ID: 2, UserName: "janesmith"}}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/admin/users/search?q=jane&role=Admin&status=1", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp response.UsersResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Len(t, resp.Users, 1)
+	mockService.AssertExpectations(t)
+}
+
+func TestAdminSearchUsers_InvalidStatus(t *testing.T) {
+	mockService := new(service.MockAdminService)
+	router := setupAdminRouter(mockService)
+
+	req, _ := http.NewRequest(http.MethodGet, "/admin/users/search?status=abc", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestAdminSuspendUser_Success(t *testing.T) {
+	mockService := new(service.MockAdminService)
+	router := setupAdminRouter(mockService)
+
+	mockService.On("SuspendUser", uint64(1), uint64(2), "spam").Return(nil)
+
+	req, _ := http.NewRequest(http.MethodPut, "/admin/users/2/suspend", bytes.NewBufferString(`{"reason":"spam"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAdminSuspendUser_NotFound(t *testing.T) {
+	mockService := new(service.MockAdminService)
+	router := setupAdminRouter(mockService)
+
+	mockService.On("SuspendUser", uint64(1), uint64(99), "").Return(service.ErrUserNotFound)
+
+	req, _ := http.NewRequest(http.MethodPut, "/admin/users/99/suspend", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestAdminReinstateUser_NotSuspended(t *testing.T) {
+	mockService := new(service.MockAdminService)
+	router := setupAdminRouter(mockService)
+
+	mockService.On("ReinstateUser", uint64(1), uint64(2), "").Return(service.ErrUserNotSuspended)
+
+	req, _ := http.NewRequest(http.MethodPut, "/admin/users/2/reinstate", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestAdminListLockedAccounts_Success(t *testing.T) {
+	mockService := new(service.MockAdminService)
+	router := setupAdminRouter(mockService)
+
+	lockedUntil := time.Now().Add(time.Minute)
+	mockService.On("ListLockedAccounts").Return([]entity.LoginThrottle{
+		{Scope: entity.LoginThrottleAccount, Subject: "jane@example.com", Failures: 10, LockedUntil: &lockedUntil},
+	}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/admin/users/locked", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp response.LockedAccountsResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	if assert.Len(t, resp.Accounts, 1) {
+		assert.Equal(t, "jane@example.com", resp.Accounts[0].Subject)
+	}
+}
+
+func TestAdminUnlockUser_Success(t *testing.T) {
+	mockService := new(service.MockAdminService)
+	router := setupAdminRouter(mockService)
+
+	mockService.On("UnlockUser", uint64(1), uint64(2)).Return(nil)
+
+	req, _ := http.NewRequest(http.MethodPut, "/admin/users/2/unlock", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAdminChangeUserRole_InvalidRole(t *testing.T) {
+	mockService := new(service.MockAdminService)
+	router := setupAdminRouter(mockService)
+
+	mockService.On("ChangeUserRole", uint64(1), uint64(2), "SuperUser").Return(service.ErrInvalidRole)
+
+	req, _ := http.NewRequest(http.MethodPut, "/admin/users/2/role", bytes.NewBufferString(`{"role":"SuperUser"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	var resp response.ErrorResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "invalid role", resp.Error)
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mlvt/internal/infra/zap-logging/log"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyController struct {
+	apiKeyService service.APIKeyService
+}
+
+func NewAPIKeyController(apiKeyService service.APIKeyService) *APIKeyController {
+	return &APIKeyController{apiKeyService: apiKeyService}
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Creates a key for scripts to call the API with instead of signing in, sent as "Authorization: Bearer mlvt_...".
+// @Description Scopes are videos:read, transcriptions:write and payments. Without expires_at the key works for 90 days by default.
+// @Description The key is returned only once.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id path uint64 true "User ID"
+// @Param body body object true "Name, scopes and optional expires_at (RFC 3339)"
+// @Success 201 {object} response.APIKeyResponse
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "error"
+// @Failure 409 {object} response.ErrorResponse "too many API keys"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /users/{user_id}/api-keys [post]
+func (h *APIKeyController) CreateAPIKey(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Name      string     `json:"name" binding:"required"`
+		Scopes    []string   `json:"scopes" binding:"required"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid input"})
+		return
+	}
+
+	apiKey, key, err := h.apiKeyService.Create(userID, request.Name, request.Scopes, request.ExpiresAt)
+	if err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, response.APIKeyResponse{APIKey: *apiKey, Key: key})
+}
+
+// ListAPIKeys godoc
+// @Summary List API keys
+// @Description Lists the user's API keys that have not been revoked, including expired ones, with when and from where each was last used
+// @Tags users
+// @Produce json
+// @Param user_id path uint64 true "User ID"
+// @Success 200 {object} response.APIKeysResponse
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /users/{user_id}/api-keys [get]
+func (h *APIKeyController) ListAPIKeys(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	apiKeys, err := h.apiKeyService.List(userID)
+	if err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.APIKeysResponse{APIKeys: apiKeys})
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Stops an API key from working
+// @Tags users
+// @Produce json
+// @Param user_id path uint64 true "User ID"
+// @Param key_id path uint64 true "API key ID"
+// @Success 200 {object} response.MessageResponse "message"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /users/{user_id}/api-keys/{key_id} [delete]
+func (h *APIKeyController) RevokeAPIKey(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	keyID, err := strconv.ParseUint(c.Param("key_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid API key ID"})
+		return
+	}
+
+	if err := h.apiKeyService.Revoke(userID, keyID); err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.MessageResponse{Message: "API key revoked"})
+}
+
+// respondAPIKeyError maps API key service errors to HTTP responses
+func respondAPIKeyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrInvalidAPIKeyRequest):
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrTooManyAPIKeys):
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: err.Error()})
+	default:
+		log.Errorf("API key request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+	}
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupAPIKeyRouter(mockService *service.MockAPIKeyService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	controller := NewAPIKeyController(mockService)
+
+	router := gin.New()
+	router.POST("/users/:user_id/api-keys", controller.CreateAPIKey)
+	router.GET("/users/:user_id/api-keys", controller.ListAPIKeys)
+	router.DELETE("/users/:user_id/api-keys/:key_id", controller.RevokeAPIKey)
+	return router
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	mockService := new(service.MockAPIKeyService)
+	router := setupAPIKeyRouter(mockService)
+
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	scopes := []string{entity.APIKeyScopeVideosRead}
+	mockService.On("Create", uint64(1), "batch", scopes, mock.MatchedBy(func(t *time.Time) bool {
+		return t != nil && t.Equal(expiresAt)
+	})).Return(&entity.APIKey{ID: 3, UserID: 1, Name: "batch", Prefix: "mlvt_abcdef", Scopes: scopes, ExpiresAt: expiresAt}, "mlvt_abcdefsecret", nil)
+
+	body := `{"name":"batch","scopes":["videos:read"],"expires_at":"2030-01-01T00:00:00Z"}`
+	req, _ := http.NewRequest(http.MethodPost, "/users/1/api-keys", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var resp response.APIKeyResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "mlvt_abcdefsecret", resp.Key)
+	assert.Equal(t, uint64(3), resp.APIKey.ID)
+	assert.NotContains(t, rr.Body.String(), "key_hash")
+}
+
+func TestCreateAPIKey_Invalid(t *testing.T) {
+	mockService := new(service.MockAPIKeyService)
+	router := setupAPIKeyRouter(mockService)
+
+	mockService.On("Create", uint64(1), "batch", []string{"admin"}, (*time.Time)(nil)).
+		Return(nil, "", service.ErrInvalidAPIKeyRequest)
+
+	for body, status := range map[string]int{
+		`{"name":"batch","scopes":["admin"]}`: http.StatusBadRequest,
+		`{"scopes":["videos:read"]}`:          http.StatusBadRequest,
+	} {
+		req, _ := http.NewRequest(http.MethodPost, "/users/1/api-keys", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, status, rr.Code, body)
+	}
+}
+
+func TestListAPIKeys(t *testing.T) {
+	mockService := new(service.MockAPIKeyService)
+	router := setupAPIKeyRouter(mockService)
+
+	mockService.On("List", uint64(1)).Return([]entity.APIKey{{ID: 3, Name: "batch"}}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users/1/api-keys", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp response.APIKeysResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	if assert.Len(t, resp.APIKeys, 1) {
+		assert.Equal(t, "batch", resp.APIKeys[0].Name)
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	mockService := new(service.MockAPIKeyService)
+	router := setupAPIKeyRouter(mockService)
+
+	mockService.On("Revoke", uint64(1), uint64(3)).Return(nil)
+	mockService.On("Revoke", uint64(1), uint64(4)).Return(service.ErrAPIKeyNotFound)
+
+	for path, status := range map[string]int{
+		"/users/1/api-keys/3":   http.StatusOK,
+		"/users/1/api-keys/4":   http.StatusNotFound,
+		"/users/1/api-keys/abc": http.StatusBadRequest,
+	} {
+		req, _ := http.NewRequest(http.MethodDelete, path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, status, rr.Code, path)
+	}
+}
@@ -141,15 +141,15 @@ func (h *AudioController) GetAudio(c *gin.Context) {
 // @Description Retrieves an audio file for a specific user and generates a presigned download URL.
 // @Tags audios
 // @Produce json
-// @Param audioID path uint64 true "ID of the audio file"
-// @Param userID path uint64 true "ID of the user"
+// @Param audio_id path uint64 true "ID of the audio file"
+// @Param user_id path uint64 true "ID of the user"
 // @Success 200 {object} response.AudioResponse "audio, download_url"
 // @Failure 400 {object} response.ErrorResponse "error"
 // @Failure 500 {object} response.ErrorResponse "error"
-// @Router /audios/{audioID}/user/{userID} [get]
+// @Router /audios/{audio_id}/user/{user_id} [get]
 func (h *AudioController) GetAudioByUser(c *gin.Context) {
 	// Parse audio ID from the URL path
-	audioIDStr := c.Param("audioID")
+	audioIDStr := c.Param("audio_id")
 	audioID, err := strconv.ParseUint(audioIDStr, 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid audio ID"})
@@ -157,7 +157,7 @@ func (h *AudioController) GetAudioByUser(c *gin.Context) {
 	}
 
 	// Parse user ID from the URL path
-	userIDStr := c.Param("userID")
+	userIDStr := c.Param("user_id")
 	userID, err := strconv.ParseUint(userIDStr, 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid user ID"})
@@ -180,11 +180,19 @@ func (h *AudioController) GetAudioByUser(c *gin.Context) {
 
 // ListAudiosByUserID godoc
 // @Summary List audios by user ID
-// @Description Retrieves all audio files belonging to a specific user.
+// @Description Retrieves a page of the audio files belonging to a specific user.
 // @Tags audios
 // @Produce json
 // @Param user_id path uint64 true "ID of the user"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field: created_at, duration (default created_at)"
+// @Param order query string false "asc or desc (default desc)"
+// @Param lang query string false "Only audios in this language"
+// @Param created_after query string false "Only items created at or after this RFC 3339 time or date"
+// @Param created_before query string false "Only items created before this RFC 3339 time or date"
 // @Success 200 {object} response.AudiosResponse "audios"
+// @Failure 400 {object} response.ErrorResponse "error"
 // @Failure 500 {object} response.ErrorResponse "error"
 // @Router /audios/user/{user_id} [get]
 func (h *AudioController) ListAudiosByUserID(c *gin.Context) {
@@ -195,13 +203,18 @@ func (h *AudioController) ListAudiosByUserID(c *gin.Context) {
 		return
 	}
 
-	transcriptions, err := h.audioService.ListAudiosByUserID(userID)
+	opts, ok := bindListOptions(c)
+	if !ok {
+		return
+	}
+
+	audios, nextCursor, err := h.audioService.ListAudiosByUserID(userID, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+		respondListError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, response.AudiosResponse{Audios: transcriptions})
+	c.JSON(http.StatusOK, response.AudiosResponse{Audios: audios, NextCursor: nextCursor})
 }
 
 // GetAudioByVideoID godoc
@@ -209,15 +222,15 @@ func (h *AudioController) ListAudiosByUserID(c *gin.Context) {
 // @Description Retrieves an audio file for a specific video and generates a presigned download URL.
 // @Tags audios
 // @Produce json
-// @Param audioID path uint64 true "ID of the audio file"
-// @Param videoID path uint64 true "ID of the video"
+// @Param audio_id path uint64 true "ID of the audio file"
+// @Param video_id path uint64 true "ID of the video"
 // @Success 200 {object} response.AudioResponse "audio, download_url"
 // @Failure 400 {object} response.ErrorResponse "error"
 // @Failure 500 {object} response.ErrorResponse "error"
-// @Router /audios/{audioID}/video/{videoID} [get]
+// @Router /audios/{audio_id}/video/{video_id} [get]
 func (h *AudioController) GetAudioByVideoID(c *gin.Context) {
 	// Parse audio ID from the URL path
-	audioIDStr := c.Param("audioID")
+	audioIDStr := c.Param("audio_id")
 	audioID, err := strconv.ParseUint(audioIDStr, 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid audio ID"})
@@ -225,7 +238,7 @@ func (h *AudioController) GetAudioByVideoID(c *gin.Context) {
 	}
 
 	// Parse video ID from the URL path
-	videoIDStr := c.Param("videoID")
+	videoIDStr := c.Param("video_id")
 	videoID, err := strconv.ParseUint(videoIDStr, 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid video ID"})
@@ -248,10 +261,17 @@ func (h *AudioController) GetAudioByVideoID(c *gin.Context) {
 
 // ListAudiosByVideoID godoc
 // @Summary List audios by Video ID
-// @Description Retrieves all audio files belonging to a specific video.
+// @Description Retrieves a page of the audio files belonging to a specific video.
 // @Tags audios
 // @Produce json
 // @Param video_id path uint64 true "ID of the video"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field: created_at, duration (default created_at)"
+// @Param order query string false "asc or desc (default desc)"
+// @Param lang query string false "Only audios in this language"
+// @Param created_after query string false "Only items created at or after this RFC 3339 time or date"
+// @Param created_before query string false "Only items created before this RFC 3339 time or date"
 // @Success 200 {object} response.AudiosResponse "audios"
 // @Failure 400 {object} response.ErrorResponse "error"
 // @Failure 500 {object} response.ErrorResponse "error"
@@ -264,13 +284,18 @@ func (h *AudioController) ListAudiosByVideoID(c *gin.Context) {
 		return
 	}
 
-	audios, err := h.audioService.ListAudiosByVideoID(videoID)
+	opts, ok := bindListOptions(c)
+	if !ok {
+		return
+	}
+
+	audios, nextCursor, err := h.audioService.ListAudiosByVideoID(videoID, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+		respondListError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, response.AudiosResponse{Audios: audios})
+	c.JSON(http.StatusOK, response.AudiosResponse{Audios: audios, NextCursor: nextCursor})
 }
 
 // DeleteAudio godoc
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"mlvt/internal/infra/zap-logging/log"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BillingController struct {
+	billingService service.BillingService
+}
+
+func NewBillingController(billingService service.BillingService) *BillingController {
+	return &BillingController{billingService: billingService}
+}
+
+// ListProducts godoc
+// @Summary List products
+// @Description Lists the credit packs and subscriptions users can order, with their price in VND and the translation minutes they add
+// @Tags billing
+// @Produce json
+// @Success 200 {object} response.ProductsResponse
+// @Router /billing/products [get]
+func (h *BillingController) ListProducts(c *gin.Context) {
+	c.JSON(http.StatusOK, response.ProductsResponse{Products: h.billingService.ListProducts()})
+}
+
+// CreateOrder godoc
+// @Summary Order a product
+// @Description Creates a pending order of the product at its current price. The order ID it gets is what the order is paid with,
+// @Description through /payments/momo/create, and the product is delivered once the payment succeeds.
+// @Tags billing
+// @Accept json
+// @Produce json
+// @Param user_id path uint64 true "User ID"
+// @Param body body object{product_id=string} true "Product ID"
+// @Success 201 {object} response.OrderResponse
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /billing/users/{user_id}/orders [post]
+func (h *BillingController) CreateOrder(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		ProductID string `json:"product_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid input"})
+		return
+	}
+
+	order, err := h.billingService.CreateOrder(userID, request.ProductID)
+	if err != nil {
+		respondBillingError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, response.OrderResponse{Order: *order})
+}
+
+// ListOrders godoc
+// @Summary List orders
+// @Description Lists the user's orders, newest first by default
+// @Tags billing
+// @Produce json
+// @Param user_id path uint64 true "User ID"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Page size"
+// @Param sort query string false "Sort field: created_at"
+// @Param order query string false "asc or desc"
+// @Param status query string false "pending, paid, failed or refunded"
+// @Success 200 {object} response.OrdersResponse
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /billing/users/{user_id}/orders [get]
+func (h *BillingController) ListOrders(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	opts, ok := bindListOptions(c)
+	if !ok {
+		return
+	}
+
+	orders, nextCursor, err := h.billingService.ListOrders(userID, opts)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.OrdersResponse{Orders: orders, NextCursor: nextCursor})
+}
+
+// GetOrder godoc
+// @Summary Get an order
+// @Description Returns one of the user's orders, to see whether it has been paid and delivered
+// @Tags billing
+// @Produce json
+// @Param user_id path uint64 true "User ID"
+// @Param order_id path string true "Order ID"
+// @Success 200 {object} response.OrderResponse
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "order not found"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /billing/users/{user_id}/orders/{order_id} [get]
+func (h *BillingController) GetOrder(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.billingService.GetOrder(c.Param("order_id"))
+	if err == nil && order.UserID != userID {
+		err = service.ErrOrderNotFound
+	}
+	if err != nil {
+		respondBillingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.OrderResponse{Order: *order})
+}
+
+// GetBalance godoc
+// @Summary Get the balance
+// @Description Returns the translation minutes the user has left.
+// @Tags billing
+// @Produce json
+// @Param user_id path uint64 true "User ID"
+// @Success 200 {object} response.BalanceResponse
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /billing/users/{user_id}/balance [get]
+func (h *BillingController) GetBalance(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	balance, err := h.billingService.GetBalance(userID)
+	if err != nil {
+		respondBillingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.BalanceResponse{Balance: balance})
+}
+
+// ListLedgerEntries godoc
+// @Summary List balance history
+// @Description Lists what added to or took from the user's translation minutes, newest first by default:
+// @Description purchases, translations, refunds of failed translations and refunds of payments
+// @Tags billing
+// @Produce json
+// @Param user_id path uint64 true "User ID"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Page size"
+// @Param sort query string false "Sort field: created_at"
+// @Param order query string false "asc or desc"
+// @Success 200 {object} response.LedgerEntriesResponse
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /billing/users/{user_id}/ledger [get]
+func (h *BillingController) ListLedgerEntries(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	opts, ok := bindListOptions(c)
+	if !ok {
+		return
+	}
+
+	entries, nextCursor, err := h.billingService.ListLedgerEntries(userID, opts)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.LedgerEntriesResponse{Entries: entries, NextCursor: nextCursor})
+}
+
+// respondBillingError maps billing service errors to HTTP responses
+func respondBillingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrProductNotFound):
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrOrderNotFound):
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+	default:
+		log.Errorf("Billing request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+	}
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupBillingRouter(mockService *service.MockBillingService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	controller := NewBillingController(mockService)
+
+	router := gin.New()
+	router.GET("/billing/products", controller.ListProducts)
+	router.POST("/billing/users/:user_id/orders", controller.CreateOrder)
+	router.GET("/billing/users/:user_id/orders", controller.ListOrders)
+	router.GET("/billing/users/:user_id/orders/:order_id", controller.GetOrder)
+	router.GET("/billing/users/:user_id/balance", controller.GetBalance)
+	router.GET("/billing/users/:user_id/ledger", controller.ListLedgerEntries)
+	return router
+}
+
+func TestCreateOrder(t *testing.T) {
+	mockService := new(service.MockBillingService)
+	router := setupBillingRouter(mockService)
+
+	mockService.On("CreateOrder", uint64(1), "credits-60").Return(&entity.Order{
+		OrderID: "MLVT-20261016-3F9A2C7B1E", UserID: 1, ProductID: "credits-60", Amount: 50000, Credits: 60, Status: entity.OrderStatusPending,
+	}, nil)
+	mockService.On("CreateOrder", uint64(1), "credits-5").Return(nil, service.ErrProductNotFound)
+
+	req, _ := http.NewRequest(http.MethodPost, "/billing/users/1/orders", bytes.NewBufferString(`{"product_id":"credits-60"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var resp response.OrderResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "MLVT-20261016-3F9A2C7B1E", resp.Order.OrderID)
+	assert.Equal(t, int64(50000), resp.Order.Amount)
+
+	for body, status := range map[string]int{
+		`{"product_id":"credits-5"}`: http.StatusBadRequest,
+		`{}`:                         http.StatusBadRequest,
+	} {
+		req, _ := http.NewRequest(http.MethodPost, "/billing/users/1/orders", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, status, rr.Code, body)
+	}
+}
+
+func TestGetOrder_OtherUser(t *testing.T) {
+	mockService := new(service.MockBillingService)
+	router := setupBillingRouter(mockService)
+
+	mockService.On("GetOrder", "MLVT-1").Return(&entity.Order{OrderID: "MLVT-1", UserID: 2}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/billing/users/2/orders/MLVT-1", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// The order is not listed under another user
+	req, _ = http.NewRequest(http.MethodGet, "/billing/users/1/orders/MLVT-1", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestGetBalanceAndLedger(t *testing.T) {
+	mockService := new(service.MockBillingService)
+	router := setupBillingRouter(mockService)
+
+	mockService.On("GetBalance", uint64(1)).Return(int64(42), nil)
+	mockService.On("ListLedgerEntries", uint64(1), mock.MatchedBy(func(opts entity.ListOptions) bool {
+		return opts.Limit == 2
+	})).Return([]entity.LedgerEntry{
+		{TransactionID: "translation:5", Account: entity.LedgerAccountCredits, UserID: 1, Amount: -3, Kind: entity.LedgerKindTranslation},
+		{TransactionID: "order:MLVT-1", Account: entity.LedgerAccountCredits, UserID: 1, Amount: 60, Kind: entity.LedgerKindPurchase},
+	}, "next", nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/billing/users/1/balance", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"balance":42}`, rr.Body.String())
+
+	req, _ = http.NewRequest(http.MethodGet, "/billing/users/1/ledger?limit=2", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp response.LedgerEntriesResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Len(t, resp.Entries, 2)
+	assert.Equal(t, "next", resp.NextCursor)
+
+	req, _ = http.NewRequest(http.MethodGet, "/billing/users/1/ledger?limit=0", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/env"
+	"mlvt/internal/infra/zap-logging/log"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FrameRequest describes a keyframe image already uploaded through the image upload URL
+type FrameRequest struct {
+	FileName    string `json:"file_name" binding:"required"` // Name the image was uploaded under
+	TimestampMs int64  `json:"timestamp_ms"`                 // Position of the frame in the video
+	Width       int    `json:"width" binding:"required"`
+	Height      int    `json:"height" binding:"required"`
+	IsPrimary   bool   `json:"is_primary"` // Make this frame the video's thumbnail
+}
+
+// AddFramesRequest lists the keyframes to register for a video
+type AddFramesRequest struct {
+	Frames []FrameRequest `json:"frames" binding:"required,dive"`
+}
+
+type FrameController struct {
+	frameService service.FrameService
+}
+
+func NewFrameController(frameService service.FrameService) *FrameController {
+	return &FrameController{frameService: frameService}
+}
+
+// AddFrames godoc
+// @Summary Register keyframes of a video
+// @Description Registers up to 100 keyframe images of a video. Each image must first be uploaded through the image upload URL.
+// @Description A frame marked primary becomes the video's thumbnail, replacing the current one.
+// @Tags Videos
+// @Accept json
+// @Produce json
+// @Param video_id path uint64 true "ID of the video"
+// @Param frames body AddFramesRequest true "Frames to register"
+// @Success 201 {object} response.FramesResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse "A frame image has not been uploaded"
+// @Failure 500 {object} response.ErrorResponse
+// @Router /videos/{video_id}/frames [post]
+func (h *FrameController) AddFrames(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("video_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid video ID"})
+		return
+	}
+
+	var req AddFramesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	frames := make([]*entity.Frame, len(req.Frames))
+	for i, frame := range req.Frames {
+		frames[i] = &entity.Frame{
+			VideoID:     videoID,
+			Folder:      env.EnvConfig.VideoFramesFolder,
+			FileName:    frame.FileName,
+			TimestampMs: frame.TimestampMs,
+			Width:       frame.Width,
+			Height:      frame.Height,
+			IsPrimary:   frame.IsPrimary,
+		}
+	}
+
+	if err := h.frameService.AddFrames(videoID, frames); err != nil {
+		respondFrameError(c, err)
+		return
+	}
+
+	created := make([]entity.Frame, len(frames))
+	for i, frame := range frames {
+		created[i] = *frame
+	}
+	c.JSON(http.StatusCreated, response.FramesResponse{Frames: created})
+}
+
+// ListFrames godoc
+// @Summary List keyframes of a video
+// @Description Lists the keyframes of a video in playback order, each with a presigned download link.
+// @Tags Videos
+// @Produce json
+// @Param video_id path uint64 true "ID of the video"
+// @Success 200 {object} response.FramesResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /videos/{video_id}/frames [get]
+func (h *FrameController) ListFrames(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("video_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid video ID"})
+		return
+	}
+
+	frames, err := h.frameService.ListFrames(videoID)
+	if err != nil {
+		respondFrameError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.FramesResponse{Frames: frames})
+}
+
+// SetPrimaryFrame godoc
+// @Summary Select the thumbnail of a video
+// @Description Makes a keyframe the primary thumbnail of its video, shown in video lists.
+// @Tags Videos
+// @Produce json
+// @Param video_id path uint64 true "ID of the video"
+// @Param frame_id path uint64 true "ID of the frame"
+// @Success 200 {object} response.FrameResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /videos/{video_id}/frames/{frame_id}/primary [put]
+func (h *FrameController) SetPrimaryFrame(c *gin.Context) {
+	videoID, frameID, ok := parseFramePath(c)
+	if !ok {
+		return
+	}
+
+	frame, err := h.frameService.SetPrimaryFrame(videoID, frameID)
+	if err != nil {
+		respondFrameError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.FrameResponse{Frame: *frame})
+}
+
+// DeleteFrame godoc
+// @Summary Delete a keyframe
+// @Description Deletes a keyframe of a video; its image is removed from storage in the background.
+// @Tags Videos
+// @Produce json
+// @Param video_id path uint64 true "ID of the video"
+// @Param frame_id path uint64 true "ID of the frame"
+// @Success 200 {object} response.MessageResponse "message"
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /videos/{video_id}/frames/{frame_id} [delete]
+func (h *FrameController) DeleteFrame(c *gin.Context) {
+	videoID, frameID, ok := parseFramePath(c)
+	if !ok {
+		return
+	}
+
+	if err := h.frameService.DeleteFrame(videoID, frameID); err != nil {
+		respondFrameError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.MessageResponse{Message: "Frame deleted successfully"})
+}
+
+// parseFramePath reads the video and frame IDs from the path and responds with 400 if either is invalid
+func parseFramePath(c *gin.Context) (uint64, uint64, bool) {
+	videoID, err := strconv.ParseUint(c.Param("video_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid video ID"})
+		return 0, 0, false
+	}
+	frameID, err := strconv.ParseUint(c.Param("frame_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid frame ID"})
+		return 0, 0, false
+	}
+	return videoID, frameID, true
+}
+
+func respondFrameError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrFrameNotFound), errors.Is(err, service.ErrVideoNotFound):
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrInvalidFrame):
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrFrameNotUploaded):
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: err.Error()})
+	default:
+		log.Errorf("Frame request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+	}
+}
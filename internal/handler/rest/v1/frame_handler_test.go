@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupFrameRouter(mockService *service.MockFrameService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	controller := NewFrameController(mockService)
+
+	router := gin.New()
+	router.POST("/videos/:video_id/frames", controller.AddFrames)
+	router.GET("/videos/:video_id/frames", controller.ListFrames)
+	router.PUT("/videos/:video_id/frames/:frame_id/primary", controller.SetPrimaryFrame)
+	router.DELETE("/videos/:video_id/frames/:frame_id", controller.DeleteFrame)
+	return router
+}
+
+func TestAddFrames_Success(t *testing.T) {
+	mockService := new(service.MockFrameService)
+	router := setupFrameRouter(mockService)
+
+	mockService.On("AddFrames", uint64(1), mock.MatchedBy(func(frames []*entity.Frame) bool {
+		return len(frames) == 2 && frames[0].FileName == "a-1.jpg" && frames[0].IsPrimary && frames[1].TimestampMs == 5000
+	})).Run(func(args mock.Arguments) {
+		for i, frame := range args.Get(1).([]*entity.Frame) {
+			frame.ID = uint64(i + 1)
+		}
+	}).Return(nil)
+
+	body, _ := json.Marshal(AddFramesRequest{Frames: []FrameRequest{
+		{FileName: "a-1.jpg", Width: 1280, Height: 720, IsPrimary: true},
+		{FileName: "a-2.jpg", TimestampMs: 5000, Width: 1280, Height: 720},
+	}})
+	req, _ := http.NewRequest(http.MethodPost, "/videos/1/frames", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var resp response.FramesResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Len(t, resp.Frames, 2)
+	assert.Equal(t, uint64(2), resp.Frames[1].ID)
+	mockService.AssertExpectations(t)
+}
+
+func TestAddFrames_MissingSize(t *testing.T) {
+	mockService := new(service.MockFrameService)
+	router := setupFrameRouter(mockService)
+
+	req, _ := http.NewRequest(http.MethodPost, "/videos/1/frames", bytes.NewBufferString(`{"frames":[{"file_name":"a.jpg"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockService.AssertNotCalled(t, "AddFrames", mock.Anything, mock.Anything)
+}
+
+func TestAddFrames_Errors(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{fmt.Errorf("%w: only one frame can be primary", service.ErrInvalidFrame), http.StatusBadRequest},
+		{fmt.Errorf("%w: a.jpg", service.ErrFrameNotUploaded), http.StatusConflict},
+		{service.ErrVideoNotFound, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		mockService := new(service.MockFrameService)
+		router := setupFrameRouter(mockService)
+		mockService.On("AddFrames", uint64(1), mock.Anything).Return(tt.err)
+
+		body, _ := json.Marshal(AddFramesRequest{Frames: []FrameRequest{{FileName: "a.jpg", Width: 1, Height: 1}}})
+		req, _ := http.NewRequest(http.MethodPost, "/videos/1/frames", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, tt.code, rr.Code, tt.err.Error())
+	}
+}
+
+func TestListFrames_Success(t *testing.T) {
+	mockService := new(service.MockFrameService)
+	router := setupFrameRouter(mockService)
+
+	mockService.On("ListFrames", uint64(1)).Return([]entity.Frame{{ID: 1, VideoID: 1, Link: "https://example.com/a-1.jpg"}}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/videos/1/frames", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp response.FramesResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "https://example.com/a-1.jpg", resp.Frames[0].Link)
+}
+
+func TestSetPrimaryFrame_Success(t *testing.T) {
+	mockService := new(service.MockFrameService)
+	router := setupFrameRouter(mockService)
+
+	mockService.On("SetPrimaryFrame", uint64(1), uint64(3)).Return(&entity.Frame{ID: 3, VideoID: 1, IsPrimary: true}, nil)
+
+	req, _ := http.NewRequest(http.MethodPut, "/videos/1/frames/3/primary", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp response.FrameResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.True(t, resp.Frame.IsPrimary)
+}
+
+func TestSetPrimaryFrame_NotFound(t *testing.T) {
+	mockService := new(service.MockFrameService)
+	router := setupFrameRouter(mockService)
+
+	mockService.On("SetPrimaryFrame", uint64(1), uint64(3)).Return(nil, service.ErrFrameNotFound)
+
+	req, _ := http.NewRequest(http.MethodPut, "/videos/1/frames/3/primary", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestDeleteFrame_Success(t *testing.T) {
+	mockService := new(service.MockFrameService)
+	router := setupFrameRouter(mockService)
+
+	mockService.On("DeleteFrame", uint64(1), uint64(3)).Return(nil)
+
+	req, _ := http.NewRequest(http.MethodDelete, "/videos/1/frames/3", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestDeleteFrame_InvalidID(t *testing.T) {
+	mockService := new(service.MockFrameService)
+	router := setupFrameRouter(mockService)
+
+	req, _ := http.NewRequest(http.MethodDelete, "/videos/1/frames/abc", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
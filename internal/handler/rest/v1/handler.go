@@ -5,8 +5,19 @@ import "github.com/google/wire"
 // ProviderSetHandler is Handler providers.
 var ProviderSetHandler = wire.NewSet(
 	NewUserController,
+	NewTwoFactorController,
+	NewOIDCController,
+	NewAPIKeyController,
+	NewJWKSController,
 	NewVideoController,
 	NewAudioController,
 	NewTranscriptionController,
 	NewMoMoPaymentHandler,
+	NewBillingController,
+	NewAdminController,
+	NewTranslationController,
+	NewSearchController,
+	NewUploadController,
+	NewFrameController,
+	NewMediaController,
 )
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http"
+
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSController struct {
+	authService service.AuthServiceInterface
+}
+
+func NewJWKSController(authService service.AuthServiceInterface) *JWKSController {
+	return &JWKSController{authService: authService}
+}
+
+// GetJWKS godoc
+// @Summary Public keys of access tokens
+// @Description Returns the JSON Web Key set other services verify access tokens with. Tokens name their key in the kid header;
+// @Description keys replaced by a rotation stay listed until the tokens they signed have expired.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} jwtkeys.JWKS
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSController) GetJWKS(c *gin.Context) {
+	// Short enough that verifiers pick up a rotated key well before the old one is pruned
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.authService.JWKS())
+}
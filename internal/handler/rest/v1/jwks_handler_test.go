@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mlvt/internal/infra/jwtkeys"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetJWKS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(service.MockAuthService)
+	controller := NewJWKSController(mockService)
+	router := gin.New()
+	router.GET("/.well-known/jwks.json", controller.GetJWKS)
+
+	set := jwtkeys.JWKS{Keys: []jwtkeys.JWK{{KeyType: "OKP", KeyID: "20261016T090000-0a1b2c3d", Use: "sig",
+		Algorithm: jwtkeys.AlgEdDSA, Curve: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}}}
+	mockService.On("JWKS").Return(set)
+
+	req, _ := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "public, max-age=300", rr.Header().Get("Cache-Control"))
+	var resp map[string][]map[string]string
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	if assert.Len(t, resp["keys"], 1) {
+		key := resp["keys"][0]
+		assert.Equal(t, "OKP", key["kty"])
+		assert.Equal(t, "20261016T090000-0a1b2c3d", key["kid"])
+		assert.Equal(t, "EdDSA", key["alg"])
+		assert.Equal(t, "Ed25519", key["crv"])
+		assert.NotContains(t, key, "n")
+	}
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bindListOptions reads the paging, sorting and filtering query parameters shared by list endpoints.
+// It responds with 400 and returns false when a parameter is malformed.
+func bindListOptions(c *gin.Context) (entity.ListOptions, bool) {
+	opts := entity.ListOptions{
+		Cursor: c.Query("cursor"),
+		SortBy: c.Query("sort"),
+		Order:  c.Query("order"),
+		Status: c.Query("status"),
+		Lang:   c.Query("lang"),
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value <= 0 {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid limit"})
+			return opts, false
+		}
+		opts.Limit = value
+	}
+
+	var ok bool
+	if opts.CreatedAfter, ok = parseDateQuery(c, "created_after"); !ok {
+		return opts, false
+	}
+	if opts.CreatedBefore, ok = parseDateQuery(c, "created_before"); !ok {
+		return opts, false
+	}
+	return opts, true
+}
+
+// parseDateQuery parses an optional RFC 3339 timestamp or YYYY-MM-DD date query parameter
+func parseDateQuery(c *gin.Context, name string) (*time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, true
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return &t, true
+	}
+	c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid " + name})
+	return nil, false
+}
+
+// respondListError maps errors returned by list methods to HTTP responses
+func respondListError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidListOptions) {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+}
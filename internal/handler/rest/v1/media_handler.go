@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"mlvt/internal/infra/zap-logging/log"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MediaController struct {
+	probeService service.MediaProbeService
+}
+
+func NewMediaController(probeService service.MediaProbeService) *MediaController {
+	return &MediaController{probeService: probeService}
+}
+
+// ProbeVideo godoc
+// @Summary Measure a video file
+// @Description Inspects the uploaded file of a video and stores its duration, codecs, resolution, bitrate,
+// @Description audio channels and sample rate, replacing the values supplied when the video was created.
+// @Tags Videos
+// @Produce json
+// @Param video_id path uint64 true "ID of the video"
+// @Success 200 {object} response.VideoResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse "The file has not been uploaded"
+// @Failure 422 {object} response.ErrorResponse "The file is not a readable video"
+// @Failure 503 {object} response.ErrorResponse "Media probing is not available"
+// @Failure 500 {object} response.ErrorResponse
+// @Router /videos/{video_id}/probe [post]
+func (h *MediaController) ProbeVideo(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("video_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid video ID"})
+		return
+	}
+
+	video, err := h.probeService.ProbeVideo(c.Request.Context(), videoID)
+	if err != nil {
+		respondProbeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.VideoResponse{Video: *video})
+}
+
+// ProbeAudio godoc
+// @Summary Measure an audio file
+// @Description Inspects the uploaded file of an audio and stores its duration, codec, bitrate, channels and
+// @Description sample rate, replacing the values supplied when the audio was created.
+// @Tags audios
+// @Produce json
+// @Param audio_id path uint64 true "ID of the audio"
+// @Success 200 {object} response.ProbedAudioResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse "The file has not been uploaded"
+// @Failure 422 {object} response.ErrorResponse "The file is not a readable audio file"
+// @Failure 503 {object} response.ErrorResponse "Media probing is not available"
+// @Failure 500 {object} response.ErrorResponse
+// @Router /audios/{audio_id}/probe [post]
+func (h *MediaController) ProbeAudio(c *gin.Context) {
+	audioID, err := strconv.ParseUint(c.Param("audio_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid audio ID"})
+		return
+	}
+
+	audio, err := h.probeService.ProbeAudio(c.Request.Context(), audioID)
+	if err != nil {
+		respondProbeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.ProbedAudioResponse{Audio: *audio})
+}
+
+func respondProbeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrVideoNotFound), errors.Is(err, service.ErrAudioNotFound):
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrMediaNotUploaded):
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrUnreadableMedia):
+		c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrMediaProbeUnavailable):
+		c.JSON(http.StatusServiceUnavailable, response.ErrorResponse{Error: err.Error()})
+	default:
+		log.Errorf("Media probe failed: %v", err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+	}
+}
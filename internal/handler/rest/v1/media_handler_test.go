@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupMediaRouter(mockService *service.MockMediaProbeService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	controller := NewMediaController(mockService)
+
+	router := gin.New()
+	router.POST("/videos/:video_id/probe", controller.ProbeVideo)
+	router.POST("/audios/:audio_id/probe", controller.ProbeAudio)
+	return router
+}
+
+func TestProbeVideo_Success(t *testing.T) {
+	mockService := new(service.MockMediaProbeService)
+	router := setupMediaRouter(mockService)
+
+	mockService.On("ProbeVideo", mock.Anything, uint64(1)).Return(&entity.Video{
+		ID:        1,
+		Duration:  91,
+		MediaInfo: entity.MediaInfo{VideoCodec: "h264", Width: 1920, Height: 1080},
+	}, nil)
+
+	req, _ := http.NewRequest(http.MethodPost, "/videos/1/probe", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp response.VideoResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, 91, resp.Video.Duration)
+	assert.Equal(t, "h264", resp.Video.VideoCodec)
+	assert.Equal(t, 1080, resp.Video.Height)
+}
+
+func TestProbeAudio_Success(t *testing.T) {
+	mockService := new(service.MockMediaProbeService)
+	router := setupMediaRouter(mockService)
+
+	mockService.On("ProbeAudio", mock.Anything, uint64(2)).Return(&entity.Audio{
+		ID:        2,
+		Duration:  42,
+		MediaInfo: entity.MediaInfo{AudioCodec: "mp3", SampleRate: 44100},
+	}, nil)
+
+	req, _ := http.NewRequest(http.MethodPost, "/audios/2/probe", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp response.ProbedAudioResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, 42, resp.Audio.Duration)
+	assert.Equal(t, 44100, resp.Audio.SampleRate)
+}
+
+func TestProbe_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		err    error
+		status int
+	}{
+		{"invalid video ID", "/videos/abc/probe", nil, http.StatusBadRequest},
+		{"video not found", "/videos/1/probe", service.ErrVideoNotFound, http.StatusNotFound},
+		{"audio not found", "/audios/1/probe", service.ErrAudioNotFound, http.StatusNotFound},
+		{"not uploaded", "/videos/1/probe", fmt.Errorf("%w: a.mp4", service.ErrMediaNotUploaded), http.StatusConflict},
+		{"unreadable", "/audios/1/probe", fmt.Errorf("%w: no audio stream", service.ErrUnreadableMedia), http.StatusUnprocessableEntity},
+		{"no prober", "/videos/1/probe", service.ErrMediaProbeUnavailable, http.StatusServiceUnavailable},
+		{"unexpected", "/videos/1/probe", fmt.Errorf("database locked"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(service.MockMediaProbeService)
+			router := setupMediaRouter(mockService)
+			mockService.On("ProbeVideo", mock.Anything, mock.Anything).Return(nil, tt.err)
+			mockService.On("ProbeAudio", mock.Anything, mock.Anything).Return(nil, tt.err)
+
+			req, _ := http.NewRequest(http.MethodPost, tt.path, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+		})
+	}
+}
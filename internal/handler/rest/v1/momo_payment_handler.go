@@ -1,6 +1,13 @@
 package handler
 
 import (
+	"encoding/json"
+	"errors"
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/momo"
+	"mlvt/internal/infra/zap-logging/log"
+	"mlvt/internal/pkg/middleware"
+	"mlvt/internal/pkg/response"
 	"mlvt/internal/service"
 	"net/http"
 
@@ -15,68 +22,198 @@ func NewMoMoPaymentHandler(momoPaymentService service.MoMoPaymentService) *MoMoP
 	return &MoMoPaymentController{momoPaymentService: momoPaymentService}
 }
 
+// CreateMoMoPayment godoc
+// @Summary Create a MoMo payment
+// @Description Creates a payment of a pending order (see /billing) and returns a QR code the MoMo app scans to pay.
+// @Description The X-MoMo-Pay-URL header holds the MoMo web page to pay on instead. An order can have one payment.
+// @Tags payments
+// @Accept json
+// @Produce png
+// @Param payment body object{order_id=string} true "Order ID"
+// @Success 200 {file} binary "QR code"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "order not found"
+// @Failure 409 {object} response.ErrorResponse "order already paid for or not awaiting payment"
+// @Failure 502 {object} response.ErrorResponse "MoMo payment gateway error"
+// @Failure 503 {object} response.ErrorResponse "MoMo payments are not configured"
+// @Router /payments/momo/create [post]
 func (p *MoMoPaymentController) CreateMoMoPayment(c *gin.Context) {
 	var request struct {
-		OrderID string `json:"order_id"`
-		Amount  string `json:"amount"`
+		OrderID string `json:"order_id" binding:"required"`
 	}
-
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid request"})
 		return
 	}
 
-	// Generate QR code for the payment
-	qrCode, err := p.momoPaymentService.GeneratePaymentQRCode(request.OrderID, request.Amount)
+	payment, qrCode, err := p.momoPaymentService.GeneratePaymentQRCode(c.Request.Context(), middleware.CurrentUser(c).ID, request.OrderID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate QR code"})
+		respondPaymentError(c, err)
 		return
 	}
 
 	// Send back the QR code as an image
-	c.Header("Content-Type", "image/png")
-	c.Writer.Write(qrCode)
+	c.Header("X-MoMo-Pay-URL", payment.PayURL)
+	c.Data(http.StatusOK, "image/png", qrCode)
 }
 
+// CheckMoMoStatus godoc
+// @Summary Check the status of a MoMo payment
+// @Description Asks MoMo for the result of a pending payment, in case its notification has not arrived
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param payment body object{order_id=string} true "Order ID"
+// @Success 200 {object} response.MoMoPaymentResponse
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 403 {object} response.ErrorResponse "another user's payment"
+// @Failure 404 {object} response.ErrorResponse "payment not found"
+// @Failure 502 {object} response.ErrorResponse "MoMo payment gateway error"
+// @Router /payments/momo/check-status [post]
 func (p *MoMoPaymentController) CheckMoMoStatus(c *gin.Context) {
 	var request struct {
-		OrderID string `json:"order_id"`
+		OrderID string `json:"order_id" binding:"required"`
 	}
-
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid request"})
+		return
+	}
+	if !p.authorizePayment(c, request.OrderID) {
 		return
 	}
 
-	success, err := p.momoPaymentService.CheckPaymentStatus(request.OrderID)
+	payment, err := p.momoPaymentService.CheckPaymentStatus(c.Request.Context(), request.OrderID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check payment status"})
+		respondPaymentError(c, err)
 		return
 	}
 
-	if success {
-		c.JSON(http.StatusOK, gin.H{"message": "Payment successful"})
-	} else {
-		c.JSON(http.StatusOK, gin.H{"message": "Payment not completed"})
+	message := "Payment not completed"
+	if payment.Status == entity.PaymentStatusPaid || payment.Status == entity.PaymentStatusRefunded {
+		message = "Payment successful"
 	}
+	c.JSON(http.StatusOK, response.MoMoPaymentResponse{Message: message, Payment: *payment})
 }
 
+// RefundMoMoPayment godoc
+// @Summary Refund a MoMo payment
+// @Description Returns amount VND of a paid payment to the user's MoMo wallet; without an amount, all that is left is refunded.
+// @Description The minutes the amount paid for are taken back, so minutes already spent cannot be refunded. Requires the payments:refund permission.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param payment body object{order_id=string,amount=int} true "Order ID and optional amount in VND"
+// @Success 200 {object} response.MoMoPaymentResponse
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 403 {object} response.ErrorResponse "not an admin"
+// @Failure 404 {object} response.ErrorResponse "payment not found"
+// @Failure 409 {object} response.ErrorResponse "payment cannot be refunded, or its minutes were spent"
+// @Failure 502 {object} response.ErrorResponse "MoMo payment gateway error"
+// @Router /payments/momo/refund [post]
 func (p *MoMoPaymentController) RefundMoMoPayment(c *gin.Context) {
 	var request struct {
-		OrderID string `json:"order_id"`
-		Amount  string `json:"amount"`
+		OrderID string      `json:"order_id" binding:"required"`
+		Amount  json.Number `json:"amount"`
 	}
-
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid request"})
+		return
+	}
+	var amount int64
+	if request.Amount != "" {
+		var err error
+		if amount, err = request.Amount.Int64(); err != nil || amount <= 0 {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "amount must be a positive whole number of VND"})
+			return
+		}
+	}
+	payment, err := p.momoPaymentService.RefundPayment(c.Request.Context(), request.OrderID, amount)
+	if err != nil {
+		respondPaymentError(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, response.MoMoPaymentResponse{Message: "Refund successful", Payment: *payment})
+}
 
-	refundResponse, err := p.momoPaymentService.RefundPayment(request.OrderID, request.Amount)
+// MoMoIPN godoc
+// @Summary Receive a MoMo payment notification
+// @Description Called by MoMo when a payment completes. The signature is checked, and repeated notifications are acknowledged without effect.
+// @Tags payments
+// @Accept json
+// @Param notification body momo.IPN true "Instant payment notification"
+// @Success 204
+// @Failure 400 {object} response.ErrorResponse "invalid MoMo notification"
+// @Failure 404 {object} response.ErrorResponse "payment not found"
+// @Router /payments/momo/ipn [post]
+func (p *MoMoPaymentController) MoMoIPN(c *gin.Context) {
+	var ipn momo.IPN
+	if err := c.ShouldBindJSON(&ipn); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid request"})
+		return
+	}
+	if err := p.momoPaymentService.HandleIPN(c.Request.Context(), &ipn); err != nil {
+		if errors.Is(err, service.ErrInvalidIPN) {
+			log.Warnf("Rejected MoMo notification for order %s from %s: %v", ipn.OrderID, c.ClientIP(), err)
+		}
+		respondPaymentError(c, err)
+		return
+	}
+	// MoMo expects 204 No Content once a notification is processed
+	c.Status(http.StatusNoContent)
+}
+
+// ListPaymentEvents godoc
+// @Summary List the events of a payment
+// @Description Returns every request sent to MoMo about the order and every notification received for it, oldest first,
+// @Description with the payloads exchanged (signatures redacted). Requires the payments:view permission.
+// @Tags admin
+// @Produce json
+// @Param order_id path string true "Order ID"
+// @Success 200 {object} response.PaymentEventsResponse
+// @Failure 403 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "no events for the order"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /admin/payments/{order_id}/events [get]
+func (p *MoMoPaymentController) ListPaymentEvents(c *gin.Context) {
+	events, err := p.momoPaymentService.ListPaymentEvents(c.Param("order_id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Refund failed"})
+		respondPaymentError(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, response.PaymentEventsResponse{Events: events})
+}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Refund successful", "data": refundResponse})
+// authorizePayment allows the request only for the owner of the payment or an admin
+func (p *MoMoPaymentController) authorizePayment(c *gin.Context, orderID string) bool {
+	payment, err := p.momoPaymentService.GetPayment(orderID)
+	if err != nil {
+		respondPaymentError(c, err)
+		return false
+	}
+	if !middleware.CanAccess(middleware.CurrentUser(c), payment.UserID) {
+		c.JSON(http.StatusForbidden, response.ErrorResponse{Error: "Forbidden"})
+		return false
+	}
+	return true
+}
+
+// respondPaymentError maps payment service errors to HTTP responses
+func respondPaymentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidPaymentRequest), errors.Is(err, service.ErrInvalidIPN):
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrPaymentNotFound), errors.Is(err, service.ErrOrderNotFound):
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrDuplicateOrder), errors.Is(err, service.ErrPaymentNotRefundable), errors.Is(err, service.ErrOrderNotPayable),
+		errors.Is(err, service.ErrInsufficientCredits):
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrPaymentGateway):
+		log.Errorf("MoMo request failed: %v", err)
+		c.JSON(http.StatusBadGateway, response.ErrorResponse{Error: service.ErrPaymentGateway.Error()})
+	case errors.Is(err, service.ErrPaymentsDisabled):
+		c.JSON(http.StatusServiceUnavailable, response.ErrorResponse{Error: err.Error()})
+	default:
+		log.Errorf("Payment request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+	}
 }
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/momo"
+	"mlvt/internal/pkg/middleware"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupMoMoPaymentRouter(mockService *service.MockMoMoPaymentService, user *entity.User) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	controller := NewMoMoPaymentHandler(mockService)
+
+	router := gin.New()
+	router.POST("/payments/momo/ipn", controller.MoMoIPN)
+	authed := router.Group("/payments/momo")
+	authed.Use(func(c *gin.Context) {
+		c.Set(middleware.UserInfoKey, user)
+		c.Next()
+	})
+	authed.POST("/create", controller.CreateMoMoPayment)
+	authed.POST("/check-status", controller.CheckMoMoStatus)
+	authed.POST("/refund", middleware.RequirePermission(middleware.PermissionRefundPayment), controller.RefundMoMoPayment)
+	authed.GET("/events/:order_id", controller.ListPaymentEvents)
+	return router
+}
+
+func postPaymentJSON(router *gin.Engine, path, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestCreateMoMoPayment(t *testing.T) {
+	mockService := new(service.MockMoMoPaymentService)
+	router := setupMoMoPaymentRouter(mockService, &entity.User{ID: 1, Role: entity.RoleUser})
+
+	payment := &entity.MoMoPayment{UserID: 1, OrderID: "order-1", Amount: 50000, PayURL: "https://momo.example/pay"}
+	mockService.On("GeneratePaymentQRCode", mock.Anything, uint64(1), "order-1").Return(payment, []byte("png"), nil)
+	mockService.On("GeneratePaymentQRCode", mock.Anything, uint64(1), "order-2").Return(nil, nil, service.ErrOrderNotFound)
+	mockService.On("GeneratePaymentQRCode", mock.Anything, uint64(1), "order-3").Return(nil, nil, service.ErrOrderNotPayable)
+
+	rr := postPaymentJSON(router, "/payments/momo/create", `{"order_id":"order-1"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "image/png", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "https://momo.example/pay", rr.Header().Get("X-MoMo-Pay-URL"))
+	assert.Equal(t, "png", rr.Body.String())
+
+	for body, status := range map[string]int{
+		`{"order_id":"order-2"}`: http.StatusNotFound,
+		`{"order_id":"order-3"}`: http.StatusConflict,
+		`{"amount":50000}`:       http.StatusBadRequest,
+	} {
+		assert.Equal(t, status, postPaymentJSON(router, "/payments/momo/create", body).Code, body)
+	}
+}
+
+func TestCheckMoMoStatus(t *testing.T) {
+	mockService := new(service.MockMoMoPaymentService)
+	router := setupMoMoPaymentRouter(mockService, &entity.User{ID: 1, Role: entity.RoleUser})
+
+	paid := &entity.MoMoPayment{UserID: 1, OrderID: "order-1", Amount: 50000, Status: entity.PaymentStatusPaid}
+	mockService.On("GetPayment", "order-1").Return(paid, nil)
+	mockService.On("CheckPaymentStatus", mock.Anything, "order-1").Return(paid, nil)
+	mockService.On("GetPayment", "order-2").Return(&entity.MoMoPayment{UserID: 2, OrderID: "order-2"}, nil)
+	mockService.On("GetPayment", "order-3").Return(nil, service.ErrPaymentNotFound)
+
+	rr := postPaymentJSON(router, "/payments/momo/check-status", `{"order_id":"order-1"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp response.MoMoPaymentResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "Payment successful", resp.Message)
+	assert.Equal(t, entity.PaymentStatusPaid, resp.Payment.Status)
+
+	assert.Equal(t, http.StatusForbidden, postPaymentJSON(router, "/payments/momo/check-status", `{"order_id":"order-2"}`).Code)
+	assert.Equal(t, http.StatusNotFound, postPaymentJSON(router, "/payments/momo/check-status", `{"order_id":"order-3"}`).Code)
+	mockService.AssertNotCalled(t, "CheckPaymentStatus", mock.Anything, "order-2")
+}
+
+func TestRefundMoMoPayment(t *testing.T) {
+	mockService := new(service.MockMoMoPaymentService)
+	router := setupMoMoPaymentRouter(mockService, &entity.User{ID: 9, Role: entity.RoleAdmin})
+
+	mockService.On("RefundPayment", mock.Anything, "order-1", int64(0)).
+		Return(&entity.MoMoPayment{UserID: 1, OrderID: "order-1", Amount: 50000, RefundedAmount: 50000, Status: entity.PaymentStatusRefunded}, nil)
+	mockService.On("RefundPayment", mock.Anything, "order-1", int64(20000)).Return(nil, service.ErrPaymentNotRefundable)
+	mockService.On("RefundPayment", mock.Anything, "order-2", int64(0)).Return(nil, service.ErrInsufficientCredits)
+
+	rr := postPaymentJSON(router, "/payments/momo/refund", `{"order_id":"order-1"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp response.MoMoPaymentResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, int64(50000), resp.Payment.RefundedAmount)
+
+	assert.Equal(t, http.StatusConflict, postPaymentJSON(router, "/payments/momo/refund", `{"order_id":"order-1","amount":20000}`).Code)
+	assert.Equal(t, http.StatusBadRequest, postPaymentJSON(router, "/payments/momo/refund", `{"order_id":"order-1","amount":-5}`).Code)
+	assert.Equal(t, http.StatusConflict, postPaymentJSON(router, "/payments/momo/refund", `{"order_id":"order-2"}`).Code)
+
+	// Owners cannot refund their own payments
+	owner := setupMoMoPaymentRouter(mockService, &entity.User{ID: 1, Role: entity.RoleUser})
+	assert.Equal(t, http.StatusForbidden, postPaymentJSON(owner, "/payments/momo/refund", `{"order_id":"order-1"}`).Code)
+	mockService.AssertNumberOfCalls(t, "RefundPayment", 3)
+}
+
+func TestMoMoIPN(t *testing.T) {
+	mockService := new(service.MockMoMoPaymentService)
+	router := setupMoMoPaymentRouter(mockService, nil)
+
+	mockService.On("HandleIPN", mock.Anything, mock.MatchedBy(func(ipn *momo.IPN) bool {
+		return ipn.OrderID == "order-1"
+	})).Return(nil)
+	mockService.On("HandleIPN", mock.Anything, mock.MatchedBy(func(ipn *momo.IPN) bool {
+		return ipn.OrderID == "order-2"
+	})).Return(service.ErrInvalidIPN)
+
+	rr := postPaymentJSON(router, "/payments/momo/ipn", `{"partnerCode":"MOMO","orderId":"order-1","amount":50000,"resultCode":0,"signature":"abc"}`)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = postPaymentJSON(router, "/payments/momo/ipn", `{"partnerCode":"MOMO","orderId":"order-2","amount":50000,"resultCode":0,"signature":"bad"}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestListPaymentEvents(t *testing.T) {
+	mockService := new(service.MockMoMoPaymentService)
+	router := setupMoMoPaymentRouter(mockService, &entity.User{ID: 1, Role: entity.RoleAdmin})
+
+	mockService.On("ListPaymentEvents", "order-1").Return([]entity.TransactionLog{
+		{ID: 1, OrderID: "order-1", PaymentMethod: entity.PaymentMethodMoMo, Action: entity.TransactionActionCreate, Status: entity.TransactionStatusSucceeded, Details: `{"path":"/v2/gateway/api/create"}`},
+		{ID: 2, OrderID: "order-1", PaymentMethod: entity.PaymentMethodMoMo, Action: entity.TransactionActionIPN, Status: entity.TransactionStatusSucceeded},
+	}, nil)
+	mockService.On("ListPaymentEvents", "order-2").Return(nil, service.ErrPaymentNotFound)
+
+	req, _ := http.NewRequest(http.MethodGet, "/payments/momo/events/order-1", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp response.PaymentEventsResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	if assert.Len(t, resp.Events, 2) {
+		assert.Equal(t, entity.TransactionActionIPN, resp.Events[1].Action)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "/payments/momo/events/order-2", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"mlvt/internal/infra/zap-logging/log"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCController struct {
+	oidcService service.OIDCService
+}
+
+func NewOIDCController(oidcService service.OIDCService) *OIDCController {
+	return &OIDCController{oidcService: oidcService}
+}
+
+// ListOIDCProviders godoc
+// @Summary List identity providers
+// @Description Lists the external identity providers (Google, GitHub or OpenID Connect issuers) users can sign in with
+// @Tags users
+// @Produce json
+// @Success 200 {object} response.OIDCProvidersResponse
+// @Router /users/oidc/providers [get]
+func (h *OIDCController) ListOIDCProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, response.OIDCProvidersResponse{Providers: h.oidcService.Providers()})
+}
+
+// AuthorizeOIDC godoc
+// @Summary Start sign-in with an identity provider
+// @Description Returns the provider page to send the user to. The provider redirects to the configured redirect URL
+// @Description with a code and a state; the app should check the state is the one in this URL before passing both to the callback.
+// @Tags users
+// @Produce json
+// @Param provider path string true "Identity provider name"
+// @Success 200 {object} response.OIDCAuthorizationResponse
+// @Failure 404 {object} response.ErrorResponse "unknown identity provider"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /users/oidc/{provider}/authorize [get]
+func (h *OIDCController) AuthorizeOIDC(c *gin.Context) {
+	authURL, err := h.oidcService.AuthorizationURL(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.OIDCAuthorizationResponse{AuthorizationURL: authURL})
+}
+
+// OIDCCallback godoc
+// @Summary Complete sign-in with an identity provider
+// @Description Redeems the code the provider returned and signs in the user linked to the provider account. New accounts
+// @Description are linked to the user with the same email address, or to a new user, if the provider has verified the address.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param provider path string true "Identity provider name"
+// @Param body body object true "Code and state from the redirect"
+// @Success 200 {object} response.TokenResponse "token, or response.TwoFactorChallengeResponse when two-factor authentication is on"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 401 {object} response.ErrorResponse "error"
+// @Failure 403 {object} response.ErrorResponse "account is suspended or deleted"
+// @Failure 404 {object} response.ErrorResponse "unknown identity provider"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /users/oidc/{provider}/callback [post]
+func (h *OIDCController) OIDCCallback(c *gin.Context) {
+	var request struct {
+		Code  string `json:"code" binding:"required"`
+		State string `json:"state" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid input"})
+		return
+	}
+
+	tokens, err := h.oidcService.Login(c.Request.Context(), c.Param("provider"), request.Code, request.State)
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+	respondLoginTokens(c, tokens)
+}
+
+// respondOIDCError maps OIDC service errors to HTTP responses
+func respondOIDCError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUnknownIdentityProvider):
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrInvalidOIDCState), errors.Is(err, service.ErrOIDCLoginFailed),
+		errors.Is(err, service.ErrUnverifiedOIDCEmail):
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrAccountDisabled):
+		c.JSON(http.StatusForbidden, response.ErrorResponse{Error: err.Error()})
+	default:
+		log.Errorf("identity provider sign-in failed: %v", err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+	}
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupOIDCRouter(mockService *service.MockOIDCService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	controller := NewOIDCController(mockService)
+
+	router := gin.New()
+	router.GET("/users/oidc/providers", controller.ListOIDCProviders)
+	router.GET("/users/oidc/:provider/authorize", controller.AuthorizeOIDC)
+	router.POST("/users/oidc/:provider/callback", controller.OIDCCallback)
+	return router
+}
+
+func TestListOIDCProviders(t *testing.T) {
+	mockService := new(service.MockOIDCService)
+	router := setupOIDCRouter(mockService)
+
+	mockService.On("Providers").Return([]string{"github", "google"})
+
+	req, _ := http.NewRequest(http.MethodGet, "/users/oidc/providers", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp response.OIDCProvidersResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, []string{"github", "google"}, resp.Providers)
+}
+
+func TestAuthorizeOIDC(t *testing.T) {
+	mockService := new(service.MockOIDCService)
+	router := setupOIDCRouter(mockService)
+
+	mockService.On("AuthorizationURL", mock.Anything, "google").Return("https://accounts.google.com/o/oauth2/v2/auth?state=s", nil)
+	mockService.On("AuthorizationURL", mock.Anything, "other").Return("", service.ErrUnknownIdentityProvider)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users/oidc/google/authorize", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp response.OIDCAuthorizationResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "https://accounts.google.com/o/oauth2/v2/auth?state=s", resp.AuthorizationURL)
+
+	req, _ = http.NewRequest(http.MethodGet, "/users/oidc/other/authorize", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestOIDCCallback(t *testing.T) {
+	mockService := new(service.MockOIDCService)
+	router := setupOIDCRouter(mockService)
+
+	mockService.On("Login", mock.Anything, "google", "code", "state").Return(&entity.AuthTokens{
+		UserID:          3,
+		AccessToken:     "access",
+		AccessExpiresAt: time.Now().Add(time.Minute),
+		RefreshToken:    "refresh",
+	}, nil)
+	mockService.On("Login", mock.Anything, "google", "code", "stale").Return(nil, service.ErrInvalidOIDCState)
+	mockService.On("Login", mock.Anything, "google", "code", "suspended").Return(nil, service.ErrAccountDisabled)
+
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`{"code":"code","state":"state"}`, http.StatusOK},
+		{`{"code":"code","state":"stale"}`, http.StatusUnauthorized},
+		{`{"code":"code","state":"suspended"}`, http.StatusForbidden},
+		{`{"code":"code"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodPost, "/users/oidc/google/callback", bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, tt.status, rr.Code, tt.body)
+
+		if tt.status == http.StatusOK {
+			var resp response.TokenResponse
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, "access", resp.Token)
+			assert.Equal(t, uint64(3), resp.UserID)
+		}
+	}
+}
+
+func TestOIDCCallback_TwoFactorRequired(t *testing.T) {
+	mockService := new(service.MockOIDCService)
+	router := setupOIDCRouter(mockService)
+
+	mockService.On("Login", mock.Anything, "github", "code", "state").Return(&entity.AuthTokens{
+		UserID:             3,
+		ChallengeToken:     "challenge",
+		ChallengeExpiresAt: time.Now().Add(time.Minute),
+	}, nil)
+
+	req, _ := http.NewRequest(http.MethodPost, "/users/oidc/github/callback", bytes.NewBufferString(`{"code":"code","state":"state"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp response.TwoFactorChallengeResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.True(t, resp.TwoFactorRequired)
+	assert.Equal(t, "challenge", resp.ChallengeToken)
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"mlvt/internal/pkg/middleware"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchController struct {
+	searchService service.SearchService
+}
+
+func NewSearchController(searchService service.SearchService) *SearchController {
+	return &SearchController{searchService: searchService}
+}
+
+// Search godoc
+// @Summary Search videos and transcriptions
+// @Description Full-text search over the caller's video titles, video descriptions and transcription text. Every word must match; the last word also matches as a prefix. Snippets are HTML-escaped with matches wrapped in <mark> tags.
+// @Tags search
+// @Produce json
+// @Param q query string true "Search text"
+// @Param lang query string false "Only transcriptions in this language"
+// @Param limit query int false "Maximum number of results (default 20, max 100)"
+// @Param offset query int false "Number of results to skip"
+// @Param user_id query uint64 false "Search another user's content (admin only)"
+// @Success 200 {object} response.SearchResponse "results"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 401 {object} response.ErrorResponse "error"
+// @Failure 403 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /search [get]
+func (h *SearchController) Search(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	userID := user.ID
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		id, err := strconv.ParseUint(userIDStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid user ID"})
+			return
+		}
+		if !middleware.CanAccess(user, id) {
+			c.JSON(http.StatusForbidden, response.ErrorResponse{Error: "Forbidden"})
+			return
+		}
+		userID = id
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid limit"})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid offset"})
+		return
+	}
+
+	results, err := h.searchService.Search(userID, c.Query("q"), c.Query("lang"), limit, offset)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSearchQuery) {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.SearchResponse{Results: results})
+}
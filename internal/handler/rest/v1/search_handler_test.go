@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/pkg/middleware"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupSearchRouter(mockService *service.MockSearchService, user *entity.User) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	controller := NewSearchController(mockService)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(middleware.UserInfoKey, user)
+		c.Next()
+	})
+	router.GET("/search", controller.Search)
+	return router
+}
+
+func TestSearch_Success(t *testing.T) {
+	mockService := new(service.MockSearchService)
+	router := setupSearchRouter(mockService, &entity.User{ID: 1, Role: entity.RoleUser})
+
+	mockService.On("Search", uint64(1), "lake", "en", 5, 10).Return([]entity.SearchResult{
+		{Kind: entity.SearchKindTranscription, ID: 4, VideoID: 2, Snippet: "by the <mark>lake</mark>"},
+	}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/search?q=lake&lang=en&limit=5&offset=10", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp response.SearchResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Len(t, resp.Results, 1)
+	mockService.AssertExpectations(t)
+}
+
+func TestSearch_OtherUser(t *testing.T) {
+	mockService := new(service.MockSearchService)
+
+	// Regular users cannot search someone else's content
+	router := setupSearchRouter(mockService, &entity.User{ID: 1, Role: entity.RoleUser})
+	req, _ := http.NewRequest(http.MethodGet, "/search?q=lake&user_id=2", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	mockService.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	// Admins can
+	mockService.On("Search", uint64(2), "lake", "", 0, 0).Return([]entity.SearchResult{}, nil)
+	router = setupSearchRouter(mockService, &entity.User{ID: 1, Role: entity.RoleAdmin})
+	req, _ = http.NewRequest(http.MethodGet, "/search?q=lake&user_id=2", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestSearch_InvalidQuery(t *testing.T) {
+	mockService := new(service.MockSearchService)
+	router := setupSearchRouter(mockService, &entity.User{ID: 1, Role: entity.RoleUser})
+
+	mockService.On("Search", uint64(1), "", "", 0, 0).Return(nil, service.ErrInvalidSearchQuery)
+
+	req, _ := http.NewRequest(http.MethodGet, "/search", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	req, _ = http.NewRequest(http.MethodGet, "/search?q=lake&limit=abc", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -1,16 +1,37 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"mlvt/internal/entity"
 	"mlvt/internal/infra/env"
 	"mlvt/internal/pkg/response"
 	"mlvt/internal/service"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+// maxSubtitleUploadSize caps the size of an imported subtitle file
+const maxSubtitleUploadSize = 5 << 20
+
+// TranscriptionSegmentRequest is one timed segment in a segments request body
+type TranscriptionSegmentRequest struct {
+	StartMs int64  `json:"start_ms"`
+	EndMs   int64  `json:"end_ms"`
+	Speaker string `json:"speaker"`
+	Text    string `json:"text"`
+}
+
+// TranscriptionSegmentsRequest is the body of the create and replace segments endpoints
+type TranscriptionSegmentsRequest struct {
+	Segments []TranscriptionSegmentRequest `json:"segments" binding:"required"`
+}
+
 type TranscriptionController struct {
 	transcriptionService service.TranscriptionService
 }
@@ -133,14 +154,14 @@ func (h *TranscriptionController) GetTranscriptionByID(c *gin.Context) {
 // @Description Retrieves a transcription for a specific user and generates a presigned download URL.
 // @Tags transcriptions
 // @Produce json
-// @Param transcriptionID path uint64 true "ID of the transcription file"
-// @Param userID path uint64 true "ID of the user"
+// @Param transcription_id path uint64 true "ID of the transcription file"
+// @Param user_id path uint64 true "ID of the user"
 // @Success 200 {object} response.TranscriptionResponse "transcription, download_url"
 // @Failure 500 {object} response.ErrorResponse "error"
-// @Router /transcriptions/{transcriptionID}/user/{userID} [get]
+// @Router /transcriptions/{transcription_id}/user/{user_id} [get]
 func (h *TranscriptionController) GetTranscriptionByUserID(c *gin.Context) {
-	transcriptionIDStr := c.Param("transcriptionID")
-	userIDStr := c.Param("userID")
+	transcriptionIDStr := c.Param("transcription_id")
+	userIDStr := c.Param("user_id")
 
 	transcriptionID, err := strconv.ParseUint(transcriptionIDStr, 10, 64)
 	if err != nil {
@@ -171,14 +192,14 @@ func (h *TranscriptionController) GetTranscriptionByUserID(c *gin.Context) {
 // @Description Retrieves a transcription for a specific video and generates a presigned download URL.
 // @Tags transcriptions
 // @Produce json
-// @Param transcriptionID path uint64 true "ID of the transcription file"
-// @Param videoID path uint64 true "ID of the video"
+// @Param transcription_id path uint64 true "ID of the transcription file"
+// @Param video_id path uint64 true "ID of the video"
 // @Success 200 {object} response.TranscriptionResponse "transcription, download_url"
 // @Failure 500 {object} response.ErrorResponse "error"
-// @Router /transcriptions/{transcriptionID}/video/{videoID} [get]
+// @Router /transcriptions/{transcription_id}/video/{video_id} [get]
 func (h *TranscriptionController) GetTranscriptionByVideoID(c *gin.Context) {
-	transcriptionIDStr := c.Param("transcriptionID")
-	videoIDStr := c.Param("videoID")
+	transcriptionIDStr := c.Param("transcription_id")
+	videoIDStr := c.Param("video_id")
 
 	transcriptionID, err := strconv.ParseUint(transcriptionIDStr, 10, 64)
 	if err != nil {
@@ -206,10 +227,17 @@ func (h *TranscriptionController) GetTranscriptionByVideoID(c *gin.Context) {
 
 // ListTranscriptionsByUserID godoc
 // @Summary List transcriptions by User ID
-// @Description Retrieves all transcriptions belonging to a specific user.
+// @Description Retrieves a page of the transcriptions belonging to a specific user.
 // @Tags transcriptions
 // @Produce json
 // @Param user_id path uint64 true "ID of the user"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field: created_at (default created_at)"
+// @Param order query string false "asc or desc (default desc)"
+// @Param lang query string false "Only transcriptions in this language"
+// @Param created_after query string false "Only items created at or after this RFC 3339 time or date"
+// @Param created_before query string false "Only items created before this RFC 3339 time or date"
 // @Success 200 {object} response.TranscriptionsResponse "transcriptions"
 // @Failure 400 {object} response.ErrorResponse "error"
 // @Failure 500 {object} response.ErrorResponse "error"
@@ -222,21 +250,33 @@ func (h *TranscriptionController) ListTranscriptionsByUserID(c *gin.Context) {
 		return
 	}
 
-	transcriptions, err := h.transcriptionService.ListTranscriptionsByUserID(userID)
+	opts, ok := bindListOptions(c)
+	if !ok {
+		return
+	}
+
+	transcriptions, nextCursor, err := h.transcriptionService.ListTranscriptionsByUserID(userID, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+		respondListError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, response.TranscriptionsResponse{Transcriptions: transcriptions})
+	c.JSON(http.StatusOK, response.TranscriptionsResponse{Transcriptions: transcriptions, NextCursor: nextCursor})
 }
 
 // ListTranscriptionsByVideoID godoc
 // @Summary List transcriptions by Video ID
-// @Description Retrieves all transcriptions belonging to a specific video.
+// @Description Retrieves a page of the transcriptions belonging to a specific video.
 // @Tags transcriptions
 // @Produce json
 // @Param video_id path uint64 true "ID of the video"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field: created_at (default created_at)"
+// @Param order query string false "asc or desc (default desc)"
+// @Param lang query string false "Only transcriptions in this language"
+// @Param created_after query string false "Only items created at or after this RFC 3339 time or date"
+// @Param created_before query string false "Only items created before this RFC 3339 time or date"
 // @Success 200 {object} response.TranscriptionsResponse "transcriptions"
 // @Failure 400 {object} response.ErrorResponse "error"
 // @Failure 500 {object} response.ErrorResponse "error"
@@ -249,13 +289,18 @@ func (h *TranscriptionController) ListTranscriptionsByVideoID(c *gin.Context) {
 		return
 	}
 
-	transcriptions, err := h.transcriptionService.ListTranscriptionsByVideoID(videoID)
+	opts, ok := bindListOptions(c)
+	if !ok {
+		return
+	}
+
+	transcriptions, nextCursor, err := h.transcriptionService.ListTranscriptionsByVideoID(videoID, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+		respondListError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, response.TranscriptionsResponse{Transcriptions: transcriptions})
+	c.JSON(http.StatusOK, response.TranscriptionsResponse{Transcriptions: transcriptions, NextCursor: nextCursor})
 }
 
 // DeleteTranscription godoc
@@ -281,3 +326,224 @@ func (h *TranscriptionController) DeleteTranscription(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response.MessageResponse{Message: "Transcription deleted successfully"})
 }
+
+// ListSegments godoc
+// @Summary List transcription segments
+// @Description Retrieves the timed segments of a transcription in order.
+// @Tags transcriptions
+// @Produce json
+// @Param transcription_id path uint64 true "ID of the transcription"
+// @Success 200 {object} response.TranscriptionSegmentsResponse "segments"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /transcriptions/{transcription_id}/segments [get]
+func (h *TranscriptionController) ListSegments(c *gin.Context) {
+	transcriptionID, err := strconv.ParseUint(c.Param("transcription_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid transcription ID"})
+		return
+	}
+
+	segments, err := h.transcriptionService.ListSegments(transcriptionID)
+	if err != nil {
+		respondTranscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.TranscriptionSegmentsResponse{Segments: segments})
+}
+
+// CreateSegments godoc
+// @Summary Add transcription segments
+// @Description Appends timed segments after the existing segments of a transcription. The transcription text is rebuilt from all segments.
+// @Tags transcriptions
+// @Accept json
+// @Produce json
+// @Param transcription_id path uint64 true "ID of the transcription"
+// @Param segments body TranscriptionSegmentsRequest true "Segments to add"
+// @Success 201 {object} response.TranscriptionSegmentsResponse "segments"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /transcriptions/{transcription_id}/segments [post]
+func (h *TranscriptionController) CreateSegments(c *gin.Context) {
+	transcriptionID, segments, ok := bindSegments(c)
+	if !ok {
+		return
+	}
+
+	all, err := h.transcriptionService.AppendSegments(transcriptionID, segments)
+	if err != nil {
+		respondTranscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.TranscriptionSegmentsResponse{Segments: all})
+}
+
+// ReplaceSegments godoc
+// @Summary Replace transcription segments
+// @Description Replaces all timed segments of a transcription. The transcription text is rebuilt from the new segments.
+// @Tags transcriptions
+// @Accept json
+// @Produce json
+// @Param transcription_id path uint64 true "ID of the transcription"
+// @Param segments body TranscriptionSegmentsRequest true "New segments"
+// @Success 200 {object} response.TranscriptionSegmentsResponse "segments"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /transcriptions/{transcription_id}/segments [put]
+func (h *TranscriptionController) ReplaceSegments(c *gin.Context) {
+	transcriptionID, segments, ok := bindSegments(c)
+	if !ok {
+		return
+	}
+
+	all, err := h.transcriptionService.ReplaceSegments(transcriptionID, segments)
+	if err != nil {
+		respondTranscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.TranscriptionSegmentsResponse{Segments: all})
+}
+
+// ExportTranscription godoc
+// @Summary Export transcription
+// @Description Downloads the segments of a transcription as SubRip, WebVTT or JSON.
+// @Tags transcriptions
+// @Produce application/x-subrip,text/vtt,json
+// @Param transcription_id path uint64 true "ID of the transcription"
+// @Param format query string false "srt, vtt or json (default srt)"
+// @Success 200 {file} file "Subtitle file"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /transcriptions/{transcription_id}/export [get]
+func (h *TranscriptionController) ExportTranscription(c *gin.Context) {
+	transcriptionID, err := strconv.ParseUint(c.Param("transcription_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid transcription ID"})
+		return
+	}
+	format := strings.ToLower(c.DefaultQuery("format", service.TranscriptionFormatSRT))
+
+	content, contentType, err := h.transcriptionService.ExportTranscription(transcriptionID, format)
+	if err != nil {
+		respondTranscriptionError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="transcription-%d.%s"`, transcriptionID, format))
+	c.Data(http.StatusOK, contentType, content)
+}
+
+// ImportSubtitles godoc
+// @Summary Import subtitles
+// @Description Replaces the segments of a transcription with the cues of an SRT or WebVTT file.
+// @Description The file is sent as the multipart field "file" or as the raw request body. The format is taken from the format query parameter, or else from the file extension.
+// @Tags transcriptions
+// @Accept multipart/form-data,application/x-subrip,text/vtt
+// @Produce json
+// @Param transcription_id path uint64 true "ID of the transcription"
+// @Param format query string false "srt or vtt"
+// @Param file formData file false "Subtitle file"
+// @Success 200 {object} response.TranscriptionSegmentsResponse "segments"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "error"
+// @Failure 413 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /transcriptions/{transcription_id}/import [post]
+func (h *TranscriptionController) ImportSubtitles(c *gin.Context) {
+	transcriptionID, err := strconv.ParseUint(c.Param("transcription_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid transcription ID"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSubtitleUploadSize)
+	format := c.Query("format")
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			respondSubtitleUploadError(c, err)
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+			return
+		}
+		defer file.Close()
+		body = file
+		if format == "" {
+			format = strings.TrimPrefix(filepath.Ext(fileHeader.Filename), ".")
+		}
+	}
+	if format == "" {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "subtitle format is required"})
+		return
+	}
+
+	segments, err := h.transcriptionService.ImportSubtitles(transcriptionID, format, body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondSubtitleUploadError(c, err)
+			return
+		}
+		respondTranscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.TranscriptionSegmentsResponse{Segments: segments})
+}
+
+// bindSegments parses the transcription ID and the segments body, responding with 400 on bad input
+func bindSegments(c *gin.Context) (uint64, []entity.TranscriptionSegment, bool) {
+	transcriptionID, err := strconv.ParseUint(c.Param("transcription_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid transcription ID"})
+		return 0, nil, false
+	}
+
+	var req TranscriptionSegmentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return 0, nil, false
+	}
+
+	segments := make([]entity.TranscriptionSegment, 0, len(req.Segments))
+	for _, segment := range req.Segments {
+		segments = append(segments, entity.TranscriptionSegment{
+			StartMs: segment.StartMs,
+			EndMs:   segment.EndMs,
+			Speaker: segment.Speaker,
+			Text:    segment.Text,
+		})
+	}
+	return transcriptionID, segments, true
+}
+
+func respondSubtitleUploadError(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		c.JSON(http.StatusRequestEntityTooLarge, response.ErrorResponse{Error: "subtitle file is too large"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+}
+
+func respondTranscriptionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrTranscriptionNotFound):
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrInvalidSegment), errors.Is(err, service.ErrUnsupportedFormat):
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+	}
+}
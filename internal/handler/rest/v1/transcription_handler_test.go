@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupTranscriptionRouter(mockService *service.MockTranscriptionService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	controller := NewTranscriptionController(mockService)
+
+	router := gin.New()
+	router.GET("/transcriptions/:transcription_id/segments", controller.ListSegments)
+	router.POST("/transcriptions/:transcription_id/segments", controller.CreateSegments)
+	router.PUT("/transcriptions/:transcription_id/segments", controller.ReplaceSegments)
+	router.GET("/transcriptions/:transcription_id/export", controller.ExportTranscription)
+	router.POST("/transcriptions/:transcription_id/import", controller.ImportSubtitles)
+	return router
+}
+
+func TestReplaceSegments_Success(t *testing.T) {
+	mockService := new(service.MockTranscriptionService)
+	router := setupTranscriptionRouter(mockService)
+
+	segments := []entity.TranscriptionSegment{{StartMs: 0, EndMs: 1200, Speaker: "Alice", Text: "Hello"}}
+	mockService.On("ReplaceSegments", uint64(1), segments).Return(segments, nil)
+
+	req, _ := http.NewRequest(http.MethodPut, "/transcriptions/1/segments",
+		bytes.NewBufferString(`{"segments": [{"start_ms": 0, "end_ms": 1200, "speaker": "Alice", "text": "Hello"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp response.TranscriptionSegmentsResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "Hello", resp.Segments[0].Text)
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateSegments_Errors(t *testing.T) {
+	mockService := new(service.MockTranscriptionService)
+	router := setupTranscriptionRouter(mockService)
+
+	mockService.On("AppendSegments", uint64(1), mock.Anything).Return(nil, fmt.Errorf("%w: segment 0 ends before it starts", service.ErrInvalidSegment))
+	mockService.On("AppendSegments", uint64(2), mock.Anything).Return(nil, service.ErrTranscriptionNotFound)
+
+	for _, tc := range []struct {
+		path, body string
+		code       int
+	}{
+		{"/transcriptions/1/segments", `{"segments": [{"start_ms": 10, "end_ms": 5, "text": "x"}]}`, http.StatusBadRequest},
+		{"/transcriptions/2/segments", `{"segments": [{"start_ms": 0, "end_ms": 5, "text": "x"}]}`, http.StatusNotFound},
+		{"/transcriptions/1/segments", `{}`, http.StatusBadRequest},
+		{"/transcriptions/abc/segments", `{"segments": []}`, http.StatusBadRequest},
+	} {
+		req, _ := http.NewRequest(http.MethodPost, tc.path, bytes.NewBufferString(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, tc.code, rr.Code, tc.body)
+	}
+}
+
+func TestExportTranscription_Success(t *testing.T) {
+	mockService := new(service.MockTranscriptionService)
+	router := setupTranscriptionRouter(mockService)
+
+	content := []byte("WEBVTT\n\n00:00:00.000 --> 00:00:01.000\nHello\n\n")
+	mockService.On("ExportTranscription", uint64(1), "vtt").Return(content, "text/vtt; charset=utf-8", nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/transcriptions/1/export?format=VTT", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/vtt; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="transcription-1.vtt"`, rr.Header().Get("Content-Disposition"))
+	assert.Equal(t, content, rr.Body.Bytes())
+}
+
+func TestExportTranscription_UnsupportedFormat(t *testing.T) {
+	mockService := new(service.MockTranscriptionService)
+	router := setupTranscriptionRouter(mockService)
+
+	mockService.On("ExportTranscription", uint64(1), "docx").Return(nil, "", service.ErrUnsupportedFormat)
+
+	req, _ := http.NewRequest(http.MethodGet, "/transcriptions/1/export?format=docx", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestImportSubtitles_Multipart(t *testing.T) {
+	mockService := new(service.MockTranscriptionService)
+	router := setupTranscriptionRouter(mockService)
+
+	segments := []entity.TranscriptionSegment{{StartMs: 0, EndMs: 1000, Text: "Hello"}}
+	mockService.On("ImportSubtitles", uint64(1), "srt", mock.Anything).Return(segments, nil)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", "episode.srt")
+	part.Write([]byte("1\n00:00:00,000 --> 00:00:01,000\nHello\n"))
+	writer.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, "/transcriptions/1/import", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestImportSubtitles_RawBodyNeedsFormat(t *testing.T) {
+	mockService := new(service.MockTranscriptionService)
+	router := setupTranscriptionRouter(mockService)
+
+	req, _ := http.NewRequest(http.MethodPost, "/transcriptions/1/import", strings.NewReader("WEBVTT\n"))
+	req.Header.Set("Content-Type", "text/vtt")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockService.AssertNotCalled(t, "ImportSubtitles", mock.Anything, mock.Anything, mock.Anything)
+}
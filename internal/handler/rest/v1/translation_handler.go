@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TranslationController struct {
+	translationService service.TranslationService
+}
+
+func NewTranslationController(translationService service.TranslationService) *TranslationController {
+	return &TranslationController{translationService: translationService}
+}
+
+// CreateTranslationRequest represents the request body for requesting translations of a video
+type CreateTranslationRequest struct {
+	VideoID     uint64   `json:"video_id" binding:"required"`
+	UserID      uint64   `json:"user_id" binding:"required"`
+	SourceLang  string   `json:"source_lang" binding:"required"`
+	TargetLangs []string `json:"target_langs" binding:"required,min=1"`
+}
+
+// CreateTranslations godoc
+// @Summary Request translations
+// @Description Requests translations of a video into one or more target languages. One translation is created per language,
+// @Description and each costs the length of the video in minutes, rounded up, from the user's balance.
+// @Description The length is measured from the video file; a video that has not been probed yet is probed first.
+// @Tags translations
+// @Accept json
+// @Produce json
+// @Param request body CreateTranslationRequest true "Video, source language and target languages"
+// @Success 201 {object} response.TranslationsResponse "translations"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 402 {object} response.ErrorResponse "not enough translation minutes"
+// @Failure 404 {object} response.ErrorResponse "error"
+// @Failure 409 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Failure 503 {object} response.ErrorResponse "media probing is not available"
+// @Router /translations [post]
+func (h *TranslationController) CreateTranslations(c *gin.Context) {
+	var req CreateTranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid input"})
+		return
+	}
+
+	translations, err := h.translationService.RequestTranslations(req.UserID, req.VideoID, req.SourceLang, req.TargetLangs)
+	if err != nil {
+		respondTranslationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.TranslationsResponse{Translations: translations})
+}
+
+// GetTranslation godoc
+// @Summary Get translation by ID
+// @Description Retrieves a translation with its artifacts and, once available, a presigned download URL for the translated video.
+// @Tags translations
+// @Produce json
+// @Param translation_id path uint64 true "Translation ID"
+// @Success 200 {object} response.TranslationResponse "translation, download_url"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /translations/{translation_id} [get]
+func (h *TranslationController) GetTranslation(c *gin.Context) {
+	translationID, err := strconv.ParseUint(c.Param("translation_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid translation ID"})
+		return
+	}
+
+	translation, downloadURL, err := h.translationService.GetTranslationByID(translationID)
+	if err != nil {
+		respondTranslationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.TranslationResponse{Translation: *translation, DownloadURL: downloadURL})
+}
+
+// UpdateTranslation godoc
+// @Summary Attach translation artifacts
+// @Description Attaches the transcription, synthesized audio and output video of a translation and advances its status.
+// @Description Called by the processing pipeline; requires the translations:manage permission.
+// @Tags translations
+// @Accept json
+// @Produce json
+// @Param translation_id path uint64 true "Translation ID"
+// @Param artifacts body service.TranslationArtifacts true "Artifacts to attach; omitted fields are unchanged"
+// @Success 200 {object} response.TranslationResponse "translation"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 403 {object} response.ErrorResponse "not an admin"
+// @Failure 404 {object} response.ErrorResponse "error"
+// @Failure 409 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /translations/{translation_id} [put]
+func (h *TranslationController) UpdateTranslation(c *gin.Context) {
+	translationID, err := strconv.ParseUint(c.Param("translation_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid translation ID"})
+		return
+	}
+
+	var artifacts service.TranslationArtifacts
+	if err := c.ShouldBindJSON(&artifacts); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid input"})
+		return
+	}
+
+	translation, err := h.translationService.UpdateTranslationArtifacts(translationID, artifacts)
+	if err != nil {
+		respondTranslationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.TranslationResponse{Translation: *translation})
+}
+
+// DeleteTranslation godoc
+// @Summary Delete translation
+// @Description Deletes a translation record. Its artifacts are kept. A translation that has not completed is refunded.
+// @Tags translations
+// @Produce json
+// @Param translation_id path uint64 true "Translation ID"
+// @Success 200 {object} response.MessageResponse "message"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /translations/{translation_id} [delete]
+func (h *TranslationController) DeleteTranslation(c *gin.Context) {
+	translationID, err := strconv.ParseUint(c.Param("translation_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid translation ID"})
+		return
+	}
+
+	if err := h.translationService.DeleteTranslation(translationID); err != nil {
+		respondTranslationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.MessageResponse{Message: "Translation deleted successfully"})
+}
+
+// ListTranslationsByVideoID godoc
+// @Summary List translations by video
+// @Description Retrieves a page of the translations of a video.
+// @Tags translations
+// @Produce json
+// @Param video_id path uint64 true "Video ID"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field: created_at (default created_at)"
+// @Param order query string false "asc or desc (default desc)"
+// @Param status query string false "Only items with this status"
+// @Param lang query string false "Only translations into this language"
+// @Param created_after query string false "Only items created at or after this RFC 3339 time or date"
+// @Param created_before query string false "Only items created before this RFC 3339 time or date"
+// @Success 200 {object} response.TranslationsResponse "translations"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /translations/video/{video_id} [get]
+func (h *TranslationController) ListTranslationsByVideoID(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("video_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid video ID"})
+		return
+	}
+
+	opts, ok := bindListOptions(c)
+	if !ok {
+		return
+	}
+
+	translations, nextCursor, err := h.translationService.ListTranslationsByVideoID(videoID, opts)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.TranslationsResponse{Translations: translations, NextCursor: nextCursor})
+}
+
+// ListTranslationsByUserID godoc
+// @Summary List translations by user
+// @Description Retrieves a page of the translations requested by a user.
+// @Tags translations
+// @Produce json
+// @Param user_id path uint64 true "User ID"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field: created_at (default created_at)"
+// @Param order query string false "asc or desc (default desc)"
+// @Param status query string false "Only items with this status"
+// @Param lang query string false "Only translations into this language"
+// @Param created_after query string false "Only items created at or after this RFC 3339 time or date"
+// @Param created_before query string false "Only items created before this RFC 3339 time or date"
+// @Success 200 {object} response.TranslationsResponse "translations"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /translations/user/{user_id} [get]
+func (h *TranslationController) ListTranslationsByUserID(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid user ID"})
+		return
+	}
+
+	opts, ok := bindListOptions(c)
+	if !ok {
+		return
+	}
+
+	translations, nextCursor, err := h.translationService.ListTranslationsByUserID(userID, opts)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.TranslationsResponse{Translations: translations, NextCursor: nextCursor})
+}
+
+// respondTranslationError maps translation service errors to HTTP responses
+func respondTranslationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrTranslationNotFound), errors.Is(err, service.ErrVideoNotFound):
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrTranslationExists), errors.Is(err, service.ErrInvalidTranslationStep):
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrInvalidTranslation):
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrInsufficientCredits):
+		c.JSON(http.StatusPaymentRequired, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrMediaProbeUnavailable):
+		c.JSON(http.StatusServiceUnavailable, response.ErrorResponse{Error: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+	}
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupTranslationRouter(mockService *service.MockTranslationService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	controller := NewTranslationController(mockService)
+
+	router := gin.New()
+	router.POST("/translations", controller.CreateTranslations)
+	router.GET("/translations/:translation_id", controller.GetTranslation)
+	router.PUT("/translations/:translation_id", controller.UpdateTranslation)
+	router.DELETE("/translations/:translation_id", controller.DeleteTranslation)
+	return router
+}
+
+func TestCreateTranslations_Success(t *testing.T) {
+	mockService := new(service.MockTranslationService)
+	router := setupTranslationRouter(mockService)
+
+	mockService.On("RequestTranslations", uint64(1), uint64(2), "en", []string{"vi", "fr"}).Return([]entity.Translation{
+		{ID: 1, VideoID: 2, UserID: 1, SourceLang: "en", TargetLang: "vi", Status: entity.TranslationStatusPending},
+		{ID: 2, VideoID: 2, UserID: 1, SourceLang: "en", TargetLang: "fr", Status: entity.TranslationStatusPending},
+	}, nil)
+
+	body, _ := json.Marshal(CreateTranslationRequest{VideoID: 2, UserID: 1, SourceLang: "en", TargetLangs: []string{"vi", "fr"}})
+	req, _ := http.NewRequest(http.MethodPost, "/translations", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var resp response.TranslationsResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Len(t, resp.Translations, 2)
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateTranslations_Conflict(t *testing.T) {
+	mockService := new(service.MockTranslationService)
+	router := setupTranslationRouter(mockService)
+
+	mockService.On("RequestTranslations", uint64(1), uint64(2), "en", []string{"vi"}).
+		Return(nil, fmt.Errorf("%w: vi", service.ErrTranslationExists))
+
+	body, _ := json.Marshal(CreateTranslationRequest{VideoID: 2, UserID: 1, SourceLang: "en", TargetLangs: []string{"vi"}})
+	req, _ := http.NewRequest(http.MethodPost, "/translations", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestCreateTranslations_InsufficientCredits(t *testing.T) {
+	mockService := new(service.MockTranslationService)
+	router := setupTranslationRouter(mockService)
+
+	mockService.On("RequestTranslations", uint64(1), uint64(2), "en", []string{"vi"}).
+		Return(nil, fmt.Errorf("%w: 3 needed, 1 left", service.ErrInsufficientCredits))
+
+	body, _ := json.Marshal(CreateTranslationRequest{VideoID: 2, UserID: 1, SourceLang: "en", TargetLangs: []string{"vi"}})
+	req, _ := http.NewRequest(http.MethodPost, "/translations", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusPaymentRequired, rr.Code)
+}
+
+func TestCreateTranslations_InvalidInput(t *testing.T) {
+	mockService := new(service.MockTranslationService)
+	router := setupTranslationRouter(mockService)
+
+	req, _ := http.NewRequest(http.MethodPost, "/translations", bytes.NewBufferString(`{"video_id": 2, "user_id": 1, "source_lang": "en"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockService.AssertNotCalled(t, "RequestTranslations", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetTranslation_Success(t *testing.T) {
+	mockService := new(service.MockTranslationService)
+	router := setupTranslationRouter(mockService)
+
+	mockService.On("GetTranslationByID", uint64(1)).
+		Return(&entity.Translation{ID: 1, TargetLang: "vi", Status: entity.TranslationStatusCompleted}, "https://s3.amazonaws.com/out.mp4", nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/translations/1", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp response.TranslationResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "vi", resp.Translation.TargetLang)
+	assert.Equal(t, "https://s3.amazonaws.com/out.mp4", resp.DownloadURL)
+}
+
+func TestGetTranslation_NotFound(t *testing.T) {
+	mockService := new(service.MockTranslationService)
+	router := setupTranslationRouter(mockService)
+
+	mockService.On("GetTranslationByID", uint64(9)).Return(nil, "", service.ErrTranslationNotFound)
+
+	req, _ := http.NewRequest(http.MethodGet, "/translations/9", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestUpdateTranslation_InvalidStep(t *testing.T) {
+	mockService := new(service.MockTranslationService)
+	router := setupTranslationRouter(mockService)
+
+	mockService.On("UpdateTranslationArtifacts", uint64(1), mock.AnythingOfType("service.TranslationArtifacts")).
+		Return(nil, fmt.Errorf("%w: pending to completed", service.ErrInvalidTranslationStep))
+
+	req, _ := http.NewRequest(http.MethodPut, "/translations/1", bytes.NewBufferString(`{"status": "completed"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestDeleteTranslation_Success(t *testing.T) {
+	mockService := new(service.MockTranslationService)
+	router := setupTranslationRouter(mockService)
+
+	mockService.On("DeleteTranslation", uint64(1)).Return(nil)
+
+	req, _ := http.NewRequest(http.MethodDelete, "/translations/1", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"mlvt/internal/infra/zap-logging/log"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorController struct {
+	twoFactorService service.TwoFactorService
+}
+
+func NewTwoFactorController(twoFactorService service.TwoFactorService) *TwoFactorController {
+	return &TwoFactorController{twoFactorService: twoFactorService}
+}
+
+// EnrollTwoFactor godoc
+// @Summary Start two-factor enrollment
+// @Description Creates a TOTP secret and returns it with its provisioning URI as a QR code for an authenticator app.
+// @Description Two-factor authentication stays off until the enable endpoint confirms a code; enrolling again replaces the secret.
+// @Tags users
+// @Produce json
+// @Param user_id path uint64 true "User ID"
+// @Success 200 {object} response.TwoFactorEnrollmentResponse
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "error"
+// @Failure 409 {object} response.ErrorResponse "two-factor authentication is already enabled"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /users/{user_id}/2fa/enroll [post]
+func (h *TwoFactorController) EnrollTwoFactor(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.twoFactorService.Enroll(userID)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.TwoFactorEnrollmentResponse{
+		Secret:     enrollment.Secret,
+		OTPAuthURI: enrollment.URI,
+		QRCode:     enrollment.QRCode,
+	})
+}
+
+// EnableTwoFactor godoc
+// @Summary Enable two-factor authentication
+// @Description Turns on two-factor authentication once a code from the enrolled secret is confirmed and returns
+// @Description the recovery codes. They are shown only once; each can replace a code from the app once.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id path uint64 true "User ID"
+// @Param body body object true "Code from the authenticator app"
+// @Success 200 {object} response.RecoveryCodesResponse
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "error"
+// @Failure 409 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /users/{user_id}/2fa/enable [post]
+func (h *TwoFactorController) EnableTwoFactor(c *gin.Context) {
+	userID, code, ok := bindTwoFactorCode(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.twoFactorService.Enable(userID, code)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable two-factor authentication
+// @Description Turns off two-factor authentication and discards the secret and recovery codes
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id path uint64 true "User ID"
+// @Param body body object true "Code from the authenticator app or a recovery code"
+// @Success 200 {object} response.MessageResponse "message"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "error"
+// @Failure 409 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /users/{user_id}/2fa/disable [post]
+func (h *TwoFactorController) DisableTwoFactor(c *gin.Context) {
+	userID, code, ok := bindTwoFactorCode(c)
+	if !ok {
+		return
+	}
+
+	if err := h.twoFactorService.Disable(userID, code); err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.MessageResponse{Message: "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replaces the recovery codes; the old ones stop working
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id path uint64 true "User ID"
+// @Param body body object true "Code from the authenticator app or a recovery code"
+// @Success 200 {object} response.RecoveryCodesResponse
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "error"
+// @Failure 409 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /users/{user_id}/2fa/recovery-codes [post]
+func (h *TwoFactorController) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, code, ok := bindTwoFactorCode(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(userID, code)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// CountRecoveryCodes godoc
+// @Summary Count recovery codes
+// @Description Returns how many unused recovery codes are left
+// @Tags users
+// @Produce json
+// @Param user_id path uint64 true "User ID"
+// @Success 200 {object} response.RecoveryCodesRemainingResponse
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "error"
+// @Failure 409 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /users/{user_id}/2fa/recovery-codes [get]
+func (h *TwoFactorController) CountRecoveryCodes(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	remaining, err := h.twoFactorService.CountRecoveryCodes(userID)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.RecoveryCodesRemainingResponse{Remaining: remaining})
+}
+
+// parseUserID reads the user ID path parameter, answering 400 if it is not a number
+func parseUserID(c *gin.Context) (uint64, bool) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid user ID"})
+		return 0, false
+	}
+	return userID, true
+}
+
+// bindTwoFactorCode reads the user ID and the code confirming a two-factor change
+func bindTwoFactorCode(c *gin.Context) (uint64, string, bool) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return 0, "", false
+	}
+
+	var request struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid input"})
+		return 0, "", false
+	}
+	return userID, request.Code, true
+}
+
+// respondTwoFactorError maps two-factor service errors to HTTP responses
+func respondTwoFactorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrTwoFactorAlreadyEnabled), errors.Is(err, service.ErrTwoFactorNotEnabled),
+		errors.Is(err, service.ErrTwoFactorNotEnrolled):
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: err.Error()})
+	default:
+		log.Errorf("two-factor request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+	}
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTwoFactorRouter(mockService *service.MockTwoFactorService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	controller := NewTwoFactorController(mockService)
+
+	router := gin.New()
+	router.POST("/users/:user_id/2fa/enroll", controller.EnrollTwoFactor)
+	router.POST("/users/:user_id/2fa/enable", controller.EnableTwoFactor)
+	return router
+}
+
+func TestEnrollTwoFactor_Success(t *testing.T) {
+	mockService := new(service.MockTwoFactorService)
+	router := setupTwoFactorRouter(mockService)
+
+	mockService.On("Enroll", uint64(1)).Return(&entity.TOTPEnrollment{
+		Secret: "JBSWY3DPEHPK3PXP",
+		URI:    "otpauth://totp/MLVT:jane@example.com?secret=JBSWY3DPEHPK3PXP",
+		QRCode: []byte("\x89PNG"),
+	}, nil)
+
+	req, _ := http.NewRequest(http.MethodPost, "/users/1/2fa/enroll", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp response.TwoFactorEnrollmentResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", resp.Secret)
+	assert.Equal(t, []byte("\x89PNG"), resp.QRCode)
+}
+
+func TestEnrollTwoFactor_AlreadyEnabled(t *testing.T) {
+	mockService := new(service.MockTwoFactorService)
+	router := setupTwoFactorRouter(mockService)
+
+	mockService.On("Enroll", uint64(1)).Return(nil, service.ErrTwoFactorAlreadyEnabled)
+
+	req, _ := http.NewRequest(http.MethodPost, "/users/1/2fa/enroll", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestEnableTwoFactor(t *testing.T) {
+	mockService := new(service.MockTwoFactorService)
+	router := setupTwoFactorRouter(mockService)
+
+	mockService.On("Enable", uint64(1), "123456").Return([]string{"abcde-fghij"}, nil)
+	mockService.On("Enable", uint64(1), "000000").Return(nil, service.ErrInvalidTwoFactorCode)
+
+	req, _ := http.NewRequest(http.MethodPost, "/users/1/2fa/enable", bytes.NewBufferString(`{"code":"123456"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp response.RecoveryCodesResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, []string{"abcde-fghij"}, resp.RecoveryCodes)
+
+	req, _ = http.NewRequest(http.MethodPost, "/users/1/2fa/enable", bytes.NewBufferString(`{"code":"000000"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
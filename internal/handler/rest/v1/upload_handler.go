@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/env"
+	"mlvt/internal/infra/zap-logging/log"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateUploadRequest describes a video file the client is about to upload straight to storage
+type CreateUploadRequest struct {
+	UserID         uint64 `json:"user_id" binding:"required"`
+	Title          string `json:"title" binding:"required"`
+	Description    string `json:"description"`
+	Duration       int    `json:"duration"`
+	Image          string `json:"image"`
+	FileName       string `json:"file_name" binding:"required"`
+	ContentType    string `json:"content_type" binding:"required"`
+	Size           int64  `json:"size" binding:"required,gt=0"`
+	ChecksumSHA256 string `json:"checksum_sha256"` // Optional base64 SHA-256 of the file; single uploads only
+	Multipart      bool   `json:"multipart"`       // Send the file in parts; required for files over 5 GiB
+	PartSize       int64  `json:"part_size"`       // Optional part size for multipart uploads
+}
+
+// PresignUploadPartsRequest lists the parts of a multipart upload to get upload URLs for
+type PresignUploadPartsRequest struct {
+	PartNumbers []int `json:"part_numbers" binding:"required"`
+}
+
+type UploadController struct {
+	uploadService service.UploadService
+}
+
+func NewUploadController(uploadService service.UploadService) *UploadController {
+	return &UploadController{uploadService: uploadService}
+}
+
+// CreateUpload godoc
+// @Summary Start a video upload
+// @Description Registers a pending video upload and returns a presigned URL to PUT the file to.
+// @Description The URL only accepts a body of the declared size, sent with the declared Content-Type and, if given, the declared SHA-256 checksum.
+// @Description With "multipart": true no URL is returned; the file is split into part_count parts of part_size bytes
+// @Description (the last one may be smaller) whose URLs are requested from the parts endpoint.
+// @Description The video is created by the finalize endpoint once the file is in storage.
+// @Tags Videos
+// @Accept json
+// @Produce json
+// @Param upload body CreateUploadRequest true "File to upload"
+// @Success 201 {object} response.VideoUploadResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /videos/uploads [post]
+func (h *UploadController) CreateUpload(c *gin.Context) {
+	var req CreateUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	upload := &entity.VideoUpload{
+		UserID:         req.UserID,
+		Title:          req.Title,
+		Description:    req.Description,
+		Duration:       req.Duration,
+		Image:          req.Image,
+		Folder:         env.EnvConfig.VideosFolder,
+		FileName:       req.FileName,
+		ContentType:    req.ContentType,
+		Size:           req.Size,
+		ChecksumSHA256: req.ChecksumSHA256,
+	}
+
+	if req.Multipart {
+		upload.PartSize = req.PartSize
+		if err := h.uploadService.CreateMultipartUpload(upload); err != nil {
+			respondUploadError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, response.VideoUploadResponse{Upload: *upload, PartCount: upload.PartCount()})
+		return
+	}
+
+	uploadURL, err := h.uploadService.CreateUpload(upload)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.VideoUploadResponse{Upload: *upload, UploadURL: uploadURL})
+}
+
+// GetUpload godoc
+// @Summary Get a video upload
+// @Description Retrieves the state of a video upload.
+// @Tags Videos
+// @Produce json
+// @Param upload_id path uint64 true "ID of the upload"
+// @Success 200 {object} response.VideoUploadResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /videos/uploads/{upload_id} [get]
+func (h *UploadController) GetUpload(c *gin.Context) {
+	uploadID, err := strconv.ParseUint(c.Param("upload_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid upload ID"})
+		return
+	}
+
+	upload, err := h.uploadService.GetUpload(uploadID)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.VideoUploadResponse{Upload: *upload, PartCount: upload.PartCount()})
+}
+
+// PresignUploadParts godoc
+// @Summary Get upload URLs for parts of a multipart upload
+// @Description Returns a presigned PUT URL for each requested part, up to 100 per request.
+// @Description Each URL only accepts a body of exactly the part's size. Parts can be uploaded in parallel and re-requested to resume.
+// @Tags Videos
+// @Accept json
+// @Produce json
+// @Param upload_id path uint64 true "ID of the upload"
+// @Param parts body PresignUploadPartsRequest true "Part numbers, starting at 1"
+// @Success 200 {object} response.UploadPartsResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse "The upload is no longer pending"
+// @Failure 410 {object} response.ErrorResponse "The upload expired"
+// @Failure 500 {object} response.ErrorResponse
+// @Router /videos/uploads/{upload_id}/parts [post]
+func (h *UploadController) PresignUploadParts(c *gin.Context) {
+	uploadID, err := strconv.ParseUint(c.Param("upload_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid upload ID"})
+		return
+	}
+
+	var req PresignUploadPartsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	parts, err := h.uploadService.PresignUploadParts(uploadID, req.PartNumbers)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.UploadPartsResponse{Parts: parts})
+}
+
+// ListUploadParts godoc
+// @Summary List received parts of a multipart upload
+// @Description Lists the parts storage has received so far, so an interrupted upload can resume with the missing ones.
+// @Tags Videos
+// @Produce json
+// @Param upload_id path uint64 true "ID of the upload"
+// @Success 200 {object} response.UploadPartsResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse "The upload is no longer pending"
+// @Failure 410 {object} response.ErrorResponse "The upload expired"
+// @Failure 500 {object} response.ErrorResponse
+// @Router /videos/uploads/{upload_id}/parts [get]
+func (h *UploadController) ListUploadParts(c *gin.Context) {
+	uploadID, err := strconv.ParseUint(c.Param("upload_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid upload ID"})
+		return
+	}
+
+	parts, err := h.uploadService.ListUploadParts(uploadID)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.UploadPartsResponse{Parts: parts})
+}
+
+// AbortUpload godoc
+// @Summary Abort a video upload
+// @Description Cancels a pending upload and removes the file or parts uploaded so far.
+// @Tags Videos
+// @Produce json
+// @Param upload_id path uint64 true "ID of the upload"
+// @Success 200 {object} response.MessageResponse "message"
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse "The upload is no longer pending"
+// @Failure 500 {object} response.ErrorResponse
+// @Router /videos/uploads/{upload_id} [delete]
+func (h *UploadController) AbortUpload(c *gin.Context) {
+	uploadID, err := strconv.ParseUint(c.Param("upload_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid upload ID"})
+		return
+	}
+
+	if err := h.uploadService.AbortUpload(uploadID); err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.MessageResponse{Message: "Upload aborted successfully"})
+}
+
+// FinalizeUpload godoc
+// @Summary Finalize a video upload
+// @Description Checks that the uploaded file is in storage with the declared size, content type and checksum, then creates the video.
+// @Description Multipart uploads are first assembled from their parts, which must all have been uploaded.
+// @Description Finalizing an upload again returns the same video.
+// @Tags Videos
+// @Produce json
+// @Param upload_id path uint64 true "ID of the upload"
+// @Success 200 {object} response.VideoResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse "The file has not been uploaded yet, or the upload was aborted"
+// @Failure 410 {object} response.ErrorResponse "The upload expired"
+// @Failure 422 {object} response.ErrorResponse "The stored file does not match the upload"
+// @Failure 500 {object} response.ErrorResponse
+// @Router /videos/uploads/{upload_id}/finalize [post]
+func (h *UploadController) FinalizeUpload(c *gin.Context) {
+	uploadID, err := strconv.ParseUint(c.Param("upload_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid upload ID"})
+		return
+	}
+
+	video, err := h.uploadService.FinalizeUpload(uploadID)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.VideoResponse{Video: *video})
+}
+
+func respondUploadError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUploadNotFound), errors.Is(err, service.ErrVideoNotFound):
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrInvalidUpload):
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrUploadNotReceived), errors.Is(err, service.ErrUploadNotPending):
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrUploadExpired):
+		c.JSON(http.StatusGone, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrUploadMismatch):
+		c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{Error: err.Error()})
+	default:
+		log.Errorf("Video upload request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+	}
+}
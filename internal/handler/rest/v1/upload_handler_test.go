@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupUploadRouter(mockService *service.MockUploadService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	controller := NewUploadController(mockService)
+
+	router := gin.New()
+	router.POST("/videos/uploads", controller.CreateUpload)
+	router.GET("/videos/uploads/:upload_id", controller.GetUpload)
+	router.POST("/videos/uploads/:upload_id/finalize", controller.FinalizeUpload)
+	router.DELETE("/videos/uploads/:upload_id", controller.AbortUpload)
+	router.POST("/videos/uploads/:upload_id/parts", controller.PresignUploadParts)
+	router.GET("/videos/uploads/:upload_id/parts", controller.ListUploadParts)
+	return router
+}
+
+func TestCreateUpload_Success(t *testing.T) {
+	mockService := new(service.MockUploadService)
+	router := setupUploadRouter(mockService)
+
+	mockService.On("CreateUpload", mock.MatchedBy(func(upload *entity.VideoUpload) bool {
+		return upload.UserID == 1 && upload.FileName == "clip.mp4" && upload.Size == 1024
+	})).Return("https://upload", nil)
+
+	body, _ := json.Marshal(CreateUploadRequest{UserID: 1, Title: "Clip", FileName: "clip.mp4", ContentType: "video/mp4", Size: 1024})
+	req, _ := http.NewRequest(http.MethodPost, "/videos/uploads", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var resp response.VideoUploadResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "https://upload", resp.UploadURL)
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateUpload_InvalidInput(t *testing.T) {
+	mockService := new(service.MockUploadService)
+	router := setupUploadRouter(mockService)
+
+	mockService.On("CreateUpload", mock.Anything).Return("", fmt.Errorf("%w: content type must be a video type", service.ErrInvalidUpload))
+
+	for _, body := range []string{
+		`{"user_id": 1, "title": "Clip", "file_name": "clip.mp4", "content_type": "video/mp4"}`,
+		`{"user_id": 1, "title": "Clip", "file_name": "clip.pdf", "content_type": "application/pdf", "size": 10}`,
+	} {
+		req, _ := http.NewRequest(http.MethodPost, "/videos/uploads", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+	}
+	mockService.AssertNumberOfCalls(t, "CreateUpload", 1)
+}
+
+func TestFinalizeUpload_Success(t *testing.T) {
+	mockService := new(service.MockUploadService)
+	router := setupUploadRouter(mockService)
+
+	mockService.On("FinalizeUpload", uint64(1)).Return(&entity.Video{ID: 5, Title: "Clip", Status: entity.StatusRaw}, nil)
+
+	req, _ := http.NewRequest(http.MethodPost, "/videos/uploads/1/finalize", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp response.VideoResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, uint64(5), resp.Video.ID)
+}
+
+func TestFinalizeUpload_Errors(t *testing.T) {
+	cases := []struct {
+		err  error
+		code int
+	}{
+		{service.ErrUploadNotFound, http.StatusNotFound},
+		{service.ErrUploadNotReceived, http.StatusConflict},
+		{service.ErrUploadExpired, http.StatusGone},
+		{fmt.Errorf("%w: size is 10 bytes, expected 1024", service.ErrUploadMismatch), http.StatusUnprocessableEntity},
+		{fmt.Errorf("storage down"), http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		mockService := new(service.MockUploadService)
+		router := setupUploadRouter(mockService)
+		mockService.On("FinalizeUpload", uint64(1)).Return(nil, tc.err)
+
+		req, _ := http.NewRequest(http.MethodPost, "/videos/uploads/1/finalize", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, tc.code, rr.Code, tc.err.Error())
+	}
+}
+
+func TestCreateUpload_Multipart(t *testing.T) {
+	mockService := new(service.MockUploadService)
+	router := setupUploadRouter(mockService)
+
+	mockService.On("CreateMultipartUpload", mock.MatchedBy(func(upload *entity.VideoUpload) bool {
+		return upload.Size == 12<<20 && upload.PartSize == 5<<20
+	})).Run(func(args mock.Arguments) {
+		upload := args.Get(0).(*entity.VideoUpload)
+		upload.MultipartID = "mp-1"
+	}).Return(nil)
+
+	body, _ := json.Marshal(CreateUploadRequest{UserID: 1, Title: "Clip", FileName: "clip.mp4", ContentType: "video/mp4",
+		Size: 12 << 20, Multipart: true, PartSize: 5 << 20})
+	req, _ := http.NewRequest(http.MethodPost, "/videos/uploads", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var resp response.VideoUploadResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Empty(t, resp.UploadURL)
+	assert.Equal(t, 3, resp.PartCount)
+	assert.Equal(t, "mp-1", resp.Upload.MultipartID)
+	mockService.AssertNotCalled(t, "CreateUpload", mock.Anything)
+}
+
+func TestPresignUploadParts(t *testing.T) {
+	mockService := new(service.MockUploadService)
+	router := setupUploadRouter(mockService)
+
+	mockService.On("PresignUploadParts", uint64(1), []int{1, 2}).Return([]entity.UploadPart{
+		{PartNumber: 1, Size: 5 << 20, UploadURL: "https://part1"},
+		{PartNumber: 2, Size: 1, UploadURL: "https://part2"},
+	}, nil)
+
+	req, _ := http.NewRequest(http.MethodPost, "/videos/uploads/1/parts", bytes.NewBufferString(`{"part_numbers": [1, 2]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp response.UploadPartsResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Len(t, resp.Parts, 2)
+	assert.Equal(t, "https://part2", resp.Parts[1].UploadURL)
+
+	req, _ = http.NewRequest(http.MethodPost, "/videos/uploads/1/parts", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestListUploadParts_Expired(t *testing.T) {
+	mockService := new(service.MockUploadService)
+	router := setupUploadRouter(mockService)
+
+	mockService.On("ListUploadParts", uint64(1)).Return(nil, service.ErrUploadExpired)
+
+	req, _ := http.NewRequest(http.MethodGet, "/videos/uploads/1/parts", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusGone, rr.Code)
+}
+
+func TestAbortUpload(t *testing.T) {
+	mockService := new(service.MockUploadService)
+	router := setupUploadRouter(mockService)
+
+	mockService.On("AbortUpload", uint64(1)).Return(nil)
+	mockService.On("AbortUpload", uint64(2)).Return(service.ErrUploadNotPending)
+
+	req, _ := http.NewRequest(http.MethodDelete, "/videos/uploads/1", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req, _ = http.NewRequest(http.MethodDelete, "/videos/uploads/2", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
@@ -1,11 +1,15 @@
 package handler
 
 import (
+	"errors"
+	"math"
 	"net/http"
 	"strconv"
+	"time"
 
 	"mlvt/internal/entity"
 	"mlvt/internal/infra/env"
+	"mlvt/internal/infra/zap-logging/log"
 	"mlvt/internal/pkg/response"
 	"mlvt/internal/service"
 
@@ -13,16 +17,17 @@ import (
 )
 
 type UserController struct {
-	userService service.UserService
+	userService    service.UserService
+	accountService service.AccountService
 }
 
-func NewUserController(userService service.UserService) *UserController {
-	return &UserController{userService: userService}
+func NewUserController(userService service.UserService, accountService service.AccountService) *UserController {
+	return &UserController{userService: userService, accountService: accountService}
 }
 
 // RegisterUser godoc
 // @Summary Register a new user
-// @Description Creates a new user in the system
+// @Description Creates a new user in the system and emails a link to verify the address
 // @Tags users
 // @Accept json
 // @Produce json
@@ -43,6 +48,11 @@ func (h *UserController) RegisterUser(c *gin.Context) {
 		return
 	}
 
+	// The account exists either way; the user can ask for another email
+	if err := h.accountService.SendVerificationEmail(c.Request.Context(), user.ID); err != nil {
+		log.Warnf("failed to send verification email to user %d: %v", user.ID, err)
+	}
+
 	c.JSON(http.StatusCreated, response.MessageResponse{Message: "User registered successfully"})
 }
 
@@ -82,9 +92,10 @@ func (h *UserController) GenerateAvatarDownloadURL(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param credentials body object true "Email and password"
-// @Success 200 {object} response.TokenResponse "token"
+// @Success 200 {object} response.TokenResponse "token, or response.TwoFactorChallengeResponse when two-factor authentication is on"
 // @Failure 400 {object} response.ErrorResponse "error"
 // @Failure 401 {object} response.ErrorResponse "error"
+// @Failure 429 {object} response.ErrorResponse "too many failed attempts; see the Retry-After header"
 // @Router /users/login [post]
 func (h *UserController) LoginUser(c *gin.Context) {
 	var credentials struct {
@@ -96,16 +107,282 @@ func (h *UserController) LoginUser(c *gin.Context) {
 		return
 	}
 
-	token, userID, err := h.userService.Login(credentials.Email, credentials.Password)
+	tokens, err := h.userService.Login(credentials.Email, credentials.Password, c.ClientIP())
+	if err != nil {
+		respondLoginError(c, err)
+		return
+	}
+
+	respondLoginTokens(c, tokens)
+}
+
+// respondLoginTokens answers a successful first login step with the tokens, or with the challenge
+// to confirm when the user has two-factor authentication
+func respondLoginTokens(c *gin.Context, tokens *entity.AuthTokens) {
+	if tokens.TwoFactorRequired() {
+		c.JSON(http.StatusOK, response.TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    tokens.ChallengeToken,
+			ExpiresAt:         tokens.ChallengeExpiresAt,
+			UserID:            tokens.UserID,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, tokenResponse(tokens))
+}
+
+// LoginTwoFactor godoc
+// @Summary Confirm two-factor login
+// @Description Completes a login of a user with two-factor authentication, using the challenge token returned by
+// @Description login and a code from their authenticator app or an unused recovery code
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body object true "Challenge token and code"
+// @Success 200 {object} response.TokenResponse "token"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 401 {object} response.ErrorResponse "error"
+// @Failure 429 {object} response.ErrorResponse "too many failed attempts; see the Retry-After header"
+// @Router /users/login/2fa [post]
+func (h *UserController) LoginTwoFactor(c *gin.Context) {
+	var request struct {
+		ChallengeToken string `json:"challenge_token" binding:"required"`
+		Code           string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	tokens, err := h.userService.CompleteTwoFactorLogin(request.ChallengeToken, request.Code, c.ClientIP())
 	if err != nil {
+		respondLoginError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenResponse(tokens))
+}
+
+// respondLoginError answers a failed login with 403 for a disabled account, 429 and a Retry-After header while locked and 401 otherwise
+func respondLoginError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrAccountDisabled) {
+		c.JSON(http.StatusForbidden, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+	var locked *service.LoginLockedError
+	if !errors.As(err, &locked) {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	retryAfter := int(math.Ceil(time.Until(locked.RetryAt).Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, response.ErrorResponse{Error: err.Error()})
+}
+
+// RefreshToken godoc
+// @Summary Refresh access token
+// @Description Exchanges a refresh token for a new access token and a new refresh token. Each refresh token can be used once.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body object true "Refresh token"
+// @Success 200 {object} response.TokenResponse "token"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 401 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /users/refresh [post]
+func (h *UserController) RefreshToken(c *gin.Context) {
+	var request struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	tokens, err := h.userService.RefreshToken(request.RefreshToken)
+	if errors.Is(err, service.ErrInvalidRefreshToken) {
 		c.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: err.Error()})
 		return
 	}
+	if err != nil {
+		log.Errorf("failed to refresh token: %v", err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenResponse(tokens))
+}
+
+// Logout godoc
+// @Summary User logout
+// @Description Revokes the session of a refresh token. With "all" set, every session of the user is revoked, including issued access tokens.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body object true "Refresh token and whether to sign out everywhere"
+// @Success 200 {object} response.MessageResponse "message"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /users/logout [post]
+func (h *UserController) Logout(c *gin.Context) {
+	var request struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+		All          bool   `json:"all"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.userService.Logout(request.RefreshToken, request.All); err != nil {
+		log.Errorf("failed to log out: %v", err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+		return
+	}
 
-	c.JSON(http.StatusOK, response.TokenResponse{
-		Token:  token,
-		UserID: userID,
-	})
+	c.JSON(http.StatusOK, response.MessageResponse{Message: "Logged out successfully"})
+}
+
+// SendVerificationEmail godoc
+// @Summary Resend email verification link
+// @Description Emails a new link to verify the user's address; earlier links stop working
+// @Tags users
+// @Produce json
+// @Param user_id path uint64 true "User ID"
+// @Success 200 {object} response.MessageResponse "message"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 404 {object} response.ErrorResponse "error"
+// @Failure 409 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /users/{user_id}/send-verification-email [post]
+func (h *UserController) SendVerificationEmail(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid user ID"})
+		return
+	}
+
+	if err := h.accountService.SendVerificationEmail(c.Request.Context(), userID); err != nil {
+		respondAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.MessageResponse{Message: "Verification email sent"})
+}
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Confirms the user's email address with the token from the verification email
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body object true "Token from the verification link"
+// @Success 200 {object} response.MessageResponse "message"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /users/verify-email [post]
+func (h *UserController) VerifyEmail(c *gin.Context) {
+	var request struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.accountService.VerifyEmail(request.Token); err != nil {
+		respondAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.MessageResponse{Message: "Email address verified"})
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Emails a password reset link if an account uses the address. The response is the same whether or not it does.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body object true "Email address"
+// @Success 202 {object} response.MessageResponse "message"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /users/forgot-password [post]
+func (h *UserController) ForgotPassword(c *gin.Context) {
+	var request struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.accountService.ForgotPassword(c.Request.Context(), request.Email); err != nil {
+		respondAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, response.MessageResponse{Message: "If an account uses this address, a password reset link has been sent"})
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Sets a new password with the token from the password reset email and signs the user out everywhere
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body object true "Token from the reset link and the new password"
+// @Success 200 {object} response.MessageResponse "message"
+// @Failure 400 {object} response.ErrorResponse "error"
+// @Failure 500 {object} response.ErrorResponse "error"
+// @Router /users/reset-password [post]
+func (h *UserController) ResetPassword(c *gin.Context) {
+	var request struct {
+		Token       string `json:"token" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.accountService.ResetPassword(request.Token, request.NewPassword); err != nil {
+		respondAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.MessageResponse{Message: "Password reset successfully"})
+}
+
+// respondAccountError maps account flow errors to HTTP responses
+func respondAccountError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidAccountToken):
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrEmailAlreadyVerified):
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: err.Error()})
+	default:
+		log.Errorf("account request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+	}
+}
+
+func tokenResponse(tokens *entity.AuthTokens) response.TokenResponse {
+	return response.TokenResponse{
+		Token:            tokens.AccessToken,
+		ExpiresAt:        tokens.AccessExpiresAt,
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresAt: tokens.RefreshExpiresAt,
+		UserID:           tokens.UserID,
+	}
 }
 
 // ChangePassword godoc
@@ -147,7 +424,7 @@ func (h *UserController) ChangePassword(c *gin.Context) {
 
 // UpdateUser godoc
 // @Summary Update user information
-// @Description Updates the user's information, excluding the avatar
+// @Description Updates the user's information, excluding the avatar. A new email address must be verified again; a verification link is sent to it.
 // @Tags users
 // @Accept json
 // @Produce json
@@ -172,11 +449,19 @@ func (h *UserController) UpdateUser(c *gin.Context) {
 	}
 	user.ID = userID
 
-	if err := h.userService.UpdateUser(&user); err != nil {
+	emailChanged, err := h.userService.UpdateUser(&user)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
 		return
 	}
 
+	// The new address is unverified until the user follows the link sent to it
+	if emailChanged {
+		if err := h.accountService.SendVerificationEmail(c.Request.Context(), user.ID); err != nil {
+			log.Warnf("failed to send verification email to user %d: %v", user.ID, err)
+		}
+	}
+
 	c.JSON(http.StatusOK, response.MessageResponse{Message: "User updated successfully"})
 }
 
@@ -12,6 +12,7 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -22,7 +23,8 @@ func TestRegisterUser_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	mockAccountService := new(service.MockAccountService)
+	controller := NewUserController(mockService, mockAccountService)
 
 	// Define the user input
 	input := entity.User{
@@ -35,6 +37,7 @@ func TestRegisterUser_Success(t *testing.T) {
 
 	// Mock the RegisterUser method
 	mockService.On("RegisterUser", mock.AnythingOfType("*entity.User")).Return(nil)
+	mockAccountService.On("SendVerificationEmail", mock.Anything, mock.Anything).Return(nil)
 
 	// Create the request body
 	body, _ := json.Marshal(input)
@@ -70,7 +73,7 @@ func TestRegisterUser_Failure_InvalidJSON(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	// Invalid JSON (missing closing brace)
 	body := []byte(`{"first_name": "Jane", "email": "jane@example.com"`)
@@ -103,7 +106,7 @@ func TestRegisterUser_Failure_ServiceError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	input := entity.User{
 		FirstName: "Jane",
@@ -142,7 +145,7 @@ func TestLoginUser_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	credentials := struct {
 		Email    string `json:"email"`
@@ -154,7 +157,8 @@ func TestLoginUser_Success(t *testing.T) {
 
 	token := "jwt.token.here"
 
-	mockService.On("Login", credentials.Email, credentials.Password).Return(token, nil)
+	mockService.On("Login", credentials.Email, credentials.Password, mock.Anything).
+		Return(&entity.AuthTokens{UserID: 1, AccessToken: token, RefreshToken: "refresh"}, nil)
 
 	body, _ := json.Marshal(credentials)
 
@@ -175,6 +179,8 @@ func TestLoginUser_Success(t *testing.T) {
 	err = json.Unmarshal(rr.Body.Bytes(), &resp)
 	assert.NoError(t, err)
 	assert.Equal(t, token, resp.Token)
+	assert.Equal(t, "refresh", resp.RefreshToken)
+	assert.Equal(t, uint64(1), resp.UserID)
 
 	mockService.AssertExpectations(t)
 }
@@ -183,7 +189,7 @@ func TestLoginUser_Failure_Unauthorized(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	credentials := struct {
 		Email    string `json:"email"`
@@ -193,7 +199,7 @@ func TestLoginUser_Failure_Unauthorized(t *testing.T) {
 		Password: "wrongpassword",
 	}
 
-	mockService.On("Login", credentials.Email, credentials.Password).Return("", errors.New("invalid credentials"))
+	mockService.On("Login", credentials.Email, credentials.Password, mock.Anything).Return(nil, errors.New("invalid credentials"))
 
 	body, _ := json.Marshal(credentials)
 
@@ -218,11 +224,96 @@ func TestLoginUser_Failure_Unauthorized(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestLoginUser_Failure_Suspended(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(service.MockUserService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
+	mockService.On("Login", "jane@example.com", "password123", mock.Anything).Return(nil, service.ErrAccountDisabled)
+
+	req, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewBufferString(`{"email":"jane@example.com","password":"password123"}`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	router := gin.Default()
+	router.POST("/users/login", controller.LoginUser)
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestLoginUser_Locked(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(service.MockUserService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
+
+	mockService.On("Login", "jane@example.com", "guess", mock.Anything).
+		Return(nil, &service.LoginLockedError{RetryAt: time.Now().Add(90 * time.Second)})
+
+	body, _ := json.Marshal(map[string]string{"email": "jane@example.com", "password": "guess"})
+	req, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewBuffer(body))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	router := gin.Default()
+	router.POST("/users/login", controller.LoginUser)
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	retryAfter, err := strconv.Atoi(rr.Header().Get("Retry-After"))
+	assert.NoError(t, err)
+	assert.InDelta(t, 90, retryAfter, 2)
+}
+
+func TestLoginUser_TwoFactorChallenge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(service.MockUserService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
+
+	mockService.On("Login", "jane@example.com", "password123", mock.Anything).
+		Return(&entity.AuthTokens{UserID: 1, ChallengeToken: "challenge", ChallengeExpiresAt: time.Now().Add(time.Minute)}, nil)
+	mockService.On("CompleteTwoFactorLogin", "challenge", "123456", mock.Anything).
+		Return(&entity.AuthTokens{UserID: 1, AccessToken: "access", RefreshToken: "refresh"}, nil)
+
+	router := gin.Default()
+	router.POST("/users/login", controller.LoginUser)
+	router.POST("/users/login/2fa", controller.LoginTwoFactor)
+
+	body, _ := json.Marshal(map[string]string{"email": "jane@example.com", "password": "password123"})
+	req, _ := http.NewRequest(http.MethodPost, "/users/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var challenge response.TwoFactorChallengeResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &challenge))
+	assert.True(t, challenge.TwoFactorRequired)
+	assert.Equal(t, "challenge", challenge.ChallengeToken)
+
+	body, _ = json.Marshal(map[string]string{"challenge_token": "challenge", "code": "123456"})
+	req, _ = http.NewRequest(http.MethodPost, "/users/login/2fa", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var tokens response.TokenResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &tokens))
+	assert.Equal(t, "access", tokens.Token)
+	mockService.AssertExpectations(t)
+}
+
 func TestChangePassword_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	userID := uint64(1)
 	oldPassword := "oldpassword"
@@ -265,7 +356,7 @@ func TestChangePassword_Failure_InvalidUserID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	invalidUserID := "abc"
 	request := struct {
@@ -303,7 +394,7 @@ func TestChangePassword_Failure_ServiceError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	userID := uint64(1)
 	oldPassword := "oldpassword"
@@ -346,7 +437,7 @@ func TestUpdateUser_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	userID := uint64(1)
 	input := entity.User{
@@ -361,7 +452,7 @@ func TestUpdateUser_Success(t *testing.T) {
 
 	input.ID = userID
 
-	mockService.On("UpdateUser", &input).Return(nil)
+	mockService.On("UpdateUser", &input).Return(false, nil)
 
 	body, _ := json.Marshal(input)
 
@@ -386,11 +477,38 @@ func TestUpdateUser_Success(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestUpdateUser_EmailChanged(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(service.MockUserService)
+	mockAccountService := new(service.MockAccountService)
+	controller := NewUserController(mockService, mockAccountService)
+
+	mockService.On("UpdateUser", mock.MatchedBy(func(user *entity.User) bool {
+		return user.ID == 1 && user.Email == "john@new.example.com"
+	})).Return(true, nil)
+	mockAccountService.On("SendVerificationEmail", mock.Anything, uint64(1)).Return(nil).Once()
+
+	req, err := http.NewRequest(http.MethodPut, "/users/1", bytes.NewBufferString(`{"username":"johndoe","email":"john@new.example.com"}`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	router := gin.Default()
+	router.PUT("/users/:user_id", controller.UpdateUser)
+	router.ServeHTTP(rr, req)
+
+	// The new address has to be verified again
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockService.AssertExpectations(t)
+	mockAccountService.AssertExpectations(t)
+}
+
 func TestUpdateUser_Failure_InvalidUserID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	invalidUserID := "abc"
 	input := entity.User{
@@ -433,7 +551,7 @@ func TestUpdateAvatar_Success(t *testing.T) {
 	env.EnvConfig.AvatarFolder = "avatars"
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	userID := uint64(1)
 	fileName := "avatar.jpg"
@@ -475,7 +593,7 @@ func TestUpdateAvatar_Failure_MissingFileName(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	userID := uint64(1)
 
@@ -504,7 +622,7 @@ func TestLoadAvatar_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	userID := uint64(1)
 	expectedURL := "https://s3.amazonaws.com/bucket/avatars/avatar.jpg?presigned"
@@ -531,7 +649,7 @@ func TestLoadAvatar_Failure_InvalidUserID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	invalidUserID := "abc"
 
@@ -559,7 +677,7 @@ func TestLoadAvatar_Failure_ServiceError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	userID := uint64(1)
 
@@ -589,7 +707,7 @@ func TestGetUser_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	userID := uint64(1)
 	user := &entity.User{
@@ -627,7 +745,7 @@ func TestGetUser_Failure_InvalidUserID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	invalidUserID := "abc"
 
@@ -655,7 +773,7 @@ func TestGetUser_Failure_ServiceError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	userID := uint64(1)
 
@@ -685,7 +803,7 @@ func TestGetAllUsers_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	users := []entity.User{
 		{
@@ -732,7 +850,7 @@ func TestGetAllUsers_Failure_ServiceError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	mockService.On("GetAllUsers").Return(nil, errors.New("db error"))
 
@@ -760,7 +878,7 @@ func TestDeleteUser_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	userID := uint64(1)
 
@@ -790,7 +908,7 @@ func TestDeleteUser_Failure_InvalidUserID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	invalidUserID := "abc"
 
@@ -818,7 +936,7 @@ func TestDeleteUser_Failure_UserNotFound(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	userID := uint64(1)
 
@@ -848,7 +966,7 @@ func TestDeleteUser_Failure_ServiceError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(service.MockUserService)
-	controller := NewUserController(mockService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
 
 	userID := uint64(1)
 
@@ -873,3 +991,134 @@ func TestDeleteUser_Failure_ServiceError(t *testing.T) {
 
 	mockService.AssertExpectations(t)
 }
+
+func TestRefreshToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(service.MockUserService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
+
+	mockService.On("RefreshToken", "valid").Return(&entity.AuthTokens{UserID: 1, AccessToken: "access", RefreshToken: "next"}, nil)
+	mockService.On("RefreshToken", "used").Return(nil, service.ErrInvalidRefreshToken)
+
+	router := gin.Default()
+	router.POST("/users/refresh", controller.RefreshToken)
+
+	tests := []struct {
+		body       string
+		wantStatus int
+	}{
+		{`{"refresh_token":"valid"}`, http.StatusOK},
+		{`{"refresh_token":"used"}`, http.StatusUnauthorized},
+		{`{}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodPost, "/users/refresh", bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, tt.wantStatus, rr.Code, tt.body)
+	}
+
+	mockService.AssertExpectations(t)
+}
+
+func TestLogout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(service.MockUserService)
+	controller := NewUserController(mockService, new(service.MockAccountService))
+
+	mockService.On("Logout", "refresh", true).Return(nil)
+
+	router := gin.Default()
+	router.POST("/users/logout", controller.Logout)
+
+	req, _ := http.NewRequest(http.MethodPost, "/users/logout", bytes.NewBufferString(`{"refresh_token":"refresh","all":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestForgotPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockAccountService := new(service.MockAccountService)
+	controller := NewUserController(new(service.MockUserService), mockAccountService)
+
+	mockAccountService.On("ForgotPassword", mock.Anything, "jane@example.com").Return(nil)
+
+	router := gin.Default()
+	router.POST("/users/forgot-password", controller.ForgotPassword)
+
+	tests := []struct {
+		body       string
+		wantStatus int
+	}{
+		{`{"email":"jane@example.com"}`, http.StatusAccepted},
+		{`{"email":"not-an-email"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodPost, "/users/forgot-password", bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, tt.wantStatus, rr.Code, tt.body)
+	}
+
+	mockAccountService.AssertExpectations(t)
+}
+
+func TestResetPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockAccountService := new(service.MockAccountService)
+	controller := NewUserController(new(service.MockUserService), mockAccountService)
+
+	mockAccountService.On("ResetPassword", "good", "newPassword123").Return(nil)
+	mockAccountService.On("ResetPassword", "used", "newPassword123").Return(service.ErrInvalidAccountToken)
+
+	router := gin.Default()
+	router.POST("/users/reset-password", controller.ResetPassword)
+
+	tests := []struct {
+		body       string
+		wantStatus int
+	}{
+		{`{"token":"good","new_password":"newPassword123"}`, http.StatusOK},
+		{`{"token":"used","new_password":"newPassword123"}`, http.StatusBadRequest},
+		{`{"token":"good"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodPost, "/users/reset-password", bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, tt.wantStatus, rr.Code, tt.body)
+	}
+
+	mockAccountService.AssertExpectations(t)
+}
+
+func TestVerifyEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockAccountService := new(service.MockAccountService)
+	controller := NewUserController(new(service.MockUserService), mockAccountService)
+
+	mockAccountService.On("VerifyEmail", "good").Return(nil)
+
+	router := gin.Default()
+	router.POST("/users/verify-email", controller.VerifyEmail)
+
+	req, _ := http.NewRequest(http.MethodPost, "/users/verify-email", bytes.NewBufferString(`{"token":"good"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockAccountService.AssertExpectations(t)
+}
@@ -1,12 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"mlvt/internal/entity"
 	"mlvt/internal/infra/env"
 	"mlvt/internal/infra/zap-logging/log"
+	"mlvt/internal/pkg/middleware"
 	"mlvt/internal/pkg/response"
 	"mlvt/internal/service"
 
@@ -57,11 +59,12 @@ func (h *VideoController) GetVideoStatus(c *gin.Context) {
 // UpdateVideoStatusRequest represents the request body for updating video status
 type UpdateVideoStatusRequest struct {
 	Status entity.VideoStatus `json:"status" binding:"required,oneof=raw processing failed success"`
+	Reason string             `json:"reason"`
 }
 
 // UpdateVideoStatus godoc
 // @Summary Update the status of a video
-// @Description Update the status of a specific video by its ID
+// @Description Update the status of a specific video by its ID. Only transitions allowed by the video status state machine are accepted.
 // @Tags Videos
 // @Accept  json
 // @Produce  json
@@ -70,6 +73,7 @@ type UpdateVideoStatusRequest struct {
 // @Success 200 {object} response.MessageResponse
 // @Failure 400 {object} response.ErrorResponse
 // @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /videos/{video_id}/status [put]
 func (vc *VideoController) UpdateVideoStatus(c *gin.Context) {
@@ -86,11 +90,22 @@ func (vc *VideoController) UpdateVideoStatus(c *gin.Context) {
 		return
 	}
 
-	err = vc.videoService.UpdateVideoStatus(videoID, req.Status)
+	actorID := entity.SystemActorID
+	if user := middleware.CurrentUser(c); user != nil {
+		actorID = user.ID
+	}
+
+	err = vc.videoService.UpdateVideoStatus(videoID, req.Status, actorID, req.Reason)
 	if err != nil {
-		if err.Error() == "no video found with id "+strconv.FormatUint(videoID, 10) {
+		var transitionErr *service.InvalidStatusTransitionError
+		switch {
+		case errors.Is(err, service.ErrVideoNotFound):
 			c.JSON(http.StatusNotFound, response.ErrorResponse{Error: "video not found"})
-		} else {
+		case errors.As(err, &transitionErr), errors.Is(err, service.ErrVideoStatusConflict):
+			c.JSON(http.StatusConflict, response.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, service.ErrInvalidVideoStatus):
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid input"})
+		default:
 			c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
 		}
 		return
@@ -99,54 +114,35 @@ func (vc *VideoController) UpdateVideoStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, response.MessageResponse{Message: "status updated successfully"})
 }
 
-// AddVideo handles adding a new video
-// @Summary Add a new video
-// @Description Creates a new video record in the system
+// GetVideoStatusHistory godoc
+// @Summary Get the status history of a video
+// @Description Retrieve every status transition of a video, oldest first, with the actor and reason
 // @Tags Videos
-// @Accept json
-// @Produce json
-// @Param video body entity.Video true "Video data"
-// @Success 201 {object} response.MessageResponse
+// @Produce  json
+// @Param   video_id path     uint64 true "Video ID"
+// @Success 200 {object} response.VideoStatusHistoryResponse
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
-// @Router /videos [post]
-func (h *VideoController) AddVideo(c *gin.Context) {
-	var video entity.Video
-	if err := c.ShouldBindJSON(&video); err != nil {
-		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
-		return
-	}
-
-	if err := h.videoService.CreateVideo(&video); err != nil {
-		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
+// @Router /videos/{video_id}/status/history [get]
+func (vc *VideoController) GetVideoStatusHistory(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("video_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid video ID"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, response.MessageResponse{Message: "Video added successfully"})
-}
-
-// GenerateUploadURLForVideo generates a presigned URL for uploading a video file
-// @Summary Generate presigned upload URL for a video
-// @Description Generates a presigned URL to upload a video file to S3
-// @Tags Videos
-// @Produce json
-// @Param file_name query string true "Name of the video file"
-// @Param file_type query string true "Type of the video file (e.g., video/mp4)"
-// @Success 200 {object} map[string]string "upload_url"
-// @Failure 500 {object} response.ErrorResponse
-// @Router /videos/generate-upload-url/video [post]
-func (h *VideoController) GenerateUploadURLForVideo(c *gin.Context) {
-	folder := env.EnvConfig.VideosFolder
-	fileName := c.Query("file_name")
-	fileType := c.Query("file_type")
-
-	url, err := h.videoService.GeneratePresignedUploadURLForVideo(folder, fileName, fileType)
+	history, err := vc.videoService.ListVideoStatusHistory(videoID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
+		if errors.Is(err, service.ErrVideoNotFound) {
+			c.JSON(http.StatusNotFound, response.ErrorResponse{Error: "video not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"upload_url": url})
+	c.JSON(http.StatusOK, response.VideoStatusHistoryResponse{History: history})
 }
 
 // GenerateUploadURLForImage generates a presigned URL for uploading an image file
@@ -253,7 +249,7 @@ func (h *VideoController) GetVideoByID(c *gin.Context) {
 	video, videoURL, imageURL, err := h.videoService.GetVideoByID(videoID)
 	if err != nil {
 		log.Errorf("Error fetching video by ID %d: %v", videoID, err)
-		if err.Error() == "video not found" {
+		if errors.Is(err, service.ErrVideoNotFound) {
 			c.JSON(http.StatusNotFound, response.ErrorResponse{Error: "video not found"})
 		} else {
 			c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
@@ -288,7 +284,7 @@ func (h *VideoController) DeleteVideo(c *gin.Context) {
 	}
 
 	if err := h.videoService.DeleteVideo(videoID); err != nil {
-		if err.Error() == "video not found" {
+		if errors.Is(err, service.ErrVideoNotFound) {
 			c.JSON(http.StatusNotFound, response.ErrorResponse{Error: "video not found"})
 		} else {
 			c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
@@ -299,13 +295,20 @@ func (h *VideoController) DeleteVideo(c *gin.Context) {
 	c.JSON(http.StatusOK, response.MessageResponse{Message: "Video deleted successfully"})
 }
 
-// ListVideosByUserID handles listing all videos for a specific user along with presigned image URLs
+// ListVideosByUserID handles listing a page of videos for a specific user along with presigned image URLs
 // @Summary List videos by user ID
-// @Description Fetches all videos for a specific user along with presigned image URLs
+// @Description Fetches a page of videos for a specific user along with presigned image URLs
 // @Tags Videos
 // @Produce json
 // @Param user_id path uint64 true "User ID"
-// @Success 200 {object} map[string]interface{} "videos, frames"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field: created_at, title, duration (default created_at)"
+// @Param order query string false "asc or desc (default desc)"
+// @Param status query string false "Only items with this status"
+// @Param created_after query string false "Only items created at or after this RFC 3339 time or date"
+// @Param created_before query string false "Only items created before this RFC 3339 time or date"
+// @Success 200 {object} response.VideosResponse "videos, frames, next_cursor"
 // @Failure 400 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /videos/user/{user_id} [get]
@@ -317,14 +320,16 @@ func (h *VideoController) ListVideosByUserID(c *gin.Context) {
 		return
 	}
 
-	videos, frames, err := h.videoService.ListVideosByUserID(userID)
+	opts, ok := bindListOptions(c)
+	if !ok {
+		return
+	}
+
+	videos, frames, nextCursor, err := h.videoService.ListVideosByUserID(userID, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+		respondListError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"videos": videos,
-		"frames": frames,
-	})
+	c.JSON(http.StatusOK, response.VideosResponse{Videos: videos, Frames: frames, NextCursor: nextCursor})
 }
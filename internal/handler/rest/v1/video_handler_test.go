@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -16,7 +17,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
 )
 
 // setupRouter initializes the Gin router with the VideoController routes
@@ -28,8 +28,7 @@ func setupRouter(controller *VideoController) *gin.Engine {
 	// Register routes
 	router.GET("/videos/:video_id/status", controller.GetVideoStatus)
 	router.PUT("/videos/:video_id/status", controller.UpdateVideoStatus)
-	router.POST("/videos", controller.AddVideo)
-	router.POST("/videos/generate-upload-url/video", controller.GenerateUploadURLForVideo)
+	router.GET("/videos/:video_id/status/history", controller.GetVideoStatusHistory)
 	router.POST("/videos/generate-upload-url/image", controller.GenerateUploadURLForImage)
 	router.GET("/videos/:video_id/download-url/video", controller.GenerateDownloadURLForVideo)
 	router.GET("/videos/:video_id/download-url/image", controller.GenerateDownloadURLForImage)
@@ -122,7 +121,7 @@ func TestUpdateVideoStatus(t *testing.T) {
 		videoID := uint64(1)
 		newStatus := entity.StatusProcessing
 
-		mockService.On("UpdateVideoStatus", videoID, newStatus).Return(nil)
+		mockService.On("UpdateVideoStatus", videoID, newStatus, entity.SystemActorID, "").Return(nil)
 
 		reqBody := UpdateVideoStatusRequest{
 			Status: newStatus,
@@ -139,7 +138,7 @@ func TestUpdateVideoStatus(t *testing.T) {
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.Equal(t, "status updated successfully", resp.Message)
 
-		mockService.AssertCalled(t, "UpdateVideoStatus", videoID, newStatus)
+		mockService.AssertCalled(t, "UpdateVideoStatus", videoID, newStatus, entity.SystemActorID, "")
 	})
 
 	t.Run("Invalid Video ID", func(t *testing.T) {
@@ -177,8 +176,7 @@ func TestUpdateVideoStatus(t *testing.T) {
 	t.Run("Video Not Found", func(t *testing.T) {
 		videoID := uint64(2)
 		newStatus := entity.StatusFailed
-		errMsg := "no video found with id 2"
-		mockService.On("UpdateVideoStatus", videoID, newStatus).Return(errors.New(errMsg))
+		mockService.On("UpdateVideoStatus", videoID, newStatus, entity.SystemActorID, "").Return(service.ErrVideoNotFound)
 
 		reqBody := UpdateVideoStatusRequest{
 			Status: newStatus,
@@ -195,14 +193,14 @@ func TestUpdateVideoStatus(t *testing.T) {
 		assert.Equal(t, http.StatusNotFound, w.Code)
 		assert.Equal(t, "video not found", resp.Error)
 
-		mockService.AssertCalled(t, "UpdateVideoStatus", videoID, newStatus)
+		mockService.AssertCalled(t, "UpdateVideoStatus", videoID, newStatus, entity.SystemActorID, "")
 	})
 
 	t.Run("Internal Server Error", func(t *testing.T) {
 		videoID := uint64(3)
 		newStatus := entity.StatusSuccess
 		errMsg := "database update failed"
-		mockService.On("UpdateVideoStatus", videoID, newStatus).Return(errors.New(errMsg))
+		mockService.On("UpdateVideoStatus", videoID, newStatus, entity.SystemActorID, "").Return(errors.New(errMsg))
 
 		reqBody := UpdateVideoStatusRequest{
 			Status: newStatus,
@@ -219,99 +217,61 @@ func TestUpdateVideoStatus(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		assert.Equal(t, "internal server error", resp.Error)
 
-		mockService.AssertCalled(t, "UpdateVideoStatus", videoID, newStatus)
+		mockService.AssertCalled(t, "UpdateVideoStatus", videoID, newStatus, entity.SystemActorID, "")
 	})
 }
 
-func TestAddVideo(t *testing.T) {
+func TestUpdateVideoStatus_IllegalTransition(t *testing.T) {
 	mockService := new(service.MockVideoService)
 	controller := NewVideoController(mockService)
 	router := setupRouter(controller)
 
-	t.Run("Success", func(t *testing.T) {
-		video := entity.Video{
-			ID:          1,
-			Title:       "Test Video",
-			Duration:    120,
-			Description: "A test video",
-			FileName:    "test.mp4",
-			Folder:      "videos",
-			Image:       "test.jpg",
-			Status:      entity.StatusRaw,
-			UserID:      1,
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-		}
+	transitionErr := &service.InvalidStatusTransitionError{From: entity.StatusFailed, To: entity.StatusRaw}
+	mockService.On("UpdateVideoStatus", uint64(1), entity.StatusRaw, entity.SystemActorID, "retry").Return(transitionErr)
 
-		// Use mock.Anything to ignore the actual Video instance
-		mockService.On("CreateVideo", mock.AnythingOfType("*entity.Video")).Return(nil)
+	body := []byte(`{"status": "raw", "reason": "retry"}`)
+	req, _ := http.NewRequest("PUT", "/videos/1/status", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
 
-		body, _ := json.Marshal(video)
-		req, _ := http.NewRequest("POST", "/videos", bytes.NewBuffer(body))
-		req.Header.Set("Content-Type", "application/json")
-		w := httptest.NewRecorder()
-		router.ServeHTTP(w, req)
-
-		var resp response.MessageResponse
-		err := json.Unmarshal(w.Body.Bytes(), &resp)
-		assert.NoError(t, err)
-		assert.Equal(t, http.StatusCreated, w.Code)
-		assert.Equal(t, "Video added successfully", resp.Message)
-
-		mockService.AssertCalled(t, "CreateVideo", mock.AnythingOfType("*entity.Video"))
-	})
+	var resp response.ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "cannot change video status from failed to raw", resp.Error)
 }
 
-func TestGenerateUploadURLForVideo(t *testing.T) {
+func TestGetVideoStatusHistory(t *testing.T) {
 	mockService := new(service.MockVideoService)
 	controller := NewVideoController(mockService)
 	router := setupRouter(controller)
 
-	// Mock environment variable
-	originalFolder := env.EnvConfig.VideosFolder
-	env.EnvConfig.VideosFolder = "test_videos"
-	defer func() {
-		env.EnvConfig.VideosFolder = originalFolder
-	}()
-
 	t.Run("Success", func(t *testing.T) {
-		fileName := "video.mp4"
-		fileType := "video/mp4"
-		uploadURL := "https://s3.amazonaws.com/test_videos/video.mp4?signature=abc"
-
-		mockService.On("GeneratePresignedUploadURLForVideo", "test_videos", fileName, fileType).Return(uploadURL, nil)
+		history := []entity.VideoStatusHistory{
+			{ID: 1, VideoID: 1, FromStatus: entity.StatusRaw, ToStatus: entity.StatusProcessing, Reason: "job 1 attempt 1 started"},
+		}
+		mockService.On("ListVideoStatusHistory", uint64(1)).Return(history, nil)
 
-		req, _ := http.NewRequest("POST", "/videos/generate-upload-url/video?file_name=video.mp4&file_type=video/mp4", nil)
+		req, _ := http.NewRequest("GET", "/videos/1/status/history", nil)
 		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
 
-		var resp map[string]string
+		var resp response.VideoStatusHistoryResponse
 		err := json.Unmarshal(w.Body.Bytes(), &resp)
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Equal(t, uploadURL, resp["upload_url"])
-
-		mockService.AssertCalled(t, "GeneratePresignedUploadURLForVideo", "test_videos", fileName, fileType)
+		assert.Equal(t, history, resp.History)
 	})
 
-	t.Run("Internal Server Error", func(t *testing.T) {
-		fileName := "video2.mp4"
-		fileType := "video/mp4"
-		errMsg := "S3 service unavailable"
-
-		mockService.On("GeneratePresignedUploadURLForVideo", "test_videos", fileName, fileType).Return("", errors.New(errMsg))
+	t.Run("Video Not Found", func(t *testing.T) {
+		mockService.On("ListVideoStatusHistory", uint64(2)).Return(nil, service.ErrVideoNotFound)
 
-		req, _ := http.NewRequest("POST", "/videos/generate-upload-url/video?file_name=video2.mp4&file_type=video/mp4", nil)
+		req, _ := http.NewRequest("GET", "/videos/2/status/history", nil)
 		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
 
-		var resp response.ErrorResponse
-		err := json.Unmarshal(w.Body.Bytes(), &resp)
-		assert.NoError(t, err)
-		assert.Equal(t, http.StatusInternalServerError, w.Code)
-		assert.Equal(t, errMsg, resp.Error)
-
-		mockService.AssertCalled(t, "GeneratePresignedUploadURLForVideo", "test_videos", fileName, fileType)
+		assert.Equal(t, http.StatusNotFound, w.Code)
 	})
 }
 
@@ -555,7 +515,7 @@ func TestGetVideoByID(t *testing.T) {
 
 	t.Run("Video Not Found", func(t *testing.T) {
 		videoID := uint64(2)
-		mockService.On("GetVideoByID", videoID).Return((*entity.Video)(nil), "", "", errors.New("video not found"))
+		mockService.On("GetVideoByID", videoID).Return((*entity.Video)(nil), "", "", service.ErrVideoNotFound)
 
 		req, _ := http.NewRequest("GET", "/videos/2", nil)
 		w := httptest.NewRecorder()
@@ -625,7 +585,7 @@ func TestDeleteVideo(t *testing.T) {
 
 	t.Run("Video Not Found", func(t *testing.T) {
 		videoID := uint64(2)
-		mockService.On("DeleteVideo", videoID).Return(errors.New("video not found"))
+		mockService.On("DeleteVideo", videoID).Return(service.ErrVideoNotFound)
 
 		req, _ := http.NewRequest("DELETE", "/videos/2", nil)
 		w := httptest.NewRecorder()
@@ -665,7 +625,7 @@ func TestListVideosByUserID(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		userID := uint64(1)
-		fixedTime := time.Date(2024, time.October, 14, 21, 35, 25, 616671000, time.Local)
+		fixedTime := time.Date(2024, time.October, 14, 21, 35, 25, 616671000, time.UTC)
 
 		videos := []entity.Video{
 			{
@@ -707,23 +667,25 @@ func TestListVideosByUserID(t *testing.T) {
 		}
 
 		// Set up mock expectation
-		mockService.On("ListVideosByUserID", userID).Return(videos, frames, nil)
+		mockService.On("ListVideosByUserID", userID, entity.ListOptions{}).Return(videos, frames, "next", nil)
 
 		req, _ := http.NewRequest("GET", "/videos/user/1", nil)
 		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
 
 		var resp struct {
-			Videos []entity.Video `json:"videos"`
-			Frames []entity.Frame `json:"frames"`
+			Videos     []entity.Video `json:"videos"`
+			Frames     []entity.Frame `json:"frames"`
+			NextCursor string         `json:"next_cursor"`
 		}
 		err := json.Unmarshal(w.Body.Bytes(), &resp)
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.Equal(t, videos, resp.Videos)
 		assert.Equal(t, frames, resp.Frames)
+		assert.Equal(t, "next", resp.NextCursor)
 
-		mockService.AssertCalled(t, "ListVideosByUserID", userID)
+		mockService.AssertCalled(t, "ListVideosByUserID", userID, entity.ListOptions{})
 	})
 
 	t.Run("Invalid User ID", func(t *testing.T) {
@@ -742,7 +704,7 @@ func TestListVideosByUserID(t *testing.T) {
 		userID := uint64(2)
 
 		// Set up mock to return empty slices and an error
-		mockService.On("ListVideosByUserID", userID).Return([]entity.Video{}, []entity.Frame{}, errors.New("database query failed"))
+		mockService.On("ListVideosByUserID", userID, entity.ListOptions{}).Return([]entity.Video{}, []entity.Frame{}, "", errors.New("database query failed"))
 
 		req, _ := http.NewRequest("GET", "/videos/user/2", nil)
 		w := httptest.NewRecorder()
@@ -754,6 +716,39 @@ func TestListVideosByUserID(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		assert.Equal(t, "internal server error", resp.Error)
 
-		mockService.AssertCalled(t, "ListVideosByUserID", userID)
+		mockService.AssertCalled(t, "ListVideosByUserID", userID, entity.ListOptions{})
+	})
+
+	t.Run("List Options", func(t *testing.T) {
+		userID := uint64(3)
+		after := time.Date(2024, time.October, 1, 0, 0, 0, 0, time.UTC)
+		opts := entity.ListOptions{Limit: 5, Cursor: "abc", SortBy: "title", Order: "asc", Status: "raw", CreatedAfter: &after}
+
+		mockService.On("ListVideosByUserID", userID, opts).Return([]entity.Video{}, []entity.Frame{}, "", nil)
+
+		req, _ := http.NewRequest("GET", "/videos/user/3?limit=5&cursor=abc&sort=title&order=asc&status=raw&created_after=2024-10-01", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertCalled(t, "ListVideosByUserID", userID, opts)
+	})
+
+	t.Run("Invalid List Options", func(t *testing.T) {
+		userID := uint64(4)
+		mockService.On("ListVideosByUserID", userID, entity.ListOptions{SortBy: "size"}).
+			Return([]entity.Video{}, []entity.Frame{}, "", fmt.Errorf("%w: cannot sort by \"size\"", service.ErrInvalidListOptions))
+
+		req, _ := http.NewRequest("GET", "/videos/user/4?sort=size", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		for _, query := range []string{"limit=0", "limit=abc", "created_before=yesterday"} {
+			req, _ := http.NewRequest("GET", "/videos/user/4?"+query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
 	})
 }
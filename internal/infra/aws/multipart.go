@@ -0,0 +1,152 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"mlvt/internal/infra/storage"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// CreateMultipartUpload starts a multipart upload and returns its S3 upload ID
+func (s *S3Client) CreateMultipartUpload(folder string, fileName string, fileType string) (string, error) {
+	fullPath, err := storage.ObjectKey(folder, fileName)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := s.Client.CreateMultipartUpload(context.TODO(), &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(s.Bucket),
+		Key:         aws.String(fullPath),
+		ContentType: aws.String(fileType),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create multipart upload for %s: %v", fullPath, err)
+	}
+	return aws.ToString(output.UploadId), nil
+}
+
+// GeneratePresignedUploadPartURL generates a presigned PUT URL for one part of a multipart upload
+func (s *S3Client) GeneratePresignedUploadPartURL(folder string, fileName string, uploadID string, partNumber int32, opts ...storage.PresignOption) (string, error) {
+	fullPath, err := storage.ObjectKey(folder, fileName)
+	if err != nil {
+		return "", err
+	}
+	if partNumber < 1 || partNumber > storage.MaxParts {
+		return "", fmt.Errorf("part number must be between 1 and %d", storage.MaxParts)
+	}
+
+	options := storage.ApplyPresignOptions(s.UploadExpiry, opts)
+	reqParams := &s3.UploadPartInput{
+		Bucket:     aws.String(s.Bucket),
+		Key:        aws.String(fullPath),
+		UploadId:   aws.String(uploadID),
+		PartNumber: aws.Int32(partNumber),
+	}
+	if options.ContentLength > 0 {
+		reqParams.ContentLength = aws.Int64(options.ContentLength)
+	}
+
+	presignReq, err := s3.NewPresignClient(s.Client).PresignUploadPart(context.TODO(), reqParams, s3.WithPresignExpires(options.Expires))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign part %d of %s: %v", partNumber, fullPath, err)
+	}
+	return presignReq.URL, nil
+}
+
+// ListUploadedParts returns every part S3 has received for a multipart upload, in part number order
+func (s *S3Client) ListUploadedParts(folder string, fileName string, uploadID string) ([]storage.UploadedPart, error) {
+	fullPath, err := storage.ObjectKey(folder, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	paginator := s3.NewListPartsPaginator(s.Client, &s3.ListPartsInput{
+		Bucket:   aws.String(s.Bucket),
+		Key:      aws.String(fullPath),
+		UploadId: aws.String(uploadID),
+	})
+
+	var parts []storage.UploadedPart
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			if isNoSuchUpload(err) {
+				return nil, storage.ErrMultipartUploadNotFound
+			}
+			return nil, fmt.Errorf("failed to list parts of %s: %v", fullPath, err)
+		}
+		for _, part := range page.Parts {
+			parts = append(parts, storage.UploadedPart{
+				PartNumber: aws.ToInt32(part.PartNumber),
+				ETag:       aws.ToString(part.ETag),
+				Size:       aws.ToInt64(part.Size),
+			})
+		}
+	}
+	return parts, nil
+}
+
+// CompleteMultipartUpload assembles the given parts into the final object
+func (s *S3Client) CompleteMultipartUpload(folder string, fileName string, uploadID string, parts []storage.UploadedPart) error {
+	fullPath, err := storage.ObjectKey(folder, fileName)
+	if err != nil {
+		return err
+	}
+
+	completed := make([]types.CompletedPart, 0, len(parts))
+	for _, part := range parts {
+		completed = append(completed, types.CompletedPart{
+			PartNumber: aws.Int32(part.PartNumber),
+			ETag:       aws.String(part.ETag),
+		})
+	}
+
+	_, err = s.Client.CompleteMultipartUpload(context.TODO(), &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.Bucket),
+		Key:             aws.String(fullPath),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		if isNoSuchUpload(err) {
+			return storage.ErrMultipartUploadNotFound
+		}
+		return fmt.Errorf("failed to complete multipart upload of %s: %v", fullPath, err)
+	}
+	return nil
+}
+
+// AbortMultipartUpload discards a multipart upload and the parts uploaded so far.
+// Aborting an upload S3 no longer knows is not an error.
+func (s *S3Client) AbortMultipartUpload(folder string, fileName string, uploadID string) error {
+	fullPath, err := storage.ObjectKey(folder, fileName)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Client.AbortMultipartUpload(context.TODO(), &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.Bucket),
+		Key:      aws.String(fullPath),
+		UploadId: aws.String(uploadID),
+	})
+	if err != nil && !isNoSuchUpload(err) {
+		return fmt.Errorf("failed to abort multipart upload of %s: %v", fullPath, err)
+	}
+	return nil
+}
+
+// isNoSuchUpload reports whether S3 answered that the multipart upload does not exist
+func isNoSuchUpload(err error) bool {
+	var noSuchUpload *types.NoSuchUpload
+	if errors.As(err, &noSuchUpload) {
+		return true
+	}
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchUpload"
+}
@@ -0,0 +1,98 @@
+package aws
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"mlvt/internal/infra/storage"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeMultipartS3 answers the multipart calls for videos/big.mp4 with upload ID "up-1"
+func fakeMultipartS3(t *testing.T, completedBody *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		w.Header().Set("Content-Type", "application/xml")
+		switch {
+		case r.Method == http.MethodPost && query.Has("uploads"):
+			io.WriteString(w, `<InitiateMultipartUploadResult><Bucket>test-bucket</Bucket><Key>videos/big.mp4</Key><UploadId>up-1</UploadId></InitiateMultipartUploadResult>`)
+		case query.Get("uploadId") != "up-1":
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `<Error><Code>NoSuchUpload</Code><Message>The specified upload does not exist.</Message></Error>`)
+		case r.Method == http.MethodGet && query.Get("part-number-marker") == "":
+			io.WriteString(w, `<ListPartsResult><UploadId>up-1</UploadId><IsTruncated>true</IsTruncated><NextPartNumberMarker>1</NextPartNumberMarker>
+				<Part><PartNumber>1</PartNumber><ETag>"e1"</ETag><Size>5242880</Size></Part></ListPartsResult>`)
+		case r.Method == http.MethodGet:
+			io.WriteString(w, `<ListPartsResult><UploadId>up-1</UploadId><IsTruncated>false</IsTruncated>
+				<Part><PartNumber>2</PartNumber><ETag>"e2"</ETag><Size>100</Size></Part></ListPartsResult>`)
+		case r.Method == http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			*completedBody = string(body)
+			io.WriteString(w, `<CompleteMultipartUploadResult><Bucket>test-bucket</Bucket><Key>videos/big.mp4</Key><ETag>"e-2"</ETag></CompleteMultipartUploadResult>`)
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+}
+
+func TestMultipartUpload(t *testing.T) {
+	var completedBody string
+	server := fakeMultipartS3(t, &completedBody)
+	defer server.Close()
+
+	s3Client := setupTestS3Client()
+	s3Client.Client = s3.New(s3.Options{
+		Region:       "us-west-2",
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+	})
+
+	uploadID, err := s3Client.CreateMultipartUpload("videos", "big.mp4", "video/mp4")
+	assert.NoError(t, err)
+	assert.Equal(t, "up-1", uploadID)
+
+	// Parts are listed across pages
+	parts, err := s3Client.ListUploadedParts("videos", "big.mp4", uploadID)
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.UploadedPart{{PartNumber: 1, ETag: `"e1"`, Size: 5242880}, {PartNumber: 2, ETag: `"e2"`, Size: 100}}, parts)
+
+	assert.NoError(t, s3Client.CompleteMultipartUpload("videos", "big.mp4", uploadID, parts))
+	assert.Contains(t, completedBody, "<PartNumber>2</PartNumber>")
+
+	_, err = s3Client.ListUploadedParts("videos", "big.mp4", "gone")
+	assert.ErrorIs(t, err, storage.ErrMultipartUploadNotFound)
+	assert.ErrorIs(t, s3Client.CompleteMultipartUpload("videos", "big.mp4", "gone", parts), storage.ErrMultipartUploadNotFound)
+
+	assert.NoError(t, s3Client.AbortMultipartUpload("videos", "big.mp4", uploadID))
+	// Aborting twice is harmless
+	assert.NoError(t, s3Client.AbortMultipartUpload("videos", "big.mp4", "gone"))
+}
+
+func TestGeneratePresignedUploadPartURL(t *testing.T) {
+	s3Client := setupTestS3Client()
+
+	presignedURL, err := s3Client.GeneratePresignedUploadPartURL("videos", "big.mp4", "up-1", 3, storage.WithContentLength(storage.MinPartSize))
+	assert.NoError(t, err)
+
+	parsed, err := url.Parse(presignedURL)
+	assert.NoError(t, err)
+	query := parsed.Query()
+	assert.Equal(t, "/videos/big.mp4", parsed.Path)
+	assert.Equal(t, "up-1", query.Get("uploadId"))
+	assert.Equal(t, "3", query.Get("partNumber"))
+	assert.True(t, strings.Contains(query.Get("X-Amz-SignedHeaders"), "content-length"))
+
+	_, err = s3Client.GeneratePresignedUploadPartURL("videos", "big.mp4", "up-1", 0)
+	assert.Error(t, err)
+}
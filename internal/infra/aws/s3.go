@@ -2,62 +2,102 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"mlvt/internal/infra/env"
 	"mlvt/internal/infra/reason"
+	"mlvt/internal/infra/storage"
 	"mlvt/internal/infra/zap-logging/log"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 )
 
-type S3ClientInterface interface {
-	GeneratePresignedURL(folder string, fileName string, fileType string) (string, error)
-}
-
+// S3Client stores objects in an S3 bucket. It also serves S3-compatible services such as MinIO.
 type S3Client struct {
-	Client *s3.Client
-	Bucket string
+	Client         *s3.Client
+	Bucket         string
+	UploadExpiry   time.Duration
+	DownloadExpiry time.Duration
 }
 
+var _ storage.Storage = (*S3Client)(nil)
+
+// NewS3Client creates a client for Amazon S3
 func NewS3Client() (*S3Client, error) {
-	// Load the default AWS configuration
-	cfg, err := config.LoadDefaultConfig(context.TODO(),
-		config.WithRegion(env.EnvConfig.AWSRegion),
-		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+	return newS3Client()
+}
+
+// NewS3CompatibleClient creates a client for an S3-compatible service such as MinIO, reached at AWS_ENDPOINT
+// with path-style addressing
+func NewS3CompatibleClient() (*S3Client, error) {
+	endpoint := env.EnvConfig.AWSEndpoint
+	if endpoint == "" {
+		return nil, fmt.Errorf("AWS_ENDPOINT is required for the %s storage driver", storage.DriverS3Compatible)
+	}
+	log.Info("Using S3-compatible endpoint: ", endpoint)
+	return newS3Client(func(o *s3.Options) {
+		o.BaseEndpoint = aws.String(endpoint)
+		o.UsePathStyle = true
+	})
+}
+
+func newS3Client(optFns ...func(*s3.Options)) (*S3Client, error) {
+	loadOptions := []func(*config.LoadOptions) error{config.WithRegion(env.EnvConfig.AWSRegion)}
+	// Without static keys the default chain applies (environment, shared config, instance role)
+	if env.EnvConfig.AWSAccessKeyID != "" {
+		loadOptions = append(loadOptions, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
 			env.EnvConfig.AWSAccessKeyID,
 			env.EnvConfig.AWSSecretKey,
 			"",
-		)),
-	)
+		)))
+	}
+
+	// Load the default AWS configuration
+	cfg, err := config.LoadDefaultConfig(context.TODO(), loadOptions...)
 	if err != nil {
 		return nil, fmt.Errorf(reason.UnableToLoadAWSConfig.Message()+": %v", err)
 	}
 
 	// Create an S3 client
-	client := s3.NewFromConfig(cfg)
+	client := s3.NewFromConfig(cfg, optFns...)
 	bucket := env.EnvConfig.AWSBucket
 	log.Info("Using bucket: ", bucket)
 
-	return &S3Client{Client: client, Bucket: bucket}, nil
+	uploadExpiry := env.EnvConfig.AWSPresignUploadExpiry
+	if uploadExpiry <= 0 {
+		uploadExpiry = storage.DefaultUploadExpiry
+	}
+	downloadExpiry := env.EnvConfig.AWSPresignDownloadExpiry
+	if downloadExpiry <= 0 {
+		downloadExpiry = storage.DefaultDownloadExpiry
+	}
+
+	return &S3Client{
+		Client:         client,
+		Bucket:         bucket,
+		UploadExpiry:   uploadExpiry,
+		DownloadExpiry: downloadExpiry,
+	}, nil
 }
 
-// GeneratePresignedURL generates a presigned URL for uploading a file to S3
-func (s *S3Client) GeneratePresignedURL(folder string, fileName string, fileType string) (string, error) {
+// GeneratePresignedUploadURL generates a presigned PUT URL for uploading a file to S3
+func (s *S3Client) GeneratePresignedUploadURL(folder string, fileName string, fileType string, opts ...storage.PresignOption) (string, error) {
 	log.Info("Folder: ", folder, ", File name: ", fileName)
-	if fileName == "" {
-		return "", fmt.Errorf("file name must not be empty")
-	}
-
-	// Combine folder and fileName to form the S3 key (path to the file)
-	fullPath := fileName
-	if folder != "" {
-		fullPath = folder + "/" + fileName // Add the folder to the file path
+	fullPath, err := storage.ObjectKey(folder, fileName)
+	if err != nil {
+		return "", err
 	}
 
+	options := storage.ApplyPresignOptions(s.UploadExpiry, opts)
 	presignClient := s3.NewPresignClient(s.Client)
 
 	reqParams := &s3.PutObjectInput{
@@ -65,11 +105,14 @@ func (s *S3Client) GeneratePresignedURL(folder string, fileName string, fileType
 		Key:         aws.String(fullPath), // Use full path (folder + fileName)
 		ContentType: aws.String(fileType),
 	}
+	if options.ContentLength > 0 {
+		reqParams.ContentLength = aws.Int64(options.ContentLength)
+	}
+	if options.ChecksumSHA256 != "" {
+		reqParams.ChecksumSHA256 = aws.String(options.ChecksumSHA256)
+	}
 
-	// Use functional options to set the expiration time
-	presignReq, err := presignClient.PresignPutObject(context.TODO(), reqParams, func(o *s3.PresignOptions) {
-		o.Expires = 15 * time.Minute // Set the expiration time for the presigned URL
-	})
+	presignReq, err := presignClient.PresignPutObject(context.TODO(), reqParams, s3.WithPresignExpires(options.Expires))
 	if err != nil {
 		log.Error(reason.FailedToPresignPutObjectRequest.Message()+": ", err)
 		return "", fmt.Errorf(reason.FailedToPresignPutObjectRequest.Message()+", %v", err)
@@ -78,3 +121,165 @@ func (s *S3Client) GeneratePresignedURL(folder string, fileName string, fileType
 	log.Info(reason.GeneratedPresignedURL.Message()+": ", presignReq.URL)
 	return presignReq.URL, nil
 }
+
+// GeneratePresignedDownloadURL generates a presigned GET URL for downloading a file from S3
+func (s *S3Client) GeneratePresignedDownloadURL(folder string, fileName string, fileType string, opts ...storage.PresignOption) (string, error) {
+	log.Info("Folder: ", folder, ", File name: ", fileName)
+	fullPath, err := storage.ObjectKey(folder, fileName)
+	if err != nil {
+		return "", err
+	}
+
+	options := storage.ApplyPresignOptions(s.DownloadExpiry, opts)
+	presignClient := s3.NewPresignClient(s.Client)
+
+	reqParams := &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(fullPath),
+	}
+	if fileType != "" {
+		reqParams.ResponseContentType = aws.String(fileType)
+	}
+	if options.ContentDisposition != "" {
+		reqParams.ResponseContentDisposition = aws.String(options.ContentDisposition)
+	}
+
+	presignReq, err := presignClient.PresignGetObject(context.TODO(), reqParams, s3.WithPresignExpires(options.Expires))
+	if err != nil {
+		log.Error(reason.FailedToPresignGetObjectRequest.Message()+": ", err)
+		return "", fmt.Errorf(reason.FailedToPresignGetObjectRequest.Message()+", %v", err)
+	}
+
+	log.Info(reason.GeneratedPresignedURL.Message()+": ", presignReq.URL)
+	return presignReq.URL, nil
+}
+
+// HeadObject returns the metadata of an object, or ErrObjectNotFound if it does not exist
+func (s *S3Client) HeadObject(folder string, fileName string) (*storage.ObjectInfo, error) {
+	fullPath, err := storage.ObjectKey(folder, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := s.Client.HeadObject(context.TODO(), &s3.HeadObjectInput{
+		Bucket:       aws.String(s.Bucket),
+		Key:          aws.String(fullPath),
+		ChecksumMode: types.ChecksumModeEnabled,
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, storage.ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to head object %s: %v", fullPath, err)
+	}
+
+	return &storage.ObjectInfo{
+		Size:           aws.ToInt64(output.ContentLength),
+		ContentType:    aws.ToString(output.ContentType),
+		ChecksumSHA256: aws.ToString(output.ChecksumSHA256),
+		ETag:           aws.ToString(output.ETag),
+		LastModified:   aws.ToTime(output.LastModified),
+	}, nil
+}
+
+// GetObject opens the content of an object, or returns ErrObjectNotFound if it does not exist.
+// The caller must close the returned reader.
+func (s *S3Client) GetObject(folder string, fileName string) (io.ReadCloser, error) {
+	fullPath, err := storage.ObjectKey(folder, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := s.Client.GetObject(context.TODO(), &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(fullPath),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, storage.ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to get object %s: %v", fullPath, err)
+	}
+	return output.Body, nil
+}
+
+// DeleteObject removes an object; deleting a missing object is not an error
+func (s *S3Client) DeleteObject(folder string, fileName string) error {
+	fullPath, err := storage.ObjectKey(folder, fileName)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Client.DeleteObject(context.TODO(), &s3.DeleteObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(fullPath),
+	})
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to delete object %s: %v", fullPath, err)
+	}
+	return nil
+}
+
+// CopyObject copies an object within the bucket. Objects over 5 GiB cannot be copied in one request.
+func (s *S3Client) CopyObject(srcFolder string, srcFileName string, dstFolder string, dstFileName string) error {
+	srcPath, err := storage.ObjectKey(srcFolder, srcFileName)
+	if err != nil {
+		return err
+	}
+	dstPath, err := storage.ObjectKey(dstFolder, dstFileName)
+	if err != nil {
+		return err
+	}
+
+	// The copy source is "bucket/key" with the key URL-encoded
+	source := s.Bucket + "/" + (&url.URL{Path: srcPath}).EscapedPath()
+	_, err = s.Client.CopyObject(context.TODO(), &s3.CopyObjectInput{
+		Bucket:     aws.String(s.Bucket),
+		Key:        aws.String(dstPath),
+		CopySource: aws.String(source),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return storage.ErrObjectNotFound
+		}
+		return fmt.Errorf("failed to copy object %s to %s: %v", srcPath, dstPath, err)
+	}
+	return nil
+}
+
+// ListObjects returns every object stored under folder, including those in nested folders
+func (s *S3Client) ListObjects(folder string) ([]storage.ObjectInfo, error) {
+	prefix := storage.FolderPrefix(folder)
+	paginator := s3.NewListObjectsV2Paginator(s.Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.Bucket),
+		Prefix: aws.String(prefix),
+	})
+
+	var objects []storage.ObjectInfo
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects under %q: %v", prefix, err)
+		}
+		for _, object := range page.Contents {
+			objects = append(objects, storage.ObjectInfo{
+				FileName:     strings.TrimPrefix(aws.ToString(object.Key), prefix),
+				Size:         aws.ToInt64(object.Size),
+				ETag:         aws.ToString(object.ETag),
+				LastModified: aws.ToTime(object.LastModified),
+			})
+		}
+	}
+	return objects, nil
+}
+
+// isNotFound reports whether S3 answered that the object or key does not exist
+func isNotFound(err error) bool {
+	var notFound *types.NotFound
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &notFound) || errors.As(err, &noSuchKey) {
+		return true
+	}
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && (apiErr.ErrorCode() == "NotFound" || apiErr.ErrorCode() == "NoSuchKey")
+}
@@ -0,0 +1,182 @@
+package aws
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"mlvt/internal/infra/storage"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTestS3Client() *S3Client {
+	client := s3.New(s3.Options{
+		Region:      "us-west-2",
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+	})
+	return &S3Client{
+		Client:         client,
+		Bucket:         "test-bucket",
+		UploadExpiry:   storage.DefaultUploadExpiry,
+		DownloadExpiry: storage.DefaultDownloadExpiry,
+	}
+}
+
+func TestGeneratePresignedUploadURL(t *testing.T) {
+	s3Client := setupTestS3Client()
+
+	presignedURL, err := s3Client.GeneratePresignedUploadURL("videos", "test.mp4", "video/mp4")
+	assert.NoError(t, err)
+
+	parsed, err := url.Parse(presignedURL)
+	assert.NoError(t, err)
+	assert.Equal(t, "/videos/test.mp4", parsed.Path)
+	assert.Equal(t, "900", parsed.Query().Get("X-Amz-Expires"))
+	assert.Equal(t, "PutObject", parsed.Query().Get("x-id"))
+}
+
+func TestGeneratePresignedDownloadURL(t *testing.T) {
+	s3Client := setupTestS3Client()
+
+	presignedURL, err := s3Client.GeneratePresignedDownloadURL("videos", "test.mp4", "video/mp4",
+		storage.AsAttachment("test.mp4"), storage.WithExpiry(5*time.Minute))
+	assert.NoError(t, err)
+
+	parsed, err := url.Parse(presignedURL)
+	assert.NoError(t, err)
+	query := parsed.Query()
+	assert.Equal(t, "/videos/test.mp4", parsed.Path)
+	assert.Equal(t, "GetObject", query.Get("x-id"))
+	assert.Equal(t, "300", query.Get("X-Amz-Expires"))
+	assert.Equal(t, "video/mp4", query.Get("response-content-type"))
+	assert.Equal(t, `attachment; filename="test.mp4"`, query.Get("response-content-disposition"))
+}
+
+func TestGeneratePresignedDownloadURLDefaultExpiry(t *testing.T) {
+	s3Client := setupTestS3Client()
+
+	presignedURL, err := s3Client.GeneratePresignedDownloadURL("", "avatar.jpg", "")
+	assert.NoError(t, err)
+
+	parsed, err := url.Parse(presignedURL)
+	assert.NoError(t, err)
+	assert.Equal(t, "/avatar.jpg", parsed.Path)
+	assert.Equal(t, "3600", parsed.Query().Get("X-Amz-Expires"))
+	assert.Empty(t, parsed.Query().Get("response-content-disposition"))
+}
+
+func TestGeneratePresignedURLEmptyFileName(t *testing.T) {
+	s3Client := setupTestS3Client()
+
+	_, err := s3Client.GeneratePresignedUploadURL("videos", "", "video/mp4")
+	assert.Error(t, err)
+
+	_, err = s3Client.GeneratePresignedDownloadURL("videos", "", "video/mp4")
+	assert.Error(t, err)
+}
+
+func TestGeneratePresignedUploadURLSignsSizeAndChecksum(t *testing.T) {
+	s3Client := setupTestS3Client()
+
+	presignedURL, err := s3Client.GeneratePresignedUploadURL("videos", "test.mp4", "video/mp4",
+		storage.WithContentLength(1024), storage.WithChecksumSHA256("47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="))
+	assert.NoError(t, err)
+
+	parsed, err := url.Parse(presignedURL)
+	assert.NoError(t, err)
+	// The checksum is signed as part of the query string, the size as a header
+	assert.Contains(t, parsed.Query().Get("X-Amz-SignedHeaders"), "content-length")
+	assert.Equal(t, "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=", parsed.Query().Get("X-Amz-Checksum-Sha256"))
+}
+
+func TestHeadGetAndDeleteObject(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodHead && r.URL.Path == "/test-bucket/videos/test.mp4":
+			w.Header().Set("Content-Length", "1024")
+			w.Header().Set("Content-Type", "video/mp4")
+			w.Header().Set("ETag", `"abc"`)
+			w.Header().Set("x-amz-checksum-sha256", "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodGet && r.URL.Path == "/test-bucket/videos/test.mp4":
+			w.Write([]byte("video"))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	s3Client := setupTestS3Client()
+	s3Client.Client = s3.New(s3.Options{
+		Region:       "us-west-2",
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+	})
+
+	info, err := s3Client.HeadObject("videos", "test.mp4")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1024), info.Size)
+	assert.Equal(t, "video/mp4", info.ContentType)
+	assert.Equal(t, "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=", info.ChecksumSHA256)
+
+	_, err = s3Client.HeadObject("videos", "missing.mp4")
+	assert.ErrorIs(t, err, storage.ErrObjectNotFound)
+
+	body, err := s3Client.GetObject("videos", "test.mp4")
+	if assert.NoError(t, err) {
+		content, _ := io.ReadAll(body)
+		body.Close()
+		assert.Equal(t, "video", string(content))
+	}
+	_, err = s3Client.GetObject("videos", "missing.mp4")
+	assert.ErrorIs(t, err, storage.ErrObjectNotFound)
+
+	assert.NoError(t, s3Client.DeleteObject("videos", "test.mp4"))
+}
+
+func TestCopyAndListObjects(t *testing.T) {
+	var copySource string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/test-bucket/videos/archive/b.mp4":
+			copySource = r.Header.Get("x-amz-copy-source")
+			w.Write([]byte(`<CopyObjectResult><ETag>"abc"</ETag></CopyObjectResult>`))
+		case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+			w.Write([]byte(`<ListBucketResult>
+				<Name>test-bucket</Name><Prefix>videos/</Prefix><IsTruncated>false</IsTruncated>
+				<Contents><Key>videos/a b.mp4</Key><Size>3</Size><ETag>"e1"</ETag></Contents>
+				<Contents><Key>videos/archive/b.mp4</Key><Size>3</Size><ETag>"abc"</ETag></Contents>
+			</ListBucketResult>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	s3Client := setupTestS3Client()
+	s3Client.Client = s3.New(s3.Options{
+		Region:       "us-west-2",
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+	})
+
+	assert.NoError(t, s3Client.CopyObject("videos", "a b.mp4", "videos/archive", "b.mp4"))
+	assert.Equal(t, "test-bucket/videos/a%20b.mp4", copySource)
+
+	objects, err := s3Client.ListObjects("videos")
+	assert.NoError(t, err)
+	assert.Len(t, objects, 2)
+	assert.Equal(t, "a b.mp4", objects[0].FileName)
+	assert.Equal(t, "archive/b.mp4", objects[1].FileName)
+}
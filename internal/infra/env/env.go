@@ -3,7 +3,9 @@ package env
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"mlvt/internal/infra/zap-logging/log"
 
@@ -19,29 +21,94 @@ const defaultEnvFilePath = ".env"
 
 // Config holds all the environment variables used in the application.
 type Config struct {
-	AppName              string
-	AppEnv               string
-	AppDebug             bool
-	ServerPort           string
-	LogLevel             string
-	LogPath              string
-	DBDriver             string
-	DBConnection         string
-	JWTSecret            string
-	SwaggerEnabled       bool
-	SwaggerURL           string
-	AWSRegion            string
-	AWSBucket            string
-	AWSAccessKeyID       string
-	AWSSecretKey         string
-	AudioFolder          string
-	AvatarFolder         string
-	VideosFolder         string
-	TranscriptionsFolder string
-	VideoFramesFolder    string
-	Language             string
-	I18NPath             string
-	RootDir              string
+	AppName                  string
+	AppEnv                   string
+	AppDebug                 bool
+	ServerPort               string
+	LogLevel                 string
+	LogPath                  string
+	DBDriver                 string
+	DBConnection             string
+	JWTSecret                string
+	JWTKeyDir                string
+	JWTSigningAlgorithm      string
+	JWTKeyRotationInterval   time.Duration
+	AccessTokenTTL           time.Duration
+	RefreshTokenTTL          time.Duration
+	LoginMaxFailures         int
+	LoginMaxIPFailures       int
+	LoginLockoutDuration     time.Duration
+	TwoFactorTTL             time.Duration
+	TOTPIssuer               string
+	OIDCProviders            []OIDCProviderConfig
+	OIDCStateTTL             time.Duration
+	APIKeyDefaultTTL         time.Duration
+	APIKeyMaxTTL             time.Duration
+	APIKeyMaxPerUser         int
+	MoMoEndpoint             string
+	MoMoPartnerCode          string
+	MoMoAccessKey            string
+	MoMoSecretKey            string
+	MoMoRedirectURL          string
+	MoMoIPNURL               string
+	MoMoRequestType          string
+	MoMoLang                 string
+	AppURL                   string
+	PasswordResetTTL         time.Duration
+	EmailVerificationTTL     time.Duration
+	MailDriver               string
+	MailFrom                 string
+	MailFileDir              string
+	SMTPHost                 string
+	SMTPPort                 int
+	SMTPUsername             string
+	SMTPPassword             string
+	SwaggerEnabled           bool
+	SwaggerURL               string
+	StorageDriver            string
+	StorageLocalRoot         string
+	StorageLocalBaseURL      string
+	StorageSigningKey        string
+	AWSRegion                string
+	AWSEndpoint              string
+	AWSBucket                string
+	AWSAccessKeyID           string
+	AWSSecretKey             string
+	AWSPresignUploadExpiry   time.Duration
+	AWSPresignDownloadExpiry time.Duration
+	AudioFolder              string
+	AvatarFolder             string
+	VideosFolder             string
+	TranscriptionsFolder     string
+	VideoFramesFolder        string
+	Language                 string
+	WorkerEnabled            bool
+	WorkerCount              int
+	WorkerPollInterval       time.Duration
+	JobStageTimeout          time.Duration
+	JobMaxAttempts           int
+	JobBackoffBase           time.Duration
+	JobBackoffMax            time.Duration
+	UploadMaxSize            int64
+	UploadPendingTTL         time.Duration
+	UploadCleanupInterval    time.Duration
+	StorageCleanupInterval   time.Duration
+	StorageDeleteAttempts    int
+	FFprobePath              string
+	MediaProbeTimeout        time.Duration
+	I18NPath                 string
+	RootDir                  string
+}
+
+// OIDCProviderConfig configures an identity provider users can sign in with
+type OIDCProviderConfig struct {
+	Name         string
+	Kind         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
 }
 
 // init loads the environment variables at startup
@@ -90,39 +157,125 @@ func initializeConfig() error {
 	logPath := resolvePath(rootDir, viper.GetString("LOG_PATH"))
 	i18nPath := resolvePath(rootDir, viper.GetString("I18N_PATH"))
 	dbPath := resolvePath(rootDir, viper.GetString("DB_CONNECTION"))
+	jwtKeyDir := viper.GetString("JWT_KEY_DIR")
+	if jwtKeyDir != "" {
+		jwtKeyDir = resolvePath(rootDir, jwtKeyDir)
+	}
+	storageLocalRoot := viper.GetString("STORAGE_LOCAL_ROOT")
+	if storageLocalRoot != "" {
+		storageLocalRoot = resolvePath(rootDir, storageLocalRoot)
+	}
 
 	EnvConfig = &Config{
-		AppName:              viper.GetString("APP_NAME"),
-		AppEnv:               viper.GetString("APP_ENV"),
-		AppDebug:             viper.GetBool("APP_DEBUG"),
-		ServerPort:           viper.GetString("SERVER_PORT"),
-		LogLevel:             viper.GetString("LOG_LEVEL"),
-		LogPath:              logPath,
-		DBDriver:             viper.GetString("DB_DRIVER"),
-		DBConnection:         dbPath,
-		JWTSecret:            viper.GetString("JWT_SECRET"),
-		SwaggerEnabled:       viper.GetBool("SWAGGER_ENABLED"),
-		SwaggerURL:           viper.GetString("SWAGGER_URL"),
-		AWSRegion:            viper.GetString("AWS_REGION"),
-		AWSBucket:            viper.GetString("AWS_BUCKET"),
-		AWSAccessKeyID:       viper.GetString("AWS_ACCESS_KEY_ID"),
-		AWSSecretKey:         viper.GetString("AWS_SECRET_KEY"),
-		Language:             viper.GetString("LANGUAGE"),
-		AudioFolder:          viper.GetString("AUDIO_FOLDER"),
-		AvatarFolder:         viper.GetString("AVATAR_FOLDER"),
-		VideosFolder:         viper.GetString("VIDEOS_FOLDER"),
-		TranscriptionsFolder: viper.GetString("TRANSCRIPTIONS_FOLDER"),
-		VideoFramesFolder:    viper.GetString("VIDEO_FRAMES_FOLDER"),
-		I18NPath:             i18nPath,
-		RootDir:              rootDir,
+		AppName:                  viper.GetString("APP_NAME"),
+		AppEnv:                   viper.GetString("APP_ENV"),
+		AppDebug:                 viper.GetBool("APP_DEBUG"),
+		ServerPort:               viper.GetString("SERVER_PORT"),
+		LogLevel:                 viper.GetString("LOG_LEVEL"),
+		LogPath:                  logPath,
+		DBDriver:                 viper.GetString("DB_DRIVER"),
+		DBConnection:             dbPath,
+		JWTSecret:                viper.GetString("JWT_SECRET"),
+		JWTKeyDir:                jwtKeyDir,
+		JWTSigningAlgorithm:      viper.GetString("JWT_SIGNING_ALG"),
+		JWTKeyRotationInterval:   viper.GetDuration("JWT_KEY_ROTATION_INTERVAL"),
+		AccessTokenTTL:           viper.GetDuration("ACCESS_TOKEN_TTL"),
+		RefreshTokenTTL:          viper.GetDuration("REFRESH_TOKEN_TTL"),
+		LoginMaxFailures:         viper.GetInt("LOGIN_MAX_FAILURES"),
+		LoginMaxIPFailures:       viper.GetInt("LOGIN_MAX_IP_FAILURES"),
+		LoginLockoutDuration:     viper.GetDuration("LOGIN_LOCKOUT_DURATION"),
+		TwoFactorTTL:             viper.GetDuration("TWO_FACTOR_TTL"),
+		TOTPIssuer:               viper.GetString("TOTP_ISSUER"),
+		OIDCProviders:            loadOIDCProviders(),
+		OIDCStateTTL:             viper.GetDuration("OIDC_STATE_TTL"),
+		APIKeyDefaultTTL:         viper.GetDuration("API_KEY_DEFAULT_TTL"),
+		APIKeyMaxTTL:             viper.GetDuration("API_KEY_MAX_TTL"),
+		APIKeyMaxPerUser:         viper.GetInt("API_KEY_MAX_PER_USER"),
+		MoMoEndpoint:             viper.GetString("MOMO_ENDPOINT"),
+		MoMoPartnerCode:          viper.GetString("MOMO_PARTNER_CODE"),
+		MoMoAccessKey:            viper.GetString("MOMO_ACCESS_KEY"),
+		MoMoSecretKey:            viper.GetString("MOMO_SECRET_KEY"),
+		MoMoRedirectURL:          viper.GetString("MOMO_REDIRECT_URL"),
+		MoMoIPNURL:               viper.GetString("MOMO_IPN_URL"),
+		MoMoRequestType:          viper.GetString("MOMO_REQUEST_TYPE"),
+		MoMoLang:                 viper.GetString("MOMO_LANG"),
+		AppURL:                   viper.GetString("APP_URL"),
+		PasswordResetTTL:         viper.GetDuration("PASSWORD_RESET_TTL"),
+		EmailVerificationTTL:     viper.GetDuration("EMAIL_VERIFICATION_TTL"),
+		MailDriver:               viper.GetString("MAIL_DRIVER"),
+		MailFrom:                 viper.GetString("MAIL_FROM"),
+		MailFileDir:              viper.GetString("MAIL_FILE_DIR"),
+		SMTPHost:                 viper.GetString("SMTP_HOST"),
+		SMTPPort:                 viper.GetInt("SMTP_PORT"),
+		SMTPUsername:             viper.GetString("SMTP_USERNAME"),
+		SMTPPassword:             viper.GetString("SMTP_PASSWORD"),
+		SwaggerEnabled:           viper.GetBool("SWAGGER_ENABLED"),
+		SwaggerURL:               viper.GetString("SWAGGER_URL"),
+		StorageDriver:            viper.GetString("STORAGE_DRIVER"),
+		StorageLocalRoot:         storageLocalRoot,
+		StorageLocalBaseURL:      viper.GetString("STORAGE_LOCAL_BASE_URL"),
+		StorageSigningKey:        viper.GetString("STORAGE_SIGNING_KEY"),
+		AWSRegion:                viper.GetString("AWS_REGION"),
+		AWSEndpoint:              viper.GetString("AWS_ENDPOINT"),
+		AWSBucket:                viper.GetString("AWS_BUCKET"),
+		AWSAccessKeyID:           viper.GetString("AWS_ACCESS_KEY_ID"),
+		AWSSecretKey:             viper.GetString("AWS_SECRET_KEY"),
+		AWSPresignUploadExpiry:   viper.GetDuration("AWS_PRESIGN_UPLOAD_EXPIRY"),
+		AWSPresignDownloadExpiry: viper.GetDuration("AWS_PRESIGN_DOWNLOAD_EXPIRY"),
+		Language:                 viper.GetString("LANGUAGE"),
+		AudioFolder:              viper.GetString("AUDIO_FOLDER"),
+		AvatarFolder:             viper.GetString("AVATAR_FOLDER"),
+		VideosFolder:             viper.GetString("VIDEOS_FOLDER"),
+		TranscriptionsFolder:     viper.GetString("TRANSCRIPTIONS_FOLDER"),
+		VideoFramesFolder:        viper.GetString("VIDEO_FRAMES_FOLDER"),
+		WorkerEnabled:            viper.GetBool("WORKER_ENABLED"),
+		WorkerCount:              viper.GetInt("WORKER_COUNT"),
+		WorkerPollInterval:       viper.GetDuration("WORKER_POLL_INTERVAL"),
+		JobStageTimeout:          viper.GetDuration("JOB_STAGE_TIMEOUT"),
+		JobMaxAttempts:           viper.GetInt("JOB_MAX_ATTEMPTS"),
+		JobBackoffBase:           viper.GetDuration("JOB_BACKOFF_BASE"),
+		JobBackoffMax:            viper.GetDuration("JOB_BACKOFF_MAX"),
+		UploadMaxSize:            viper.GetInt64("UPLOAD_MAX_SIZE"),
+		UploadPendingTTL:         viper.GetDuration("UPLOAD_PENDING_TTL"),
+		UploadCleanupInterval:    viper.GetDuration("UPLOAD_CLEANUP_INTERVAL"),
+		StorageCleanupInterval:   viper.GetDuration("STORAGE_CLEANUP_INTERVAL"),
+		StorageDeleteAttempts:    viper.GetInt("STORAGE_DELETE_MAX_ATTEMPTS"),
+		FFprobePath:              viper.GetString("FFPROBE_PATH"),
+		MediaProbeTimeout:        viper.GetDuration("MEDIA_PROBE_TIMEOUT"),
+		I18NPath:                 i18nPath,
+		RootDir:                  rootDir,
 	}
 
-	if EnvConfig.JWTSecret == "" {
-		return fmt.Errorf("required environment variable JWT_SECRET is not set")
+	// Tokens are signed with the keys in JWT_KEY_DIR; JWT_SECRET signs without them and verifies older tokens
+	if EnvConfig.JWTSecret == "" && EnvConfig.JWTKeyDir == "" {
+		return fmt.Errorf("required environment variable JWT_KEY_DIR or JWT_SECRET is not set")
 	}
 	return nil
 }
 
+// loadOIDCProviders reads the identity providers named in OIDC_PROVIDERS, e.g. "google,github,corp".
+// Each is configured by OIDC_<NAME>_KIND, _ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL and _SCOPES.
+func loadOIDCProviders() []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+	for _, name := range strings.Split(viper.GetString("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		providers = append(providers, OIDCProviderConfig{
+			Name:         name,
+			Kind:         viper.GetString(prefix + "KIND"),
+			Issuer:       viper.GetString(prefix + "ISSUER"),
+			ClientID:     viper.GetString(prefix + "CLIENT_ID"),
+			ClientSecret: viper.GetString(prefix + "CLIENT_SECRET"),
+			RedirectURL:  viper.GetString(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(strings.ReplaceAll(viper.GetString(prefix+"SCOPES"), ",", " ")),
+		})
+	}
+	return providers
+}
+
 // getProjectRootDir returns the root directory of the project
 func getProjectRootDir() (string, error) {
 	// Set your own root go manage the .env
@@ -0,0 +1,385 @@
+// Package jwtkeys manages the asymmetric keys access tokens are signed with. Keys are PEM files named
+// <kid>.pem in one directory: the newest private key of the configured algorithm signs, and every key
+// in the directory verifies, so tokens signed before a rotation stay valid until they expire. Other
+// services verify the tokens with the public keys published as a JWK set.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Supported signing algorithms
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// Defaults used when a Config field is left at zero
+const (
+	DefaultAlgorithm        = AlgRS256
+	DefaultRotationInterval = 30 * 24 * time.Hour
+	DefaultRetention        = 24 * time.Hour
+)
+
+const (
+	keyFileExt        = ".pem"
+	rsaKeyBits        = 2048
+	minRSAKeyBits     = 2048
+	minReloadInterval = time.Minute // Tokens with an unknown kid reload the directory at most this often
+)
+
+var (
+	ErrUnknownKey        = errors.New("unknown signing key")
+	ErrAlgorithmMismatch = errors.New("token algorithm does not match its key")
+)
+
+// Config selects the key directory and how keys are rotated
+type Config struct {
+	Dir              string        // Directory holding the <kid>.pem key files; created if missing
+	Algorithm        string        // RS256 (the default) or EdDSA; new keys use it and only keys of it sign
+	RotationInterval time.Duration // How long a key signs before a new one replaces it
+	Retention        time.Duration // How long a replaced key keeps verifying; never less than DefaultRetention
+}
+
+// withDefaults fills zero fields with the package defaults
+func (c Config) withDefaults() Config {
+	if c.Algorithm == "" {
+		c.Algorithm = DefaultAlgorithm
+	}
+	if c.RotationInterval <= 0 {
+		c.RotationInterval = DefaultRotationInterval
+	}
+	if c.Retention < DefaultRetention {
+		c.Retention = DefaultRetention
+	}
+	return c
+}
+
+// Key is a signing key of the set
+type Key struct {
+	ID        string
+	Algorithm string
+	CreatedAt time.Time // Modification time of the key file
+
+	private crypto.PrivateKey // nil for keys that only verify
+	public  crypto.PublicKey
+}
+
+// CanSign reports whether the private half of the key is known
+func (k *Key) CanSign() bool {
+	return k.private != nil
+}
+
+// KeySet signs tokens with the current key and verifies tokens signed by any key of the directory
+type KeySet struct {
+	config Config
+	now    func() time.Time
+
+	mu         sync.RWMutex
+	keys       map[string]*Key
+	signing    *Key
+	reloadedAt time.Time
+}
+
+// Load reads the keys in config.Dir and generates the first signing key when none is there
+func Load(config Config) (*KeySet, error) {
+	config = config.withDefaults()
+	if config.Dir == "" {
+		return nil, errors.New("a key directory is required")
+	}
+	if config.Algorithm != AlgRS256 && config.Algorithm != AlgEdDSA {
+		return nil, fmt.Errorf("unknown signing algorithm %q, expected %s or %s", config.Algorithm, AlgRS256, AlgEdDSA)
+	}
+	if err := os.MkdirAll(config.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %v", err)
+	}
+
+	s := &KeySet{config: config, now: time.Now}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	if s.SigningKey() == nil {
+		if _, err := s.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Reload rereads the key directory, picking up keys added or rotated by other servers sharing it
+func (s *KeySet) Reload() error {
+	entries, err := os.ReadDir(s.config.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to read key directory: %v", err)
+	}
+
+	keys := make(map[string]*Key)
+	var signing *Key
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != keyFileExt {
+			continue
+		}
+		key, err := readKey(filepath.Join(s.config.Dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		keys[key.ID] = key
+		if key.CanSign() && key.Algorithm == s.config.Algorithm && newer(key, signing) {
+			signing = key
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	s.signing = signing
+	s.reloadedAt = s.now()
+	return nil
+}
+
+// Rotate generates a new key, saves it to the directory and signs with it from now on
+func (s *KeySet) Rotate() (*Key, error) {
+	now := s.now()
+	key, err := generateKey(s.config.Algorithm, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeKey(filepath.Join(s.config.Dir, key.ID+keyFileExt), key); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys == nil {
+		s.keys = make(map[string]*Key)
+	}
+	s.keys[key.ID] = key
+	s.signing = key
+	return key, nil
+}
+
+// RotateIfDue replaces the signing key once it has signed for the rotation interval.
+// It returns the new key, or nil when no rotation was due.
+func (s *KeySet) RotateIfDue() (*Key, error) {
+	current := s.SigningKey()
+	if current != nil && s.now().Before(current.CreatedAt.Add(s.config.RotationInterval)) {
+		return nil, nil
+	}
+	return s.Rotate()
+}
+
+// Prune deletes the private keys that were replaced more than the retention period ago, since no
+// unexpired token was signed with them, and returns how many were deleted. Keys that only hold a
+// public half are left to whoever put them in the directory.
+func (s *KeySet) Prune() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var private []*Key
+	for _, key := range s.keys {
+		if key.CanSign() {
+			private = append(private, key)
+		}
+	}
+	sort.Slice(private, func(i, j int) bool { return newer(private[j], private[i]) })
+
+	cutoff := s.now().Add(-s.config.Retention)
+	pruned := 0
+	for i := 0; i < len(private)-1; i++ {
+		key, replacement := private[i], private[i+1]
+		if key == s.signing || replacement.CreatedAt.After(cutoff) {
+			continue
+		}
+		err := os.Remove(filepath.Join(s.config.Dir, key.ID+keyFileExt))
+		if err != nil && !os.IsNotExist(err) {
+			return pruned, fmt.Errorf("failed to delete key %s: %v", key.ID, err)
+		}
+		delete(s.keys, key.ID)
+		pruned++
+	}
+	return pruned, nil
+}
+
+// SigningKey returns the key new tokens are signed with
+func (s *KeySet) SigningKey() *Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.signing
+}
+
+// Sign returns a token carrying claims, signed with the current key and naming it in the kid header
+func (s *KeySet) Sign(claims jwt.MapClaims) (string, error) {
+	key := s.SigningKey()
+	if key == nil {
+		return "", errors.New("no signing key")
+	}
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.private)
+}
+
+// Keyfunc returns the public key that signed token, for use with jwt.Parse. A kid this server does
+// not know reloads the directory first, as another server may have just rotated.
+func (s *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrUnknownKey
+	}
+	key := s.key(kid)
+	if key == nil && s.reloadDue() {
+		if err := s.Reload(); err != nil {
+			return nil, err
+		}
+		key = s.key(kid)
+	}
+	if key == nil {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, ErrAlgorithmMismatch
+	}
+	return key.public, nil
+}
+
+func (s *KeySet) key(kid string) *Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keys[kid]
+}
+
+func (s *KeySet) reloadDue() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.now().Sub(s.reloadedAt) >= minReloadInterval
+}
+
+// newer reports whether key was created after other; any key is newer than nil
+func newer(key, other *Key) bool {
+	if other == nil {
+		return true
+	}
+	if key.CreatedAt.Equal(other.CreatedAt) {
+		return key.ID > other.ID
+	}
+	return key.CreatedAt.After(other.CreatedAt)
+}
+
+// generateKey creates a key named after its creation date and some random bytes
+func generateKey(algorithm string, now time.Time) (*Key, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	key := &Key{
+		ID:        now.UTC().Format("20060102T150405") + "-" + hex.EncodeToString(suffix),
+		Algorithm: algorithm,
+		CreatedAt: now,
+	}
+
+	switch algorithm {
+	case AlgEdDSA:
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate Ed25519 key: %v", err)
+		}
+		key.private, key.public = private, public
+	default:
+		private, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate RSA key: %v", err)
+		}
+		key.private, key.public = private, &private.PublicKey
+	}
+	return key, nil
+}
+
+// writeKey saves the private key as PKCS #8 PEM. The file is renamed into place so servers reloading
+// the directory never read half of it.
+func writeKey(path string, key *Key) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key.private)
+	if err != nil {
+		return fmt.Errorf("failed to encode key %s: %v", key.ID, err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		return fmt.Errorf("failed to write key %s: %v", key.ID, err)
+	}
+	// The file time records when the key started signing
+	if err := os.Chtimes(tmp, key.CreatedAt, key.CreatedAt); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write key %s: %v", key.ID, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write key %s: %v", key.ID, err)
+	}
+	return nil
+}
+
+// readKey parses a PEM file holding an RSA or Ed25519 private key (PKCS #8 or PKCS #1) or public key (PKIX)
+func readKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file %s: %v", path, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file %s: %v", path, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key file %s is not PEM encoded", path)
+	}
+
+	key := &Key{
+		ID:        strings.TrimSuffix(filepath.Base(path), keyFileExt),
+		CreatedAt: info.ModTime(),
+	}
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("key file %s holds an unsupported %q block", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key file %s: %v", path, err)
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Algorithm, key.private, key.public = AlgRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Algorithm, key.public = AlgRS256, k
+	case ed25519.PrivateKey:
+		key.Algorithm, key.private, key.public = AlgEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Algorithm, key.public = AlgEdDSA, k
+	default:
+		return nil, fmt.Errorf("key file %s holds an unsupported key type %T", path, parsed)
+	}
+	if public, ok := key.public.(*rsa.PublicKey); ok && public.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("RSA key %s is shorter than %d bits", key.ID, minRSAKeyBits)
+	}
+	return key, nil
+}
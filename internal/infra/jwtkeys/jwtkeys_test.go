@@ -0,0 +1,223 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func parse(s *KeySet, token string) (*jwt.Token, error) {
+	return jwt.Parse(token, s.Keyfunc)
+}
+
+func TestLoadGeneratesSigningKey(t *testing.T) {
+	for _, algorithm := range []string{AlgRS256, AlgEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			dir := t.TempDir()
+			keys, err := Load(Config{Dir: dir, Algorithm: algorithm})
+			if !assert.NoError(t, err) {
+				return
+			}
+			signing := keys.SigningKey()
+			if assert.NotNil(t, signing) {
+				assert.Equal(t, algorithm, signing.Algorithm)
+				assert.FileExists(t, filepath.Join(dir, signing.ID+".pem"))
+			}
+
+			token, err := keys.Sign(jwt.MapClaims{"userID": 1})
+			assert.NoError(t, err)
+			parsed, err := parse(keys, token)
+			if assert.NoError(t, err) {
+				assert.Equal(t, signing.ID, parsed.Header["kid"])
+				assert.Equal(t, algorithm, parsed.Header["alg"])
+			}
+
+			// A restart signs with the key already on disk
+			reloaded, err := Load(Config{Dir: dir, Algorithm: algorithm})
+			assert.NoError(t, err)
+			assert.Equal(t, signing.ID, reloaded.SigningKey().ID)
+			_, err = parse(reloaded, token)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLoadRejectsUnknownAlgorithm(t *testing.T) {
+	_, err := Load(Config{Dir: t.TempDir(), Algorithm: "HS256"})
+	assert.Error(t, err)
+	_, err = Load(Config{})
+	assert.Error(t, err)
+}
+
+func TestRotateKeepsOldKeysVerifying(t *testing.T) {
+	keys, err := Load(Config{Dir: t.TempDir(), Algorithm: AlgEdDSA})
+	assert.NoError(t, err)
+	old := keys.SigningKey()
+	oldToken, _ := keys.Sign(jwt.MapClaims{"userID": 1})
+
+	rotated, err := keys.Rotate()
+	assert.NoError(t, err)
+	assert.NotEqual(t, old.ID, rotated.ID)
+	assert.Equal(t, rotated, keys.SigningKey())
+
+	newToken, _ := keys.Sign(jwt.MapClaims{"userID": 1})
+	_, err = parse(keys, oldToken)
+	assert.NoError(t, err)
+	parsed, err := parse(keys, newToken)
+	if assert.NoError(t, err) {
+		assert.Equal(t, rotated.ID, parsed.Header["kid"])
+	}
+	assert.Len(t, keys.JWKS().Keys, 2)
+}
+
+func TestRotateIfDueAndPrune(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().Add(-90 * 24 * time.Hour).Truncate(time.Second)
+	keys := &KeySet{config: Config{Dir: dir}.withDefaults(), now: func() time.Time { return now }}
+	first, err := keys.RotateIfDue()
+	assert.NoError(t, err)
+	assert.NotNil(t, first)
+
+	now = now.Add(DefaultRotationInterval - time.Hour)
+	notDue, err := keys.RotateIfDue()
+	assert.NoError(t, err)
+	assert.Nil(t, notDue)
+
+	now = now.Add(time.Hour)
+	second, err := keys.RotateIfDue()
+	assert.NoError(t, err)
+	assert.NotNil(t, second)
+
+	// The replaced key verifies for the retention period
+	now = now.Add(DefaultRetention - time.Minute)
+	pruned, err := keys.Prune()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pruned)
+
+	now = now.Add(2 * time.Minute)
+	pruned, err = keys.Prune()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+	assert.NoFileExists(t, filepath.Join(dir, first.ID+".pem"))
+	assert.FileExists(t, filepath.Join(dir, second.ID+".pem"))
+
+	// Key files keep their creation time across restarts
+	assert.NoError(t, keys.Reload())
+	assert.Equal(t, second.ID, keys.SigningKey().ID)
+	assert.True(t, second.CreatedAt.Equal(keys.SigningKey().CreatedAt))
+}
+
+func TestKeyfuncReloadsForUnknownKey(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	clock := func() time.Time { return now }
+	serverA, err := Load(Config{Dir: dir})
+	assert.NoError(t, err)
+	serverA.now = clock
+	serverB, err := Load(Config{Dir: dir})
+	assert.NoError(t, err)
+	serverB.now = clock
+
+	_, err = serverB.Rotate()
+	assert.NoError(t, err)
+	token, _ := serverB.Sign(jwt.MapClaims{"userID": 1})
+
+	// Server A reloaded on start less than a minute ago
+	serverA.reloadedAt = now
+	_, err = parse(serverA, token)
+	assert.Error(t, err)
+
+	now = now.Add(minReloadInterval)
+	_, err = parse(serverA, token)
+	assert.NoError(t, err)
+}
+
+func TestKeyfuncRejectsAlgorithmMismatch(t *testing.T) {
+	keys, err := Load(Config{Dir: t.TempDir()})
+	assert.NoError(t, err)
+	kid := keys.SigningKey().ID
+
+	// Signing with the public key as an HMAC secret must not pass as RS256
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"userID": 1})
+	hmacToken.Header["kid"] = kid
+	signed, err := hmacToken.SignedString([]byte("anything"))
+	assert.NoError(t, err)
+	_, err = parse(keys, signed)
+	assert.Error(t, err)
+
+	noKid, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{}).SignedString([]byte("x"))
+	assert.NoError(t, err)
+	_, err = parse(keys, noKid)
+	assert.Error(t, err)
+}
+
+func TestVerificationOnlyKeys(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "legacy.pem"),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+	keys, err := Load(Config{Dir: dir, Algorithm: AlgEdDSA})
+	assert.NoError(t, err)
+	assert.NotEqual(t, "legacy", keys.SigningKey().ID)
+
+	// Tokens signed elsewhere with the private half verify
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"userID": 1})
+	token.Header["kid"] = "legacy"
+	signed, err := token.SignedString(rsaKey)
+	assert.NoError(t, err)
+	_, err = parse(keys, signed)
+	assert.NoError(t, err)
+
+	// Public keys are never pruned
+	keys.now = func() time.Time { return time.Now().Add(365 * 24 * time.Hour) }
+	_, err = keys.Rotate()
+	assert.NoError(t, err)
+	_, err = keys.Prune()
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, "legacy.pem"))
+}
+
+func TestJWKS(t *testing.T) {
+	dir := t.TempDir()
+	rsaKeys, err := Load(Config{Dir: dir})
+	assert.NoError(t, err)
+	edKey, err := generateKey(AlgEdDSA, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.NoError(t, writeKey(filepath.Join(dir, edKey.ID+".pem"), edKey))
+	assert.NoError(t, rsaKeys.Reload())
+
+	set := rsaKeys.JWKS()
+	if !assert.Len(t, set.Keys, 2) {
+		return
+	}
+
+	// Newest first
+	okp := set.Keys[0]
+	assert.Equal(t, JWK{KeyType: "OKP", KeyID: edKey.ID, Use: "sig", Algorithm: AlgEdDSA, Curve: "Ed25519",
+		X: base64.RawURLEncoding.EncodeToString(edKey.public.(ed25519.PublicKey))}, okp)
+
+	jwk := set.Keys[1]
+	public := rsaKeys.SigningKey().public.(*rsa.PublicKey)
+	assert.Equal(t, "RSA", jwk.KeyType)
+	assert.Equal(t, rsaKeys.SigningKey().ID, jwk.KeyID)
+	assert.Equal(t, AlgRS256, jwk.Algorithm)
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, public.N.Cmp(new(big.Int).SetBytes(n)))
+	assert.Equal(t, "AQAB", jwk.E)
+}
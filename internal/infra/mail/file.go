@@ -0,0 +1,69 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// FileMailer writes messages to a directory, one .eml file each, or to a writer such as the console.
+// Nothing is delivered; use it in development to follow verification and reset links.
+type FileMailer struct {
+	from string
+	dir  string
+	out  io.Writer
+	mu   sync.Mutex
+	now  func() time.Time
+}
+
+// NewFileMailer creates a FileMailer writing into dir, which is created on first use
+func NewFileMailer(from, dir string) *FileMailer {
+	return &FileMailer{from: from, dir: dir, now: time.Now}
+}
+
+// NewConsoleMailer creates a FileMailer printing messages to standard output
+func NewConsoleMailer(from string) *FileMailer {
+	return NewWriterMailer(from, os.Stdout)
+}
+
+// NewWriterMailer creates a FileMailer writing messages to out
+func NewWriterMailer(from string, out io.Writer) *FileMailer {
+	return &FileMailer{from: from, out: out, now: time.Now}
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._@-]+`)
+
+// Send writes the message
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := validateAddress(msg.To); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	now := m.now()
+	data := format(m.from, msg, now)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.out != nil {
+		_, err := fmt.Fprintf(m.out, "----- mail -----\n%s----- end of mail -----\n", data)
+		return err
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create mail directory: %v", err)
+	}
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), unsafeFileChars.ReplaceAllString(msg.To, "_"))
+	if err := os.WriteFile(filepath.Join(m.dir, name), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write mail: %v", err)
+	}
+	return nil
+}
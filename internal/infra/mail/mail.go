@@ -0,0 +1,97 @@
+// Package mail sends the emails of account flows such as email verification and password resets.
+// SMTPMailer delivers through an SMTP server; FileMailer writes messages to a directory or to the
+// console for local development and tests.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+// Supported values of MAIL_DRIVER
+const (
+	DriverSMTP    = "smtp"
+	DriverFile    = "file"
+	DriverConsole = "console"
+)
+
+// DefaultFrom is the sender address used when Config.From is empty
+const DefaultFrom = "noreply@localhost"
+
+// Message is a plain-text email to a single recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config selects and configures a Mailer
+type Config struct {
+	Driver   string // smtp, file or console (the default)
+	From     string // Sender address; DefaultFrom when empty
+	FileDir  string // Directory the file driver writes messages to
+	SMTPHost string
+	SMTPPort int
+	Username string // SMTP username; no authentication when empty
+	Password string
+}
+
+// New creates the Mailer selected by config.Driver; the console driver is the default
+func New(config Config) (Mailer, error) {
+	if config.From == "" {
+		config.From = DefaultFrom
+	}
+	switch config.Driver {
+	case "", DriverConsole:
+		return NewConsoleMailer(config.From), nil
+	case DriverFile:
+		if config.FileDir == "" {
+			return nil, fmt.Errorf("a directory is required for the %s mail driver", DriverFile)
+		}
+		return NewFileMailer(config.From, config.FileDir), nil
+	case DriverSMTP:
+		if config.SMTPHost == "" {
+			return nil, fmt.Errorf("an SMTP host is required for the %s mail driver", DriverSMTP)
+		}
+		return NewSMTPMailer(config), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q, expected %s, %s or %s",
+			config.Driver, DriverSMTP, DriverFile, DriverConsole)
+	}
+}
+
+// format renders msg as an RFC 5322 message with CRLF line endings
+func format(from string, msg Message, date time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	if !strings.HasSuffix(body, "\n") {
+		b.WriteString("\r\n")
+	}
+	return b.Bytes()
+}
+
+// validateAddress rejects header injection through the recipient
+func validateAddress(address string) error {
+	if address == "" || strings.ContainsAny(address, "\r\n") {
+		return fmt.Errorf("invalid recipient address %q", address)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSMTPServer accepts one SMTP session without TLS or authentication and returns the received commands and data
+func fakeSMTPServer(t *testing.T) (host string, port int, received <-chan []string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	lines := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var got []string
+		reader := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 localhost ESMTP")
+		inData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				lines <- got
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			got = append(got, line)
+			switch {
+			case inData:
+				if line == "." {
+					inData = false
+					reply("250 queued")
+				}
+			case strings.HasPrefix(line, "EHLO"):
+				reply("250 localhost")
+			case line == "DATA":
+				inData = true
+				reply("354 go ahead")
+			case line == "QUIT":
+				reply("221 bye")
+				lines <- got
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, lines
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	host, port, received := fakeSMTPServer(t)
+	mailer := NewSMTPMailer(Config{From: "noreply@mlvt.test", SMTPHost: host, SMTPPort: port})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := mailer.Send(ctx, Message{To: "john@example.com", Subject: "Verify your email", Body: "Open the link\nhttp://x"})
+	assert.NoError(t, err)
+
+	got := strings.Join(<-received, "\n")
+	assert.Contains(t, got, "MAIL FROM:<noreply@mlvt.test>")
+	assert.Contains(t, got, "RCPT TO:<john@example.com>")
+	assert.Contains(t, got, "Subject: Verify your email")
+	assert.Contains(t, got, "Open the link\nhttp://x")
+}
+
+func TestSMTPMailer_RejectsHeaderInjection(t *testing.T) {
+	mailer := NewSMTPMailer(Config{From: "noreply@mlvt.test", SMTPHost: "127.0.0.1", SMTPPort: 1})
+	err := mailer.Send(context.Background(), Message{To: "john@example.com\r\nBcc: eve@example.com"})
+	assert.Error(t, err)
+}
+
+func TestFileMailer_Send(t *testing.T) {
+	dir := t.TempDir()
+	mailer := NewFileMailer("noreply@mlvt.test", dir)
+
+	err := mailer.Send(context.Background(), Message{To: "john@example.com", Subject: "Reset", Body: "token"})
+	assert.NoError(t, err)
+
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	if assert.Len(t, files, 1) {
+		assert.True(t, strings.HasSuffix(files[0].Name(), "-john@example.com.eml"))
+		data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), "To: john@example.com\r\n")
+		assert.Contains(t, string(data), "\r\n\r\ntoken\r\n")
+	}
+}
+
+func TestWriterMailer_Send(t *testing.T) {
+	var out bytes.Buffer
+	mailer := NewWriterMailer("noreply@mlvt.test", &out)
+
+	err := mailer.Send(context.Background(), Message{To: "john@example.com", Subject: "Reset", Body: "token"})
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "Subject: Reset")
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		config  Config
+		wantErr bool
+	}{
+		{Config{From: "a@b.c"}, false},
+		{Config{From: "a@b.c", Driver: DriverFile, FileDir: t.TempDir()}, false},
+		{Config{From: "a@b.c", Driver: DriverFile}, true},
+		{Config{From: "a@b.c", Driver: DriverSMTP, SMTPHost: "localhost"}, false},
+		{Config{From: "a@b.c", Driver: DriverSMTP}, true},
+		{Config{From: "a@b.c", Driver: "pigeon"}, true},
+		{Config{Driver: DriverConsole}, false},
+	}
+	for i, tt := range tests {
+		_, err := New(tt.config)
+		assert.Equal(t, tt.wantErr, err != nil, strconv.Itoa(i))
+	}
+}
@@ -0,0 +1,17 @@
+package mail
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockMailer is a mock implementation of Mailer
+type MockMailer struct {
+	mock.Mock
+}
+
+func (m *MockMailer) Send(ctx context.Context, msg Message) error {
+	args := m.Called(ctx, msg)
+	return args.Error(0)
+}
@@ -0,0 +1,95 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// DefaultSMTPPort is used when Config.SMTPPort is zero
+const DefaultSMTPPort = 587
+
+// SMTPMailer delivers messages through an SMTP server. STARTTLS is used when the server offers it,
+// and PLAIN authentication when a username is configured.
+type SMTPMailer struct {
+	from     string
+	host     string
+	port     int
+	username string
+	password string
+	now      func() time.Time
+}
+
+// NewSMTPMailer creates an SMTPMailer from the SMTP fields of config
+func NewSMTPMailer(config Config) *SMTPMailer {
+	port := config.SMTPPort
+	if port == 0 {
+		port = DefaultSMTPPort
+	}
+	return &SMTPMailer{
+		from:     config.From,
+		host:     config.SMTPHost,
+		port:     port,
+		username: config.Username,
+		password: config.Password,
+		now:      time.Now,
+	}
+}
+
+// Send delivers the message; the context bounds the whole SMTP conversation
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if err := validateAddress(msg.To); err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(m.host, strconv.Itoa(m.port)))
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %v", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		return fmt.Errorf("failed to start SMTP session: %v", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %v", err)
+		}
+	}
+	if m.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %v", err)
+		}
+	}
+
+	if err := client.Mail(m.from); err != nil {
+		return fmt.Errorf("SMTP server rejected sender: %v", err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("SMTP server rejected recipient: %v", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(format(m.from, msg, m.now())); err != nil {
+		return fmt.Errorf("failed to send mail: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send mail: %v", err)
+	}
+	return client.Quit()
+}
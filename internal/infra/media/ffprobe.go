@@ -0,0 +1,134 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultFFprobePath is used when FFPROBE_PATH is not set; the binary is looked up in PATH
+const DefaultFFprobePath = "ffprobe"
+
+// FFprobe probes files by running the ffprobe command
+type FFprobe struct {
+	path string
+}
+
+// NewFFprobe creates a prober that runs the ffprobe binary at path, or DefaultFFprobePath if path is empty
+func NewFFprobe(path string) *FFprobe {
+	if path == "" {
+		path = DefaultFFprobePath
+	}
+	return &FFprobe{path: path}
+}
+
+// Available reports whether the ffprobe binary can be found
+func (f *FFprobe) Available() bool {
+	_, err := exec.LookPath(f.path)
+	return err == nil
+}
+
+// Probe runs ffprobe on a local file. It returns ErrInvalidMedia if ffprobe cannot read the file
+// or finds neither an audio nor a video stream.
+func (f *FFprobe) Probe(ctx context.Context, filePath string) (*Info, error) {
+	cmd := exec.CommandContext(ctx, f.path,
+		"-v", "error", "-print_format", "json", "-show_format", "-show_streams", "--", filePath)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidMedia, strings.TrimSpace(stderr.String()))
+		}
+		return nil, fmt.Errorf("failed to run ffprobe: %v", err)
+	}
+	return parseProbeOutput(stdout.Bytes())
+}
+
+// probeOutput is the part of ffprobe's JSON output that is used
+type probeOutput struct {
+	Streams []struct {
+		CodecType   string `json:"codec_type"`
+		CodecName   string `json:"codec_name"`
+		Width       int    `json:"width"`
+		Height      int    `json:"height"`
+		SampleRate  string `json:"sample_rate"`
+		Channels    int    `json:"channels"`
+		Duration    string `json:"duration"`
+		Disposition struct {
+			AttachedPic int `json:"attached_pic"`
+		} `json:"disposition"`
+	} `json:"streams"`
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+		BitRate    string `json:"bit_rate"`
+	} `json:"format"`
+}
+
+// parseProbeOutput reads the first video and audio stream from ffprobe's JSON output.
+// Cover art, which ffprobe reports as a video stream, is ignored.
+func parseProbeOutput(data []byte) (*Info, error) {
+	var output probeOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %v", err)
+	}
+
+	info := &Info{
+		FormatName: output.Format.FormatName,
+		Duration:   parseSeconds(output.Format.Duration),
+		Bitrate:    parseInt(output.Format.BitRate),
+	}
+	for _, stream := range output.Streams {
+		switch {
+		case stream.CodecType == "video" && stream.Disposition.AttachedPic == 0 && !info.HasVideo():
+			info.VideoCodec = stream.CodecName
+			info.Width = stream.Width
+			info.Height = stream.Height
+		case stream.CodecType == "audio" && !info.HasAudio():
+			info.AudioCodec = stream.CodecName
+			info.AudioChannels = stream.Channels
+			info.SampleRate = int(parseInt(stream.SampleRate))
+		default:
+			continue
+		}
+		// Some containers only report durations per stream
+		if duration := parseSeconds(stream.Duration); duration > info.Duration {
+			info.Duration = duration
+		}
+	}
+
+	if !info.HasVideo() && !info.HasAudio() {
+		return nil, fmt.Errorf("%w: no audio or video stream", ErrInvalidMedia)
+	}
+	return info, nil
+}
+
+// parseSeconds parses a duration such as "60.023000"; missing or invalid values are zero
+func parseSeconds(value string) time.Duration {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 || math.IsInf(seconds, 0) || math.IsNaN(seconds) {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func parseInt(value string) int64 {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,106 @@
+package media
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const videoProbeOutput = `{
+    "streams": [
+        {"index": 0, "codec_name": "h264", "codec_type": "video", "width": 1920, "height": 1080, "duration": "60.000000",
+         "disposition": {"attached_pic": 0}},
+        {"index": 1, "codec_name": "aac", "codec_type": "audio", "sample_rate": "48000", "channels": 2, "duration": "60.023000",
+         "disposition": {"attached_pic": 0}}
+    ],
+    "format": {"format_name": "mov,mp4,m4a,3gp,3g2,mj2", "duration": "60.023000", "bit_rate": "5123456"}
+}`
+
+func TestParseProbeOutput(t *testing.T) {
+	info, err := parseProbeOutput([]byte(videoProbeOutput))
+	assert.NoError(t, err)
+	assert.Equal(t, &Info{
+		FormatName:    "mov,mp4,m4a,3gp,3g2,mj2",
+		Duration:      60023 * time.Millisecond,
+		Bitrate:       5123456,
+		VideoCodec:    "h264",
+		Width:         1920,
+		Height:        1080,
+		AudioCodec:    "aac",
+		AudioChannels: 2,
+		SampleRate:    48000,
+	}, info)
+}
+
+func TestParseProbeOutput_AudioWithCoverArt(t *testing.T) {
+	info, err := parseProbeOutput([]byte(`{
+        "streams": [
+            {"codec_name": "mp3", "codec_type": "audio", "sample_rate": "44100", "channels": 1},
+            {"codec_name": "mjpeg", "codec_type": "video", "width": 500, "height": 500, "disposition": {"attached_pic": 1}}
+        ],
+        "format": {"format_name": "mp3", "duration": "12.5", "bit_rate": "128000"}
+    }`))
+	assert.NoError(t, err)
+	assert.False(t, info.HasVideo())
+	assert.Equal(t, "mp3", info.AudioCodec)
+	assert.Equal(t, 12500*time.Millisecond, info.Duration)
+}
+
+func TestParseProbeOutput_StreamDuration(t *testing.T) {
+	info, err := parseProbeOutput([]byte(`{
+        "streams": [{"codec_name": "vp9", "codec_type": "video", "width": 640, "height": 360, "duration": "9.5"}],
+        "format": {"format_name": "matroska,webm", "duration": "N/A"}
+    }`))
+	assert.NoError(t, err)
+	assert.Equal(t, 9500*time.Millisecond, info.Duration)
+	assert.Zero(t, info.Bitrate)
+}
+
+func TestParseProbeOutput_NoStreams(t *testing.T) {
+	_, err := parseProbeOutput([]byte(`{"streams": [{"codec_type": "data"}], "format": {"format_name": "tty"}}`))
+	assert.ErrorIs(t, err, ErrInvalidMedia)
+
+	_, err = parseProbeOutput([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+// fakeFFprobe writes a script that behaves like ffprobe: it prints output, or fails with a message
+func fakeFFprobe(t *testing.T, output string, fail bool) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake ffprobe is a shell script")
+	}
+	script := "#!/bin/sh\ncat <<'EOF'\n" + output + "\nEOF\n"
+	if fail {
+		script = "#!/bin/sh\necho 'Invalid data found when processing input' >&2\nexit 1\n"
+	}
+	path := filepath.Join(t.TempDir(), "ffprobe")
+	assert.NoError(t, os.WriteFile(path, []byte(script), 0o755))
+	return path
+}
+
+func TestFFprobe(t *testing.T) {
+	prober := NewFFprobe(fakeFFprobe(t, videoProbeOutput, false))
+	assert.True(t, prober.Available())
+
+	info, err := prober.Probe(context.Background(), "video.mp4")
+	assert.NoError(t, err)
+	assert.Equal(t, "h264", info.VideoCodec)
+
+	_, err = NewFFprobe(fakeFFprobe(t, "", true)).Probe(context.Background(), "video.mp4")
+	assert.ErrorIs(t, err, ErrInvalidMedia)
+	assert.Contains(t, err.Error(), "Invalid data found")
+}
+
+func TestFFprobe_Missing(t *testing.T) {
+	prober := NewFFprobe(filepath.Join(t.TempDir(), "ffprobe"))
+	assert.False(t, prober.Available())
+
+	_, err := prober.Probe(context.Background(), "video.mp4")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidMedia)
+}
@@ -0,0 +1,40 @@
+// Package media inspects audio and video files. The ffprobe implementation needs the ffprobe
+// binary from FFmpeg; tests use MockProber instead.
+package media
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrInvalidMedia is returned when a file is not an audio or video file the prober can read
+var ErrInvalidMedia = errors.New("file is not a readable audio or video file")
+
+// Info describes the streams of a media file. Fields of a stream the file does not have are zero.
+type Info struct {
+	FormatName    string        // Container format, e.g. "mov,mp4,m4a,3gp,3g2,mj2"
+	Duration      time.Duration // Length of the longest stream
+	Bitrate       int64         // Overall bitrate in bits per second
+	VideoCodec    string        // e.g. "h264"; empty for audio files
+	Width         int           // Width of the video stream in pixels
+	Height        int           // Height of the video stream in pixels
+	AudioCodec    string        // e.g. "aac"; empty for silent videos
+	AudioChannels int
+	SampleRate    int // Audio sample rate in Hz
+}
+
+// HasVideo reports whether the file has a video stream
+func (i *Info) HasVideo() bool {
+	return i.VideoCodec != ""
+}
+
+// HasAudio reports whether the file has an audio stream
+func (i *Info) HasAudio() bool {
+	return i.AudioCodec != ""
+}
+
+// Prober reads the stream information of a local media file
+type Prober interface {
+	Probe(ctx context.Context, filePath string) (*Info, error)
+}
@@ -0,0 +1,18 @@
+package media
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockProber is a mock implementation of Prober
+type MockProber struct {
+	mock.Mock
+}
+
+func (m *MockProber) Probe(ctx context.Context, filePath string) (*Info, error) {
+	args := m.Called(ctx, filePath)
+	info, _ := args.Get(0).(*Info)
+	return info, args.Error(1)
+}
@@ -0,0 +1,439 @@
+// Package momo is a client of the MoMo v2 payment gateway (https://developers.momo.vn). It creates
+// payments, queries their status, refunds them and verifies the instant payment notifications (IPN)
+// MoMo posts when a payment completes. Every request and notification is signed with HMAC-SHA256
+// over the fields MoMo lists for it, in alphabetical order.
+package momo
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults used when a Config field is left at zero
+const (
+	DefaultEndpoint    = "https://test-payment.momo.vn"
+	DefaultRequestType = "captureWallet"
+	DefaultLang        = "vi"
+	DefaultTimeout     = 30 * time.Second
+)
+
+// API paths of the v2 gateway
+const (
+	CreatePath = "/v2/gateway/api/create"
+	QueryPath  = "/v2/gateway/api/query"
+	RefundPath = "/v2/gateway/api/refund"
+)
+
+// Result codes MoMo reports for requests and payments
+const (
+	ResultSuccess            = 0    // Request succeeded; for payments, paid
+	ResultInitiated          = 1000 // Payment created, waiting for the user to confirm it
+	ResultProcessing         = 7000 // Payment being processed
+	ResultProcessingProvider = 7002 // Payment being processed by the provider of the payment method
+	ResultConfirming         = 8000 // Payment waiting for the user to confirm it again
+	ResultAuthorized         = 9000 // Payment authorized and waiting to be captured
+)
+
+// Amount limits of a single payment, in VND
+const (
+	MinAmount int64 = 1000
+	MaxAmount int64 = 50000000
+)
+
+// ErrInvalidSignature is returned for notifications whose signature was not made with our secret key
+var ErrInvalidSignature = errors.New("invalid MoMo signature")
+
+// Error is a request MoMo answered with a non-zero result code
+type Error struct {
+	ResultCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("MoMo request failed with result code %d: %s", e.ResultCode, e.Message)
+}
+
+// Pending reports whether a payment with the result code may still complete
+func Pending(resultCode int) bool {
+	switch resultCode {
+	case ResultInitiated, ResultProcessing, ResultProcessingProvider, ResultConfirming:
+		return true
+	}
+	return false
+}
+
+// Failed reports whether a payment with the result code has definitively failed. Codes below
+// ResultInitiated are errors of the request itself (10 maintenance, 11 access denied, 99 unknown
+// error, ...) and say nothing about the payment.
+func Failed(resultCode int) bool {
+	return resultCode > ResultInitiated && !Pending(resultCode) && !Paid(resultCode)
+}
+
+// Paid reports whether a payment with the result code has been paid
+func Paid(resultCode int) bool {
+	return resultCode == ResultSuccess || resultCode == ResultAuthorized
+}
+
+// Config holds the merchant credentials and URLs MoMo sends the user and its notifications to
+type Config struct {
+	Endpoint    string // Gateway base URL; the test environment by default
+	PartnerCode string
+	AccessKey   string
+	SecretKey   string
+	RedirectURL string // Page MoMo sends the user back to after paying
+	IPNURL      string // Public URL of POST /payments/momo/ipn
+	RequestType string // Payment method; captureWallet (the MoMo app) by default
+	Lang        string // Language of MoMo's messages: vi (the default) or en
+}
+
+// withDefaults fills zero fields with the package defaults
+func (c Config) withDefaults() Config {
+	if c.Endpoint == "" {
+		c.Endpoint = DefaultEndpoint
+	}
+	c.Endpoint = strings.TrimRight(c.Endpoint, "/")
+	if c.RequestType == "" {
+		c.RequestType = DefaultRequestType
+	}
+	if c.Lang == "" {
+		c.Lang = DefaultLang
+	}
+	return c
+}
+
+// Client calls the MoMo v2 gateway
+type Client struct {
+	config Config
+	client *http.Client
+}
+
+// New creates a client; the partner code, keys and both URLs are required. client may be nil.
+func New(config Config, client *http.Client) (*Client, error) {
+	config = config.withDefaults()
+	for name, value := range map[string]string{
+		"partner code": config.PartnerCode,
+		"access key":   config.AccessKey,
+		"secret key":   config.SecretKey,
+		"redirect URL": config.RedirectURL,
+		"IPN URL":      config.IPNURL,
+	} {
+		if value == "" {
+			return nil, fmt.Errorf("MoMo %s is required", name)
+		}
+	}
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &Client{config: config, client: client}, nil
+}
+
+// CreateRequest describes a new payment. OrderID and RequestID must be unique per partner.
+type CreateRequest struct {
+	OrderID   string
+	RequestID string
+	Amount    int64  // VND, from MinAmount to MaxAmount
+	OrderInfo string // Shown to the user in the MoMo app
+	ExtraData string // Returned unchanged in the notification; base64 by convention
+}
+
+// CreateResponse is MoMo's answer to a new payment
+type CreateResponse struct {
+	PartnerCode  string `json:"partnerCode"`
+	OrderID      string `json:"orderId"`
+	RequestID    string `json:"requestId"`
+	Amount       int64  `json:"amount"`
+	ResponseTime int64  `json:"responseTime"`
+	Message      string `json:"message"`
+	ResultCode   int    `json:"resultCode"`
+	PayURL       string `json:"payUrl"`    // MoMo page the user pays on
+	Deeplink     string `json:"deeplink"`  // Opens the payment in the MoMo app
+	QRCodeURL    string `json:"qrCodeUrl"` // Content of the QR code the MoMo app scans
+}
+
+// CreatePayment registers a payment and returns the URLs the user pays through
+func (c *Client) CreatePayment(ctx context.Context, req CreateRequest) (*CreateResponse, error) {
+	signature := Sign(c.config.SecretKey, []Field{
+		{"accessKey", c.config.AccessKey},
+		{"amount", strconv.FormatInt(req.Amount, 10)},
+		{"extraData", req.ExtraData},
+		{"ipnUrl", c.config.IPNURL},
+		{"orderId", req.OrderID},
+		{"orderInfo", req.OrderInfo},
+		{"partnerCode", c.config.PartnerCode},
+		{"redirectUrl", c.config.RedirectURL},
+		{"requestId", req.RequestID},
+		{"requestType", c.config.RequestType},
+	})
+	body := map[string]interface{}{
+		"partnerCode": c.config.PartnerCode,
+		"requestId":   req.RequestID,
+		"amount":      req.Amount,
+		"orderId":     req.OrderID,
+		"orderInfo":   req.OrderInfo,
+		"redirectUrl": c.config.RedirectURL,
+		"ipnUrl":      c.config.IPNURL,
+		"requestType": c.config.RequestType,
+		"extraData":   req.ExtraData,
+		"lang":        c.config.Lang,
+		"signature":   signature,
+	}
+
+	var resp CreateResponse
+	if err := c.post(ctx, CreatePath, body, &resp); err != nil {
+		return nil, err
+	}
+	if resp.ResultCode != ResultSuccess {
+		return nil, &Error{ResultCode: resp.ResultCode, Message: resp.Message}
+	}
+	return &resp, nil
+}
+
+// QueryResponse is the status of a payment. A ResultCode from ResultInitiated up is the state of
+// the payment, not a failed query: see Paid, Pending and Failed.
+type QueryResponse struct {
+	PartnerCode  string `json:"partnerCode"`
+	OrderID      string `json:"orderId"`
+	RequestID    string `json:"requestId"`
+	ExtraData    string `json:"extraData"`
+	Amount       int64  `json:"amount"`
+	TransID      int64  `json:"transId"`
+	PayType      string `json:"payType"`
+	ResultCode   int    `json:"resultCode"`
+	Message      string `json:"message"`
+	ResponseTime int64  `json:"responseTime"`
+}
+
+// QueryPayment returns the status of the payment of orderID; requestID must be new
+func (c *Client) QueryPayment(ctx context.Context, orderID, requestID string) (*QueryResponse, error) {
+	signature := Sign(c.config.SecretKey, []Field{
+		{"accessKey", c.config.AccessKey},
+		{"orderId", orderID},
+		{"partnerCode", c.config.PartnerCode},
+		{"requestId", requestID},
+	})
+	body := map[string]interface{}{
+		"partnerCode": c.config.PartnerCode,
+		"requestId":   requestID,
+		"orderId":     orderID,
+		"lang":        c.config.Lang,
+		"signature":   signature,
+	}
+
+	var resp QueryResponse
+	if err := c.post(ctx, QueryPath, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RefundRequest returns part or all of a paid transaction. OrderID identifies the refund itself
+// and must be new; TransID is MoMo's ID of the paid transaction.
+type RefundRequest struct {
+	OrderID     string
+	RequestID   string
+	Amount      int64
+	TransID     int64
+	Description string
+}
+
+// RefundResponse is MoMo's answer to a refund
+type RefundResponse struct {
+	PartnerCode  string `json:"partnerCode"`
+	OrderID      string `json:"orderId"`
+	RequestID    string `json:"requestId"`
+	Amount       int64  `json:"amount"`
+	TransID      int64  `json:"transId"` // ID of the refund transaction
+	ResultCode   int    `json:"resultCode"`
+	Message      string `json:"message"`
+	ResponseTime int64  `json:"responseTime"`
+}
+
+// Refund returns money of a paid transaction to the user
+func (c *Client) Refund(ctx context.Context, req RefundRequest) (*RefundResponse, error) {
+	signature := Sign(c.config.SecretKey, []Field{
+		{"accessKey", c.config.AccessKey},
+		{"amount", strconv.FormatInt(req.Amount, 10)},
+		{"description", req.Description},
+		{"orderId", req.OrderID},
+		{"partnerCode", c.config.PartnerCode},
+		{"requestId", req.RequestID},
+		{"transId", strconv.FormatInt(req.TransID, 10)},
+	})
+	body := map[string]interface{}{
+		"partnerCode": c.config.PartnerCode,
+		"orderId":     req.OrderID,
+		"requestId":   req.RequestID,
+		"amount":      req.Amount,
+		"transId":     req.TransID,
+		"lang":        c.config.Lang,
+		"description": req.Description,
+		"signature":   signature,
+	}
+
+	var resp RefundResponse
+	if err := c.post(ctx, RefundPath, body, &resp); err != nil {
+		return nil, err
+	}
+	if resp.ResultCode != ResultSuccess {
+		return nil, &Error{ResultCode: resp.ResultCode, Message: resp.Message}
+	}
+	return &resp, nil
+}
+
+// IPN is the instant payment notification MoMo posts to the IPN URL when a payment completes
+type IPN struct {
+	PartnerCode  string `json:"partnerCode"`
+	OrderID      string `json:"orderId"`
+	RequestID    string `json:"requestId"`
+	Amount       int64  `json:"amount"`
+	OrderInfo    string `json:"orderInfo"`
+	OrderType    string `json:"orderType"`
+	TransID      int64  `json:"transId"`
+	ResultCode   int    `json:"resultCode"`
+	Message      string `json:"message"`
+	PayType      string `json:"payType"`
+	ResponseTime int64  `json:"responseTime"`
+	ExtraData    string `json:"extraData"`
+	Signature    string `json:"signature"`
+}
+
+// SignatureFields returns the fields the signature of the notification covers
+func (n *IPN) SignatureFields(accessKey string) []Field {
+	return []Field{
+		{"accessKey", accessKey},
+		{"amount", strconv.FormatInt(n.Amount, 10)},
+		{"extraData", n.ExtraData},
+		{"message", n.Message},
+		{"orderId", n.OrderID},
+		{"orderInfo", n.OrderInfo},
+		{"orderType", n.OrderType},
+		{"partnerCode", n.PartnerCode},
+		{"payType", n.PayType},
+		{"requestId", n.RequestID},
+		{"responseTime", strconv.FormatInt(n.ResponseTime, 10)},
+		{"resultCode", strconv.Itoa(n.ResultCode)},
+		{"transId", strconv.FormatInt(n.TransID, 10)},
+	}
+}
+
+// VerifyIPN checks that the notification was signed with our secret key and is meant for our partner code
+func (c *Client) VerifyIPN(n *IPN) error {
+	expected := Sign(c.config.SecretKey, n.SignatureFields(c.config.AccessKey))
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(n.Signature))) || n.PartnerCode != c.config.PartnerCode {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Field is a name and value covered by a signature
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Sign returns the hex HMAC-SHA256 of the fields written as name=value pairs joined by "&".
+// MoMo lists the fields of each request in alphabetical order.
+func Sign(secretKey string, fields []Field) string {
+	pairs := make([]string, len(fields))
+	for i, field := range fields {
+		pairs[i] = field.Name + "=" + field.Value
+	}
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(strings.Join(pairs, "&")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Exchange is a request sent to the gateway and the answer it got, with secrets redacted
+type Exchange struct {
+	Path       string          `json:"path,omitempty"`        // Empty for notifications MoMo sent us
+	StatusCode int             `json:"status_code,omitempty"` // Zero if the gateway could not be reached
+	Request    json.RawMessage `json:"request,omitempty"`
+	Response   json.RawMessage `json:"response,omitempty"`
+}
+
+type exchangeKey struct{}
+
+// WithExchange returns a context in which the client records the request it sends and the answer it gets
+// in exchange, for keeping a trail of what was said to MoMo
+func WithExchange(ctx context.Context, exchange *Exchange) context.Context {
+	return context.WithValue(ctx, exchangeKey{}, exchange)
+}
+
+// redactedFields are the payload fields Redact hides: the keys and the signatures made with them
+var redactedFields = []string{"accessKey", "secretKey", "signature"}
+
+// Redact returns the JSON payload with the values of keys and signatures replaced. A payload that is
+// not a JSON object is returned as a JSON string.
+func Redact(payload []byte) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		quoted, _ := json.Marshal(string(payload))
+		return quoted
+	}
+	for _, name := range redactedFields {
+		if _, ok := fields[name]; ok {
+			fields[name] = json.RawMessage(`"[REDACTED]"`)
+		}
+	}
+	redacted, _ := json.Marshal(fields)
+	return redacted
+}
+
+// post sends body as JSON and decodes MoMo's JSON answer into out. MoMo answers failed requests
+// with a 4xx status and a result code, which is returned as an *Error.
+func (c *Client) post(ctx context.Context, path string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.Endpoint+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	exchange, _ := ctx.Value(exchangeKey{}).(*Exchange)
+	if exchange != nil {
+		exchange.Path = path
+		exchange.Request = Redact(payload)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("MoMo request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read MoMo response: %v", err)
+	}
+	if exchange != nil {
+		exchange.StatusCode = resp.StatusCode
+		exchange.Response = Redact(data)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			ResultCode int    `json:"resultCode"`
+			Message    string `json:"message"`
+		}
+		if json.Unmarshal(data, &failure) == nil && failure.ResultCode != ResultSuccess {
+			return &Error{ResultCode: failure.ResultCode, Message: failure.Message}
+		}
+		return fmt.Errorf("MoMo request failed with status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode MoMo response: %v", err)
+	}
+	return nil
+}
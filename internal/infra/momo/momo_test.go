@@ -0,0 +1,167 @@
+package momo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"mlvt/internal/infra/momo"
+	"mlvt/internal/infra/momo/momotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(t *testing.T) (*momo.Client, *momotest.Server) {
+	server := momotest.NewServer()
+	t.Cleanup(server.Close)
+	client, err := momo.New(server.Config(), nil)
+	assert.NoError(t, err)
+	return client, server
+}
+
+func TestSign(t *testing.T) {
+	signature := momo.Sign("secret", []momo.Field{
+		{Name: "accessKey", Value: "ak"},
+		{Name: "orderId", Value: "o1"},
+		{Name: "partnerCode", Value: "MOMO"},
+		{Name: "requestId", Value: "r1"},
+	})
+	assert.Equal(t, "537f1797155b20eeb20ebc49019aa3630807cdf01fa3e2d5cec1f695f01a11fc", signature)
+}
+
+func TestNewRequiresCredentials(t *testing.T) {
+	server := momotest.NewServer()
+	defer server.Close()
+	config := server.Config()
+	config.SecretKey = ""
+	_, err := momo.New(config, nil)
+	assert.Error(t, err)
+}
+
+func TestPaymentFlow(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+
+	created, err := client.CreatePayment(ctx, momo.CreateRequest{
+		OrderID: "order-1", RequestID: "req-1", Amount: 50000, OrderInfo: "Credit pack",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, server.URL+"/pay/order-1", created.PayURL)
+	assert.NotEmpty(t, created.QRCodeURL)
+
+	status, err := client.QueryPayment(ctx, "order-1", "req-2")
+	assert.NoError(t, err)
+	assert.True(t, momo.Pending(status.ResultCode))
+
+	ipn, err := server.Complete("order-1", momo.ResultSuccess)
+	assert.NoError(t, err)
+	assert.NoError(t, client.VerifyIPN(ipn))
+
+	status, err = client.QueryPayment(ctx, "order-1", "req-3")
+	assert.NoError(t, err)
+	assert.True(t, momo.Paid(status.ResultCode))
+	assert.Equal(t, ipn.TransID, status.TransID)
+
+	refund, err := client.Refund(ctx, momo.RefundRequest{
+		OrderID: "order-1-r1", RequestID: "req-4", Amount: 20000, TransID: status.TransID, Description: "partial",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20000), refund.Amount)
+
+	// More than what is left of the payment
+	_, err = client.Refund(ctx, momo.RefundRequest{
+		OrderID: "order-1-r2", RequestID: "req-5", Amount: 40000, TransID: status.TransID,
+	})
+	var momoErr *momo.Error
+	if assert.True(t, errors.As(err, &momoErr)) {
+		assert.Equal(t, momotest.ResultBadAmount, momoErr.ResultCode)
+	}
+}
+
+func TestCreatePaymentErrors(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+
+	_, err := client.CreatePayment(ctx, momo.CreateRequest{OrderID: "small", RequestID: "r1", Amount: 500})
+	var momoErr *momo.Error
+	if assert.True(t, errors.As(err, &momoErr)) {
+		assert.Equal(t, momotest.ResultBadAmount, momoErr.ResultCode)
+	}
+
+	_, err = client.CreatePayment(ctx, momo.CreateRequest{OrderID: "dup", RequestID: "r2", Amount: 10000})
+	assert.NoError(t, err)
+	_, err = client.CreatePayment(ctx, momo.CreateRequest{OrderID: "dup", RequestID: "r3", Amount: 10000})
+	if assert.True(t, errors.As(err, &momoErr)) {
+		assert.Equal(t, momotest.ResultDuplicate, momoErr.ResultCode)
+	}
+
+	// A client with the wrong secret is refused
+	config := server.Config()
+	config.SecretKey = "wrong"
+	wrongClient, _ := momo.New(config, nil)
+	_, err = wrongClient.CreatePayment(ctx, momo.CreateRequest{OrderID: "other", RequestID: "r4", Amount: 10000})
+	if assert.True(t, errors.As(err, &momoErr)) {
+		assert.Equal(t, momotest.ResultAccessDenied, momoErr.ResultCode)
+	}
+}
+
+func TestVerifyIPNRejectsTampering(t *testing.T) {
+	client, server := newTestClient(t)
+	_, err := client.CreatePayment(context.Background(), momo.CreateRequest{OrderID: "order-1", RequestID: "r1", Amount: 10000})
+	assert.NoError(t, err)
+
+	ipn, err := server.Complete("order-1", momo.ResultSuccess)
+	assert.NoError(t, err)
+	ipn.Amount = 1000000
+	assert.ErrorIs(t, client.VerifyIPN(ipn), momo.ErrInvalidSignature)
+
+	// Signed correctly, but for another merchant
+	ipn, _ = server.Complete("order-1", momo.ResultSuccess)
+	ipn.PartnerCode = "OTHER"
+	server.SignIPN(ipn)
+	assert.ErrorIs(t, client.VerifyIPN(ipn), momo.ErrInvalidSignature)
+}
+
+func TestWithExchange(t *testing.T) {
+	client, server := newTestClient(t)
+
+	var exchange momo.Exchange
+	ctx := momo.WithExchange(context.Background(), &exchange)
+	_, err := client.CreatePayment(ctx, momo.CreateRequest{OrderID: "order-1", RequestID: "r1", Amount: 10000})
+	assert.NoError(t, err)
+	assert.Equal(t, momo.CreatePath, exchange.Path)
+	assert.Equal(t, 200, exchange.StatusCode)
+	assert.Contains(t, string(exchange.Request), `"orderId":"order-1"`)
+	assert.Contains(t, string(exchange.Request), `"signature":"[REDACTED]"`)
+	assert.Contains(t, string(exchange.Response), `"payUrl":"`+server.URL+`/pay/order-1"`)
+
+	// Refused requests are recorded too
+	exchange = momo.Exchange{}
+	_, err = client.CreatePayment(ctx, momo.CreateRequest{OrderID: "order-1", RequestID: "r2", Amount: 10000})
+	assert.Error(t, err)
+	assert.Equal(t, 400, exchange.StatusCode)
+	assert.Contains(t, string(exchange.Response), `"resultCode":41`)
+}
+
+func TestRedact(t *testing.T) {
+	redacted := momo.Redact([]byte(`{"orderId":"o1","accessKey":"ak","signature":"abc","amount":1000}`))
+	assert.JSONEq(t, `{"orderId":"o1","accessKey":"[REDACTED]","signature":"[REDACTED]","amount":1000}`, string(redacted))
+	assert.JSONEq(t, `"<html>Bad Gateway</html>"`, string(momo.Redact([]byte("<html>Bad Gateway</html>"))))
+}
+
+func TestResultCodes(t *testing.T) {
+	for code, want := range map[int][3]bool{ // pending, paid, failed
+		momo.ResultSuccess:    {false, true, false},
+		momo.ResultAuthorized: {false, true, false},
+		momo.ResultInitiated:  {true, false, false},
+		momo.ResultConfirming: {true, false, false},
+		1006:                  {false, false, true},  // declined by the user
+		1005:                  {false, false, true},  // expired
+		10:                    {false, false, false}, // gateway under maintenance
+		99:                    {false, false, false}, // unknown error
+	} {
+		assert.Equal(t, want, [3]bool{momo.Pending(code), momo.Paid(code), momo.Failed(code)}, "result code %d", code)
+	}
+}
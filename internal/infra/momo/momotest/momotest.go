@@ -0,0 +1,367 @@
+// Package momotest runs a fake MoMo v2 gateway for tests. It checks the signature of each request,
+// keeps the payments created through it, and lets tests complete them and get the signed IPN MoMo
+// would post.
+package momotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"mlvt/internal/infra/momo"
+)
+
+// Result codes the fake answers bad requests with
+const (
+	ResultAccessDenied  = 11 // Wrong signature or partner code
+	ResultBadAmount     = 22 // Amount outside the allowed range
+	ResultDuplicate     = 41 // Order ID already used
+	ResultOrderNotFound = 42 // No payment with the order ID
+)
+
+// Payment is a payment created through the fake gateway
+type Payment struct {
+	OrderID    string
+	RequestID  string
+	Amount     int64
+	OrderInfo  string
+	ExtraData  string
+	IPNURL     string
+	TransID    int64
+	ResultCode int
+	Refunded   int64
+}
+
+// Server is a fake MoMo gateway
+type Server struct {
+	*httptest.Server
+	PartnerCode string
+	AccessKey   string
+	SecretKey   string
+
+	mu          sync.Mutex
+	payments    map[string]*Payment
+	nextTransID int64
+	requests    []string
+}
+
+// NewServer starts a fake gateway; close it when done
+func NewServer() *Server {
+	s := &Server{
+		PartnerCode: "MOMOTEST",
+		AccessKey:   "test-access-key",
+		SecretKey:   "test-secret-key",
+		payments:    make(map[string]*Payment),
+		nextTransID: 4000000000,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(momo.CreatePath, s.create)
+	mux.HandleFunc(momo.QueryPath, s.query)
+	mux.HandleFunc(momo.RefundPath, s.refund)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Config returns a client configuration pointing at the fake gateway
+func (s *Server) Config() momo.Config {
+	return momo.Config{
+		Endpoint:    s.URL,
+		PartnerCode: s.PartnerCode,
+		AccessKey:   s.AccessKey,
+		SecretKey:   s.SecretKey,
+		RedirectURL: "http://localhost:3000/payments/momo/return",
+		IPNURL:      "http://localhost:8080/api/payments/momo/ipn",
+	}
+}
+
+// Payment returns the payment of orderID, or nil
+func (s *Server) Payment(orderID string) *Payment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if payment, ok := s.payments[orderID]; ok {
+		copied := *payment
+		return &copied
+	}
+	return nil
+}
+
+// Requests returns the API paths called so far, in order
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// Complete ends the payment of orderID with resultCode (momo.ResultSuccess when paid) and returns
+// the signed notification MoMo would post to the IPN URL
+func (s *Server) Complete(orderID string, resultCode int) (*momo.IPN, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	payment, ok := s.payments[orderID]
+	if !ok {
+		return nil, fmt.Errorf("no MoMo payment for order %s", orderID)
+	}
+	payment.ResultCode = resultCode
+	if momo.Paid(resultCode) {
+		payment.TransID = s.newTransID()
+	}
+
+	ipn := &momo.IPN{
+		PartnerCode:  s.PartnerCode,
+		OrderID:      payment.OrderID,
+		RequestID:    payment.RequestID,
+		Amount:       payment.Amount,
+		OrderInfo:    payment.OrderInfo,
+		OrderType:    "momo_wallet",
+		TransID:      payment.TransID,
+		ResultCode:   resultCode,
+		Message:      message(resultCode),
+		PayType:      "qr",
+		ResponseTime: time.Now().UnixMilli(),
+		ExtraData:    payment.ExtraData,
+	}
+	s.SignIPN(ipn)
+	return ipn, nil
+}
+
+// SignIPN signs the notification with the gateway's secret key
+func (s *Server) SignIPN(ipn *momo.IPN) {
+	ipn.Signature = momo.Sign(s.SecretKey, ipn.SignatureFields(s.AccessKey))
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PartnerCode string `json:"partnerCode"`
+		RequestID   string `json:"requestId"`
+		Amount      int64  `json:"amount"`
+		OrderID     string `json:"orderId"`
+		OrderInfo   string `json:"orderInfo"`
+		RedirectURL string `json:"redirectUrl"`
+		IPNURL      string `json:"ipnUrl"`
+		RequestType string `json:"requestType"`
+		ExtraData   string `json:"extraData"`
+		Signature   string `json:"signature"`
+	}
+	if !s.decode(w, r, &req) {
+		return
+	}
+	signature := momo.Sign(s.SecretKey, []momo.Field{
+		{Name: "accessKey", Value: s.AccessKey},
+		{Name: "amount", Value: strconv.FormatInt(req.Amount, 10)},
+		{Name: "extraData", Value: req.ExtraData},
+		{Name: "ipnUrl", Value: req.IPNURL},
+		{Name: "orderId", Value: req.OrderID},
+		{Name: "orderInfo", Value: req.OrderInfo},
+		{Name: "partnerCode", Value: req.PartnerCode},
+		{Name: "redirectUrl", Value: req.RedirectURL},
+		{Name: "requestId", Value: req.RequestID},
+		{Name: "requestType", Value: req.RequestType},
+	})
+	if !s.authorized(w, req.PartnerCode, req.Signature, signature) {
+		return
+	}
+	if req.Amount < momo.MinAmount || req.Amount > momo.MaxAmount {
+		s.fail(w, ResultBadAmount)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.payments[req.OrderID]; ok {
+		s.fail(w, ResultDuplicate)
+		return
+	}
+	s.payments[req.OrderID] = &Payment{
+		OrderID:    req.OrderID,
+		RequestID:  req.RequestID,
+		Amount:     req.Amount,
+		OrderInfo:  req.OrderInfo,
+		ExtraData:  req.ExtraData,
+		IPNURL:     req.IPNURL,
+		ResultCode: momo.ResultInitiated,
+	}
+	writeJSON(w, http.StatusOK, momo.CreateResponse{
+		PartnerCode:  s.PartnerCode,
+		OrderID:      req.OrderID,
+		RequestID:    req.RequestID,
+		Amount:       req.Amount,
+		ResponseTime: time.Now().UnixMilli(),
+		Message:      message(momo.ResultSuccess),
+		ResultCode:   momo.ResultSuccess,
+		PayURL:       s.URL + "/pay/" + req.OrderID,
+		Deeplink:     "momo://app?action=payWithApp&orderId=" + req.OrderID,
+		QRCodeURL:    "momo://app?action=payWithApp&qr=" + req.OrderID,
+	})
+}
+
+func (s *Server) query(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PartnerCode string `json:"partnerCode"`
+		RequestID   string `json:"requestId"`
+		OrderID     string `json:"orderId"`
+		Signature   string `json:"signature"`
+	}
+	if !s.decode(w, r, &req) {
+		return
+	}
+	signature := momo.Sign(s.SecretKey, []momo.Field{
+		{Name: "accessKey", Value: s.AccessKey},
+		{Name: "orderId", Value: req.OrderID},
+		{Name: "partnerCode", Value: req.PartnerCode},
+		{Name: "requestId", Value: req.RequestID},
+	})
+	if !s.authorized(w, req.PartnerCode, req.Signature, signature) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	payment, ok := s.payments[req.OrderID]
+	if !ok {
+		s.fail(w, ResultOrderNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, momo.QueryResponse{
+		PartnerCode:  s.PartnerCode,
+		OrderID:      payment.OrderID,
+		RequestID:    req.RequestID,
+		ExtraData:    payment.ExtraData,
+		Amount:       payment.Amount,
+		TransID:      payment.TransID,
+		PayType:      "qr",
+		ResultCode:   payment.ResultCode,
+		Message:      message(payment.ResultCode),
+		ResponseTime: time.Now().UnixMilli(),
+	})
+}
+
+func (s *Server) refund(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PartnerCode string `json:"partnerCode"`
+		OrderID     string `json:"orderId"`
+		RequestID   string `json:"requestId"`
+		Amount      int64  `json:"amount"`
+		TransID     int64  `json:"transId"`
+		Description string `json:"description"`
+		Signature   string `json:"signature"`
+	}
+	if !s.decode(w, r, &req) {
+		return
+	}
+	signature := momo.Sign(s.SecretKey, []momo.Field{
+		{Name: "accessKey", Value: s.AccessKey},
+		{Name: "amount", Value: strconv.FormatInt(req.Amount, 10)},
+		{Name: "description", Value: req.Description},
+		{Name: "orderId", Value: req.OrderID},
+		{Name: "partnerCode", Value: req.PartnerCode},
+		{Name: "requestId", Value: req.RequestID},
+		{Name: "transId", Value: strconv.FormatInt(req.TransID, 10)},
+	})
+	if !s.authorized(w, req.PartnerCode, req.Signature, signature) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.payments[req.OrderID]; ok {
+		s.fail(w, ResultDuplicate)
+		return
+	}
+	var paid *Payment
+	for _, payment := range s.payments {
+		if payment.TransID == req.TransID && momo.Paid(payment.ResultCode) {
+			paid = payment
+		}
+	}
+	if paid == nil {
+		s.fail(w, ResultOrderNotFound)
+		return
+	}
+	if req.Amount <= 0 || paid.Refunded+req.Amount > paid.Amount {
+		s.fail(w, ResultBadAmount)
+		return
+	}
+	paid.Refunded += req.Amount
+	// Refunds use up their order ID like payments do
+	s.payments[req.OrderID] = &Payment{OrderID: req.OrderID, RequestID: req.RequestID, Amount: req.Amount, ResultCode: momo.ResultSuccess}
+
+	writeJSON(w, http.StatusOK, momo.RefundResponse{
+		PartnerCode:  s.PartnerCode,
+		OrderID:      req.OrderID,
+		RequestID:    req.RequestID,
+		Amount:       req.Amount,
+		TransID:      s.newTransID(),
+		ResultCode:   momo.ResultSuccess,
+		Message:      message(momo.ResultSuccess),
+		ResponseTime: time.Now().UnixMilli(),
+	})
+}
+
+// decode records the request and reads its JSON body
+func (s *Server) decode(w http.ResponseWriter, r *http.Request, out interface{}) bool {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.URL.Path)
+	s.mu.Unlock()
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return false
+	}
+	if err := json.NewDecoder(r.Body).Decode(out); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// authorized answers ResultAccessDenied unless the request is signed for the gateway's partner
+func (s *Server) authorized(w http.ResponseWriter, partnerCode, got, want string) bool {
+	if partnerCode != s.PartnerCode || got != want {
+		s.fail(w, ResultAccessDenied)
+		return false
+	}
+	return true
+}
+
+func (s *Server) fail(w http.ResponseWriter, resultCode int) {
+	writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+		"resultCode":   resultCode,
+		"message":      message(resultCode),
+		"responseTime": time.Now().UnixMilli(),
+	})
+}
+
+// newTransID returns a new MoMo transaction ID; the caller holds s.mu
+func (s *Server) newTransID() int64 {
+	s.nextTransID++
+	return s.nextTransID
+}
+
+func message(resultCode int) string {
+	switch resultCode {
+	case momo.ResultSuccess:
+		return "Successful."
+	case momo.ResultInitiated:
+		return "Transaction is initiated, waiting for user confirmation."
+	case ResultAccessDenied:
+		return "Access denied."
+	case ResultBadAmount:
+		return "Invalid amount."
+	case ResultDuplicate:
+		return "Duplicated orderId."
+	case ResultOrderNotFound:
+		return "Order not found."
+	default:
+		return "Transaction failed."
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
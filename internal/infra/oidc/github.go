@@ -0,0 +1,72 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// githubProvider signs users in with GitHub OAuth apps. GitHub issues no ID tokens, so the
+// identity is read from the REST API with the access token.
+type githubProvider struct {
+	config Config
+	client *http.Client
+}
+
+func newGitHubProvider(config Config, client *http.Client) *githubProvider {
+	return &githubProvider{config: config, client: client}
+}
+
+func (p *githubProvider) Name() string {
+	return p.config.Name
+}
+
+// AuthCodeURL ignores nonce, which only applies to ID tokens
+func (p *githubProvider) AuthCodeURL(_ context.Context, state, codeChallenge, _ string) (string, error) {
+	return authCodeURL(p.config.AuthURL, p.config, state, codeChallenge, "")
+}
+
+func (p *githubProvider) Exchange(ctx context.Context, code, codeVerifier, _ string) (*Identity, error) {
+	token, err := exchangeCode(ctx, p.client, p.config.TokenURL, p.config, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	apiURL := strings.TrimRight(p.config.APIURL, "/")
+
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := getJSON(ctx, p.client, apiURL+"/user", token.AccessToken, &user); err != nil {
+		return nil, fmt.Errorf("failed to read the GitHub user: %v", err)
+	}
+	if user.ID == 0 {
+		return nil, fmt.Errorf("the GitHub user has no ID")
+	}
+
+	// The profile email may be unverified or hidden; only the primary address of the email list counts
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, p.client, apiURL+"/user/emails", token.AccessToken, &emails); err != nil {
+		return nil, fmt.Errorf("failed to read the GitHub user's emails: %v", err)
+	}
+
+	identity := &Identity{Provider: p.config.Name, Subject: strconv.FormatInt(user.ID, 10)}
+	for _, email := range emails {
+		if email.Primary {
+			identity.Email = email.Email
+			identity.EmailVerified = email.Verified
+		}
+	}
+	identity.FirstName, identity.LastName = splitName(user.Name)
+	if identity.FirstName == "" {
+		identity.FirstName = user.Login
+	}
+	return identity, nil
+}
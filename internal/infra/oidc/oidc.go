@@ -0,0 +1,279 @@
+// Package oidc signs users in with external identity providers using the OAuth 2.0 authorization
+// code flow with PKCE. Google and generic issuers are used through OpenID Connect discovery and
+// signed ID tokens; GitHub, which does not issue ID tokens, through its REST API.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Provider kinds
+const (
+	KindGoogle  = "google"
+	KindGitHub  = "github"
+	KindGeneric = "oidc" // Any issuer that publishes /.well-known/openid-configuration
+)
+
+// Endpoints used when a Config leaves them empty
+const (
+	GoogleIssuer   = "https://accounts.google.com"
+	GitHubAuthURL  = "https://github.com/login/oauth/authorize"
+	GitHubTokenURL = "https://github.com/login/oauth/access_token"
+	GitHubAPIURL   = "https://api.github.com"
+)
+
+// DefaultTimeout bounds each request to a provider when New is given no HTTP client
+const DefaultTimeout = 10 * time.Second
+
+var (
+	ErrExchangeFailed = errors.New("authorization code exchange failed")
+	ErrInvalidIDToken = errors.New("invalid ID token")
+)
+
+// Config configures one identity provider
+type Config struct {
+	Name         string   // Identifies the provider in URLs, e.g. google or corp
+	Kind         string   // KindGoogle, KindGitHub or KindGeneric; "google" and "github" names imply their kind
+	Issuer       string   // Issuer URL of generic providers; GoogleIssuer for Google
+	ClientID     string   // OAuth client registered with the provider
+	ClientSecret string   // Empty for public clients, which rely on PKCE alone
+	RedirectURL  string   // Page the provider sends the user back to with the code and state
+	Scopes       []string // Defaults to openid, email and profile; read:user and user:email for GitHub
+	AuthURL      string   // GitHub only: authorization endpoint, for GitHub Enterprise
+	TokenURL     string   // GitHub only: token endpoint
+	APIURL       string   // GitHub only: REST API base URL
+}
+
+// withDefaults fills the kind, endpoints and scopes that follow from the name and kind
+func (c Config) withDefaults() Config {
+	if c.Kind == "" {
+		switch c.Name {
+		case KindGoogle, KindGitHub:
+			c.Kind = c.Name
+		default:
+			c.Kind = KindGeneric
+		}
+	}
+	switch c.Kind {
+	case KindGoogle:
+		if c.Issuer == "" {
+			c.Issuer = GoogleIssuer
+		}
+	case KindGitHub:
+		if c.AuthURL == "" {
+			c.AuthURL = GitHubAuthURL
+		}
+		if c.TokenURL == "" {
+			c.TokenURL = GitHubTokenURL
+		}
+		if c.APIURL == "" {
+			c.APIURL = GitHubAPIURL
+		}
+		if len(c.Scopes) == 0 {
+			c.Scopes = []string{"read:user", "user:email"}
+		}
+	}
+	if len(c.Scopes) == 0 {
+		c.Scopes = []string{"openid", "email", "profile"}
+	}
+	return c
+}
+
+// Identity is the user an identity provider vouches for
+type Identity struct {
+	Provider      string // Config.Name of the provider
+	Subject       string // Stable ID of the user at the provider
+	Email         string
+	EmailVerified bool // Whether the provider confirmed the user owns Email
+	FirstName     string
+	LastName      string
+}
+
+// Provider runs the authorization code flow with one identity provider
+type Provider interface {
+	Name() string
+	// AuthCodeURL returns the provider page the user signs in on. state and nonce are echoed back;
+	// codeChallenge is the S256 challenge of the verifier later passed to Exchange.
+	AuthCodeURL(ctx context.Context, state, codeChallenge, nonce string) (string, error)
+	// Exchange redeems the code returned to the redirect URL and returns the signed-in identity
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error)
+}
+
+// Providers holds the configured providers by name
+type Providers map[string]Provider
+
+// Names returns the names of the configured providers
+func (p Providers) Names() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	return names
+}
+
+// New creates a provider for each config. A nil client uses one with DefaultTimeout.
+func New(configs []Config, client *http.Client) (Providers, error) {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	providers := make(Providers, len(configs))
+	for _, config := range configs {
+		provider, err := NewProvider(config, client)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := providers[provider.Name()]; ok {
+			return nil, fmt.Errorf("identity provider %q is configured twice", provider.Name())
+		}
+		providers[provider.Name()] = provider
+	}
+	return providers, nil
+}
+
+// NewProvider creates the provider of config.Kind
+func NewProvider(config Config, client *http.Client) (Provider, error) {
+	config = config.withDefaults()
+	if config.Name == "" {
+		return nil, errors.New("identity provider name is required")
+	}
+	if config.ClientID == "" || config.RedirectURL == "" {
+		return nil, fmt.Errorf("identity provider %q needs a client ID and a redirect URL", config.Name)
+	}
+
+	switch config.Kind {
+	case KindGoogle, KindGeneric:
+		if config.Issuer == "" {
+			return nil, fmt.Errorf("identity provider %q needs an issuer URL", config.Name)
+		}
+		return newOIDCProvider(config, client), nil
+	case KindGitHub:
+		return newGitHubProvider(config, client), nil
+	default:
+		return nil, fmt.Errorf("identity provider %q has unknown kind %q, expected %s, %s or %s",
+			config.Name, config.Kind, KindGoogle, KindGitHub, KindGeneric)
+	}
+}
+
+// NewCodeVerifier returns a random PKCE code verifier (RFC 7636)
+func NewCodeVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CodeChallenge returns the S256 challenge of a code verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// authCodeURL adds the authorization request parameters to endpoint
+func authCodeURL(endpoint string, config Config, state, codeChallenge, nonce string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization endpoint %q: %v", endpoint, err)
+	}
+	query := u.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", config.ClientID)
+	query.Set("redirect_uri", config.RedirectURL)
+	query.Set("scope", strings.Join(config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	if nonce != "" {
+		query.Set("nonce", nonce)
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// tokenResponse is the token endpoint reply; errors are reported in the body by some providers even with status 200
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// exchangeCode redeems an authorization code at the token endpoint
+func exchangeCode(ctx context.Context, client *http.Client, tokenURL string, config Config, code, codeVerifier string) (*tokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", config.RedirectURL)
+	form.Set("client_id", config.ClientID)
+	form.Set("code_verifier", codeVerifier)
+	if config.ClientSecret != "" {
+		form.Set("client_secret", config.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token tokenResponse
+	status, err := doJSON(client, req, &token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchangeFailed, err)
+	}
+	if status != http.StatusOK || token.Error != "" || token.AccessToken == "" {
+		return nil, fmt.Errorf("%w: status %d: %s %s", ErrExchangeFailed, status, token.Error, token.ErrorDescription)
+	}
+	return &token, nil
+}
+
+// getJSON fetches url and decodes the JSON body into v, failing on statuses other than 200
+func getJSON(ctx context.Context, client *http.Client, url, bearer string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	status, err := doJSON(client, req, v)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, status)
+	}
+	return nil
+}
+
+// doJSON sends req and decodes a JSON body of up to 1 MiB into v; non-JSON bodies of failed requests are ignored
+func doJSON(client *http.Client, req *http.Request, v interface{}) (int, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if err := json.Unmarshal(body, v); err != nil && resp.StatusCode == http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("decode %s: %v", req.URL, err)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,232 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"mlvt/internal/infra/oidc/oidctest"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+const testRedirectURL = "http://localhost:3000/auth/callback"
+
+func newTestProvider(t *testing.T, server *oidctest.Server) Provider {
+	provider, err := NewProvider(Config{
+		Name:        "corp",
+		Issuer:      server.Issuer(),
+		ClientID:    server.ClientID,
+		RedirectURL: testRedirectURL,
+	}, server.Client())
+	assert.NoError(t, err)
+	return provider
+}
+
+func TestOIDCProviderLogin(t *testing.T) {
+	server := oidctest.NewServer("mlvt")
+	defer server.Close()
+	provider := newTestProvider(t, server)
+	ctx := context.Background()
+
+	verifier, err := NewCodeVerifier()
+	assert.NoError(t, err)
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", CodeChallenge(verifier), "nonce-1")
+	assert.NoError(t, err)
+
+	code, state, err := server.Authorize(authURL)
+	assert.NoError(t, err)
+	assert.Equal(t, "state-1", state)
+
+	identity, err := provider.Exchange(ctx, code, verifier, "nonce-1")
+	assert.NoError(t, err)
+	assert.Equal(t, &Identity{
+		Provider:      "corp",
+		Subject:       "1234567890",
+		Email:         "jane@example.com",
+		EmailVerified: true,
+		FirstName:     "Jane",
+		LastName:      "Doe",
+	}, identity)
+
+	// Codes are single use
+	_, err = provider.Exchange(ctx, code, verifier, "nonce-1")
+	assert.ErrorIs(t, err, ErrExchangeFailed)
+}
+
+func TestOIDCProviderRejectsWrongVerifier(t *testing.T) {
+	server := oidctest.NewServer("mlvt")
+	defer server.Close()
+	provider := newTestProvider(t, server)
+	ctx := context.Background()
+
+	verifier, _ := NewCodeVerifier()
+	authURL, err := provider.AuthCodeURL(ctx, "state", CodeChallenge(verifier), "nonce")
+	assert.NoError(t, err)
+	code, _, err := server.Authorize(authURL)
+	assert.NoError(t, err)
+
+	other, _ := NewCodeVerifier()
+	_, err = provider.Exchange(ctx, code, other, "nonce")
+	assert.ErrorIs(t, err, ErrExchangeFailed)
+}
+
+func TestOIDCProviderRejectsWrongNonce(t *testing.T) {
+	server := oidctest.NewServer("mlvt")
+	defer server.Close()
+	provider := newTestProvider(t, server)
+	ctx := context.Background()
+
+	verifier, _ := NewCodeVerifier()
+	authURL, err := provider.AuthCodeURL(ctx, "state", CodeChallenge(verifier), "nonce")
+	assert.NoError(t, err)
+	code, _, err := server.Authorize(authURL)
+	assert.NoError(t, err)
+
+	_, err = provider.Exchange(ctx, code, verifier, "replayed")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	server := oidctest.NewServer("mlvt")
+	defer server.Close()
+	provider := newTestProvider(t, server).(*oidcProvider)
+	ctx := context.Background()
+	discovery, err := provider.discover(ctx)
+	assert.NoError(t, err)
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss": server.Issuer(), "aud": "mlvt", "sub": "1",
+			"exp": time.Now().Add(time.Minute).Unix(), "nonce": "n",
+		}
+	}
+	tests := []struct {
+		name   string
+		modify func(jwt.MapClaims)
+		valid  bool
+	}{
+		{"valid", func(jwt.MapClaims) {}, true},
+		{"audience list", func(c jwt.MapClaims) { c["aud"] = []string{"other", "mlvt"} }, true},
+		{"other issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, false},
+		{"other audience", func(c jwt.MapClaims) { c["aud"] = "other" }, false},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }, false},
+		{"no expiry", func(c jwt.MapClaims) { delete(c, "exp") }, false},
+		{"no nonce", func(c jwt.MapClaims) { delete(c, "nonce") }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			tt.modify(claims)
+			raw, err := server.SignIDToken(claims)
+			assert.NoError(t, err)
+
+			_, err = provider.verifyIDToken(ctx, discovery, raw, "n")
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidIDToken)
+			}
+		})
+	}
+
+	// Tokens signed with HS256 using the public key as secret must not verify
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, valid())
+	raw, err := token.SignedString([]byte("secret"))
+	assert.NoError(t, err)
+	_, err = provider.verifyIDToken(ctx, discovery, raw, "n")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+}
+
+// fakeGitHub serves the GitHub token endpoint and the user APIs for a single user
+func fakeGitHub(t *testing.T, emailVerified bool) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "code-1", r.PostForm.Get("code"))
+		assert.Equal(t, "secret", r.PostForm.Get("client_secret"))
+		assert.NotEmpty(t, r.PostForm.Get("code_verifier"))
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "gho_token", "token_type": "bearer"})
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer gho_token", r.Header.Get("Authorization"))
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 583231, "login": "octocat", "name": "Mona Lisa Octocat"})
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"email": "octocat@users.noreply.github.com", "primary": false, "verified": true},
+			{"email": "octocat@github.com", "primary": true, "verified": emailVerified},
+		})
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestGitHubProviderLogin(t *testing.T) {
+	server := fakeGitHub(t, true)
+	defer server.Close()
+
+	provider, err := NewProvider(Config{
+		Name:         "github",
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  testRedirectURL,
+		AuthURL:      server.URL + "/login/oauth/authorize",
+		TokenURL:     server.URL + "/login/oauth/access_token",
+		APIURL:       server.URL,
+	}, server.Client())
+	assert.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "state", "challenge", "nonce")
+	assert.NoError(t, err)
+	parsed, err := url.Parse(authURL)
+	assert.NoError(t, err)
+	assert.Equal(t, "read:user user:email", parsed.Query().Get("scope"))
+	assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+	assert.Empty(t, parsed.Query().Get("nonce"))
+
+	identity, err := provider.Exchange(context.Background(), "code-1", "verifier", "nonce")
+	assert.NoError(t, err)
+	assert.Equal(t, &Identity{
+		Provider:      "github",
+		Subject:       "583231",
+		Email:         "octocat@github.com",
+		EmailVerified: true,
+		FirstName:     "Mona",
+		LastName:      "Lisa Octocat",
+	}, identity)
+}
+
+func TestNewProviders(t *testing.T) {
+	providers, err := New([]Config{
+		{Name: "google", ClientID: "id", RedirectURL: testRedirectURL},
+		{Name: "github", ClientID: "id", RedirectURL: testRedirectURL},
+	}, nil)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"google", "github"}, providers.Names())
+	assert.Equal(t, GoogleIssuer, providers["google"].(*oidcProvider).config.Issuer)
+
+	_, err = New([]Config{{Name: "corp", ClientID: "id", RedirectURL: testRedirectURL}}, nil)
+	assert.Error(t, err, "generic providers need an issuer")
+
+	_, err = New([]Config{
+		{Name: "github", ClientID: "id", RedirectURL: testRedirectURL},
+		{Name: "github", ClientID: "other", RedirectURL: testRedirectURL},
+	}, nil)
+	assert.Error(t, err)
+}
+
+func TestNewCodeVerifier(t *testing.T) {
+	verifier, err := NewCodeVerifier()
+	assert.NoError(t, err)
+	// RFC 7636 requires 43 to 128 unreserved characters
+	assert.Regexp(t, `^[A-Za-z0-9_-]{43,128}$`, verifier)
+	assert.Regexp(t, `^[A-Za-z0-9_-]{43}$`, CodeChallenge(verifier))
+
+	other, err := NewCodeVerifier()
+	assert.NoError(t, err)
+	assert.NotEqual(t, verifier, other)
+}
@@ -38,6 +38,7 @@ var (
 	FailedToCreatePresignedURL      localization.LocalizedString = "error.data.failed_to_create_presigned_url"
 	FailedToGeneratePresignedURL    localization.LocalizedString = "error.data.failed_to_generate_presigned_url"
 	FailedToPresignPutObjectRequest localization.LocalizedString = "error.data.failed_to_presign_put_object_request"
+	FailedToPresignGetObjectRequest localization.LocalizedString = "error.data.failed_to_presign_get_object_request"
 	RequestFormatError              localization.LocalizedString = "error.data.request_format_error"
 	ErrorReadingYAMLFile            localization.LocalizedString = "error.data.error_reading_yaml"
 	ErrorUnmarshalingYAMLData       localization.LocalizedString = "error.data.error_unmarshaling_yaml"
@@ -0,0 +1,66 @@
+// Package driver creates the object storage selected in the environment configuration.
+package driver
+
+import (
+	"crypto/rand"
+	"fmt"
+	"mlvt/internal/infra/aws"
+	"mlvt/internal/infra/env"
+	"mlvt/internal/infra/storage"
+	"mlvt/internal/infra/storage/local"
+	"mlvt/internal/infra/zap-logging/log"
+	"path/filepath"
+)
+
+// New creates the object storage selected by STORAGE_DRIVER; S3 is the default
+func New() (storage.Storage, error) {
+	switch env.EnvConfig.StorageDriver {
+	case "", storage.DriverS3:
+		s3Client, err := aws.NewS3Client()
+		if err != nil {
+			return nil, err
+		}
+		return s3Client, nil
+	case storage.DriverS3Compatible:
+		s3Client, err := aws.NewS3CompatibleClient()
+		if err != nil {
+			return nil, err
+		}
+		return s3Client, nil
+	case storage.DriverLocal:
+		return newLocalStorage()
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER %q, expected %s, %s or %s",
+			env.EnvConfig.StorageDriver, storage.DriverS3, storage.DriverS3Compatible, storage.DriverLocal)
+	}
+}
+
+// newLocalStorage creates the local disk storage, falling back to development defaults
+func newLocalStorage() (*local.Storage, error) {
+	root := env.EnvConfig.StorageLocalRoot
+	if root == "" {
+		root = filepath.Join(env.EnvConfig.RootDir, "data", "storage")
+	}
+	baseURL := env.EnvConfig.StorageLocalBaseURL
+	if baseURL == "" {
+		baseURL = "http://localhost:" + env.EnvConfig.ServerPort + "/storage"
+	}
+
+	signingKey := []byte(env.EnvConfig.StorageSigningKey)
+	if len(signingKey) == 0 {
+		log.Warn("STORAGE_SIGNING_KEY is not set; presigned URLs will stop working when the server restarts")
+		signingKey = make([]byte, 32)
+		if _, err := rand.Read(signingKey); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Infof("Using local storage in %s, served at %s", root, baseURL)
+	return local.NewStorage(local.Config{
+		Root:           root,
+		BaseURL:        baseURL,
+		SigningKey:     signingKey,
+		UploadExpiry:   env.EnvConfig.AWSPresignUploadExpiry,
+		DownloadExpiry: env.EnvConfig.AWSPresignDownloadExpiry,
+	})
+}
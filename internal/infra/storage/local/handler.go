@@ -0,0 +1,219 @@
+package local
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"mlvt/internal/infra/storage"
+	"mlvt/internal/infra/zap-logging/log"
+)
+
+// errBadRequest marks uploads that do not satisfy the conditions of their presigned URL
+var errBadRequest = errors.New("request does not match the presigned URL")
+
+// ServeHTTP serves the presigned URLs handed out by the Storage: GET and HEAD download an object,
+// PUT uploads an object or a multipart upload part.
+func (s *Storage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key, ok := strings.CutPrefix(r.URL.Path, s.mountPath+"/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if _, err := s.key("", key); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	params := r.URL.Query()
+	method := r.Method
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	if err := s.verify(method, key, params); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		s.serveObject(w, r, key, params)
+	case r.Method == http.MethodPut && params.Has(paramUploadID):
+		s.receivePart(w, r, key, params)
+	case r.Method == http.MethodPut:
+		s.receiveObject(w, r, key, params)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// verify checks the signature and expiry of a presigned request
+func (s *Storage) verify(method, key string, params url.Values) error {
+	signature := params.Get(paramSignature)
+	if signature == "" || !hmac.Equal([]byte(signature), []byte(s.sign(method, key, params))) {
+		return errors.New("signature does not match")
+	}
+	expires, err := strconv.ParseInt(params.Get(paramExpires), 10, 64)
+	if err != nil || s.now().After(time.Unix(expires, 0)) {
+		return errors.New("request has expired")
+	}
+	return nil
+}
+
+func (s *Storage) serveObject(w http.ResponseWriter, r *http.Request, key string, params url.Values) {
+	file, err := os.Open(s.objectPath(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		s.internalError(w, key, err)
+		return
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil || stat.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	meta := s.readMeta(key)
+	contentType := params.Get(paramResponseType)
+	if contentType == "" {
+		contentType = meta.ContentType
+	}
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	if disposition := params.Get(paramResponseDisposition); disposition != "" {
+		w.Header().Set("Content-Disposition", disposition)
+	}
+	if meta.ETag != "" {
+		w.Header().Set("ETag", meta.ETag)
+	}
+	http.ServeContent(w, r, "", stat.ModTime(), file)
+}
+
+func (s *Storage) receiveObject(w http.ResponseWriter, r *http.Request, key string, params url.Values) {
+	contentType := r.Header.Get("Content-Type")
+	if expected := params.Get(paramContentType); expected != "" && contentType != expected {
+		http.Error(w, "Content-Type does not match the presigned URL", http.StatusForbidden)
+		return
+	}
+
+	tmp, err := s.receiveBody(r, params)
+	if err != nil {
+		s.bodyError(w, key, err)
+		return
+	}
+
+	meta := objectMeta{ContentType: contentType, ETag: quotedHex(tmp.md5)}
+	if checksum := params.Get(paramChecksumSHA256); checksum != "" {
+		meta.ChecksumSHA256 = checksum
+	}
+	if err := s.commit(tmp.path, key, meta); err != nil {
+		s.internalError(w, key, err)
+		return
+	}
+	w.Header().Set("ETag", meta.ETag)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Storage) receivePart(w http.ResponseWriter, r *http.Request, key string, params url.Values) {
+	uploadID := params.Get(paramUploadID)
+	partNumber, err := strconv.ParseInt(params.Get(paramPartNumber), 10, 32)
+	if err != nil || partNumber < 1 || partNumber > storage.MaxParts {
+		http.Error(w, "invalid part number", http.StatusBadRequest)
+		return
+	}
+	if _, err := s.readMultipart(uploadID, key); err != nil {
+		if errors.Is(err, storage.ErrMultipartUploadNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		s.internalError(w, key, err)
+		return
+	}
+
+	tmp, err := s.receiveBody(r, params)
+	if err != nil {
+		s.bodyError(w, key, err)
+		return
+	}
+
+	// The ETag file is written last: a part without one is still being uploaded
+	partPath := s.partPath(uploadID, int32(partNumber))
+	etag := quotedHex(tmp.md5)
+	os.Remove(partPath + ".etag")
+	if err := os.Rename(tmp.path, partPath); err != nil {
+		os.Remove(tmp.path)
+		s.internalError(w, key, err)
+		return
+	}
+	if err := os.WriteFile(partPath+".etag", []byte(etag), 0o644); err != nil {
+		s.internalError(w, key, err)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	w.WriteHeader(http.StatusOK)
+}
+
+// receiveBody writes the request body to a temporary file and checks it against the
+// size and checksum the URL was presigned with
+func (s *Storage) receiveBody(r *http.Request, params url.Values) (*tempFile, error) {
+	body := io.Reader(r.Body)
+	expectedSize := int64(-1)
+	if value := params.Get(paramContentLength); value != "" {
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad content length", errBadRequest)
+		}
+		if r.ContentLength != size {
+			return nil, fmt.Errorf("%w: Content-Length must be %d", errBadRequest, size)
+		}
+		expectedSize = size
+		body = io.LimitReader(r.Body, size+1)
+	}
+
+	tmp, err := s.writeTemp(body)
+	if err != nil {
+		return nil, err
+	}
+	if expectedSize >= 0 && tmp.size != expectedSize {
+		os.Remove(tmp.path)
+		return nil, fmt.Errorf("%w: body is %d bytes, expected %d", errBadRequest, tmp.size, expectedSize)
+	}
+	if checksum := params.Get(paramChecksumSHA256); checksum != "" && base64.StdEncoding.EncodeToString(tmp.sha256) != checksum {
+		os.Remove(tmp.path)
+		return nil, fmt.Errorf("%w: SHA-256 checksum does not match", errBadRequest)
+	}
+	return tmp, nil
+}
+
+func (s *Storage) bodyError(w http.ResponseWriter, key string, err error) {
+	if errors.Is(err, errBadRequest) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.internalError(w, key, err)
+}
+
+func (s *Storage) internalError(w http.ResponseWriter, key string, err error) {
+	log.Errorf("Local storage request for %s failed: %v", key, err)
+	http.Error(w, "internal server error", http.StatusInternalServerError)
+}
+
+func quotedHex(sum []byte) string {
+	return `"` + hex.EncodeToString(sum) + `"`
+}
@@ -0,0 +1,642 @@
+// Package local stores objects in a directory on disk and serves their presigned URLs from the
+// application itself, so development and CI can run without S3.
+//
+// Objects live at <root>/<folder>/<file name>. Metadata, multipart parts and temporary files are kept
+// in the reserved top-level directories .meta, .multipart and .tmp.
+package local
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mlvt/internal/infra/storage"
+)
+
+const (
+	metaDir      = ".meta"
+	multipartDir = ".multipart"
+	tmpDir       = ".tmp"
+)
+
+// Query parameters of a presigned URL. All but paramSignature are covered by the signature.
+const (
+	paramExpires             = "expires"
+	paramContentType         = "content-type"
+	paramContentLength       = "content-length"
+	paramChecksumSHA256      = "checksum-sha256"
+	paramResponseType        = "response-content-type"
+	paramResponseDisposition = "response-content-disposition"
+	paramUploadID            = "upload-id"
+	paramPartNumber          = "part-number"
+	paramSignature           = "signature"
+)
+
+// Config configures the local storage driver
+type Config struct {
+	Root           string        // Directory the objects are stored in; created if missing
+	BaseURL        string        // Public URL the Storage handler is mounted at, e.g. http://localhost:8080/storage
+	SigningKey     []byte        // HMAC key that signs presigned URLs
+	UploadExpiry   time.Duration // Default lifetime of upload URLs
+	DownloadExpiry time.Duration // Default lifetime of download URLs
+}
+
+// Storage is a storage.Storage backed by a local directory. It is also the http.Handler
+// that serves its presigned URLs and must be mounted at MountPath.
+type Storage struct {
+	root           string
+	baseURL        string
+	mountPath      string
+	signingKey     []byte
+	uploadExpiry   time.Duration
+	downloadExpiry time.Duration
+	now            func() time.Time
+}
+
+var _ storage.Storage = (*Storage)(nil)
+
+// objectMeta is the metadata stored next to each object
+type objectMeta struct {
+	ContentType    string `json:"content_type"`
+	ChecksumSHA256 string `json:"checksum_sha256,omitempty"`
+	ETag           string `json:"etag"`
+}
+
+// multipartMeta describes a multipart upload in progress
+type multipartMeta struct {
+	Key         string `json:"key"`
+	ContentType string `json:"content_type"`
+}
+
+// NewStorage creates the storage directory layout and returns a Storage serving it
+func NewStorage(config Config) (*Storage, error) {
+	if config.Root == "" {
+		return nil, errors.New("local storage root directory is required")
+	}
+	if len(config.SigningKey) == 0 {
+		return nil, errors.New("local storage signing key is required")
+	}
+	base, err := url.Parse(strings.TrimRight(config.BaseURL, "/"))
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("local storage base URL must be an absolute URL, got %q", config.BaseURL)
+	}
+
+	for _, dir := range []string{config.Root, filepath.Join(config.Root, metaDir),
+		filepath.Join(config.Root, multipartDir), filepath.Join(config.Root, tmpDir)} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create local storage directory: %v", err)
+		}
+	}
+
+	if config.UploadExpiry <= 0 {
+		config.UploadExpiry = storage.DefaultUploadExpiry
+	}
+	if config.DownloadExpiry <= 0 {
+		config.DownloadExpiry = storage.DefaultDownloadExpiry
+	}
+
+	return &Storage{
+		root:           config.Root,
+		baseURL:        base.String(),
+		mountPath:      base.Path,
+		signingKey:     config.SigningKey,
+		uploadExpiry:   config.UploadExpiry,
+		downloadExpiry: config.DownloadExpiry,
+		now:            time.Now,
+	}, nil
+}
+
+// MountPath is the URL path the Storage handler must be served under
+func (s *Storage) MountPath() string {
+	return s.mountPath
+}
+
+// GeneratePresignedUploadURL returns a signed URL that accepts a PUT of the object
+func (s *Storage) GeneratePresignedUploadURL(folder string, fileName string, fileType string, opts ...storage.PresignOption) (string, error) {
+	key, err := s.key(folder, fileName)
+	if err != nil {
+		return "", err
+	}
+
+	options := storage.ApplyPresignOptions(s.uploadExpiry, opts)
+	params := url.Values{}
+	if fileType != "" {
+		params.Set(paramContentType, fileType)
+	}
+	if options.ContentLength > 0 {
+		params.Set(paramContentLength, strconv.FormatInt(options.ContentLength, 10))
+	}
+	if options.ChecksumSHA256 != "" {
+		params.Set(paramChecksumSHA256, options.ChecksumSHA256)
+	}
+	return s.presign("PUT", key, params, options.Expires), nil
+}
+
+// GeneratePresignedDownloadURL returns a signed URL that serves the object to GET and HEAD requests
+func (s *Storage) GeneratePresignedDownloadURL(folder string, fileName string, fileType string, opts ...storage.PresignOption) (string, error) {
+	key, err := s.key(folder, fileName)
+	if err != nil {
+		return "", err
+	}
+
+	options := storage.ApplyPresignOptions(s.downloadExpiry, opts)
+	params := url.Values{}
+	if fileType != "" {
+		params.Set(paramResponseType, fileType)
+	}
+	if options.ContentDisposition != "" {
+		params.Set(paramResponseDisposition, options.ContentDisposition)
+	}
+	return s.presign("GET", key, params, options.Expires), nil
+}
+
+// HeadObject returns the metadata of an object, or storage.ErrObjectNotFound if it does not exist
+func (s *Storage) HeadObject(folder string, fileName string) (*storage.ObjectInfo, error) {
+	key, err := s.key(folder, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := os.Stat(s.objectPath(key))
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && stat.IsDir()) {
+		return nil, storage.ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat object %s: %v", key, err)
+	}
+
+	meta := s.readMeta(key)
+	return &storage.ObjectInfo{
+		Size:           stat.Size(),
+		ContentType:    meta.ContentType,
+		ChecksumSHA256: meta.ChecksumSHA256,
+		ETag:           meta.ETag,
+		LastModified:   stat.ModTime(),
+	}, nil
+}
+
+// GetObject opens the content of an object, or returns storage.ErrObjectNotFound if it does not exist.
+// The caller must close the returned reader.
+func (s *Storage) GetObject(folder string, fileName string) (io.ReadCloser, error) {
+	key, err := s.key(folder, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(s.objectPath(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, storage.ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open object %s: %v", key, err)
+	}
+	if stat, err := file.Stat(); err != nil || stat.IsDir() {
+		file.Close()
+		return nil, storage.ErrObjectNotFound
+	}
+	return file, nil
+}
+
+// DeleteObject removes an object; deleting a missing object is not an error
+func (s *Storage) DeleteObject(folder string, fileName string) error {
+	key, err := s.key(folder, fileName)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(s.objectPath(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete object %s: %v", key, err)
+	}
+	if err := os.Remove(s.metaPath(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete metadata of %s: %v", key, err)
+	}
+	return nil
+}
+
+// CopyObject copies an object and its metadata
+func (s *Storage) CopyObject(srcFolder string, srcFileName string, dstFolder string, dstFileName string) error {
+	srcKey, err := s.key(srcFolder, srcFileName)
+	if err != nil {
+		return err
+	}
+	dstKey, err := s.key(dstFolder, dstFileName)
+	if err != nil {
+		return err
+	}
+
+	src, err := os.Open(s.objectPath(srcKey))
+	if errors.Is(err, fs.ErrNotExist) {
+		return storage.ErrObjectNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open object %s: %v", srcKey, err)
+	}
+	defer src.Close()
+
+	tmp, err := s.writeTemp(src)
+	if err != nil {
+		return fmt.Errorf("failed to copy object %s: %v", srcKey, err)
+	}
+	if err := s.commit(tmp.path, dstKey, s.readMeta(srcKey)); err != nil {
+		return fmt.Errorf("failed to copy object %s to %s: %v", srcKey, dstKey, err)
+	}
+	return nil
+}
+
+// ListObjects returns every object stored under folder, including those in nested folders
+func (s *Storage) ListObjects(folder string) ([]storage.ObjectInfo, error) {
+	prefix := storage.FolderPrefix(folder)
+	if prefix != "" {
+		if _, err := s.key("", strings.TrimSuffix(prefix, "/")); err != nil {
+			return nil, err
+		}
+	}
+	dir := filepath.Join(s.root, filepath.FromSlash(prefix))
+
+	var objects []storage.ObjectInfo
+	err := filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && filePath == dir {
+			return fs.SkipAll
+		}
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if prefix == "" && entry.IsDir() && strings.HasPrefix(rel, ".") && rel != "." {
+			return fs.SkipDir // Reserved directories
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		meta := s.readMeta(prefix + rel)
+		objects = append(objects, storage.ObjectInfo{
+			FileName:     rel,
+			Size:         info.Size(),
+			ContentType:  meta.ContentType,
+			ETag:         meta.ETag,
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects under %q: %v", prefix, err)
+	}
+	return objects, nil
+}
+
+// CreateMultipartUpload starts a multipart upload and returns its ID
+func (s *Storage) CreateMultipartUpload(folder string, fileName string, fileType string) (string, error) {
+	key, err := s.key(folder, fileName)
+	if err != nil {
+		return "", err
+	}
+
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	uploadID := hex.EncodeToString(random)
+
+	dir := s.multipartPath(uploadID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create multipart upload for %s: %v", key, err)
+	}
+	if err := writeJSON(filepath.Join(dir, "upload.json"), multipartMeta{Key: key, ContentType: fileType}); err != nil {
+		return "", fmt.Errorf("failed to create multipart upload for %s: %v", key, err)
+	}
+	return uploadID, nil
+}
+
+// GeneratePresignedUploadPartURL returns a signed URL that accepts a PUT of one part of a multipart upload
+func (s *Storage) GeneratePresignedUploadPartURL(folder string, fileName string, uploadID string, partNumber int32, opts ...storage.PresignOption) (string, error) {
+	key, err := s.key(folder, fileName)
+	if err != nil {
+		return "", err
+	}
+	if partNumber < 1 || partNumber > storage.MaxParts {
+		return "", fmt.Errorf("part number must be between 1 and %d", storage.MaxParts)
+	}
+
+	options := storage.ApplyPresignOptions(s.uploadExpiry, opts)
+	params := url.Values{}
+	params.Set(paramUploadID, uploadID)
+	params.Set(paramPartNumber, strconv.Itoa(int(partNumber)))
+	if options.ContentLength > 0 {
+		params.Set(paramContentLength, strconv.FormatInt(options.ContentLength, 10))
+	}
+	return s.presign("PUT", key, params, options.Expires), nil
+}
+
+// ListUploadedParts returns every part received for a multipart upload, in part number order
+func (s *Storage) ListUploadedParts(folder string, fileName string, uploadID string) ([]storage.UploadedPart, error) {
+	key, err := s.key(folder, fileName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.readMultipart(uploadID, key); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(s.multipartPath(uploadID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list parts of %s: %v", key, err)
+	}
+
+	var parts []storage.UploadedPart
+	for _, entry := range entries {
+		partNumber, err := strconv.ParseInt(entry.Name(), 10, 32)
+		if err != nil || entry.IsDir() {
+			continue // upload.json and ETag files
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list parts of %s: %v", key, err)
+		}
+		etag, err := os.ReadFile(s.partPath(uploadID, int32(partNumber)) + ".etag")
+		if err != nil {
+			continue // Still being written
+		}
+		parts = append(parts, storage.UploadedPart{PartNumber: int32(partNumber), ETag: string(etag), Size: info.Size()})
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+	return parts, nil
+}
+
+// CompleteMultipartUpload concatenates the given parts, in order, into the final object
+func (s *Storage) CompleteMultipartUpload(folder string, fileName string, uploadID string, parts []storage.UploadedPart) error {
+	key, err := s.key(folder, fileName)
+	if err != nil {
+		return err
+	}
+	upload, err := s.readMultipart(uploadID, key)
+	if err != nil {
+		return err
+	}
+	if len(parts) == 0 {
+		return errors.New("a multipart upload needs at least one part")
+	}
+
+	paths := make([]string, 0, len(parts))
+	etags := md5.New()
+	for i, part := range parts {
+		if i > 0 && part.PartNumber <= parts[i-1].PartNumber {
+			return errors.New("parts must be in ascending part number order")
+		}
+		partPath := s.partPath(uploadID, part.PartNumber)
+		etag, err := os.ReadFile(partPath + ".etag")
+		if err != nil || string(etag) != part.ETag {
+			return fmt.Errorf("part %d was not uploaded or its ETag does not match", part.PartNumber)
+		}
+		stat, err := os.Stat(partPath)
+		if err != nil {
+			return fmt.Errorf("failed to stat part %d: %v", part.PartNumber, err)
+		}
+		if i < len(parts)-1 && stat.Size() < storage.MinPartSize {
+			return fmt.Errorf("part %d is smaller than the minimum part size", part.PartNumber)
+		}
+
+		sum, _ := hex.DecodeString(strings.Trim(part.ETag, `"`))
+		etags.Write(sum)
+		paths = append(paths, partPath)
+	}
+
+	tmp, err := s.writeTemp(&filesReader{paths: paths})
+	if err != nil {
+		return fmt.Errorf("failed to complete multipart upload of %s: %v", key, err)
+	}
+	// Like S3, the ETag of a multipart object is the MD5 of the part MD5s followed by the part count
+	meta := objectMeta{
+		ContentType: upload.ContentType,
+		ETag:        fmt.Sprintf(`"%s-%d"`, hex.EncodeToString(etags.Sum(nil)), len(parts)),
+	}
+	if err := s.commit(tmp.path, key, meta); err != nil {
+		return fmt.Errorf("failed to complete multipart upload of %s: %v", key, err)
+	}
+	return os.RemoveAll(s.multipartPath(uploadID))
+}
+
+// AbortMultipartUpload discards a multipart upload and its parts.
+// Aborting an unknown upload is not an error.
+func (s *Storage) AbortMultipartUpload(folder string, fileName string, uploadID string) error {
+	key, err := s.key(folder, fileName)
+	if err != nil {
+		return err
+	}
+	_, err = s.readMultipart(uploadID, key)
+	if errors.Is(err, storage.ErrMultipartUploadNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(s.multipartPath(uploadID)); err != nil {
+		return fmt.Errorf("failed to abort multipart upload of %s: %v", key, err)
+	}
+	return nil
+}
+
+// key builds and validates an object key. Keys may not escape the root or use the reserved directories.
+func (s *Storage) key(folder, fileName string) (string, error) {
+	key, err := storage.ObjectKey(folder, fileName)
+	if err != nil {
+		return "", err
+	}
+	segments := strings.Split(key, "/")
+	for _, segment := range segments {
+		if segment == "" || segment == "." || segment == ".." || strings.ContainsRune(segment, '\\') {
+			return "", fmt.Errorf("invalid object key %q", key)
+		}
+	}
+	if strings.HasPrefix(segments[0], ".") {
+		return "", fmt.Errorf("invalid object key %q: names starting with a dot are reserved", key)
+	}
+	return key, nil
+}
+
+func (s *Storage) objectPath(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(key))
+}
+
+func (s *Storage) metaPath(key string) string {
+	return filepath.Join(s.root, metaDir, filepath.FromSlash(key)+".json")
+}
+
+func (s *Storage) multipartPath(uploadID string) string {
+	return filepath.Join(s.root, multipartDir, uploadID)
+}
+
+func (s *Storage) partPath(uploadID string, partNumber int32) string {
+	return filepath.Join(s.multipartPath(uploadID), fmt.Sprintf("%05d", partNumber))
+}
+
+// readMeta returns the stored metadata of an object, or empty metadata if there is none
+func (s *Storage) readMeta(key string) objectMeta {
+	var meta objectMeta
+	data, err := os.ReadFile(s.metaPath(key))
+	if err == nil {
+		_ = json.Unmarshal(data, &meta)
+	}
+	return meta
+}
+
+// readMultipart loads a multipart upload and checks it belongs to key
+func (s *Storage) readMultipart(uploadID, key string) (*multipartMeta, error) {
+	// Upload IDs are hex; anything else cannot name an upload directory
+	if _, err := hex.DecodeString(uploadID); err != nil || uploadID == "" {
+		return nil, storage.ErrMultipartUploadNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(s.multipartPath(uploadID), "upload.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, storage.ErrMultipartUploadNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read multipart upload %s: %v", uploadID, err)
+	}
+	var upload multipartMeta
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return nil, fmt.Errorf("failed to read multipart upload %s: %v", uploadID, err)
+	}
+	if upload.Key != key {
+		return nil, storage.ErrMultipartUploadNotFound
+	}
+	return &upload, nil
+}
+
+// tempFile is a fully written temporary file with its digests
+type tempFile struct {
+	path   string
+	size   int64
+	sha256 []byte
+	md5    []byte
+}
+
+// writeTemp copies r into a new file under the temporary directory
+func (s *Storage) writeTemp(r io.Reader) (*tempFile, error) {
+	file, err := os.CreateTemp(filepath.Join(s.root, tmpDir), "object-*")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sha, sum := sha256.New(), md5.New()
+	size, err := io.Copy(io.MultiWriter(file, sha, sum), r)
+	if err == nil {
+		err = file.Close()
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return &tempFile{path: file.Name(), size: size, sha256: sha.Sum(nil), md5: sum.Sum(nil)}, nil
+}
+
+// commit moves a temporary file into place as the object key and records its metadata
+func (s *Storage) commit(tmpPath, key string, meta objectMeta) error {
+	objectPath := s.objectPath(key)
+	if err := os.MkdirAll(filepath.Dir(objectPath), 0o755); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.metaPath(key)), 0o755); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := writeJSON(s.metaPath(key), meta); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, objectPath)
+}
+
+// presign builds a URL for method on key that is valid for expires
+func (s *Storage) presign(method, key string, params url.Values, expires time.Duration) string {
+	params.Set(paramExpires, strconv.FormatInt(s.now().Add(expires).Unix(), 10))
+	params.Set(paramSignature, s.sign(method, key, params))
+	return s.baseURL + "/" + escapeKey(key) + "?" + params.Encode()
+}
+
+// sign computes the signature over the method, the key and every parameter but the signature itself
+func (s *Storage) sign(method, key string, params url.Values) string {
+	signed := url.Values{}
+	for name, values := range params {
+		if name != paramSignature {
+			signed[name] = values
+		}
+	}
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(method + "\n" + key + "\n" + signed.Encode()))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// escapeKey escapes each segment of a key for use in a URL path
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return path.Join(segments...)
+}
+
+// filesReader reads a list of files one after another, keeping only one of them open
+type filesReader struct {
+	paths   []string
+	current *os.File
+}
+
+func (r *filesReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.paths) == 0 {
+				return 0, io.EOF
+			}
+			file, err := os.Open(r.paths[0])
+			if err != nil {
+				return 0, err
+			}
+			r.current, r.paths = file, r.paths[1:]
+		}
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func writeJSON(filePath string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, data, 0o644)
+}
@@ -0,0 +1,227 @@
+package local
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mlvt/internal/infra/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setupLocalStorage serves a Storage rooted in a temporary directory at <server>/storage
+func setupLocalStorage(t *testing.T) *Storage {
+	var store *Storage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		store.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	store, err := NewStorage(Config{Root: t.TempDir(), BaseURL: server.URL + "/storage", SigningKey: []byte("secret")})
+	assert.NoError(t, err)
+	return store
+}
+
+func put(t *testing.T, presignedURL, contentType string, body []byte) *http.Response {
+	req, err := http.NewRequest(http.MethodPut, presignedURL, bytes.NewReader(body))
+	assert.NoError(t, err)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	return resp
+}
+
+func TestUploadAndDownload(t *testing.T) {
+	store := setupLocalStorage(t)
+	body := []byte("video bytes")
+	sum := sha256.Sum256(body)
+	checksum := base64.StdEncoding.EncodeToString(sum[:])
+
+	uploadURL, err := store.GeneratePresignedUploadURL("videos", "clip.mp4", "video/mp4",
+		storage.WithContentLength(int64(len(body))), storage.WithChecksumSHA256(checksum))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(uploadURL, store.baseURL+"/videos/clip.mp4?"))
+	assert.Equal(t, http.StatusOK, put(t, uploadURL, "video/mp4", body).StatusCode)
+
+	info, err := store.HeadObject("videos", "clip.mp4")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(body)), info.Size)
+	assert.Equal(t, "video/mp4", info.ContentType)
+	assert.Equal(t, checksum, info.ChecksumSHA256)
+
+	downloadURL, err := store.GeneratePresignedDownloadURL("videos", "clip.mp4", "video/mp4", storage.AsAttachment("clip.mp4"))
+	assert.NoError(t, err)
+	resp, err := http.Get(downloadURL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	downloaded, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, body, downloaded)
+	assert.Equal(t, `attachment; filename="clip.mp4"`, resp.Header.Get("Content-Disposition"))
+	assert.Equal(t, "video/mp4", resp.Header.Get("Content-Type"))
+}
+
+func TestUploadRejectsMismatchedBody(t *testing.T) {
+	store := setupLocalStorage(t)
+
+	uploadURL, err := store.GeneratePresignedUploadURL("videos", "clip.mp4", "video/mp4",
+		storage.WithContentLength(4), storage.WithChecksumSHA256(base64.StdEncoding.EncodeToString(make([]byte, 32))))
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusBadRequest, put(t, uploadURL, "video/mp4", []byte("too long")).StatusCode)
+	assert.Equal(t, http.StatusBadRequest, put(t, uploadURL, "video/mp4", []byte("abcd")).StatusCode) // Wrong checksum
+	assert.Equal(t, http.StatusForbidden, put(t, uploadURL, "image/png", []byte("abcd")).StatusCode)
+
+	_, err = store.HeadObject("videos", "clip.mp4")
+	assert.ErrorIs(t, err, storage.ErrObjectNotFound)
+}
+
+func TestPresignedURLSignature(t *testing.T) {
+	store := setupLocalStorage(t)
+
+	uploadURL, err := store.GeneratePresignedUploadURL("videos", "clip.mp4", "video/mp4", storage.WithContentLength(4))
+	assert.NoError(t, err)
+
+	// Changing any signed parameter invalidates the URL
+	tampered := strings.Replace(uploadURL, "content-length=4", "content-length=5", 1)
+	assert.Equal(t, http.StatusForbidden, put(t, tampered, "video/mp4", []byte("abcde")).StatusCode)
+
+	// So does using it for another object or method
+	otherKey := strings.Replace(uploadURL, "/videos/clip.mp4", "/videos/other.mp4", 1)
+	assert.Equal(t, http.StatusForbidden, put(t, otherKey, "video/mp4", []byte("abcd")).StatusCode)
+	resp, err := http.Get(uploadURL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// And expiry
+	store.now = func() time.Time { return time.Now().Add(time.Hour) }
+	assert.Equal(t, http.StatusForbidden, put(t, uploadURL, "video/mp4", []byte("abcd")).StatusCode)
+}
+
+func TestCopyListAndDelete(t *testing.T) {
+	store := setupLocalStorage(t)
+
+	uploadURL, _ := store.GeneratePresignedUploadURL("videos", "a.mp4", "video/mp4")
+	assert.Equal(t, http.StatusOK, put(t, uploadURL, "video/mp4", []byte("aaa")).StatusCode)
+
+	assert.NoError(t, store.CopyObject("videos", "a.mp4", "videos/archive", "b.mp4"))
+	info, err := store.HeadObject("videos/archive", "b.mp4")
+	assert.NoError(t, err)
+	assert.Equal(t, "video/mp4", info.ContentType)
+
+	body, err := store.GetObject("videos/archive", "b.mp4")
+	if assert.NoError(t, err) {
+		content, _ := io.ReadAll(body)
+		body.Close()
+		assert.Equal(t, "aaa", string(content))
+	}
+	_, err = store.GetObject("videos", "missing.mp4")
+	assert.ErrorIs(t, err, storage.ErrObjectNotFound)
+	_, err = store.GetObject("", "videos")
+	assert.ErrorIs(t, err, storage.ErrObjectNotFound)
+	assert.ErrorIs(t, store.CopyObject("videos", "missing.mp4", "videos", "c.mp4"), storage.ErrObjectNotFound)
+
+	objects, err := store.ListObjects("videos")
+	assert.NoError(t, err)
+	var names []string
+	for _, object := range objects {
+		names = append(names, object.FileName)
+	}
+	assert.ElementsMatch(t, []string{"a.mp4", "archive/b.mp4"}, names)
+
+	// The reserved directories are not objects
+	all, err := store.ListObjects("")
+	assert.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	empty, err := store.ListObjects("audios")
+	assert.NoError(t, err)
+	assert.Empty(t, empty)
+
+	assert.NoError(t, store.DeleteObject("videos", "a.mp4"))
+	assert.NoError(t, store.DeleteObject("videos", "a.mp4"))
+	_, err = store.HeadObject("videos", "a.mp4")
+	assert.ErrorIs(t, err, storage.ErrObjectNotFound)
+}
+
+func TestInvalidKeys(t *testing.T) {
+	store := setupLocalStorage(t)
+
+	for _, key := range [][2]string{{"..", "etc"}, {"videos", "../../x"}, {"", ".meta"}, {"videos", ""}, {"a//b", "c"}} {
+		_, err := store.GeneratePresignedUploadURL(key[0], key[1], "")
+		assert.Error(t, err, key)
+	}
+}
+
+func TestMultipartUpload(t *testing.T) {
+	store := setupLocalStorage(t)
+
+	uploadID, err := store.CreateMultipartUpload("videos", "big.mp4", "video/mp4")
+	assert.NoError(t, err)
+
+	first := bytes.Repeat([]byte("a"), int(storage.MinPartSize))
+	last := []byte("tail")
+	for partNumber, body := range map[int32][]byte{1: first, 2: last} {
+		partURL, err := store.GeneratePresignedUploadPartURL("videos", "big.mp4", uploadID, partNumber,
+			storage.WithContentLength(int64(len(body))))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, put(t, partURL, "", body).StatusCode)
+	}
+
+	parts, err := store.ListUploadedParts("videos", "big.mp4", uploadID)
+	assert.NoError(t, err)
+	assert.Len(t, parts, 2)
+	assert.Equal(t, int32(1), parts[0].PartNumber)
+	assert.Equal(t, int64(len(last)), parts[1].Size)
+
+	assert.NoError(t, store.CompleteMultipartUpload("videos", "big.mp4", uploadID, parts))
+	info, err := store.HeadObject("videos", "big.mp4")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(first)+len(last)), info.Size)
+	assert.Equal(t, "video/mp4", info.ContentType)
+	assert.True(t, strings.HasSuffix(info.ETag, `-2"`))
+
+	// The upload is gone once completed
+	_, err = store.ListUploadedParts("videos", "big.mp4", uploadID)
+	assert.ErrorIs(t, err, storage.ErrMultipartUploadNotFound)
+	assert.ErrorIs(t, store.CompleteMultipartUpload("videos", "big.mp4", uploadID, parts), storage.ErrMultipartUploadNotFound)
+}
+
+func TestAbortMultipartUpload(t *testing.T) {
+	store := setupLocalStorage(t)
+
+	uploadID, err := store.CreateMultipartUpload("videos", "big.mp4", "video/mp4")
+	assert.NoError(t, err)
+
+	// Parts smaller than the minimum may only come last
+	for _, partNumber := range []int32{1, 2} {
+		partURL, _ := store.GeneratePresignedUploadPartURL("videos", "big.mp4", uploadID, partNumber)
+		assert.Equal(t, http.StatusOK, put(t, partURL, "", []byte("small")).StatusCode)
+	}
+	parts, err := store.ListUploadedParts("videos", "big.mp4", uploadID)
+	assert.NoError(t, err)
+	assert.Error(t, store.CompleteMultipartUpload("videos", "big.mp4", uploadID, parts))
+
+	// Upload IDs are bound to their key
+	_, err = store.ListUploadedParts("videos", "other.mp4", uploadID)
+	assert.ErrorIs(t, err, storage.ErrMultipartUploadNotFound)
+
+	assert.NoError(t, store.AbortMultipartUpload("videos", "big.mp4", uploadID))
+	assert.NoError(t, store.AbortMultipartUpload("videos", "big.mp4", uploadID))
+	_, err = store.ListUploadedParts("videos", "big.mp4", uploadID)
+	assert.ErrorIs(t, err, storage.ErrMultipartUploadNotFound)
+
+	partURL, _ := store.GeneratePresignedUploadPartURL("videos", "big.mp4", uploadID, 3)
+	assert.Equal(t, http.StatusNotFound, put(t, partURL, "", []byte("late")).StatusCode)
+}
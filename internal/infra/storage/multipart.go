@@ -0,0 +1,30 @@
+package storage
+
+import "errors"
+
+// Multipart upload limits. They are the S3 limits; every driver enforces the same ones.
+const (
+	MinPartSize int64 = 5 << 20 // Every part but the last must be at least 5 MiB
+	MaxPartSize int64 = 5 << 30
+	MaxParts          = 10000
+)
+
+// ErrMultipartUploadNotFound is returned when storage no longer knows a multipart upload,
+// because it was completed, aborted or never existed
+var ErrMultipartUploadNotFound = errors.New("multipart upload not found")
+
+// UploadedPart is a part storage has received for a multipart upload
+type UploadedPart struct {
+	PartNumber int32
+	ETag       string
+	Size       int64
+}
+
+// MultipartUploader orchestrates multipart uploads so large files can be sent in resumable, parallel parts
+type MultipartUploader interface {
+	CreateMultipartUpload(folder string, fileName string, fileType string) (string, error)
+	GeneratePresignedUploadPartURL(folder string, fileName string, uploadID string, partNumber int32, opts ...PresignOption) (string, error)
+	ListUploadedParts(folder string, fileName string, uploadID string) ([]UploadedPart, error)
+	CompleteMultipartUpload(folder string, fileName string, uploadID string, parts []UploadedPart) error
+	AbortMultipartUpload(folder string, fileName string, uploadID string) error
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// PresignOptions holds the optional settings of a presigned request
+type PresignOptions struct {
+	Expires            time.Duration
+	ContentDisposition string
+	ContentLength      int64  // Upload only: the exact size the client must send
+	ChecksumSHA256     string // Upload only: base64 SHA-256 the client must send and storage verifies
+}
+
+// PresignOption overrides a single presign setting
+type PresignOption func(*PresignOptions)
+
+// WithExpiry sets how long the presigned URL stays valid
+func WithExpiry(expires time.Duration) PresignOption {
+	return func(o *PresignOptions) {
+		o.Expires = expires
+	}
+}
+
+// WithContentDisposition sets the Content-Disposition header returned with a download
+func WithContentDisposition(disposition string) PresignOption {
+	return func(o *PresignOptions) {
+		o.ContentDisposition = disposition
+	}
+}
+
+// WithContentLength binds a presigned upload to an exact object size in bytes
+func WithContentLength(size int64) PresignOption {
+	return func(o *PresignOptions) {
+		o.ContentLength = size
+	}
+}
+
+// WithChecksumSHA256 makes storage reject a presigned upload whose body does not match the base64 SHA-256
+func WithChecksumSHA256(checksum string) PresignOption {
+	return func(o *PresignOptions) {
+		o.ChecksumSHA256 = checksum
+	}
+}
+
+// AsAttachment makes browsers save the downloaded object under the given file name
+func AsAttachment(fileName string) PresignOption {
+	return WithContentDisposition(fmt.Sprintf("attachment; filename=%q", fileName))
+}
+
+// ApplyPresignOptions applies the caller's options on top of the given default expiry
+func ApplyPresignOptions(defaultExpiry time.Duration, opts []PresignOption) *PresignOptions {
+	options := &PresignOptions{Expires: defaultExpiry}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
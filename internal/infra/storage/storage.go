@@ -0,0 +1,73 @@
+// Package storage defines the object storage used for videos, audio, transcriptions and images.
+// Drivers live in internal/infra/aws (S3 and S3-compatible services such as MinIO)
+// and internal/infra/storage/local (a directory on disk served by the application).
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// Supported values of STORAGE_DRIVER
+const (
+	DriverS3           = "s3"
+	DriverS3Compatible = "s3-compatible"
+	DriverLocal        = "local"
+)
+
+const (
+	// DefaultUploadExpiry is used when AWS_PRESIGN_UPLOAD_EXPIRY is not set
+	DefaultUploadExpiry = 15 * time.Minute
+	// DefaultDownloadExpiry is used when AWS_PRESIGN_DOWNLOAD_EXPIRY is not set
+	DefaultDownloadExpiry = time.Hour
+)
+
+// ErrObjectNotFound is returned when the requested object does not exist
+var ErrObjectNotFound = errors.New("object not found")
+
+// Storage stores objects under a folder and file name and hands out presigned URLs,
+// so clients transfer files directly instead of through the API
+type Storage interface {
+	GeneratePresignedUploadURL(folder string, fileName string, fileType string, opts ...PresignOption) (string, error)
+	GeneratePresignedDownloadURL(folder string, fileName string, fileType string, opts ...PresignOption) (string, error)
+	HeadObject(folder string, fileName string) (*ObjectInfo, error)
+	GetObject(folder string, fileName string) (io.ReadCloser, error)
+	DeleteObject(folder string, fileName string) error
+	CopyObject(srcFolder string, srcFileName string, dstFolder string, dstFileName string) error
+	ListObjects(folder string) ([]ObjectInfo, error)
+	MultipartUploader
+}
+
+// ObjectInfo is the metadata of a stored object
+type ObjectInfo struct {
+	FileName       string // Set by ListObjects: the name relative to the listed folder
+	Size           int64
+	ContentType    string
+	ChecksumSHA256 string // Base64 SHA-256 of the object; empty unless it was uploaded with a checksum
+	ETag           string
+	LastModified   time.Time
+}
+
+// ObjectKey combines folder and fileName to form the object key (path to the file)
+func ObjectKey(folder, fileName string) (string, error) {
+	if fileName == "" {
+		return "", fmt.Errorf("file name must not be empty")
+	}
+	if folder == "" {
+		return fileName, nil
+	}
+	return folder + "/" + fileName, nil
+}
+
+// FolderPrefix returns the key prefix shared by every object in folder
+func FolderPrefix(folder string) string {
+	folder = strings.Trim(path.Clean("/"+folder), "/")
+	if folder == "" {
+		return ""
+	}
+	return folder + "/"
+}
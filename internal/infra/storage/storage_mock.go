@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"io"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockStorage is a mock implementation of Storage
+type MockStorage struct {
+	mock.Mock
+}
+
+func (m *MockStorage) GeneratePresignedUploadURL(folder string, fileName string, fileType string, opts ...PresignOption) (string, error) {
+	args := m.Called(folder, fileName, fileType)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockStorage) GeneratePresignedDownloadURL(folder string, fileName string, fileType string, opts ...PresignOption) (string, error) {
+	args := m.Called(folder, fileName, fileType)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockStorage) HeadObject(folder string, fileName string) (*ObjectInfo, error) {
+	args := m.Called(folder, fileName)
+	info, _ := args.Get(0).(*ObjectInfo)
+	return info, args.Error(1)
+}
+
+func (m *MockStorage) GetObject(folder string, fileName string) (io.ReadCloser, error) {
+	args := m.Called(folder, fileName)
+	body, _ := args.Get(0).(io.ReadCloser)
+	return body, args.Error(1)
+}
+
+func (m *MockStorage) DeleteObject(folder string, fileName string) error {
+	args := m.Called(folder, fileName)
+	return args.Error(0)
+}
+
+func (m *MockStorage) CopyObject(srcFolder string, srcFileName string, dstFolder string, dstFileName string) error {
+	args := m.Called(srcFolder, srcFileName, dstFolder, dstFileName)
+	return args.Error(0)
+}
+
+func (m *MockStorage) ListObjects(folder string) ([]ObjectInfo, error) {
+	args := m.Called(folder)
+	objects, _ := args.Get(0).([]ObjectInfo)
+	return objects, args.Error(1)
+}
+
+func (m *MockStorage) CreateMultipartUpload(folder string, fileName string, fileType string) (string, error) {
+	args := m.Called(folder, fileName, fileType)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockStorage) GeneratePresignedUploadPartURL(folder string, fileName string, uploadID string, partNumber int32, opts ...PresignOption) (string, error) {
+	args := m.Called(folder, fileName, uploadID, partNumber)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockStorage) ListUploadedParts(folder string, fileName string, uploadID string) ([]UploadedPart, error) {
+	args := m.Called(folder, fileName, uploadID)
+	parts, _ := args.Get(0).([]UploadedPart)
+	return parts, args.Error(1)
+}
+
+func (m *MockStorage) CompleteMultipartUpload(folder string, fileName string, uploadID string, parts []UploadedPart) error {
+	args := m.Called(folder, fileName, uploadID, parts)
+	return args.Error(0)
+}
+
+func (m *MockStorage) AbortMultipartUpload(folder string, fileName string, uploadID string) error {
+	args := m.Called(folder, fileName, uploadID)
+	return args.Error(0)
+}
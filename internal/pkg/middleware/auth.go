@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"mlvt/internal/entity"
+	"mlvt/internal/pkg/response"
 	"mlvt/internal/service"
 	"net/http"
 	"strings"
@@ -9,26 +10,35 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// UserInfoKey is the gin context key holding the authenticated *entity.User
+const UserInfoKey = "userInfo"
+
 // AuthService defines methods for user authentication
 type AuthService interface {
 	GetUserByToken(token string) (*entity.User, error)
 	// Add other authentication-related methods if needed
 }
 
-// AuthUserMiddleware handles user authentication
+// APIKeyInfoKey is the gin context key holding the *entity.APIKey of requests authenticated by an API key
+const APIKeyInfoKey = "apiKeyInfo"
+
+// AuthUserMiddleware handles user authentication by bearer JWT or API key
 type AuthUserMiddleware struct {
-	authService *service.AuthService
+	authService   *service.AuthService
+	apiKeyService service.APIKeyService
 }
 
 // NewAuthUserMiddleware creates a new AuthUserMiddleware
-func NewAuthUserMiddleware(authService *service.AuthService) *AuthUserMiddleware {
+func NewAuthUserMiddleware(authService *service.AuthService, apiKeyService service.APIKeyService) *AuthUserMiddleware {
 	return &AuthUserMiddleware{
-		authService: authService,
+		authService:   authService,
+		apiKeyService: apiKeyService,
 	}
 }
 
-// Auth is a middleware function that authenticates the user if a token is present
-func (am *AuthUserMiddleware) Auth() gin.HandlerFunc {
+// Auth is a middleware function that authenticates the user if a token is present.
+// API keys are accepted if they hold one of the scopes, as in MustAuth.
+func (am *AuthUserMiddleware) Auth(scopes ...string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		token := extractToken(ctx)
 		if len(token) == 0 {
@@ -36,19 +46,24 @@ func (am *AuthUserMiddleware) Auth() gin.HandlerFunc {
 			return
 		}
 
-		userInfo, err := am.authService.GetUserByToken(token)
-		if err != nil || userInfo == nil {
+		userInfo, apiKey, err := am.authenticate(ctx, token)
+		if err != nil || userInfo == nil || (apiKey != nil && !apiKeyAllows(apiKey, scopes, ctx.Request.Method)) {
 			ctx.Next()
 			return
 		}
 
-		ctx.Set("userInfo", userInfo)
+		ctx.Set(UserInfoKey, userInfo)
+		if apiKey != nil {
+			ctx.Set(APIKeyInfoKey, apiKey)
+		}
 		ctx.Next()
 	}
 }
 
-// MustAuth ensures the user is authenticated; otherwise, returns an error
-func (am *AuthUserMiddleware) MustAuth() gin.HandlerFunc {
+// MustAuth ensures the user is authenticated; otherwise, returns an error.
+// API keys are only accepted on routes that name scopes, and must hold one of them; without scopes
+// a route is for signed-in users only, so keys cannot, for example, create further keys.
+func (am *AuthUserMiddleware) MustAuth(scopes ...string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		token := extractToken(ctx)
 		if len(token) == 0 {
@@ -56,17 +71,62 @@ func (am *AuthUserMiddleware) MustAuth() gin.HandlerFunc {
 			return
 		}
 
-		userInfo, err := am.authService.GetUserByToken(token)
-		if err != nil || userInfo == nil || userInfo.Status == entity.UserStatusSuspended || userInfo.Status == entity.UserStatusDeleted {
+		userInfo, apiKey, err := am.authenticate(ctx, token)
+		if err != nil || userInfo == nil {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			return
 		}
+		if apiKey != nil && !apiKeyAllows(apiKey, scopes, ctx.Request.Method) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.ErrorResponse{Error: "API key lacks the required scope"})
+			return
+		}
 
-		ctx.Set("userInfo", userInfo)
+		ctx.Set(UserInfoKey, userInfo)
+		if apiKey != nil {
+			ctx.Set(APIKeyInfoKey, apiKey)
+		}
 		ctx.Next()
 	}
 }
 
+// authenticate resolves the user of a JWT or an API key; the key is nil for JWTs.
+// Suspended and deleted users resolve to no user, so their tokens and keys stop working at once.
+func (am *AuthUserMiddleware) authenticate(ctx *gin.Context, token string) (*entity.User, *entity.APIKey, error) {
+	var userInfo *entity.User
+	var apiKey *entity.APIKey
+	var err error
+	if strings.HasPrefix(token, entity.APIKeyPrefix) {
+		userInfo, apiKey, err = am.apiKeyService.Authenticate(token, ctx.ClientIP())
+	} else {
+		userInfo, err = am.authService.GetUserByToken(token)
+	}
+	if err != nil || userInfo == nil {
+		return nil, nil, err
+	}
+	if userInfo.Status == entity.UserStatusSuspended || userInfo.Status == entity.UserStatusDeleted {
+		return nil, nil, nil
+	}
+	return userInfo, apiKey, nil
+}
+
+// apiKeyAllows reports whether the key holds one of the scopes for a request with the given method.
+// Read scopes only allow GET, HEAD and OPTIONS requests.
+func apiKeyAllows(apiKey *entity.APIKey, scopes []string, method string) bool {
+	for _, scope := range scopes {
+		if !apiKey.HasScope(scope) {
+			continue
+		}
+		if !strings.HasSuffix(scope, ":read") {
+			return true
+		}
+		switch method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return true
+		}
+	}
+	return false
+}
+
 // extractToken extracts the token from the Authorization header or query parameter
 func extractToken(ctx *gin.Context) string {
 	token := ctx.GetHeader("Authorization")
@@ -75,3 +135,23 @@ func extractToken(ctx *gin.Context) string {
 	}
 	return strings.TrimPrefix(token, "Bearer ")
 }
+
+// CurrentUser returns the authenticated user stored by Auth or MustAuth
+func CurrentUser(ctx *gin.Context) *entity.User {
+	value, exists := ctx.Get(UserInfoKey)
+	if !exists {
+		return nil
+	}
+	user, _ := value.(*entity.User)
+	return user
+}
+
+// CurrentAPIKey returns the API key that authenticated the request, or nil if it was a JWT or anonymous
+func CurrentAPIKey(ctx *gin.Context) *entity.APIKey {
+	value, exists := ctx.Get(APIKeyInfoKey)
+	if !exists {
+		return nil
+	}
+	apiKey, _ := value.(*entity.APIKey)
+	return apiKey
+}
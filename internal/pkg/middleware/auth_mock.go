@@ -38,7 +38,7 @@ func (m *MockAuthMiddleware) MustAuthAuthenticated() gin.HandlerFunc {
 			CreatedAt:    time.Now(),
 			UpdatedAt:    time.Now(),
 		}
-		c.Set("userInfo", userInfo)
+		c.Set(UserInfoKey, userInfo)
 		c.Next()
 	}
 }
@@ -69,7 +69,7 @@ func (m *MockAuthMiddleware) AuthAuthenticated() gin.HandlerFunc {
 			CreatedAt:    time.Now(),
 			UpdatedAt:    time.Now(),
 		}
-		c.Set("userInfo", userInfo)
+		c.Set(UserInfoKey, userInfo)
 		c.Next()
 	}
 }
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/repo"
+	"mlvt/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupAuthRouter(t *testing.T) (*gin.Engine, string) {
+	gin.SetMode(gin.TestMode)
+	user := &entity.User{ID: 1, Status: entity.UserStatusAvailable}
+
+	userRepo := new(repo.MockUserRepository)
+	userRepo.On("GetUserByID", uint64(1)).Return(user, nil)
+	authService := service.NewAuthService(userRepo, new(repo.MockRefreshTokenRepository), new(repo.MockLoginThrottleRepository),
+		new(repo.MockTwoFactorRepository), nil, "secret", service.AuthConfig{})
+	jwt, err := authService.GenerateToken(user)
+	assert.NoError(t, err)
+
+	apiKeyService := new(service.MockAPIKeyService)
+	apiKeyService.On("Authenticate", "mlvt_videos", mock.Anything).Return(user,
+		&entity.APIKey{ID: 1, UserID: 1, Scopes: []string{entity.APIKeyScopeVideosRead}}, nil)
+	apiKeyService.On("Authenticate", "mlvt_transcriptions", mock.Anything).Return(user,
+		&entity.APIKey{ID: 2, UserID: 1, Scopes: []string{entity.APIKeyScopeTranscriptionsWrite}}, nil)
+	apiKeyService.On("Authenticate", "mlvt_revoked", mock.Anything).Return(nil, nil, service.ErrInvalidAPIKey)
+	apiKeyService.On("Authenticate", "mlvt_suspended", mock.Anything).Return(&entity.User{ID: 2, Status: entity.UserStatusSuspended},
+		&entity.APIKey{ID: 3, UserID: 2, Scopes: []string{entity.APIKeyScopeVideosRead}}, nil)
+
+	am := NewAuthUserMiddleware(authService, apiKeyService)
+	router := gin.New()
+	router.GET("/users/1", am.MustAuth(), ok)
+	router.GET("/videos", am.MustAuth(entity.APIKeyScopeVideosRead), ok)
+	router.POST("/videos", am.MustAuth(entity.APIKeyScopeVideosRead), ok)
+	router.POST("/transcriptions", am.MustAuth(entity.APIKeyScopeTranscriptionsWrite), func(c *gin.Context) {
+		if key := CurrentAPIKey(c); key != nil {
+			c.String(http.StatusOK, key.Scopes[0])
+			return
+		}
+		c.String(http.StatusOK, "jwt")
+	})
+	router.GET("/search", am.Auth(entity.APIKeyScopeVideosRead), func(c *gin.Context) {
+		if user := CurrentUser(c); user != nil {
+			c.String(http.StatusOK, "user")
+			return
+		}
+		c.String(http.StatusOK, "anonymous")
+	})
+	return router, jwt
+}
+
+func performAuthRequest(router *gin.Engine, method, path, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMustAuth(t *testing.T) {
+	router, jwt := setupAuthRouter(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		status int
+	}{
+		{"NoToken", "GET", "/videos", "", http.StatusUnauthorized},
+		{"JWT", "GET", "/users/1", jwt, http.StatusOK},
+		{"JWTWrite", "POST", "/videos", jwt, http.StatusOK},
+		{"APIKeyRead", "GET", "/videos", "mlvt_videos", http.StatusOK},
+		{"APIKeyReadScopeWrite", "POST", "/videos", "mlvt_videos", http.StatusForbidden},
+		{"APIKeyWrongScope", "POST", "/transcriptions", "mlvt_videos", http.StatusForbidden},
+		{"APIKeyWriteScope", "POST", "/transcriptions", "mlvt_transcriptions", http.StatusOK},
+		{"APIKeyUnscopedRoute", "GET", "/users/1", "mlvt_videos", http.StatusForbidden},
+		{"RevokedAPIKey", "GET", "/videos", "mlvt_revoked", http.StatusUnauthorized},
+		{"SuspendedUser", "GET", "/videos", "mlvt_suspended", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := performAuthRequest(router, tt.method, tt.path, tt.token)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+
+	w := performAuthRequest(router, "POST", "/transcriptions", "mlvt_transcriptions")
+	assert.Equal(t, entity.APIKeyScopeTranscriptionsWrite, w.Body.String())
+	w = performAuthRequest(router, "POST", "/transcriptions", jwt)
+	assert.Equal(t, "jwt", w.Body.String())
+}
+
+func TestAuth(t *testing.T) {
+	router, jwt := setupAuthRouter(t)
+
+	tests := []struct {
+		name  string
+		token string
+		body  string
+	}{
+		{"NoToken", "", "anonymous"},
+		{"JWT", jwt, "user"},
+		{"APIKey", "mlvt_videos", "user"},
+		{"RevokedAPIKey", "mlvt_revoked", "anonymous"},
+		// Suspended users are treated as anonymous, as MustAuth rejects them
+		{"SuspendedUser", "mlvt_suspended", "anonymous"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := performAuthRequest(router, "GET", "/search", tt.token)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.body, w.Body.String())
+		})
+	}
+}
+
+func TestAPIKeyAllows(t *testing.T) {
+	key := &entity.APIKey{Scopes: []string{entity.APIKeyScopeVideosRead, entity.APIKeyScopePayments}, ExpiresAt: time.Now()}
+	assert.True(t, apiKeyAllows(key, []string{entity.APIKeyScopeVideosRead}, http.MethodGet))
+	assert.False(t, apiKeyAllows(key, []string{entity.APIKeyScopeVideosRead}, http.MethodDelete))
+	assert.True(t, apiKeyAllows(key, []string{entity.APIKeyScopePayments}, http.MethodPost))
+	assert.False(t, apiKeyAllows(key, []string{entity.APIKeyScopeTranscriptionsWrite}, http.MethodGet))
+	assert.False(t, apiKeyAllows(key, nil, http.MethodGet))
+}
@@ -0,0 +1,211 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/zap-logging/log"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/repo"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IsAdmin reports whether the user holds the admin role
+func IsAdmin(user *entity.User) bool {
+	return user != nil && user.Role == entity.RoleAdmin
+}
+
+// CanAccess reports whether the user may act on a resource owned by ownerID
+func CanAccess(user *entity.User, ownerID uint64) bool {
+	if user == nil {
+		return false
+	}
+	return IsAdmin(user) || user.ID == ownerID
+}
+
+// OwnershipMiddleware enforces owner-or-admin access on user-owned resources
+type OwnershipMiddleware struct {
+	videoRepo         repo.VideoRepository
+	audioRepo         repo.AudioRepository
+	transcriptionRepo repo.TranscriptionRepository
+	translationRepo   repo.TranslationRepository
+	uploadRepo        repo.VideoUploadRepository
+}
+
+// NewOwnershipMiddleware creates a new OwnershipMiddleware
+func NewOwnershipMiddleware(videoRepo repo.VideoRepository, audioRepo repo.AudioRepository, transcriptionRepo repo.TranscriptionRepository, translationRepo repo.TranslationRepository, uploadRepo repo.VideoUploadRepository) *OwnershipMiddleware {
+	return &OwnershipMiddleware{
+		videoRepo:         videoRepo,
+		audioRepo:         audioRepo,
+		transcriptionRepo: transcriptionRepo,
+		translationRepo:   translationRepo,
+		uploadRepo:        uploadRepo,
+	}
+}
+
+// OwnsUser allows the request only if the path parameter names the authenticated user
+func (om *OwnershipMiddleware) OwnsUser(param string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, ok := parseIDParam(ctx, param, "invalid user ID")
+		if !ok {
+			return
+		}
+		authorize(ctx, userID)
+	}
+}
+
+// OwnsVideo allows the request only if the authenticated user owns the video in the path
+func (om *OwnershipMiddleware) OwnsVideo(param string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		videoID, ok := parseIDParam(ctx, param, "invalid video ID")
+		if !ok {
+			return
+		}
+
+		video, err := om.videoRepo.GetVideoByID(videoID)
+		if err != nil {
+			log.Errorf("Error loading video %d for authorization: %v", videoID, err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+			return
+		}
+		if video == nil {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.ErrorResponse{Error: "video not found"})
+			return
+		}
+		authorize(ctx, video.UserID)
+	}
+}
+
+// OwnsAudio allows the request only if the authenticated user owns the audio in the path
+func (om *OwnershipMiddleware) OwnsAudio(param string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		audioID, ok := parseIDParam(ctx, param, "invalid audio ID")
+		if !ok {
+			return
+		}
+
+		audio, err := om.audioRepo.GetAudioByID(audioID)
+		if err != nil {
+			log.Errorf("Error loading audio %d for authorization: %v", audioID, err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+			return
+		}
+		if audio == nil {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.ErrorResponse{Error: "audio not found"})
+			return
+		}
+		authorize(ctx, audio.UserID)
+	}
+}
+
+// OwnsTranscription allows the request only if the authenticated user owns the transcription in the path
+func (om *OwnershipMiddleware) OwnsTranscription(param string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		transcriptionID, ok := parseIDParam(ctx, param, "invalid transcription ID")
+		if !ok {
+			return
+		}
+
+		transcription, err := om.transcriptionRepo.GetTranscriptionByID(transcriptionID)
+		if err != nil {
+			log.Errorf("Error loading transcription %d for authorization: %v", transcriptionID, err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+			return
+		}
+		if transcription == nil {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.ErrorResponse{Error: "transcription not found"})
+			return
+		}
+		authorize(ctx, transcription.UserID)
+	}
+}
+
+// OwnsTranslation allows the request only if the authenticated user owns the translation in the path
+func (om *OwnershipMiddleware) OwnsTranslation(param string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		translationID, ok := parseIDParam(ctx, param, "invalid translation ID")
+		if !ok {
+			return
+		}
+
+		translation, err := om.translationRepo.GetTranslationByID(translationID)
+		if err != nil {
+			log.Errorf("Error loading translation %d for authorization: %v", translationID, err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+			return
+		}
+		if translation == nil {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.ErrorResponse{Error: "translation not found"})
+			return
+		}
+		authorize(ctx, translation.UserID)
+	}
+}
+
+// OwnsUpload allows the request only if the authenticated user started the video upload in the path
+func (om *OwnershipMiddleware) OwnsUpload(param string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		uploadID, ok := parseIDParam(ctx, param, "invalid upload ID")
+		if !ok {
+			return
+		}
+
+		upload, err := om.uploadRepo.GetUploadByID(uploadID)
+		if err != nil {
+			log.Errorf("Error loading upload %d for authorization: %v", uploadID, err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.ErrorResponse{Error: "internal server error"})
+			return
+		}
+		if upload == nil {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.ErrorResponse{Error: "upload not found"})
+			return
+		}
+		authorize(ctx, upload.UserID)
+	}
+}
+
+// OwnsPayload allows the request only if the user_id in the JSON body is the authenticated user.
+// The body is restored so the handler can bind it again.
+func (om *OwnershipMiddleware) OwnsPayload() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid input"})
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		var payload struct {
+			UserID uint64 `json:"user_id"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid input"})
+			return
+		}
+		authorize(ctx, payload.UserID)
+	}
+}
+
+// authorize continues the chain if the current user may access resources of ownerID, otherwise aborts with 403
+func authorize(ctx *gin.Context, ownerID uint64) {
+	if !CanAccess(CurrentUser(ctx), ownerID) {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, response.ErrorResponse{Error: "Forbidden"})
+		return
+	}
+	ctx.Next()
+}
+
+// parseIDParam parses a numeric path parameter, aborting with 400 when it is malformed
+func parseIDParam(ctx *gin.Context, param, message string) (uint64, bool) {
+	id, err := strconv.ParseUint(ctx.Param(param), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.ErrorResponse{Error: message})
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/pkg/response"
+	"mlvt/internal/repo"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// withUser injects the given user the way MustAuth does
+func withUser(user *entity.User) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(UserInfoKey, user)
+		c.Next()
+	}
+}
+
+func ok(c *gin.Context) {
+	c.JSON(http.StatusOK, response.MessageResponse{Message: "ok"})
+}
+
+func setupOwnershipRouter(user *entity.User, videoRepo repo.VideoRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	om := NewOwnershipMiddleware(videoRepo, new(repo.MockAudioRepository), new(repo.MockTranscriptionRepository), new(repo.MockTranslationRepository), new(repo.MockVideoUploadRepository))
+
+	router := gin.New()
+	router.Use(withUser(user))
+	router.GET("/users/:user_id", om.OwnsUser("user_id"), ok)
+	router.GET("/videos/:video_id", om.OwnsVideo("video_id"), ok)
+	router.POST("/videos", om.OwnsPayload(), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusCreated, string(body))
+	})
+	return router
+}
+
+func performRequest(router *gin.Engine, method, path string, body []byte) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestOwnsUser(t *testing.T) {
+	owner := &entity.User{ID: 1, Role: entity.RoleUser}
+	admin := &entity.User{ID: 2, Role: entity.RoleAdmin}
+
+	t.Run("Owner", func(t *testing.T) {
+		w := performRequest(setupOwnershipRouter(owner, nil), "GET", "/users/1", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Other User", func(t *testing.T) {
+		w := performRequest(setupOwnershipRouter(owner, nil), "GET", "/users/3", nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		var resp response.ErrorResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "Forbidden", resp.Error)
+	})
+
+	t.Run("Admin", func(t *testing.T) {
+		w := performRequest(setupOwnershipRouter(admin, nil), "GET", "/users/3", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		w := performRequest(setupOwnershipRouter(nil, nil), "GET", "/users/1", nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Invalid User ID", func(t *testing.T) {
+		w := performRequest(setupOwnershipRouter(owner, nil), "GET", "/users/abc", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestOwnsVideo(t *testing.T) {
+	owner := &entity.User{ID: 1, Role: entity.RoleUser}
+	videoRepo := new(repo.MockVideoRepository)
+	videoRepo.On("GetVideoByID", uint64(10)).Return(&entity.Video{ID: 10, UserID: 1}, nil)
+	videoRepo.On("GetVideoByID", uint64(11)).Return(&entity.Video{ID: 11, UserID: 2}, nil)
+	videoRepo.On("GetVideoByID", uint64(12)).Return((*entity.Video)(nil), nil)
+
+	router := setupOwnershipRouter(owner, videoRepo)
+
+	t.Run("Owner", func(t *testing.T) {
+		w := performRequest(router, "GET", "/videos/10", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Other Owner", func(t *testing.T) {
+		w := performRequest(router, "GET", "/videos/11", nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		w := performRequest(router, "GET", "/videos/12", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	videoRepo.AssertExpectations(t)
+}
+
+func TestOwnsPayload(t *testing.T) {
+	owner := &entity.User{ID: 1, Role: entity.RoleUser}
+	router := setupOwnershipRouter(owner, nil)
+
+	t.Run("Own Payload Is Passed Through", func(t *testing.T) {
+		body := []byte(`{"user_id":1,"title":"My Video"}`)
+		w := performRequest(router, "POST", "/videos", body)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, string(body), w.Body.String())
+	})
+
+	t.Run("Foreign Payload", func(t *testing.T) {
+		w := performRequest(router, "POST", "/videos", []byte(`{"user_id":2}`))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Invalid JSON", func(t *testing.T) {
+		w := performRequest(router, "POST", "/videos", []byte(`{"user_id":`))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
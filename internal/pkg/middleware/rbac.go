@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net/http"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Permission names an action that may be granted to a role
+type Permission string
+
+// Permission constants
+const (
+	PermissionViewUsers     Permission = "users:view"
+	PermissionManageUsers   Permission = "users:manage"
+	PermissionManageRoles   Permission = "roles:manage"
+	PermissionViewAuditLogs Permission = "audit_logs:view"
+	PermissionViewPayments  Permission = "payments:view"
+	PermissionRefundPayment Permission = "payments:refund"
+
+	PermissionManageTranslations Permission = "translations:manage"
+)
+
+// rolePermissions maps each role to the permissions it grants
+var rolePermissions = map[string][]Permission{
+	entity.RoleAdmin: {
+		PermissionViewUsers,
+		PermissionManageUsers,
+		PermissionManageRoles,
+		PermissionViewAuditLogs,
+		PermissionViewPayments,
+		PermissionRefundPayment,
+		PermissionManageTranslations,
+	},
+	entity.RoleUser: {},
+}
+
+// HasRole reports whether the user holds any of the given roles
+func HasRole(user *entity.User, roles ...string) bool {
+	if user == nil {
+		return false
+	}
+	for _, role := range roles {
+		if user.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission reports whether the user's role grants the permission
+func HasPermission(user *entity.User, permission Permission) bool {
+	if user == nil {
+		return false
+	}
+	for _, granted := range rolePermissions[user.Role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireRole allows the request only if the authenticated user holds one of the roles.
+// It must run after MustAuth.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !HasRole(CurrentUser(ctx), roles...) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.ErrorResponse{Error: "Forbidden"})
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// RequirePermission allows the request only if the authenticated user's role grants the permission.
+// It must run after MustAuth.
+func RequirePermission(permission Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !HasPermission(CurrentUser(ctx), permission) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.ErrorResponse{Error: "Forbidden"})
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"mlvt/internal/entity"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRBACRouter(user *entity.User) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	if user != nil {
+		router.Use(withUser(user))
+	}
+	router.GET("/admin", RequireRole(entity.RoleAdmin), ok)
+	router.GET("/audit-logs", RequirePermission(PermissionViewAuditLogs), ok)
+	return router
+}
+
+func TestRequireRole(t *testing.T) {
+	t.Run("Admin", func(t *testing.T) {
+		w := performRequest(setupRBACRouter(&entity.User{ID: 1, Role: entity.RoleAdmin}), "GET", "/admin", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("User", func(t *testing.T) {
+		w := performRequest(setupRBACRouter(&entity.User{ID: 1, Role: entity.RoleUser}), "GET", "/admin", nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Anonymous", func(t *testing.T) {
+		w := performRequest(setupRBACRouter(nil), "GET", "/admin", nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestRequirePermission(t *testing.T) {
+	t.Run("Granted", func(t *testing.T) {
+		w := performRequest(setupRBACRouter(&entity.User{ID: 1, Role: entity.RoleAdmin}), "GET", "/audit-logs", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Denied", func(t *testing.T) {
+		w := performRequest(setupRBACRouter(&entity.User{ID: 1, Role: entity.RoleUser}), "GET", "/audit-logs", nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("UnknownRole", func(t *testing.T) {
+		w := performRequest(setupRBACRouter(&entity.User{ID: 1, Role: "Guest"}), "GET", "/audit-logs", nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+
+	"mlvt/internal/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireVerifiedEmail lets users who have not confirmed their email address read but not change data:
+// requests other than GET, HEAD and OPTIONS are rejected until they do. It must run after MustAuth.
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		switch ctx.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			ctx.Next()
+			return
+		}
+
+		user := CurrentUser(ctx)
+		if user == nil || !user.EmailVerified() {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.ErrorResponse{Error: "email address not verified"})
+			return
+		}
+		ctx.Next()
+	}
+}
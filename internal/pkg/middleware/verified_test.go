@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"mlvt/internal/entity"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupVerifiedRouter(user *entity.User) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(withUser(user), RequireVerifiedEmail())
+	router.GET("/videos", ok)
+	router.POST("/videos", ok)
+	return router
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	verifiedAt := time.Now()
+
+	t.Run("Verified", func(t *testing.T) {
+		w := performRequest(setupVerifiedRouter(&entity.User{ID: 1, EmailVerifiedAt: &verifiedAt}), "POST", "/videos", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("UnverifiedRead", func(t *testing.T) {
+		w := performRequest(setupVerifiedRouter(&entity.User{ID: 1}), "GET", "/videos", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("UnverifiedWrite", func(t *testing.T) {
+		w := performRequest(setupVerifiedRouter(&entity.User{ID: 1}), "POST", "/videos", nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -1,6 +1,9 @@
 package response
 
-import "mlvt/internal/entity"
+import (
+	"mlvt/internal/entity"
+	"time"
+)
 
 // ErrorResponse represents an error response
 type ErrorResponse struct {
@@ -12,15 +15,107 @@ type StatusResponse struct {
 	Status entity.VideoStatus `json:"status"`
 }
 
+// VideoStatusHistoryResponse represents the status transitions of a video
+type VideoStatusHistoryResponse struct {
+	History []entity.VideoStatusHistory `json:"history"`
+}
+
 // MessageResponse represents a message response
 type MessageResponse struct {
 	Message string `json:"message"`
 }
 
-// TokenResponse represents the response containing a token
+// TokenResponse represents the response containing an access token and the refresh token that renews it
 type TokenResponse struct {
-	Token  string `json:"token"`
-	UserID uint64 `json:"user_id"`
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	UserID           uint64    `json:"user_id"`
+}
+
+// TwoFactorChallengeResponse is returned by login instead of tokens while a two-factor code still has to be confirmed
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool      `json:"two_factor_required"`
+	ChallengeToken    string    `json:"challenge_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+	UserID            uint64    `json:"user_id"`
+}
+
+// OIDCProvidersResponse lists the identity providers users can sign in with
+type OIDCProvidersResponse struct {
+	Providers []string `json:"providers"`
+}
+
+// OIDCAuthorizationResponse is the identity provider page that starts a sign-in
+type OIDCAuthorizationResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// TwoFactorEnrollmentResponse represents the secret to add to an authenticator app
+type TwoFactorEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+	QRCode     []byte `json:"qr_code"` // PNG of otpauth_uri, base64-encoded
+}
+
+// RecoveryCodesResponse represents newly issued recovery codes; they are shown only once
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// RecoveryCodesRemainingResponse represents the number of unused recovery codes
+type RecoveryCodesRemainingResponse struct {
+	Remaining int `json:"remaining"`
+}
+
+// APIKeyResponse represents an API key; Key is only set in the response that creates it, as it cannot be shown again
+type APIKeyResponse struct {
+	APIKey entity.APIKey `json:"api_key"`
+	Key    string        `json:"key,omitempty"`
+}
+
+// APIKeysResponse represents the API keys of a user
+type APIKeysResponse struct {
+	APIKeys []entity.APIKey `json:"api_keys"`
+}
+
+// MoMoPaymentResponse represents a MoMo payment and what happened to it
+type MoMoPaymentResponse struct {
+	Message string             `json:"message"`
+	Payment entity.MoMoPayment `json:"payment"`
+}
+
+// ProductsResponse represents the products users can order
+type ProductsResponse struct {
+	Products []entity.Product `json:"products"`
+}
+
+// OrderResponse represents the response containing a single order
+type OrderResponse struct {
+	Order entity.Order `json:"order"`
+}
+
+// OrdersResponse represents the response containing a page of orders
+type OrdersResponse struct {
+	Orders     []entity.Order `json:"orders"`
+	NextCursor string         `json:"next_cursor,omitempty"` // Absent on the last page
+}
+
+// BalanceResponse represents the translation minutes a user has left
+type BalanceResponse struct {
+	Balance int64 `json:"balance"`
+}
+
+// LedgerEntriesResponse represents the response containing a page of a user's balance history
+type LedgerEntriesResponse struct {
+	Entries    []entity.LedgerEntry `json:"entries"`
+	NextCursor string               `json:"next_cursor,omitempty"` // Absent on the last page
+}
+
+// PaymentEventsResponse represents the logged history of an order's payment
+type PaymentEventsResponse struct {
+	Events []entity.TransactionLog `json:"events"`
 }
 
 // AvatarDownloadURLResponse represents the response containing avatar download URL
@@ -53,6 +148,41 @@ type DownloadURLResponse struct {
 	DownloadURL string `json:"download_url"`
 }
 
+// VideosResponse represents the response containing a page of videos and their frames
+type VideosResponse struct {
+	Videos     []entity.Video `json:"videos"`
+	Frames     []entity.Frame `json:"frames"`
+	NextCursor string         `json:"next_cursor,omitempty"` // Absent on the last page
+}
+
+// FramesResponse represents the response containing the keyframes of a video
+type FramesResponse struct {
+	Frames []entity.Frame `json:"frames"`
+}
+
+// FrameResponse represents the response containing a single keyframe
+type FrameResponse struct {
+	Frame entity.Frame `json:"frame"`
+}
+
+// VideoUploadResponse represents a video upload and, when it was just created, the URL to PUT the file to.
+// Multipart uploads have no upload URL; their part URLs are requested separately.
+type VideoUploadResponse struct {
+	Upload    entity.VideoUpload `json:"upload"`
+	UploadURL string             `json:"upload_url,omitempty"`
+	PartCount int                `json:"part_count,omitempty"` // Number of parts of a multipart upload
+}
+
+// UploadPartsResponse represents parts of a multipart video upload
+type UploadPartsResponse struct {
+	Parts []entity.UploadPart `json:"parts"`
+}
+
+// VideoResponse represents the response containing a single video
+type VideoResponse struct {
+	Video entity.Video `json:"video"`
+}
+
 // TranscriptionResponse represents the response containing a transcription and its download URL
 type TranscriptionResponse struct {
 	Transcription entity.Transcription `json:"transcription"`
@@ -62,6 +192,12 @@ type TranscriptionResponse struct {
 // TranscriptionsResponse represents the response containing a list of transcriptions
 type TranscriptionsResponse struct {
 	Transcriptions []entity.Transcription `json:"transcriptions"`
+	NextCursor     string                 `json:"next_cursor,omitempty"` // Absent on the last page
+}
+
+// TranscriptionSegmentsResponse represents the response containing the timed segments of a transcription
+type TranscriptionSegmentsResponse struct {
+	Segments []entity.TranscriptionSegment `json:"segments"`
 }
 
 // AudioResponse represents the response containing an audio and its download URL
@@ -70,7 +206,40 @@ type AudioResponse struct {
 	DownloadURL string       `json:"download_url"`
 }
 
+// ProbedAudioResponse represents the response containing an audio with its measured media info
+type ProbedAudioResponse struct {
+	Audio entity.Audio `json:"audio"`
+}
+
 // AudiosResponse represents the response containing a list of audios
 type AudiosResponse struct {
-	Audios []entity.Audio `json:"audios"`
+	Audios     []entity.Audio `json:"audios"`
+	NextCursor string         `json:"next_cursor,omitempty"` // Absent on the last page
+}
+
+// LockedAccountsResponse represents the response containing the accounts locked after failed sign-ins
+type LockedAccountsResponse struct {
+	Accounts []entity.LoginThrottle `json:"accounts"`
+}
+
+// AuditLogsResponse represents the response containing a list of audit records
+type AuditLogsResponse struct {
+	AuditLogs []entity.AuditLog `json:"audit_logs"`
+}
+
+// TranslationResponse represents the response containing a translation and the download URL of its output video
+type TranslationResponse struct {
+	Translation entity.Translation `json:"translation"`
+	DownloadURL string             `json:"download_url,omitempty"`
+}
+
+// TranslationsResponse represents the response containing a list of translations
+type TranslationsResponse struct {
+	Translations []entity.Translation `json:"translations"`
+	NextCursor   string               `json:"next_cursor,omitempty"` // Absent on the last page
+}
+
+// SearchResponse represents the response containing search results, most relevant first
+type SearchResponse struct {
+	Results []entity.SearchResult `json:"results"`
 }
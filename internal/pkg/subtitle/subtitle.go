@@ -0,0 +1,242 @@
+// Package subtitle reads and writes SubRip (SRT) and WebVTT subtitles as transcription segments.
+package subtitle
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"mlvt/internal/entity"
+)
+
+// Supported subtitle formats
+const (
+	FormatSRT = "srt"
+	FormatVTT = "vtt"
+)
+
+// ErrInvalidSubtitle is returned when a subtitle file cannot be parsed
+var ErrInvalidSubtitle = errors.New("invalid subtitle file")
+
+// ErrUnsupportedFormat is returned for formats other than FormatSRT and FormatVTT
+var ErrUnsupportedFormat = errors.New("unsupported subtitle format")
+
+// timingPattern matches a cue timing line such as "00:01:02,500 --> 00:01:04,000" (SRT)
+// or "01:02.500 --> 01:04.000 align:start" (WebVTT; hours optional, cue settings ignored)
+var timingPattern = regexp.MustCompile(`^((?:\d+:)?\d{1,2}:\d{2}[,.]\d{1,3})\s+-->\s+((?:\d+:)?\d{1,2}:\d{2}[,.]\d{1,3})`)
+
+// voicePattern matches a WebVTT voice span opening a cue, such as "<v Alice>"
+var voicePattern = regexp.MustCompile(`^<v(?:\.[^\s>]+)*\s+([^>]+)>`)
+
+// tagPattern matches the remaining WebVTT cue markup
+var tagPattern = regexp.MustCompile(`</?[a-zA-Z][^>]*>|<\d[^>]*>`)
+
+// Parse reads subtitles in the given format into segments ordered as they appear in the file
+func Parse(format string, r io.Reader) ([]entity.TranscriptionSegment, error) {
+	switch format {
+	case FormatSRT:
+		return ParseSRT(r)
+	case FormatVTT:
+		return ParseVTT(r)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+}
+
+// Write writes segments as subtitles in the given format
+func Write(format string, w io.Writer, segments []entity.TranscriptionSegment) error {
+	switch format {
+	case FormatSRT:
+		return WriteSRT(w, segments)
+	case FormatVTT:
+		return WriteVTT(w, segments)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+}
+
+// ParseSRT reads SubRip subtitles. Cue numbers are ignored; segments keep file order.
+func ParseSRT(r io.Reader) ([]entity.TranscriptionSegment, error) {
+	blocks, err := readBlocks(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []entity.TranscriptionSegment
+	for _, block := range blocks {
+		// The cue number line is optional in practice
+		if len(block) > 1 && !timingPattern.MatchString(block[0]) {
+			block = block[1:]
+		}
+		segment, err := parseCue(block)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+// ParseVTT reads WebVTT subtitles. A leading voice span ("<v Speaker>") sets the segment's speaker;
+// other markup is stripped. NOTE, STYLE and REGION blocks are skipped.
+func ParseVTT(r io.Reader) ([]entity.TranscriptionSegment, error) {
+	blocks, err := readBlocks(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) == 0 || !strings.HasPrefix(blocks[0][0], "WEBVTT") {
+		return nil, fmt.Errorf("%w: missing WEBVTT header", ErrInvalidSubtitle)
+	}
+
+	var segments []entity.TranscriptionSegment
+	for _, block := range blocks[1:] {
+		if first := block[0]; first == "NOTE" || strings.HasPrefix(first, "NOTE ") ||
+			first == "STYLE" || first == "REGION" {
+			continue
+		}
+		// Skip the optional cue identifier
+		if len(block) > 1 && !timingPattern.MatchString(block[0]) {
+			block = block[1:]
+		}
+		segment, err := parseCue(block)
+		if err != nil {
+			return nil, err
+		}
+
+		if match := voicePattern.FindStringSubmatch(segment.Text); match != nil {
+			segment.Speaker = strings.TrimSpace(match[1])
+		}
+		segment.Text = strings.TrimSpace(tagPattern.ReplaceAllString(segment.Text, ""))
+		segment.Text = unescapeVTT(segment.Text)
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+// WriteSRT writes segments as SubRip subtitles. SubRip has no speaker field, so speakers are not written.
+func WriteSRT(w io.Writer, segments []entity.TranscriptionSegment) error {
+	bw := bufio.NewWriter(w)
+	for i, segment := range segments {
+		fmt.Fprintf(bw, "%d\n%s --> %s\n%s\n\n", i+1,
+			formatTimestamp(segment.StartMs, ','), formatTimestamp(segment.EndMs, ','), segment.Text)
+	}
+	return bw.Flush()
+}
+
+// WriteVTT writes segments as WebVTT subtitles, with speakers as voice spans
+func WriteVTT(w io.Writer, segments []entity.TranscriptionSegment) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("WEBVTT\n\n")
+	for _, segment := range segments {
+		text := escapeVTT(segment.Text)
+		if segment.Speaker != "" {
+			text = "<v " + escapeVTT(segment.Speaker) + ">" + text
+		}
+		fmt.Fprintf(bw, "%s --> %s\n%s\n\n",
+			formatTimestamp(segment.StartMs, '.'), formatTimestamp(segment.EndMs, '.'), text)
+	}
+	return bw.Flush()
+}
+
+// readBlocks splits the input into blocks of non-empty, trimmed lines separated by blank lines
+func readBlocks(r io.Reader) ([][]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var blocks [][]string
+	var block []string
+	first := true
+	for scanner.Scan() {
+		line := scanner.Text()
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+			first = false
+		}
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			if len(block) > 0 {
+				blocks = append(blocks, block)
+				block = nil
+			}
+			continue
+		}
+		block = append(block, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(block) > 0 {
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// parseCue parses a timing line followed by the cue text
+func parseCue(block []string) (entity.TranscriptionSegment, error) {
+	match := timingPattern.FindStringSubmatch(block[0])
+	if match == nil {
+		return entity.TranscriptionSegment{}, fmt.Errorf("%w: expected a cue timing line, got %q", ErrInvalidSubtitle, block[0])
+	}
+
+	start, err := parseTimestamp(match[1])
+	if err != nil {
+		return entity.TranscriptionSegment{}, err
+	}
+	end, err := parseTimestamp(match[2])
+	if err != nil {
+		return entity.TranscriptionSegment{}, err
+	}
+	if end < start {
+		return entity.TranscriptionSegment{}, fmt.Errorf("%w: cue %q ends before it starts", ErrInvalidSubtitle, block[0])
+	}
+
+	return entity.TranscriptionSegment{
+		StartMs: start,
+		EndMs:   end,
+		Text:    strings.Join(block[1:], "\n"),
+	}, nil
+}
+
+// parseTimestamp parses "[hh:]mm:ss,mmm" or "[hh:]mm:ss.mmm" into milliseconds
+func parseTimestamp(value string) (int64, error) {
+	value = strings.Replace(value, ",", ".", 1)
+	clock, fraction, _ := strings.Cut(value, ".")
+	parts := strings.Split(clock, ":")
+	if len(parts) == 2 {
+		parts = append([]string{"0"}, parts...)
+	}
+
+	hours, err1 := strconv.ParseInt(parts[0], 10, 64)
+	minutes, err2 := strconv.ParseInt(parts[1], 10, 64)
+	seconds, err3 := strconv.ParseInt(parts[2], 10, 64)
+	// "5" means 500 ms, not 5 ms
+	fraction = (fraction + "00")[:3]
+	millis, err4 := strconv.ParseInt(fraction, 10, 64)
+	if err := errors.Join(err1, err2, err3, err4); err != nil || minutes > 59 || seconds > 59 {
+		return 0, fmt.Errorf("%w: bad timestamp %q", ErrInvalidSubtitle, value)
+	}
+	return ((hours*60+minutes)*60+seconds)*1000 + millis, nil
+}
+
+// formatTimestamp formats milliseconds as "hh:mm:ss" followed by sep and the milliseconds
+func formatTimestamp(ms int64, sep byte) string {
+	hours := ms / 3_600_000
+	minutes := ms / 60_000 % 60
+	seconds := ms / 1000 % 60
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", hours, minutes, seconds, sep, ms%1000)
+}
+
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+var vttUnescaper = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&nbsp;", " ")
+
+func escapeVTT(text string) string {
+	return vttEscaper.Replace(text)
+}
+
+func unescapeVTT(text string) string {
+	return vttUnescaper.Replace(text)
+}
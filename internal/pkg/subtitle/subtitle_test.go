@@ -0,0 +1,82 @@
+package subtitle
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"mlvt/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testSegments = []entity.TranscriptionSegment{
+	{StartMs: 0, EndMs: 2500, Speaker: "Alice", Text: "Hello <there> & welcome"},
+	{StartMs: 3_723_004, EndMs: 3_725_000, Text: "Two\nlines"},
+}
+
+func TestSRTRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WriteSRT(&buf, testSegments))
+	assert.Equal(t, "1\n00:00:00,000 --> 00:00:02,500\nHello <there> & welcome\n\n"+
+		"2\n01:02:03,004 --> 01:02:05,000\nTwo\nlines\n\n", buf.String())
+
+	segments, err := ParseSRT(&buf)
+	assert.NoError(t, err)
+	if assert.Len(t, segments, 2) {
+		assert.Equal(t, int64(2500), segments[0].EndMs)
+		assert.Equal(t, "Hello <there> & welcome", segments[0].Text)
+		assert.Empty(t, segments[0].Speaker) // SubRip has no speakers
+		assert.Equal(t, int64(3_723_004), segments[1].StartMs)
+		assert.Equal(t, "Two\nlines", segments[1].Text)
+	}
+}
+
+func TestVTTRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WriteVTT(&buf, testSegments))
+	assert.True(t, strings.HasPrefix(buf.String(), "WEBVTT\n\n00:00:00.000 --> 00:00:02.500\n<v Alice>Hello &lt;there&gt; &amp; welcome\n"))
+
+	segments, err := ParseVTT(&buf)
+	assert.NoError(t, err)
+	if assert.Len(t, segments, 2) {
+		assert.Equal(t, "Alice", segments[0].Speaker)
+		assert.Equal(t, "Hello <there> & welcome", segments[0].Text)
+		assert.Equal(t, testSegments[1].StartMs, segments[1].StartMs)
+	}
+}
+
+func TestParseVTT(t *testing.T) {
+	input := "\ufeffWEBVTT - sample\r\n\r\n" +
+		"NOTE written by hand\r\n\r\n" +
+		"intro\r\n" +
+		"01:02.5 --> 01:04.000 align:start\r\n" +
+		"<v.loud Bob>Hi <i>there</i>\r\n"
+	segments, err := ParseVTT(strings.NewReader(input))
+	assert.NoError(t, err)
+	if assert.Len(t, segments, 1) {
+		assert.Equal(t, int64(62_500), segments[0].StartMs)
+		assert.Equal(t, int64(64_000), segments[0].EndMs)
+		assert.Equal(t, "Bob", segments[0].Speaker)
+		assert.Equal(t, "Hi there", segments[0].Text)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for name, input := range map[string]string{
+		"missing timing":  "1\nHello\n",
+		"bad timestamp":   "1\n00:00:99,000 --> 00:01:00,000\nHello\n",
+		"ends too early":  "1\n00:00:05,000 --> 00:00:01,000\nHello\n",
+		"missing VTT hdr": "00:00.000 --> 00:01.000\nHello\n",
+	} {
+		format := FormatSRT
+		if strings.Contains(name, "VTT") {
+			format = FormatVTT
+		}
+		_, err := Parse(format, strings.NewReader(input))
+		assert.ErrorIs(t, err, ErrInvalidSubtitle, name)
+	}
+
+	_, err := Parse("ass", strings.NewReader(""))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
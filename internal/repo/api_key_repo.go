@@ -0,0 +1,113 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"mlvt/internal/entity"
+	"strings"
+	"time"
+)
+
+// APIKeyRepository stores the hashed API keys users create for their scripts
+type APIKeyRepository interface {
+	CreateAPIKey(key *entity.APIKey) error
+	GetAPIKeyByHash(keyHash string) (*entity.APIKey, error)
+	ListUserAPIKeys(userID uint64) ([]entity.APIKey, error)
+	RevokeAPIKey(userID, keyID uint64) (bool, error)
+	TouchAPIKey(keyID uint64, usedAt time.Time, ip string) error
+}
+
+type apiKeyRepo struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) APIKeyRepository {
+	return &apiKeyRepo{db: db}
+}
+
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, last_used_ip, revoked_at, created_at`
+
+// CreateAPIKey stores a new key and sets its ID. Scopes are stored space-separated.
+func (r *apiKeyRepo) CreateAPIKey(key *entity.APIKey) error {
+	now := time.Now()
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	result, err := r.db.Exec(query, key.UserID, key.Name, key.Prefix, key.KeyHash, strings.Join(key.Scopes, " "),
+		key.ExpiresAt, now)
+	if err != nil {
+		return fmt.Errorf("failed to create API key: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	key.ID = uint64(id)
+	key.CreatedAt = now
+	return nil
+}
+
+// GetAPIKeyByHash retrieves a key, revoked or not, by the hash of its value
+func (r *apiKeyRepo) GetAPIKeyByHash(keyHash string) (*entity.APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = ?`, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return key, err
+}
+
+// ListUserAPIKeys returns the user's keys that have not been revoked, newest first. Expired keys are included.
+func (r *apiKeyRepo) ListUserAPIKeys(userID uint64) ([]entity.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id = ? AND revoked_at IS NULL ORDER BY id DESC`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %v", err)
+	}
+	defer rows.Close()
+
+	keys := []entity.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey revokes a key of the user. It reports false if the user has no such key or it was already revoked.
+func (r *apiKeyRepo) RevokeAPIKey(userID, keyID uint64) (bool, error) {
+	result, err := r.db.Exec(`UPDATE api_keys SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL`,
+		time.Now(), keyID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke API key: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// TouchAPIKey records when and from where a key was last used
+func (r *apiKeyRepo) TouchAPIKey(keyID uint64, usedAt time.Time, ip string) error {
+	_, err := r.db.Exec(`UPDATE api_keys SET last_used_at = ?, last_used_ip = ? WHERE id = ?`, usedAt, ip, keyID)
+	if err != nil {
+		return fmt.Errorf("failed to update API key: %v", err)
+	}
+	return nil
+}
+
+func scanAPIKey(row rowScanner) (*entity.APIKey, error) {
+	var key entity.APIKey
+	var scopes string
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.KeyHash, &scopes, &key.ExpiresAt,
+		&key.LastUsedAt, &key.LastUsedIP, &key.RevokedAt, &key.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	key.Scopes = strings.Fields(scopes)
+	return &key, nil
+}
@@ -0,0 +1,40 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAPIKeyRepository is a mock implementation of APIKeyRepository
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) CreateAPIKey(key *entity.APIKey) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) GetAPIKeyByHash(keyHash string) (*entity.APIKey, error) {
+	args := m.Called(keyHash)
+	key, _ := args.Get(0).(*entity.APIKey)
+	return key, args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) ListUserAPIKeys(userID uint64) ([]entity.APIKey, error) {
+	args := m.Called(userID)
+	keys, _ := args.Get(0).([]entity.APIKey)
+	return keys, args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) RevokeAPIKey(userID, keyID uint64) (bool, error) {
+	args := m.Called(userID, keyID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) TouchAPIKey(keyID uint64, usedAt time.Time, ip string) error {
+	args := m.Called(keyID, usedAt, ip)
+	return args.Error(0)
+}
@@ -0,0 +1,83 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAndGetAPIKey(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	keyRepo := NewAPIKeyRepository(db)
+	key := &entity.APIKey{
+		UserID:    1,
+		Name:      "nightly batch",
+		Prefix:    "mlvt_abcd",
+		KeyHash:   "hash",
+		Scopes:    []string{entity.APIKeyScopeVideosRead, entity.APIKeyScopeTranscriptionsWrite},
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	assert.NoError(t, keyRepo.CreateAPIKey(key))
+	assert.NotZero(t, key.ID)
+
+	found, err := keyRepo.GetAPIKeyByHash("hash")
+	assert.NoError(t, err)
+	if assert.NotNil(t, found) {
+		assert.Equal(t, "nightly batch", found.Name)
+		assert.Equal(t, key.Scopes, found.Scopes)
+		assert.Nil(t, found.LastUsedAt)
+	}
+
+	usedAt := time.Now()
+	assert.NoError(t, keyRepo.TouchAPIKey(key.ID, usedAt, "10.0.0.1"))
+	found, _ = keyRepo.GetAPIKeyByHash("hash")
+	if assert.NotNil(t, found.LastUsedAt) {
+		assert.WithinDuration(t, usedAt, *found.LastUsedAt, time.Second)
+	}
+	assert.Equal(t, "10.0.0.1", found.LastUsedIP)
+
+	missing, err := keyRepo.GetAPIKeyByHash("other")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestListAndRevokeAPIKeys(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	keyRepo := NewAPIKeyRepository(db)
+	expiresAt := time.Now().Add(time.Hour)
+	for _, key := range []*entity.APIKey{
+		{UserID: 1, Name: "a", Prefix: "a", KeyHash: "a", Scopes: []string{entity.APIKeyScopePayments}, ExpiresAt: expiresAt},
+		{UserID: 1, Name: "b", Prefix: "b", KeyHash: "b", Scopes: []string{entity.APIKeyScopePayments}, ExpiresAt: expiresAt},
+		{UserID: 2, Name: "c", Prefix: "c", KeyHash: "c", Scopes: []string{entity.APIKeyScopePayments}, ExpiresAt: expiresAt},
+	} {
+		assert.NoError(t, keyRepo.CreateAPIKey(key))
+	}
+
+	keys, err := keyRepo.ListUserAPIKeys(1)
+	assert.NoError(t, err)
+	if assert.Len(t, keys, 2) {
+		assert.Equal(t, "b", keys[0].Name)
+	}
+
+	// Users can only revoke their own keys, once
+	revoked, err := keyRepo.RevokeAPIKey(2, keys[0].ID)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	revoked, err = keyRepo.RevokeAPIKey(1, keys[0].ID)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = keyRepo.RevokeAPIKey(1, keys[0].ID)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	keys, err = keyRepo.ListUserAPIKeys(1)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	found, _ := keyRepo.GetAPIKeyByHash("b")
+	assert.NotNil(t, found.RevokedAt)
+}
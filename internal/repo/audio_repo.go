@@ -2,6 +2,7 @@ package repo
 
 import (
 	"database/sql"
+	"fmt"
 	"mlvt/internal/entity"
 	"time"
 )
@@ -10,9 +11,10 @@ type AudioRepository interface {
 	CreateAudio(audio *entity.Audio) error
 	GetAudioByID(audioID uint64) (*entity.Audio, error)
 	GetAudioByIDAndUserID(audioID, userID uint64) (*entity.Audio, error)
-	ListAudiosByUserID(userID uint64) ([]entity.Audio, error)
+	ListAudiosByUserID(userID uint64, opts entity.ListOptions) ([]entity.Audio, string, error)
 	GetAudioByVideoID(videoID, audioID uint64) (*entity.Audio, error)
-	ListAudiosByVideoID(videoID uint64) ([]entity.Audio, error)
+	ListAudiosByVideoID(videoID uint64, opts entity.ListOptions) ([]entity.Audio, string, error)
+	UpdateAudioMediaInfo(audioID uint64, duration int, info entity.MediaInfo) error
 	DeleteAudioByID(audioID uint64) error
 }
 
@@ -24,30 +26,35 @@ func NewAudioRepository(db *sql.DB) AudioRepository {
 	return &audioRepo{db: db}
 }
 
-// CreateAudio inserts a new audio record into the database
+const audioColumns = `id, video_id, user_id, duration, lang, folder, file_name, created_at, updated_at, ` + mediaInfoColumns
+
+// CreateAudio inserts a new audio record into the database and sets its ID
 func (r *audioRepo) CreateAudio(audio *entity.Audio) error {
 	query := `
 		INSERT INTO audios (video_id, user_id, duration, lang, folder, file_name, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
 
-	now := time.Now()
-	_, err := r.db.Exec(query,
+	now := time.Now().UTC()
+	result, err := r.db.Exec(query,
 		audio.VideoID, audio.UserID, audio.Duration, audio.Lang, audio.Folder, audio.FileName, now, now)
+	if err != nil {
+		return err
+	}
 
-	return err
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	audio.ID = uint64(id)
+	audio.CreatedAt = now
+	audio.UpdatedAt = now
+	return nil
 }
 
 // GetAudioByID fetches an audio by its ID and user ID
 func (r *audioRepo) GetAudioByID(audioID uint64) (*entity.Audio, error) {
-	query := `SELECT id, video_id, user_id, duration, lang, folder, file_name, created_at, updated_at
-	          FROM audios WHERE id = ?`
-
-	row := r.db.QueryRow(query, audioID)
-
-	audio := &entity.Audio{}
-	err := row.Scan(&audio.ID, &audio.VideoID, &audio.UserID, &audio.Duration, &audio.Lang, &audio.Folder,
-		&audio.FileName, &audio.CreatedAt, &audio.UpdatedAt)
-
+	query := `SELECT ` + audioColumns + ` FROM audios WHERE id = ?`
+	audio, err := scanAudio(r.db.QueryRow(query, audioID))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -56,88 +63,107 @@ func (r *audioRepo) GetAudioByID(audioID uint64) (*entity.Audio, error) {
 
 // GetAudioByIDAndUserID retrieves a single audio by its ID and User ID (owner)
 func (r *audioRepo) GetAudioByIDAndUserID(audioID, userID uint64) (*entity.Audio, error) {
-	query := `
-		SELECT id, video_id, user_id, duration, lang, folder, file_name, created_at, updated_at
-		FROM audios WHERE id = ? AND user_id = ?`
-
-	row := r.db.QueryRow(query, audioID, userID)
-
-	audio := &entity.Audio{}
-	err := row.Scan(&audio.ID, &audio.VideoID, &audio.UserID, &audio.Duration, &audio.Lang, &audio.Folder, &audio.FileName, &audio.CreatedAt, &audio.UpdatedAt)
+	query := `SELECT ` + audioColumns + ` FROM audios WHERE id = ? AND user_id = ?`
+	audio, err := scanAudio(r.db.QueryRow(query, audioID, userID))
 	if err == sql.ErrNoRows {
 		return nil, nil // No record found
 	}
 	return audio, err
 }
 
-// ListAudiosByUserID returns all audios associated with a given user ID
-func (r *audioRepo) ListAudiosByUserID(userID uint64) ([]entity.Audio, error) {
-	query := `SELECT id, video_id, user_id, duration, lang, folder, file_name, created_at, updated_at
-	          FROM audios WHERE user_id = ?`
-
-	rows, err := r.db.Query(query, userID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var audios []entity.Audio
-	for rows.Next() {
-		var audio entity.Audio
-		if err := rows.Scan(&audio.ID, &audio.VideoID, &audio.UserID, &audio.Duration, &audio.Lang, &audio.Folder,
-			&audio.FileName, &audio.CreatedAt, &audio.UpdatedAt); err != nil {
-			return nil, err
-		}
-		audios = append(audios, audio)
-	}
+// audioListSpec lists the sort fields and filters supported by audio lists
+var audioListSpec = listSpec{
+	sortable: map[string]sortColumn{
+		entity.SortByCreatedAt: {column: "created_at", kind: sortTime},
+		entity.SortByDuration:  {column: "duration", kind: sortInt},
+	},
+	langColumn: "lang",
+}
 
-	return audios, nil
+// ListAudiosByUserID returns one page of the audios associated with a given user ID and the cursor of the next page
+func (r *audioRepo) ListAudiosByUserID(userID uint64, opts entity.ListOptions) ([]entity.Audio, string, error) {
+	query := `SELECT ` + audioColumns + ` FROM audios WHERE user_id = ?`
+	return r.listAudios(query, userID, opts)
 }
 
 // GetAudioByVideoID retrieves a specific audio by its video ID and audio ID
 func (r *audioRepo) GetAudioByVideoID(videoID, audioID uint64) (*entity.Audio, error) {
-	query := `SELECT id, video_id, user_id, duration, lang, folder, file_name, created_at, updated_at
-	          FROM audios WHERE video_id = ? AND id = ?`
-
-	row := r.db.QueryRow(query, videoID, audioID)
-
-	audio := &entity.Audio{}
-	err := row.Scan(&audio.ID, &audio.VideoID, &audio.UserID, &audio.Duration, &audio.Lang, &audio.Folder,
-		&audio.FileName, &audio.CreatedAt, &audio.UpdatedAt)
-
+	query := `SELECT ` + audioColumns + ` FROM audios WHERE video_id = ? AND id = ?`
+	audio, err := scanAudio(r.db.QueryRow(query, videoID, audioID))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	return audio, err
 }
 
-// ListAudiosByVideoID returns all audios associated with a given video ID
-func (r *audioRepo) ListAudiosByVideoID(videoID uint64) ([]entity.Audio, error) {
-	query := `SELECT id, video_id, user_id, duration, lang, folder, file_name, created_at, updated_at
-	          FROM audios WHERE video_id = ?`
+// ListAudiosByVideoID returns one page of the audios associated with a given video ID and the cursor of the next page
+func (r *audioRepo) ListAudiosByVideoID(videoID uint64, opts entity.ListOptions) ([]entity.Audio, string, error) {
+	query := `SELECT ` + audioColumns + ` FROM audios WHERE video_id = ?`
+	return r.listAudios(query, videoID, opts)
+}
 
-	rows, err := r.db.Query(query, videoID)
+// UpdateAudioMediaInfo stores the duration and stream details probed from the uploaded audio file
+func (r *audioRepo) UpdateAudioMediaInfo(audioID uint64, duration int, info entity.MediaInfo) error {
+	rowsAffected, err := updateMediaInfo(r.db, "audios", audioID, duration, info)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to update audio media info: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no audio found with id %d", audioID)
+	}
+	return nil
+}
+
+// DeleteAudioByID deletes an audio record by its ID and queues its file for deletion from storage
+func (r *audioRepo) DeleteAudioByID(audioID uint64) error {
+	_, err := cascadeDelete(r.db, fmt.Sprintf("audio %d", audioID), audioID,
+		`SELECT folder, file_name FROM audios WHERE id = ?1`,
+		`UPDATE translations SET audio_id = NULL WHERE audio_id = ?1`,
+		`DELETE FROM audios WHERE id = ?1`)
+	return err
+}
+
+func (r *audioRepo) listAudios(query string, scopeID uint64, opts entity.ListOptions) ([]entity.Audio, string, error) {
+	page, err := buildPageQuery(query, []interface{}{scopeID}, opts, audioListSpec)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows, err := r.db.Query(page.query, page.args...)
+	if err != nil {
+		return nil, "", err
 	}
 	defer rows.Close()
 
 	var audios []entity.Audio
 	for rows.Next() {
-		var audio entity.Audio
-		if err := rows.Scan(&audio.ID, &audio.VideoID, &audio.UserID, &audio.Duration, &audio.Lang, &audio.Folder,
-			&audio.FileName, &audio.CreatedAt, &audio.UpdatedAt); err != nil {
-			return nil, err
+		audio, err := scanAudio(rows)
+		if err != nil {
+			return nil, "", err
 		}
-		audios = append(audios, audio)
+		audios = append(audios, *audio)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
 	}
 
-	return audios, nil
+	audios, nextCursor := trimPage(page, audios, audioSortKey)
+	return audios, nextCursor, nil
 }
 
-// DeleteAudioByID deletes an audio record by its ID
-func (r *audioRepo) DeleteAudioByID(audioID uint64) error {
-	query := "DELETE FROM audios WHERE id = ?"
-	_, err := r.db.Exec(query, audioID)
-	return err
+func audioSortKey(audio entity.Audio, sortBy string) (interface{}, uint64) {
+	if sortBy == entity.SortByDuration {
+		return audio.Duration, audio.ID
+	}
+	return audio.CreatedAt, audio.ID
+}
+
+func scanAudio(row rowScanner) (*entity.Audio, error) {
+	var audio entity.Audio
+	dest := []interface{}{&audio.ID, &audio.VideoID, &audio.UserID, &audio.Duration, &audio.Lang, &audio.Folder,
+		&audio.FileName, &audio.CreatedAt, &audio.UpdatedAt}
+	if err := row.Scan(append(dest, mediaInfoFields(&audio.MediaInfo)...)...); err != nil {
+		return nil, err
+	}
+	return &audio, nil
 }
@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAudioRepository mocks the AudioRepository interface
+type MockAudioRepository struct {
+	mock.Mock
+}
+
+func (m *MockAudioRepository) CreateAudio(audio *entity.Audio) error {
+	args := m.Called(audio)
+	return args.Error(0)
+}
+
+func (m *MockAudioRepository) GetAudioByID(audioID uint64) (*entity.Audio, error) {
+	args := m.Called(audioID)
+	if audio, ok := args.Get(0).(*entity.Audio); ok {
+		return audio, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAudioRepository) GetAudioByIDAndUserID(audioID, userID uint64) (*entity.Audio, error) {
+	args := m.Called(audioID, userID)
+	if audio, ok := args.Get(0).(*entity.Audio); ok {
+		return audio, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAudioRepository) ListAudiosByUserID(userID uint64, opts entity.ListOptions) ([]entity.Audio, string, error) {
+	args := m.Called(userID, opts)
+	if audios, ok := args.Get(0).([]entity.Audio); ok {
+		return audios, args.String(1), args.Error(2)
+	}
+	return nil, args.String(1), args.Error(2)
+}
+
+func (m *MockAudioRepository) GetAudioByVideoID(videoID, audioID uint64) (*entity.Audio, error) {
+	args := m.Called(videoID, audioID)
+	if audio, ok := args.Get(0).(*entity.Audio); ok {
+		return audio, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAudioRepository) ListAudiosByVideoID(videoID uint64, opts entity.ListOptions) ([]entity.Audio, string, error) {
+	args := m.Called(videoID, opts)
+	if audios, ok := args.Get(0).([]entity.Audio); ok {
+		return audios, args.String(1), args.Error(2)
+	}
+	return nil, args.String(1), args.Error(2)
+}
+
+func (m *MockAudioRepository) UpdateAudioMediaInfo(audioID uint64, duration int, info entity.MediaInfo) error {
+	args := m.Called(audioID, duration, info)
+	return args.Error(0)
+}
+
+func (m *MockAudioRepository) DeleteAudioByID(audioID uint64) error {
+	args := m.Called(audioID)
+	return args.Error(0)
+}
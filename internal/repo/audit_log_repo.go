@@ -0,0 +1,81 @@
+package repo
+
+import (
+	"database/sql"
+	"mlvt/internal/entity"
+	"time"
+)
+
+type AuditLogRepository interface {
+	CreateAuditLog(auditLog *entity.AuditLog) error
+	ListAuditLogs(limit int) ([]entity.AuditLog, error)
+	ListAuditLogsByTarget(targetType string, targetID uint64) ([]entity.AuditLog, error)
+}
+
+type auditLogRepo struct {
+	db *sql.DB
+}
+
+func NewAuditLogRepository(db *sql.DB) AuditLogRepository {
+	return &auditLogRepo{db: db}
+}
+
+// CreateAuditLog inserts a new audit record into the database
+func (r *auditLogRepo) CreateAuditLog(auditLog *entity.AuditLog) error {
+	return insertAuditLog(r.db, auditLog)
+}
+
+// insertAuditLog writes an audit record, inside a transaction when db is one
+func insertAuditLog(db execer, auditLog *entity.AuditLog) error {
+	query := `
+		INSERT INTO audit_logs (actor_id, action, target_type, target_id, details, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`
+	if auditLog.CreatedAt.IsZero() {
+		auditLog.CreatedAt = time.Now()
+	}
+	result, err := db.Exec(query, auditLog.ActorID, auditLog.Action, auditLog.TargetType, auditLog.TargetID,
+		auditLog.Details, auditLog.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	auditLog.ID = uint64(id)
+	return nil
+}
+
+// ListAuditLogs returns the most recent audit records, newest first
+func (r *auditLogRepo) ListAuditLogs(limit int) ([]entity.AuditLog, error) {
+	query := `SELECT id, actor_id, action, target_type, target_id, details, created_at
+	          FROM audit_logs ORDER BY id DESC LIMIT ?`
+	return r.queryAuditLogs(query, limit)
+}
+
+// ListAuditLogsByTarget returns every audit record for a single resource, newest first
+func (r *auditLogRepo) ListAuditLogsByTarget(targetType string, targetID uint64) ([]entity.AuditLog, error) {
+	query := `SELECT id, actor_id, action, target_type, target_id, details, created_at
+	          FROM audit_logs WHERE target_type = ? AND target_id = ? ORDER BY id DESC`
+	return r.queryAuditLogs(query, targetType, targetID)
+}
+
+func (r *auditLogRepo) queryAuditLogs(query string, args ...interface{}) ([]entity.AuditLog, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var auditLogs []entity.AuditLog
+	for rows.Next() {
+		var auditLog entity.AuditLog
+		if err := rows.Scan(&auditLog.ID, &auditLog.ActorID, &auditLog.Action, &auditLog.TargetType, &auditLog.TargetID,
+			&auditLog.Details, &auditLog.CreatedAt); err != nil {
+			return nil, err
+		}
+		auditLogs = append(auditLogs, auditLog)
+	}
+	return auditLogs, rows.Err()
+}
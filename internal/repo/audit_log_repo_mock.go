@@ -0,0 +1,33 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAuditLogRepository mocks the AuditLogRepository interface
+type MockAuditLogRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditLogRepository) CreateAuditLog(auditLog *entity.AuditLog) error {
+	args := m.Called(auditLog)
+	return args.Error(0)
+}
+
+func (m *MockAuditLogRepository) ListAuditLogs(limit int) ([]entity.AuditLog, error) {
+	args := m.Called(limit)
+	if auditLogs, ok := args.Get(0).([]entity.AuditLog); ok {
+		return auditLogs, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAuditLogRepository) ListAuditLogsByTarget(targetType string, targetID uint64) ([]entity.AuditLog, error) {
+	args := m.Called(targetType, targetID)
+	if auditLogs, ok := args.Get(0).([]entity.AuditLog); ok {
+		return auditLogs, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
@@ -0,0 +1,408 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"mlvt/internal/entity"
+	"time"
+)
+
+// BillingRepository stores orders and the double-entry ledger of translation minutes. Orders and
+// ledger entries live together because paying an order and crediting its minutes must happen at once.
+type BillingRepository interface {
+	CreateOrder(order *entity.Order) error
+	GetOrderByOrderID(orderID string) (*entity.Order, error)
+	ListOrdersByUserID(userID uint64, opts entity.ListOptions) ([]entity.Order, string, error)
+	FulfillOrder(orderID string, paidAt time.Time, entries []entity.LedgerEntry) (bool, error)
+	UpdateOrderStatus(orderID, from, to string) (bool, error)
+	PostTransaction(entries []entity.LedgerEntry) (bool, error)
+	ChargeCredits(userID uint64, entries []entity.LedgerEntry) (bool, error)
+	GetTransaction(transactionID string) ([]entity.LedgerEntry, error)
+	GetBalance(userID uint64) (int64, error)
+	GetRefundedCredits(orderID string) (int64, error)
+	ListLedgerEntries(userID uint64, opts entity.ListOptions) ([]entity.LedgerEntry, string, error)
+}
+
+type billingRepo struct {
+	db *sql.DB
+}
+
+func NewBillingRepository(db *sql.DB) BillingRepository {
+	return &billingRepo{db: db}
+}
+
+const orderColumns = `id, order_id, user_id, product_id, amount, credits, premium_days, status, paid_at, created_at, updated_at`
+
+const ledgerEntryColumns = `id, transaction_id, account, user_id, amount, kind, order_id, translation_id, description, created_at`
+
+// CreateOrder inserts a new order
+func (r *billingRepo) CreateOrder(order *entity.Order) error {
+	query := `
+		INSERT INTO orders (order_id, user_id, product_id, amount, credits, premium_days, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	now := time.Now().UTC()
+	result, err := r.db.Exec(query, order.OrderID, order.UserID, order.ProductID, order.Amount, order.Credits,
+		order.PremiumDays, order.Status, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to create order: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	order.ID = uint64(id)
+	order.CreatedAt = now
+	order.UpdatedAt = now
+	return nil
+}
+
+// GetOrderByOrderID fetches an order by its order ID, or returns nil if there is none
+func (r *billingRepo) GetOrderByOrderID(orderID string) (*entity.Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE order_id = ?`
+	order, err := scanOrder(r.db.QueryRow(query, orderID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %v", err)
+	}
+	return order, nil
+}
+
+// orderListSpec lists the sort fields and filters supported by order lists
+var orderListSpec = listSpec{
+	sortable: map[string]sortColumn{
+		entity.SortByCreatedAt: {column: "created_at", kind: sortTime},
+	},
+	statusColumn: "status",
+}
+
+// ListOrdersByUserID lists one page of a user's orders and returns the cursor of the next page
+func (r *billingRepo) ListOrdersByUserID(userID uint64, opts entity.ListOptions) ([]entity.Order, string, error) {
+	page, err := buildPageQuery(`SELECT `+orderColumns+` FROM orders WHERE user_id = ?`, []interface{}{userID}, opts, orderListSpec)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows, err := r.db.Query(page.query, page.args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var orders []entity.Order
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		orders = append(orders, *order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	orders, nextCursor := trimPage(page, orders, func(order entity.Order, _ string) (interface{}, uint64) {
+		return order.CreatedAt, order.ID
+	})
+	return orders, nextCursor, nil
+}
+
+// FulfillOrder marks a pending order paid, posts the entries crediting what it bought and extends the
+// user's premium by the order's premium days, all in one transaction. It reports false, changing
+// nothing, if the order is not pending, so an order is delivered once however often it is paid.
+func (r *billingRepo) FulfillOrder(orderID string, paidAt time.Time, entries []entity.LedgerEntry) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	query := `UPDATE orders SET status = ?, paid_at = ?, updated_at = ? WHERE order_id = ? AND status = ?`
+	result, err := tx.Exec(query, entity.OrderStatusPaid, paidAt, now, orderID, entity.OrderStatusPending)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark order paid: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	if err := insertLedgerEntries(tx, entries); err != nil {
+		return false, err
+	}
+
+	var userID uint64
+	var premiumDays int
+	err = tx.QueryRow(`SELECT user_id, premium_days FROM orders WHERE order_id = ?`, orderID).Scan(&userID, &premiumDays)
+	if err != nil {
+		return false, fmt.Errorf("failed to get order: %v", err)
+	}
+	if premiumDays > 0 {
+		if err := extendPremium(tx, userID, paidAt, premiumDays); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// extendPremium adds days of premium to the user, from the end of their current subscription or from
+// since if it has ended. Premium without an end date is left alone.
+func extendPremium(tx *sql.Tx, userID uint64, since time.Time, days int) error {
+	var premium bool
+	var until *time.Time
+	err := tx.QueryRow(`SELECT premium, premium_until FROM users WHERE id = ?`, userID).Scan(&premium, &until)
+	if err != nil {
+		return fmt.Errorf("failed to get premium of user %d: %v", userID, err)
+	}
+	if premium && until == nil {
+		return nil
+	}
+
+	start := since
+	if premium && until.After(since) {
+		start = *until
+	}
+	query := `UPDATE users SET premium = 1, premium_until = ?, updated_at = ? WHERE id = ?`
+	if _, err := tx.Exec(query, start.AddDate(0, 0, days), time.Now(), userID); err != nil {
+		return fmt.Errorf("failed to extend premium of user %d: %v", userID, err)
+	}
+	return nil
+}
+
+// UpdateOrderStatus moves an order from one status to another; it reports false if the order is not in from
+func (r *billingRepo) UpdateOrderStatus(orderID, from, to string) (bool, error) {
+	query := `UPDATE orders SET status = ?, updated_at = ? WHERE order_id = ? AND status = ?`
+	result, err := r.db.Exec(query, to, time.Now(), orderID, from)
+	if err != nil {
+		return false, fmt.Errorf("failed to update order status: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// PostTransaction records the balanced entries of a ledger transaction. It reports false, posting
+// nothing, if a transaction with the same ID was posted before, so retries post it once.
+func (r *billingRepo) PostTransaction(entries []entity.LedgerEntry) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	for _, entry := range entries {
+		var posted int
+		err := tx.QueryRow(`SELECT COUNT(*) FROM ledger_entries WHERE transaction_id = ?`, entry.TransactionID).Scan(&posted)
+		if err != nil {
+			return false, fmt.Errorf("failed to look up ledger transaction: %v", err)
+		}
+		if posted > 0 {
+			return false, nil
+		}
+	}
+
+	if err := insertLedgerEntries(tx, entries); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ChargeCredits records balanced entries that take minutes from the user's credits account. It
+// reports false, posting nothing, if that would leave the user's balance below zero.
+func (r *billingRepo) ChargeCredits(userID uint64, entries []entity.LedgerEntry) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Reading the balance and writing in one transaction keeps concurrent charges from overdrawing it
+	balance, err := queryBalance(tx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range entries {
+		if entry.Account == entity.LedgerAccountCredits && entry.UserID == userID {
+			balance += entry.Amount
+		}
+	}
+	if balance < 0 {
+		return false, nil
+	}
+
+	if err := insertLedgerEntries(tx, entries); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetTransaction returns the entries of a ledger transaction, or none if it was never posted
+func (r *billingRepo) GetTransaction(transactionID string) ([]entity.LedgerEntry, error) {
+	query := `SELECT ` + ledgerEntryColumns + ` FROM ledger_entries WHERE transaction_id = ? ORDER BY id`
+	rows, err := r.db.Query(query, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger transaction: %v", err)
+	}
+	defer rows.Close()
+
+	var entries []entity.LedgerEntry
+	for rows.Next() {
+		entry, err := scanLedgerEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, rows.Err()
+}
+
+// GetBalance returns the minutes in the user's credits account
+func (r *billingRepo) GetBalance(userID uint64) (int64, error) {
+	return queryBalance(r.db, userID)
+}
+
+// GetRefundedCredits returns how many of the order's minutes its refunds have taken back so far
+func (r *billingRepo) GetRefundedCredits(orderID string) (int64, error) {
+	var refunded int64
+	query := `SELECT COALESCE(-SUM(amount), 0) FROM ledger_entries WHERE order_id = ? AND account = ? AND kind = ?`
+	if err := r.db.QueryRow(query, orderID, entity.LedgerAccountCredits, entity.LedgerKindPaymentRefund).Scan(&refunded); err != nil {
+		return 0, fmt.Errorf("failed to get refunded credits: %v", err)
+	}
+	return refunded, nil
+}
+
+// ledgerEntryListSpec lists the sort fields supported by ledger entry lists
+var ledgerEntryListSpec = listSpec{
+	sortable: map[string]sortColumn{
+		entity.SortByCreatedAt: {column: "created_at", kind: sortTime},
+	},
+}
+
+// ListLedgerEntries lists one page of the entries of the user's credits account and returns the cursor of the next page
+func (r *billingRepo) ListLedgerEntries(userID uint64, opts entity.ListOptions) ([]entity.LedgerEntry, string, error) {
+	query := `SELECT ` + ledgerEntryColumns + ` FROM ledger_entries WHERE user_id = ? AND account = ?`
+	page, err := buildPageQuery(query, []interface{}{userID, entity.LedgerAccountCredits}, opts, ledgerEntryListSpec)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows, err := r.db.Query(page.query, page.args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var entries []entity.LedgerEntry
+	for rows.Next() {
+		entry, err := scanLedgerEntry(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		entries = append(entries, *entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	entries, nextCursor := trimPage(page, entries, func(entry entity.LedgerEntry, _ string) (interface{}, uint64) {
+		return entry.CreatedAt, entry.ID
+	})
+	return entries, nextCursor, nil
+}
+
+// rowQueryer is satisfied by both *sql.DB and *sql.Tx
+type rowQueryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func queryBalance(q rowQueryer, userID uint64) (int64, error) {
+	var balance int64
+	query := `SELECT COALESCE(SUM(amount), 0) FROM ledger_entries WHERE user_id = ? AND account = ?`
+	if err := q.QueryRow(query, userID, entity.LedgerAccountCredits).Scan(&balance); err != nil {
+		return 0, fmt.Errorf("failed to get balance: %v", err)
+	}
+	return balance, nil
+}
+
+// insertLedgerEntries writes the entries of one or more transactions, refusing any transaction
+// whose entries do not add up to zero
+func insertLedgerEntries(tx *sql.Tx, entries []entity.LedgerEntry) error {
+	sums := make(map[string]int64)
+	for _, entry := range entries {
+		sums[entry.TransactionID] += entry.Amount
+	}
+	for transactionID, sum := range sums {
+		if sum != 0 {
+			return fmt.Errorf("ledger transaction %s is unbalanced by %d", transactionID, sum)
+		}
+	}
+
+	query := `
+		INSERT INTO ledger_entries (transaction_id, account, user_id, amount, kind, order_id, translation_id, description, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	now := time.Now().UTC()
+	for i := range entries {
+		entry := &entries[i]
+		var orderID *string
+		if entry.OrderID != "" {
+			orderID = &entry.OrderID
+		}
+		result, err := tx.Exec(query, entry.TransactionID, entry.Account, entry.UserID, entry.Amount, entry.Kind,
+			orderID, entry.TranslationID, entry.Description, now)
+		if err != nil {
+			return fmt.Errorf("failed to post ledger entry: %v", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		entry.ID = uint64(id)
+		entry.CreatedAt = now
+	}
+	return nil
+}
+
+func scanOrder(row rowScanner) (*entity.Order, error) {
+	order := &entity.Order{}
+	err := row.Scan(&order.ID, &order.OrderID, &order.UserID, &order.ProductID, &order.Amount, &order.Credits,
+		&order.PremiumDays, &order.Status, &order.PaidAt, &order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func scanLedgerEntry(row rowScanner) (*entity.LedgerEntry, error) {
+	entry := &entity.LedgerEntry{}
+	var orderID sql.NullString
+	var translationID sql.NullInt64
+	err := row.Scan(&entry.ID, &entry.TransactionID, &entry.Account, &entry.UserID, &entry.Amount, &entry.Kind,
+		&orderID, &translationID, &entry.Description, &entry.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	entry.OrderID = orderID.String
+	if translationID.Valid {
+		id := uint64(translationID.Int64)
+		entry.TranslationID = &id
+	}
+	return entry, nil
+}
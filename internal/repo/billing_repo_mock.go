@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockBillingRepository is a mock implementation of BillingRepository
+type MockBillingRepository struct {
+	mock.Mock
+}
+
+func (m *MockBillingRepository) CreateOrder(order *entity.Order) error {
+	args := m.Called(order)
+	return args.Error(0)
+}
+
+func (m *MockBillingRepository) GetOrderByOrderID(orderID string) (*entity.Order, error) {
+	args := m.Called(orderID)
+	order, _ := args.Get(0).(*entity.Order)
+	return order, args.Error(1)
+}
+
+func (m *MockBillingRepository) ListOrdersByUserID(userID uint64, opts entity.ListOptions) ([]entity.Order, string, error) {
+	args := m.Called(userID, opts)
+	orders, _ := args.Get(0).([]entity.Order)
+	return orders, args.String(1), args.Error(2)
+}
+
+func (m *MockBillingRepository) FulfillOrder(orderID string, paidAt time.Time, entries []entity.LedgerEntry) (bool, error) {
+	args := m.Called(orderID, paidAt, entries)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockBillingRepository) UpdateOrderStatus(orderID, from, to string) (bool, error) {
+	args := m.Called(orderID, from, to)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockBillingRepository) PostTransaction(entries []entity.LedgerEntry) (bool, error) {
+	args := m.Called(entries)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockBillingRepository) ChargeCredits(userID uint64, entries []entity.LedgerEntry) (bool, error) {
+	args := m.Called(userID, entries)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockBillingRepository) GetTransaction(transactionID string) ([]entity.LedgerEntry, error) {
+	args := m.Called(transactionID)
+	entries, _ := args.Get(0).([]entity.LedgerEntry)
+	return entries, args.Error(1)
+}
+
+func (m *MockBillingRepository) GetBalance(userID uint64) (int64, error) {
+	args := m.Called(userID)
+	balance, _ := args.Get(0).(int64)
+	return balance, args.Error(1)
+}
+
+func (m *MockBillingRepository) GetRefundedCredits(orderID string) (int64, error) {
+	args := m.Called(orderID)
+	refunded, _ := args.Get(0).(int64)
+	return refunded, args.Error(1)
+}
+
+func (m *MockBillingRepository) ListLedgerEntries(userID uint64, opts entity.ListOptions) ([]entity.LedgerEntry, string, error) {
+	args := m.Called(userID, opts)
+	entries, _ := args.Get(0).([]entity.LedgerEntry)
+	return entries, args.String(1), args.Error(2)
+}
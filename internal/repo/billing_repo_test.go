@@ -0,0 +1,139 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+// transfer returns the two entries of a transaction moving amount minutes into the user's credits from account
+func transfer(transactionID string, userID uint64, account string, amount int64) []entity.LedgerEntry {
+	return []entity.LedgerEntry{
+		{TransactionID: transactionID, Account: entity.LedgerAccountCredits, UserID: userID, Amount: amount, Kind: entity.LedgerKindPurchase},
+		{TransactionID: transactionID, Account: account, UserID: userID, Amount: -amount, Kind: entity.LedgerKindPurchase},
+	}
+}
+
+func TestFulfillOrderOnce(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	insertTestUser(t, db, "john")
+
+	billingRepo := NewBillingRepository(db)
+	order := &entity.Order{OrderID: "MLVT1", UserID: 1, ProductID: "premium", Amount: 250000, Credits: 300, PremiumDays: 30, Status: entity.OrderStatusPending}
+	assert.NoError(t, billingRepo.CreateOrder(order))
+	assert.NotZero(t, order.ID)
+
+	paidAt := time.Now()
+	fulfilled, err := billingRepo.FulfillOrder("MLVT1", paidAt, transfer("order:MLVT1", 1, entity.LedgerAccountSales, 300))
+	assert.NoError(t, err)
+	assert.True(t, fulfilled)
+
+	// Paying the order again delivers nothing more
+	fulfilled, err = billingRepo.FulfillOrder("MLVT1", paidAt, transfer("order:MLVT1-again", 1, entity.LedgerAccountSales, 300))
+	assert.NoError(t, err)
+	assert.False(t, fulfilled)
+
+	balance, err := billingRepo.GetBalance(1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(300), balance)
+
+	stored, err := billingRepo.GetOrderByOrderID("MLVT1")
+	assert.NoError(t, err)
+	assert.Equal(t, entity.OrderStatusPaid, stored.Status)
+	assert.NotNil(t, stored.PaidAt)
+
+	var premium bool
+	var until time.Time
+	assert.NoError(t, db.QueryRow(`SELECT premium, premium_until FROM users WHERE id = 1`).Scan(&premium, &until))
+	assert.True(t, premium)
+	assert.WithinDuration(t, paidAt.AddDate(0, 0, 30), until, time.Second)
+
+	// A second subscription starts when the first ends
+	assert.NoError(t, billingRepo.CreateOrder(&entity.Order{OrderID: "MLVT2", UserID: 1, ProductID: "premium", PremiumDays: 30, Status: entity.OrderStatusPending}))
+	fulfilled, err = billingRepo.FulfillOrder("MLVT2", paidAt, nil)
+	assert.NoError(t, err)
+	assert.True(t, fulfilled)
+	assert.NoError(t, db.QueryRow(`SELECT premium_until FROM users WHERE id = 1`).Scan(&until))
+	assert.WithinDuration(t, paidAt.AddDate(0, 0, 60), until, time.Second)
+
+	orders, nextCursor, err := billingRepo.ListOrdersByUserID(1, entity.ListOptions{Status: entity.OrderStatusPaid, Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, orders, 1)
+	assert.NotEmpty(t, nextCursor)
+}
+
+func TestPostLedgerTransactions(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	billingRepo := NewBillingRepository(db)
+	posted, err := billingRepo.PostTransaction(transfer("grant:1", 1, entity.LedgerAccountSales, 10))
+	assert.NoError(t, err)
+	assert.True(t, posted)
+
+	// Retries are posted once
+	posted, err = billingRepo.PostTransaction(transfer("grant:1", 1, entity.LedgerAccountSales, 10))
+	assert.NoError(t, err)
+	assert.False(t, posted)
+
+	// Unbalanced transactions are refused
+	unbalanced := transfer("grant:2", 1, entity.LedgerAccountSales, 10)
+	unbalanced[1].Amount = -5
+	_, err = billingRepo.PostTransaction(unbalanced)
+	assert.Error(t, err)
+
+	// Charges may not overdraw the balance
+	charged, err := billingRepo.ChargeCredits(1, transfer("charge:1", 1, entity.LedgerAccountUsage, -15))
+	assert.NoError(t, err)
+	assert.False(t, charged)
+	charged, err = billingRepo.ChargeCredits(1, transfer("charge:2", 1, entity.LedgerAccountUsage, -10))
+	assert.NoError(t, err)
+	assert.True(t, charged)
+
+	balance, err := billingRepo.GetBalance(1)
+	assert.NoError(t, err)
+	assert.Zero(t, balance)
+
+	entries, err := billingRepo.GetTransaction("charge:2")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	// The history only shows the user's credits account
+	history, nextCursor, err := billingRepo.ListLedgerEntries(1, entity.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, nextCursor)
+	if assert.Len(t, history, 2) {
+		assert.Equal(t, int64(-10), history[0].Amount)
+		assert.Equal(t, entity.LedgerAccountCredits, history[1].Account)
+	}
+}
+
+func TestGetRefundedCredits(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	billingRepo := NewBillingRepository(db)
+	refund := func(transactionID string, minutes int64) []entity.LedgerEntry {
+		entries := transfer(transactionID, 1, entity.LedgerAccountRefunds, -minutes)
+		for i := range entries {
+			entries[i].Kind = entity.LedgerKindPaymentRefund
+			entries[i].OrderID = "MLVT1"
+		}
+		return entries
+	}
+	_, err := billingRepo.PostTransaction(transfer("order:MLVT1", 1, entity.LedgerAccountSales, 300))
+	assert.NoError(t, err)
+
+	refunded, err := billingRepo.GetRefundedCredits("MLVT1")
+	assert.NoError(t, err)
+	assert.Zero(t, refunded)
+
+	for _, entries := range [][]entity.LedgerEntry{refund("refund:MLVT1-R1", 75), refund("refund:MLVT1-R2", 25)} {
+		_, err := billingRepo.PostTransaction(entries)
+		assert.NoError(t, err)
+	}
+	refunded, err = billingRepo.GetRefundedCredits("MLVT1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), refunded)
+}
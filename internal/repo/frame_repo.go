@@ -0,0 +1,177 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"mlvt/internal/entity"
+	"strings"
+	"time"
+)
+
+// FrameRepository stores the keyframes of videos and which one is the primary thumbnail
+type FrameRepository interface {
+	CreateFrames(frames []*entity.Frame) error
+	GetFrameByID(frameID uint64) (*entity.Frame, error)
+	ListFramesByVideoID(videoID uint64) ([]entity.Frame, error)
+	ListThumbnails(videoIDs []uint64) ([]entity.Frame, error)
+	SetPrimaryFrame(videoID, frameID uint64) error
+	DeleteFrame(frameID uint64) error
+}
+
+type frameRepo struct {
+	db *sql.DB
+}
+
+func NewFrameRepository(db *sql.DB) FrameRepository {
+	return &frameRepo{db: db}
+}
+
+const frameColumns = `id, video_id, folder, file_name, timestamp_ms, width, height, is_primary, created_at`
+
+// CreateFrames inserts several frames in one transaction and sets their IDs.
+// A new primary frame replaces the current primary frame of its video.
+func (r *frameRepo) CreateFrames(frames []*entity.Frame) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// link predates the folder and file name columns and still holds the object key
+	query := `
+		INSERT INTO frames (video_id, link, folder, file_name, timestamp_ms, width, height, is_primary, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	now := time.Now()
+	for _, frame := range frames {
+		if frame.IsPrimary {
+			if _, err := tx.Exec(`UPDATE frames SET is_primary = FALSE WHERE video_id = ? AND is_primary`, frame.VideoID); err != nil {
+				return fmt.Errorf("failed to clear primary frame: %v", err)
+			}
+		}
+
+		link := frame.FileName
+		if folder := strings.Trim(frame.Folder, "/"); folder != "" {
+			link = folder + "/" + frame.FileName
+		}
+		result, err := tx.Exec(query, frame.VideoID, link, frame.Folder, frame.FileName, frame.TimestampMs,
+			frame.Width, frame.Height, frame.IsPrimary, now)
+		if err != nil {
+			return fmt.Errorf("failed to create frame: %v", err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		frame.ID = uint64(id)
+		frame.CreatedAt = now
+	}
+
+	return tx.Commit()
+}
+
+// GetFrameByID retrieves a frame by its ID
+func (r *frameRepo) GetFrameByID(frameID uint64) (*entity.Frame, error) {
+	row := r.db.QueryRow(`SELECT `+frameColumns+` FROM frames WHERE id = ?`, frameID)
+	frame, err := scanFrame(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return frame, err
+}
+
+// ListFramesByVideoID lists the frames of a video in playback order
+func (r *frameRepo) ListFramesByVideoID(videoID uint64) ([]entity.Frame, error) {
+	rows, err := r.db.Query(`SELECT `+frameColumns+` FROM frames WHERE video_id = ? ORDER BY timestamp_ms, id`, videoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list frames: %v", err)
+	}
+	return scanFrames(rows)
+}
+
+// ListThumbnails returns one frame for each of the videos that has frames: its primary frame,
+// or its earliest frame when none was selected
+func (r *frameRepo) ListThumbnails(videoIDs []uint64) ([]entity.Frame, error) {
+	if len(videoIDs) == 0 {
+		return nil, nil
+	}
+
+	args := make([]interface{}, len(videoIDs))
+	for i, videoID := range videoIDs {
+		args[i] = videoID
+	}
+	query := `SELECT ` + frameColumns + ` FROM frames f
+		WHERE f.video_id IN (` + strings.TrimSuffix(strings.Repeat("?, ", len(videoIDs)), ", ") + `)
+		AND f.id = (SELECT id FROM frames WHERE video_id = f.video_id ORDER BY is_primary DESC, timestamp_ms, id LIMIT 1)
+		ORDER BY f.video_id`
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list thumbnails: %v", err)
+	}
+	return scanFrames(rows)
+}
+
+// SetPrimaryFrame makes a frame the primary thumbnail of its video, replacing the current one
+func (r *frameRepo) SetPrimaryFrame(videoID, frameID uint64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE frames SET is_primary = FALSE WHERE video_id = ? AND is_primary`, videoID); err != nil {
+		return fmt.Errorf("failed to clear primary frame: %v", err)
+	}
+	result, err := tx.Exec(`UPDATE frames SET is_primary = TRUE WHERE id = ? AND video_id = ?`, frameID, videoID)
+	if err != nil {
+		return fmt.Errorf("failed to set primary frame: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no frame found with id %d", frameID)
+	}
+
+	return tx.Commit()
+}
+
+// DeleteFrame deletes a frame by its ID and queues its image for deletion from storage
+func (r *frameRepo) DeleteFrame(frameID uint64) error {
+	rowsAffected, err := cascadeDelete(r.db, fmt.Sprintf("frame %d", frameID), frameID,
+		`SELECT folder, file_name FROM frames WHERE id = ?1 AND file_name != ''`,
+		`DELETE FROM frames WHERE id = ?1`)
+	if err != nil {
+		return fmt.Errorf("failed to delete frame: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no frame found with id %d", frameID)
+	}
+	return nil
+}
+
+func scanFrames(rows *sql.Rows) ([]entity.Frame, error) {
+	defer rows.Close()
+
+	var frames []entity.Frame
+	for rows.Next() {
+		frame, err := scanFrame(rows)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, *frame)
+	}
+	return frames, rows.Err()
+}
+
+func scanFrame(row rowScanner) (*entity.Frame, error) {
+	var frame entity.Frame
+	err := row.Scan(&frame.ID, &frame.VideoID, &frame.Folder, &frame.FileName, &frame.TimestampMs,
+		&frame.Width, &frame.Height, &frame.IsPrimary, &frame.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &frame, nil
+}
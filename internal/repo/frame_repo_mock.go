@@ -0,0 +1,45 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockFrameRepository mocks the FrameRepository interface
+type MockFrameRepository struct {
+	mock.Mock
+}
+
+func (m *MockFrameRepository) CreateFrames(frames []*entity.Frame) error {
+	args := m.Called(frames)
+	return args.Error(0)
+}
+
+func (m *MockFrameRepository) GetFrameByID(frameID uint64) (*entity.Frame, error) {
+	args := m.Called(frameID)
+	frame, _ := args.Get(0).(*entity.Frame)
+	return frame, args.Error(1)
+}
+
+func (m *MockFrameRepository) ListFramesByVideoID(videoID uint64) ([]entity.Frame, error) {
+	args := m.Called(videoID)
+	frames, _ := args.Get(0).([]entity.Frame)
+	return frames, args.Error(1)
+}
+
+func (m *MockFrameRepository) ListThumbnails(videoIDs []uint64) ([]entity.Frame, error) {
+	args := m.Called(videoIDs)
+	frames, _ := args.Get(0).([]entity.Frame)
+	return frames, args.Error(1)
+}
+
+func (m *MockFrameRepository) SetPrimaryFrame(videoID, frameID uint64) error {
+	args := m.Called(videoID, frameID)
+	return args.Error(0)
+}
+
+func (m *MockFrameRepository) DeleteFrame(frameID uint64) error {
+	args := m.Called(frameID)
+	return args.Error(0)
+}
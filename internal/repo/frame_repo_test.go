@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrameRepository(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	frameRepo := NewFrameRepository(db)
+
+	frames := []*entity.Frame{
+		{VideoID: 1, Folder: "frames", FileName: "a-2.jpg", TimestampMs: 2000, Width: 1280, Height: 720},
+		{VideoID: 1, Folder: "frames", FileName: "a-1.jpg", TimestampMs: 1000, Width: 1280, Height: 720},
+		{VideoID: 2, Folder: "frames", FileName: "b-1.jpg", TimestampMs: 500, Width: 640, Height: 360, IsPrimary: true},
+		{VideoID: 2, Folder: "frames", FileName: "b-2.jpg", TimestampMs: 900, Width: 640, Height: 360},
+	}
+	assert.NoError(t, frameRepo.CreateFrames(frames))
+	assert.Equal(t, uint64(1), frames[0].ID)
+	assert.False(t, frames[0].CreatedAt.IsZero())
+
+	listed, err := frameRepo.ListFramesByVideoID(1)
+	assert.NoError(t, err)
+	if assert.Len(t, listed, 2) {
+		assert.Equal(t, "a-1.jpg", listed[0].FileName)
+		assert.Equal(t, int64(2000), listed[1].TimestampMs)
+		assert.Equal(t, 1280, listed[1].Width)
+	}
+
+	// Without a primary frame the earliest frame is the thumbnail
+	thumbnails, err := frameRepo.ListThumbnails([]uint64{1, 2, 3})
+	assert.NoError(t, err)
+	if assert.Len(t, thumbnails, 2) {
+		assert.Equal(t, "a-1.jpg", thumbnails[0].FileName)
+		assert.Equal(t, "b-1.jpg", thumbnails[1].FileName)
+	}
+
+	// Selecting another primary frame replaces the current one
+	assert.NoError(t, frameRepo.SetPrimaryFrame(2, 4))
+	thumbnails, err = frameRepo.ListThumbnails([]uint64{2})
+	assert.NoError(t, err)
+	if assert.Len(t, thumbnails, 1) {
+		assert.Equal(t, uint64(4), thumbnails[0].ID)
+	}
+	previous, err := frameRepo.GetFrameByID(3)
+	assert.NoError(t, err)
+	assert.False(t, previous.IsPrimary)
+
+	// A frame of another video cannot become the primary frame
+	assert.Error(t, frameRepo.SetPrimaryFrame(2, 1))
+
+	// A new primary frame replaces the current one too
+	assert.NoError(t, frameRepo.CreateFrames([]*entity.Frame{{VideoID: 2, Folder: "frames", FileName: "b-3.jpg", IsPrimary: true}}))
+	frame, err := frameRepo.GetFrameByID(4)
+	assert.NoError(t, err)
+	assert.False(t, frame.IsPrimary)
+
+	missing, err := frameRepo.GetFrameByID(99)
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+
+	none, err := frameRepo.ListThumbnails(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, none)
+}
+
+func TestDeleteFrame(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	frameRepo := NewFrameRepository(db)
+
+	assert.NoError(t, frameRepo.CreateFrames([]*entity.Frame{{VideoID: 1, Folder: "frames", FileName: "a-1.jpg"}}))
+	assert.NoError(t, frameRepo.DeleteFrame(1))
+
+	frame, err := frameRepo.GetFrameByID(1)
+	assert.NoError(t, err)
+	assert.Nil(t, frame)
+
+	queued, err := NewStorageObjectRepository(db).ListQueuedObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.StoredObject{{Folder: "frames", FileName: "a-1.jpg"}}, queued)
+
+	assert.Error(t, frameRepo.DeleteFrame(1))
+}
@@ -0,0 +1,215 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"mlvt/internal/entity"
+	"time"
+)
+
+type JobRepository interface {
+	CreateJob(job *entity.Job) error
+	GetJobByID(jobID uint64) (*entity.Job, error)
+	ListJobsByVideoID(videoID uint64) ([]entity.Job, error)
+	EnqueueRawVideos(maxAttempts, limit int) (int64, error)
+	ClaimNextJob(now time.Time) (*entity.Job, error)
+	UpdateJobStage(jobID uint64, stage string) error
+	CompleteJob(jobID uint64) error
+	RetryJob(jobID uint64, runAt time.Time, lastError string) error
+	FailJob(jobID uint64, lastError string) error
+	RequeueStaleJobs(lockedBefore time.Time) (int64, error)
+}
+
+type jobRepo struct {
+	db *sql.DB
+}
+
+func NewJobRepository(db *sql.DB) JobRepository {
+	return &jobRepo{db: db}
+}
+
+// Timestamps are stored in UTC so that SQLite can compare them as strings.
+const jobColumns = `id, video_id, stage, status, attempts, max_attempts, last_error, run_at, locked_at, created_at, updated_at`
+
+// CreateJob inserts a new queued job for a video
+func (r *jobRepo) CreateJob(job *entity.Job) error {
+	query := `
+		INSERT INTO jobs (video_id, stage, status, attempts, max_attempts, last_error, run_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	now := time.Now().UTC()
+	if job.Status == "" {
+		job.Status = entity.JobStatusQueued
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = now
+	}
+	result, err := r.db.Exec(query, job.VideoID, job.Stage, job.Status, job.Attempts, job.MaxAttempts, job.LastError,
+		job.RunAt.UTC(), now, now)
+	if err != nil {
+		return fmt.Errorf("failed to create job: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	job.ID = uint64(id)
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	return nil
+}
+
+// GetJobByID fetches a job by its ID
+func (r *jobRepo) GetJobByID(jobID uint64) (*entity.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE id = ?`
+	job, err := scanJob(r.db.QueryRow(query, jobID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return job, err
+}
+
+// ListJobsByVideoID lists every job that was created for a video, newest first
+func (r *jobRepo) ListJobsByVideoID(videoID uint64) ([]entity.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE video_id = ? ORDER BY id DESC`
+	rows, err := r.db.Query(query, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []entity.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, rows.Err()
+}
+
+// EnqueueRawVideos creates a queued job for up to limit raw videos that have no active job
+func (r *jobRepo) EnqueueRawVideos(maxAttempts, limit int) (int64, error) {
+	query := `
+		INSERT INTO jobs (video_id, stage, status, attempts, max_attempts, last_error, run_at, created_at, updated_at)
+		SELECT v.id, '', ?, 0, ?, '', ?, ?, ?
+		FROM videos v
+		WHERE v.status = ?
+		  AND NOT EXISTS (
+		      SELECT 1 FROM jobs j WHERE j.video_id = v.id AND j.status IN (?, ?)
+		  )
+		ORDER BY v.id
+		LIMIT ?`
+
+	now := time.Now().UTC()
+	result, err := r.db.Exec(query, entity.JobStatusQueued, maxAttempts, now, now, now,
+		entity.StatusRaw, entity.JobStatusQueued, entity.JobStatusRunning, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue raw videos: %v", err)
+	}
+	return result.RowsAffected()
+}
+
+// ClaimNextJob atomically marks the oldest due queued job as running and returns it.
+// It returns nil when no job is due.
+func (r *jobRepo) ClaimNextJob(now time.Time) (*entity.Job, error) {
+	query := `
+		UPDATE jobs
+		SET status = ?, attempts = attempts + 1, locked_at = ?, updated_at = ?
+		WHERE id = (
+		    SELECT id FROM jobs
+		    WHERE status = ? AND run_at <= ?
+		    ORDER BY run_at, id
+		    LIMIT 1
+		)
+		RETURNING id`
+
+	now = now.UTC()
+	var jobID uint64
+	err := r.db.QueryRow(query, entity.JobStatusRunning, now, now, entity.JobStatusQueued, now).Scan(&jobID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %v", err)
+	}
+	return r.GetJobByID(jobID)
+}
+
+// UpdateJobStage records the stage a running job has reached
+func (r *jobRepo) UpdateJobStage(jobID uint64, stage string) error {
+	query := `UPDATE jobs SET stage = ?, updated_at = ? WHERE id = ?`
+	return r.exec(jobID, query, stage, time.Now().UTC(), jobID)
+}
+
+// CompleteJob marks a job as succeeded
+func (r *jobRepo) CompleteJob(jobID uint64) error {
+	query := `UPDATE jobs SET status = ?, last_error = '', locked_at = NULL, updated_at = ? WHERE id = ?`
+	return r.exec(jobID, query, entity.JobStatusSucceeded, time.Now().UTC(), jobID)
+}
+
+// RetryJob puts a job back in the queue to be claimed again at runAt
+func (r *jobRepo) RetryJob(jobID uint64, runAt time.Time, lastError string) error {
+	query := `UPDATE jobs SET status = ?, run_at = ?, last_error = ?, locked_at = NULL, updated_at = ? WHERE id = ?`
+	return r.exec(jobID, query, entity.JobStatusQueued, runAt.UTC(), lastError, time.Now().UTC(), jobID)
+}
+
+// FailJob marks a job as permanently failed
+func (r *jobRepo) FailJob(jobID uint64, lastError string) error {
+	query := `UPDATE jobs SET status = ?, last_error = ?, locked_at = NULL, updated_at = ? WHERE id = ?`
+	return r.exec(jobID, query, entity.JobStatusFailed, lastError, time.Now().UTC(), jobID)
+}
+
+// RequeueStaleJobs returns running jobs locked before lockedBefore to the queue.
+// This recovers jobs whose worker died without reporting a result.
+func (r *jobRepo) RequeueStaleJobs(lockedBefore time.Time) (int64, error) {
+	query := `
+		UPDATE jobs
+		SET status = ?, locked_at = NULL, run_at = ?, updated_at = ?
+		WHERE status = ? AND locked_at < ?`
+
+	now := time.Now().UTC()
+	result, err := r.db.Exec(query, entity.JobStatusQueued, now, now, entity.JobStatusRunning, lockedBefore.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue stale jobs: %v", err)
+	}
+	return result.RowsAffected()
+}
+
+// exec runs an update against a single job and reports a missing job as an error
+func (r *jobRepo) exec(jobID uint64, query string, args ...interface{}) error {
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update job: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no job found with id %d", jobID)
+	}
+	return nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanJob(row rowScanner) (*entity.Job, error) {
+	job := &entity.Job{}
+	var lockedAt sql.NullTime
+	err := row.Scan(&job.ID, &job.VideoID, &job.Stage, &job.Status, &job.Attempts, &job.MaxAttempts, &job.LastError,
+		&job.RunAt, &lockedAt, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if lockedAt.Valid {
+		job.LockedAt = &lockedAt.Time
+	}
+	return job, nil
+}
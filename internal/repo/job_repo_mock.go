@@ -0,0 +1,66 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockJobRepository mocks the JobRepository interface
+type MockJobRepository struct {
+	mock.Mock
+}
+
+func (m *MockJobRepository) CreateJob(job *entity.Job) error {
+	args := m.Called(job)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) GetJobByID(jobID uint64) (*entity.Job, error) {
+	args := m.Called(jobID)
+	job, _ := args.Get(0).(*entity.Job)
+	return job, args.Error(1)
+}
+
+func (m *MockJobRepository) ListJobsByVideoID(videoID uint64) ([]entity.Job, error) {
+	args := m.Called(videoID)
+	jobs, _ := args.Get(0).([]entity.Job)
+	return jobs, args.Error(1)
+}
+
+func (m *MockJobRepository) EnqueueRawVideos(maxAttempts, limit int) (int64, error) {
+	args := m.Called(maxAttempts, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockJobRepository) ClaimNextJob(now time.Time) (*entity.Job, error) {
+	args := m.Called(now)
+	job, _ := args.Get(0).(*entity.Job)
+	return job, args.Error(1)
+}
+
+func (m *MockJobRepository) UpdateJobStage(jobID uint64, stage string) error {
+	args := m.Called(jobID, stage)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) CompleteJob(jobID uint64) error {
+	args := m.Called(jobID)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) RetryJob(jobID uint64, runAt time.Time, lastError string) error {
+	args := m.Called(jobID, runAt, lastError)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) FailJob(jobID uint64, lastError string) error {
+	args := m.Called(jobID, lastError)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) RequeueStaleJobs(lockedBefore time.Time) (int64, error) {
+	args := m.Called(lockedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
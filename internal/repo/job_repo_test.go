@@ -0,0 +1,122 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnqueueRawVideos(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	videoRepo := NewVideoRepo(db)
+	jobRepo := NewJobRepository(db)
+
+	assert.NoError(t, videoRepo.CreateVideo(&entity.Video{Title: "raw", Status: entity.StatusRaw, UserID: 1}))
+	assert.NoError(t, videoRepo.CreateVideo(&entity.Video{Title: "done", Status: entity.StatusSuccess, UserID: 1}))
+
+	count, err := jobRepo.EnqueueRawVideos(3, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// A video with an active job is not enqueued twice
+	count, err = jobRepo.EnqueueRawVideos(3, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	jobs, err := jobRepo.ListJobsByVideoID(1)
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, entity.JobStatusQueued, jobs[0].Status)
+	assert.Equal(t, 3, jobs[0].MaxAttempts)
+}
+
+func TestClaimNextJob(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	jobRepo := NewJobRepository(db)
+	now := time.Now()
+
+	assert.NoError(t, jobRepo.CreateJob(&entity.Job{VideoID: 1, MaxAttempts: 3, RunAt: now.Add(time.Hour)}))
+	assert.NoError(t, jobRepo.CreateJob(&entity.Job{VideoID: 2, MaxAttempts: 3, RunAt: now.Add(-time.Minute)}))
+
+	job, err := jobRepo.ClaimNextJob(now)
+	assert.NoError(t, err)
+	assert.NotNil(t, job)
+	assert.Equal(t, uint64(2), job.VideoID)
+	assert.Equal(t, entity.JobStatusRunning, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	assert.NotNil(t, job.LockedAt)
+
+	// The remaining job is not due yet
+	job, err = jobRepo.ClaimNextJob(now)
+	assert.NoError(t, err)
+	assert.Nil(t, job)
+}
+
+func TestRetryCompleteAndFailJob(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	jobRepo := NewJobRepository(db)
+
+	job := &entity.Job{VideoID: 1, MaxAttempts: 3}
+	assert.NoError(t, jobRepo.CreateJob(job))
+	now := time.Now()
+
+	claimed, err := jobRepo.ClaimNextJob(now)
+	assert.NoError(t, err)
+	assert.NoError(t, jobRepo.UpdateJobStage(claimed.ID, "transcribe"))
+	assert.NoError(t, jobRepo.RetryJob(claimed.ID, now.Add(time.Minute), "boom"))
+
+	retried, err := jobRepo.GetJobByID(job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.JobStatusQueued, retried.Status)
+	assert.Equal(t, "transcribe", retried.Stage)
+	assert.Equal(t, "boom", retried.LastError)
+	assert.Nil(t, retried.LockedAt)
+
+	claimed, err = jobRepo.ClaimNextJob(now.Add(2 * time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, claimed.Attempts)
+	assert.NoError(t, jobRepo.CompleteJob(claimed.ID))
+
+	completed, err := jobRepo.GetJobByID(job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.JobStatusSucceeded, completed.Status)
+	assert.Empty(t, completed.LastError)
+
+	assert.NoError(t, jobRepo.FailJob(job.ID, "gave up"))
+	failed, err := jobRepo.GetJobByID(job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.JobStatusFailed, failed.Status)
+
+	assert.EqualError(t, jobRepo.CompleteJob(99), "no job found with id 99")
+}
+
+func TestRequeueStaleJobs(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	jobRepo := NewJobRepository(db)
+
+	assert.NoError(t, jobRepo.CreateJob(&entity.Job{VideoID: 1, MaxAttempts: 3}))
+	now := time.Now()
+
+	claimed, err := jobRepo.ClaimNextJob(now)
+	assert.NoError(t, err)
+	assert.NotNil(t, claimed)
+
+	count, err := jobRepo.RequeueStaleJobs(now.Add(-time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	count, err = jobRepo.RequeueStaleJobs(now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	job, err := jobRepo.GetJobByID(claimed.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.JobStatusQueued, job.Status)
+}
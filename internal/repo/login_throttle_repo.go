@@ -0,0 +1,130 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"mlvt/internal/entity"
+	"time"
+)
+
+// LoginThrottleRepository counts failed sign-ins per account and per client IP
+type LoginThrottleRepository interface {
+	GetLoginThrottle(scope, subject string) (*entity.LoginThrottle, error)
+	RecordLoginFailure(scope, subject string, userID *uint64, windowStart time.Time) (int, error)
+	LockLogin(scope, subject string, until time.Time) error
+	ResetLoginThrottle(scope, subject string) error
+	ListLockedLogins(scope string, now time.Time) ([]entity.LoginThrottle, error)
+}
+
+type loginThrottleRepo struct {
+	db *sql.DB
+}
+
+func NewLoginThrottleRepository(db *sql.DB) LoginThrottleRepository {
+	return &loginThrottleRepo{db: db}
+}
+
+const loginThrottleColumns = `scope, subject, user_id, failures, last_failure_at, locked_until`
+
+// GetLoginThrottle returns the failure count of an account or IP, or nil if it has none
+func (r *loginThrottleRepo) GetLoginThrottle(scope, subject string) (*entity.LoginThrottle, error) {
+	query := `SELECT ` + loginThrottleColumns + ` FROM login_throttles WHERE scope = ? AND subject = ?`
+	throttle, err := scanLoginThrottle(r.db.QueryRow(query, scope, subject))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return throttle, err
+}
+
+// RecordLoginFailure counts a failed sign-in and returns the number of failures so far.
+// Counting restarts when the previous failure happened before windowStart.
+func (r *loginThrottleRepo) RecordLoginFailure(scope, subject string, userID *uint64, windowStart time.Time) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	failures := 1
+	var lastFailureAt time.Time
+	err = tx.QueryRow(`SELECT failures, last_failure_at FROM login_throttles WHERE scope = ? AND subject = ?`, scope, subject).
+		Scan(&failures, &lastFailureAt)
+	switch {
+	case err == sql.ErrNoRows:
+		failures = 1
+	case err != nil:
+		return 0, fmt.Errorf("failed to read login failures: %v", err)
+	case lastFailureAt.Before(windowStart):
+		failures = 1
+	default:
+		failures++
+	}
+
+	query := `
+		INSERT INTO login_throttles (scope, subject, user_id, failures, last_failure_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (scope, subject) DO UPDATE
+		SET user_id = COALESCE(excluded.user_id, user_id), failures = excluded.failures, last_failure_at = excluded.last_failure_at`
+	if _, err := tx.Exec(query, scope, subject, userID, failures, time.Now()); err != nil {
+		return 0, fmt.Errorf("failed to record login failure: %v", err)
+	}
+
+	return failures, tx.Commit()
+}
+
+// LockLogin refuses sign-ins for an account or IP until the given time
+func (r *loginThrottleRepo) LockLogin(scope, subject string, until time.Time) error {
+	_, err := r.db.Exec(`UPDATE login_throttles SET locked_until = ? WHERE scope = ? AND subject = ?`, until, scope, subject)
+	if err != nil {
+		return fmt.Errorf("failed to lock login: %v", err)
+	}
+	return nil
+}
+
+// ResetLoginThrottle forgets the failures and any lock of an account or IP
+func (r *loginThrottleRepo) ResetLoginThrottle(scope, subject string) error {
+	_, err := r.db.Exec(`DELETE FROM login_throttles WHERE scope = ? AND subject = ?`, scope, subject)
+	if err != nil {
+		return fmt.Errorf("failed to reset login failures: %v", err)
+	}
+	return nil
+}
+
+// ListLockedLogins returns the accounts or IPs of a scope that are locked at the given time, longest lock first
+func (r *loginThrottleRepo) ListLockedLogins(scope string, now time.Time) ([]entity.LoginThrottle, error) {
+	query := `
+		SELECT ` + loginThrottleColumns + `
+		FROM login_throttles
+		WHERE scope = ? AND locked_until > ?
+		ORDER BY locked_until DESC`
+	rows, err := r.db.Query(query, scope, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var throttles []entity.LoginThrottle
+	for rows.Next() {
+		throttle, err := scanLoginThrottle(rows)
+		if err != nil {
+			return nil, err
+		}
+		throttles = append(throttles, *throttle)
+	}
+	return throttles, rows.Err()
+}
+
+func scanLoginThrottle(row rowScanner) (*entity.LoginThrottle, error) {
+	var throttle entity.LoginThrottle
+	var userID sql.NullInt64
+	err := row.Scan(&throttle.Scope, &throttle.Subject, &userID, &throttle.Failures, &throttle.LastFailureAt,
+		&throttle.LockedUntil)
+	if err != nil {
+		return nil, err
+	}
+	if userID.Valid {
+		id := uint64(userID.Int64)
+		throttle.UserID = &id
+	}
+	return &throttle, nil
+}
@@ -0,0 +1,40 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockLoginThrottleRepository is a mock implementation of LoginThrottleRepository
+type MockLoginThrottleRepository struct {
+	mock.Mock
+}
+
+func (m *MockLoginThrottleRepository) GetLoginThrottle(scope, subject string) (*entity.LoginThrottle, error) {
+	args := m.Called(scope, subject)
+	throttle, _ := args.Get(0).(*entity.LoginThrottle)
+	return throttle, args.Error(1)
+}
+
+func (m *MockLoginThrottleRepository) RecordLoginFailure(scope, subject string, userID *uint64, windowStart time.Time) (int, error) {
+	args := m.Called(scope, subject, userID, windowStart)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockLoginThrottleRepository) LockLogin(scope, subject string, until time.Time) error {
+	args := m.Called(scope, subject, until)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleRepository) ResetLoginThrottle(scope, subject string) error {
+	args := m.Called(scope, subject)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleRepository) ListLockedLogins(scope string, now time.Time) ([]entity.LoginThrottle, error) {
+	args := m.Called(scope, now)
+	throttles, _ := args.Get(0).([]entity.LoginThrottle)
+	return throttles, args.Error(1)
+}
@@ -0,0 +1,69 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordLoginFailure(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	throttleRepo := NewLoginThrottleRepository(db)
+	userID := uint64(7)
+	windowStart := time.Now().Add(-time.Hour)
+
+	failures, err := throttleRepo.RecordLoginFailure(entity.LoginThrottleAccount, "john@example.com", &userID, windowStart)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, failures)
+
+	failures, err = throttleRepo.RecordLoginFailure(entity.LoginThrottleAccount, "john@example.com", nil, windowStart)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, failures)
+
+	// Other scopes and subjects are counted separately
+	failures, err = throttleRepo.RecordLoginFailure(entity.LoginThrottleIP, "john@example.com", nil, windowStart)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, failures)
+
+	// Failures before the window are forgotten
+	failures, err = throttleRepo.RecordLoginFailure(entity.LoginThrottleAccount, "john@example.com", nil, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, failures)
+
+	throttle, err := throttleRepo.GetLoginThrottle(entity.LoginThrottleAccount, "john@example.com")
+	assert.NoError(t, err)
+	if assert.NotNil(t, throttle) && assert.NotNil(t, throttle.UserID) {
+		assert.Equal(t, userID, *throttle.UserID)
+	}
+}
+
+func TestLockAndResetLogin(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	throttleRepo := NewLoginThrottleRepository(db)
+	windowStart := time.Now().Add(-time.Hour)
+	_, err := throttleRepo.RecordLoginFailure(entity.LoginThrottleAccount, "locked@example.com", nil, windowStart)
+	assert.NoError(t, err)
+	_, err = throttleRepo.RecordLoginFailure(entity.LoginThrottleAccount, "expired@example.com", nil, windowStart)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	assert.NoError(t, throttleRepo.LockLogin(entity.LoginThrottleAccount, "locked@example.com", now.Add(time.Hour)))
+	assert.NoError(t, throttleRepo.LockLogin(entity.LoginThrottleAccount, "expired@example.com", now.Add(-time.Minute)))
+
+	locked, err := throttleRepo.ListLockedLogins(entity.LoginThrottleAccount, now)
+	assert.NoError(t, err)
+	if assert.Len(t, locked, 1) {
+		assert.Equal(t, "locked@example.com", locked[0].Subject)
+		assert.True(t, locked[0].LockedAt(now))
+	}
+
+	assert.NoError(t, throttleRepo.ResetLoginThrottle(entity.LoginThrottleAccount, "locked@example.com"))
+	throttle, err := throttleRepo.GetLoginThrottle(entity.LoginThrottleAccount, "locked@example.com")
+	assert.NoError(t, err)
+	assert.Nil(t, throttle)
+}
@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"mlvt/internal/entity"
+	"time"
+)
+
+// mediaInfoColumns are the probed stream details stored on both videos and audios
+const mediaInfoColumns = `video_codec, audio_codec, width, height, bitrate, audio_channels, sample_rate, probed_at`
+
+// mediaInfoFields returns the scan destinations of mediaInfoColumns
+func mediaInfoFields(info *entity.MediaInfo) []interface{} {
+	return []interface{}{&info.VideoCodec, &info.AudioCodec, &info.Width, &info.Height, &info.Bitrate,
+		&info.AudioChannels, &info.SampleRate, &info.ProbedAt}
+}
+
+// updateMediaInfo replaces the duration and media info of a row in the videos or audios table
+// and returns the number of rows updated
+func updateMediaInfo(db *sql.DB, table string, id uint64, duration int, info entity.MediaInfo) (int64, error) {
+	query := `
+		UPDATE ` + table + `
+		SET duration = ?, video_codec = ?, audio_codec = ?, width = ?, height = ?, bitrate = ?,
+		    audio_channels = ?, sample_rate = ?, probed_at = ?, updated_at = ?
+		WHERE id = ?`
+	result, err := db.Exec(query, duration, info.VideoCodec, info.AudioCodec, info.Width, info.Height, info.Bitrate,
+		info.AudioChannels, info.SampleRate, info.ProbedAt, time.Now(), id)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	return rowsAffected, nil
+}
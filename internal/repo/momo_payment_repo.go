@@ -1,137 +1,113 @@
 package repo
 
 import (
-	"bytes"
-	"encoding/json"
-	"errors"
-	"io/ioutil"
+	"database/sql"
+	"fmt"
 	"mlvt/internal/entity"
-	"net/http"
 	"time"
 )
 
-type MoMoRepo interface {
-	CreatePayment(orderID, amount string) (string, error)
-	CheckPaymentStatus(orderID string) (bool, error)
-	RefundPayment(orderID, amount string) (string, error)
+// MoMoPaymentRepository stores the payments made through the MoMo gateway
+type MoMoPaymentRepository interface {
+	CreatePayment(payment *entity.MoMoPayment) error
+	GetPaymentByOrderID(orderID string) (*entity.MoMoPayment, error)
+	CompletePayment(orderID, status string, transID int64, resultCode int, message string, at time.Time) (bool, error)
+	AddRefund(orderID string, amount int64) (bool, error)
 }
 
-type momoRepo struct {
-	endpoint    string
-	partnerCode string
-	accessKey   string
-	secrectKey  string
+type momoPaymentRepo struct {
+	db *sql.DB
 }
 
-func NewMoMoRepo() MoMoRepo {
-	return &momoRepo{
-		endpoint:    "", //env.EnvConfig.MoMoEndpoint,
-		partnerCode: "", //env.EnvConfig.MoMoPartnerCode,
-		accessKey:   "", //env.EnvConfig.MoMoAccessKey,
-		secrectKey:  "", //env.EnvConfig.MoMoSecretKey,
-	}
+func NewMoMoPaymentRepository(db *sql.DB) MoMoPaymentRepository {
+	return &momoPaymentRepo{db: db}
 }
 
-func (m *momoRepo) CreatePayment(orderID, amount string) (string, error) {
-	momoRequest := entity.NewMoMoRequest(m.partnerCode, m.accessKey, orderID, amount, orderID)
-	momoRequest.GenerateSignature(m.secrectKey)
-
-	requestBody := momoRequest.ToMap()
+const momoPaymentColumns = `id, user_id, order_id, request_id, amount, order_info, status, pay_url, trans_id, result_code,
+	message, refunded_amount, paid_at, created_at, updated_at`
 
-	jsonBody, err := json.Marshal(requestBody)
-	if err != nil {
-		return "", err
+// CreatePayment stores a new payment and sets its ID
+func (r *momoPaymentRepo) CreatePayment(payment *entity.MoMoPayment) error {
+	now := time.Now()
+	if payment.Status == "" {
+		payment.Status = entity.PaymentStatusPending
 	}
-
-	client := &http.Client{Timeout: time.Second * 30}
-	req, err := http.NewRequest("POST", m.endpoint, bytes.NewBuffer(jsonBody))
+	query := `
+		INSERT INTO momo_payments (user_id, order_id, request_id, amount, order_info, status, pay_url, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := r.db.Exec(query, payment.UserID, payment.OrderID, payment.RequestID, payment.Amount, payment.OrderInfo,
+		payment.Status, payment.PayURL, now, now)
 	if err != nil {
-		return "", err
+		return fmt.Errorf("failed to create MoMo payment: %v", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := client.Do(req)
+	id, err := result.LastInsertId()
 	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	body, _ := ioutil.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return "", errors.New("payment request failed")
+		return err
 	}
+	payment.ID = uint64(id)
+	payment.CreatedAt = now
+	payment.UpdatedAt = now
+	return nil
+}
 
-	var response struct {
-		PayURL string `json:"payUrl"`
+// GetPaymentByOrderID retrieves a payment by its order ID
+func (r *momoPaymentRepo) GetPaymentByOrderID(orderID string) (*entity.MoMoPayment, error) {
+	payment, err := scanMoMoPayment(r.db.QueryRow(`SELECT `+momoPaymentColumns+` FROM momo_payments WHERE order_id = ?`, orderID))
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-	json.Unmarshal(body, &response)
-
-	return response.PayURL, nil
+	return payment, err
 }
 
-func (m *momoRepo) CheckPaymentStatus(orderID string) (bool, error) {
-	momoRequest := entity.NewMoMoRequest(m.partnerCode, m.accessKey, orderID, "0", orderID)
-	momoRequest.GenerateSignature(m.secrectKey)
-
-	requestBody := momoRequest.ToMap()
-	jsonBody, err := json.Marshal(requestBody)
-	if err != nil {
-		return false, err
+// CompletePayment moves a pending payment to its final status. It reports false if the payment was
+// already completed, so a result reported twice, by a notification and a status check or by a
+// repeated notification, is only acted on once.
+func (r *momoPaymentRepo) CompletePayment(orderID, status string, transID int64, resultCode int, message string, at time.Time) (bool, error) {
+	var paidAt *time.Time
+	if status == entity.PaymentStatusPaid {
+		paidAt = &at
 	}
-
-	client := &http.Client{Timeout: time.Second * 30}
-	req, err := http.NewRequest("POST", m.endpoint+"/check-status", bytes.NewBuffer(jsonBody))
+	query := `
+		UPDATE momo_payments SET status = ?, trans_id = ?, result_code = ?, message = ?, paid_at = ?, updated_at = ?
+		WHERE order_id = ? AND status = ?`
+	result, err := r.db.Exec(query, status, transID, resultCode, message, paidAt, at, orderID, entity.PaymentStatusPending)
 	if err != nil {
-		return false, err
+		return false, fmt.Errorf("failed to complete MoMo payment: %v", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := client.Do(req)
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return false, err
+		return false, fmt.Errorf("failed to retrieve rows affected: %v", err)
 	}
-	defer resp.Body.Close()
-
-	body, _ := ioutil.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return false, errors.New("failed to check payment status")
-	}
-
-	var response struct {
-		Status string `json:"status"`
-	}
-	json.Unmarshal(body, &response)
-
-	return response.Status == "success", nil
+	return rowsAffected > 0, nil
 }
 
-func (m *momoRepo) RefundPayment(orderID, amount string) (string, error) {
-	momoRequest := entity.NewMoMoRequest(m.partnerCode, m.accessKey, orderID+"_refund", amount, orderID)
-	momoRequest.GenerateSignature(m.secrectKey)
-
-	requestBody := momoRequest.ToMap()
-	jsonBody, err := json.Marshal(requestBody)
+// AddRefund records a refund of a paid payment, which becomes refunded once nothing is left.
+// It reports false if the payment is not paid or the amount exceeds what is left.
+func (r *momoPaymentRepo) AddRefund(orderID string, amount int64) (bool, error) {
+	query := `
+		UPDATE momo_payments SET refunded_amount = refunded_amount + ?,
+			status = CASE WHEN refunded_amount + ? >= amount THEN ? ELSE status END, updated_at = ?
+		WHERE order_id = ? AND status = ? AND refunded_amount + ? <= amount`
+	result, err := r.db.Exec(query, amount, amount, entity.PaymentStatusRefunded, time.Now(), orderID,
+		entity.PaymentStatusPaid, amount)
 	if err != nil {
-		return "", err
+		return false, fmt.Errorf("failed to record MoMo refund: %v", err)
 	}
-
-	client := &http.Client{Timeout: time.Second * 30}
-	req, err := http.NewRequest("POST", m.endpoint+"/refund", bytes.NewBuffer(jsonBody))
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return "", err
+		return false, fmt.Errorf("failed to retrieve rows affected: %v", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
+	return rowsAffected > 0, nil
+}
 
-	resp, err := client.Do(req)
+func scanMoMoPayment(row rowScanner) (*entity.MoMoPayment, error) {
+	var payment entity.MoMoPayment
+	err := row.Scan(&payment.ID, &payment.UserID, &payment.OrderID, &payment.RequestID, &payment.Amount, &payment.OrderInfo,
+		&payment.Status, &payment.PayURL, &payment.TransID, &payment.ResultCode, &payment.Message, &payment.RefundedAmount,
+		&payment.PaidAt, &payment.CreatedAt, &payment.UpdatedAt)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	body, _ := ioutil.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return "", errors.New("refund request failed")
-	}
-
-	return string(body), nil
+	return &payment, nil
 }
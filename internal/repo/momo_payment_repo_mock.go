@@ -0,0 +1,34 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockMoMoPaymentRepository is a mock implementation of MoMoPaymentRepository
+type MockMoMoPaymentRepository struct {
+	mock.Mock
+}
+
+func (m *MockMoMoPaymentRepository) CreatePayment(payment *entity.MoMoPayment) error {
+	args := m.Called(payment)
+	return args.Error(0)
+}
+
+func (m *MockMoMoPaymentRepository) GetPaymentByOrderID(orderID string) (*entity.MoMoPayment, error) {
+	args := m.Called(orderID)
+	payment, _ := args.Get(0).(*entity.MoMoPayment)
+	return payment, args.Error(1)
+}
+
+func (m *MockMoMoPaymentRepository) CompletePayment(orderID, status string, transID int64, resultCode int, message string, at time.Time) (bool, error) {
+	args := m.Called(orderID, status, transID, resultCode, message, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMoMoPaymentRepository) AddRefund(orderID string, amount int64) (bool, error) {
+	args := m.Called(orderID, amount)
+	return args.Bool(0), args.Error(1)
+}
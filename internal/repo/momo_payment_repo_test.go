@@ -0,0 +1,79 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompleteMoMoPaymentOnce(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	paymentRepo := NewMoMoPaymentRepository(db)
+	payment := &entity.MoMoPayment{UserID: 1, OrderID: "order-1", RequestID: "req-1", Amount: 50000, PayURL: "https://pay"}
+	assert.NoError(t, paymentRepo.CreatePayment(payment))
+	assert.NotZero(t, payment.ID)
+	assert.Error(t, paymentRepo.CreatePayment(&entity.MoMoPayment{UserID: 1, OrderID: "order-1", RequestID: "req-2", Amount: 1}))
+
+	stored, err := paymentRepo.GetPaymentByOrderID("order-1")
+	assert.NoError(t, err)
+	assert.Equal(t, entity.PaymentStatusPending, stored.Status)
+	assert.Nil(t, stored.ResultCode)
+
+	paidAt := time.Now()
+	completed, err := paymentRepo.CompletePayment("order-1", entity.PaymentStatusPaid, 42, 0, "Successful.", paidAt)
+	assert.NoError(t, err)
+	assert.True(t, completed)
+
+	// A repeated result changes nothing
+	completed, err = paymentRepo.CompletePayment("order-1", entity.PaymentStatusFailed, 0, 1006, "Declined.", time.Now())
+	assert.NoError(t, err)
+	assert.False(t, completed)
+
+	stored, _ = paymentRepo.GetPaymentByOrderID("order-1")
+	assert.Equal(t, entity.PaymentStatusPaid, stored.Status)
+	assert.Equal(t, int64(42), stored.TransID)
+	if assert.NotNil(t, stored.ResultCode) {
+		assert.Equal(t, 0, *stored.ResultCode)
+	}
+	if assert.NotNil(t, stored.PaidAt) {
+		assert.WithinDuration(t, paidAt, *stored.PaidAt, time.Second)
+	}
+
+	missing, err := paymentRepo.GetPaymentByOrderID("unknown")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestAddMoMoRefund(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	paymentRepo := NewMoMoPaymentRepository(db)
+	assert.NoError(t, paymentRepo.CreatePayment(&entity.MoMoPayment{UserID: 1, OrderID: "order-1", RequestID: "req-1", Amount: 50000}))
+
+	// Only paid payments can be refunded
+	refunded, err := paymentRepo.AddRefund("order-1", 10000)
+	assert.NoError(t, err)
+	assert.False(t, refunded)
+
+	_, err = paymentRepo.CompletePayment("order-1", entity.PaymentStatusPaid, 42, 0, "Successful.", time.Now())
+	assert.NoError(t, err)
+	refunded, _ = paymentRepo.AddRefund("order-1", 20000)
+	assert.True(t, refunded)
+	refunded, _ = paymentRepo.AddRefund("order-1", 40000)
+	assert.False(t, refunded)
+
+	stored, _ := paymentRepo.GetPaymentByOrderID("order-1")
+	assert.Equal(t, entity.PaymentStatusPaid, stored.Status)
+	assert.Equal(t, int64(20000), stored.RefundedAmount)
+	assert.Equal(t, int64(30000), stored.Refundable())
+
+	refunded, _ = paymentRepo.AddRefund("order-1", 30000)
+	assert.True(t, refunded)
+	stored, _ = paymentRepo.GetPaymentByOrderID("order-1")
+	assert.Equal(t, entity.PaymentStatusRefunded, stored.Status)
+	assert.Equal(t, int64(0), stored.Refundable())
+}
@@ -0,0 +1,138 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"mlvt/internal/entity"
+	"time"
+)
+
+// OIDCRepository stores pending sign-ins with external identity providers and the identities linked to users
+type OIDCRepository interface {
+	CreateLoginState(state *entity.OIDCLoginState) error
+	ConsumeLoginState(provider, stateHash string) (*entity.OIDCLoginState, error)
+	GetIdentity(provider, subject string) (*entity.UserIdentity, error)
+	CreateIdentity(identity *entity.UserIdentity) error
+	TouchIdentity(identityID uint64) error
+}
+
+type oidcRepo struct {
+	db *sql.DB
+}
+
+func NewOIDCRepository(db *sql.DB) OIDCRepository {
+	return &oidcRepo{db: db}
+}
+
+const userIdentityColumns = `id, user_id, provider, subject, email, created_at, last_login_at`
+
+// CreateLoginState stores a new pending sign-in. Expired ones that were never completed are removed.
+func (r *oidcRepo) CreateLoginState(state *entity.OIDCLoginState) error {
+	now := time.Now()
+	if _, err := r.db.Exec(`DELETE FROM oidc_login_states WHERE expires_at <= ?`, now); err != nil {
+		return fmt.Errorf("failed to delete expired login states: %v", err)
+	}
+
+	query := `
+		INSERT INTO oidc_login_states (state_hash, provider, code_verifier, nonce, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(query, state.StateHash, state.Provider, state.CodeVerifier, state.Nonce, state.ExpiresAt, now)
+	if err != nil {
+		return fmt.Errorf("failed to create login state: %v", err)
+	}
+	state.CreatedAt = now
+	return nil
+}
+
+// ConsumeLoginState deletes a pending sign-in of the provider and returns it if it has not expired.
+// It returns nil if no such sign-in exists, so each state can be used only once.
+func (r *oidcRepo) ConsumeLoginState(provider, stateHash string) (*entity.OIDCLoginState, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var state entity.OIDCLoginState
+	query := `
+		SELECT state_hash, provider, code_verifier, nonce, expires_at, created_at
+		FROM oidc_login_states WHERE state_hash = ? AND provider = ?`
+	err = tx.QueryRow(query, stateHash, provider).Scan(&state.StateHash, &state.Provider, &state.CodeVerifier,
+		&state.Nonce, &state.ExpiresAt, &state.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login state: %v", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM oidc_login_states WHERE state_hash = ?`, stateHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete login state: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return nil, nil // Used concurrently
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if !time.Now().Before(state.ExpiresAt) {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+// GetIdentity retrieves the identity of an account at a provider
+func (r *oidcRepo) GetIdentity(provider, subject string) (*entity.UserIdentity, error) {
+	query := `SELECT ` + userIdentityColumns + ` FROM user_identities WHERE provider = ? AND subject = ?`
+	identity, err := scanUserIdentity(r.db.QueryRow(query, provider, subject))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return identity, err
+}
+
+// CreateIdentity links an account at a provider to a user and sets the identity's ID
+func (r *oidcRepo) CreateIdentity(identity *entity.UserIdentity) error {
+	now := time.Now()
+	query := `
+		INSERT INTO user_identities (user_id, provider, subject, email, created_at, last_login_at)
+		VALUES (?, ?, ?, ?, ?, ?)`
+	result, err := r.db.Exec(query, identity.UserID, identity.Provider, identity.Subject, identity.Email, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to create user identity: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	identity.ID = uint64(id)
+	identity.CreatedAt = now
+	identity.LastLoginAt = &now
+	return nil
+}
+
+// TouchIdentity records a sign-in with the identity
+func (r *oidcRepo) TouchIdentity(identityID uint64) error {
+	_, err := r.db.Exec(`UPDATE user_identities SET last_login_at = ? WHERE id = ?`, time.Now(), identityID)
+	if err != nil {
+		return fmt.Errorf("failed to update user identity: %v", err)
+	}
+	return nil
+}
+
+func scanUserIdentity(row rowScanner) (*entity.UserIdentity, error) {
+	var identity entity.UserIdentity
+	err := row.Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email,
+		&identity.CreatedAt, &identity.LastLoginAt)
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
@@ -0,0 +1,39 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockOIDCRepository is a mock implementation of OIDCRepository
+type MockOIDCRepository struct {
+	mock.Mock
+}
+
+func (m *MockOIDCRepository) CreateLoginState(state *entity.OIDCLoginState) error {
+	args := m.Called(state)
+	return args.Error(0)
+}
+
+func (m *MockOIDCRepository) ConsumeLoginState(provider, stateHash string) (*entity.OIDCLoginState, error) {
+	args := m.Called(provider, stateHash)
+	state, _ := args.Get(0).(*entity.OIDCLoginState)
+	return state, args.Error(1)
+}
+
+func (m *MockOIDCRepository) GetIdentity(provider, subject string) (*entity.UserIdentity, error) {
+	args := m.Called(provider, subject)
+	identity, _ := args.Get(0).(*entity.UserIdentity)
+	return identity, args.Error(1)
+}
+
+func (m *MockOIDCRepository) CreateIdentity(identity *entity.UserIdentity) error {
+	args := m.Called(identity)
+	return args.Error(0)
+}
+
+func (m *MockOIDCRepository) TouchIdentity(identityID uint64) error {
+	args := m.Called(identityID)
+	return args.Error(0)
+}
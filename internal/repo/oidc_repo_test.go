@@ -0,0 +1,89 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumeLoginState(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	oidcRepo := NewOIDCRepository(db)
+	state := &entity.OIDCLoginState{
+		StateHash:    "hash",
+		Provider:     "google",
+		CodeVerifier: "verifier",
+		Nonce:        "nonce",
+		ExpiresAt:    time.Now().Add(time.Minute),
+	}
+	assert.NoError(t, oidcRepo.CreateLoginState(state))
+
+	// States only work with the provider they were created for
+	other, err := oidcRepo.ConsumeLoginState("github", "hash")
+	assert.NoError(t, err)
+	assert.Nil(t, other)
+
+	consumed, err := oidcRepo.ConsumeLoginState("google", "hash")
+	assert.NoError(t, err)
+	if assert.NotNil(t, consumed) {
+		assert.Equal(t, "verifier", consumed.CodeVerifier)
+		assert.Equal(t, "nonce", consumed.Nonce)
+	}
+
+	// A state can only be used once
+	again, err := oidcRepo.ConsumeLoginState("google", "hash")
+	assert.NoError(t, err)
+	assert.Nil(t, again)
+}
+
+func TestConsumeExpiredLoginState(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	oidcRepo := NewOIDCRepository(db)
+	expired := &entity.OIDCLoginState{StateHash: "old", Provider: "google", CodeVerifier: "v", Nonce: "n",
+		ExpiresAt: time.Now().Add(-time.Minute)}
+	assert.NoError(t, oidcRepo.CreateLoginState(expired))
+
+	consumed, err := oidcRepo.ConsumeLoginState("google", "old")
+	assert.NoError(t, err)
+	assert.Nil(t, consumed)
+
+	// Creating a state removes expired ones
+	_, err = db.Exec(`INSERT INTO oidc_login_states (state_hash, provider, code_verifier, nonce, expires_at)
+		VALUES ('stale', 'google', 'v', 'n', ?)`, time.Now().Add(-time.Minute))
+	assert.NoError(t, err)
+	assert.NoError(t, oidcRepo.CreateLoginState(&entity.OIDCLoginState{StateHash: "new", Provider: "google",
+		CodeVerifier: "v", Nonce: "n", ExpiresAt: time.Now().Add(time.Minute)}))
+	var count int
+	assert.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM oidc_login_states`).Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+func TestUserIdentities(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	oidcRepo := NewOIDCRepository(db)
+	identity := &entity.UserIdentity{UserID: 7, Provider: "github", Subject: "583231", Email: "octocat@github.com"}
+	assert.NoError(t, oidcRepo.CreateIdentity(identity))
+	assert.NotZero(t, identity.ID)
+
+	found, err := oidcRepo.GetIdentity("github", "583231")
+	assert.NoError(t, err)
+	if assert.NotNil(t, found) {
+		assert.Equal(t, uint64(7), found.UserID)
+		assert.NotNil(t, found.LastLoginAt)
+	}
+	assert.NoError(t, oidcRepo.TouchIdentity(identity.ID))
+
+	// The same account cannot be linked twice
+	duplicate := &entity.UserIdentity{UserID: 8, Provider: "github", Subject: "583231", Email: "other@example.com"}
+	assert.Error(t, oidcRepo.CreateIdentity(duplicate))
+
+	missing, err := oidcRepo.GetIdentity("google", "583231")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
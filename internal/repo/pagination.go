@@ -0,0 +1,192 @@
+package repo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mlvt/internal/entity"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// ErrInvalidListOptions is returned when a list query has an unknown sort field or order,
+// a malformed cursor, or a filter the list does not support
+var ErrInvalidListOptions = errors.New("invalid list options")
+
+type sortKind int
+
+const (
+	sortTime sortKind = iota
+	sortText
+	sortInt
+)
+
+// sortColumn is a column a list can be ordered by
+type sortColumn struct {
+	column string
+	kind   sortKind
+}
+
+// listSpec describes how the rows of one list are sorted and filtered
+type listSpec struct {
+	sortable     map[string]sortColumn // Keyed by the SortBy value
+	statusColumn string                // Empty if the list cannot be filtered by status
+	langColumn   string                // Empty if the list cannot be filtered by language
+}
+
+// pageCursor is the position after the last row of a page. Sort and Order pin the cursor
+// to the ordering it was issued for. Times are encoded in UTC, like the created_at they point into.
+type pageCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    uint64 `json:"id"`
+}
+
+// pageQuery is a list query with its filters, keyset condition, ordering and limit applied
+type pageQuery struct {
+	query  string
+	args   []interface{}
+	sortBy string
+	order  string
+	sort   sortColumn
+	limit  int
+}
+
+// buildPageQuery completes a "SELECT ... FROM table WHERE scope" query for the page described by opts.
+// One row past the limit is fetched to tell whether another page follows.
+func buildPageQuery(selectQuery string, args []interface{}, opts entity.ListOptions, spec listSpec) (*pageQuery, error) {
+	page := &pageQuery{sortBy: opts.SortBy, order: strings.ToLower(opts.Order), limit: opts.Limit}
+	if page.sortBy == "" {
+		page.sortBy = entity.SortByCreatedAt
+	}
+	if page.order == "" {
+		page.order = entity.SortDesc
+	}
+	if page.limit == 0 {
+		page.limit = DefaultPageLimit
+	}
+	if page.limit < 0 {
+		return nil, fmt.Errorf("%w: limit must be positive", ErrInvalidListOptions)
+	}
+	if page.limit > MaxPageLimit {
+		page.limit = MaxPageLimit
+	}
+
+	sort, ok := spec.sortable[page.sortBy]
+	if !ok {
+		return nil, fmt.Errorf("%w: cannot sort by %q", ErrInvalidListOptions, page.sortBy)
+	}
+	page.sort = sort
+	if page.order != entity.SortAsc && page.order != entity.SortDesc {
+		return nil, fmt.Errorf("%w: order must be %s or %s", ErrInvalidListOptions, entity.SortAsc, entity.SortDesc)
+	}
+
+	query := selectQuery
+	if opts.Status != "" {
+		if spec.statusColumn == "" {
+			return nil, fmt.Errorf("%w: cannot filter by status", ErrInvalidListOptions)
+		}
+		query += " AND " + spec.statusColumn + " = ?"
+		args = append(args, opts.Status)
+	}
+	if opts.Lang != "" {
+		if spec.langColumn == "" {
+			return nil, fmt.Errorf("%w: cannot filter by language", ErrInvalidListOptions)
+		}
+		query += " AND " + spec.langColumn + " = ?"
+		args = append(args, opts.Lang)
+	}
+	// created_at is stored as text in UTC, so bounds are converted to UTC to compare in the same form
+	if opts.CreatedAfter != nil {
+		query += " AND created_at >= ?"
+		args = append(args, opts.CreatedAfter.UTC())
+	}
+	if opts.CreatedBefore != nil {
+		query += " AND created_at < ?"
+		args = append(args, opts.CreatedBefore.UTC())
+	}
+
+	comparison, direction := "<", "DESC"
+	if page.order == entity.SortAsc {
+		comparison, direction = ">", "ASC"
+	}
+
+	if opts.Cursor != "" {
+		value, id, err := page.decodeCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query += fmt.Sprintf(" AND (%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", sort.column, comparison)
+		args = append(args, value, value, id)
+	}
+
+	page.query = fmt.Sprintf("%s ORDER BY %s %s, id %s LIMIT ?", query, sort.column, direction, direction)
+	page.args = append(args, page.limit+1)
+	return page, nil
+}
+
+// decodeCursor returns the sort value and ID a cursor points after
+func (p *pageQuery) decodeCursor(encoded string) (interface{}, uint64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: malformed cursor", ErrInvalidListOptions)
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, 0, fmt.Errorf("%w: malformed cursor", ErrInvalidListOptions)
+	}
+	if cursor.Sort != p.sortBy || cursor.Order != p.order {
+		return nil, 0, fmt.Errorf("%w: cursor was issued for a different ordering", ErrInvalidListOptions)
+	}
+
+	switch p.sort.kind {
+	case sortTime:
+		t, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: malformed cursor", ErrInvalidListOptions)
+		}
+		return t.UTC(), cursor.ID, nil
+	case sortInt:
+		n, err := strconv.ParseInt(cursor.Value, 10, 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: malformed cursor", ErrInvalidListOptions)
+		}
+		return n, cursor.ID, nil
+	default:
+		return cursor.Value, cursor.ID, nil
+	}
+}
+
+// encodeCursor returns the cursor pointing after a row with the given sort value and ID
+func (p *pageQuery) encodeCursor(value interface{}, id uint64) string {
+	cursor := pageCursor{Sort: p.sortBy, Order: p.order, ID: id}
+	switch v := value.(type) {
+	case time.Time:
+		cursor.Value = v.UTC().Format(time.RFC3339Nano)
+	case int:
+		cursor.Value = strconv.Itoa(v)
+	case string:
+		cursor.Value = v
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// trimPage drops the lookahead row fetched by buildPageQuery and returns the cursor of the next page,
+// or an empty cursor if this is the last page. key returns the value of the sort field and the ID of a row.
+func trimPage[T any](page *pageQuery, rows []T, key func(row T, sortBy string) (interface{}, uint64)) ([]T, string) {
+	if len(rows) <= page.limit {
+		return rows, ""
+	}
+	rows = rows[:page.limit]
+	value, id := key(rows[len(rows)-1], page.sortBy)
+	return rows, page.encodeCursor(value, id)
+}
@@ -8,7 +8,23 @@ var ProviderSetRepository = wire.NewSet(
 	NewVideoRepo,
 	NewAudioRepository,
 	NewTranscriptionRepository,
-	NewMoMoRepo,
+	NewMoMoPaymentRepository,
+	NewTransactionLogRepo,
+	NewBillingRepository,
+	NewAuditLogRepository,
+	NewJobRepository,
+	NewTranslationRepository,
+	NewSearchRepository,
+	NewTranscriptionSegmentRepository,
+	NewVideoUploadRepository,
+	NewFrameRepository,
+	NewRefreshTokenRepository,
+	NewUserTokenRepository,
+	NewLoginThrottleRepository,
+	NewTwoFactorRepository,
+	NewOIDCRepository,
+	NewAPIKeyRepository,
+	NewStorageObjectRepository,
 	// wire.Bind(new(UserRepository), new(*userRepo)),
 	// wire.Bind(new(VideoRepository), new(*videoRepo)),
 	// wire.Bind(new(AudioRepository), new(*audioRepo)),
@@ -0,0 +1,135 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"mlvt/internal/entity"
+	"time"
+)
+
+// ErrRefreshTokenRevoked is returned by RotateRefreshToken when the token was revoked or used concurrently
+var ErrRefreshTokenRevoked = errors.New("refresh token already revoked")
+
+// RefreshTokenRepository stores hashed refresh tokens and their rotation chain
+type RefreshTokenRepository interface {
+	CreateRefreshToken(token *entity.RefreshToken) error
+	GetRefreshTokenByHash(tokenHash string) (*entity.RefreshToken, error)
+	RotateRefreshToken(oldID uint64, next *entity.RefreshToken) error
+	RevokeRefreshTokenFamily(familyID string) error
+	RevokeUserRefreshTokens(userID uint64) error
+}
+
+type refreshTokenRepo struct {
+	db *sql.DB
+}
+
+func NewRefreshTokenRepository(db *sql.DB) RefreshTokenRepository {
+	return &refreshTokenRepo{db: db}
+}
+
+const refreshTokenColumns = `id, user_id, token_hash, family_id, expires_at, revoked_at, replaced_by_id, created_at`
+
+// CreateRefreshToken inserts a new refresh token and sets its ID
+func (r *refreshTokenRepo) CreateRefreshToken(token *entity.RefreshToken) error {
+	return insertRefreshToken(r.db, token)
+}
+
+// GetRefreshTokenByHash retrieves a refresh token, revoked or not, by the hash of its value
+func (r *refreshTokenRepo) GetRefreshTokenByHash(tokenHash string) (*entity.RefreshToken, error) {
+	row := r.db.QueryRow(`SELECT `+refreshTokenColumns+` FROM refresh_tokens WHERE token_hash = ?`, tokenHash)
+	token, err := scanRefreshToken(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return token, err
+}
+
+// RotateRefreshToken revokes a refresh token and stores the token replacing it in one transaction.
+// It returns ErrRefreshTokenRevoked if the old token is no longer active, so a token can be used only once.
+func (r *refreshTokenRepo) RotateRefreshToken(oldID uint64, next *entity.RefreshToken) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(`UPDATE refresh_tokens SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, now, oldID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return ErrRefreshTokenRevoked
+	}
+
+	if err := insertRefreshToken(tx, next); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET replaced_by_id = ? WHERE id = ?`, next.ID, oldID); err != nil {
+		return fmt.Errorf("failed to link refresh tokens: %v", err)
+	}
+
+	return tx.Commit()
+}
+
+// RevokeRefreshTokenFamily revokes every active token rotated from the same login
+func (r *refreshTokenRepo) RevokeRefreshTokenFamily(familyID string) error {
+	_, err := r.db.Exec(`UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL`, time.Now(), familyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %v", err)
+	}
+	return nil
+}
+
+// RevokeUserRefreshTokens revokes every active refresh token of a user
+func (r *refreshTokenRepo) RevokeUserRefreshTokens(userID uint64) error {
+	_, err := r.db.Exec(`UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL`, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %v", err)
+	}
+	return nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func insertRefreshToken(db execer, token *entity.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?)`
+	now := time.Now()
+	result, err := db.Exec(query, token.UserID, token.TokenHash, token.FamilyID, token.ExpiresAt, now)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	token.ID = uint64(id)
+	token.CreatedAt = now
+	return nil
+}
+
+func scanRefreshToken(row rowScanner) (*entity.RefreshToken, error) {
+	var token entity.RefreshToken
+	var replacedByID sql.NullInt64
+	err := row.Scan(&token.ID, &token.UserID, &token.TokenHash, &token.FamilyID, &token.ExpiresAt, &token.RevokedAt,
+		&replacedByID, &token.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if replacedByID.Valid {
+		id := uint64(replacedByID.Int64)
+		token.ReplacedByID = &id
+	}
+	return &token, nil
+}
@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRefreshTokenRepository is a mock implementation of RefreshTokenRepository
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) CreateRefreshToken(token *entity.RefreshToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) GetRefreshTokenByHash(tokenHash string) (*entity.RefreshToken, error) {
+	args := m.Called(tokenHash)
+	token, _ := args.Get(0).(*entity.RefreshToken)
+	return token, args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RotateRefreshToken(oldID uint64, next *entity.RefreshToken) error {
+	args := m.Called(oldID, next)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeRefreshTokenFamily(familyID string) error {
+	args := m.Called(familyID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeUserRefreshTokens(userID uint64) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotateRefreshToken(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	tokenRepo := NewRefreshTokenRepository(db)
+	expiresAt := time.Now().Add(time.Hour)
+	first := &entity.RefreshToken{UserID: 1, TokenHash: "hash-1", FamilyID: "family", ExpiresAt: expiresAt}
+	assert.NoError(t, tokenRepo.CreateRefreshToken(first))
+	assert.NotZero(t, first.ID)
+
+	second := &entity.RefreshToken{UserID: 1, TokenHash: "hash-2", FamilyID: "family", ExpiresAt: expiresAt}
+	assert.NoError(t, tokenRepo.RotateRefreshToken(first.ID, second))
+
+	rotated, err := tokenRepo.GetRefreshTokenByHash("hash-1")
+	assert.NoError(t, err)
+	assert.NotNil(t, rotated.RevokedAt)
+	if assert.NotNil(t, rotated.ReplacedByID) {
+		assert.Equal(t, second.ID, *rotated.ReplacedByID)
+	}
+
+	// A token can only be rotated once
+	third := &entity.RefreshToken{UserID: 1, TokenHash: "hash-3", FamilyID: "family", ExpiresAt: expiresAt}
+	assert.ErrorIs(t, tokenRepo.RotateRefreshToken(first.ID, third), ErrRefreshTokenRevoked)
+	missing, err := tokenRepo.GetRefreshTokenByHash("hash-3")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestRevokeRefreshTokens(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	tokenRepo := NewRefreshTokenRepository(db)
+	expiresAt := time.Now().Add(time.Hour)
+	for _, token := range []*entity.RefreshToken{
+		{UserID: 1, TokenHash: "a", FamilyID: "phone", ExpiresAt: expiresAt},
+		{UserID: 1, TokenHash: "b", FamilyID: "laptop", ExpiresAt: expiresAt},
+		{UserID: 2, TokenHash: "c", FamilyID: "other", ExpiresAt: expiresAt},
+	} {
+		assert.NoError(t, tokenRepo.CreateRefreshToken(token))
+	}
+
+	assert.NoError(t, tokenRepo.RevokeRefreshTokenFamily("phone"))
+	phone, _ := tokenRepo.GetRefreshTokenByHash("a")
+	laptop, _ := tokenRepo.GetRefreshTokenByHash("b")
+	assert.NotNil(t, phone.RevokedAt)
+	assert.Nil(t, laptop.RevokedAt)
+
+	assert.NoError(t, tokenRepo.RevokeUserRefreshTokens(1))
+	laptop, _ = tokenRepo.GetRefreshTokenByHash("b")
+	other, _ := tokenRepo.GetRefreshTokenByHash("c")
+	assert.NotNil(t, laptop.RevokedAt)
+	assert.Nil(t, other.RevokedAt)
+}
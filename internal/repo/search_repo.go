@@ -0,0 +1,82 @@
+package repo
+
+import (
+	"database/sql"
+	"mlvt/internal/entity"
+)
+
+// Control characters wrapped around matched terms in search snippets. They cannot occur in
+// indexed text, so callers can escape the snippet and then turn them into markup.
+const (
+	SnippetMatchStart = "\x02"
+	SnippetMatchEnd   = "\x03"
+)
+
+const (
+	snippetEllipsis = "…"
+	snippetTokens   = 16
+)
+
+// SearchRepository queries the full-text index over video titles, video descriptions and transcription text.
+// The index lives in the videos_fts and transcriptions_fts FTS5 tables, which triggers keep in sync with their source tables.
+type SearchRepository interface {
+	// Search returns the user's videos and transcriptions matching an FTS5 match expression, most relevant first.
+	// A non-empty lang restricts the results to transcriptions in that language.
+	Search(userID uint64, match, lang string, limit, offset int) ([]entity.SearchResult, error)
+}
+
+type searchRepo struct {
+	db *sql.DB
+}
+
+func NewSearchRepository(db *sql.DB) SearchRepository {
+	return &searchRepo{db: db}
+}
+
+func (r *searchRepo) Search(userID uint64, match, lang string, limit, offset int) ([]entity.SearchResult, error) {
+	transcriptionQuery := `
+		SELECT 'transcription', t.id, t.video_id, v.title, t.lang,
+		       snippet(transcriptions_fts, 0, ?, ?, ?, ?), bm25(transcriptions_fts)
+		FROM transcriptions_fts
+		JOIN transcriptions t ON t.id = transcriptions_fts.rowid
+		JOIN videos v ON v.id = t.video_id
+		WHERE transcriptions_fts MATCH ? AND t.user_id = ?`
+	args := []interface{}{SnippetMatchStart, SnippetMatchEnd, snippetEllipsis, snippetTokens, match, userID}
+
+	var query string
+	if lang != "" {
+		// Videos carry no language, so a language filter only matches transcriptions
+		query = transcriptionQuery + ` AND t.lang = ?`
+		args = append(args, lang)
+	} else {
+		query = `
+		SELECT 'video', v.id, v.id, v.title, '',
+		       snippet(videos_fts, -1, ?, ?, ?, ?), bm25(videos_fts)
+		FROM videos_fts
+		JOIN videos v ON v.id = videos_fts.rowid
+		WHERE videos_fts MATCH ? AND v.user_id = ?
+		UNION ALL` + transcriptionQuery
+		args = append([]interface{}{SnippetMatchStart, SnippetMatchEnd, snippetEllipsis, snippetTokens, match, userID}, args...)
+	}
+	query += `
+		ORDER BY 7, 2
+		LIMIT ? OFFSET ?`
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []entity.SearchResult
+	for rows.Next() {
+		var result entity.SearchResult
+		if err := rows.Scan(&result.Kind, &result.ID, &result.VideoID, &result.VideoTitle, &result.Lang,
+			&result.Snippet, &result.Rank); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,18 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSearchRepository mocks the SearchRepository interface
+type MockSearchRepository struct {
+	mock.Mock
+}
+
+func (m *MockSearchRepository) Search(userID uint64, match, lang string, limit, offset int) ([]entity.SearchResult, error) {
+	args := m.Called(userID, match, lang, limit, offset)
+	results, _ := args.Get(0).([]entity.SearchResult)
+	return results, args.Error(1)
+}
@@ -0,0 +1,93 @@
+package repo
+
+import (
+	"database/sql"
+	"mlvt/internal/entity"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupSearchTestDB returns a migrated database with the search index.
+// FTS5 is only compiled into SQLite with -tags sqlite_fts5, so the test is skipped without it.
+func setupSearchTestDB(t *testing.T) *sql.DB {
+	db := setupMigratedTestDB(t)
+	if !hasFTS5(db) {
+		t.Skip("SQLite was built without FTS5; run the tests with -tags sqlite_fts5")
+	}
+	return db
+}
+
+func TestSearch(t *testing.T) {
+	db := setupSearchTestDB(t)
+
+	videoRepo := NewVideoRepo(db)
+	transcriptionRepo := NewTranscriptionRepository(db)
+	searchRepo := NewSearchRepository(db)
+
+	assert.NoError(t, videoRepo.CreateVideo(&entity.Video{Title: "Cooking pasta", Description: "Italian dinner", UserID: 1}))
+	assert.NoError(t, videoRepo.CreateVideo(&entity.Video{Title: "Hiking trip", Description: "Mountains", UserID: 1}))
+	assert.NoError(t, videoRepo.CreateVideo(&entity.Video{Title: "Pasta for two", Description: "", UserID: 2}))
+	assert.NoError(t, transcriptionRepo.CreateTranscription(&entity.Transcription{VideoID: 2, UserID: 1, Text: "we cooked pasta by the lake", Lang: "en"}))
+	assert.NoError(t, transcriptionRepo.CreateTranscription(&entity.Transcription{VideoID: 2, UserID: 1, Text: "chúng tôi nấu pasta", Lang: "vi"}))
+
+	results, err := searchRepo.Search(1, `"pasta"`, "", 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	kinds := map[string]int{}
+	for _, result := range results {
+		kinds[result.Kind]++
+		assert.Contains(t, result.Snippet, SnippetMatchStart+"pasta"+SnippetMatchEnd)
+		assert.NotEqual(t, uint64(3), result.VideoID) // Other users' videos are never returned
+	}
+	assert.Equal(t, map[string]int{entity.SearchKindVideo: 1, entity.SearchKindTranscription: 2}, kinds)
+
+	// The language filter only matches transcriptions
+	results, err = searchRepo.Search(1, `"pasta"`, "vi", 10, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, entity.SearchKindTranscription, results[0].Kind)
+		assert.Equal(t, "Hiking trip", results[0].VideoTitle)
+		assert.Equal(t, "vi", results[0].Lang)
+	}
+
+	// Diacritics are folded
+	results, err = searchRepo.Search(1, `"nau"`, "", 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	results, err = searchRepo.Search(1, `"pasta"`, "", 1, 1)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+}
+
+func TestSearch_IndexFollowsChanges(t *testing.T) {
+	db := setupSearchTestDB(t)
+
+	videoRepo := NewVideoRepo(db)
+	searchRepo := NewSearchRepository(db)
+
+	assert.NoError(t, videoRepo.CreateVideo(&entity.Video{Title: "Old title", UserID: 1}))
+
+	video, err := videoRepo.GetVideoByID(1)
+	assert.NoError(t, err)
+	video.Title = "Sunset timelapse"
+	assert.NoError(t, videoRepo.UpdateVideo(video))
+
+	results, err := searchRepo.Search(1, `"old"`, "", 10, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+
+	results, err = searchRepo.Search(1, `"sun"*`, "", 10, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.True(t, strings.HasPrefix(results[0].Snippet, SnippetMatchStart+"Sunset"))
+	}
+
+	assert.NoError(t, videoRepo.DeleteVideo(1))
+	results, err = searchRepo.Search(1, `"sunset"`, "", 10, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
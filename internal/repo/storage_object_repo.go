@@ -0,0 +1,237 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"mlvt/internal/entity"
+	"strings"
+	"time"
+)
+
+// StorageObjectRepository tracks the storage objects referenced by the database
+// and the outbox of objects to delete once their records are gone
+type StorageObjectRepository interface {
+	EnqueueDeletions(objects []entity.StoredObject, reason string) error
+	ListDueDeletions(now time.Time, limit int) ([]entity.StorageDeletion, error)
+	ListQueuedObjects() ([]entity.StoredObject, error)
+	CompleteDeletion(deletionID uint64) error
+	RetryDeletion(deletionID uint64, nextAttemptAt time.Time, lastError string) error
+	FailDeletion(deletionID uint64, lastError string) error
+	ListObjectReferences() ([]entity.ObjectReference, error)
+	IsObjectReferenced(folder, fileName string) (bool, error)
+}
+
+type storageObjectRepo struct {
+	db *sql.DB
+}
+
+func NewStorageObjectRepository(db *sql.DB) StorageObjectRepository {
+	return &storageObjectRepo{db: db}
+}
+
+const storageDeletionColumns = `id, folder, file_name, reason, status, attempts, last_error, next_attempt_at, created_at, updated_at`
+
+// objectReferencesQuery selects every row that points at a storage object, along with the object key.
+// Thumbnails and avatars may also hold external URLs, which are not storage objects.
+// Frames created before they had a folder and file name keep the full object key in file_name.
+const objectReferencesQuery = `
+	SELECT source, row_id, folder, file_name,
+	       CASE WHEN trim(folder, '/') = '' THEN file_name ELSE trim(folder, '/') || '/' || file_name END AS object_key
+	FROM (
+	    SELECT 'videos' AS source, id AS row_id, folder, file_name FROM videos
+	    UNION ALL SELECT 'videos', id, folder, image FROM videos WHERE image != '' AND image NOT LIKE '%://%'
+	    UNION ALL SELECT 'frames', id, folder, file_name FROM frames WHERE file_name != ''
+	    UNION ALL SELECT 'audios', id, folder, file_name FROM audios
+	    UNION ALL SELECT 'transcriptions', id, folder, file_name FROM transcriptions
+	    UNION ALL SELECT 'translations', id, output_folder, output_file_name FROM translations WHERE output_file_name != ''
+	    UNION ALL SELECT 'users', id, avatar_folder, avatar FROM users WHERE avatar != '' AND avatar NOT LIKE '%://%'
+	    UNION ALL SELECT 'video_uploads', id, folder, file_name FROM video_uploads WHERE status = 'pending'
+	)`
+
+// EnqueueDeletions queues objects to be deleted from storage
+func (r *storageObjectRepo) EnqueueDeletions(objects []entity.StoredObject, reason string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := enqueueStorageDeletions(tx, objects, reason); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListDueDeletions returns up to limit pending deletions whose next attempt is due, oldest first
+func (r *storageObjectRepo) ListDueDeletions(now time.Time, limit int) ([]entity.StorageDeletion, error) {
+	query := `SELECT ` + storageDeletionColumns + ` FROM storage_deletions
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at, id
+		LIMIT ?`
+	rows, err := r.db.Query(query, entity.StorageDeletionPending, now.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deletions []entity.StorageDeletion
+	for rows.Next() {
+		var deletion entity.StorageDeletion
+		if err := rows.Scan(&deletion.ID, &deletion.Folder, &deletion.FileName, &deletion.Reason, &deletion.Status,
+			&deletion.Attempts, &deletion.LastError, &deletion.NextAttemptAt, &deletion.CreatedAt, &deletion.UpdatedAt); err != nil {
+			return nil, err
+		}
+		deletions = append(deletions, deletion)
+	}
+	return deletions, rows.Err()
+}
+
+// ListQueuedObjects returns the objects that are still waiting to be deleted
+func (r *storageObjectRepo) ListQueuedObjects() ([]entity.StoredObject, error) {
+	rows, err := r.db.Query(`SELECT folder, file_name FROM storage_deletions WHERE status = ?`, entity.StorageDeletionPending)
+	if err != nil {
+		return nil, err
+	}
+	return scanStoredObjects(rows)
+}
+
+// CompleteDeletion removes a deletion from the outbox once its object is gone
+func (r *storageObjectRepo) CompleteDeletion(deletionID uint64) error {
+	return r.exec(deletionID, `DELETE FROM storage_deletions WHERE id = ?`, deletionID)
+}
+
+// RetryDeletion records a failed attempt and schedules the next one
+func (r *storageObjectRepo) RetryDeletion(deletionID uint64, nextAttemptAt time.Time, lastError string) error {
+	query := `UPDATE storage_deletions SET attempts = attempts + 1, last_error = ?, next_attempt_at = ?, updated_at = ? WHERE id = ?`
+	return r.exec(deletionID, query, lastError, nextAttemptAt.UTC(), time.Now().UTC(), deletionID)
+}
+
+// FailDeletion records the last failed attempt and stops retrying the deletion
+func (r *storageObjectRepo) FailDeletion(deletionID uint64, lastError string) error {
+	query := `UPDATE storage_deletions SET status = ?, attempts = attempts + 1, last_error = ?, updated_at = ? WHERE id = ?`
+	return r.exec(deletionID, query, entity.StorageDeletionFailed, lastError, time.Now().UTC(), deletionID)
+}
+
+// ListObjectReferences returns every row that points at a storage object
+func (r *storageObjectRepo) ListObjectReferences() ([]entity.ObjectReference, error) {
+	rows, err := r.db.Query(objectReferencesQuery + ` ORDER BY source, row_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list object references: %v", err)
+	}
+	defer rows.Close()
+
+	var references []entity.ObjectReference
+	for rows.Next() {
+		var reference entity.ObjectReference
+		var key string
+		if err := rows.Scan(&reference.Table, &reference.RowID, &reference.Folder, &reference.FileName, &key); err != nil {
+			return nil, err
+		}
+		references = append(references, reference)
+	}
+	return references, rows.Err()
+}
+
+// IsObjectReferenced reports whether any row points at the object, e.g. a new video uploaded under
+// the name of a deleted one
+func (r *storageObjectRepo) IsObjectReferenced(folder, fileName string) (bool, error) {
+	key := fileName
+	if folder = strings.Trim(folder, "/"); folder != "" {
+		key = folder + "/" + fileName
+	}
+
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM (`+objectReferencesQuery+`) WHERE object_key = ?`, key).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up object references: %v", err)
+	}
+	return count > 0, nil
+}
+
+// exec runs a statement against a single deletion and reports a missing deletion as an error
+func (r *storageObjectRepo) exec(deletionID uint64, query string, args ...interface{}) error {
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update storage deletion: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no storage deletion found with id %d", deletionID)
+	}
+	return nil
+}
+
+// cascadeDelete deletes a record and the rows that depend on it in one transaction, and queues the
+// objects they referenced for deletion from storage. objectsQuery selects (folder, file_name) pairs;
+// every query refers to the record ID as ?1. It returns the rows affected by the last statement.
+func cascadeDelete(db *sql.DB, reason string, id uint64, objectsQuery string, statements ...string) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(objectsQuery, id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list storage objects: %v", err)
+	}
+	objects, err := scanStoredObjects(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	var rowsAffected int64
+	for _, statement := range statements {
+		result, err := tx.Exec(statement, id)
+		if err != nil {
+			return 0, err
+		}
+		if rowsAffected, err = result.RowsAffected(); err != nil {
+			return 0, fmt.Errorf("failed to retrieve rows affected: %v", err)
+		}
+	}
+
+	if err := enqueueStorageDeletions(tx, objects, reason); err != nil {
+		return 0, err
+	}
+	return rowsAffected, tx.Commit()
+}
+
+// enqueueStorageDeletions queues objects for deletion as part of tx, skipping duplicates
+func enqueueStorageDeletions(tx *sql.Tx, objects []entity.StoredObject, reason string) error {
+	now := time.Now().UTC()
+	seen := make(map[entity.StoredObject]bool, len(objects))
+	for _, object := range objects {
+		if object.FileName == "" || seen[object] {
+			continue
+		}
+		seen[object] = true
+
+		_, err := tx.Exec(`
+			INSERT INTO storage_deletions (folder, file_name, reason, status, attempts, last_error, next_attempt_at, created_at, updated_at)
+			VALUES (?, ?, ?, ?, 0, '', ?, ?, ?)`,
+			object.Folder, object.FileName, reason, entity.StorageDeletionPending, now, now, now)
+		if err != nil {
+			return fmt.Errorf("failed to queue storage deletion: %v", err)
+		}
+	}
+	return nil
+}
+
+func scanStoredObjects(rows *sql.Rows) ([]entity.StoredObject, error) {
+	defer rows.Close()
+
+	var objects []entity.StoredObject
+	for rows.Next() {
+		var object entity.StoredObject
+		if err := rows.Scan(&object.Folder, &object.FileName); err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+	return objects, rows.Err()
+}
@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockStorageObjectRepository mocks the StorageObjectRepository interface
+type MockStorageObjectRepository struct {
+	mock.Mock
+}
+
+func (m *MockStorageObjectRepository) EnqueueDeletions(objects []entity.StoredObject, reason string) error {
+	args := m.Called(objects, reason)
+	return args.Error(0)
+}
+
+func (m *MockStorageObjectRepository) ListDueDeletions(now time.Time, limit int) ([]entity.StorageDeletion, error) {
+	args := m.Called(now, limit)
+	deletions, _ := args.Get(0).([]entity.StorageDeletion)
+	return deletions, args.Error(1)
+}
+
+func (m *MockStorageObjectRepository) ListQueuedObjects() ([]entity.StoredObject, error) {
+	args := m.Called()
+	objects, _ := args.Get(0).([]entity.StoredObject)
+	return objects, args.Error(1)
+}
+
+func (m *MockStorageObjectRepository) CompleteDeletion(deletionID uint64) error {
+	args := m.Called(deletionID)
+	return args.Error(0)
+}
+
+func (m *MockStorageObjectRepository) RetryDeletion(deletionID uint64, nextAttemptAt time.Time, lastError string) error {
+	args := m.Called(deletionID, nextAttemptAt, lastError)
+	return args.Error(0)
+}
+
+func (m *MockStorageObjectRepository) FailDeletion(deletionID uint64, lastError string) error {
+	args := m.Called(deletionID, lastError)
+	return args.Error(0)
+}
+
+func (m *MockStorageObjectRepository) ListObjectReferences() ([]entity.ObjectReference, error) {
+	args := m.Called()
+	references, _ := args.Get(0).([]entity.ObjectReference)
+	return references, args.Error(1)
+}
+
+func (m *MockStorageObjectRepository) IsObjectReferenced(folder, fileName string) (bool, error) {
+	args := m.Called(folder, fileName)
+	return args.Bool(0), args.Error(1)
+}
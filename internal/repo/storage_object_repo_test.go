@@ -0,0 +1,194 @@
+package repo
+
+import (
+	"database/sql"
+	"mlvt/internal/entity"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func countRows(t *testing.T, db *sql.DB, table string) int {
+	var count int
+	assert.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM `+table).Scan(&count))
+	return count
+}
+
+func queuedObjects(t *testing.T, repo StorageObjectRepository) []entity.StoredObject {
+	objects, err := repo.ListQueuedObjects()
+	assert.NoError(t, err)
+	return objects
+}
+
+func TestDeleteVideo_CascadesToStorage(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	videoRepo := NewVideoRepo(db)
+	storageRepo := NewStorageObjectRepository(db)
+
+	assert.NoError(t, videoRepo.CreateVideo(&entity.Video{Title: "a", FileName: "a.mp4", Folder: "videos", Image: "a.jpg", UserID: 1}))
+	assert.NoError(t, videoRepo.CreateVideo(&entity.Video{Title: "b", FileName: "b.mp4", Folder: "videos", Image: "https://example.com/b.jpg", UserID: 1}))
+	_, err := db.Exec(`
+	INSERT INTO frames (video_id, link, folder, file_name) VALUES (1, 'frames/a-1.jpg', 'frames', 'a-1.jpg'), (2, 'frames/b-1.jpg', 'frames', 'b-1.jpg');
+	INSERT INTO audios (video_id, user_id, duration, lang, folder, file_name) VALUES (1, 1, 10, 'vi', 'audios', 'a.mp3');
+	INSERT INTO transcriptions (video_id, user_id, text, lang, folder, file_name) VALUES (1, 1, '', 'en', 'transcriptions', 'a.srt');
+	INSERT INTO transcription_segments (transcription_id, position, start_ms, end_ms, text) VALUES (1, 0, 0, 1000, 'hello');
+	INSERT INTO translations (video_id, user_id, source_lang, target_lang, output_folder, output_file_name) VALUES (1, 1, 'en', 'vi', 'videos', 'a.vi.mp4');
+	INSERT INTO jobs (video_id, max_attempts, run_at) VALUES (1, 3, CURRENT_TIMESTAMP);
+	INSERT INTO video_uploads (user_id, title, folder, file_name, content_type, size, status, video_id, expires_at)
+	VALUES (1, 'a', 'videos', 'a.mp4', 'video/mp4', 1, 'completed', 1, CURRENT_TIMESTAMP);`)
+	assert.NoError(t, err)
+
+	assert.NoError(t, videoRepo.DeleteVideo(1))
+
+	assert.ElementsMatch(t, []entity.StoredObject{
+		{Folder: "videos", FileName: "a.mp4"},
+		{Folder: "videos", FileName: "a.jpg"},
+		{Folder: "frames", FileName: "a-1.jpg"},
+		{Folder: "audios", FileName: "a.mp3"},
+		{Folder: "transcriptions", FileName: "a.srt"},
+		{Folder: "videos", FileName: "a.vi.mp4"},
+	}, queuedObjects(t, storageRepo))
+
+	for table, count := range map[string]int{
+		"videos": 1, "frames": 1, "audios": 0, "transcriptions": 0, "transcription_segments": 0, "translations": 0, "jobs": 0,
+	} {
+		assert.Equal(t, count, countRows(t, db, table), table)
+	}
+	var uploadVideoID sql.NullInt64
+	assert.NoError(t, db.QueryRow(`SELECT video_id FROM video_uploads`).Scan(&uploadVideoID))
+	assert.False(t, uploadVideoID.Valid)
+
+	// Deleting a missing video queues nothing
+	assert.ErrorIs(t, videoRepo.DeleteVideo(1), ErrVideoNotFound)
+	assert.Len(t, queuedObjects(t, storageRepo), 6)
+}
+
+func TestAudioMediaInfo(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	audioRepo := NewAudioRepository(db)
+	audio := &entity.Audio{VideoID: 1, UserID: 1, Duration: 10, Lang: "vi", Folder: "audios", FileName: "a.mp3"}
+	assert.NoError(t, audioRepo.CreateAudio(audio))
+	assert.Equal(t, uint64(1), audio.ID)
+
+	unprobed, err := audioRepo.GetAudioByID(audio.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, unprobed.ProbedAt)
+
+	probedAt := time.Now()
+	info := entity.MediaInfo{AudioCodec: "mp3", Bitrate: 128000, AudioChannels: 2, SampleRate: 44100, ProbedAt: &probedAt}
+	assert.NoError(t, audioRepo.UpdateAudioMediaInfo(audio.ID, 42, info))
+
+	audios, _, err := audioRepo.ListAudiosByVideoID(1, entity.ListOptions{})
+	assert.NoError(t, err)
+	if assert.Len(t, audios, 1) {
+		assert.Equal(t, 42, audios[0].Duration)
+		assert.Equal(t, "mp3", audios[0].AudioCodec)
+		assert.Equal(t, 44100, audios[0].SampleRate)
+		assert.NotNil(t, audios[0].ProbedAt)
+	}
+
+	assert.Error(t, audioRepo.UpdateAudioMediaInfo(99, 1, info))
+}
+
+func TestDeleteAudioAndTranscription_CascadeToStorage(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	storageRepo := NewStorageObjectRepository(db)
+	_, err := db.Exec(`
+	INSERT INTO audios (video_id, user_id, duration, lang, folder, file_name) VALUES (1, 1, 10, 'vi', 'audios', 'a.mp3');
+	INSERT INTO transcriptions (video_id, user_id, text, lang, folder, file_name) VALUES (1, 1, '', 'en', 'transcriptions', 'a.srt');
+	INSERT INTO transcription_segments (transcription_id, position, start_ms, end_ms, text) VALUES (1, 0, 0, 1000, 'hello');
+	INSERT INTO translations (video_id, user_id, source_lang, target_lang, transcription_id, audio_id, created_at, updated_at)
+	VALUES (1, 1, 'en', 'vi', 1, 1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);`)
+	assert.NoError(t, err)
+
+	assert.NoError(t, NewAudioRepository(db).DeleteAudioByID(1))
+	assert.NoError(t, NewTranscriptionRepository(db).DeleteTranscription(1))
+
+	assert.ElementsMatch(t, []entity.StoredObject{
+		{Folder: "audios", FileName: "a.mp3"},
+		{Folder: "transcriptions", FileName: "a.srt"},
+	}, queuedObjects(t, storageRepo))
+	assert.Equal(t, 0, countRows(t, db, "transcription_segments"))
+
+	translation, err := NewTranslationRepository(db).GetTranslationByID(1)
+	assert.NoError(t, err)
+	assert.Nil(t, translation.TranscriptionID)
+	assert.Nil(t, translation.AudioID)
+}
+
+func TestStorageDeletionLifecycle(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	storageRepo := NewStorageObjectRepository(db)
+	assert.NoError(t, storageRepo.EnqueueDeletions([]entity.StoredObject{
+		{Folder: "videos", FileName: "a.mp4"},
+		{Folder: "videos", FileName: "a.mp4"},
+		{Folder: "videos", FileName: "b.mp4"},
+		{Folder: "videos"},
+	}, "orphan"))
+
+	now := time.Now()
+	due, err := storageRepo.ListDueDeletions(now, 10)
+	assert.NoError(t, err)
+	assert.Len(t, due, 2)
+	assert.Equal(t, "orphan", due[0].Reason)
+	assert.Equal(t, entity.StorageDeletionPending, due[0].Status)
+
+	assert.NoError(t, storageRepo.CompleteDeletion(due[0].ID))
+	assert.NoError(t, storageRepo.RetryDeletion(due[1].ID, now.Add(time.Minute), "timeout"))
+
+	due, err = storageRepo.ListDueDeletions(now, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, due)
+
+	due, err = storageRepo.ListDueDeletions(now.Add(2*time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Len(t, due, 1)
+	assert.Equal(t, 1, due[0].Attempts)
+	assert.Equal(t, "timeout", due[0].LastError)
+
+	assert.NoError(t, storageRepo.FailDeletion(due[0].ID, "access denied"))
+	assert.Empty(t, queuedObjects(t, storageRepo))
+	assert.Error(t, storageRepo.CompleteDeletion(42))
+}
+
+func TestListObjectReferences(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	_, err := db.Exec(`
+	INSERT INTO videos (title, duration, file_name, folder, image, user_id) VALUES ('a', 10, 'a.mp4', 'videos/', 'a.jpg', 1);
+	INSERT INTO users (first_name, last_name, username, email, password, status, avatar, avatar_folder)
+	VALUES ('A', 'A', 'a', 'a@example.com', 'hash', 1, 'me.png', 'avatars'), ('B', 'B', 'b', 'b@example.com', 'hash', 1, 'https://example.com/me.png', '');
+	INSERT INTO frames (video_id, link, file_name) VALUES (1, 'frames/a-1.jpg', 'frames/a-1.jpg');
+	INSERT INTO video_uploads (user_id, title, folder, file_name, content_type, size, status, expires_at)
+	VALUES (1, 'c', 'videos', 'c.mp4', 'video/mp4', 1, 'pending', CURRENT_TIMESTAMP), (1, 'd', 'videos', 'd.mp4', 'video/mp4', 1, 'expired', CURRENT_TIMESTAMP);`)
+	assert.NoError(t, err)
+
+	storageRepo := NewStorageObjectRepository(db)
+	references, err := storageRepo.ListObjectReferences()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []entity.ObjectReference{
+		{Table: "videos", RowID: 1, Folder: "videos/", FileName: "a.mp4"},
+		{Table: "videos", RowID: 1, Folder: "videos/", FileName: "a.jpg"},
+		{Table: "frames", RowID: 1, FileName: "frames/a-1.jpg"},
+		{Table: "users", RowID: 1, Folder: "avatars", FileName: "me.png"},
+		{Table: "video_uploads", RowID: 1, Folder: "videos", FileName: "c.mp4"},
+	}, references)
+
+	for _, object := range []entity.StoredObject{
+		{Folder: "videos", FileName: "a.mp4"}, {Folder: "/videos", FileName: "a.jpg"},
+		{Folder: "frames", FileName: "a-1.jpg"}, {Folder: "videos", FileName: "c.mp4"},
+	} {
+		referenced, err := storageRepo.IsObjectReferenced(object.Folder, object.FileName)
+		assert.NoError(t, err)
+		assert.True(t, referenced, object)
+	}
+	referenced, err := storageRepo.IsObjectReferenced("videos", "d.mp4")
+	assert.NoError(t, err)
+	assert.False(t, referenced)
+}
@@ -0,0 +1,58 @@
+package repo
+
+import (
+	"database/sql"
+	"testing"
+
+	"mlvt/cmd/migration"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+// setupMigratedTestDB opens an in-memory database with the schema built by migration.Migrate,
+// so the repository tests run against the same tables as the server.
+// Without -tags sqlite_fts5 the search index migration is recorded as applied instead of run.
+func setupMigratedTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	// Every connection to ":memory:" opens a separate database
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if !hasFTS5(db) {
+		_, err = db.Exec(`
+		CREATE TABLE migrations (name TEXT NOT NULL UNIQUE);
+		INSERT INTO migrations (name) VALUES ('create_search_index');`)
+		require.NoError(t, err)
+	}
+	require.NoError(t, migration.Migrate(db))
+
+	// Migrate also inserts sample users and videos; start every test from empty tables
+	_, err = db.Exec(`
+	DELETE FROM videos;
+	DELETE FROM users;
+	DELETE FROM sqlite_sequence;`)
+	require.NoError(t, err)
+	return db
+}
+
+// hasFTS5 reports whether SQLite was built with FTS5.
+func hasFTS5(db *sql.DB) bool {
+	if _, err := db.Exec(`CREATE VIRTUAL TABLE temp.fts5_probe USING fts5(text)`); err != nil {
+		return false
+	}
+	_, _ = db.Exec(`DROP TABLE temp.fts5_probe`)
+	return true
+}
+
+// insertTestUser adds an active user with the given username and returns its ID.
+func insertTestUser(t *testing.T, db *sql.DB, username string) uint64 {
+	result, err := db.Exec(`
+	INSERT INTO users (first_name, last_name, username, email, password, status)
+	VALUES ('Test', 'User', ?, ?, 'hash', 1)`, username, username+"@example.com")
+	require.NoError(t, err)
+	id, err := result.LastInsertId()
+	require.NoError(t, err)
+	return uint64(id)
+}
@@ -9,6 +9,7 @@ import (
 // TransactionLogRepo is responsible for logging transaction events to the database
 type TransactionLogRepo interface {
 	LogTransaction(log *entity.TransactionLog) error
+	ListTransactionLogsByOrderID(orderID string) ([]entity.TransactionLog, error)
 }
 
 type transactionLogRepo struct {
@@ -27,8 +28,31 @@ func (r *transactionLogRepo) LogTransaction(log *entity.TransactionLog) error {
 
 	_, err := r.db.Exec(query, log.OrderID, log.PaymentMethod, log.Action, log.Status, log.Details)
 	if err != nil {
-		fmt.Errorf("Error logging transaction: %v", err)
-		return err
+		return fmt.Errorf("error logging transaction: %v", err)
 	}
 	return nil
 }
+
+// ListTransactionLogsByOrderID returns every entry logged for the order, oldest first
+func (r *transactionLogRepo) ListTransactionLogsByOrderID(orderID string) ([]entity.TransactionLog, error) {
+	query := `SELECT id, order_id, payment_method, action, status, details, created_at
+              FROM transaction_logs WHERE order_id = ? ORDER BY id`
+
+	rows, err := r.db.Query(query, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transaction logs: %v", err)
+	}
+	defer rows.Close()
+
+	var logs []entity.TransactionLog
+	for rows.Next() {
+		var log entity.TransactionLog
+		var details sql.NullString
+		if err := rows.Scan(&log.ID, &log.OrderID, &log.PaymentMethod, &log.Action, &log.Status, &details, &log.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction log: %v", err)
+		}
+		log.Details = details.String
+		logs = append(logs, log)
+	}
+	return logs, rows.Err()
+}
@@ -0,0 +1,23 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTransactionLogRepo is a mock implementation of TransactionLogRepo
+type MockTransactionLogRepo struct {
+	mock.Mock
+}
+
+func (m *MockTransactionLogRepo) LogTransaction(log *entity.TransactionLog) error {
+	args := m.Called(log)
+	return args.Error(0)
+}
+
+func (m *MockTransactionLogRepo) ListTransactionLogsByOrderID(orderID string) ([]entity.TransactionLog, error) {
+	args := m.Called(orderID)
+	logs, _ := args.Get(0).([]entity.TransactionLog)
+	return logs, args.Error(1)
+}
@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionLogsByOrder(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	repo := NewTransactionLogRepo(db)
+
+	for _, log := range []entity.TransactionLog{
+		{OrderID: "order-1", PaymentMethod: entity.PaymentMethodMoMo, Action: entity.TransactionActionCreate, Status: entity.TransactionStatusSucceeded, Details: `{"path":"/v2/gateway/api/create"}`},
+		{OrderID: "order-2", PaymentMethod: entity.PaymentMethodMoMo, Action: entity.TransactionActionCreate, Status: entity.TransactionStatusFailed},
+		{OrderID: "order-1", PaymentMethod: entity.PaymentMethodMoMo, Action: entity.TransactionActionIPN, Status: entity.TransactionStatusSucceeded},
+		{OrderID: "order-1", PaymentMethod: entity.PaymentMethodMoMo, Action: entity.TransactionActionIPN, Status: entity.TransactionStatusSucceeded},
+	} {
+		log := log
+		assert.NoError(t, repo.LogTransaction(&log))
+	}
+
+	// Repeated events of an order are all kept, oldest first
+	logs, err := repo.ListTransactionLogsByOrderID("order-1")
+	assert.NoError(t, err)
+	if assert.Len(t, logs, 3) {
+		assert.Equal(t, entity.TransactionActionCreate, logs[0].Action)
+		assert.Equal(t, `{"path":"/v2/gateway/api/create"}`, logs[0].Details)
+		assert.Equal(t, entity.TransactionActionIPN, logs[2].Action)
+		assert.False(t, logs[2].CreatedAt.IsZero())
+	}
+
+	logs, err = repo.ListTransactionLogsByOrderID("order-3")
+	assert.NoError(t, err)
+	assert.Empty(t, logs)
+}
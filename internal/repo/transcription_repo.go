@@ -2,6 +2,7 @@ package repo
 
 import (
 	"database/sql"
+	"fmt"
 	"mlvt/internal/entity"
 	"time"
 )
@@ -11,8 +12,8 @@ type TranscriptionRepository interface {
 	GetTranscriptionByID(transcriptionID uint64) (*entity.Transcription, error)
 	GetTranscriptionByIDAndUserID(transcriptionID, userID uint64) (*entity.Transcription, error)
 	GetTranscriptionByIDAndVideoID(transcriptionID, videoID uint64) (*entity.Transcription, error)
-	ListTranscriptionsByUserID(userID uint64) ([]entity.Transcription, error)
-	ListTranscriptionsByVideoID(videoID uint64) ([]entity.Transcription, error)
+	ListTranscriptionsByUserID(userID uint64, opts entity.ListOptions) ([]entity.Transcription, string, error)
+	ListTranscriptionsByVideoID(videoID uint64, opts entity.ListOptions) ([]entity.Transcription, string, error)
 	DeleteTranscription(transcriptionID uint64) error
 }
 
@@ -29,7 +30,7 @@ func (r *transcriptionRepo) CreateTranscription(transcription *entity.Transcript
 	query := `
 		INSERT INTO transcriptions (video_id, user_id, text, lang, folder, file_name, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-	now := time.Now()
+	now := time.Now().UTC()
 	_, err := r.db.Exec(query, transcription.VideoID, transcription.UserID, transcription.Text,
 		transcription.Lang, transcription.Folder, transcription.FileName, now, now)
 	return err
@@ -77,35 +78,47 @@ func (r *transcriptionRepo) GetTranscriptionByIDAndVideoID(transcriptionID, vide
 	return transcription, err
 }
 
-// ListTranscriptionsByUserID lists all transcriptions for a specific user
-func (r *transcriptionRepo) ListTranscriptionsByUserID(userID uint64) ([]entity.Transcription, error) {
+// transcriptionListSpec lists the sort fields and filters supported by transcription lists
+var transcriptionListSpec = listSpec{
+	sortable: map[string]sortColumn{
+		entity.SortByCreatedAt: {column: "created_at", kind: sortTime},
+	},
+	langColumn: "lang",
+}
+
+// ListTranscriptionsByUserID lists one page of the transcriptions for a specific user and returns the cursor of the next page
+func (r *transcriptionRepo) ListTranscriptionsByUserID(userID uint64, opts entity.ListOptions) ([]entity.Transcription, string, error) {
 	query := `SELECT id, video_id, user_id, text, lang, folder, file_name, created_at, updated_at
 	          FROM transcriptions WHERE user_id = ?`
-	rows, err := r.db.Query(query, userID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var transcriptions []entity.Transcription
-	for rows.Next() {
-		var transcription entity.Transcription
-		if err := rows.Scan(&transcription.ID, &transcription.VideoID, &transcription.UserID, &transcription.Text,
-			&transcription.Lang, &transcription.Folder, &transcription.FileName, &transcription.CreatedAt, &transcription.UpdatedAt); err != nil {
-			return nil, err
-		}
-		transcriptions = append(transcriptions, transcription)
-	}
-	return transcriptions, nil
+	return r.listTranscriptions(query, userID, opts)
 }
 
-// ListTranscriptionsByVideoID lists all transcriptions for a specific video
-func (r *transcriptionRepo) ListTranscriptionsByVideoID(videoID uint64) ([]entity.Transcription, error) {
+// ListTranscriptionsByVideoID lists one page of the transcriptions for a specific video and returns the cursor of the next page
+func (r *transcriptionRepo) ListTranscriptionsByVideoID(videoID uint64, opts entity.ListOptions) ([]entity.Transcription, string, error) {
 	query := `SELECT id, video_id, user_id, text, lang, folder, file_name, created_at, updated_at
 	          FROM transcriptions WHERE video_id = ?`
-	rows, err := r.db.Query(query, videoID)
+	return r.listTranscriptions(query, videoID, opts)
+}
+
+// DeleteTranscription deletes a transcription and its segments by its ID, and queues its file for deletion from storage
+func (r *transcriptionRepo) DeleteTranscription(transcriptionID uint64) error {
+	_, err := cascadeDelete(r.db, fmt.Sprintf("transcription %d", transcriptionID), transcriptionID,
+		`SELECT folder, file_name FROM transcriptions WHERE id = ?1`,
+		`DELETE FROM transcription_segments WHERE transcription_id = ?1`,
+		`UPDATE translations SET transcription_id = NULL WHERE transcription_id = ?1`,
+		`DELETE FROM transcriptions WHERE id = ?1`)
+	return err
+}
+
+func (r *transcriptionRepo) listTranscriptions(query string, scopeID uint64, opts entity.ListOptions) ([]entity.Transcription, string, error) {
+	page, err := buildPageQuery(query, []interface{}{scopeID}, opts, transcriptionListSpec)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	rows, err := r.db.Query(page.query, page.args...)
+	if err != nil {
+		return nil, "", err
 	}
 	defer rows.Close()
 
@@ -114,16 +127,18 @@ func (r *transcriptionRepo) ListTranscriptionsByVideoID(videoID uint64) ([]entit
 		var transcription entity.Transcription
 		if err := rows.Scan(&transcription.ID, &transcription.VideoID, &transcription.UserID, &transcription.Text,
 			&transcription.Lang, &transcription.Folder, &transcription.FileName, &transcription.CreatedAt, &transcription.UpdatedAt); err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		transcriptions = append(transcriptions, transcription)
 	}
-	return transcriptions, nil
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	transcriptions, nextCursor := trimPage(page, transcriptions, transcriptionSortKey)
+	return transcriptions, nextCursor, nil
 }
 
-// DeleteTranscription deletes a transcription by its ID
-func (r *transcriptionRepo) DeleteTranscription(transcriptionID uint64) error {
-	query := "DELETE FROM transcriptions WHERE id = ?"
-	_, err := r.db.Exec(query, transcriptionID)
-	return err
+func transcriptionSortKey(transcription entity.Transcription, _ string) (interface{}, uint64) {
+	return transcription.CreatedAt, transcription.ID
 }
@@ -0,0 +1,62 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTranscriptionRepository mocks the TranscriptionRepository interface
+type MockTranscriptionRepository struct {
+	mock.Mock
+}
+
+func (m *MockTranscriptionRepository) CreateTranscription(transcription *entity.Transcription) error {
+	args := m.Called(transcription)
+	return args.Error(0)
+}
+
+func (m *MockTranscriptionRepository) GetTranscriptionByID(transcriptionID uint64) (*entity.Transcription, error) {
+	args := m.Called(transcriptionID)
+	if transcription, ok := args.Get(0).(*entity.Transcription); ok {
+		return transcription, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTranscriptionRepository) GetTranscriptionByIDAndUserID(transcriptionID, userID uint64) (*entity.Transcription, error) {
+	args := m.Called(transcriptionID, userID)
+	if transcription, ok := args.Get(0).(*entity.Transcription); ok {
+		return transcription, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTranscriptionRepository) GetTranscriptionByIDAndVideoID(transcriptionID, videoID uint64) (*entity.Transcription, error) {
+	args := m.Called(transcriptionID, videoID)
+	if transcription, ok := args.Get(0).(*entity.Transcription); ok {
+		return transcription, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTranscriptionRepository) ListTranscriptionsByUserID(userID uint64, opts entity.ListOptions) ([]entity.Transcription, string, error) {
+	args := m.Called(userID, opts)
+	if transcriptions, ok := args.Get(0).([]entity.Transcription); ok {
+		return transcriptions, args.String(1), args.Error(2)
+	}
+	return nil, args.String(1), args.Error(2)
+}
+
+func (m *MockTranscriptionRepository) ListTranscriptionsByVideoID(videoID uint64, opts entity.ListOptions) ([]entity.Transcription, string, error) {
+	args := m.Called(videoID, opts)
+	if transcriptions, ok := args.Get(0).([]entity.Transcription); ok {
+		return transcriptions, args.String(1), args.Error(2)
+	}
+	return nil, args.String(1), args.Error(2)
+}
+
+func (m *MockTranscriptionRepository) DeleteTranscription(transcriptionID uint64) error {
+	args := m.Called(transcriptionID)
+	return args.Error(0)
+}
@@ -0,0 +1,130 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"mlvt/internal/entity"
+	"strings"
+	"time"
+)
+
+// TranscriptionSegmentRepository stores the timed segments of transcriptions.
+// Writing segments also rewrites the transcription's text as the segment texts in order,
+// so the text, and the search index built on it, always match the segments.
+type TranscriptionSegmentRepository interface {
+	ListSegments(transcriptionID uint64) ([]entity.TranscriptionSegment, error)
+	AppendSegments(transcriptionID uint64, segments []entity.TranscriptionSegment) ([]entity.TranscriptionSegment, error)
+	ReplaceSegments(transcriptionID uint64, segments []entity.TranscriptionSegment) ([]entity.TranscriptionSegment, error)
+}
+
+type transcriptionSegmentRepo struct {
+	db *sql.DB
+}
+
+func NewTranscriptionSegmentRepository(db *sql.DB) TranscriptionSegmentRepository {
+	return &transcriptionSegmentRepo{db: db}
+}
+
+// ListSegments returns the segments of a transcription in order
+func (r *transcriptionSegmentRepo) ListSegments(transcriptionID uint64) ([]entity.TranscriptionSegment, error) {
+	return listSegments(r.db, transcriptionID)
+}
+
+// AppendSegments adds segments after the transcription's existing ones and returns all of its segments
+func (r *transcriptionSegmentRepo) AppendSegments(transcriptionID uint64, segments []entity.TranscriptionSegment) ([]entity.TranscriptionSegment, error) {
+	return r.writeSegments(transcriptionID, segments, false)
+}
+
+// ReplaceSegments swaps all segments of the transcription for the given ones and returns them
+func (r *transcriptionSegmentRepo) ReplaceSegments(transcriptionID uint64, segments []entity.TranscriptionSegment) ([]entity.TranscriptionSegment, error) {
+	return r.writeSegments(transcriptionID, segments, true)
+}
+
+func (r *transcriptionSegmentRepo) writeSegments(transcriptionID uint64, segments []entity.TranscriptionSegment, replace bool) ([]entity.TranscriptionSegment, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	position := 0
+	if replace {
+		if _, err := tx.Exec(`DELETE FROM transcription_segments WHERE transcription_id = ?`, transcriptionID); err != nil {
+			return nil, fmt.Errorf("failed to delete transcription segments: %v", err)
+		}
+	} else {
+		err := tx.QueryRow(`SELECT COALESCE(MAX(position) + 1, 0) FROM transcription_segments WHERE transcription_id = ?`,
+			transcriptionID).Scan(&position)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	query := `
+		INSERT INTO transcription_segments (transcription_id, position, start_ms, end_ms, speaker, text, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	now := time.Now()
+	for _, segment := range segments {
+		_, err := tx.Exec(query, transcriptionID, position, segment.StartMs, segment.EndMs, segment.Speaker, segment.Text, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create transcription segment: %v", err)
+		}
+		position++
+	}
+
+	all, err := listSegments(tx, transcriptionID)
+	if err != nil {
+		return nil, err
+	}
+	texts := make([]string, 0, len(all))
+	for _, segment := range all {
+		texts = append(texts, segment.Text)
+	}
+
+	result, err := tx.Exec(`UPDATE transcriptions SET text = ?, updated_at = ? WHERE id = ?`,
+		strings.Join(texts, "\n"), now, transcriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update transcription text: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("no transcription found with id %d", transcriptionID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func listSegments(q queryer, transcriptionID uint64) ([]entity.TranscriptionSegment, error) {
+	query := `
+		SELECT id, transcription_id, position, start_ms, end_ms, speaker, text, created_at
+		FROM transcription_segments
+		WHERE transcription_id = ?
+		ORDER BY position`
+	rows, err := q.Query(query, transcriptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var segments []entity.TranscriptionSegment
+	for rows.Next() {
+		var segment entity.TranscriptionSegment
+		if err := rows.Scan(&segment.ID, &segment.TranscriptionID, &segment.Position, &segment.StartMs, &segment.EndMs,
+			&segment.Speaker, &segment.Text, &segment.CreatedAt); err != nil {
+			return nil, err
+		}
+		segments = append(segments, segment)
+	}
+	return segments, rows.Err()
+}
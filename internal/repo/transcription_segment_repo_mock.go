@@ -0,0 +1,30 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTranscriptionSegmentRepository mocks the TranscriptionSegmentRepository interface
+type MockTranscriptionSegmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockTranscriptionSegmentRepository) ListSegments(transcriptionID uint64) ([]entity.TranscriptionSegment, error) {
+	args := m.Called(transcriptionID)
+	segments, _ := args.Get(0).([]entity.TranscriptionSegment)
+	return segments, args.Error(1)
+}
+
+func (m *MockTranscriptionSegmentRepository) AppendSegments(transcriptionID uint64, segments []entity.TranscriptionSegment) ([]entity.TranscriptionSegment, error) {
+	args := m.Called(transcriptionID, segments)
+	all, _ := args.Get(0).([]entity.TranscriptionSegment)
+	return all, args.Error(1)
+}
+
+func (m *MockTranscriptionSegmentRepository) ReplaceSegments(transcriptionID uint64, segments []entity.TranscriptionSegment) ([]entity.TranscriptionSegment, error) {
+	args := m.Called(transcriptionID, segments)
+	all, _ := args.Get(0).([]entity.TranscriptionSegment)
+	return all, args.Error(1)
+}
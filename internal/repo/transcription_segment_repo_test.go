@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"database/sql"
+	"mlvt/internal/entity"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func transcriptionText(t *testing.T, db *sql.DB, transcriptionID uint64) string {
+	var text string
+	assert.NoError(t, db.QueryRow(`SELECT text FROM transcriptions WHERE id = ?`, transcriptionID).Scan(&text))
+	return text
+}
+
+func TestAppendAndReplaceSegments(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	_, err := db.Exec(`INSERT INTO transcriptions (video_id, user_id, text, lang, folder, file_name) VALUES (1, 1, 'old text', 'en', 'f', 'a.json')`)
+	assert.NoError(t, err)
+
+	segmentRepo := NewTranscriptionSegmentRepository(db)
+
+	segments, err := segmentRepo.AppendSegments(1, []entity.TranscriptionSegment{
+		{StartMs: 0, EndMs: 1000, Speaker: "Alice", Text: "Hello"},
+		{StartMs: 1000, EndMs: 2000, Text: "world"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, segments, 2)
+
+	// Appended segments continue after the existing positions
+	segments, err = segmentRepo.AppendSegments(1, []entity.TranscriptionSegment{{StartMs: 2000, EndMs: 2500, Text: "again"}})
+	assert.NoError(t, err)
+	assert.Len(t, segments, 3)
+	assert.Equal(t, []int{0, 1, 2}, []int{segments[0].Position, segments[1].Position, segments[2].Position})
+	assert.Equal(t, "Alice", segments[0].Speaker)
+	assert.Equal(t, "Hello\nworld\nagain", transcriptionText(t, db, 1))
+
+	segments, err = segmentRepo.ReplaceSegments(1, []entity.TranscriptionSegment{{StartMs: 500, EndMs: 900, Text: "Replaced"}})
+	assert.NoError(t, err)
+	assert.Len(t, segments, 1)
+	assert.Equal(t, 0, segments[0].Position)
+	assert.Equal(t, "Replaced", transcriptionText(t, db, 1))
+
+	listed, err := segmentRepo.ListSegments(1)
+	assert.NoError(t, err)
+	assert.Equal(t, segments, listed)
+}
+
+func TestWriteSegments_TranscriptionNotFound(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	segmentRepo := NewTranscriptionSegmentRepository(db)
+
+	_, err := segmentRepo.ReplaceSegments(2, []entity.TranscriptionSegment{{StartMs: 0, EndMs: 1, Text: "x"}})
+	assert.EqualError(t, err, "no transcription found with id 2")
+
+	// The failed write is rolled back
+	segments, err := segmentRepo.ListSegments(2)
+	assert.NoError(t, err)
+	assert.Empty(t, segments)
+}
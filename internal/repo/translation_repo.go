@@ -0,0 +1,209 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"mlvt/internal/entity"
+	"time"
+)
+
+type TranslationRepository interface {
+	CreateTranslations(translations []*entity.Translation) error
+	GetTranslationByID(translationID uint64) (*entity.Translation, error)
+	ListTranslationsByVideoID(videoID uint64, opts entity.ListOptions) ([]entity.Translation, string, error)
+	ListTranslationsByUserID(userID uint64, opts entity.ListOptions) ([]entity.Translation, string, error)
+	ListActiveTargetLangs(videoID uint64) ([]string, error)
+	UpdateTranslation(translation *entity.Translation) error
+	DeleteTranslation(translationID uint64) error
+}
+
+// ErrTranslationNotFound is returned by deletes of a translation that does not exist
+var ErrTranslationNotFound = errors.New("no translation found")
+
+type translationRepo struct {
+	db *sql.DB
+}
+
+func NewTranslationRepository(db *sql.DB) TranslationRepository {
+	return &translationRepo{db: db}
+}
+
+const translationColumns = `id, video_id, user_id, source_lang, target_lang, transcription_id, audio_id,
+	output_folder, output_file_name, status, created_at, updated_at`
+
+// CreateTranslations inserts several translations in one transaction, so a request for N languages is stored entirely or not at all
+func (r *translationRepo) CreateTranslations(translations []*entity.Translation) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO translations (video_id, user_id, source_lang, target_lang, transcription_id, audio_id,
+		                          output_folder, output_file_name, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	now := time.Now().UTC()
+	for _, translation := range translations {
+		result, err := tx.Exec(query, translation.VideoID, translation.UserID, translation.SourceLang, translation.TargetLang,
+			translation.TranscriptionID, translation.AudioID, translation.OutputFolder, translation.OutputFileName,
+			translation.Status, now, now)
+		if err != nil {
+			return fmt.Errorf("failed to create translation: %v", err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		translation.ID = uint64(id)
+		translation.CreatedAt = now
+		translation.UpdatedAt = now
+	}
+
+	return tx.Commit()
+}
+
+// GetTranslationByID fetches a translation by its ID
+func (r *translationRepo) GetTranslationByID(translationID uint64) (*entity.Translation, error) {
+	query := `SELECT ` + translationColumns + ` FROM translations WHERE id = ?`
+	translation, err := scanTranslation(r.db.QueryRow(query, translationID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return translation, err
+}
+
+// translationListSpec lists the sort fields and filters supported by translation lists
+var translationListSpec = listSpec{
+	sortable: map[string]sortColumn{
+		entity.SortByCreatedAt: {column: "created_at", kind: sortTime},
+	},
+	statusColumn: "status",
+	langColumn:   "target_lang",
+}
+
+// ListTranslationsByVideoID lists one page of the translations of a video and returns the cursor of the next page
+func (r *translationRepo) ListTranslationsByVideoID(videoID uint64, opts entity.ListOptions) ([]entity.Translation, string, error) {
+	query := `SELECT ` + translationColumns + ` FROM translations WHERE video_id = ?`
+	return r.listTranslations(query, videoID, opts)
+}
+
+// ListTranslationsByUserID lists one page of the translations requested by a user and returns the cursor of the next page
+func (r *translationRepo) ListTranslationsByUserID(userID uint64, opts entity.ListOptions) ([]entity.Translation, string, error) {
+	query := `SELECT ` + translationColumns + ` FROM translations WHERE user_id = ?`
+	return r.listTranslations(query, userID, opts)
+}
+
+// ListActiveTargetLangs returns the target languages of the video's translations that have not failed
+func (r *translationRepo) ListActiveTargetLangs(videoID uint64) ([]string, error) {
+	query := `SELECT DISTINCT target_lang FROM translations WHERE video_id = ? AND status != ?`
+	rows, err := r.db.Query(query, videoID, entity.TranslationStatusFailed)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var langs []string
+	for rows.Next() {
+		var lang string
+		if err := rows.Scan(&lang); err != nil {
+			return nil, err
+		}
+		langs = append(langs, lang)
+	}
+	return langs, rows.Err()
+}
+
+// UpdateTranslation updates the artifacts and status of a translation
+func (r *translationRepo) UpdateTranslation(translation *entity.Translation) error {
+	query := `
+		UPDATE translations
+		SET transcription_id = ?, audio_id = ?, output_folder = ?, output_file_name = ?, status = ?, updated_at = ?
+		WHERE id = ?`
+	now := time.Now()
+	result, err := r.db.Exec(query, translation.TranscriptionID, translation.AudioID, translation.OutputFolder,
+		translation.OutputFileName, translation.Status, now, translation.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update translation: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no translation found with id %d", translation.ID)
+	}
+
+	translation.UpdatedAt = now
+	return nil
+}
+
+// DeleteTranslation deletes a translation by its ID and queues the translated video for deletion from storage
+func (r *translationRepo) DeleteTranslation(translationID uint64) error {
+	rowsAffected, err := cascadeDelete(r.db, fmt.Sprintf("translation %d", translationID), translationID,
+		`SELECT output_folder, output_file_name FROM translations WHERE id = ?1 AND output_file_name != ''`,
+		`DELETE FROM translations WHERE id = ?1`)
+	if err != nil {
+		return fmt.Errorf("failed to delete translation: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w with id %d", ErrTranslationNotFound, translationID)
+	}
+	return nil
+}
+
+func (r *translationRepo) listTranslations(query string, scopeID uint64, opts entity.ListOptions) ([]entity.Translation, string, error) {
+	page, err := buildPageQuery(query, []interface{}{scopeID}, opts, translationListSpec)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows, err := r.db.Query(page.query, page.args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var translations []entity.Translation
+	for rows.Next() {
+		translation, err := scanTranslation(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		translations = append(translations, *translation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	translations, nextCursor := trimPage(page, translations, translationSortKey)
+	return translations, nextCursor, nil
+}
+
+func translationSortKey(translation entity.Translation, _ string) (interface{}, uint64) {
+	return translation.CreatedAt, translation.ID
+}
+
+func scanTranslation(row rowScanner) (*entity.Translation, error) {
+	translation := &entity.Translation{}
+	var transcriptionID, audioID sql.NullInt64
+	err := row.Scan(&translation.ID, &translation.VideoID, &translation.UserID, &translation.SourceLang, &translation.TargetLang,
+		&transcriptionID, &audioID, &translation.OutputFolder, &translation.OutputFileName, &translation.Status,
+		&translation.CreatedAt, &translation.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if transcriptionID.Valid {
+		id := uint64(transcriptionID.Int64)
+		translation.TranscriptionID = &id
+	}
+	if audioID.Valid {
+		id := uint64(audioID.Int64)
+		translation.AudioID = &id
+	}
+	return translation, nil
+}
@@ -0,0 +1,51 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTranslationRepository mocks the TranslationRepository interface
+type MockTranslationRepository struct {
+	mock.Mock
+}
+
+func (m *MockTranslationRepository) CreateTranslations(translations []*entity.Translation) error {
+	args := m.Called(translations)
+	return args.Error(0)
+}
+
+func (m *MockTranslationRepository) GetTranslationByID(translationID uint64) (*entity.Translation, error) {
+	args := m.Called(translationID)
+	translation, _ := args.Get(0).(*entity.Translation)
+	return translation, args.Error(1)
+}
+
+func (m *MockTranslationRepository) ListTranslationsByVideoID(videoID uint64, opts entity.ListOptions) ([]entity.Translation, string, error) {
+	args := m.Called(videoID, opts)
+	translations, _ := args.Get(0).([]entity.Translation)
+	return translations, args.String(1), args.Error(2)
+}
+
+func (m *MockTranslationRepository) ListTranslationsByUserID(userID uint64, opts entity.ListOptions) ([]entity.Translation, string, error) {
+	args := m.Called(userID, opts)
+	translations, _ := args.Get(0).([]entity.Translation)
+	return translations, args.String(1), args.Error(2)
+}
+
+func (m *MockTranslationRepository) ListActiveTargetLangs(videoID uint64) ([]string, error) {
+	args := m.Called(videoID)
+	langs, _ := args.Get(0).([]string)
+	return langs, args.Error(1)
+}
+
+func (m *MockTranslationRepository) UpdateTranslation(translation *entity.Translation) error {
+	args := m.Called(translation)
+	return args.Error(0)
+}
+
+func (m *MockTranslationRepository) DeleteTranslation(translationID uint64) error {
+	args := m.Called(translationID)
+	return args.Error(0)
+}
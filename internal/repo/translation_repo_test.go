@@ -0,0 +1,102 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAndListTranslations(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	translationRepo := NewTranslationRepository(db)
+	translations := []*entity.Translation{
+		{VideoID: 1, UserID: 1, SourceLang: "en", TargetLang: "vi", Status: entity.TranslationStatusPending},
+		{VideoID: 1, UserID: 1, SourceLang: "en", TargetLang: "fr", Status: entity.TranslationStatusPending},
+	}
+	assert.NoError(t, translationRepo.CreateTranslations(translations))
+	assert.NotZero(t, translations[0].ID)
+	assert.NotZero(t, translations[1].ID)
+
+	byVideo, nextCursor, err := translationRepo.ListTranslationsByVideoID(1, entity.ListOptions{Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, byVideo, 1)
+	assert.Equal(t, "fr", byVideo[0].TargetLang)
+	assert.Nil(t, byVideo[0].TranscriptionID)
+
+	byVideo, nextCursor, err = translationRepo.ListTranslationsByVideoID(1, entity.ListOptions{Limit: 1, Cursor: nextCursor})
+	assert.NoError(t, err)
+	assert.Len(t, byVideo, 1)
+	assert.Equal(t, "vi", byVideo[0].TargetLang)
+	assert.Empty(t, nextCursor)
+
+	byLang, _, err := translationRepo.ListTranslationsByVideoID(1, entity.ListOptions{Lang: "vi"})
+	assert.NoError(t, err)
+	assert.Len(t, byLang, 1)
+
+	byUser, _, err := translationRepo.ListTranslationsByUserID(2, entity.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, byUser)
+}
+
+func TestListActiveTargetLangs(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	translationRepo := NewTranslationRepository(db)
+	assert.NoError(t, translationRepo.CreateTranslations([]*entity.Translation{
+		{VideoID: 1, UserID: 1, SourceLang: "en", TargetLang: "vi", Status: entity.TranslationStatusProcessing},
+		{VideoID: 1, UserID: 1, SourceLang: "en", TargetLang: "fr", Status: entity.TranslationStatusFailed},
+		{VideoID: 2, UserID: 1, SourceLang: "en", TargetLang: "de", Status: entity.TranslationStatusPending},
+	}))
+
+	langs, err := translationRepo.ListActiveTargetLangs(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"vi"}, langs)
+}
+
+func TestUpdateTranslation(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	translationRepo := NewTranslationRepository(db)
+	translation := &entity.Translation{VideoID: 1, UserID: 1, SourceLang: "en", TargetLang: "vi", Status: entity.TranslationStatusPending}
+	assert.NoError(t, translationRepo.CreateTranslations([]*entity.Translation{translation}))
+
+	transcriptionID := uint64(7)
+	translation.TranscriptionID = &transcriptionID
+	translation.Status = entity.TranslationStatusProcessing
+	assert.NoError(t, translationRepo.UpdateTranslation(translation))
+
+	stored, err := translationRepo.GetTranslationByID(translation.ID)
+	assert.NoError(t, err)
+	assert.NotNil(t, stored)
+	assert.Equal(t, entity.TranslationStatusProcessing, stored.Status)
+	if assert.NotNil(t, stored.TranscriptionID) {
+		assert.Equal(t, transcriptionID, *stored.TranscriptionID)
+	}
+	assert.Nil(t, stored.AudioID)
+}
+
+func TestDeleteTranslation(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	translationRepo := NewTranslationRepository(db)
+	storageRepo := NewStorageObjectRepository(db)
+	translation := &entity.Translation{VideoID: 1, UserID: 1, SourceLang: "en", TargetLang: "vi", Status: entity.TranslationStatusPending}
+	assert.NoError(t, translationRepo.CreateTranslations([]*entity.Translation{translation}))
+	translation.OutputFolder = "translations/1"
+	translation.OutputFileName = "video_1_vi.mp4"
+	assert.NoError(t, translationRepo.UpdateTranslation(translation))
+
+	assert.NoError(t, translationRepo.DeleteTranslation(translation.ID))
+
+	stored, err := translationRepo.GetTranslationByID(translation.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, stored)
+
+	// The output video is removed from storage in the background
+	assert.Equal(t, []entity.StoredObject{{Folder: "translations/1", FileName: "video_1_vi.mp4"}}, queuedObjects(t, storageRepo))
+
+	assert.ErrorIs(t, translationRepo.DeleteTranslation(translation.ID), ErrTranslationNotFound)
+}
@@ -0,0 +1,184 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// TwoFactorRepository stores each user's TOTP secret and the hashes of their recovery codes
+type TwoFactorRepository interface {
+	GetTOTPSecret(userID uint64) (string, error)
+	SetPendingTOTPSecret(userID uint64, secret string) error
+	EnableTOTP(userID uint64, step int64, recoveryCodeHashes []string) error
+	DisableTOTP(userID uint64) error
+	UseTOTPStep(userID uint64, step int64) (bool, error)
+	ReplaceRecoveryCodes(userID uint64, codeHashes []string) error
+	ConsumeRecoveryCode(userID uint64, codeHash string) (bool, error)
+	CountRecoveryCodes(userID uint64) (int, error)
+}
+
+type twoFactorRepo struct {
+	db *sql.DB
+}
+
+func NewTwoFactorRepository(db *sql.DB) TwoFactorRepository {
+	return &twoFactorRepo{db: db}
+}
+
+// GetTOTPSecret returns the user's TOTP secret, or "" if they never enrolled
+func (r *twoFactorRepo) GetTOTPSecret(userID uint64) (string, error) {
+	var secret sql.NullString
+	err := r.db.QueryRow(`SELECT totp_secret FROM users WHERE id = ?`, userID).Scan(&secret)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get TOTP secret: %v", err)
+	}
+	return secret.String, nil
+}
+
+// SetPendingTOTPSecret stores a secret that is not used for sign-ins until EnableTOTP.
+// It fails if two-factor authentication is already on, so an enabled secret is never replaced.
+func (r *twoFactorRepo) SetPendingTOTPSecret(userID uint64, secret string) error {
+	query := `UPDATE users SET totp_secret = ?, totp_last_step = 0, updated_at = ? WHERE id = ? AND totp_enabled_at IS NULL`
+	result, err := r.db.Exec(query, secret, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to set TOTP secret: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no user without two-factor authentication found with id %d", userID)
+	}
+
+	return nil
+}
+
+// EnableTOTP turns on two-factor authentication with the pending secret, recording the step of the
+// code that confirmed it, and stores the user's first recovery codes
+func (r *twoFactorRepo) EnableTOTP(userID uint64, step int64, recoveryCodeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	query := `
+		UPDATE users SET totp_enabled_at = ?, totp_last_step = ?, updated_at = ?
+		WHERE id = ? AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL`
+	result, err := tx.Exec(query, now, step, now, userID)
+	if err != nil {
+		return fmt.Errorf("failed to enable TOTP: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no pending TOTP enrollment found for user %d", userID)
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DisableTOTP turns off two-factor authentication and removes the secret and recovery codes
+func (r *twoFactorRepo) DisableTOTP(userID uint64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0, updated_at = ? WHERE id = ?`
+	result, err := tx.Exec(query, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to disable TOTP: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no user found with id %d", userID)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %v", err)
+	}
+	return tx.Commit()
+}
+
+// UseTOTPStep records that the code of a step was accepted. It returns false if a code of this
+// or a later step was accepted before, so each code signs in once.
+func (r *twoFactorRepo) UseTOTPStep(userID uint64, step int64) (bool, error) {
+	result, err := r.db.Exec(`UPDATE users SET totp_last_step = ? WHERE id = ? AND totp_last_step < ?`, step, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to use TOTP step: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// ReplaceRecoveryCodes discards the user's recovery codes and stores new ones
+func (r *twoFactorRepo) ReplaceRecoveryCodes(userID uint64, codeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ConsumeRecoveryCode marks an unused recovery code as used. It returns false if the user has no such code.
+func (r *twoFactorRepo) ConsumeRecoveryCode(userID uint64, codeHash string) (bool, error) {
+	query := `UPDATE recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL`
+	result, err := r.db.Exec(query, time.Now(), userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// CountRecoveryCodes returns how many unused recovery codes the user has left
+func (r *twoFactorRepo) CountRecoveryCodes(userID uint64) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM recovery_codes WHERE user_id = ? AND used_at IS NULL`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %v", err)
+	}
+	return count, nil
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, userID uint64, codeHashes []string) error {
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %v", err)
+	}
+	now := time.Now()
+	for _, codeHash := range codeHashes {
+		_, err := tx.Exec(`INSERT INTO recovery_codes (user_id, code_hash, created_at) VALUES (?, ?, ?)`, userID, codeHash, now)
+		if err != nil {
+			return fmt.Errorf("failed to create recovery code: %v", err)
+		}
+	}
+	return nil
+}
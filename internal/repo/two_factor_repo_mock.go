@@ -0,0 +1,48 @@
+package repo
+
+import "github.com/stretchr/testify/mock"
+
+// MockTwoFactorRepository is a mock implementation of TwoFactorRepository
+type MockTwoFactorRepository struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorRepository) GetTOTPSecret(userID uint64) (string, error) {
+	args := m.Called(userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) SetPendingTOTPSecret(userID uint64, secret string) error {
+	args := m.Called(userID, secret)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) EnableTOTP(userID uint64, step int64, recoveryCodeHashes []string) error {
+	args := m.Called(userID, step, recoveryCodeHashes)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) DisableTOTP(userID uint64) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) UseTOTPStep(userID uint64, step int64) (bool, error) {
+	args := m.Called(userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) ReplaceRecoveryCodes(userID uint64, codeHashes []string) error {
+	args := m.Called(userID, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) ConsumeRecoveryCode(userID uint64, codeHash string) (bool, error) {
+	args := m.Called(userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) CountRecoveryCodes(userID uint64) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
@@ -0,0 +1,81 @@
+package repo
+
+import (
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTwoFactorEnrollment(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	insertTestUser(t, db, "john")
+
+	twoFactorRepo := NewTwoFactorRepository(db)
+	secret, err := twoFactorRepo.GetTOTPSecret(1)
+	assert.NoError(t, err)
+	assert.Empty(t, secret)
+
+	// Enabling needs a pending secret
+	assert.Error(t, twoFactorRepo.EnableTOTP(1, 100, nil))
+
+	assert.NoError(t, twoFactorRepo.SetPendingTOTPSecret(1, "SECRET"))
+	assert.NoError(t, twoFactorRepo.EnableTOTP(1, 100, []string{"a", "b"}))
+	secret, _ = twoFactorRepo.GetTOTPSecret(1)
+	assert.Equal(t, "SECRET", secret)
+
+	// An enabled secret is never replaced by a new enrollment
+	assert.Error(t, twoFactorRepo.SetPendingTOTPSecret(1, "OTHER"))
+
+	count, err := twoFactorRepo.CountRecoveryCodes(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.NoError(t, twoFactorRepo.DisableTOTP(1))
+	secret, _ = twoFactorRepo.GetTOTPSecret(1)
+	assert.Empty(t, secret)
+	count, _ = twoFactorRepo.CountRecoveryCodes(1)
+	assert.Zero(t, count)
+}
+
+func TestUseTOTPStep_RejectsReplay(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	insertTestUser(t, db, "john")
+
+	twoFactorRepo := NewTwoFactorRepository(db)
+	assert.NoError(t, twoFactorRepo.SetPendingTOTPSecret(1, "SECRET"))
+	assert.NoError(t, twoFactorRepo.EnableTOTP(1, 100, nil))
+
+	used, err := twoFactorRepo.UseTOTPStep(1, 100)
+	assert.NoError(t, err)
+	assert.False(t, used, "the code that enabled 2FA cannot sign in")
+
+	used, _ = twoFactorRepo.UseTOTPStep(1, 101)
+	assert.True(t, used)
+	used, _ = twoFactorRepo.UseTOTPStep(1, 101)
+	assert.False(t, used)
+}
+
+func TestConsumeRecoveryCode(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	insertTestUser(t, db, "john")
+
+	twoFactorRepo := NewTwoFactorRepository(db)
+	assert.NoError(t, twoFactorRepo.ReplaceRecoveryCodes(1, []string{"a", "b"}))
+
+	used, err := twoFactorRepo.ConsumeRecoveryCode(1, "a")
+	assert.NoError(t, err)
+	assert.True(t, used)
+	used, _ = twoFactorRepo.ConsumeRecoveryCode(1, "a")
+	assert.False(t, used)
+	used, _ = twoFactorRepo.ConsumeRecoveryCode(2, "b")
+	assert.False(t, used)
+
+	count, _ := twoFactorRepo.CountRecoveryCodes(1)
+	assert.Equal(t, 1, count)
+
+	// Replacing discards the remaining codes
+	assert.NoError(t, twoFactorRepo.ReplaceRecoveryCodes(1, []string{"c"}))
+	used, _ = twoFactorRepo.ConsumeRecoveryCode(1, "b")
+	assert.False(t, used)
+}
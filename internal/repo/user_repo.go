@@ -2,7 +2,9 @@ package repo
 
 import (
 	"database/sql"
+	"fmt"
 	"mlvt/internal/entity"
+	"strings"
 	"time"
 )
 
@@ -10,11 +12,17 @@ type UserRepository interface {
 	CreateUser(user *entity.User) error
 	GetUserByEmail(email string) (*entity.User, error)
 	GetUserByID(userID uint64) (*entity.User, error)
-	UpdateUser(user *entity.User) error
+	UpdateUser(user *entity.User) (bool, error)
 	DeleteUser(userID uint64) error
 	GetAllUsers() ([]entity.User, error)
 	UpdateUserPassword(userID uint64, hashedPassword string) error
 	UpdateUserAvatar(userID uint64, avatarPath, avatarFolder string) error
+	UpdateUserStatus(userID uint64, status int) error
+	ChangeUserStatus(userID uint64, from, to int, auditLog *entity.AuditLog) (bool, error)
+	UpdateUserRole(userID uint64, role string) error
+	IncrementTokenVersion(userID uint64) error
+	MarkEmailVerified(userID uint64) error
+	SearchUsers(query, role string, status int) ([]entity.User, error)
 }
 
 type userRepo struct {
@@ -25,25 +33,32 @@ func NewUserRepo(db *sql.DB) UserRepository {
 	return &userRepo{db: db}
 }
 
+const userColumns = `id, first_name, last_name, username, email, password, status, premium, role, avatar, avatar_folder, created_at, updated_at, token_version, email_verified_at, totp_enabled_at, premium_until`
+
 // CreateUser inserts a new user into the database
 func (r *userRepo) CreateUser(user *entity.User) error {
 	query := `
 		INSERT INTO users (first_name, last_name, username, email, password, status, premium, role, avatar, avatar_folder, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	_, err := r.db.Exec(query, user.FirstName, user.LastName, user.UserName, user.Email, user.Password, user.Status,
+	result, err := r.db.Exec(query, user.FirstName, user.LastName, user.UserName, user.Email, user.Password, user.Status,
 		user.Premium, user.Role, user.Avatar, user.AvatarFolder, user.CreatedAt, user.UpdatedAt)
-	return err
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	user.ID = uint64(id)
+	return nil
 }
 
-// GetUserByEmail retrieves a user by their email address
+// GetUserByEmail retrieves a user by their email address, ignoring case: an account registered
+// as User@example.com is found as user@example.com. Addresses are unique ignoring case, so at most one user matches.
 func (r *userRepo) GetUserByEmail(email string) (*entity.User, error) {
-	query := `SELECT id, first_name, last_name, username, email, password, status, premium, role, avatar, avatar_folder, created_at, updated_at
-	          FROM users WHERE email = ?`
-	row := r.db.QueryRow(query, email)
-
-	user := &entity.User{}
-	err := row.Scan(&user.ID, &user.FirstName, &user.LastName, &user.UserName, &user.Email, &user.Password,
-		&user.Status, &user.Premium, &user.Role, &user.Avatar, &user.AvatarFolder, &user.CreatedAt, &user.UpdatedAt)
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = ? COLLATE NOCASE`
+	user, err := scanUser(r.db.QueryRow(query, email))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -52,27 +67,59 @@ func (r *userRepo) GetUserByEmail(email string) (*entity.User, error) {
 
 // GetUserByID retrieves a user by their ID
 func (r *userRepo) GetUserByID(userID uint64) (*entity.User, error) {
-	query := `SELECT id, first_name, last_name, username, email, password, status, premium, role, avatar, avatar_folder, created_at, updated_at
-	          FROM users WHERE id = ?`
-	row := r.db.QueryRow(query, userID)
-
-	user := &entity.User{}
-	err := row.Scan(&user.ID, &user.FirstName, &user.LastName, &user.UserName, &user.Email, &user.Password,
-		&user.Status, &user.Premium, &user.Role, &user.Avatar, &user.AvatarFolder, &user.CreatedAt, &user.UpdatedAt)
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = ?`
+	user, err := scanUser(r.db.QueryRow(query, userID))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	return user, err
 }
 
-// UpdateUser updates user information
-func (r *userRepo) UpdateUser(user *entity.User) error {
+// UpdateUser updates the user's profile information and reports whether the email address changed.
+// A new address is unverified, and the links sent to the old one stop working: following a password
+// reset link would verify the new address too.
+// Status, premium and role are managed separately and are left untouched.
+func (r *userRepo) UpdateUser(user *entity.User) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var email string
+	err = tx.QueryRow(`SELECT email FROM users WHERE id = ?`, user.ID).Scan(&email)
+	if err == sql.ErrNoRows {
+		return false, fmt.Errorf("no user found with id %d", user.ID)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get user: %v", err)
+	}
+
 	query := `
 		UPDATE users
-		SET first_name = ?, last_name = ?, username = ?, email = ?, status = ?, premium = ?, role = ?, updated_at = ?
+		SET first_name = ?, last_name = ?, username = ?, email = ?, updated_at = ?
 		WHERE id = ?`
-	_, err := r.db.Exec(query, user.FirstName, user.LastName, user.UserName, user.Email, user.Status, user.Premium, user.Role, user.UpdatedAt, user.ID)
-	return err
+	_, err = tx.Exec(query, user.FirstName, user.LastName, user.UserName, user.Email, user.UpdatedAt, user.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update user: %v", err)
+	}
+
+	changed := user.Email != email
+	if changed {
+		if _, err := tx.Exec(`UPDATE users SET email_verified_at = NULL WHERE id = ?`, user.ID); err != nil {
+			return false, fmt.Errorf("failed to reset email verification: %v", err)
+		}
+		_, err = tx.Exec(`UPDATE user_tokens SET used_at = ? WHERE user_id = ? AND used_at IS NULL`, user.UpdatedAt, user.ID)
+		if err != nil {
+			return false, fmt.Errorf("failed to invalidate user tokens: %v", err)
+		}
+		user.EmailVerifiedAt = nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return changed, nil
 }
 
 // DeleteUser performs a soft delete by updating the status of a user to "deleted"
@@ -98,8 +145,7 @@ func (r *userRepo) UpdateUserAvatar(userID uint64, avatarPath, avatarFolder stri
 
 // GetAllUsers retrieves all users
 func (r *userRepo) GetAllUsers() ([]entity.User, error) {
-	query := `SELECT id, first_name, last_name, username, email, password, status, premium, role, avatar, avatar_folder, created_at, updated_at
-	          FROM users`
+	query := `SELECT ` + userColumns + ` FROM users`
 	rows, err := r.db.Query(query)
 	if err != nil {
 		return nil, err
@@ -108,13 +154,173 @@ func (r *userRepo) GetAllUsers() ([]entity.User, error) {
 
 	var users []entity.User
 	for rows.Next() {
-		var user entity.User
-		err := rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.UserName, &user.Email, &user.Password,
-			&user.Status, &user.Premium, &user.Role, &user.Avatar, &user.AvatarFolder, &user.CreatedAt, &user.UpdatedAt)
+		user, err := scanUser(rows)
 		if err != nil {
 			return nil, err
 		}
-		users = append(users, user)
+		users = append(users, *user)
 	}
 	return users, nil
 }
+
+// ChangeUserStatus moves a user from one status to another and records the audit log in the same
+// transaction. It reports false, changing nothing, if the user no longer has the from status.
+func (r *userRepo) ChangeUserStatus(userID uint64, from, to int, auditLog *entity.AuditLog) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE users SET status = ?, updated_at = ? WHERE id = ? AND status = ?`, to, time.Now(), userID, from)
+	if err != nil {
+		return false, fmt.Errorf("failed to update user status: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	if err := insertAuditLog(tx, auditLog); err != nil {
+		return false, fmt.Errorf("failed to create audit log: %v", err)
+	}
+	return true, tx.Commit()
+}
+
+// UpdateUserStatus updates only the status of a user
+func (r *userRepo) UpdateUserStatus(userID uint64, status int) error {
+	query := `UPDATE users SET status = ?, updated_at = ? WHERE id = ?`
+	result, err := r.db.Exec(query, status, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to update user status: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no user found with id %d", userID)
+	}
+
+	return nil
+}
+
+// UpdateUserRole updates only the role of a user
+func (r *userRepo) UpdateUserRole(userID uint64, role string) error {
+	query := `UPDATE users SET role = ?, updated_at = ? WHERE id = ?`
+	result, err := r.db.Exec(query, role, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to update user role: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no user found with id %d", userID)
+	}
+
+	return nil
+}
+
+// IncrementTokenVersion invalidates every access token issued to a user so far
+func (r *userRepo) IncrementTokenVersion(userID uint64) error {
+	query := `UPDATE users SET token_version = token_version + 1, updated_at = ? WHERE id = ?`
+	result, err := r.db.Exec(query, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to update token version: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no user found with id %d", userID)
+	}
+
+	return nil
+}
+
+// MarkEmailVerified records that the user proved ownership of their email address; an earlier verification time is kept
+func (r *userRepo) MarkEmailVerified(userID uint64) error {
+	now := time.Now()
+	query := `UPDATE users SET email_verified_at = COALESCE(email_verified_at, ?), updated_at = ? WHERE id = ?`
+	result, err := r.db.Exec(query, now, now, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no user found with id %d", userID)
+	}
+
+	return nil
+}
+
+// SearchUsers lists users whose name, username or email contains query.
+// An empty query, empty role or zero status disables that filter.
+func (r *userRepo) SearchUsers(query, role string, status int) ([]entity.User, error) {
+	var conditions []string
+	var args []interface{}
+
+	if query != "" {
+		pattern := "%" + query + "%"
+		conditions = append(conditions, "(first_name LIKE ? OR last_name LIKE ? OR username LIKE ? OR email LIKE ?)")
+		args = append(args, pattern, pattern, pattern, pattern)
+	}
+	if role != "" {
+		conditions = append(conditions, "role = ?")
+		args = append(args, role)
+	}
+	if status != 0 {
+		conditions = append(conditions, "status = ?")
+		args = append(args, status)
+	}
+
+	sqlQuery := `SELECT ` + userColumns + ` FROM users`
+	if len(conditions) > 0 {
+		sqlQuery += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	sqlQuery += " ORDER BY id"
+
+	rows, err := r.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []entity.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	return users, rows.Err()
+}
+
+func scanUser(row rowScanner) (*entity.User, error) {
+	var user entity.User
+	err := row.Scan(&user.ID, &user.FirstName, &user.LastName, &user.UserName, &user.Email, &user.Password,
+		&user.Status, &user.Premium, &user.Role, &user.Avatar, &user.AvatarFolder, &user.CreatedAt, &user.UpdatedAt,
+		&user.TokenVersion, &user.EmailVerifiedAt, &user.TOTPEnabledAt, &user.PremiumUntil)
+	if err != nil {
+		return nil, err
+	}
+	// Premium bought as a subscription ends with it; premium without an end date is permanent
+	if user.PremiumUntil != nil && !user.PremiumUntil.After(time.Now()) {
+		user.Premium = false
+	}
+	return &user, nil
+}
@@ -32,9 +32,9 @@ func (m *MockUserRepository) GetUserByID(userID uint64) (*entity.User, error) {
 	return nil, args.Error(1)
 }
 
-func (m *MockUserRepository) UpdateUser(user *entity.User) error {
+func (m *MockUserRepository) UpdateUser(user *entity.User) (bool, error) {
 	args := m.Called(user)
-	return args.Error(0)
+	return args.Bool(0), args.Error(1)
 }
 
 func (m *MockUserRepository) DeleteUser(userID uint64) error {
@@ -59,3 +59,36 @@ func (m *MockUserRepository) UpdateUserAvatar(userID uint64, avatarPath, avatarF
 	args := m.Called(userID, avatarPath, avatarFolder)
 	return args.Error(0)
 }
+
+func (m *MockUserRepository) UpdateUserStatus(userID uint64, status int) error {
+	args := m.Called(userID, status)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ChangeUserStatus(userID uint64, from, to int, auditLog *entity.AuditLog) (bool, error) {
+	args := m.Called(userID, from, to, auditLog)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateUserRole(userID uint64, role string) error {
+	args := m.Called(userID, role)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) IncrementTokenVersion(userID uint64) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SearchUsers(query, role string, status int) ([]entity.User, error) {
+	args := m.Called(query, role, status)
+	if users, ok := args.Get(0).([]entity.User); ok {
+		return users, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserRepository) MarkEmailVerified(userID uint64) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
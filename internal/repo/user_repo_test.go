@@ -1,14 +1,17 @@
 package repo
 
 import (
+	"fmt"
 	"regexp"
 	"testing"
 	"time"
 
+	"mlvt/cmd/migration"
 	"mlvt/internal/entity"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCreateUser(t *testing.T) {
@@ -43,6 +46,7 @@ func TestCreateUser(t *testing.T) {
 
 	err = repo.CreateUser(user)
 	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), user.ID)
 
 	// Ensure all expectations were met
 	err = mock.ExpectationsWereMet()
@@ -59,16 +63,14 @@ func TestGetUserByEmail(t *testing.T) {
 
 	rows := sqlmock.NewRows([]string{
 		"id", "first_name", "last_name", "username", "email", "password",
-		"status", "premium", "role", "avatar", "avatar_folder", "created_at", "updated_at",
+		"status", "premium", "role", "avatar", "avatar_folder", "created_at", "updated_at", "token_version", "email_verified_at", "totp_enabled_at", "premium_until",
 	}).AddRow(
 		1, "John", "Doe", "johndoe", email, "hashedpassword",
 		entity.UserStatusAvailable, false, "user", "avatar.jpg", "avatars",
-		time.Now(), time.Now(),
+		time.Now(), time.Now(), 0, nil, nil, nil,
 	)
 
-	mock.ExpectQuery(regexp.QuoteMeta(`
-		SELECT id, first_name, last_name, username, email, password, status, premium, role, avatar, avatar_folder, created_at, updated_at
-		          FROM users WHERE email = ?`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT ` + userColumns + ` FROM users WHERE email = ? COLLATE NOCASE`)).
 		WithArgs(email).
 		WillReturnRows(rows)
 
@@ -81,6 +83,54 @@ func TestGetUserByEmail(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestGetUserByEmail_IgnoresCase(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	userID := insertTestUser(t, db, "John")
+
+	user, err := NewUserRepo(db).GetUserByEmail("john@example.com")
+	assert.NoError(t, err)
+	if assert.NotNil(t, user) {
+		assert.Equal(t, userID, user.ID)
+		assert.Equal(t, "John@example.com", user.Email)
+	}
+}
+
+func TestCreateUser_EmailUniqueIgnoringCase(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	insertTestUser(t, db, "bob")
+
+	err := NewUserRepo(db).CreateUser(&entity.User{
+		FirstName: "Bob",
+		LastName:  "Again",
+		UserName:  "bob2",
+		Email:     "Bob@Example.com",
+		Password:  "hash",
+		Status:    entity.UserStatusAvailable,
+		Role:      entity.RoleUser,
+	})
+	assert.Error(t, err)
+}
+
+func TestMigrate_ResolvesEmailCaseDuplicates(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	_, err := db.Exec(`
+	DROP INDEX idx_users_email_nocase;
+	DELETE FROM migrations WHERE name = 'unique_users_email_ignoring_case';`)
+	require.NoError(t, err)
+	olderID := insertTestUser(t, db, "Bob")
+	newerID := insertTestUser(t, db, "bob")
+
+	require.NoError(t, migration.Migrate(db))
+
+	userRepo := NewUserRepo(db)
+	older, err := userRepo.GetUserByID(olderID)
+	require.NoError(t, err)
+	assert.Equal(t, "bob@example.com", older.Email)
+	newer, err := userRepo.GetUserByID(newerID)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("bob@example.com#duplicate-%d", newerID), newer.Email)
+}
+
 func TestGetUserByID(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -91,16 +141,14 @@ func TestGetUserByID(t *testing.T) {
 
 	rows := sqlmock.NewRows([]string{
 		"id", "first_name", "last_name", "username", "email", "password",
-		"status", "premium", "role", "avatar", "avatar_folder", "created_at", "updated_at",
+		"status", "premium", "role", "avatar", "avatar_folder", "created_at", "updated_at", "token_version", "email_verified_at", "totp_enabled_at", "premium_until",
 	}).AddRow(
 		userID, "John", "Doe", "johndoe", "john@example.com", "hashedpassword",
 		entity.UserStatusAvailable, false, "user", "avatar.jpg", "avatars",
-		time.Now(), time.Now(),
+		time.Now(), time.Now(), 0, nil, nil, nil,
 	)
 
-	mock.ExpectQuery(regexp.QuoteMeta(`
-		SELECT id, first_name, last_name, username, email, password, status, premium, role, avatar, avatar_folder, created_at, updated_at
-		          FROM users WHERE id = ?`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT ` + userColumns + ` FROM users WHERE id = ?`)).
 		WithArgs(userID).
 		WillReturnRows(rows)
 
@@ -132,15 +180,55 @@ func TestUpdateUser(t *testing.T) {
 		UpdatedAt: time.Now(),
 	}
 
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT email FROM users WHERE id = ?`)).
+		WithArgs(user.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow(user.Email))
 	mock.ExpectExec(regexp.QuoteMeta(`
 		UPDATE users
-		SET first_name = ?, last_name = ?, username = ?, email = ?, status = ?, premium = ?, role = ?, updated_at = ?
+		SET first_name = ?, last_name = ?, username = ?, email = ?, updated_at = ?
 		WHERE id = ?`)).
-		WithArgs(user.FirstName, user.LastName, user.UserName, user.Email, user.Status, user.Premium, user.Role, user.UpdatedAt, user.ID).
+		WithArgs(user.FirstName, user.LastName, user.UserName, user.Email, user.UpdatedAt, user.ID).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
-	err = repo.UpdateUser(user)
+	emailChanged, err := repo.UpdateUser(user)
 	assert.NoError(t, err)
+	assert.False(t, emailChanged)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestUpdateUser_EmailChanged(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(db)
+
+	verifiedAt := time.Now().Add(-time.Hour)
+	user := &entity.User{ID: 1, UserName: "janedoe", Email: "jane@new.example.com", EmailVerifiedAt: &verifiedAt, UpdatedAt: time.Now()}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT email FROM users WHERE id = ?`)).
+		WithArgs(user.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("jane@example.com"))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// The new address is unverified, and links sent to the old one stop working
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET email_verified_at = NULL WHERE id = ?`)).
+		WithArgs(user.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE user_tokens SET used_at = ? WHERE user_id = ? AND used_at IS NULL`)).
+		WithArgs(user.UpdatedAt, user.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	emailChanged, err := repo.UpdateUser(user)
+	assert.NoError(t, err)
+	assert.True(t, emailChanged)
+	assert.Nil(t, user.EmailVerifiedAt)
 
 	err = mock.ExpectationsWereMet()
 	assert.NoError(t, err)
@@ -215,22 +303,20 @@ func TestGetAllUsers(t *testing.T) {
 
 	rows := sqlmock.NewRows([]string{
 		"id", "first_name", "last_name", "username", "email", "password",
-		"status", "premium", "role", "avatar", "avatar_folder", "created_at", "updated_at",
+		"status", "premium", "role", "avatar", "avatar_folder", "created_at", "updated_at", "token_version", "email_verified_at", "totp_enabled_at", "premium_until",
 	}).
 		AddRow(
 			1, "John", "Doe", "johndoe", "john@example.com", "hashedpassword",
 			entity.UserStatusAvailable, false, "user", "avatar.jpg", "avatars",
-			time.Now(), time.Now(),
+			time.Now(), time.Now(), 0, nil, nil, nil,
 		).
 		AddRow(
 			2, "Jane", "Smith", "janesmith", "jane@example.com", "hashedpassword2",
 			entity.UserStatusAvailable, true, "admin", "avatar2.jpg", "avatars",
-			time.Now(), time.Now(),
+			time.Now(), time.Now(), 0, nil, nil, nil,
 		)
 
-	mock.ExpectQuery(regexp.QuoteMeta(`
-		SELECT id, first_name, last_name, username, email, password, status, premium, role, avatar, avatar_folder, created_at, updated_at
-		          FROM users`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT ` + userColumns + ` FROM users`)).
 		WillReturnRows(rows)
 
 	users, err := repo.GetAllUsers()
@@ -242,3 +328,110 @@ func TestGetAllUsers(t *testing.T) {
 	err = mock.ExpectationsWereMet()
 	assert.NoError(t, err)
 }
+
+func TestUpdateUserStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(db)
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET status = ?, updated_at = ? WHERE id = ?`)).
+		WithArgs(entity.UserStatusSuspended, sqlmock.AnyArg(), uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.UpdateUserStatus(1, entity.UserStatusSuspended)
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestUpdateUserStatus_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(db)
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET status = ?, updated_at = ? WHERE id = ?`)).
+		WithArgs(entity.UserStatusSuspended, sqlmock.AnyArg(), uint64(42)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = repo.UpdateUserStatus(42, entity.UserStatusSuspended)
+	assert.EqualError(t, err, "no user found with id 42")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestChangeUserStatus(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	userRepo := NewUserRepo(db)
+	userID := insertTestUser(t, db, "bob")
+	auditLog := func() *entity.AuditLog {
+		return &entity.AuditLog{ActorID: 1, Action: entity.AuditActionSuspendUser, TargetType: entity.AuditTargetUser, TargetID: userID}
+	}
+
+	changed, err := userRepo.ChangeUserStatus(userID, entity.UserStatusAvailable, entity.UserStatusSuspended, auditLog())
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 1, countRows(t, db, "audit_logs"))
+
+	// A user without the expected status is left alone, and nothing is audited
+	changed, err = userRepo.ChangeUserStatus(userID, entity.UserStatusAvailable, entity.UserStatusSuspended, auditLog())
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, 1, countRows(t, db, "audit_logs"))
+
+	// The status change is rolled back when its audit record cannot be written
+	_, err = db.Exec(`DROP TABLE audit_logs`)
+	require.NoError(t, err)
+	_, err = userRepo.ChangeUserStatus(userID, entity.UserStatusSuspended, entity.UserStatusAvailable, auditLog())
+	assert.Error(t, err)
+	user, err := userRepo.GetUserByID(userID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.UserStatusSuspended, user.Status)
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(db)
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET role = ?, updated_at = ? WHERE id = ?`)).
+		WithArgs(entity.RoleAdmin, sqlmock.AnyArg(), uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.UpdateUserRole(1, entity.RoleAdmin)
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestSearchUsers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(db)
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "username", "email", "password", "status", "premium", "role", "avatar", "avatar_folder", "created_at", "updated_at", "token_version", "email_verified_at", "totp_enabled_at", "premium_until"}).
+		AddRow(1, "John", "Doe", "johndoe", "john@example.com", "hashedpassword", entity.UserStatusSuspended, false, entity.RoleUser, "", "", now, now, 0, nil, nil, nil)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE (first_name LIKE ? OR last_name LIKE ? OR username LIKE ? OR email LIKE ?) AND role = ? AND status = ? ORDER BY id`)).
+		WithArgs("%john%", "%john%", "%john%", "%john%", entity.RoleUser, entity.UserStatusSuspended).
+		WillReturnRows(rows)
+
+	users, err := repo.SearchUsers("john", entity.RoleUser, entity.UserStatusSuspended)
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, "johndoe", users[0].UserName)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
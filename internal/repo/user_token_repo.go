@@ -0,0 +1,107 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"mlvt/internal/entity"
+	"time"
+)
+
+// UserTokenRepository stores the hashed single-use tokens sent by email for password resets and email verification
+type UserTokenRepository interface {
+	CreateUserToken(token *entity.UserToken) error
+	ConsumeUserToken(purpose, tokenHash string) (*entity.UserToken, error)
+}
+
+type userTokenRepo struct {
+	db *sql.DB
+}
+
+func NewUserTokenRepository(db *sql.DB) UserTokenRepository {
+	return &userTokenRepo{db: db}
+}
+
+const userTokenColumns = `id, user_id, purpose, token_hash, expires_at, used_at, created_at`
+
+// CreateUserToken stores a new token and sets its ID. Unused tokens of the same user and purpose
+// are invalidated, so only the most recently sent link works.
+func (r *userTokenRepo) CreateUserToken(token *entity.UserToken) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	_, err = tx.Exec(`UPDATE user_tokens SET used_at = ? WHERE user_id = ? AND purpose = ? AND used_at IS NULL`,
+		now, token.UserID, token.Purpose)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate user tokens: %v", err)
+	}
+
+	query := `
+		INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?)`
+	result, err := tx.Exec(query, token.UserID, token.Purpose, token.TokenHash, token.ExpiresAt, now)
+	if err != nil {
+		return fmt.Errorf("failed to create user token: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	token.ID = uint64(id)
+	token.CreatedAt = now
+
+	return tx.Commit()
+}
+
+// ConsumeUserToken marks an unused, unexpired token as used and returns it.
+// It returns nil if no such token exists, so each token can be used only once.
+func (r *userTokenRepo) ConsumeUserToken(purpose, tokenHash string) (*entity.UserToken, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRow(`SELECT `+userTokenColumns+` FROM user_tokens WHERE purpose = ? AND token_hash = ?`, purpose, tokenHash)
+	token, err := scanUserToken(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if token.UsedAt != nil || !now.Before(token.ExpiresAt) {
+		return nil, nil
+	}
+
+	result, err := tx.Exec(`UPDATE user_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL`, now, token.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use user token: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return nil, nil // Used concurrently
+	}
+	token.UsedAt = &now
+
+	return token, tx.Commit()
+}
+
+func scanUserToken(row rowScanner) (*entity.UserToken, error) {
+	var token entity.UserToken
+	err := row.Scan(&token.ID, &token.UserID, &token.Purpose, &token.TokenHash, &token.ExpiresAt, &token.UsedAt,
+		&token.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
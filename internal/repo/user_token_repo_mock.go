@@ -0,0 +1,23 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockUserTokenRepository is a mock implementation of UserTokenRepository
+type MockUserTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserTokenRepository) CreateUserToken(token *entity.UserToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockUserTokenRepository) ConsumeUserToken(purpose, tokenHash string) (*entity.UserToken, error) {
+	args := m.Called(purpose, tokenHash)
+	token, _ := args.Get(0).(*entity.UserToken)
+	return token, args.Error(1)
+}
@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumeUserToken(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	tokenRepo := NewUserTokenRepository(db)
+	token := &entity.UserToken{UserID: 1, Purpose: entity.TokenPurposePasswordReset, TokenHash: "hash-1",
+		ExpiresAt: time.Now().Add(time.Hour)}
+	assert.NoError(t, tokenRepo.CreateUserToken(token))
+	assert.NotZero(t, token.ID)
+
+	// The purpose must match
+	consumed, err := tokenRepo.ConsumeUserToken(entity.TokenPurposeEmailVerification, "hash-1")
+	assert.NoError(t, err)
+	assert.Nil(t, consumed)
+
+	consumed, err = tokenRepo.ConsumeUserToken(entity.TokenPurposePasswordReset, "hash-1")
+	assert.NoError(t, err)
+	if assert.NotNil(t, consumed) {
+		assert.Equal(t, uint64(1), consumed.UserID)
+		assert.NotNil(t, consumed.UsedAt)
+	}
+
+	// A token works only once
+	consumed, err = tokenRepo.ConsumeUserToken(entity.TokenPurposePasswordReset, "hash-1")
+	assert.NoError(t, err)
+	assert.Nil(t, consumed)
+}
+
+func TestCreateUserToken_InvalidatesEarlierTokens(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	tokenRepo := NewUserTokenRepository(db)
+	expiresAt := time.Now().Add(time.Hour)
+	assert.NoError(t, tokenRepo.CreateUserToken(&entity.UserToken{UserID: 1, Purpose: entity.TokenPurposePasswordReset,
+		TokenHash: "old", ExpiresAt: expiresAt}))
+	assert.NoError(t, tokenRepo.CreateUserToken(&entity.UserToken{UserID: 1, Purpose: entity.TokenPurposeEmailVerification,
+		TokenHash: "other-purpose", ExpiresAt: expiresAt}))
+	assert.NoError(t, tokenRepo.CreateUserToken(&entity.UserToken{UserID: 1, Purpose: entity.TokenPurposePasswordReset,
+		TokenHash: "new", ExpiresAt: expiresAt}))
+
+	consumed, err := tokenRepo.ConsumeUserToken(entity.TokenPurposePasswordReset, "old")
+	assert.NoError(t, err)
+	assert.Nil(t, consumed)
+
+	consumed, err = tokenRepo.ConsumeUserToken(entity.TokenPurposeEmailVerification, "other-purpose")
+	assert.NoError(t, err)
+	assert.NotNil(t, consumed)
+
+	consumed, err = tokenRepo.ConsumeUserToken(entity.TokenPurposePasswordReset, "new")
+	assert.NoError(t, err)
+	assert.NotNil(t, consumed)
+}
+
+func TestConsumeUserToken_Expired(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	tokenRepo := NewUserTokenRepository(db)
+	assert.NoError(t, tokenRepo.CreateUserToken(&entity.UserToken{UserID: 1, Purpose: entity.TokenPurposePasswordReset,
+		TokenHash: "expired", ExpiresAt: time.Now().Add(-time.Minute)}))
+
+	consumed, err := tokenRepo.ConsumeUserToken(entity.TokenPurposePasswordReset, "expired")
+	assert.NoError(t, err)
+	assert.Nil(t, consumed)
+}
@@ -2,6 +2,7 @@ package repo
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"mlvt/internal/entity"
 	"time"
@@ -10,13 +11,22 @@ import (
 type VideoRepository interface {
 	CreateVideo(video *entity.Video) error
 	GetVideoByID(videoID uint64) (*entity.Video, error)
-	ListVideosByUserID(userID uint64) ([]entity.Video, error)
+	ListVideosByUserID(userID uint64, opts entity.ListOptions) ([]entity.Video, string, error)
 	DeleteVideo(videoID uint64) error
 	UpdateVideo(video *entity.Video) error
 	GetVideoStatus(videoID uint64) (entity.VideoStatus, error)
 	UpdateVideoStatus(videoId uint64, status entity.VideoStatus) error
+	UpdateVideoMediaInfo(videoID uint64, duration int, info entity.MediaInfo) error
+	TransitionVideoStatus(history *entity.VideoStatusHistory) error
+	ListVideoStatusHistory(videoID uint64) ([]entity.VideoStatusHistory, error)
 }
 
+// ErrVideoStatusChanged is returned by TransitionVideoStatus when the video no longer has the expected status
+var ErrVideoStatusChanged = errors.New("video status changed concurrently")
+
+// ErrVideoNotFound is returned by updates and deletions of a video that does not exist
+var ErrVideoNotFound = errors.New("no video found")
+
 type videoRepo struct {
 	db *sql.DB
 }
@@ -33,60 +43,112 @@ func (r *videoRepo) CreateVideo(video *entity.Video) error {
 	query := `
 		INSERT INTO videos (title, duration, description, file_name, folder, image, status, user_id, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	now := time.Now()
+	now := time.Now().UTC()
 	_, err := r.db.Exec(query, video.Title, video.Duration, video.Description, video.FileName, video.Folder, video.Image, video.Status, video.UserID, now, now)
 	return err
 }
 
+const videoColumns = `id, title, duration, description, file_name, folder, image, status, user_id, created_at, updated_at, ` + mediaInfoColumns
+
 // GetVideoByID retrieves a video record by its ID
 func (r *videoRepo) GetVideoByID(videoID uint64) (*entity.Video, error) {
-	query := `SELECT id, title, duration, description, file_name, folder, image, status, user_id, created_at, updated_at
-	          FROM videos WHERE id = ?`
-	row := r.db.QueryRow(query, videoID)
-	video := &entity.Video{}
-	err := row.Scan(&video.ID, &video.Title, &video.Duration, &video.Description, &video.FileName, &video.Folder, &video.Image, &video.Status, &video.UserID, &video.CreatedAt, &video.UpdatedAt)
+	query := `SELECT ` + videoColumns + ` FROM videos WHERE id = ?`
+	video, err := scanVideo(r.db.QueryRow(query, videoID))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	return video, err
 }
 
-// ListVideosByUserID lists all videos uploaded by a specific user
-func (r *videoRepo) ListVideosByUserID(userID uint64) ([]entity.Video, error) {
-	query := `SELECT id, title, duration, description, file_name, folder, image, status, user_id, created_at, updated_at
-	          FROM videos WHERE user_id = ?`
-	rows, err := r.db.Query(query, userID)
+// videoListSpec lists the sort fields and filters supported by video lists
+var videoListSpec = listSpec{
+	sortable: map[string]sortColumn{
+		entity.SortByCreatedAt: {column: "created_at", kind: sortTime},
+		entity.SortByTitle:     {column: "title", kind: sortText},
+		entity.SortByDuration:  {column: "duration", kind: sortInt},
+	},
+	statusColumn: "status",
+}
+
+// ListVideosByUserID lists one page of the videos uploaded by a specific user and returns the cursor of the next page
+func (r *videoRepo) ListVideosByUserID(userID uint64, opts entity.ListOptions) ([]entity.Video, string, error) {
+	query := `SELECT ` + videoColumns + ` FROM videos WHERE user_id = ?`
+	page, err := buildPageQuery(query, []interface{}{userID}, opts, videoListSpec)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	rows, err := r.db.Query(page.query, page.args...)
+	if err != nil {
+		return nil, "", err
 	}
 	defer rows.Close()
 
 	var videos []entity.Video
 	for rows.Next() {
-		var video entity.Video
-		if err := rows.Scan(&video.ID, &video.Title, &video.Duration, &video.Description, &video.FileName, &video.Folder, &video.Image, &video.Status, &video.UserID, &video.CreatedAt, &video.UpdatedAt); err != nil {
-			return nil, err
+		video, err := scanVideo(rows)
+		if err != nil {
+			return nil, "", err
 		}
-		videos = append(videos, video)
+		videos = append(videos, *video)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
 	}
-	return videos, nil
+
+	videos, nextCursor := trimPage(page, videos, videoSortKey)
+	return videos, nextCursor, nil
 }
 
-// DeleteVideo deletes a video record by its ID
+func videoSortKey(video entity.Video, sortBy string) (interface{}, uint64) {
+	switch sortBy {
+	case entity.SortByTitle:
+		return video.Title, video.ID
+	case entity.SortByDuration:
+		return video.Duration, video.ID
+	default:
+		return video.CreatedAt, video.ID
+	}
+}
+
+// DeleteVideo deletes a video record by its ID together with its frames, audios, transcriptions,
+// translations and processing history. The files they reference are queued for deletion from storage.
 func (r *videoRepo) DeleteVideo(videoID uint64) error {
-	query := "DELETE FROM videos WHERE id = ?"
-	_, err := r.db.Exec(query, videoID)
-	return err
+	objectsQuery := `
+		SELECT folder, file_name FROM videos WHERE id = ?1
+		UNION ALL SELECT folder, image FROM videos WHERE id = ?1 AND image != '' AND image NOT LIKE '%://%'
+		UNION ALL SELECT folder, file_name FROM frames WHERE video_id = ?1 AND file_name != ''
+		UNION ALL SELECT folder, file_name FROM audios WHERE video_id = ?1
+		UNION ALL SELECT folder, file_name FROM transcriptions WHERE video_id = ?1
+		UNION ALL SELECT output_folder, output_file_name FROM translations WHERE video_id = ?1 AND output_file_name != ''`
+	rowsAffected, err := cascadeDelete(r.db, fmt.Sprintf("video %d", videoID), videoID, objectsQuery,
+		`DELETE FROM transcription_segments WHERE transcription_id IN (SELECT id FROM transcriptions WHERE video_id = ?1)`,
+		`DELETE FROM translations WHERE video_id = ?1`,
+		`DELETE FROM transcriptions WHERE video_id = ?1`,
+		`DELETE FROM audios WHERE video_id = ?1`,
+		`DELETE FROM frames WHERE video_id = ?1`,
+		`DELETE FROM jobs WHERE video_id = ?1`,
+		`DELETE FROM video_status_history WHERE video_id = ?1`,
+		`UPDATE video_uploads SET video_id = NULL WHERE video_id = ?1`,
+		`DELETE FROM videos WHERE id = ?1`)
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w with id %d", ErrVideoNotFound, videoID)
+	}
+	return nil
 }
 
-// UpdateVideo updates an existing video record
+// UpdateVideo updates an existing video record.
+// The status is left untouched; it only changes through TransitionVideoStatus.
 func (r *videoRepo) UpdateVideo(video *entity.Video) error {
 	query := `
 		UPDATE videos
-		SET title = ?, duration = ?, description = ?, file_name = ?, folder = ?, image = ?, status = ?, updated_at = ?
+		SET title = ?, duration = ?, description = ?, file_name = ?, folder = ?, image = ?, updated_at = ?
 		WHERE id = ?`
 	now := time.Now()
-	_, err := r.db.Exec(query, video.Title, video.Duration, video.Description, video.FileName, video.Folder, video.Image, video.Status, now, video.ID)
+	_, err := r.db.Exec(query, video.Title, video.Duration, video.Description, video.FileName, video.Folder, video.Image, now, video.ID)
 	return err
 }
 
@@ -107,12 +169,24 @@ func (r *videoRepo) UpdateVideoStatus(videoID uint64, status entity.VideoStatus)
 		return fmt.Errorf("failed to retrieve rows affected: %v", err)
 	}
 	if rowsAffected == 0 {
-		return fmt.Errorf("no video found with id %d", videoID)
+		return fmt.Errorf("%w with id %d", ErrVideoNotFound, videoID)
 	}
 
 	return nil
 }
 
+// UpdateVideoMediaInfo stores the duration and stream details probed from the uploaded video file
+func (r *videoRepo) UpdateVideoMediaInfo(videoID uint64, duration int, info entity.MediaInfo) error {
+	rowsAffected, err := updateMediaInfo(r.db, "videos", videoID, duration, info)
+	if err != nil {
+		return fmt.Errorf("failed to update video media info: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w with id %d", ErrVideoNotFound, videoID)
+	}
+	return nil
+}
+
 func (r *videoRepo) GetVideoStatus(videoID uint64) (entity.VideoStatus, error) {
 	var status entity.VideoStatus
 	query := `
@@ -129,3 +203,80 @@ func (r *videoRepo) GetVideoStatus(videoID uint64) (entity.VideoStatus, error) {
 	}
 	return status, nil
 }
+
+// TransitionVideoStatus moves a video from history.FromStatus to history.ToStatus and records the
+// transition in one transaction. It returns ErrVideoStatusChanged if the video's status is no longer FromStatus.
+func (r *videoRepo) TransitionVideoStatus(history *entity.VideoStatusHistory) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(`UPDATE videos SET status = ?, updated_at = ? WHERE id = ? AND status = ?`,
+		history.ToStatus, now, history.VideoID, history.FromStatus)
+	if err != nil {
+		return fmt.Errorf("failed to update video status: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return ErrVideoStatusChanged
+	}
+
+	query := `
+		INSERT INTO video_status_history (video_id, from_status, to_status, actor_id, reason, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`
+	result, err = tx.Exec(query, history.VideoID, history.FromStatus, history.ToStatus, history.ActorID, history.Reason, now)
+	if err != nil {
+		return fmt.Errorf("failed to record video status history: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	history.ID = uint64(id)
+	history.CreatedAt = now
+
+	return tx.Commit()
+}
+
+// ListVideoStatusHistory returns every status transition of a video, oldest first
+func (r *videoRepo) ListVideoStatusHistory(videoID uint64) ([]entity.VideoStatusHistory, error) {
+	query := `
+		SELECT id, video_id, from_status, to_status, actor_id, reason, created_at
+		FROM video_status_history
+		WHERE video_id = ?
+		ORDER BY id`
+	rows, err := r.db.Query(query, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []entity.VideoStatusHistory
+	for rows.Next() {
+		var entry entity.VideoStatusHistory
+		if err := rows.Scan(&entry.ID, &entry.VideoID, &entry.FromStatus, &entry.ToStatus, &entry.ActorID,
+			&entry.Reason, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, entry)
+	}
+	return history, rows.Err()
+}
+
+func scanVideo(row rowScanner) (*entity.Video, error) {
+	var video entity.Video
+	dest := []interface{}{&video.ID, &video.Title, &video.Duration, &video.Description, &video.FileName, &video.Folder,
+		&video.Image, &video.Status, &video.UserID, &video.CreatedAt, &video.UpdatedAt}
+	if err := row.Scan(append(dest, mediaInfoFields(&video.MediaInfo)...)...); err != nil {
+		return nil, err
+	}
+	return &video, nil
+}
@@ -20,9 +20,9 @@ func (m *MockVideoRepository) GetVideoByID(videoID uint64) (*entity.Video, error
 	return args.Get(0).(*entity.Video), args.Error(1)
 }
 
-func (m *MockVideoRepository) ListVideosByUserID(userID uint64) ([]entity.Video, error) {
-	args := m.Called(userID)
-	return args.Get(0).([]entity.Video), args.Error(1)
+func (m *MockVideoRepository) ListVideosByUserID(userID uint64, opts entity.ListOptions) ([]entity.Video, string, error) {
+	args := m.Called(userID, opts)
+	return args.Get(0).([]entity.Video), args.String(1), args.Error(2)
 }
 
 func (m *MockVideoRepository) DeleteVideo(videoID uint64) error {
@@ -44,3 +44,19 @@ func (m *MockVideoRepository) GetVideoStatus(videoID uint64) (entity.VideoStatus
 	args := m.Called(videoID)
 	return args.Get(0).(entity.VideoStatus), args.Error(1)
 }
+
+func (m *MockVideoRepository) TransitionVideoStatus(history *entity.VideoStatusHistory) error {
+	args := m.Called(history)
+	return args.Error(0)
+}
+
+func (m *MockVideoRepository) ListVideoStatusHistory(videoID uint64) ([]entity.VideoStatusHistory, error) {
+	args := m.Called(videoID)
+	history, _ := args.Get(0).([]entity.VideoStatusHistory)
+	return history, args.Error(1)
+}
+
+func (m *MockVideoRepository) UpdateVideoMediaInfo(videoID uint64, duration int, info entity.MediaInfo) error {
+	args := m.Called(videoID, duration, info)
+	return args.Error(0)
+}
@@ -1,49 +1,18 @@
 package repo
 
 import (
-	"database/sql"
+	"mlvt/cmd/migration"
 	"mlvt/internal/entity"
 	"testing"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func setupTestDB() (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", ":memory:")
-	if err != nil {
-		return nil, err
-	}
-
-	createTableQuery := `
-	CREATE TABLE videos (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		title TEXT,
-		duration INTEGER,
-		description TEXT,
-		file_name TEXT,
-		folder TEXT,
-		image TEXT,
-		status TEXT,
-		user_id INTEGER,
-		created_at DATETIME,
-		updated_at DATETIME
-	);`
-
-	_, err = db.Exec(createTableQuery)
-	if err != nil {
-		db.Close()
-		return nil, err
-	}
-
-	return db, nil
-}
-
 func TestCreateVideo(t *testing.T) {
-	db, err := setupTestDB()
-	assert.NoError(t, err)
-	defer db.Close()
+	db := setupMigratedTestDB(t)
 
 	videoRepo := NewVideoRepo(db)
 
@@ -58,7 +27,7 @@ func TestCreateVideo(t *testing.T) {
 		UserID:      1,
 	}
 
-	err = videoRepo.CreateVideo(video)
+	err := videoRepo.CreateVideo(video)
 	assert.NoError(t, err)
 
 	var count int
@@ -68,9 +37,7 @@ func TestCreateVideo(t *testing.T) {
 }
 
 func TestGetVideoByID(t *testing.T) {
-	db, err := setupTestDB()
-	assert.NoError(t, err)
-	defer db.Close()
+	db := setupMigratedTestDB(t)
 
 	videoRepo := NewVideoRepo(db)
 
@@ -87,7 +54,7 @@ func TestGetVideoByID(t *testing.T) {
 		UpdatedAt:   time.Now(),
 	}
 
-	err = videoRepo.CreateVideo(video)
+	err := videoRepo.CreateVideo(video)
 	assert.NoError(t, err)
 
 	result, err := videoRepo.GetVideoByID(1)
@@ -97,9 +64,7 @@ func TestGetVideoByID(t *testing.T) {
 }
 
 func TestGetVideoByIDNotFound(t *testing.T) {
-	db, err := setupTestDB()
-	assert.NoError(t, err)
-	defer db.Close()
+	db := setupMigratedTestDB(t)
 
 	videoRepo := NewVideoRepo(db)
 
@@ -109,9 +74,7 @@ func TestGetVideoByIDNotFound(t *testing.T) {
 }
 
 func TestListVideosByUserID(t *testing.T) {
-	db, err := setupTestDB()
-	assert.NoError(t, err)
-	defer db.Close()
+	db := setupMigratedTestDB(t)
 
 	videoRepo := NewVideoRepo(db)
 
@@ -136,20 +99,144 @@ func TestListVideosByUserID(t *testing.T) {
 		UserID:      1,
 	}
 
-	err = videoRepo.CreateVideo(video1)
+	err := videoRepo.CreateVideo(video1)
 	assert.NoError(t, err)
 	err = videoRepo.CreateVideo(video2)
 	assert.NoError(t, err)
 
-	result, err := videoRepo.ListVideosByUserID(1)
+	result, nextCursor, err := videoRepo.ListVideosByUserID(1, entity.ListOptions{})
 	assert.NoError(t, err)
 	assert.Len(t, result, 2)
+	assert.Empty(t, nextCursor)
 }
 
-func TestUpdateVideo(t *testing.T) {
-	db, err := setupTestDB()
+func TestListVideosByUserID_Pagination(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	videoRepo := NewVideoRepo(db)
+	for i, title := range []string{"delta", "alpha", "echo", "charlie", "bravo"} {
+		status := entity.StatusRaw
+		if i%2 == 1 {
+			status = entity.StatusSuccess
+		}
+		assert.NoError(t, videoRepo.CreateVideo(&entity.Video{Title: title, Duration: 60 * (i % 3), Status: status, UserID: 1}))
+	}
+	assert.NoError(t, videoRepo.CreateVideo(&entity.Video{Title: "other", UserID: 2}))
+
+	// Walk every page sorted by title
+	var titles []string
+	opts := entity.ListOptions{Limit: 2, SortBy: entity.SortByTitle, Order: entity.SortAsc}
+	for pages := 0; ; pages++ {
+		assert.Less(t, pages, 3)
+		videos, nextCursor, err := videoRepo.ListVideosByUserID(1, opts)
+		assert.NoError(t, err)
+		for _, video := range videos {
+			titles = append(titles, video.Title)
+		}
+		if nextCursor == "" {
+			break
+		}
+		opts.Cursor = nextCursor
+	}
+	assert.Equal(t, []string{"alpha", "bravo", "charlie", "delta", "echo"}, titles)
+
+	// Ties on the sort value are broken by ID, newest first
+	videos, nextCursor, err := videoRepo.ListVideosByUserID(1, entity.ListOptions{Limit: 2, SortBy: entity.SortByDuration})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"echo", "bravo"}, []string{videos[0].Title, videos[1].Title})
+	videos, _, err = videoRepo.ListVideosByUserID(1, entity.ListOptions{Limit: 2, SortBy: entity.SortByDuration, Cursor: nextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alpha", "charlie"}, []string{videos[0].Title, videos[1].Title})
+
+	// Default ordering is newest first
+	videos, _, err = videoRepo.ListVideosByUserID(1, entity.ListOptions{Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "bravo", videos[0].Title)
+
+	// Filters
+	videos, _, err = videoRepo.ListVideosByUserID(1, entity.ListOptions{Status: string(entity.StatusSuccess)})
+	assert.NoError(t, err)
+	assert.Len(t, videos, 2)
+
+	future := time.Now().Add(time.Hour)
+	videos, _, err = videoRepo.ListVideosByUserID(1, entity.ListOptions{CreatedAfter: &future})
+	assert.NoError(t, err)
+	assert.Empty(t, videos)
+	videos, _, err = videoRepo.ListVideosByUserID(1, entity.ListOptions{CreatedBefore: &future})
+	assert.NoError(t, err)
+	assert.Len(t, videos, 5)
+}
+
+func TestListVideosByUserID_CreatedAtInUTC(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	// Rows written before created_at was stored in UTC, in the order they were created
+	_, err := db.Exec(`
+	INSERT INTO videos (id, user_id, title, description, duration, file_name, folder, image, created_at) VALUES
+		(1, 99, 'first', '', 0, '', '', '', '2026-03-29 01:30:00.25+01:00'),
+		(2, 99, 'third', '', 0, '', '', '', '2026-03-29 03:10:00+02:00'),
+		(3, 99, 'second', '', 0, '', '', '', '2026-03-29 00:50:00');
+	DELETE FROM migrations WHERE name = 'store_list_created_at_in_utc';`)
+	require.NoError(t, err)
+	require.NoError(t, migration.Migrate(db))
+
+	// Stored in the form the driver writes UTC times in
+	var createdAt string
+	require.NoError(t, db.QueryRow(`SELECT CAST(created_at AS TEXT) FROM videos WHERE id = 1`).Scan(&createdAt))
+	assert.Equal(t, "2026-03-29 00:30:00.25+00:00", createdAt)
+
+	videoRepo := NewVideoRepo(db)
+	var titles []string
+	opts := entity.ListOptions{Limit: 1, SortBy: entity.SortByCreatedAt, Order: entity.SortAsc}
+	for pages := 0; ; pages++ {
+		assert.Less(t, pages, 3)
+		videos, nextCursor, err := videoRepo.ListVideosByUserID(99, opts)
+		require.NoError(t, err)
+		for _, video := range videos {
+			titles = append(titles, video.Title)
+		}
+		if nextCursor == "" {
+			break
+		}
+		opts.Cursor = nextCursor
+	}
+	assert.Equal(t, []string{"first", "second", "third"}, titles)
+
+	// Bounds in any time zone select by the same instant
+	after := time.Date(2026, 3, 29, 7, 45, 0, 0, time.FixedZone("ICT", 7*60*60))
+	videos, _, err := videoRepo.ListVideosByUserID(99, entity.ListOptions{CreatedAfter: &after, Order: entity.SortAsc})
+	require.NoError(t, err)
+	if assert.Len(t, videos, 2) {
+		assert.Equal(t, "second", videos[0].Title)
+	}
+}
+
+func TestListVideosByUserID_InvalidOptions(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	videoRepo := NewVideoRepo(db)
+	assert.NoError(t, videoRepo.CreateVideo(&entity.Video{Title: "a", UserID: 1}))
+	assert.NoError(t, videoRepo.CreateVideo(&entity.Video{Title: "b", UserID: 1}))
+
+	_, nextCursor, err := videoRepo.ListVideosByUserID(1, entity.ListOptions{Limit: 1})
 	assert.NoError(t, err)
-	defer db.Close()
+	assert.NotEmpty(t, nextCursor)
+
+	for _, opts := range []entity.ListOptions{
+		{SortBy: "size"},
+		{Order: "sideways"},
+		{Lang: "en"},
+		{Cursor: "not-a-cursor"},
+		// A cursor only continues the ordering it was issued for
+		{Cursor: nextCursor, SortBy: entity.SortByTitle},
+	} {
+		_, _, err := videoRepo.ListVideosByUserID(1, opts)
+		assert.ErrorIs(t, err, ErrInvalidListOptions)
+	}
+}
+
+func TestUpdateVideo(t *testing.T) {
+	db := setupMigratedTestDB(t)
 
 	videoRepo := NewVideoRepo(db)
 
@@ -166,7 +253,7 @@ func TestUpdateVideo(t *testing.T) {
 		UpdatedAt:   time.Now(),
 	}
 
-	err = videoRepo.CreateVideo(video)
+	err := videoRepo.CreateVideo(video)
 	assert.NoError(t, err)
 
 	// Retrieve the video to get the assigned ID
@@ -186,10 +273,40 @@ func TestUpdateVideo(t *testing.T) {
 	assert.WithinDuration(t, savedVideo.UpdatedAt, updatedVideo.UpdatedAt, time.Second)
 }
 
-func TestDeleteVideo(t *testing.T) {
-	db, err := setupTestDB()
+func TestUpdateVideoMediaInfo(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	videoRepo := NewVideoRepo(db)
+	assert.NoError(t, videoRepo.CreateVideo(&entity.Video{Title: "Test Video", Duration: 999, FileName: "test.mp4", UserID: 1}))
+
+	probedAt := time.Now()
+	info := entity.MediaInfo{
+		VideoCodec:    "h264",
+		AudioCodec:    "aac",
+		Width:         1920,
+		Height:        1080,
+		Bitrate:       4500000,
+		AudioChannels: 2,
+		SampleRate:    48000,
+		ProbedAt:      &probedAt,
+	}
+	assert.NoError(t, videoRepo.UpdateVideoMediaInfo(1, 125, info))
+
+	video, err := videoRepo.GetVideoByID(1)
 	assert.NoError(t, err)
-	defer db.Close()
+	assert.Equal(t, 125, video.Duration)
+	assert.Equal(t, "h264", video.VideoCodec)
+	assert.Equal(t, 1080, video.Height)
+	assert.Equal(t, 48000, video.SampleRate)
+	if assert.NotNil(t, video.ProbedAt) {
+		assert.WithinDuration(t, probedAt, *video.ProbedAt, time.Second)
+	}
+
+	assert.Error(t, videoRepo.UpdateVideoMediaInfo(2, 10, info))
+}
+
+func TestDeleteVideo(t *testing.T) {
+	db := setupMigratedTestDB(t)
 
 	videoRepo := NewVideoRepo(db)
 
@@ -204,7 +321,7 @@ func TestDeleteVideo(t *testing.T) {
 		UserID:      1,
 	}
 
-	err = videoRepo.CreateVideo(video)
+	err := videoRepo.CreateVideo(video)
 	assert.NoError(t, err)
 
 	err = videoRepo.DeleteVideo(1)
@@ -213,4 +330,34 @@ func TestDeleteVideo(t *testing.T) {
 	deletedVideo, err := videoRepo.GetVideoByID(1)
 	assert.NoError(t, err)
 	assert.Nil(t, deletedVideo)
+
+	assert.ErrorIs(t, videoRepo.DeleteVideo(1), ErrVideoNotFound)
+}
+
+func TestTransitionVideoStatus(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	videoRepo := NewVideoRepo(db)
+	assert.NoError(t, videoRepo.CreateVideo(&entity.Video{Title: "Test Video", Status: entity.StatusRaw, UserID: 1}))
+
+	history := &entity.VideoStatusHistory{VideoID: 1, FromStatus: entity.StatusRaw, ToStatus: entity.StatusProcessing, ActorID: 3, Reason: "started"}
+	err := videoRepo.TransitionVideoStatus(history)
+	assert.NoError(t, err)
+	assert.NotZero(t, history.ID)
+
+	status, err := videoRepo.GetVideoStatus(1)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.StatusProcessing, status)
+
+	// The video is no longer raw, so a second raw -> processing transition must not apply
+	err = videoRepo.TransitionVideoStatus(&entity.VideoStatusHistory{VideoID: 1, FromStatus: entity.StatusRaw, ToStatus: entity.StatusFailed})
+	assert.ErrorIs(t, err, ErrVideoStatusChanged)
+
+	entries, err := videoRepo.ListVideoStatusHistory(1)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, entity.StatusRaw, entries[0].FromStatus)
+	assert.Equal(t, entity.StatusProcessing, entries[0].ToStatus)
+	assert.Equal(t, uint64(3), entries[0].ActorID)
+	assert.Equal(t, "started", entries[0].Reason)
 }
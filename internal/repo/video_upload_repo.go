@@ -0,0 +1,174 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"mlvt/internal/entity"
+	"time"
+)
+
+// ErrUploadNotPending is returned when an upload was completed or expired concurrently
+var ErrUploadNotPending = errors.New("upload is no longer pending")
+
+// VideoUploadRepository tracks direct-to-storage video uploads
+type VideoUploadRepository interface {
+	CreateUpload(upload *entity.VideoUpload) error
+	GetUploadByID(uploadID uint64) (*entity.VideoUpload, error)
+	CompleteUpload(uploadID uint64, video *entity.Video) error
+	ListExpiredUploads(now time.Time, limit int) ([]entity.VideoUpload, error)
+	CloseUpload(uploadID uint64, status entity.UploadStatus) error
+}
+
+type videoUploadRepo struct {
+	db *sql.DB
+}
+
+func NewVideoUploadRepository(db *sql.DB) VideoUploadRepository {
+	return &videoUploadRepo{db: db}
+}
+
+const videoUploadColumns = `id, user_id, title, description, duration, image, folder, file_name, content_type, size,
+	checksum_sha256, multipart_upload_id, part_size, status, video_id, expires_at, created_at, updated_at`
+
+// CreateUpload stores a pending upload and sets its ID
+func (r *videoUploadRepo) CreateUpload(upload *entity.VideoUpload) error {
+	if upload.Status == "" {
+		upload.Status = entity.UploadStatusPending
+	}
+	query := `
+		INSERT INTO video_uploads (user_id, title, description, duration, image, folder, file_name, content_type, size,
+			checksum_sha256, multipart_upload_id, part_size, status, expires_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	now := time.Now()
+	result, err := r.db.Exec(query, upload.UserID, upload.Title, upload.Description, upload.Duration, upload.Image,
+		upload.Folder, upload.FileName, upload.ContentType, upload.Size, upload.ChecksumSHA256, upload.MultipartID,
+		upload.PartSize, upload.Status, upload.ExpiresAt, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to create video upload: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	upload.ID = uint64(id)
+	upload.CreatedAt = now
+	upload.UpdatedAt = now
+	return nil
+}
+
+// GetUploadByID retrieves an upload by its ID
+func (r *videoUploadRepo) GetUploadByID(uploadID uint64) (*entity.VideoUpload, error) {
+	row := r.db.QueryRow(`SELECT `+videoUploadColumns+` FROM video_uploads WHERE id = ?`, uploadID)
+	upload, err := scanVideoUpload(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return upload, err
+}
+
+// CompleteUpload creates the video for a pending upload and marks the upload completed in one transaction.
+// It returns ErrUploadNotPending if the upload was completed or expired in the meantime.
+func (r *videoUploadRepo) CompleteUpload(uploadID uint64, video *entity.Video) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if video.Status == "" {
+		video.Status = entity.StatusRaw
+	}
+	now := time.Now()
+	result, err := tx.Exec(`
+		INSERT INTO videos (title, duration, description, file_name, folder, image, status, user_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		video.Title, video.Duration, video.Description, video.FileName, video.Folder, video.Image, video.Status, video.UserID, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to create video: %v", err)
+	}
+	videoID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	result, err = tx.Exec(`UPDATE video_uploads SET status = ?, video_id = ?, updated_at = ? WHERE id = ? AND status = ?`,
+		entity.UploadStatusCompleted, videoID, now, uploadID, entity.UploadStatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to complete video upload: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return ErrUploadNotPending
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	video.ID = uint64(videoID)
+	video.CreatedAt = now
+	video.UpdatedAt = now
+	return nil
+}
+
+// ListExpiredUploads returns up to limit pending uploads whose deadline passed before now, oldest first
+func (r *videoUploadRepo) ListExpiredUploads(now time.Time, limit int) ([]entity.VideoUpload, error) {
+	query := `SELECT ` + videoUploadColumns + ` FROM video_uploads
+		WHERE status = ? AND expires_at < ?
+		ORDER BY expires_at, id
+		LIMIT ?`
+	rows, err := r.db.Query(query, entity.UploadStatusPending, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var uploads []entity.VideoUpload
+	for rows.Next() {
+		upload, err := scanVideoUpload(rows)
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, *upload)
+	}
+	return uploads, rows.Err()
+}
+
+// CloseUpload moves a pending upload to a final status such as expired or aborted.
+// It returns ErrUploadNotPending if the upload was completed or closed in the meantime.
+func (r *videoUploadRepo) CloseUpload(uploadID uint64, status entity.UploadStatus) error {
+	result, err := r.db.Exec(`UPDATE video_uploads SET status = ?, updated_at = ? WHERE id = ? AND status = ?`,
+		status, time.Now(), uploadID, entity.UploadStatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to close video upload: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return ErrUploadNotPending
+	}
+	return nil
+}
+
+func scanVideoUpload(row rowScanner) (*entity.VideoUpload, error) {
+	var upload entity.VideoUpload
+	var videoID sql.NullInt64
+	err := row.Scan(&upload.ID, &upload.UserID, &upload.Title, &upload.Description, &upload.Duration, &upload.Image,
+		&upload.Folder, &upload.FileName, &upload.ContentType, &upload.Size, &upload.ChecksumSHA256, &upload.MultipartID,
+		&upload.PartSize, &upload.Status,
+		&videoID, &upload.ExpiresAt, &upload.CreatedAt, &upload.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if videoID.Valid {
+		id := uint64(videoID.Int64)
+		upload.VideoID = &id
+	}
+	return &upload, nil
+}
@@ -0,0 +1,40 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockVideoUploadRepository mocks the VideoUploadRepository interface
+type MockVideoUploadRepository struct {
+	mock.Mock
+}
+
+func (m *MockVideoUploadRepository) CreateUpload(upload *entity.VideoUpload) error {
+	args := m.Called(upload)
+	return args.Error(0)
+}
+
+func (m *MockVideoUploadRepository) GetUploadByID(uploadID uint64) (*entity.VideoUpload, error) {
+	args := m.Called(uploadID)
+	upload, _ := args.Get(0).(*entity.VideoUpload)
+	return upload, args.Error(1)
+}
+
+func (m *MockVideoUploadRepository) CompleteUpload(uploadID uint64, video *entity.Video) error {
+	args := m.Called(uploadID, video)
+	return args.Error(0)
+}
+
+func (m *MockVideoUploadRepository) ListExpiredUploads(now time.Time, limit int) ([]entity.VideoUpload, error) {
+	args := m.Called(now, limit)
+	uploads, _ := args.Get(0).([]entity.VideoUpload)
+	return uploads, args.Error(1)
+}
+
+func (m *MockVideoUploadRepository) CloseUpload(uploadID uint64, status entity.UploadStatus) error {
+	args := m.Called(uploadID, status)
+	return args.Error(0)
+}
@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"mlvt/internal/entity"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAndCompleteUpload(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	uploadRepo := NewVideoUploadRepository(db)
+	upload := &entity.VideoUpload{UserID: 1, Title: "Clip", Folder: "videos", FileName: "abc-clip.mp4",
+		ContentType: "video/mp4", Size: 1024, ExpiresAt: time.Now().Add(time.Hour)}
+	assert.NoError(t, uploadRepo.CreateUpload(upload))
+	assert.NotZero(t, upload.ID)
+
+	video := &entity.Video{Title: upload.Title, Folder: upload.Folder, FileName: upload.FileName, UserID: 1}
+	assert.NoError(t, uploadRepo.CompleteUpload(upload.ID, video))
+	assert.NotZero(t, video.ID)
+
+	completed, err := uploadRepo.GetUploadByID(upload.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.UploadStatusCompleted, completed.Status)
+	assert.Equal(t, video.ID, *completed.VideoID)
+
+	stored, err := NewVideoRepo(db).GetVideoByID(video.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc-clip.mp4", stored.FileName)
+	assert.Equal(t, entity.StatusRaw, stored.Status)
+
+	// A second completion must not create another video
+	assert.ErrorIs(t, uploadRepo.CompleteUpload(upload.ID, &entity.Video{Title: "again", UserID: 1}), ErrUploadNotPending)
+	var count int
+	assert.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM videos`).Scan(&count))
+	assert.Equal(t, 1, count)
+
+	missing, err := uploadRepo.GetUploadByID(99)
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestListAndExpireUploads(t *testing.T) {
+	db := setupMigratedTestDB(t)
+
+	uploadRepo := NewVideoUploadRepository(db)
+	now := time.Now()
+	stale := &entity.VideoUpload{UserID: 1, Title: "stale", Folder: "videos", FileName: "a.mp4", ContentType: "video/mp4", Size: 1, ExpiresAt: now.Add(-time.Hour)}
+	fresh := &entity.VideoUpload{UserID: 1, Title: "fresh", Folder: "videos", FileName: "b.mp4", ContentType: "video/mp4", Size: 1,
+		MultipartID: "up-1", PartSize: 5 << 20, ExpiresAt: now.Add(time.Hour)}
+	assert.NoError(t, uploadRepo.CreateUpload(stale))
+	assert.NoError(t, uploadRepo.CreateUpload(fresh))
+
+	expired, err := uploadRepo.ListExpiredUploads(now, 10)
+	assert.NoError(t, err)
+	assert.Len(t, expired, 1)
+	assert.Equal(t, stale.ID, expired[0].ID)
+
+	assert.NoError(t, uploadRepo.CloseUpload(stale.ID, entity.UploadStatusExpired))
+	assert.ErrorIs(t, uploadRepo.CloseUpload(stale.ID, entity.UploadStatusAborted), ErrUploadNotPending)
+
+	expired, err = uploadRepo.ListExpiredUploads(now, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, expired)
+
+	stored, err := uploadRepo.GetUploadByID(fresh.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "up-1", stored.MultipartID)
+	assert.Equal(t, int64(5<<20), stored.PartSize)
+}
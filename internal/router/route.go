@@ -1,6 +1,7 @@
 package router
 
 import (
+	"mlvt/internal/entity"
 	handler "mlvt/internal/handler/rest/v1"
 	"mlvt/internal/pkg/middleware"
 
@@ -13,18 +14,42 @@ type AppRouter struct {
 	audioController         *handler.AudioController
 	transcriptionController *handler.TranscriptionController
 	authMiddleware          *middleware.AuthUserMiddleware
+	ownershipMiddleware     *middleware.OwnershipMiddleware
 	momoPaymentController   *handler.MoMoPaymentController
+	billingController       *handler.BillingController
+	adminController         *handler.AdminController
+	translationController   *handler.TranslationController
+	searchController        *handler.SearchController
+	uploadController        *handler.UploadController
+	frameController         *handler.FrameController
+	mediaController         *handler.MediaController
+	twoFactorController     *handler.TwoFactorController
+	oidcController          *handler.OIDCController
+	apiKeyController        *handler.APIKeyController
+	jwksController          *handler.JWKSController
 	swaggerRouter           *SwaggerRouter
 }
 
-func NewAppRouter(userController *handler.UserController, videoController *handler.VideoController, audioController *handler.AudioController, transcriptionController *handler.TranscriptionController, authMiddleware *middleware.AuthUserMiddleware, momoPaymentController *handler.MoMoPaymentController, swaggerRouter *SwaggerRouter) *AppRouter {
+func NewAppRouter(userController *handler.UserController, videoController *handler.VideoController, audioController *handler.AudioController, transcriptionController *handler.TranscriptionController, authMiddleware *middleware.AuthUserMiddleware, ownershipMiddleware *middleware.OwnershipMiddleware, momoPaymentController *handler.MoMoPaymentController, billingController *handler.BillingController, adminController *handler.AdminController, translationController *handler.TranslationController, searchController *handler.SearchController, uploadController *handler.UploadController, frameController *handler.FrameController, mediaController *handler.MediaController, twoFactorController *handler.TwoFactorController, oidcController *handler.OIDCController, apiKeyController *handler.APIKeyController, jwksController *handler.JWKSController, swaggerRouter *SwaggerRouter) *AppRouter {
 	return &AppRouter{
 		userController:          userController,
 		videoController:         videoController,
 		audioController:         audioController,
 		transcriptionController: transcriptionController,
 		authMiddleware:          authMiddleware,
+		ownershipMiddleware:     ownershipMiddleware,
 		momoPaymentController:   momoPaymentController,
+		billingController:       billingController,
+		adminController:         adminController,
+		translationController:   translationController,
+		searchController:        searchController,
+		uploadController:        uploadController,
+		frameController:         frameController,
+		mediaController:         mediaController,
+		twoFactorController:     twoFactorController,
+		oidcController:          oidcController,
+		apiKeyController:        apiKeyController,
+		jwksController:          jwksController,
 		swaggerRouter:           swaggerRouter,
 	}
 }
@@ -35,70 +60,139 @@ func (a *AppRouter) RegisterUserRoutes(r *gin.RouterGroup) {
 	{
 		public.POST("/register", a.userController.RegisterUser)
 		public.POST("/login", a.userController.LoginUser)
+		public.POST("/login/2fa", a.userController.LoginTwoFactor) // Authenticated by the challenge token from login
+		public.POST("/refresh", a.userController.RefreshToken)     // Authenticated by the refresh token in the body
+		public.POST("/logout", a.userController.Logout)
+		public.POST("/verify-email", a.userController.VerifyEmail)       // Authenticated by the emailed token
+		public.POST("/forgot-password", a.userController.ForgotPassword) // Emails a password reset link
+		public.POST("/reset-password", a.userController.ResetPassword)   // Authenticated by the emailed token
+		public.GET("/oidc/providers", a.oidcController.ListOIDCProviders)
+		public.GET("/oidc/:provider/authorize", a.oidcController.AuthorizeOIDC) // Provider page to sign in on
+		public.POST("/oidc/:provider/callback", a.oidcController.OIDCCallback)  // Authenticated by the code from the provider
 	}
 
 	protected := r.Group("/users")
-	protected.Use(a.authMiddleware.MustAuth())
+	protected.Use(a.authMiddleware.MustAuth())               // Signed-in users only; API keys cannot manage accounts
+	protected.Use(a.ownershipMiddleware.OwnsUser("user_id")) // Users may only act on their own account
 	{
 		protected.GET("/:user_id", a.userController.GetUser)
 		protected.PUT("/:user_id", a.userController.UpdateUser)
 		protected.DELETE("/:user_id", a.userController.DeleteUser)
 		protected.PUT("/:user_id/change-password", a.userController.ChangePassword)
-		protected.PUT("/:user_id/update-avatar", a.userController.UpdateAvatar)                    // Avatar upload (presigned URL)
-		protected.GET("/:user_id/avatar-download-url", a.userController.GenerateAvatarDownloadURL) // Avatar download (presigned URL)
-		protected.GET("/:user_id/avatar", a.userController.LoadAvatar)                             // Load avatar directly
+		protected.PUT("/:user_id/update-avatar", a.userController.UpdateAvatar)                       // Avatar upload (presigned URL)
+		protected.GET("/:user_id/avatar-download-url", a.userController.GenerateAvatarDownloadURL)    // Avatar download (presigned URL)
+		protected.GET("/:user_id/avatar", a.userController.LoadAvatar)                                // Load avatar directly
+		protected.POST("/:user_id/send-verification-email", a.userController.SendVerificationEmail)   // Resend the email verification link
+		protected.POST("/:user_id/2fa/enroll", a.twoFactorController.EnrollTwoFactor)                 // New TOTP secret and QR code
+		protected.POST("/:user_id/2fa/enable", a.twoFactorController.EnableTwoFactor)                 // Confirm a code and turn on 2FA
+		protected.POST("/:user_id/2fa/disable", a.twoFactorController.DisableTwoFactor)               // Turn off 2FA
+		protected.GET("/:user_id/2fa/recovery-codes", a.twoFactorController.CountRecoveryCodes)       // Unused recovery codes left
+		protected.POST("/:user_id/2fa/recovery-codes", a.twoFactorController.RegenerateRecoveryCodes) // Replace the recovery codes
+		protected.POST("/:user_id/api-keys", a.apiKeyController.CreateAPIKey)                         // New API key for scripts; shown once
+		protected.GET("/:user_id/api-keys", a.apiKeyController.ListAPIKeys)
+		protected.DELETE("/:user_id/api-keys/:key_id", a.apiKeyController.RevokeAPIKey)
 	}
 }
 
 // RegisterVideoRoutes sets up the routes for video-related operations
 func (a *AppRouter) RegisterVideoRoutes(r *gin.RouterGroup) {
+	ownsVideo := a.ownershipMiddleware.OwnsVideo("video_id")
+	ownsUpload := a.ownershipMiddleware.OwnsUpload("upload_id")
+
 	protected := r.Group("/videos")
+	protected.Use(a.authMiddleware.MustAuth(entity.APIKeyScopeVideosRead)) // API keys can only read
+	protected.Use(middleware.RequireVerifiedEmail())                       // Unverified users can only read
+	{
+		protected.GET("/:video_id", ownsVideo, a.videoController.GetVideoByID)                                           // Get video by ID
+		protected.GET("/user/:user_id", a.ownershipMiddleware.OwnsUser("user_id"), a.videoController.ListVideosByUserID) // List videos by user ID
+		protected.DELETE("/:video_id", ownsVideo, a.videoController.DeleteVideo)                                         // Delete video by ID
+		protected.GET("/:video_id/status", ownsVideo, a.videoController.GetVideoStatus)                                  // Get video status
+		protected.PUT("/:video_id/status", ownsVideo, a.videoController.UpdateVideoStatus)                               // Update video status
+		protected.GET("/:video_id/status/history", ownsVideo, a.videoController.GetVideoStatusHistory)                   // Get video status history
+		protected.POST("/generate-upload-url/image", a.videoController.GenerateUploadURLForImage)                        // Generate presigned upload URL for image
+		protected.GET("/:video_id/download-url/video", ownsVideo, a.videoController.GenerateDownloadURLForVideo)         // Generate presigned download URL for video
+		protected.GET("/:video_id/download-url/image", ownsVideo, a.videoController.GenerateDownloadURLForImage)         // Generate presigned download URL for image
+		protected.POST("/uploads", a.ownershipMiddleware.OwnsPayload(), a.uploadController.CreateUpload)                 // Start a verified upload (presigned URL)
+		protected.GET("/uploads/:upload_id", ownsUpload, a.uploadController.GetUpload)                                   // Get upload state
+		protected.DELETE("/uploads/:upload_id", ownsUpload, a.uploadController.AbortUpload)                              // Abort an upload and remove its data
+		protected.POST("/uploads/:upload_id/parts", ownsUpload, a.uploadController.PresignUploadParts)                   // Presigned URLs for multipart upload parts
+		protected.GET("/uploads/:upload_id/parts", ownsUpload, a.uploadController.ListUploadParts)                       // Parts received so far (resume)
+		protected.POST("/uploads/:upload_id/finalize", ownsUpload, a.uploadController.FinalizeUpload)                    // Verify the stored file and create the video
+		protected.POST("/:video_id/frames", ownsVideo, a.frameController.AddFrames)                                      // Register uploaded keyframes
+		protected.GET("/:video_id/frames", ownsVideo, a.frameController.ListFrames)                                      // List keyframes
+		protected.PUT("/:video_id/frames/:frame_id/primary", ownsVideo, a.frameController.SetPrimaryFrame)               // Select the thumbnail
+		protected.DELETE("/:video_id/frames/:frame_id", ownsVideo, a.frameController.DeleteFrame)                        // Delete a keyframe
+		protected.POST("/:video_id/probe", ownsVideo, a.mediaController.ProbeVideo)                                      // Measure duration and streams of the uploaded file
+	}
+}
+
+// RegisterTranslationRoutes sets up the routes for translation-related operations
+func (a *AppRouter) RegisterTranslationRoutes(r *gin.RouterGroup) {
+	ownsTranslation := a.ownershipMiddleware.OwnsTranslation("translation_id")
+
+	protected := r.Group("/translations")
 	protected.Use(a.authMiddleware.MustAuth())
+	protected.Use(middleware.RequireVerifiedEmail()) // Unverified users can only read
 	{
-		protected.POST("/", a.videoController.AddVideo)                                               // Add a new video
-		protected.GET("/:video_id", a.videoController.GetVideoByID)                                   // Get video by ID
-		protected.GET("/user/:user_id", a.videoController.ListVideosByUserID)                         // List videos by user ID
-		protected.DELETE("/:video_id", a.videoController.DeleteVideo)                                 // Delete video by ID
-		protected.GET("/:video_id/status", a.videoController.GetVideoStatus)                          // Get video status
-		protected.PUT("/:video_id/status", a.videoController.UpdateVideoStatus)                       // Update video status
-		protected.POST("/generate-upload-url/video", a.videoController.GenerateUploadURLForVideo)     // Generate presigned upload URL for video
-		protected.POST("/generate-upload-url/image", a.videoController.GenerateUploadURLForImage)     // Generate presigned upload URL for image
-		protected.GET("/:video_id/download-url/video", a.videoController.GenerateDownloadURLForVideo) // Generate presigned download URL for video
-		protected.GET("/:video_id/download-url/image", a.videoController.GenerateDownloadURLForImage) // Generate presigned download URL for image
+		protected.POST("/", a.ownershipMiddleware.OwnsPayload(), a.translationController.CreateTranslations)                              // Request translations of a video
+		protected.GET("/:translation_id", ownsTranslation, a.translationController.GetTranslation)                                        // Get translation by ID
+		protected.DELETE("/:translation_id", ownsTranslation, a.translationController.DeleteTranslation)                                  // Delete translation by ID
+		protected.GET("/video/:video_id", a.ownershipMiddleware.OwnsVideo("video_id"), a.translationController.ListTranslationsByVideoID) // List translations by video ID
+		protected.GET("/user/:user_id", a.ownershipMiddleware.OwnsUser("user_id"), a.translationController.ListTranslationsByUserID)      // List translations by user ID
+
+		// Artifacts and status come from the processing pipeline; failing a translation refunds its minutes
+		protected.PUT("/:translation_id", middleware.RequirePermission(middleware.PermissionManageTranslations), a.translationController.UpdateTranslation)
 	}
 }
 
+// RegisterSearchRoutes sets up the full-text search route
+func (a *AppRouter) RegisterSearchRoutes(r *gin.RouterGroup) {
+	r.GET("/search", a.authMiddleware.MustAuth(), a.searchController.Search) // Search the caller's videos and transcriptions
+}
+
 // RegisterTranscriptionRoutes sets up the routes for transcription-related operations
 func (a *AppRouter) RegisterTranscriptionRoutes(r *gin.RouterGroup) {
+	ownsTranscription := a.ownershipMiddleware.OwnsTranscription("transcription_id")
+
 	protected := r.Group("/transcriptions")
-	protected.Use(a.authMiddleware.MustAuth()) // Require authentication
+	protected.Use(a.authMiddleware.MustAuth(entity.APIKeyScopeTranscriptionsWrite)) // Require authentication
+	protected.Use(middleware.RequireVerifiedEmail())                                // Unverified users can only read
 	{
-		protected.POST("/", a.transcriptionController.AddTranscription)                                        // Add a new transcription
-		protected.GET("/:transcriptionID", a.transcriptionController.GetTranscriptionByID)                     // Get transcription by ID
-		protected.GET("/:transcriptionID/user/:userID", a.transcriptionController.GetTranscriptionByUserID)    // Get transcription by transcription ID and user ID
-		protected.GET("/:transcriptionID/video/:videoID", a.transcriptionController.GetTranscriptionByVideoID) // Get transcription by transcription ID and video ID
-		protected.GET("/user/:user_id", a.transcriptionController.ListTranscriptionsByUserID)                  // List transcriptions by user ID
-		protected.GET("/video/:video_id", a.transcriptionController.ListTranscriptionsByVideoID)               // List transcriptions by video ID
-		protected.DELETE("/:transcriptionID", a.transcriptionController.DeleteTranscription)                   // Delete transcription by ID
-		protected.POST("/generate-upload-url", a.transcriptionController.GenerateUploadURL)                    // Generate presigned upload URL
-		protected.GET("/:transcriptionID/download-url", a.transcriptionController.GenerateDownloadURL)         // Generate presigned download URL
+		protected.POST("/", a.ownershipMiddleware.OwnsPayload(), a.transcriptionController.AddTranscription)                                             // Add a new transcription
+		protected.GET("/:transcription_id", ownsTranscription, a.transcriptionController.GetTranscriptionByID)                                           // Get transcription by ID
+		protected.GET("/:transcription_id/user/:user_id", a.ownershipMiddleware.OwnsUser("user_id"), a.transcriptionController.GetTranscriptionByUserID) // Get transcription by transcription ID and user ID
+		protected.GET("/:transcription_id/video/:video_id", ownsTranscription, a.transcriptionController.GetTranscriptionByVideoID)                      // Get transcription by transcription ID and video ID
+		protected.GET("/user/:user_id", a.ownershipMiddleware.OwnsUser("user_id"), a.transcriptionController.ListTranscriptionsByUserID)                 // List transcriptions by user ID
+		protected.GET("/video/:video_id", a.ownershipMiddleware.OwnsVideo("video_id"), a.transcriptionController.ListTranscriptionsByVideoID)            // List transcriptions by video ID
+		protected.DELETE("/:transcription_id", ownsTranscription, a.transcriptionController.DeleteTranscription)                                         // Delete transcription by ID
+		protected.POST("/generate-upload-url", a.transcriptionController.GenerateUploadURL)                                                              // Generate presigned upload URL
+		protected.GET("/:transcription_id/download-url", ownsTranscription, a.transcriptionController.GenerateDownloadURL)                               // Generate presigned download URL
+		protected.GET("/:transcription_id/segments", ownsTranscription, a.transcriptionController.ListSegments)                                          // List timed segments
+		protected.POST("/:transcription_id/segments", ownsTranscription, a.transcriptionController.CreateSegments)                                       // Append timed segments
+		protected.PUT("/:transcription_id/segments", ownsTranscription, a.transcriptionController.ReplaceSegments)                                       // Replace all timed segments
+		protected.GET("/:transcription_id/export", ownsTranscription, a.transcriptionController.ExportTranscription)                                     // Export as SRT, WebVTT or JSON
+		protected.POST("/:transcription_id/import", ownsTranscription, a.transcriptionController.ImportSubtitles)                                        // Import segments from an SRT or WebVTT file
 	}
 }
 
 // RegisterAudioRoutes sets up the routes for audio-related operations
 func (a *AppRouter) RegisterAudioRoutes(r *gin.RouterGroup) {
+	ownsAudio := a.ownershipMiddleware.OwnsAudio("audio_id")
+
 	protected := r.Group("/audios")
 	protected.Use(a.authMiddleware.MustAuth())
+	protected.Use(middleware.RequireVerifiedEmail()) // Unverified users can only read
 	{
-		protected.POST("/", a.audioController.AddAudio)                                // Add a new audio
-		protected.GET("/:audioID", a.audioController.GetAudio)                         // Get a specific audio by ID
-		protected.DELETE("/:audioID", a.audioController.DeleteAudio)                   // Delete an audio
-		protected.GET("/user/:userID", a.audioController.ListAudiosByUserID)           // Get all audios by user
-		protected.GET("/video/:videoID", a.audioController.ListAudiosByVideoID)        // Get all audios by video
-		protected.GET("/:audioID/user/:userID", a.audioController.GetAudioByUser)      // Get specific audio by audio ID and user ID
-		protected.GET("/:audioID/video/:videoID", a.audioController.GetAudioByVideoID) // Get specific audio by audio ID and video ID
-		protected.POST("/generate-presigned-url", a.audioController.GenerateUploadURL) // Generate presigned URL for audio upload
-		protected.GET("/:audioID/download-url", a.audioController.GenerateDownloadURL) // Generate presigned URL for audio download
+		protected.POST("/", a.ownershipMiddleware.OwnsPayload(), a.audioController.AddAudio)                                   // Add a new audio
+		protected.GET("/:audio_id", ownsAudio, a.audioController.GetAudio)                                                     // Get a specific audio by ID
+		protected.DELETE("/:audio_id", ownsAudio, a.audioController.DeleteAudio)                                               // Delete an audio
+		protected.GET("/user/:user_id", a.ownershipMiddleware.OwnsUser("user_id"), a.audioController.ListAudiosByUserID)       // Get all audios by user
+		protected.GET("/video/:video_id", a.ownershipMiddleware.OwnsVideo("video_id"), a.audioController.ListAudiosByVideoID)  // Get all audios by video
+		protected.GET("/:audio_id/user/:user_id", a.ownershipMiddleware.OwnsUser("user_id"), a.audioController.GetAudioByUser) // Get specific audio by audio ID and user ID
+		protected.GET("/:audio_id/video/:video_id", ownsAudio, a.audioController.GetAudioByVideoID)                            // Get specific audio by audio ID and video ID
+		protected.POST("/generate-presigned-url", a.audioController.GenerateUploadURL)                                         // Generate presigned URL for audio upload
+		protected.GET("/:audio_id/download-url", ownsAudio, a.audioController.GenerateDownloadURL)                             // Generate presigned URL for audio download
+		protected.POST("/:audio_id/probe", ownsAudio, a.mediaController.ProbeAudio)                                            // Measure duration and streams of the uploaded file
 	}
 }
 
@@ -106,18 +200,69 @@ func (a *AppRouter) RegisterAudioRoutes(r *gin.RouterGroup) {
 func (a *AppRouter) RegisterPaymentRoutes(r *gin.RouterGroup) {
 	payment := r.Group("/payments")
 	{
+		// MoMo posts payment results here; the notifications are signed, so no login is needed
+		payment.POST("/momo/ipn", a.momoPaymentController.MoMoIPN)
+
 		// Group for MoMo-specific routes
 		momo := payment.Group("/momo")
+		momo.Use(a.authMiddleware.MustAuth(entity.APIKeyScopePayments))
 		{
 			momo.POST("/create", a.momoPaymentController.CreateMoMoPayment)     // Create MoMo payment and return QR code
 			momo.POST("/check-status", a.momoPaymentController.CheckMoMoStatus) // Check status of MoMo payment
-			momo.POST("/refund", a.momoPaymentController.RefundMoMoPayment)     // Refund MoMo payment
+			// Refunds are for admins: users could otherwise be paid back for minutes they have spent
+			momo.POST("/refund", middleware.RequirePermission(middleware.PermissionRefundPayment), a.momoPaymentController.RefundMoMoPayment)
 		}
 
 		// More payment methods can be added here...
 	}
 }
 
+// RegisterBillingRoutes sets up the routes for products, orders and balances of translation minutes
+func (a *AppRouter) RegisterBillingRoutes(r *gin.RouterGroup) {
+	public := r.Group("/billing")
+	{
+		public.GET("/products", a.billingController.ListProducts) // Credit packs and subscriptions for sale
+	}
+
+	protected := r.Group("/billing/users")
+	protected.Use(a.authMiddleware.MustAuth(entity.APIKeyScopePayments))
+	protected.Use(a.ownershipMiddleware.OwnsUser("user_id")) // Users may only see their own orders and balance
+	{
+		protected.POST("/:user_id/orders", a.billingController.CreateOrder) // Order a product; pay for it with its order ID
+		protected.GET("/:user_id/orders", a.billingController.ListOrders)
+		protected.GET("/:user_id/orders/:order_id", a.billingController.GetOrder)
+		protected.GET("/:user_id/balance", a.billingController.GetBalance)       // Translation minutes left
+		protected.GET("/:user_id/ledger", a.billingController.ListLedgerEntries) // What added to or took from the balance
+	}
+}
+
+// RegisterAdminRoutes sets up the routes for administrative operations; every route requires the admin role
+func (a *AppRouter) RegisterAdminRoutes(r *gin.RouterGroup) {
+	admin := r.Group("/admin")
+	admin.Use(a.authMiddleware.MustAuth())
+	admin.Use(middleware.RequireRole(entity.RoleAdmin))
+	{
+		admin.GET("/users", middleware.RequirePermission(middleware.PermissionViewUsers), a.adminController.ListUsers)                          // List all users
+		admin.GET("/users/search", middleware.RequirePermission(middleware.PermissionViewUsers), a.adminController.SearchUsers)                 // Search users by text, role and status
+		admin.PUT("/users/:user_id/suspend", middleware.RequirePermission(middleware.PermissionManageUsers), a.adminController.SuspendUser)     // Suspend a user
+		admin.PUT("/users/:user_id/reinstate", middleware.RequirePermission(middleware.PermissionManageUsers), a.adminController.ReinstateUser) // Reinstate a suspended user
+		admin.GET("/users/locked", middleware.RequirePermission(middleware.PermissionViewUsers), a.adminController.ListLockedAccounts)          // List accounts locked after failed sign-ins
+		admin.PUT("/users/:user_id/unlock", middleware.RequirePermission(middleware.PermissionManageUsers), a.adminController.UnlockUser)       // Unlock an account locked after failed sign-ins
+		admin.PUT("/users/:user_id/role", middleware.RequirePermission(middleware.PermissionManageRoles), a.adminController.ChangeUserRole)     // Change a user's role
+		admin.GET("/audit-logs", middleware.RequirePermission(middleware.PermissionViewAuditLogs), a.adminController.ListAuditLogs)             // List admin audit records
+		// Logged history of an order's payment
+		admin.GET("/payments/:order_id/events", middleware.RequirePermission(middleware.PermissionViewPayments), a.momoPaymentController.ListPaymentEvents)
+	}
+}
+
+// RegisterWellKnownRoutes sets up the /.well-known routes other services discover this one through
+func (a *AppRouter) RegisterWellKnownRoutes(r *gin.RouterGroup) {
+	wellKnown := r.Group("/.well-known")
+	{
+		wellKnown.GET("/jwks.json", a.jwksController.GetJWKS) // Public keys that verify access tokens
+	}
+}
+
 // RegisterSwaggerRoutes sets up the route for Swagger API documentation
 func (a *AppRouter) RegisterSwaggerRoutes(r *gin.RouterGroup) {
 	// Check if SwaggerRouter is initialized before registering
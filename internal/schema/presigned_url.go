@@ -6,5 +6,5 @@ type GeneratePresignedURLRequest struct {
 	FileName string `json:"file_name" validate:"required"` // Name of the video file to be uploaded
 	FileType string `json:"file_type" validate:"required"` // Type of the video file (e.g., video/mp4)
 	Title    string `json:"title" validate:"required"`     // Title of the video
-	Duration int    `json:"duration" validate:"required"`  // Expected duration of the video
+	Duration int    `json:"duration"`                      // Optional; measured from the file once it is uploaded
 }
@@ -20,7 +20,7 @@ type AddVideoRequest struct {
 	UserID   uint64 `json:"user_id" validate:"required"`
 	Title    string `json:"title" validate:"required"` // Add Title field
 	Link     string `json:"link" validate:"required,url"`
-	Duration int    `json:"duration" validate:"required"`
+	Duration int    `json:"duration"` // Optional; measured from the file once it is uploaded
 }
 
 // GetVideosResponse represents the response structure for fetching a list of videos
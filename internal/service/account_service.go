@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/mail"
+	"mlvt/internal/infra/zap-logging/log"
+	"mlvt/internal/repo"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidAccountToken  = errors.New("invalid or expired token")
+	ErrEmailAlreadyVerified = errors.New("email address already verified")
+)
+
+// Defaults used when an AccountConfig field is left at zero
+const (
+	DefaultPasswordResetTTL     = time.Hour
+	DefaultEmailVerificationTTL = 48 * time.Hour
+	DefaultAppURL               = "http://localhost:3000"
+)
+
+// AccountConfig controls the links emailed for password resets and email verification
+type AccountConfig struct {
+	AppURL               string        // Base URL of the web app; links point to its /reset-password and /verify-email pages
+	PasswordResetTTL     time.Duration // How long a password reset link works
+	EmailVerificationTTL time.Duration // How long an email verification link works
+}
+
+// withDefaults fills zero fields with the package defaults
+func (c AccountConfig) withDefaults() AccountConfig {
+	if c.AppURL == "" {
+		c.AppURL = DefaultAppURL
+	}
+	c.AppURL = strings.TrimRight(c.AppURL, "/")
+	if c.PasswordResetTTL <= 0 {
+		c.PasswordResetTTL = DefaultPasswordResetTTL
+	}
+	if c.EmailVerificationTTL <= 0 {
+		c.EmailVerificationTTL = DefaultEmailVerificationTTL
+	}
+	return c
+}
+
+// AccountService runs the account flows that prove ownership of an email address by sending a single-use link
+type AccountService interface {
+	SendVerificationEmail(ctx context.Context, userID uint64) error
+	VerifyEmail(token string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(token, newPassword string) error
+}
+
+type accountService struct {
+	userRepo  repo.UserRepository
+	tokenRepo repo.UserTokenRepository
+	auth      AuthServiceInterface
+	mailer    mail.Mailer
+	config    AccountConfig
+	now       func() time.Time
+}
+
+func NewAccountService(userRepo repo.UserRepository, tokenRepo repo.UserTokenRepository, auth AuthServiceInterface,
+	mailer mail.Mailer, config AccountConfig) AccountService {
+	return &accountService{
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
+		auth:      auth,
+		mailer:    mailer,
+		config:    config.withDefaults(),
+		now:       time.Now,
+	}
+}
+
+// SendVerificationEmail emails the user a link confirming their address; earlier links stop working
+func (s *accountService) SendVerificationEmail(ctx context.Context, userID uint64) error {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	if user.EmailVerified() {
+		return ErrEmailAlreadyVerified
+	}
+
+	token, err := s.issueToken(user.ID, entity.TokenPurposeEmailVerification, s.config.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hello %s,\n\nConfirm your email address by opening this link:\n\n%s\n\n"+
+			"The link expires in %s. If you did not create an account, ignore this email.\n",
+			user.FirstName, s.link("/verify-email", token), describeDuration(s.config.EmailVerificationTTL)),
+	})
+}
+
+// VerifyEmail marks the address of the token's user as verified
+func (s *accountService) VerifyEmail(token string) error {
+	userToken, err := s.tokenRepo.ConsumeUserToken(entity.TokenPurposeEmailVerification, hashToken(token))
+	if err != nil {
+		return err
+	}
+	if userToken == nil {
+		return ErrInvalidAccountToken
+	}
+	return s.userRepo.MarkEmailVerified(userToken.UserID)
+}
+
+// ForgotPassword emails a password reset link if an active account uses the address.
+// Unknown addresses are not reported, so the endpoint cannot be used to find out who has an account.
+func (s *accountService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetUserByEmail(normalizeEmail(email))
+	if err != nil {
+		return err
+	}
+	if user == nil || user.Status == entity.UserStatusSuspended || user.Status == entity.UserStatusDeleted {
+		log.Infof("Password reset requested for unknown or inactive account %q", email)
+		return nil
+	}
+
+	token, err := s.issueToken(user.ID, entity.TokenPurposePasswordReset, s.config.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\nChoose a new password by opening this link:\n\n%s\n\n"+
+			"The link expires in %s and works once. If you did not ask to reset your password, ignore this email.\n",
+			user.FirstName, s.link("/reset-password", token), describeDuration(s.config.PasswordResetTTL)),
+	})
+}
+
+// ResetPassword sets a new password and signs the user out everywhere. Following the emailed link
+// also proves ownership of the address, so it is marked verified.
+func (s *accountService) ResetPassword(token, newPassword string) error {
+	userToken, err := s.tokenRepo.ConsumeUserToken(entity.TokenPurposePasswordReset, hashToken(token))
+	if err != nil {
+		return err
+	}
+	if userToken == nil {
+		return ErrInvalidAccountToken
+	}
+
+	user, err := s.userRepo.GetUserByID(userToken.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.Status == entity.UserStatusSuspended || user.Status == entity.UserStatusDeleted {
+		return ErrInvalidAccountToken
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdateUserPassword(user.ID, string(hashedPassword)); err != nil {
+		return err
+	}
+	if err := s.userRepo.MarkEmailVerified(user.ID); err != nil {
+		return err
+	}
+	return s.auth.RevokeSessions(user.ID)
+}
+
+// issueToken stores the hash of a new single-use token and returns the token
+func (s *accountService) issueToken(userID uint64, purpose string, ttl time.Duration) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	err = s.tokenRepo.CreateUserToken(&entity.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		ExpiresAt: s.now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// link returns the web app URL of page carrying the token
+func (s *accountService) link(page, token string) string {
+	return s.config.AppURL + page + "?token=" + url.QueryEscape(token)
+}
+
+// describeDuration writes d in the largest whole unit for use in emails, e.g. "2 days" or "90 minutes"
+func describeDuration(d time.Duration) string {
+	unit, size := "minute", time.Minute
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		unit, size = "day", 24*time.Hour
+	case d >= time.Hour && d%time.Hour == 0:
+		unit, size = "hour", time.Hour
+	}
+	n := int64(d / size)
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/mail"
+	"mlvt/internal/repo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type accountServiceMocks struct {
+	userRepo  *repo.MockUserRepository
+	tokenRepo *repo.MockUserTokenRepository
+	auth      *MockAuthService
+	mailer    *mail.MockMailer
+}
+
+func newTestAccountService() (AccountService, accountServiceMocks) {
+	mocks := accountServiceMocks{
+		userRepo:  new(repo.MockUserRepository),
+		tokenRepo: new(repo.MockUserTokenRepository),
+		auth:      new(MockAuthService),
+		mailer:    new(mail.MockMailer),
+	}
+	service := NewAccountService(mocks.userRepo, mocks.tokenRepo, mocks.auth, mocks.mailer,
+		AccountConfig{AppURL: "https://app.example.com/"})
+	return service, mocks
+}
+
+// tokenFromLink extracts the token from the link in an emailed message
+func tokenFromLink(t *testing.T, body, page string) string {
+	start := strings.Index(body, "https://app.example.com"+page+"?token=")
+	if !assert.NotEqual(t, -1, start, body) {
+		return ""
+	}
+	link := strings.Fields(body[start:])[0]
+	parsed, err := url.Parse(link)
+	assert.NoError(t, err)
+	return parsed.Query().Get("token")
+}
+
+func TestSendVerificationEmail(t *testing.T) {
+	accountService, mocks := newTestAccountService()
+	mocks.userRepo.On("GetUserByID", uint64(1)).Return(&entity.User{ID: 1, Email: "john@example.com", FirstName: "John"}, nil)
+
+	var stored *entity.UserToken
+	mocks.tokenRepo.On("CreateUserToken", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(*entity.UserToken)
+	}).Return(nil)
+	var sent mail.Message
+	mocks.mailer.On("Send", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		sent = args.Get(1).(mail.Message)
+	}).Return(nil)
+
+	err := accountService.SendVerificationEmail(context.Background(), 1)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "john@example.com", sent.To)
+	assert.Contains(t, sent.Body, "expires in 2 days")
+	token := tokenFromLink(t, sent.Body, "/verify-email")
+	assert.Equal(t, entity.TokenPurposeEmailVerification, stored.Purpose)
+	assert.Equal(t, hashToken(token), stored.TokenHash)
+	assert.WithinDuration(t, time.Now().Add(DefaultEmailVerificationTTL), stored.ExpiresAt, time.Minute)
+}
+
+func TestSendVerificationEmail_AlreadyVerified(t *testing.T) {
+	accountService, mocks := newTestAccountService()
+	verifiedAt := time.Now()
+	mocks.userRepo.On("GetUserByID", uint64(1)).Return(&entity.User{ID: 1, EmailVerifiedAt: &verifiedAt}, nil)
+
+	err := accountService.SendVerificationEmail(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrEmailAlreadyVerified)
+	mocks.mailer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+}
+
+func TestVerifyEmail(t *testing.T) {
+	accountService, mocks := newTestAccountService()
+	mocks.tokenRepo.On("ConsumeUserToken", entity.TokenPurposeEmailVerification, hashToken("good")).
+		Return(&entity.UserToken{UserID: 1}, nil)
+	mocks.tokenRepo.On("ConsumeUserToken", entity.TokenPurposeEmailVerification, hashToken("bad")).Return(nil, nil)
+	mocks.userRepo.On("MarkEmailVerified", uint64(1)).Return(nil)
+
+	assert.NoError(t, accountService.VerifyEmail("good"))
+	assert.ErrorIs(t, accountService.VerifyEmail("bad"), ErrInvalidAccountToken)
+	mocks.userRepo.AssertExpectations(t)
+}
+
+func TestForgotPassword_UnknownEmail(t *testing.T) {
+	accountService, mocks := newTestAccountService()
+	mocks.userRepo.On("GetUserByEmail", "nobody@example.com").Return(nil, nil)
+
+	err := accountService.ForgotPassword(context.Background(), "nobody@example.com")
+	assert.NoError(t, err)
+	mocks.tokenRepo.AssertNotCalled(t, "CreateUserToken", mock.Anything)
+	mocks.mailer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+}
+
+func TestForgotAndResetPassword(t *testing.T) {
+	accountService, mocks := newTestAccountService()
+	user := &entity.User{ID: 1, Email: "john@example.com", Status: entity.UserStatusAvailable}
+	mocks.userRepo.On("GetUserByEmail", "john@example.com").Return(user, nil)
+	mocks.tokenRepo.On("CreateUserToken", mock.MatchedBy(func(token *entity.UserToken) bool {
+		return token.UserID == 1 && token.Purpose == entity.TokenPurposePasswordReset
+	})).Return(nil)
+	var sent mail.Message
+	mocks.mailer.On("Send", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		sent = args.Get(1).(mail.Message)
+	}).Return(nil)
+
+	assert.NoError(t, accountService.ForgotPassword(context.Background(), "john@example.com"))
+	assert.Contains(t, sent.Body, "expires in 1 hour")
+	token := tokenFromLink(t, sent.Body, "/reset-password")
+
+	mocks.tokenRepo.On("ConsumeUserToken", entity.TokenPurposePasswordReset, hashToken(token)).
+		Return(&entity.UserToken{UserID: 1}, nil)
+	mocks.userRepo.On("GetUserByID", uint64(1)).Return(user, nil)
+	mocks.userRepo.On("UpdateUserPassword", uint64(1), mock.MatchedBy(func(hashed string) bool {
+		return bcrypt.CompareHashAndPassword([]byte(hashed), []byte("newPassword123")) == nil
+	})).Return(nil)
+	mocks.userRepo.On("MarkEmailVerified", uint64(1)).Return(nil)
+	mocks.auth.On("RevokeSessions", uint64(1)).Return(nil)
+
+	assert.NoError(t, accountService.ResetPassword(token, "newPassword123"))
+	mocks.userRepo.AssertExpectations(t)
+	mocks.auth.AssertExpectations(t)
+}
+
+func TestResetPassword_InvalidToken(t *testing.T) {
+	accountService, mocks := newTestAccountService()
+	mocks.tokenRepo.On("ConsumeUserToken", entity.TokenPurposePasswordReset, hashToken("used")).Return(nil, nil)
+
+	err := accountService.ResetPassword("used", "newPassword123")
+	assert.ErrorIs(t, err, ErrInvalidAccountToken)
+	mocks.userRepo.AssertNotCalled(t, "UpdateUserPassword", mock.Anything, mock.Anything)
+}
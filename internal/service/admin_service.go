@@ -0,0 +1,198 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"mlvt/internal/entity"
+	"mlvt/internal/repo"
+)
+
+// DefaultAuditLogLimit is the number of audit records returned when no limit is given
+const DefaultAuditLogLimit = 100
+
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrInvalidRole      = errors.New("invalid role")
+	ErrSelfAdminAction  = errors.New("admins cannot perform this action on their own account")
+	ErrUserDeleted      = errors.New("user has been deleted")
+	ErrUserNotSuspended = errors.New("user is not suspended")
+)
+
+type AdminService interface {
+	ListUsers() ([]entity.User, error)
+	SearchUsers(query, role string, status int) ([]entity.User, error)
+	SuspendUser(actorID, userID uint64, reason string) error
+	ReinstateUser(actorID, userID uint64, reason string) error
+	ChangeUserRole(actorID, userID uint64, role string) error
+	ListLockedAccounts() ([]entity.LoginThrottle, error)
+	UnlockUser(actorID, userID uint64) error
+	ListAuditLogs(limit int) ([]entity.AuditLog, error)
+	ListUserAuditLogs(userID uint64) ([]entity.AuditLog, error)
+}
+
+type adminService struct {
+	userRepo     repo.UserRepository
+	auditLogRepo repo.AuditLogRepository
+	auth         AuthServiceInterface
+}
+
+func NewAdminService(userRepo repo.UserRepository, auditLogRepo repo.AuditLogRepository, auth AuthServiceInterface) AdminService {
+	return &adminService{
+		userRepo:     userRepo,
+		auditLogRepo: auditLogRepo,
+		auth:         auth,
+	}
+}
+
+// ListUsers retrieves every user with password hashes stripped
+func (s *adminService) ListUsers() ([]entity.User, error) {
+	users, err := s.userRepo.GetAllUsers()
+	if err != nil {
+		return nil, err
+	}
+	return sanitizeUsers(users), nil
+}
+
+// SearchUsers filters users by a free-text query, role and status
+func (s *adminService) SearchUsers(query, role string, status int) ([]entity.User, error) {
+	users, err := s.userRepo.SearchUsers(query, role, status)
+	if err != nil {
+		return nil, err
+	}
+	return sanitizeUsers(users), nil
+}
+
+// SuspendUser marks a user as suspended and revokes their sessions so they can no longer authenticate
+func (s *adminService) SuspendUser(actorID, userID uint64, reason string) error {
+	user, err := s.targetUser(actorID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdateUserStatus(userID, entity.UserStatusSuspended); err != nil {
+		return err
+	}
+	if err := s.auth.RevokeSessions(userID); err != nil {
+		return err
+	}
+
+	return s.audit(actorID, entity.AuditActionSuspendUser, userID,
+		fmt.Sprintf("status %d -> %d; reason: %s", user.Status, entity.UserStatusSuspended, reason))
+}
+
+// ReinstateUser restores a suspended user to the available status. The change and its audit record are
+// written together; users that are not suspended are left alone.
+func (s *adminService) ReinstateUser(actorID, userID uint64, reason string) error {
+	user, err := s.targetUser(actorID, userID)
+	if err != nil {
+		return err
+	}
+	if user.Status != entity.UserStatusSuspended {
+		return ErrUserNotSuspended
+	}
+
+	changed, err := s.userRepo.ChangeUserStatus(userID, entity.UserStatusSuspended, entity.UserStatusAvailable,
+		newUserAuditLog(actorID, entity.AuditActionReinstateUser, userID,
+			fmt.Sprintf("status %d -> %d; reason: %s", user.Status, entity.UserStatusAvailable, reason)))
+	if err != nil {
+		return err
+	}
+	if !changed {
+		// Another admin changed the status since it was read
+		return ErrUserNotSuspended
+	}
+	return nil
+}
+
+// ChangeUserRole assigns a new role to a user
+func (s *adminService) ChangeUserRole(actorID, userID uint64, role string) error {
+	if !entity.IsValidRole(role) {
+		return ErrInvalidRole
+	}
+
+	user, err := s.targetUser(actorID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdateUserRole(userID, role); err != nil {
+		return err
+	}
+
+	return s.audit(actorID, entity.AuditActionChangeRole, userID, fmt.Sprintf("role %s -> %s", user.Role, role))
+}
+
+// ListLockedAccounts returns the accounts locked after repeated failed sign-ins
+func (s *adminService) ListLockedAccounts() ([]entity.LoginThrottle, error) {
+	return s.auth.ListLockedAccounts()
+}
+
+// UnlockUser lets a user locked after repeated failed sign-ins sign in again right away
+func (s *adminService) UnlockUser(actorID, userID uint64) error {
+	user, err := s.targetUser(actorID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.auth.UnlockAccount(user.Email); err != nil {
+		return err
+	}
+
+	return s.audit(actorID, entity.AuditActionUnlockUser, userID, "cleared failed sign-ins")
+}
+
+// ListAuditLogs returns the most recent audit records
+func (s *adminService) ListAuditLogs(limit int) ([]entity.AuditLog, error) {
+	if limit <= 0 {
+		limit = DefaultAuditLogLimit
+	}
+	return s.auditLogRepo.ListAuditLogs(limit)
+}
+
+// ListUserAuditLogs returns every audit record targeting the given user
+func (s *adminService) ListUserAuditLogs(userID uint64) ([]entity.AuditLog, error) {
+	return s.auditLogRepo.ListAuditLogsByTarget(entity.AuditTargetUser, userID)
+}
+
+// targetUser loads the user an admin action applies to, rejecting actions on the admin's own account
+func (s *adminService) targetUser(actorID, userID uint64) (*entity.User, error) {
+	if actorID == userID {
+		return nil, ErrSelfAdminAction
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if user.Status == entity.UserStatusDeleted {
+		return nil, ErrUserDeleted
+	}
+	return user, nil
+}
+
+// audit records an admin action performed on a user
+func (s *adminService) audit(actorID uint64, action string, userID uint64, details string) error {
+	return s.auditLogRepo.CreateAuditLog(newUserAuditLog(actorID, action, userID, details))
+}
+
+// newUserAuditLog describes an admin action performed on a user
+func newUserAuditLog(actorID uint64, action string, userID uint64, details string) *entity.AuditLog {
+	return &entity.AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: entity.AuditTargetUser,
+		TargetID:   userID,
+		Details:    details,
+	}
+}
+
+// sanitizeUsers clears password hashes before users are returned to a client
+func sanitizeUsers(users []entity.User) []entity.User {
+	for i := range users {
+		users[i].Password = ""
+	}
+	return users
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"mlvt/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAdminService is a mock implementation of the AdminService interface
+type MockAdminService struct {
+	mock.Mock
+}
+
+func (m *MockAdminService) ListUsers() ([]entity.User, error) {
+	args := m.Called()
+	users, _ := args.Get(0).([]entity.User)
+	return users, args.Error(1)
+}
+
+func (m *MockAdminService) SearchUsers(query, role string, status int) ([]entity.User, error) {
+	args := m.Called(query, role, status)
+	users, _ := args.Get(0).([]entity.User)
+	return users, args.Error(1)
+}
+
+func (m *MockAdminService) SuspendUser(actorID, userID uint64, reason string) error {
+	args := m.Called(actorID, userID, reason)
+	return args.Error(0)
+}
+
+func (m *MockAdminService) ReinstateUser(actorID, userID uint64, reason string) error {
+	args := m.Called(actorID, userID, reason)
+	return args.Error(0)
+}
+
+func (m *MockAdminService) ChangeUserRole(actorID, userID uint64, role string) error {
+	args := m.Called(actorID, userID, role)
+	return args.Error(0)
+}
+
+func (m *MockAdminService) ListAuditLogs(limit int) ([]entity.AuditLog, error) {
+	args := m.Called(limit)
+	auditLogs, _ := args.Get(0).([]entity.AuditLog)
+	return auditLogs, args.Error(1)
+}
+
+func (m *MockAdminService) ListUserAuditLogs(userID uint64) ([]entity.AuditLog, error) {
+	args := m.Called(userID)
+	auditLogs, _ := args.Get(0).([]entity.AuditLog)
+	return auditLogs, args.Error(1)
+}
+
+func (m *MockAdminService) ListLockedAccounts() ([]entity.LoginThrottle, error) {
+	args := m.Called()
+	accounts, _ := args.Get(0).([]entity.LoginThrottle)
+	return accounts, args.Error(1)
+}
+
+func (m *MockAdminService) UnlockUser(actorID, userID uint64) error {
+	args := m.Called(actorID, userID)
+	return args.Error(0)
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"testing"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/repo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSuspendUser_Success(t *testing.T) {
+	mockUserRepo := new(repo.MockUserRepository)
+	mockAuditRepo := new(repo.MockAuditLogRepository)
+	mockAuth := new(MockAuthService)
+	adminService := NewAdminService(mockUserRepo, mockAuditRepo, mockAuth)
+
+	mockUserRepo.On("GetUserByID", uint64(2)).Return(&entity.User{ID: 2, Status: entity.UserStatusAvailable}, nil)
+	mockUserRepo.On("UpdateUserStatus", uint64(2), entity.UserStatusSuspended).Return(nil)
+	mockAuth.On("RevokeSessions", uint64(2)).Return(nil)
+	mockAuditRepo.On("CreateAuditLog", mock.MatchedBy(func(auditLog *entity.AuditLog) bool {
+		return auditLog.ActorID == 1 && auditLog.Action == entity.AuditActionSuspendUser &&
+			auditLog.TargetType == entity.AuditTargetUser && auditLog.TargetID == 2 &&
+			auditLog.Details == "status 1 -> 9; reason: spam"
+	})).Return(nil)
+
+	err := adminService.SuspendUser(1, 2, "spam")
+	assert.NoError(t, err)
+
+	mockUserRepo.AssertExpectations(t)
+	mockAuditRepo.AssertExpectations(t)
+	mockAuth.AssertExpectations(t)
+}
+
+func TestSuspendUser_Self(t *testing.T) {
+	mockUserRepo := new(repo.MockUserRepository)
+	mockAuditRepo := new(repo.MockAuditLogRepository)
+	adminService := NewAdminService(mockUserRepo, mockAuditRepo, new(MockAuthService))
+
+	err := adminService.SuspendUser(1, 1, "")
+	assert.ErrorIs(t, err, ErrSelfAdminAction)
+
+	mockUserRepo.AssertNotCalled(t, "UpdateUserStatus", mock.Anything, mock.Anything)
+	mockAuditRepo.AssertNotCalled(t, "CreateAuditLog", mock.Anything)
+}
+
+func TestReinstateUser_Success(t *testing.T) {
+	mockUserRepo := new(repo.MockUserRepository)
+	mockAuditRepo := new(repo.MockAuditLogRepository)
+	adminService := NewAdminService(mockUserRepo, mockAuditRepo, new(MockAuthService))
+
+	mockUserRepo.On("GetUserByID", uint64(2)).Return(&entity.User{ID: 2, Status: entity.UserStatusSuspended}, nil)
+	mockUserRepo.On("ChangeUserStatus", uint64(2), entity.UserStatusSuspended, entity.UserStatusAvailable,
+		mock.MatchedBy(func(auditLog *entity.AuditLog) bool {
+			return auditLog.ActorID == 1 && auditLog.Action == entity.AuditActionReinstateUser &&
+				auditLog.TargetID == 2 && auditLog.Details == "status 9 -> 1; reason: appeal"
+		})).Return(true, nil)
+
+	err := adminService.ReinstateUser(1, 2, "appeal")
+	assert.NoError(t, err)
+
+	mockUserRepo.AssertExpectations(t)
+	// The audit record is written with the status change, not on its own
+	mockAuditRepo.AssertNotCalled(t, "CreateAuditLog", mock.Anything)
+}
+
+func TestReinstateUser_NotSuspended(t *testing.T) {
+	mockUserRepo := new(repo.MockUserRepository)
+	adminService := NewAdminService(mockUserRepo, new(repo.MockAuditLogRepository), new(MockAuthService))
+
+	mockUserRepo.On("GetUserByID", uint64(2)).Return(&entity.User{ID: 2, Status: entity.UserStatusAvailable}, nil).Once()
+	assert.ErrorIs(t, adminService.ReinstateUser(1, 2, ""), ErrUserNotSuspended)
+	mockUserRepo.AssertNotCalled(t, "ChangeUserStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	// The user was reinstated by someone else after being read
+	mockUserRepo.On("GetUserByID", uint64(2)).Return(&entity.User{ID: 2, Status: entity.UserStatusSuspended}, nil).Once()
+	mockUserRepo.On("ChangeUserStatus", uint64(2), entity.UserStatusSuspended, entity.UserStatusAvailable, mock.Anything).Return(false, nil)
+	assert.ErrorIs(t, adminService.ReinstateUser(1, 2, ""), ErrUserNotSuspended)
+}
+
+func TestReinstateUser_NotFound(t *testing.T) {
+	mockUserRepo := new(repo.MockUserRepository)
+	mockAuditRepo := new(repo.MockAuditLogRepository)
+	adminService := NewAdminService(mockUserRepo, mockAuditRepo, new(MockAuthService))
+
+	mockUserRepo.On("GetUserByID", uint64(2)).Return(nil, nil)
+
+	err := adminService.ReinstateUser(1, 2, "")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	mockAuditRepo.AssertNotCalled(t, "CreateAuditLog", mock.Anything)
+}
+
+func TestUnlockUser_Success(t *testing.T) {
+	mockUserRepo := new(repo.MockUserRepository)
+	mockAuditRepo := new(repo.MockAuditLogRepository)
+	mockAuth := new(MockAuthService)
+	adminService := NewAdminService(mockUserRepo, mockAuditRepo, mockAuth)
+
+	mockUserRepo.On("GetUserByID", uint64(2)).Return(&entity.User{ID: 2, Email: "jane@example.com"}, nil)
+	mockAuth.On("UnlockAccount", "jane@example.com").Return(nil)
+	mockAuditRepo.On("CreateAuditLog", mock.MatchedBy(func(auditLog *entity.AuditLog) bool {
+		return auditLog.ActorID == 1 && auditLog.Action == entity.AuditActionUnlockUser && auditLog.TargetID == 2
+	})).Return(nil)
+
+	err := adminService.UnlockUser(1, 2)
+	assert.NoError(t, err)
+
+	mockAuth.AssertExpectations(t)
+	mockAuditRepo.AssertExpectations(t)
+}
+
+func TestChangeUserRole_Success(t *testing.T) {
+	mockUserRepo := new(repo.MockUserRepository)
+	mockAuditRepo := new(repo.MockAuditLogRepository)
+	adminService := NewAdminService(mockUserRepo, mockAuditRepo, new(MockAuthService))
+
+	mockUserRepo.On("GetUserByID", uint64(2)).Return(&entity.User{ID: 2, Role: entity.RoleUser, Status: entity.UserStatusAvailable}, nil)
+	mockUserRepo.On("UpdateUserRole", uint64(2), entity.RoleAdmin).Return(nil)
+	mockAuditRepo.On("CreateAuditLog", mock.MatchedBy(func(auditLog *entity.AuditLog) bool {
+		return auditLog.Action == entity.AuditActionChangeRole && auditLog.Details == "role User -> Admin"
+	})).Return(nil)
+
+	err := adminService.ChangeUserRole(1, 2, entity.RoleAdmin)
+	assert.NoError(t, err)
+
+	mockUserRepo.AssertExpectations(t)
+	mockAuditRepo.AssertExpectations(t)
+}
+
+func TestChangeUserRole_InvalidRole(t *testing.T) {
+	mockUserRepo := new(repo.MockUserRepository)
+	mockAuditRepo := new(repo.MockAuditLogRepository)
+	adminService := NewAdminService(mockUserRepo, mockAuditRepo, new(MockAuthService))
+
+	err := adminService.ChangeUserRole(1, 2, "SuperUser")
+	assert.ErrorIs(t, err, ErrInvalidRole)
+
+	mockUserRepo.AssertNotCalled(t, "GetUserByID", mock.Anything)
+}
+
+func TestSearchUsers_StripsPasswords(t *testing.T) {
+	mockUserRepo := new(repo.MockUserRepository)
+	mockAuditRepo := new(repo.MockAuditLogRepository)
+	adminService := NewAdminService(mockUserRepo, mockAuditRepo, new(MockAuthService))
+
+	mockUserRepo.On("SearchUsers", "john", "", 0).Return([]entity.User{{ID: 1, Password: "hash"}}, nil)
+
+	users, err := adminService.SearchUsers("john", "", 0)
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Empty(t, users[0].Password)
+}
+
+func TestListAuditLogs_DefaultLimit(t *testing.T) {
+	mockUserRepo := new(repo.MockUserRepository)
+	mockAuditRepo := new(repo.MockAuditLogRepository)
+	adminService := NewAdminService(mockUserRepo, mockAuditRepo, new(MockAuthService))
+
+	mockAuditRepo.On("ListAuditLogs", DefaultAuditLogLimit).Return([]entity.AuditLog{{ID: 1}}, nil)
+
+	auditLogs, err := adminService.ListAuditLogs(0)
+	assert.NoError(t, err)
+	assert.Len(t, auditLogs, 1)
+
+	mockAuditRepo.AssertExpectations(t)
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/zap-logging/log"
+	"mlvt/internal/repo"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidAPIKey        = errors.New("invalid, expired or revoked API key")
+	ErrAPIKeyNotFound       = errors.New("API key not found")
+	ErrInvalidAPIKeyRequest = errors.New("invalid API key request")
+	ErrTooManyAPIKeys       = errors.New("too many API keys")
+)
+
+// Defaults used when an APIKeyConfig field is left at zero
+const (
+	DefaultAPIKeyTTL       = 90 * 24 * time.Hour
+	DefaultAPIKeyMaxTTL    = 365 * 24 * time.Hour
+	DefaultMaxAPIKeys      = 25
+	APIKeyLastUsedInterval = time.Minute // Uses closer together than this are not recorded again
+	MaxAPIKeyNameLength    = 100
+)
+
+// apiKeyPrefixLength is how much of a key is kept in plain text to recognise it in lists
+const apiKeyPrefixLength = len(entity.APIKeyPrefix) + 6
+
+// APIKeyConfig limits the API keys users can create
+type APIKeyConfig struct {
+	DefaultTTL time.Duration // Lifetime of keys created without an expiry
+	MaxTTL     time.Duration // Latest expiry a key can be created with
+	MaxKeys    int           // Keys a user can have at once, expired ones included until revoked
+}
+
+// withDefaults fills zero fields with the package defaults
+func (c APIKeyConfig) withDefaults() APIKeyConfig {
+	if c.DefaultTTL <= 0 {
+		c.DefaultTTL = DefaultAPIKeyTTL
+	}
+	if c.MaxTTL <= 0 {
+		c.MaxTTL = DefaultAPIKeyMaxTTL
+	}
+	if c.DefaultTTL > c.MaxTTL {
+		c.DefaultTTL = c.MaxTTL
+	}
+	if c.MaxKeys <= 0 {
+		c.MaxKeys = DefaultMaxAPIKeys
+	}
+	return c
+}
+
+// APIKeyService manages the API keys users create for scripts, and authenticates requests made with them
+type APIKeyService interface {
+	Create(userID uint64, name string, scopes []string, expiresAt *time.Time) (*entity.APIKey, string, error)
+	List(userID uint64) ([]entity.APIKey, error)
+	Revoke(userID, keyID uint64) error
+	Authenticate(key, clientIP string) (*entity.User, *entity.APIKey, error)
+}
+
+type apiKeyService struct {
+	userRepo   repo.UserRepository
+	apiKeyRepo repo.APIKeyRepository
+	config     APIKeyConfig
+	now        func() time.Time
+}
+
+func NewAPIKeyService(userRepo repo.UserRepository, apiKeyRepo repo.APIKeyRepository, config APIKeyConfig) APIKeyService {
+	return &apiKeyService{
+		userRepo:   userRepo,
+		apiKeyRepo: apiKeyRepo,
+		config:     config.withDefaults(),
+		now:        time.Now,
+	}
+}
+
+// Create issues a new key with the given scopes and returns it with the key itself, which is not stored
+// and cannot be shown again. Without an expiry the key works for the configured default lifetime.
+func (s *apiKeyService) Create(userID uint64, name string, scopes []string, expiresAt *time.Time) (*entity.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > MaxAPIKeyNameLength {
+		return nil, "", fmt.Errorf("%w: name must have 1 to %d characters", ErrInvalidAPIKeyRequest, MaxAPIKeyNameLength)
+	}
+	scopes, err := normalizeAPIKeyScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := s.now()
+	expiry := now.Add(s.config.DefaultTTL)
+	if expiresAt != nil {
+		expiry = *expiresAt
+	}
+	if !expiry.After(now) || expiry.After(now.Add(s.config.MaxTTL)) {
+		return nil, "", fmt.Errorf("%w: expires_at must be in the future and at most %s away",
+			ErrInvalidAPIKeyRequest, describeDuration(s.config.MaxTTL))
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if user == nil {
+		return nil, "", ErrUserNotFound
+	}
+	existing, err := s.apiKeyRepo.ListUserAPIKeys(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(existing) >= s.config.MaxKeys {
+		return nil, "", fmt.Errorf("%w: revoke one of your %d keys first", ErrTooManyAPIKeys, len(existing))
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	plain := entity.APIKeyPrefix + secret
+	key := &entity.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    plain[:apiKeyPrefixLength],
+		KeyHash:   hashToken(plain),
+		Scopes:    scopes,
+		ExpiresAt: expiry,
+	}
+	if err := s.apiKeyRepo.CreateAPIKey(key); err != nil {
+		return nil, "", err
+	}
+	return key, plain, nil
+}
+
+// List returns the user's keys that have not been revoked
+func (s *apiKeyService) List(userID uint64) ([]entity.APIKey, error) {
+	return s.apiKeyRepo.ListUserAPIKeys(userID)
+}
+
+// Revoke stops a key of the user from working
+func (s *apiKeyService) Revoke(userID, keyID uint64) error {
+	revoked, err := s.apiKeyRepo.RevokeAPIKey(userID, keyID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate returns the owner of a valid key together with the key, and records its use.
+// Keys of suspended or deleted users are rejected like revoked ones.
+func (s *apiKeyService) Authenticate(plain, clientIP string) (*entity.User, *entity.APIKey, error) {
+	if !strings.HasPrefix(plain, entity.APIKeyPrefix) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	key, err := s.apiKeyRepo.GetAPIKeyByHash(hashToken(plain))
+	if err != nil {
+		return nil, nil, err
+	}
+	now := s.now()
+	if key == nil || key.RevokedAt != nil || !now.Before(key.ExpiresAt) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	user, err := s.userRepo.GetUserByID(key.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil || user.Status == entity.UserStatusSuspended || user.Status == entity.UserStatusDeleted {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	// Scripts may call many times a second; recording every use would turn each read into a write
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= APIKeyLastUsedInterval || key.LastUsedIP != clientIP {
+		if err := s.apiKeyRepo.TouchAPIKey(key.ID, now, clientIP); err != nil {
+			log.Warnf("Failed to record use of API key %d: %v", key.ID, err)
+		} else {
+			key.LastUsedAt = &now
+			key.LastUsedIP = clientIP
+		}
+	}
+	return user, key, nil
+}
+
+// normalizeAPIKeyScopes checks that scopes are known and removes duplicates
+func normalizeAPIKeyScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required (%s)", ErrInvalidAPIKeyRequest,
+			strings.Join(entity.APIKeyScopes, ", "))
+	}
+	normalized := make([]string, 0, len(scopes))
+	seen := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		if !entity.IsValidAPIKeyScope(scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrInvalidAPIKeyRequest, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/repo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestAPIKeyService() (*apiKeyService, *repo.MockUserRepository, *repo.MockAPIKeyRepository) {
+	userRepo := new(repo.MockUserRepository)
+	apiKeyRepo := new(repo.MockAPIKeyRepository)
+	service := NewAPIKeyService(userRepo, apiKeyRepo, APIKeyConfig{MaxKeys: 2}).(*apiKeyService)
+	return service, userRepo, apiKeyRepo
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	apiKeyService, userRepo, apiKeyRepo := newTestAPIKeyService()
+	now := time.Now()
+	apiKeyService.now = func() time.Time { return now }
+
+	userRepo.On("GetUserByID", uint64(1)).Return(&entity.User{ID: 1}, nil)
+	apiKeyRepo.On("ListUserAPIKeys", uint64(1)).Return([]entity.APIKey{}, nil)
+	var stored *entity.APIKey
+	apiKeyRepo.On("CreateAPIKey", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(*entity.APIKey)
+	}).Return(nil)
+
+	key, plain, err := apiKeyService.Create(1, " batch ", []string{entity.APIKeyScopeVideosRead, entity.APIKeyScopeVideosRead}, nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(plain, entity.APIKeyPrefix))
+	assert.Equal(t, stored, key)
+	assert.Equal(t, "batch", key.Name)
+	assert.Equal(t, []string{entity.APIKeyScopeVideosRead}, key.Scopes)
+	assert.Equal(t, hashToken(plain), key.KeyHash)
+	assert.True(t, strings.HasPrefix(plain, key.Prefix))
+	assert.Equal(t, now.Add(DefaultAPIKeyTTL), key.ExpiresAt)
+}
+
+func TestCreateAPIKeyValidation(t *testing.T) {
+	apiKeyService, userRepo, apiKeyRepo := newTestAPIKeyService()
+	userRepo.On("GetUserByID", uint64(1)).Return(&entity.User{ID: 1}, nil)
+	apiKeyRepo.On("ListUserAPIKeys", uint64(1)).Return([]entity.APIKey{{ID: 1}, {ID: 2}}, nil)
+
+	past := time.Now().Add(-time.Hour)
+	tooLate := time.Now().Add(2 * DefaultAPIKeyMaxTTL)
+	tests := []struct {
+		name      string
+		keyName   string
+		scopes    []string
+		expiresAt *time.Time
+		err       error
+	}{
+		{"no name", " ", []string{entity.APIKeyScopePayments}, nil, ErrInvalidAPIKeyRequest},
+		{"no scopes", "batch", nil, nil, ErrInvalidAPIKeyRequest},
+		{"unknown scope", "batch", []string{"users:admin"}, nil, ErrInvalidAPIKeyRequest},
+		{"expired", "batch", []string{entity.APIKeyScopePayments}, &past, ErrInvalidAPIKeyRequest},
+		{"expiry too late", "batch", []string{entity.APIKeyScopePayments}, &tooLate, ErrInvalidAPIKeyRequest},
+		{"too many keys", "batch", []string{entity.APIKeyScopePayments}, nil, ErrTooManyAPIKeys},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := apiKeyService.Create(1, tt.keyName, tt.scopes, tt.expiresAt)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+	apiKeyRepo.AssertNotCalled(t, "CreateAPIKey", mock.Anything)
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	apiKeyService, _, apiKeyRepo := newTestAPIKeyService()
+	apiKeyRepo.On("RevokeAPIKey", uint64(1), uint64(5)).Return(true, nil)
+	apiKeyRepo.On("RevokeAPIKey", uint64(1), uint64(6)).Return(false, nil)
+
+	assert.NoError(t, apiKeyService.Revoke(1, 5))
+	assert.ErrorIs(t, apiKeyService.Revoke(1, 6), ErrAPIKeyNotFound)
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	apiKeyService, userRepo, apiKeyRepo := newTestAPIKeyService()
+	now := time.Now()
+	apiKeyService.now = func() time.Time { return now }
+
+	user := &entity.User{ID: 1, Status: entity.UserStatusAvailable}
+	recent := now.Add(-time.Second)
+	keys := map[string]*entity.APIKey{
+		"mlvt_valid":   {ID: 1, UserID: 1, ExpiresAt: now.Add(time.Hour)},
+		"mlvt_recent":  {ID: 2, UserID: 1, ExpiresAt: now.Add(time.Hour), LastUsedAt: &recent, LastUsedIP: "10.0.0.1"},
+		"mlvt_expired": {ID: 3, UserID: 1, ExpiresAt: now},
+		"mlvt_revoked": {ID: 4, UserID: 1, ExpiresAt: now.Add(time.Hour), RevokedAt: &recent},
+		"mlvt_banned":  {ID: 5, UserID: 2, ExpiresAt: now.Add(time.Hour)},
+	}
+	for plain, key := range keys {
+		apiKeyRepo.On("GetAPIKeyByHash", hashToken(plain)).Return(key, nil)
+	}
+	apiKeyRepo.On("GetAPIKeyByHash", hashToken("mlvt_unknown")).Return(nil, nil)
+	userRepo.On("GetUserByID", uint64(1)).Return(user, nil)
+	userRepo.On("GetUserByID", uint64(2)).Return(&entity.User{ID: 2, Status: entity.UserStatusSuspended}, nil)
+	apiKeyRepo.On("TouchAPIKey", uint64(1), now, "10.0.0.1").Return(nil)
+
+	got, key, err := apiKeyService.Authenticate("mlvt_valid", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, user, got)
+	assert.Equal(t, uint64(1), key.ID)
+	apiKeyRepo.AssertCalled(t, "TouchAPIKey", uint64(1), now, "10.0.0.1")
+
+	// Recent uses from the same address are not recorded again
+	_, _, err = apiKeyService.Authenticate("mlvt_recent", "10.0.0.1")
+	assert.NoError(t, err)
+	apiKeyRepo.AssertNotCalled(t, "TouchAPIKey", uint64(2), mock.Anything, mock.Anything)
+
+	for _, plain := range []string{"mlvt_expired", "mlvt_revoked", "mlvt_banned", "mlvt_unknown", "eyJhbGciOi"} {
+		_, _, err := apiKeyService.Authenticate(plain, "10.0.0.1")
+		assert.ErrorIs(t, err, ErrInvalidAPIKey, plain)
+	}
+}
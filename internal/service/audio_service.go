@@ -1,9 +1,12 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"mlvt/internal/entity"
-	"mlvt/internal/infra/aws"
+	"mlvt/internal/infra/storage"
+	"mlvt/internal/infra/zap-logging/log"
 	"mlvt/internal/repo"
 )
 
@@ -13,26 +16,28 @@ type AudioService interface {
 	CreateAudio(audio *entity.Audio) error
 	GetAudioByID(audioID uint64) (*entity.Audio, string, error)
 	GetAudioByIDAndUserID(audioID, userID uint64) (*entity.Audio, string, error)
-	ListAudiosByUserID(userID uint64) ([]entity.Audio, error)
+	ListAudiosByUserID(userID uint64, opts entity.ListOptions) ([]entity.Audio, string, error)
 	GetAudioByVideoID(videoID, audioID uint64) (*entity.Audio, string, error)
-	ListAudiosByVideoID(videoID uint64) ([]entity.Audio, error)
+	ListAudiosByVideoID(videoID uint64, opts entity.ListOptions) ([]entity.Audio, string, error)
 	DeleteAudio(audioID uint64) error
 }
 
 type audioService struct {
-	repo     repo.AudioRepository
-	s3Client *aws.S3Client
+	repo         repo.AudioRepository
+	store        storage.Storage
+	probeService MediaProbeService
 }
 
-func NewAudioService(repo repo.AudioRepository, s3Client *aws.S3Client) AudioService {
+func NewAudioService(repo repo.AudioRepository, store storage.Storage, probeService MediaProbeService) AudioService {
 	return &audioService{
-		repo:     repo,
-		s3Client: s3Client,
+		repo:         repo,
+		store:        store,
+		probeService: probeService,
 	}
 }
 
 func (s *audioService) GeneratePresignedUploadURL(folder, fileName, fileType string) (string, error) {
-	return s.s3Client.GeneratePresignedURL(folder, fileName, fileType)
+	return s.store.GeneratePresignedUploadURL(folder, fileName, fileType)
 }
 
 func (s *audioService) GeneratePresignedDownloadURL(audioID uint64) (string, error) {
@@ -42,8 +47,8 @@ func (s *audioService) GeneratePresignedDownloadURL(audioID uint64) (string, err
 		return "", fmt.Errorf("could not find audio with ID %d: %v", audioID, err)
 	}
 
-	// Generate the presigned URL using S3 client
-	presignedURL, err := s.s3Client.GeneratePresignedURL(audio.Folder, audio.FileName, "audio/mpeg")
+	// Generate the presigned URL using the object storage
+	presignedURL, err := s.store.GeneratePresignedDownloadURL(audio.Folder, audio.FileName, "audio/mpeg", storage.AsAttachment(audio.FileName))
 	if err != nil {
 		return "", fmt.Errorf("failed to generate presigned download URL: %v", err)
 	}
@@ -52,8 +57,25 @@ func (s *audioService) GeneratePresignedDownloadURL(audioID uint64) (string, err
 	return presignedURL, nil
 }
 
+// CreateAudio stores a new audio and, when its file is already uploaded, replaces the client-supplied
+// duration with the one measured from the file. A failed probe does not fail the creation;
+// the audio can be probed again later.
 func (s *audioService) CreateAudio(audio *entity.Audio) error {
-	return s.repo.CreateAudio(audio)
+	if err := s.repo.CreateAudio(audio); err != nil {
+		return err
+	}
+
+	probed, err := s.probeService.ProbeAudio(context.Background(), audio.ID)
+	switch {
+	case err == nil:
+		audio.Duration = probed.Duration
+		audio.MediaInfo = probed.MediaInfo
+	case errors.Is(err, ErrMediaNotUploaded), errors.Is(err, ErrMediaProbeUnavailable):
+		// Keep the client-supplied duration until the file can be probed
+	default:
+		log.Warnf("Failed to probe audio %d: %v", audio.ID, err)
+	}
+	return nil
 }
 
 func (s *audioService) GetAudioByID(audioID uint64) (*entity.Audio, string, error) {
@@ -61,7 +83,7 @@ func (s *audioService) GetAudioByID(audioID uint64) (*entity.Audio, string, erro
 	if err != nil {
 		return nil, "", err
 	}
-	presignedURL, err := s.s3Client.GeneratePresignedURL(audio.Folder, audio.FileName, "audio/mpeg")
+	presignedURL, err := s.store.GeneratePresignedDownloadURL(audio.Folder, audio.FileName, "audio/mpeg")
 	if err != nil {
 		return nil, "", err
 	}
@@ -79,16 +101,16 @@ func (s *audioService) GetAudioByIDAndUserID(audioID, userID uint64) (*entity.Au
 		return nil, "", fmt.Errorf("audio not found")
 	}
 
-	// Generate the presigned URL using the S3 client
-	presignedURL, err := s.s3Client.GeneratePresignedURL(audio.Folder, audio.FileName, "audio/mpeg")
+	// Generate the presigned URL using the object storage
+	presignedURL, err := s.store.GeneratePresignedDownloadURL(audio.Folder, audio.FileName, "audio/mpeg")
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to generate presigned download URL: %v", err)
 	}
 
 	return audio, presignedURL, nil
 }
-func (s *audioService) ListAudiosByUserID(userID uint64) ([]entity.Audio, error) {
-	return s.repo.ListAudiosByUserID(userID)
+func (s *audioService) ListAudiosByUserID(userID uint64, opts entity.ListOptions) ([]entity.Audio, string, error) {
+	return s.repo.ListAudiosByUserID(userID, opts)
 }
 
 func (s *audioService) GetAudioByVideoID(videoID, audioID uint64) (*entity.Audio, string, error) {
@@ -96,15 +118,15 @@ func (s *audioService) GetAudioByVideoID(videoID, audioID uint64) (*entity.Audio
 	if err != nil {
 		return nil, "", err
 	}
-	presignedURL, err := s.s3Client.GeneratePresignedURL(audio.Folder, audio.FileName, "audio/mpeg")
+	presignedURL, err := s.store.GeneratePresignedDownloadURL(audio.Folder, audio.FileName, "audio/mpeg")
 	if err != nil {
 		return nil, "", err
 	}
 	return audio, presignedURL, nil
 }
 
-func (s *audioService) ListAudiosByVideoID(videoID uint64) ([]entity.Audio, error) {
-	return s.repo.ListAudiosByVideoID(videoID)
+func (s *audioService) ListAudiosByVideoID(videoID uint64, opts entity.ListOptions) ([]entity.Audio, string, error) {
+	return s.repo.ListAudiosByVideoID(videoID, opts)
 }
 
 func (s *audioService) DeleteAudio(audioID uint64) error {
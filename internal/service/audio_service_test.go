@@ -0,0 +1,43 @@
+package service
+
+import (
+	"errors"
+	"mlvt/internal/entity"
+	"mlvt/internal/repo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateAudio_UsesProbedDuration(t *testing.T) {
+	audioRepo := new(repo.MockAudioRepository)
+	probeService := new(MockMediaProbeService)
+	audioService := NewAudioService(audioRepo, nil, probeService)
+
+	audioRepo.On("CreateAudio", mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(0).(*entity.Audio).ID = 7
+	}).Return(nil)
+	probeService.On("ProbeAudio", mock.Anything, uint64(7)).
+		Return(&entity.Audio{ID: 7, Duration: 42, MediaInfo: entity.MediaInfo{AudioCodec: "aac"}}, nil)
+
+	audio := &entity.Audio{Duration: 999, FileName: "a.m4a"}
+	assert.NoError(t, audioService.CreateAudio(audio))
+	assert.Equal(t, 42, audio.Duration)
+	assert.Equal(t, "aac", audio.AudioCodec)
+}
+
+func TestCreateAudio_ProbeFailureKeepsAudio(t *testing.T) {
+	for _, probeErr := range []error{ErrMediaNotUploaded, ErrMediaProbeUnavailable, errors.New("storage unavailable")} {
+		audioRepo := new(repo.MockAudioRepository)
+		probeService := new(MockMediaProbeService)
+		audioService := NewAudioService(audioRepo, nil, probeService)
+
+		audioRepo.On("CreateAudio", mock.Anything).Return(nil)
+		probeService.On("ProbeAudio", mock.Anything, mock.Anything).Return(nil, probeErr)
+
+		audio := &entity.Audio{Duration: 30}
+		assert.NoError(t, audioService.CreateAudio(audio))
+		assert.Equal(t, 30, audio.Duration)
+	}
+}
@@ -1,8 +1,13 @@
 package service
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"mlvt/internal/entity"
+	"mlvt/internal/infra/jwtkeys"
 	"mlvt/internal/infra/reason"
 	"mlvt/internal/infra/zap-logging/log"
 	"mlvt/internal/repo"
@@ -12,87 +17,381 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Defaults used when an AuthConfig field is left at zero
+const (
+	DefaultAccessTokenTTL     = 15 * time.Minute
+	DefaultRefreshTokenTTL    = 30 * 24 * time.Hour
+	DefaultMaxLoginFailures   = 10
+	DefaultMaxIPLoginFailures = 50
+	DefaultLoginLockout       = 15 * time.Minute
+	DefaultTwoFactorTTL       = 5 * time.Minute
+)
+
+// challengePurpose marks challenge tokens so they are never accepted as access tokens
+const challengePurpose = "2fa"
+
+// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens
+var ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+// ErrAccountDisabled is returned when a suspended or deleted user tries to sign in
+var ErrAccountDisabled = errors.New("account is suspended or deleted")
+
+// AuthConfig sets the lifetime of issued tokens and how failed sign-ins are throttled
+type AuthConfig struct {
+	AccessTokenTTL     time.Duration // Lifetime of access tokens (JWTs)
+	RefreshTokenTTL    time.Duration // Lifetime of each refresh token; using one issues a new one
+	MaxLoginFailures   int           // Failed sign-ins to one account before it is locked
+	MaxIPLoginFailures int           // Failed sign-ins from one client IP before it is locked
+	LoginLockout       time.Duration // How long a lock lasts and how long failures are counted
+	TwoFactorTTL       time.Duration // How long after the password a two-factor code can be confirmed
+}
+
+// withDefaults fills zero fields with the package defaults
+func (c AuthConfig) withDefaults() AuthConfig {
+	if c.AccessTokenTTL <= 0 {
+		c.AccessTokenTTL = DefaultAccessTokenTTL
+	}
+	if c.RefreshTokenTTL <= 0 {
+		c.RefreshTokenTTL = DefaultRefreshTokenTTL
+	}
+	if c.MaxLoginFailures <= 0 {
+		c.MaxLoginFailures = DefaultMaxLoginFailures
+	}
+	if c.MaxIPLoginFailures <= 0 {
+		c.MaxIPLoginFailures = DefaultMaxIPLoginFailures
+	}
+	if c.LoginLockout <= 0 {
+		c.LoginLockout = DefaultLoginLockout
+	}
+	if c.TwoFactorTTL <= 0 {
+		c.TwoFactorTTL = DefaultTwoFactorTTL
+	}
+	return c
+}
+
 // AuthServiceInterface defines the methods used by UserService for authentication
 type AuthServiceInterface interface {
-	Login(email, password string) (string, uint64, error)
+	Login(email, password, clientIP string) (*entity.AuthTokens, error)
+	CompleteTwoFactorLogin(challengeToken, code, clientIP string) (*entity.AuthTokens, error)
+	IssueLoginTokens(user *entity.User) (*entity.AuthTokens, error)
 	GenerateToken(user *entity.User) (string, error)
 	GetUserByToken(tokenStr string) (*entity.User, error)
+	Refresh(refreshToken string) (*entity.AuthTokens, error)
+	Logout(refreshToken string, allSessions bool) error
+	RevokeSessions(userID uint64) error
+	ListLockedAccounts() ([]entity.LoginThrottle, error)
+	UnlockAccount(email string) error
+	JWKS() jwtkeys.JWKS
 }
 
-// AuthService handles user authentication
+// AuthService handles user authentication. Tokens are signed with the asymmetric keys of a key set;
+// tokens signed with the HS256 secret are accepted while one is configured, so sessions survive the
+// switch from the secret to keys. Without a key set the secret also signs.
 type AuthService struct {
-	userRepo  repo.UserRepository
-	secretKey string
+	userRepo         repo.UserRepository
+	refreshTokenRepo repo.RefreshTokenRepository
+	throttleRepo     repo.LoginThrottleRepository
+	twoFactorRepo    repo.TwoFactorRepository
+	keys             *jwtkeys.KeySet
+	secretKey        string
+	config           AuthConfig
+	now              func() time.Time
 }
 
 // NewAuthService creates a new AuthService
-func NewAuthService(userRepo repo.UserRepository, secretKey string) *AuthService {
+func NewAuthService(userRepo repo.UserRepository, refreshTokenRepo repo.RefreshTokenRepository,
+	throttleRepo repo.LoginThrottleRepository, twoFactorRepo repo.TwoFactorRepository, keys *jwtkeys.KeySet, secretKey string,
+	config AuthConfig) *AuthService {
 	return &AuthService{
-		userRepo:  userRepo,
-		secretKey: secretKey,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		throttleRepo:     throttleRepo,
+		twoFactorRepo:    twoFactorRepo,
+		keys:             keys,
+		secretKey:        secretKey,
+		config:           config.withDefaults(),
+		now:              time.Now,
 	}
 }
 
-// Login authenticates the user and returns a JWT token
-func (s *AuthService) Login(email, password string) (string, uint64, error) {
+// Login authenticates the user and returns an access token and a refresh token starting a new session.
+// Unknown emails and wrong passwords fail alike, and both count towards locking the account and the client IP.
+// While either is locked Login returns a *LoginLockedError. Users with two-factor authentication
+// only get a challenge token, to be exchanged with CompleteTwoFactorLogin.
+func (s *AuthService) Login(email, password, clientIP string) (*entity.AuthTokens, error) {
+	// Every spelling of the address finds the same account and counts against the same limit
+	email = normalizeEmail(email)
+	if err := s.checkLoginLocks(email, clientIP); err != nil {
+		return nil, err
+	}
+
 	user, err := s.userRepo.GetUserByEmail(email)
 	if err != nil {
 		log.Errorf("Error retrieving user by email %s: %v", email, err)
-		return "", 0, errors.New(reason.UserNotFound.Message())
+		return nil, errors.New(reason.InvalidCredentials.Message())
 	}
 
 	if user == nil {
 		log.Warnf("User not found with email %s", email)
-		return "", 0, errors.New(reason.UserNotFound.Message())
+		compareDummyPassword(password) // Take as long as a wrong password so timing does not reveal the account
+		s.recordLoginFailure(email, clientIP, nil)
+		return nil, errors.New(reason.InvalidCredentials.Message())
 	}
 
 	// Compare the hashed password
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
 	if err != nil {
-		return "", 0, errors.New(reason.InvalidCredentials.Message())
+		s.recordLoginFailure(email, clientIP, &user.ID)
+		return nil, errors.New(reason.InvalidCredentials.Message())
 	}
 
-	// Generate JWT token
-	token, err := s.GenerateToken(user)
+	// Failures are kept until the second factor is confirmed too, so wrong codes add up towards the lock
+	if !user.TwoFactorEnabled() {
+		s.resetLoginFailures(email)
+	}
+	return s.IssueLoginTokens(user)
+}
+
+// IssueLoginTokens starts a session for a user whose first factor has been checked, by password
+// or by an external identity provider. Users with two-factor authentication only get a challenge
+// token, to be exchanged with CompleteTwoFactorLogin. Suspended and deleted users get ErrAccountDisabled.
+func (s *AuthService) IssueLoginTokens(user *entity.User) (*entity.AuthTokens, error) {
+	if user.Status == entity.UserStatusSuspended || user.Status == entity.UserStatusDeleted {
+		return nil, ErrAccountDisabled
+	}
+	if user.TwoFactorEnabled() {
+		challenge, expiresAt, err := s.generateChallengeToken(user)
+		if err != nil {
+			log.Errorf("Error issuing two-factor challenge for user %d: %v", user.ID, err)
+			return nil, errors.New(reason.FailedToGenerateToken.Message())
+		}
+		return &entity.AuthTokens{UserID: user.ID, ChallengeToken: challenge, ChallengeExpiresAt: expiresAt}, nil
+	}
+
+	tokens, err := s.startSession(user)
 	if err != nil {
-		return "", 0, errors.New(reason.FailedToGenerateToken.Message())
+		log.Errorf("Error issuing tokens for user %d: %v", user.ID, err)
+		return nil, errors.New(reason.FailedToGenerateToken.Message())
+	}
+	return tokens, nil
+}
+
+// CompleteTwoFactorLogin confirms the TOTP or recovery code of a user who passed the password step
+// and starts their session. Wrong codes count towards locking the account and the client IP.
+func (s *AuthService) CompleteTwoFactorLogin(challengeToken, code, clientIP string) (*entity.AuthTokens, error) {
+	user, err := s.userByChallengeToken(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	account := normalizeEmail(user.Email)
+	if err := s.checkLoginLocks(account, clientIP); err != nil {
+		return nil, err
+	}
+
+	ok, err := verifySecondFactor(s.twoFactorRepo, user.ID, code, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.recordLoginFailure(account, clientIP, &user.ID)
+		return nil, ErrInvalidTwoFactorCode
 	}
+	s.resetLoginFailures(account)
 
-	return token, user.ID, nil
+	// The account may have been suspended since the password step
+	if user.Status == entity.UserStatusSuspended || user.Status == entity.UserStatusDeleted {
+		return nil, ErrAccountDisabled
+	}
+	tokens, err := s.startSession(user)
+	if err != nil {
+		log.Errorf("Error issuing tokens for user %d: %v", user.ID, err)
+		return nil, errors.New(reason.FailedToGenerateToken.Message())
+	}
+	return tokens, nil
 }
 
-// GenerateToken creates a JWT token for a user
+// GenerateToken creates a short-lived access token (JWT) for a user
 func (s *AuthService) GenerateToken(user *entity.User) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	token, _, err := s.generateAccessToken(user)
+	return token, err
+}
+
+// generateAccessToken creates an access token carrying the user's token version and returns its expiry
+func (s *AuthService) generateAccessToken(user *entity.User) (string, time.Time, error) {
+	now := s.now()
+	expiresAt := now.Add(s.config.AccessTokenTTL)
+	tokenString, err := s.signToken(jwt.MapClaims{
 		"userID": user.ID,
 		"email":  user.Email,
-		"exp":    time.Now().Add(time.Hour * 72).Unix(), // Token valid for 72 hours
+		"ver":    user.TokenVersion, // Raising the user's version revokes the token
+		"iat":    now.Unix(),
+		"exp":    expiresAt.Unix(),
 	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return tokenString, expiresAt, nil
+}
 
-	tokenString, err := token.SignedString([]byte(s.secretKey))
+// generateChallengeToken creates the token proving the password step of a two-factor login
+func (s *AuthService) generateChallengeToken(user *entity.User) (string, time.Time, error) {
+	now := s.now()
+	expiresAt := now.Add(s.config.TwoFactorTTL)
+	tokenString, err := s.signToken(jwt.MapClaims{
+		"userID":  user.ID,
+		"ver":     user.TokenVersion,
+		"purpose": challengePurpose,
+		"iat":     now.Unix(),
+		"exp":     expiresAt.Unix(),
+	})
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
+	}
+	return tokenString, expiresAt, nil
+}
+
+// userByChallengeToken returns the user a valid challenge token was issued to
+func (s *AuthService) userByChallengeToken(tokenStr string) (*entity.User, error) {
+	claims, err := s.parseToken(tokenStr)
+	if err != nil || claims["purpose"] != challengePurpose {
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+	userIDFloat, ok := claims["userID"].(float64)
+	if !ok {
+		return nil, ErrInvalidTwoFactorChallenge
 	}
 
-	return tokenString, nil
+	user, err := s.userRepo.GetUserByID(uint64(userIDFloat))
+	if err != nil {
+		return nil, err
+	}
+	version, _ := claims["ver"].(float64)
+	if user == nil || int(version) != user.TokenVersion || !user.TwoFactorEnabled() {
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+	return user, nil
 }
 
-// GetUserByToken extracts user information from a JWT token
-func (s *AuthService) GetUserByToken(tokenStr string) (*entity.User, error) {
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New(reason.UnexpectedSigningMethod.Message())
+// Refresh exchanges a refresh token for a new access token and a new refresh token.
+// Each refresh token works once; presenting one that was already used revokes its whole session,
+// since either the client or an attacker holds a stolen copy.
+func (s *AuthService) Refresh(refreshToken string) (*entity.AuthTokens, error) {
+	stored, err := s.refreshTokenRepo.GetRefreshTokenByHash(hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || !s.now().Before(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if stored.RevokedAt != nil {
+		if stored.ReplacedByID != nil {
+			log.Warnf("Refresh token of user %d reused; revoking session %s", stored.UserID, stored.FamilyID)
+			if err := s.refreshTokenRepo.RevokeRefreshTokenFamily(stored.FamilyID); err != nil {
+				return nil, err
+			}
 		}
-		return []byte(s.secretKey), nil
-	})
+		return nil, ErrInvalidRefreshToken
+	}
 
-	if err != nil || !token.Valid {
-		return nil, errors.New(reason.InvalidToken.Message())
+	user, err := s.userRepo.GetUserByID(stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.Status == entity.UserStatusSuspended || user.Status == entity.UserStatusDeleted {
+		return nil, ErrInvalidRefreshToken
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		return nil, errors.New(reason.InvalidTokenClaims.Message())
+	tokens, next, err := s.newTokens(user, stored.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.refreshTokenRepo.RotateRefreshToken(stored.ID, next)
+	if errors.Is(err, repo.ErrRefreshTokenRevoked) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Logout ends the session a refresh token belongs to, or every session of its user when allSessions is set.
+// Unknown and already revoked tokens are ignored.
+func (s *AuthService) Logout(refreshToken string, allSessions bool) error {
+	stored, err := s.refreshTokenRepo.GetRefreshTokenByHash(hashToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	if stored == nil || !s.now().Before(stored.ExpiresAt) {
+		return nil
+	}
+	if allSessions {
+		return s.RevokeSessions(stored.UserID)
+	}
+	return s.refreshTokenRepo.RevokeRefreshTokenFamily(stored.FamilyID)
+}
+
+// RevokeSessions invalidates every access and refresh token issued to a user so far
+func (s *AuthService) RevokeSessions(userID uint64) error {
+	if err := s.userRepo.IncrementTokenVersion(userID); err != nil {
+		return err
+	}
+	return s.refreshTokenRepo.RevokeUserRefreshTokens(userID)
+}
+
+// startSession issues the first tokens of a new session; later refresh tokens share its family ID
+func (s *AuthService) startSession(user *entity.User) (*entity.AuthTokens, error) {
+	familyID, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	tokens, refreshToken, err := s.newTokens(user, familyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refreshTokenRepo.CreateRefreshToken(refreshToken); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// newTokens creates an access token and a refresh token; the refresh token still has to be stored
+func (s *AuthService) newTokens(user *entity.User, familyID string) (*entity.AuthTokens, *entity.RefreshToken, error) {
+	accessToken, accessExpiresAt, err := s.generateAccessToken(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stored := &entity.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(refreshToken),
+		FamilyID:  familyID,
+		ExpiresAt: s.now().Add(s.config.RefreshTokenTTL),
+	}
+	return &entity.AuthTokens{
+		UserID:           user.ID,
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: stored.ExpiresAt,
+	}, stored, nil
+}
+
+// GetUserByToken extracts user information from a JWT token
+func (s *AuthService) GetUserByToken(tokenStr string) (*entity.User, error) {
+	claims, err := s.parseToken(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+	// Challenge tokens only prove the password step of a two-factor login
+	if _, ok := claims["purpose"]; ok {
+		return nil, errors.New(reason.InvalidToken.Message())
 	}
 
 	// Safely assert types from claims
@@ -107,5 +406,76 @@ func (s *AuthService) GetUserByToken(tokenStr string) (*entity.User, error) {
 		return nil, errors.New(reason.UserNotFound.Message())
 	}
 
+	// Tokens issued before versioning carry no version and count as version 0
+	version, _ := claims["ver"].(float64)
+	if user != nil && int(version) != user.TokenVersion {
+		return nil, errors.New(reason.InvalidToken.Message())
+	}
+
 	return user, nil
 }
+
+// JWKS returns the public keys that verify the tokens of this service; empty while only the secret signs
+func (s *AuthService) JWKS() jwtkeys.JWKS {
+	if s.keys == nil {
+		return jwtkeys.JWKS{Keys: []jwtkeys.JWK{}}
+	}
+	return s.keys.JWKS()
+}
+
+// signToken signs claims with the current key of the key set, or with the HS256 secret when there is none
+func (s *AuthService) signToken(claims jwt.MapClaims) (string, error) {
+	if s.keys != nil {
+		return s.keys.Sign(claims)
+	}
+	if s.secretKey == "" {
+		return "", errors.New("no JWT signing key configured")
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.secretKey))
+}
+
+// parseToken checks the signature and expiry of a JWT signed by this service and returns its claims
+func (s *AuthService) parseToken(tokenStr string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			// Tokens signed before the switch to keys stay valid until the secret is removed
+			if s.secretKey == "" {
+				return nil, errors.New(reason.UnexpectedSigningMethod.Message())
+			}
+			return []byte(s.secretKey), nil
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodEd25519:
+			if s.keys == nil {
+				return nil, errors.New(reason.UnexpectedSigningMethod.Message())
+			}
+			return s.keys.Keyfunc(token)
+		default:
+			return nil, errors.New(reason.UnexpectedSigningMethod.Message())
+		}
+	})
+
+	if err != nil || !token.Valid {
+		return nil, errors.New(reason.InvalidToken.Message())
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New(reason.InvalidTokenClaims.Message())
+	}
+	return claims, nil
+}
+
+// randomToken returns n random bytes encoded for use in URLs and JSON
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex SHA-256 of a token; only hashes of bearer secrets are stored
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
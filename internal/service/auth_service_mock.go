@@ -2,6 +2,7 @@ package service
 
 import (
 	"mlvt/internal/entity"
+	"mlvt/internal/infra/jwtkeys"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -10,9 +11,22 @@ type MockAuthService struct {
 	mock.Mock
 }
 
-func (m *MockAuthService) Login(email, password string) (string, error) {
-	args := m.Called(email, password)
-	return args.String(0), args.Error(1)
+func (m *MockAuthService) Login(email, password, clientIP string) (*entity.AuthTokens, error) {
+	args := m.Called(email, password, clientIP)
+	tokens, _ := args.Get(0).(*entity.AuthTokens)
+	return tokens, args.Error(1)
+}
+
+func (m *MockAuthService) CompleteTwoFactorLogin(challengeToken, code, clientIP string) (*entity.AuthTokens, error) {
+	args := m.Called(challengeToken, code, clientIP)
+	tokens, _ := args.Get(0).(*entity.AuthTokens)
+	return tokens, args.Error(1)
+}
+
+func (m *MockAuthService) IssueLoginTokens(user *entity.User) (*entity.AuthTokens, error) {
+	args := m.Called(user)
+	tokens, _ := args.Get(0).(*entity.AuthTokens)
+	return tokens, args.Error(1)
 }
 
 func (m *MockAuthService) GenerateToken(user *entity.User) (string, error) {
@@ -27,3 +41,35 @@ func (m *MockAuthService) GetUserByToken(tokenStr string) (*entity.User, error)
 	}
 	return nil, args.Error(1)
 }
+
+func (m *MockAuthService) Refresh(refreshToken string) (*entity.AuthTokens, error) {
+	args := m.Called(refreshToken)
+	tokens, _ := args.Get(0).(*entity.AuthTokens)
+	return tokens, args.Error(1)
+}
+
+func (m *MockAuthService) Logout(refreshToken string, allSessions bool) error {
+	args := m.Called(refreshToken, allSessions)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) RevokeSessions(userID uint64) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ListLockedAccounts() ([]entity.LoginThrottle, error) {
+	args := m.Called()
+	accounts, _ := args.Get(0).([]entity.LoginThrottle)
+	return accounts, args.Error(1)
+}
+
+func (m *MockAuthService) UnlockAccount(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) JWKS() jwtkeys.JWKS {
+	args := m.Called()
+	return args.Get(0).(jwtkeys.JWKS)
+}
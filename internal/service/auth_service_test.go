@@ -0,0 +1,326 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/jwtkeys"
+	"mlvt/internal/pkg/totp"
+	"mlvt/internal/repo"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestAuthService() (*AuthService, *repo.MockUserRepository, *repo.MockRefreshTokenRepository) {
+	authService, mockUserRepo, mockTokenRepo, mockThrottleRepo := newTestThrottledAuthService()
+	mockThrottleRepo.On("GetLoginThrottle", mock.Anything, mock.Anything).Return(nil, nil)
+	mockThrottleRepo.On("ResetLoginThrottle", mock.Anything, mock.Anything).Return(nil)
+	return authService, mockUserRepo, mockTokenRepo
+}
+
+func newTestThrottledAuthService() (*AuthService, *repo.MockUserRepository, *repo.MockRefreshTokenRepository, *repo.MockLoginThrottleRepository) {
+	mockUserRepo := new(repo.MockUserRepository)
+	mockTokenRepo := new(repo.MockRefreshTokenRepository)
+	mockThrottleRepo := new(repo.MockLoginThrottleRepository)
+	authService := NewAuthService(mockUserRepo, mockTokenRepo, mockThrottleRepo, new(repo.MockTwoFactorRepository), nil, "secret", AuthConfig{})
+	return authService, mockUserRepo, mockTokenRepo, mockThrottleRepo
+}
+
+func TestAuthLogin_IssuesStoredRefreshToken(t *testing.T) {
+	authService, mockUserRepo, mockTokenRepo := newTestAuthService()
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	mockUserRepo.On("GetUserByEmail", "john@example.com").
+		Return(&entity.User{ID: 1, Email: "john@example.com", Password: string(hashed)}, nil)
+
+	var stored *entity.RefreshToken
+	mockTokenRepo.On("CreateRefreshToken", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(*entity.RefreshToken)
+	}).Return(nil)
+
+	tokens, err := authService.Login("john@example.com", "password123", "203.0.113.7")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), tokens.UserID)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.WithinDuration(t, time.Now().Add(DefaultAccessTokenTTL), tokens.AccessExpiresAt, time.Minute)
+
+	// Only the hash of the refresh token is stored
+	assert.Equal(t, hashToken(tokens.RefreshToken), stored.TokenHash)
+	assert.NotEqual(t, tokens.RefreshToken, stored.TokenHash)
+	assert.NotEmpty(t, stored.FamilyID)
+	assert.Equal(t, tokens.RefreshExpiresAt, stored.ExpiresAt)
+}
+
+func TestAuthRefresh_RotatesToken(t *testing.T) {
+	authService, mockUserRepo, mockTokenRepo := newTestAuthService()
+	mockTokenRepo.On("GetRefreshTokenByHash", hashToken("old")).Return(&entity.RefreshToken{
+		ID: 5, UserID: 1, FamilyID: "family", ExpiresAt: time.Now().Add(time.Hour),
+	}, nil)
+	mockUserRepo.On("GetUserByID", uint64(1)).Return(&entity.User{ID: 1, Status: entity.UserStatusAvailable}, nil)
+	mockTokenRepo.On("RotateRefreshToken", uint64(5), mock.MatchedBy(func(next *entity.RefreshToken) bool {
+		return next.UserID == 1 && next.FamilyID == "family"
+	})).Return(nil)
+
+	tokens, err := authService.Refresh("old")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "old", tokens.RefreshToken)
+	mockTokenRepo.AssertExpectations(t)
+}
+
+func TestAuthRefresh_ReuseRevokesFamily(t *testing.T) {
+	authService, _, mockTokenRepo := newTestAuthService()
+	revokedAt := time.Now()
+	replacedBy := uint64(6)
+	mockTokenRepo.On("GetRefreshTokenByHash", hashToken("used")).Return(&entity.RefreshToken{
+		ID: 5, UserID: 1, FamilyID: "family", ExpiresAt: time.Now().Add(time.Hour),
+		RevokedAt: &revokedAt, ReplacedByID: &replacedBy,
+	}, nil)
+	mockTokenRepo.On("RevokeRefreshTokenFamily", "family").Return(nil)
+
+	_, err := authService.Refresh("used")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	mockTokenRepo.AssertExpectations(t)
+}
+
+func TestAuthRefresh_ExpiredOrUnknown(t *testing.T) {
+	authService, _, mockTokenRepo := newTestAuthService()
+	mockTokenRepo.On("GetRefreshTokenByHash", hashToken("expired")).Return(&entity.RefreshToken{
+		ID: 5, UserID: 1, FamilyID: "family", ExpiresAt: time.Now().Add(-time.Minute),
+	}, nil)
+	mockTokenRepo.On("GetRefreshTokenByHash", hashToken("unknown")).Return(nil, nil)
+
+	_, err := authService.Refresh("expired")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	_, err = authService.Refresh("unknown")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestAuthRefresh_SuspendedUser(t *testing.T) {
+	authService, mockUserRepo, mockTokenRepo := newTestAuthService()
+	mockTokenRepo.On("GetRefreshTokenByHash", hashToken("token")).Return(&entity.RefreshToken{
+		ID: 5, UserID: 1, FamilyID: "family", ExpiresAt: time.Now().Add(time.Hour),
+	}, nil)
+	mockUserRepo.On("GetUserByID", uint64(1)).Return(&entity.User{ID: 1, Status: entity.UserStatusSuspended}, nil)
+
+	_, err := authService.Refresh("token")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	mockTokenRepo.AssertNotCalled(t, "RotateRefreshToken", mock.Anything, mock.Anything)
+}
+
+func TestAuthLogout(t *testing.T) {
+	authService, mockUserRepo, mockTokenRepo := newTestAuthService()
+	mockTokenRepo.On("GetRefreshTokenByHash", hashToken("token")).Return(&entity.RefreshToken{
+		ID: 5, UserID: 1, FamilyID: "family", ExpiresAt: time.Now().Add(time.Hour),
+	}, nil)
+	mockTokenRepo.On("RevokeRefreshTokenFamily", "family").Return(nil)
+	mockUserRepo.On("IncrementTokenVersion", uint64(1)).Return(nil)
+	mockTokenRepo.On("RevokeUserRefreshTokens", uint64(1)).Return(nil)
+
+	assert.NoError(t, authService.Logout("token", false))
+	mockUserRepo.AssertNotCalled(t, "IncrementTokenVersion", uint64(1))
+
+	assert.NoError(t, authService.Logout("token", true))
+	mockUserRepo.AssertExpectations(t)
+	mockTokenRepo.AssertExpectations(t)
+}
+
+func TestGetUserByToken_RejectsOldVersion(t *testing.T) {
+	authService, mockUserRepo, _ := newTestAuthService()
+	user := &entity.User{ID: 1, Email: "john@example.com", TokenVersion: 2}
+	token, err := authService.GenerateToken(user)
+	assert.NoError(t, err)
+
+	mockUserRepo.On("GetUserByID", uint64(1)).Return(&entity.User{ID: 1, TokenVersion: 2}, nil).Once()
+	found, err := authService.GetUserByToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), found.ID)
+
+	// Revoking sessions raises the version and invalidates the token
+	mockUserRepo.On("GetUserByID", uint64(1)).Return(&entity.User{ID: 1, TokenVersion: 3}, nil).Once()
+	_, err = authService.GetUserByToken(token)
+	assert.Error(t, err)
+}
+
+func TestGetUserByToken_KeySetWithLegacySecret(t *testing.T) {
+	legacyService, _, _ := newTestAuthService()
+	user := &entity.User{ID: 1, Email: "john@example.com"}
+	legacyToken, err := legacyService.GenerateToken(user)
+	assert.NoError(t, err)
+
+	keys, err := jwtkeys.Load(jwtkeys.Config{Dir: t.TempDir(), Algorithm: jwtkeys.AlgEdDSA})
+	assert.NoError(t, err)
+	mockUserRepo := new(repo.MockUserRepository)
+	mockUserRepo.On("GetUserByID", uint64(1)).Return(user, nil)
+	authService := NewAuthService(mockUserRepo, new(repo.MockRefreshTokenRepository), new(repo.MockLoginThrottleRepository),
+		new(repo.MockTwoFactorRepository), keys, "secret", AuthConfig{})
+
+	token, err := authService.GenerateToken(user)
+	assert.NoError(t, err)
+	parsed, _ := jwt.Parse(token, keys.Keyfunc)
+	if assert.NotNil(t, parsed) {
+		assert.Equal(t, jwtkeys.AlgEdDSA, parsed.Header["alg"])
+		assert.Equal(t, keys.SigningKey().ID, parsed.Header["kid"])
+	}
+	_, err = authService.GetUserByToken(token)
+	assert.NoError(t, err)
+
+	// HS256 tokens issued before the switch keep working while the secret is set
+	_, err = authService.GetUserByToken(legacyToken)
+	assert.NoError(t, err)
+	assert.Len(t, authService.JWKS().Keys, 1)
+
+	keysOnly := NewAuthService(mockUserRepo, new(repo.MockRefreshTokenRepository), new(repo.MockLoginThrottleRepository),
+		new(repo.MockTwoFactorRepository), keys, "", AuthConfig{})
+	_, err = keysOnly.GetUserByToken(token)
+	assert.NoError(t, err)
+	_, err = keysOnly.GetUserByToken(legacyToken)
+	assert.Error(t, err)
+
+	// Without a key set only the secret verifies
+	_, err = legacyService.GetUserByToken(token)
+	assert.Error(t, err)
+	assert.Empty(t, legacyService.JWKS().Keys)
+}
+
+func TestAuthLogin_UniformErrorForUnknownEmail(t *testing.T) {
+	authService, mockUserRepo, _, mockThrottleRepo := newTestThrottledAuthService()
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	userID := uint64(1)
+	mockUserRepo.On("GetUserByEmail", "john@example.com").
+		Return(&entity.User{ID: userID, Email: "john@example.com", Password: string(hashed)}, nil)
+	mockUserRepo.On("GetUserByEmail", "nobody@example.com").Return(nil, nil)
+	mockThrottleRepo.On("GetLoginThrottle", mock.Anything, mock.Anything).Return(nil, nil)
+	mockThrottleRepo.On("RecordLoginFailure", entity.LoginThrottleAccount, "john@example.com", &userID, mock.Anything).Return(1, nil)
+	mockThrottleRepo.On("RecordLoginFailure", entity.LoginThrottleAccount, "nobody@example.com", (*uint64)(nil), mock.Anything).Return(1, nil)
+	mockThrottleRepo.On("RecordLoginFailure", entity.LoginThrottleIP, "203.0.113.7", (*uint64)(nil), mock.Anything).Return(1, nil)
+
+	_, wrongPassword := authService.Login("john@example.com", "guess", "203.0.113.7")
+	_, unknownEmail := authService.Login("nobody@example.com", "guess", "203.0.113.7")
+	assert.Error(t, wrongPassword)
+	assert.Equal(t, wrongPassword, unknownEmail)
+	mockThrottleRepo.AssertExpectations(t)
+	mockThrottleRepo.AssertNotCalled(t, "LockLogin", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAuthLogin_LocksAfterRepeatedFailures(t *testing.T) {
+	authService, mockUserRepo, _, mockThrottleRepo := newTestThrottledAuthService()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	authService.now = func() time.Time { return now }
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	// Whatever the case the address is typed in, the account is looked up and throttled by its normalized form
+	mockUserRepo.On("GetUserByEmail", "john@example.com").
+		Return(&entity.User{ID: 1, Email: "john@example.com", Password: string(hashed)}, nil)
+	mockThrottleRepo.On("GetLoginThrottle", mock.Anything, mock.Anything).Return(nil, nil)
+	mockThrottleRepo.On("RecordLoginFailure", entity.LoginThrottleAccount, "john@example.com", mock.Anything,
+		now.Add(-DefaultLoginLockout)).Return(4, nil).Once()
+	mockThrottleRepo.On("LockLogin", entity.LoginThrottleAccount, "john@example.com", now.Add(2*LoginDelayBase)).Return(nil).Once()
+
+	_, err := authService.Login("John@Example.com", "guess", "")
+	assert.Error(t, err)
+
+	mockThrottleRepo.On("RecordLoginFailure", entity.LoginThrottleAccount, "john@example.com", mock.Anything,
+		mock.Anything).Return(DefaultMaxLoginFailures, nil).Once()
+	mockThrottleRepo.On("LockLogin", entity.LoginThrottleAccount, "john@example.com", now.Add(DefaultLoginLockout)).Return(nil).Once()
+
+	_, err = authService.Login("John@Example.com", "guess", "")
+	assert.Error(t, err)
+	mockThrottleRepo.AssertExpectations(t)
+}
+
+func TestAuthLogin_RejectsWhileLocked(t *testing.T) {
+	authService, mockUserRepo, _, mockThrottleRepo := newTestThrottledAuthService()
+	lockedUntil := time.Now().Add(time.Minute)
+	mockThrottleRepo.On("GetLoginThrottle", entity.LoginThrottleAccount, "john@example.com").Return(nil, nil)
+	mockThrottleRepo.On("GetLoginThrottle", entity.LoginThrottleIP, "203.0.113.7").
+		Return(&entity.LoginThrottle{Scope: entity.LoginThrottleIP, Subject: "203.0.113.7", LockedUntil: &lockedUntil}, nil)
+
+	_, err := authService.Login("john@example.com", "password123", "203.0.113.7")
+	var locked *LoginLockedError
+	if assert.ErrorAs(t, err, &locked) {
+		assert.Equal(t, lockedUntil, locked.RetryAt)
+	}
+	assert.ErrorIs(t, err, ErrTooManyLoginAttempts)
+	// Locked attempts are neither checked nor counted
+	mockUserRepo.AssertNotCalled(t, "GetUserByEmail", mock.Anything)
+	mockThrottleRepo.AssertNotCalled(t, "RecordLoginFailure", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAuthLogin_SuspendedOrDeletedUser(t *testing.T) {
+	for _, status := range []int{entity.UserStatusSuspended, entity.UserStatusDeleted} {
+		authService, mockUserRepo, mockTokenRepo := newTestAuthService()
+		hashed, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+		mockUserRepo.On("GetUserByEmail", "john@example.com").
+			Return(&entity.User{ID: 1, Email: "john@example.com", Password: string(hashed), Status: status}, nil)
+
+		_, err := authService.Login("john@example.com", "password123", "203.0.113.7")
+		assert.ErrorIs(t, err, ErrAccountDisabled)
+		mockTokenRepo.AssertNotCalled(t, "CreateRefreshToken", mock.Anything)
+	}
+}
+
+func TestAuthLogin_SuccessResetsAccountFailures(t *testing.T) {
+	authService, mockUserRepo, mockTokenRepo, mockThrottleRepo := newTestThrottledAuthService()
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	mockUserRepo.On("GetUserByEmail", "john@example.com").
+		Return(&entity.User{ID: 1, Email: "john@example.com", Password: string(hashed)}, nil)
+	mockTokenRepo.On("CreateRefreshToken", mock.Anything).Return(nil)
+	mockThrottleRepo.On("GetLoginThrottle", mock.Anything, mock.Anything).Return(nil, nil)
+	mockThrottleRepo.On("ResetLoginThrottle", entity.LoginThrottleAccount, "john@example.com").Return(nil)
+
+	_, err := authService.Login("john@example.com", "password123", "203.0.113.7")
+	assert.NoError(t, err)
+	mockThrottleRepo.AssertExpectations(t)
+	mockThrottleRepo.AssertNotCalled(t, "ResetLoginThrottle", entity.LoginThrottleIP, mock.Anything)
+}
+
+func TestAuthLogin_TwoFactorChallenge(t *testing.T) {
+	authService, mockUserRepo, mockTokenRepo, mockThrottleRepo := newTestThrottledAuthService()
+	mockTwoFactorRepo := new(repo.MockTwoFactorRepository)
+	authService.twoFactorRepo = mockTwoFactorRepo
+	secret, _ := totp.GenerateSecret()
+	enabledAt := time.Now()
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	user := &entity.User{ID: 1, Email: "john@example.com", Password: string(hashed), TOTPEnabledAt: &enabledAt}
+	mockUserRepo.On("GetUserByEmail", "john@example.com").Return(user, nil)
+	mockUserRepo.On("GetUserByID", uint64(1)).Return(user, nil)
+	mockThrottleRepo.On("GetLoginThrottle", mock.Anything, mock.Anything).Return(nil, nil)
+
+	tokens, err := authService.Login("john@example.com", "password123", "")
+	assert.NoError(t, err)
+	assert.True(t, tokens.TwoFactorRequired())
+	assert.Empty(t, tokens.AccessToken)
+	mockThrottleRepo.AssertNotCalled(t, "ResetLoginThrottle", mock.Anything, mock.Anything)
+
+	// The challenge token is not an access token
+	_, err = authService.GetUserByToken(tokens.ChallengeToken)
+	assert.Error(t, err)
+
+	// A wrong code counts as a failed sign-in
+	mockTwoFactorRepo.On("GetTOTPSecret", uint64(1)).Return(secret, nil)
+	mockTwoFactorRepo.On("ConsumeRecoveryCode", uint64(1), mock.Anything).Return(false, nil)
+	mockThrottleRepo.On("RecordLoginFailure", entity.LoginThrottleAccount, "john@example.com", &user.ID, mock.Anything).Return(1, nil)
+	_, err = authService.CompleteTwoFactorLogin(tokens.ChallengeToken, "not-a-code", "")
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+
+	code, _ := totp.Code(secret, totp.Step(time.Now()))
+	mockTwoFactorRepo.On("UseTOTPStep", uint64(1), mock.Anything).Return(true, nil)
+	mockThrottleRepo.On("ResetLoginThrottle", entity.LoginThrottleAccount, "john@example.com").Return(nil)
+	mockTokenRepo.On("CreateRefreshToken", mock.Anything).Return(nil)
+	session, err := authService.CompleteTwoFactorLogin(tokens.ChallengeToken, code, "")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, session.AccessToken)
+	assert.NotEmpty(t, session.RefreshToken)
+	mockThrottleRepo.AssertExpectations(t)
+}
+
+func TestCompleteTwoFactorLogin_RejectsAccessToken(t *testing.T) {
+	authService, _, _ := newTestAuthService()
+	accessToken, err := authService.GenerateToken(&entity.User{ID: 1, Email: "john@example.com"})
+	assert.NoError(t, err)
+
+	_, err = authService.CompleteTwoFactorLogin(accessToken, "123456", "")
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorChallenge)
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/zap-logging/log"
+	"mlvt/internal/repo"
+	"strings"
+	"time"
+)
+
+var (
+	ErrProductNotFound     = errors.New("product not found")
+	ErrOrderNotFound       = errors.New("order not found")
+	ErrOrderNotPayable     = errors.New("order is not awaiting payment")
+	ErrInsufficientCredits = errors.New("not enough translation minutes")
+)
+
+// DefaultProducts is what users can buy. Prices are in VND; credits are translation minutes.
+var DefaultProducts = []entity.Product{
+	{ID: "credits-60", Name: "60 translation minutes", Price: 50000, Credits: 60},
+	{ID: "credits-300", Name: "300 translation minutes", Price: 200000, Credits: 300},
+	{ID: "credits-1000", Name: "1,000 translation minutes", Price: 600000, Credits: 1000},
+	{ID: "premium-monthly", Name: "Premium for 30 days with 300 translation minutes", Price: 250000, Credits: 300, PremiumDays: 30},
+}
+
+// BillingService sells products through orders and keeps each user's balance of translation minutes
+// in a double-entry ledger: minutes bought, spent on translations, given back and taken back.
+type BillingService interface {
+	ListProducts() []entity.Product
+	CreateOrder(userID uint64, productID string) (*entity.Order, error)
+	GetOrder(orderID string) (*entity.Order, error)
+	ListOrders(userID uint64, opts entity.ListOptions) ([]entity.Order, string, error)
+	FulfillOrder(orderID string) (bool, error)
+	FailOrder(orderID string) error
+	ReserveRefund(orderID, refundID string, amount int64, full bool) error
+	CancelRefund(refundID string) error
+	CompleteRefund(orderID string, full bool) error
+	GetBalance(userID uint64) (int64, error)
+	ListLedgerEntries(userID uint64, opts entity.ListOptions) ([]entity.LedgerEntry, string, error)
+	ChargeTranslations(userID uint64, translations []entity.Translation, minutes int64) error
+	RefundTranslation(translation *entity.Translation) error
+}
+
+type billingService struct {
+	billingRepo repo.BillingRepository
+	products    []entity.Product
+	now         func() time.Time
+}
+
+func NewBillingService(billingRepo repo.BillingRepository) BillingService {
+	return &billingService{billingRepo: billingRepo, products: DefaultProducts, now: time.Now}
+}
+
+// ListProducts returns the products users can buy
+func (s *billingService) ListProducts() []entity.Product {
+	return s.products
+}
+
+// CreateOrder creates a pending order of the product for the user, priced as the product is now
+func (s *billingService) CreateOrder(userID uint64, productID string) (*entity.Order, error) {
+	var product *entity.Product
+	for i := range s.products {
+		if s.products[i].ID == productID {
+			product = &s.products[i]
+		}
+	}
+	if product == nil {
+		return nil, fmt.Errorf("%w: %q", ErrProductNotFound, productID)
+	}
+
+	orderID, err := s.newOrderID()
+	if err != nil {
+		return nil, err
+	}
+	order := &entity.Order{
+		OrderID:     orderID,
+		UserID:      userID,
+		ProductID:   product.ID,
+		Amount:      product.Price,
+		Credits:     product.Credits,
+		PremiumDays: product.PremiumDays,
+		Status:      entity.OrderStatusPending,
+	}
+	if err := s.billingRepo.CreateOrder(order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// GetOrder returns the order with the given order ID
+func (s *billingService) GetOrder(orderID string) (*entity.Order, error) {
+	order, err := s.billingRepo.GetOrderByOrderID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, ErrOrderNotFound
+	}
+	return order, nil
+}
+
+func (s *billingService) ListOrders(userID uint64, opts entity.ListOptions) ([]entity.Order, string, error) {
+	return s.billingRepo.ListOrdersByUserID(userID, opts)
+}
+
+// FulfillOrder marks a paid order paid and delivers what it bought: its minutes go to the user's
+// balance and its premium days to their subscription. It reports false if the order was not pending,
+// so calling it for every notification of a payment delivers the order once.
+func (s *billingService) FulfillOrder(orderID string) (bool, error) {
+	order, err := s.GetOrder(orderID)
+	if err != nil {
+		return false, err
+	}
+
+	var entries []entity.LedgerEntry
+	if order.Credits > 0 {
+		entries = transferEntries("order:"+order.OrderID, order.UserID, entity.LedgerAccountSales, order.Credits,
+			entity.LedgerKindPurchase, fmt.Sprintf("Bought with order %s", order.OrderID))
+		for i := range entries {
+			entries[i].OrderID = order.OrderID
+		}
+	}
+
+	fulfilled, err := s.billingRepo.FulfillOrder(orderID, s.now(), entries)
+	if err != nil {
+		return false, err
+	}
+	if fulfilled {
+		log.Infof("Delivered order %s of %s to user %d", order.OrderID, order.ProductID, order.UserID)
+	}
+	return fulfilled, nil
+}
+
+// FailOrder closes a pending order whose payment failed
+func (s *billingService) FailOrder(orderID string) error {
+	_, err := s.billingRepo.UpdateOrderStatus(orderID, entity.OrderStatusPending, entity.OrderStatusFailed)
+	return err
+}
+
+// ReserveRefund takes back the share of the order's minutes that amount VND paid for, once per refundID.
+// Call it before paying the money back: it fails with ErrInsufficientCredits, taking nothing, if the user
+// has spent them. Premium days are not taken back.
+func (s *billingService) ReserveRefund(orderID, refundID string, amount int64, full bool) error {
+	order, err := s.GetOrder(orderID)
+	if err != nil {
+		return err
+	}
+
+	transactionID := refundTransactionID(refundID)
+	posted, err := s.billingRepo.GetTransaction(transactionID)
+	if err != nil {
+		return err
+	}
+	if len(posted) > 0 {
+		return nil
+	}
+	refunded, err := s.billingRepo.GetRefundedCredits(orderID)
+	if err != nil {
+		return err
+	}
+	minutes := refundMinutes(order, refunded, amount, full)
+	if minutes <= 0 {
+		return nil
+	}
+
+	entries := transferEntries(transactionID, order.UserID, entity.LedgerAccountRefunds, -minutes,
+		entity.LedgerKindPaymentRefund, fmt.Sprintf("Refund of %d VND of order %s", amount, order.OrderID))
+	for i := range entries {
+		entries[i].OrderID = order.OrderID
+	}
+	// Charged like a translation, so the balance cannot go below zero
+	charged, err := s.billingRepo.ChargeCredits(order.UserID, entries)
+	if err != nil {
+		return err
+	}
+	if !charged {
+		return fmt.Errorf("%w: the refund of order %s takes back %d", ErrInsufficientCredits, orderID, minutes)
+	}
+	return nil
+}
+
+// CancelRefund gives back the minutes ReserveRefund took for a refund that was not paid, once
+func (s *billingService) CancelRefund(refundID string) error {
+	reserved, err := s.billingRepo.GetTransaction(refundTransactionID(refundID))
+	if err != nil {
+		return err
+	}
+	if len(reserved) == 0 {
+		return nil
+	}
+
+	entries := make([]entity.LedgerEntry, 0, len(reserved))
+	for _, entry := range reserved {
+		entries = append(entries, entity.LedgerEntry{
+			TransactionID: refundTransactionID(refundID) + ":cancel",
+			Account:       entry.Account,
+			UserID:        entry.UserID,
+			Amount:        -entry.Amount,
+			Kind:          entity.LedgerKindPaymentRefund, // Counted with the refund, so the order's refunded minutes stay right
+			OrderID:       entry.OrderID,
+			Description:   fmt.Sprintf("Cancelled refund of order %s", entry.OrderID),
+		})
+	}
+	_, err = s.billingRepo.PostTransaction(entries)
+	return err
+}
+
+// CompleteRefund closes the order once its payment has been refunded in full
+func (s *billingService) CompleteRefund(orderID string, full bool) error {
+	if !full {
+		return nil
+	}
+	_, err := s.billingRepo.UpdateOrderStatus(orderID, entity.OrderStatusPaid, entity.OrderStatusRefunded)
+	return err
+}
+
+// refundTransactionID is the ledger transaction that takes back the minutes of a refund
+func refundTransactionID(refundID string) string {
+	return "refund:" + refundID
+}
+
+// refundMinutes returns the share of the order's minutes that amount VND paid for, out of those
+// earlier refunds have not already taken back. The refund that completes the order takes back the rest.
+func refundMinutes(order *entity.Order, refunded, amount int64, full bool) int64 {
+	remaining := order.Credits - refunded
+	if remaining <= 0 {
+		return 0
+	}
+	if full || order.Amount <= 0 {
+		return remaining
+	}
+	return min(order.Credits*amount/order.Amount, remaining)
+}
+
+// GetBalance returns the user's translation minutes
+func (s *billingService) GetBalance(userID uint64) (int64, error) {
+	return s.billingRepo.GetBalance(userID)
+}
+
+// ListLedgerEntries lists the movements of the user's translation minutes, newest first by default
+func (s *billingService) ListLedgerEntries(userID uint64, opts entity.ListOptions) ([]entity.LedgerEntry, string, error) {
+	return s.billingRepo.ListLedgerEntries(userID, opts)
+}
+
+// ChargeTranslations takes minutes from the user's balance for each of the translations, all or none.
+// It fails with ErrInsufficientCredits if the balance does not cover them all.
+func (s *billingService) ChargeTranslations(userID uint64, translations []entity.Translation, minutes int64) error {
+	var entries []entity.LedgerEntry
+	for _, translation := range translations {
+		translationID := translation.ID
+		charge := transferEntries(translationTransactionID(translationID), userID, entity.LedgerAccountUsage, -minutes,
+			entity.LedgerKindTranslation, fmt.Sprintf("Translation of video %d into %s", translation.VideoID, translation.TargetLang))
+		for i := range charge {
+			charge[i].TranslationID = &translationID
+		}
+		entries = append(entries, charge...)
+	}
+
+	charged, err := s.billingRepo.ChargeCredits(userID, entries)
+	if err != nil {
+		return err
+	}
+	if !charged {
+		return fmt.Errorf("%w: %d needed", ErrInsufficientCredits, minutes*int64(len(translations)))
+	}
+	return nil
+}
+
+// RefundTranslation gives back the minutes charged for a failed or cancelled translation, once.
+// Translations requested before they were charged for have nothing to give back.
+func (s *billingService) RefundTranslation(translation *entity.Translation) error {
+	charge, err := s.billingRepo.GetTransaction(translationTransactionID(translation.ID))
+	if err != nil {
+		return err
+	}
+	if len(charge) == 0 {
+		return nil
+	}
+
+	reason := "Cancelled"
+	if translation.Status == entity.TranslationStatusFailed {
+		reason = "Failed"
+	}
+
+	entries := make([]entity.LedgerEntry, 0, len(charge))
+	for _, entry := range charge {
+		entries = append(entries, entity.LedgerEntry{
+			TransactionID: translationTransactionID(translation.ID) + ":refund",
+			Account:       entry.Account,
+			UserID:        entry.UserID,
+			Amount:        -entry.Amount,
+			Kind:          entity.LedgerKindTranslationRefund,
+			TranslationID: entry.TranslationID,
+			Description:   fmt.Sprintf("%s translation of video %d into %s", reason, translation.VideoID, translation.TargetLang),
+		})
+	}
+	_, err = s.billingRepo.PostTransaction(entries)
+	return err
+}
+
+// newOrderID returns an order ID like MLVT-20261016-3F9A2C7B1E, unique and in the format payment gateways accept
+func (s *billingService) newOrderID() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("MLVT-%s-%s", s.now().Format("20060102"), strings.ToUpper(hex.EncodeToString(b))), nil
+}
+
+// transferEntries returns the two entries of a transaction moving minutes into the user's credits
+// account from account; negative minutes move them the other way
+func transferEntries(transactionID string, userID uint64, account string, minutes int64, kind, description string) []entity.LedgerEntry {
+	return []entity.LedgerEntry{
+		{TransactionID: transactionID, Account: entity.LedgerAccountCredits, UserID: userID, Amount: minutes, Kind: kind, Description: description},
+		{TransactionID: transactionID, Account: account, UserID: userID, Amount: -minutes, Kind: kind, Description: description},
+	}
+}
+
+func translationTransactionID(translationID uint64) string {
+	return fmt.Sprintf("translation:%d", translationID)
+}
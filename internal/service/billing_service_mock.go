@@ -0,0 +1,83 @@
+package service
+
+import (
+	"mlvt/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockBillingService is a mock implementation of BillingService
+type MockBillingService struct {
+	mock.Mock
+}
+
+func (m *MockBillingService) ListProducts() []entity.Product {
+	args := m.Called()
+	products, _ := args.Get(0).([]entity.Product)
+	return products
+}
+
+func (m *MockBillingService) CreateOrder(userID uint64, productID string) (*entity.Order, error) {
+	args := m.Called(userID, productID)
+	order, _ := args.Get(0).(*entity.Order)
+	return order, args.Error(1)
+}
+
+func (m *MockBillingService) GetOrder(orderID string) (*entity.Order, error) {
+	args := m.Called(orderID)
+	order, _ := args.Get(0).(*entity.Order)
+	return order, args.Error(1)
+}
+
+func (m *MockBillingService) ListOrders(userID uint64, opts entity.ListOptions) ([]entity.Order, string, error) {
+	args := m.Called(userID, opts)
+	orders, _ := args.Get(0).([]entity.Order)
+	return orders, args.String(1), args.Error(2)
+}
+
+func (m *MockBillingService) FulfillOrder(orderID string) (bool, error) {
+	args := m.Called(orderID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockBillingService) FailOrder(orderID string) error {
+	args := m.Called(orderID)
+	return args.Error(0)
+}
+
+func (m *MockBillingService) ReserveRefund(orderID, refundID string, amount int64, full bool) error {
+	args := m.Called(orderID, refundID, amount, full)
+	return args.Error(0)
+}
+
+func (m *MockBillingService) CancelRefund(refundID string) error {
+	args := m.Called(refundID)
+	return args.Error(0)
+}
+
+func (m *MockBillingService) CompleteRefund(orderID string, full bool) error {
+	args := m.Called(orderID, full)
+	return args.Error(0)
+}
+
+func (m *MockBillingService) GetBalance(userID uint64) (int64, error) {
+	args := m.Called(userID)
+	balance, _ := args.Get(0).(int64)
+	return balance, args.Error(1)
+}
+
+func (m *MockBillingService) ListLedgerEntries(userID uint64, opts entity.ListOptions) ([]entity.LedgerEntry, string, error) {
+	args := m.Called(userID, opts)
+	entries, _ := args.Get(0).([]entity.LedgerEntry)
+	return entries, args.String(1), args.Error(2)
+}
+
+func (m *MockBillingService) ChargeTranslations(userID uint64, translations []entity.Translation, minutes int64) error {
+	args := m.Called(userID, translations, minutes)
+	return args.Error(0)
+}
+
+func (m *MockBillingService) RefundTranslation(translation *entity.Translation) error {
+	args := m.Called(translation)
+	return args.Error(0)
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/repo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var billingTestNow = time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+func setupBillingService() (*billingService, *repo.MockBillingRepository) {
+	billingRepo := new(repo.MockBillingRepository)
+	service := NewBillingService(billingRepo).(*billingService)
+	service.now = func() time.Time { return billingTestNow }
+	return service, billingRepo
+}
+
+// balanced reports whether the entries of each transaction add up to zero
+func balanced(entries []entity.LedgerEntry) bool {
+	sums := make(map[string]int64)
+	for _, entry := range entries {
+		sums[entry.TransactionID] += entry.Amount
+	}
+	for _, sum := range sums {
+		if sum != 0 {
+			return false
+		}
+	}
+	return len(entries) > 0
+}
+
+// creditsOf returns how many minutes the entries add to the user's credits account
+func creditsOf(entries []entity.LedgerEntry, userID uint64) int64 {
+	var credits int64
+	for _, entry := range entries {
+		if entry.Account == entity.LedgerAccountCredits && entry.UserID == userID {
+			credits += entry.Amount
+		}
+	}
+	return credits
+}
+
+func TestCreateOrder(t *testing.T) {
+	service, billingRepo := setupBillingService()
+	billingRepo.On("CreateOrder", mock.AnythingOfType("*entity.Order")).Return(nil)
+
+	order, err := service.CreateOrder(1, "premium-monthly")
+	assert.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^MLVT-20261016-[0-9A-F]{10}$`), order.OrderID)
+	assert.Equal(t, int64(250000), order.Amount)
+	assert.Equal(t, int64(300), order.Credits)
+	assert.Equal(t, 30, order.PremiumDays)
+	assert.Equal(t, entity.OrderStatusPending, order.Status)
+
+	_, err = service.CreateOrder(1, "free-minutes")
+	assert.ErrorIs(t, err, ErrProductNotFound)
+	billingRepo.AssertNumberOfCalls(t, "CreateOrder", 1)
+}
+
+func TestFulfillOrder(t *testing.T) {
+	service, billingRepo := setupBillingService()
+	billingRepo.On("GetOrderByOrderID", "MLVT-1").Return(&entity.Order{OrderID: "MLVT-1", UserID: 1, Credits: 60, Status: entity.OrderStatusPending}, nil)
+	billingRepo.On("FulfillOrder", "MLVT-1", billingTestNow, mock.MatchedBy(func(entries []entity.LedgerEntry) bool {
+		return balanced(entries) && creditsOf(entries, 1) == 60 && entries[0].OrderID == "MLVT-1"
+	})).Return(true, nil)
+	billingRepo.On("GetOrderByOrderID", "MLVT-2").Return(nil, nil)
+
+	fulfilled, err := service.FulfillOrder("MLVT-1")
+	assert.NoError(t, err)
+	assert.True(t, fulfilled)
+
+	_, err = service.FulfillOrder("MLVT-2")
+	assert.ErrorIs(t, err, ErrOrderNotFound)
+	billingRepo.AssertExpectations(t)
+}
+
+func TestReserveRefund(t *testing.T) {
+	service, billingRepo := setupBillingService()
+	billingRepo.On("GetOrderByOrderID", "MLVT-1").Return(&entity.Order{OrderID: "MLVT-1", UserID: 1, Amount: 200000, Credits: 300, Status: entity.OrderStatusPaid}, nil)
+	billingRepo.On("GetTransaction", mock.Anything).Return(nil, nil)
+
+	// A quarter of the price takes back a quarter of the minutes
+	billingRepo.On("GetRefundedCredits", "MLVT-1").Return(int64(0), nil).Once()
+	billingRepo.On("ChargeCredits", uint64(1), mock.MatchedBy(func(entries []entity.LedgerEntry) bool {
+		return balanced(entries) && creditsOf(entries, 1) == -75 && entries[0].TransactionID == "refund:MLVT-1-R1"
+	})).Return(true, nil).Once()
+	assert.NoError(t, service.ReserveRefund("MLVT-1", "MLVT-1-R1", 50000, false))
+
+	// The last refund takes back the rest of them
+	billingRepo.On("GetRefundedCredits", "MLVT-1").Return(int64(75), nil).Once()
+	billingRepo.On("ChargeCredits", uint64(1), mock.MatchedBy(func(entries []entity.LedgerEntry) bool {
+		return creditsOf(entries, 1) == -225
+	})).Return(true, nil).Once()
+	assert.NoError(t, service.ReserveRefund("MLVT-1", "MLVT-1-R2", 150000, true))
+	billingRepo.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything, mock.Anything)
+	billingRepo.AssertExpectations(t)
+}
+
+func TestReserveRefund_PartialThenFinal(t *testing.T) {
+	service, billingRepo := setupBillingService()
+	billingRepo.On("GetOrderByOrderID", "MLVT-1").Return(&entity.Order{OrderID: "MLVT-1", UserID: 1, Amount: 200000, Credits: 300, Status: entity.OrderStatusPaid}, nil)
+	billingRepo.On("GetTransaction", mock.Anything).Return(nil, nil)
+
+	// Half of the order was refunded; refunding the other half takes back the other 150 minutes, not all 300 again
+	billingRepo.On("GetRefundedCredits", "MLVT-1").Return(int64(0), nil).Once()
+	billingRepo.On("GetRefundedCredits", "MLVT-1").Return(int64(150), nil)
+	billingRepo.On("ChargeCredits", uint64(1), mock.MatchedBy(func(entries []entity.LedgerEntry) bool {
+		return balanced(entries) && creditsOf(entries, 1) == -150
+	})).Return(true, nil).Twice()
+
+	assert.NoError(t, service.ReserveRefund("MLVT-1", "MLVT-1-R1", 100000, false))
+	assert.NoError(t, service.ReserveRefund("MLVT-1", "MLVT-1-R2", 100000, true))
+	billingRepo.AssertExpectations(t)
+}
+
+func TestReserveRefund_SpentMinutes(t *testing.T) {
+	service, billingRepo := setupBillingService()
+	billingRepo.On("GetOrderByOrderID", "MLVT-1").Return(&entity.Order{OrderID: "MLVT-1", UserID: 1, Amount: 200000, Credits: 300, Status: entity.OrderStatusPaid}, nil)
+	billingRepo.On("GetRefundedCredits", "MLVT-1").Return(int64(0), nil)
+	billingRepo.On("GetTransaction", "refund:MLVT-1-R1").Return(nil, nil)
+	billingRepo.On("ChargeCredits", uint64(1), mock.Anything).Return(false, nil).Once()
+
+	assert.ErrorIs(t, service.ReserveRefund("MLVT-1", "MLVT-1-R1", 200000, true), ErrInsufficientCredits)
+}
+
+func TestReserveRefund_Once(t *testing.T) {
+	service, billingRepo := setupBillingService()
+	billingRepo.On("GetOrderByOrderID", "MLVT-1").Return(&entity.Order{OrderID: "MLVT-1", UserID: 1, Amount: 200000, Credits: 300, Status: entity.OrderStatusPaid}, nil)
+	billingRepo.On("GetTransaction", "refund:MLVT-1-R1").Return([]entity.LedgerEntry{{TransactionID: "refund:MLVT-1-R1"}}, nil)
+
+	assert.NoError(t, service.ReserveRefund("MLVT-1", "MLVT-1-R1", 200000, true))
+	billingRepo.AssertNotCalled(t, "ChargeCredits", mock.Anything, mock.Anything)
+}
+
+func TestCancelRefund(t *testing.T) {
+	service, billingRepo := setupBillingService()
+	reserved := transferEntries("refund:MLVT-1-R1", 1, entity.LedgerAccountRefunds, -75, entity.LedgerKindPaymentRefund, "Refund")
+	for i := range reserved {
+		reserved[i].OrderID = "MLVT-1"
+	}
+	billingRepo.On("GetTransaction", "refund:MLVT-1-R1").Return(reserved, nil)
+	billingRepo.On("PostTransaction", mock.MatchedBy(func(entries []entity.LedgerEntry) bool {
+		return balanced(entries) && creditsOf(entries, 1) == 75 && entries[0].TransactionID == "refund:MLVT-1-R1:cancel" &&
+			entries[0].Kind == entity.LedgerKindPaymentRefund && entries[0].OrderID == "MLVT-1"
+	})).Return(true, nil).Once()
+	assert.NoError(t, service.CancelRefund("MLVT-1-R1"))
+
+	// Refunds that took nothing have nothing to give back
+	billingRepo.On("GetTransaction", "refund:MLVT-1-R2").Return(nil, nil)
+	assert.NoError(t, service.CancelRefund("MLVT-1-R2"))
+	billingRepo.AssertExpectations(t)
+}
+
+func TestCompleteRefund(t *testing.T) {
+	service, billingRepo := setupBillingService()
+	billingRepo.On("UpdateOrderStatus", "MLVT-1", entity.OrderStatusPaid, entity.OrderStatusRefunded).Return(true, nil).Once()
+
+	assert.NoError(t, service.CompleteRefund("MLVT-1", false))
+	assert.NoError(t, service.CompleteRefund("MLVT-1", true))
+	billingRepo.AssertExpectations(t)
+}
+
+func TestChargeAndRefundTranslations(t *testing.T) {
+	service, billingRepo := setupBillingService()
+	translations := []entity.Translation{{ID: 5, VideoID: 1, TargetLang: "vi"}, {ID: 6, VideoID: 1, TargetLang: "fr"}}
+
+	var charged []entity.LedgerEntry
+	billingRepo.On("ChargeCredits", uint64(1), mock.MatchedBy(func(entries []entity.LedgerEntry) bool {
+		return balanced(entries) && creditsOf(entries, 1) == -6
+	})).Run(func(args mock.Arguments) {
+		charged = args.Get(1).([]entity.LedgerEntry)
+	}).Return(true, nil).Once()
+	assert.NoError(t, service.ChargeTranslations(1, translations, 3))
+
+	billingRepo.On("ChargeCredits", uint64(1), mock.Anything).Return(false, nil).Once()
+	assert.ErrorIs(t, service.ChargeTranslations(1, translations, 3), ErrInsufficientCredits)
+
+	// A failed translation gets its own charge back
+	billingRepo.On("GetTransaction", "translation:5").Return(charged[:2], nil)
+	billingRepo.On("PostTransaction", mock.MatchedBy(func(entries []entity.LedgerEntry) bool {
+		return balanced(entries) && creditsOf(entries, 1) == 3 && entries[0].TransactionID == "translation:5:refund" &&
+			entries[0].Kind == entity.LedgerKindTranslationRefund && *entries[0].TranslationID == 5
+	})).Return(true, nil).Once()
+	assert.NoError(t, service.RefundTranslation(&translations[0]))
+
+	// Translations requested before they were charged for are left alone
+	billingRepo.On("GetTransaction", "translation:4").Return(nil, nil)
+	assert.NoError(t, service.RefundTranslation(&entity.Translation{ID: 4}))
+	billingRepo.AssertExpectations(t)
+}
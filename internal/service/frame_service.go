@@ -0,0 +1,191 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/storage"
+	"mlvt/internal/repo"
+	"path"
+	"strings"
+)
+
+var (
+	ErrFrameNotFound    = errors.New("frame not found")
+	ErrInvalidFrame     = errors.New("invalid frame")
+	ErrFrameNotUploaded = errors.New("frame image not found in storage")
+)
+
+// MaxFramesPerRequest is the most frames AddFrames registers at once
+const MaxFramesPerRequest = 100
+
+// FrameService registers the keyframes uploaded for a video and selects its primary thumbnail
+type FrameService interface {
+	AddFrames(videoID uint64, frames []*entity.Frame) error
+	ListFrames(videoID uint64) ([]entity.Frame, error)
+	SetPrimaryFrame(videoID, frameID uint64) (*entity.Frame, error)
+	DeleteFrame(videoID, frameID uint64) error
+}
+
+type frameService struct {
+	repo      repo.FrameRepository
+	videoRepo repo.VideoRepository
+	store     storage.Storage
+}
+
+func NewFrameService(repo repo.FrameRepository, videoRepo repo.VideoRepository, store storage.Storage) FrameService {
+	return &frameService{
+		repo:      repo,
+		videoRepo: videoRepo,
+		store:     store,
+	}
+}
+
+// AddFrames registers frame images that were already uploaded to storage. Each image must exist
+// and be an image; the frames are stored all together or not at all.
+func (s *frameService) AddFrames(videoID uint64, frames []*entity.Frame) error {
+	video, err := s.getVideo(videoID)
+	if err != nil {
+		return err
+	}
+	if err := validateFrames(video, frames); err != nil {
+		return err
+	}
+
+	for _, frame := range frames {
+		info, err := s.store.HeadObject(frame.Folder, frame.FileName)
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return fmt.Errorf("%w: %s", ErrFrameNotUploaded, frame.FileName)
+		}
+		if err != nil {
+			return err
+		}
+		if info.ContentType != "" && !strings.HasPrefix(mediaType(info.ContentType), "image/") {
+			return fmt.Errorf("%w: %s is not an image", ErrInvalidFrame, frame.FileName)
+		}
+		frame.VideoID = videoID
+	}
+
+	if err := s.repo.CreateFrames(frames); err != nil {
+		return err
+	}
+	for _, frame := range frames {
+		if err := presignFrame(s.store, frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListFrames returns the frames of a video in playback order with presigned download links
+func (s *frameService) ListFrames(videoID uint64) ([]entity.Frame, error) {
+	if _, err := s.getVideo(videoID); err != nil {
+		return nil, err
+	}
+
+	frames, err := s.repo.ListFramesByVideoID(videoID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range frames {
+		if err := presignFrame(s.store, &frames[i]); err != nil {
+			return nil, err
+		}
+	}
+	return frames, nil
+}
+
+// SetPrimaryFrame makes a frame the thumbnail of its video
+func (s *frameService) SetPrimaryFrame(videoID, frameID uint64) (*entity.Frame, error) {
+	frame, err := s.getFrame(videoID, frameID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetPrimaryFrame(videoID, frameID); err != nil {
+		return nil, err
+	}
+
+	frame.IsPrimary = true
+	if err := presignFrame(s.store, frame); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// DeleteFrame deletes a frame; its image is removed from storage in the background
+func (s *frameService) DeleteFrame(videoID, frameID uint64) error {
+	if _, err := s.getFrame(videoID, frameID); err != nil {
+		return err
+	}
+	return s.repo.DeleteFrame(frameID)
+}
+
+func (s *frameService) getVideo(videoID uint64) (*entity.Video, error) {
+	video, err := s.videoRepo.GetVideoByID(videoID)
+	if err != nil {
+		return nil, err
+	}
+	if video == nil {
+		return nil, ErrVideoNotFound
+	}
+	return video, nil
+}
+
+// getFrame returns a frame only if it belongs to the video
+func (s *frameService) getFrame(videoID, frameID uint64) (*entity.Frame, error) {
+	frame, err := s.repo.GetFrameByID(frameID)
+	if err != nil {
+		return nil, err
+	}
+	if frame == nil || frame.VideoID != videoID {
+		return nil, ErrFrameNotFound
+	}
+	return frame, nil
+}
+
+func validateFrames(video *entity.Video, frames []*entity.Frame) error {
+	if len(frames) == 0 || len(frames) > MaxFramesPerRequest {
+		return fmt.Errorf("%w: between 1 and %d frames may be added at once", ErrInvalidFrame, MaxFramesPerRequest)
+	}
+
+	primaries := 0
+	for _, frame := range frames {
+		switch {
+		case !isBaseName(frame.FileName):
+			return fmt.Errorf("%w: file_name must be the name of an uploaded image", ErrInvalidFrame)
+		case frame.TimestampMs < 0:
+			return fmt.Errorf("%w: timestamp_ms must not be negative", ErrInvalidFrame)
+		case video.Duration > 0 && frame.TimestampMs > int64(video.Duration)*1000:
+			return fmt.Errorf("%w: timestamp_ms is past the end of the video", ErrInvalidFrame)
+		case frame.Width <= 0 || frame.Height <= 0:
+			return fmt.Errorf("%w: width and height must be positive", ErrInvalidFrame)
+		}
+		if frame.IsPrimary {
+			primaries++
+		}
+	}
+	if primaries > 1 {
+		return fmt.Errorf("%w: only one frame can be primary", ErrInvalidFrame)
+	}
+	return nil
+}
+
+// isBaseName reports whether name is a file name without any directory
+func isBaseName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\\")
+}
+
+// presignFrame sets the link of a frame to a presigned download URL of its image
+func presignFrame(store storage.Storage, frame *entity.Frame) error {
+	contentType := mime.TypeByExtension(path.Ext(frame.FileName))
+	if !strings.HasPrefix(contentType, "image/") {
+		contentType = "image/jpeg"
+	}
+	link, err := store.GeneratePresignedDownloadURL(frame.Folder, frame.FileName, contentType)
+	if err != nil {
+		return fmt.Errorf("failed to generate presigned URL for frame %d: %v", frame.ID, err)
+	}
+	frame.Link = link
+	return nil
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"mlvt/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockFrameService is a mock implementation of the FrameService interface
+type MockFrameService struct {
+	mock.Mock
+}
+
+func (m *MockFrameService) AddFrames(videoID uint64, frames []*entity.Frame) error {
+	args := m.Called(videoID, frames)
+	return args.Error(0)
+}
+
+func (m *MockFrameService) ListFrames(videoID uint64) ([]entity.Frame, error) {
+	args := m.Called(videoID)
+	frames, _ := args.Get(0).([]entity.Frame)
+	return frames, args.Error(1)
+}
+
+func (m *MockFrameService) SetPrimaryFrame(videoID, frameID uint64) (*entity.Frame, error) {
+	args := m.Called(videoID, frameID)
+	frame, _ := args.Get(0).(*entity.Frame)
+	return frame, args.Error(1)
+}
+
+func (m *MockFrameService) DeleteFrame(videoID, frameID uint64) error {
+	args := m.Called(videoID, frameID)
+	return args.Error(0)
+}
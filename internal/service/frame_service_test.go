@@ -0,0 +1,140 @@
+package service
+
+import (
+	"errors"
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/storage"
+	"mlvt/internal/repo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupFrameService() (FrameService, *repo.MockFrameRepository, *repo.MockVideoRepository, *storage.MockStorage) {
+	frameRepo := new(repo.MockFrameRepository)
+	videoRepo := new(repo.MockVideoRepository)
+	store := new(storage.MockStorage)
+	return NewFrameService(frameRepo, videoRepo, store), frameRepo, videoRepo, store
+}
+
+func TestAddFrames(t *testing.T) {
+	frameService, frameRepo, videoRepo, store := setupFrameService()
+
+	videoRepo.On("GetVideoByID", uint64(1)).Return(&entity.Video{ID: 1, Duration: 60}, nil)
+	store.On("HeadObject", "frames", "a-1.jpg").Return(&storage.ObjectInfo{ContentType: "image/jpeg"}, nil)
+	store.On("HeadObject", "frames", "a-2.webp").Return(&storage.ObjectInfo{ContentType: "image/webp"}, nil)
+	frameRepo.On("CreateFrames", mock.Anything).Return(nil)
+	store.On("GeneratePresignedDownloadURL", "frames", "a-1.jpg", "image/jpeg").Return("https://example.com/a-1.jpg", nil)
+	store.On("GeneratePresignedDownloadURL", "frames", "a-2.webp", "image/webp").Return("https://example.com/a-2.webp", nil)
+
+	frames := []*entity.Frame{
+		{Folder: "frames", FileName: "a-1.jpg", TimestampMs: 0, Width: 1280, Height: 720, IsPrimary: true},
+		{Folder: "frames", FileName: "a-2.webp", TimestampMs: 60000, Width: 1280, Height: 720},
+	}
+	err := frameService.AddFrames(1, frames)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), frames[1].VideoID)
+	assert.Equal(t, "https://example.com/a-2.webp", frames[1].Link)
+	frameRepo.AssertExpectations(t)
+}
+
+func TestAddFrames_Invalid(t *testing.T) {
+	valid := entity.Frame{Folder: "frames", FileName: "a.jpg", Width: 640, Height: 360}
+	withFrame := func(change func(frame *entity.Frame)) []*entity.Frame {
+		frame := valid
+		change(&frame)
+		return []*entity.Frame{&frame}
+	}
+
+	tests := []struct {
+		name   string
+		frames []*entity.Frame
+	}{
+		{"no frames", nil},
+		{"path in file name", withFrame(func(frame *entity.Frame) { frame.FileName = "../videos/a.mp4" })},
+		{"negative timestamp", withFrame(func(frame *entity.Frame) { frame.TimestampMs = -1 })},
+		{"past the end", withFrame(func(frame *entity.Frame) { frame.TimestampMs = 60001 })},
+		{"no size", withFrame(func(frame *entity.Frame) { frame.Width = 0 })},
+		{"two primaries", []*entity.Frame{{FileName: "a.jpg", Width: 1, Height: 1, IsPrimary: true}, {FileName: "b.jpg", Width: 1, Height: 1, IsPrimary: true}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frameService, frameRepo, videoRepo, _ := setupFrameService()
+			videoRepo.On("GetVideoByID", uint64(1)).Return(&entity.Video{ID: 1, Duration: 60}, nil)
+
+			err := frameService.AddFrames(1, tt.frames)
+			assert.ErrorIs(t, err, ErrInvalidFrame)
+			frameRepo.AssertNotCalled(t, "CreateFrames", mock.Anything)
+		})
+	}
+}
+
+func TestAddFrames_ImageNotUploaded(t *testing.T) {
+	frameService, frameRepo, videoRepo, store := setupFrameService()
+	videoRepo.On("GetVideoByID", uint64(1)).Return(&entity.Video{ID: 1}, nil)
+	store.On("HeadObject", "frames", "a.jpg").Return(nil, storage.ErrObjectNotFound)
+
+	err := frameService.AddFrames(1, []*entity.Frame{{Folder: "frames", FileName: "a.jpg", Width: 1, Height: 1}})
+	assert.ErrorIs(t, err, ErrFrameNotUploaded)
+	frameRepo.AssertNotCalled(t, "CreateFrames", mock.Anything)
+}
+
+func TestAddFrames_NotAnImage(t *testing.T) {
+	frameService, _, videoRepo, store := setupFrameService()
+	videoRepo.On("GetVideoByID", uint64(1)).Return(&entity.Video{ID: 1}, nil)
+	store.On("HeadObject", "frames", "a.jpg").Return(&storage.ObjectInfo{ContentType: "video/mp4"}, nil)
+
+	err := frameService.AddFrames(1, []*entity.Frame{{Folder: "frames", FileName: "a.jpg", Width: 1, Height: 1}})
+	assert.ErrorIs(t, err, ErrInvalidFrame)
+}
+
+func TestAddFrames_VideoNotFound(t *testing.T) {
+	frameService, _, videoRepo, _ := setupFrameService()
+	videoRepo.On("GetVideoByID", uint64(1)).Return((*entity.Video)(nil), nil)
+
+	err := frameService.AddFrames(1, []*entity.Frame{{FileName: "a.jpg", Width: 1, Height: 1}})
+	assert.ErrorIs(t, err, ErrVideoNotFound)
+}
+
+func TestListFrames(t *testing.T) {
+	frameService, frameRepo, videoRepo, store := setupFrameService()
+	videoRepo.On("GetVideoByID", uint64(1)).Return(&entity.Video{ID: 1}, nil)
+	frameRepo.On("ListFramesByVideoID", uint64(1)).Return([]entity.Frame{{ID: 1, VideoID: 1, Folder: "frames", FileName: "a-1"}}, nil)
+	store.On("GeneratePresignedDownloadURL", "frames", "a-1", "image/jpeg").Return("https://example.com/a-1", nil)
+
+	frames, err := frameService.ListFrames(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/a-1", frames[0].Link)
+}
+
+func TestSetPrimaryFrame(t *testing.T) {
+	frameService, frameRepo, _, store := setupFrameService()
+	frameRepo.On("GetFrameByID", uint64(3)).Return(&entity.Frame{ID: 3, VideoID: 1, Folder: "frames", FileName: "a.png"}, nil)
+	frameRepo.On("SetPrimaryFrame", uint64(1), uint64(3)).Return(nil)
+	store.On("GeneratePresignedDownloadURL", "frames", "a.png", "image/png").Return("https://example.com/a.png", nil)
+
+	frame, err := frameService.SetPrimaryFrame(1, 3)
+	assert.NoError(t, err)
+	assert.True(t, frame.IsPrimary)
+	assert.Equal(t, "https://example.com/a.png", frame.Link)
+}
+
+func TestSetPrimaryFrame_OtherVideo(t *testing.T) {
+	frameService, frameRepo, _, _ := setupFrameService()
+	frameRepo.On("GetFrameByID", uint64(3)).Return(&entity.Frame{ID: 3, VideoID: 2}, nil)
+
+	_, err := frameService.SetPrimaryFrame(1, 3)
+	assert.ErrorIs(t, err, ErrFrameNotFound)
+	frameRepo.AssertNotCalled(t, "SetPrimaryFrame", mock.Anything, mock.Anything)
+}
+
+func TestDeleteFrame(t *testing.T) {
+	frameService, frameRepo, _, _ := setupFrameService()
+	frameRepo.On("GetFrameByID", uint64(3)).Return(&entity.Frame{ID: 3, VideoID: 1}, nil)
+	frameRepo.On("GetFrameByID", uint64(4)).Return(nil, nil)
+	frameRepo.On("DeleteFrame", uint64(3)).Return(errors.New("database locked"))
+
+	assert.Error(t, frameService.DeleteFrame(1, 3))
+	assert.ErrorIs(t, frameService.DeleteFrame(1, 4), ErrFrameNotFound)
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/zap-logging/log"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Failed sign-ins to an account beyond LoginDelayAfterFailures lock it for a delay that starts at
+// LoginDelayBase and doubles with each further failure, until MaxLoginFailures locks it for LoginLockout
+const (
+	LoginDelayAfterFailures = 3
+	LoginDelayBase          = time.Second
+)
+
+// ErrTooManyLoginAttempts is wrapped by LoginLockedError
+var ErrTooManyLoginAttempts = errors.New("too many failed sign-in attempts")
+
+// LoginLockedError is returned by Login while the account or the client IP is locked
+type LoginLockedError struct {
+	RetryAt time.Time // When sign-ins are accepted again
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("%v; try again after %s", ErrTooManyLoginAttempts, e.RetryAt.UTC().Format(time.RFC3339))
+}
+
+func (e *LoginLockedError) Unwrap() error {
+	return ErrTooManyLoginAttempts
+}
+
+// ListLockedAccounts returns the accounts that currently refuse sign-ins
+func (s *AuthService) ListLockedAccounts() ([]entity.LoginThrottle, error) {
+	return s.throttleRepo.ListLockedLogins(entity.LoginThrottleAccount, s.now())
+}
+
+// UnlockAccount lifts the lock of an account and forgets its failed sign-ins
+func (s *AuthService) UnlockAccount(email string) error {
+	return s.throttleRepo.ResetLoginThrottle(entity.LoginThrottleAccount, normalizeEmail(email))
+}
+
+// checkLoginLocks returns a *LoginLockedError if the account or the client IP is locked.
+// Failing to read the locks is logged and does not block sign-ins.
+func (s *AuthService) checkLoginLocks(account, clientIP string) error {
+	now := s.now()
+	var retryAt time.Time
+	for _, key := range loginThrottleKeys(account, clientIP) {
+		throttle, err := s.throttleRepo.GetLoginThrottle(key.scope, key.subject)
+		if err != nil {
+			log.Errorf("failed to read login failures of %s %s: %v", key.scope, key.subject, err)
+			continue
+		}
+		if throttle != nil && throttle.LockedAt(now) && throttle.LockedUntil.After(retryAt) {
+			retryAt = *throttle.LockedUntil
+		}
+	}
+	if !retryAt.IsZero() {
+		return &LoginLockedError{RetryAt: retryAt}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed sign-in against the account and the client IP and locks them when due
+func (s *AuthService) recordLoginFailure(account, clientIP string, userID *uint64) {
+	now := s.now()
+	for _, key := range loginThrottleKeys(account, clientIP) {
+		var id *uint64
+		if key.scope == entity.LoginThrottleAccount {
+			id = userID
+		}
+		failures, err := s.throttleRepo.RecordLoginFailure(key.scope, key.subject, id, now.Add(-s.config.LoginLockout))
+		if err != nil {
+			log.Errorf("failed to record login failure of %s %s: %v", key.scope, key.subject, err)
+			continue
+		}
+
+		lock := s.loginLockDuration(key.scope, failures)
+		if lock <= 0 {
+			continue
+		}
+		if lock == s.config.LoginLockout {
+			log.Warnf("Locking sign-ins of %s %s for %s after %d failures", key.scope, key.subject, lock, failures)
+		}
+		if err := s.throttleRepo.LockLogin(key.scope, key.subject, now.Add(lock)); err != nil {
+			log.Errorf("failed to lock login of %s %s: %v", key.scope, key.subject, err)
+		}
+	}
+}
+
+// resetLoginFailures forgets the failed sign-ins of an account after a successful one.
+// Failures of the client IP are kept, so signing in to one account does not hide guessing at others.
+func (s *AuthService) resetLoginFailures(account string) {
+	if err := s.throttleRepo.ResetLoginThrottle(entity.LoginThrottleAccount, account); err != nil {
+		log.Errorf("failed to reset login failures of %s: %v", account, err)
+	}
+}
+
+// loginLockDuration returns how long sign-ins are refused after the given number of failures
+func (s *AuthService) loginLockDuration(scope string, failures int) time.Duration {
+	if scope == entity.LoginThrottleIP {
+		if failures >= s.config.MaxIPLoginFailures {
+			return s.config.LoginLockout
+		}
+		return 0
+	}
+
+	if failures >= s.config.MaxLoginFailures {
+		return s.config.LoginLockout
+	}
+	if failures < LoginDelayAfterFailures {
+		return 0
+	}
+	delay := LoginDelayBase << (failures - LoginDelayAfterFailures)
+	if delay <= 0 || delay > s.config.LoginLockout {
+		return s.config.LoginLockout
+	}
+	return delay
+}
+
+type loginThrottleKey struct {
+	scope   string
+	subject string
+}
+
+// loginThrottleKeys returns the account and, if known, the client IP a sign-in is counted against
+func loginThrottleKeys(account, clientIP string) []loginThrottleKey {
+	keys := []loginThrottleKey{{entity.LoginThrottleAccount, account}}
+	if clientIP != "" {
+		keys = append(keys, loginThrottleKey{entity.LoginThrottleIP, clientIP})
+	}
+	return keys
+}
+
+// normalizeEmail returns the form of an email address failures are counted under
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+var (
+	dummyPasswordHash     []byte
+	dummyPasswordHashOnce sync.Once
+)
+
+// compareDummyPassword spends the time of a bcrypt comparison
+func compareDummyPassword(password string) {
+	dummyPasswordHashOnce.Do(func() {
+		dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/media"
+	"mlvt/internal/infra/storage"
+	"mlvt/internal/infra/zap-logging/log"
+	"mlvt/internal/repo"
+	"os"
+	"path"
+	"time"
+)
+
+var (
+	ErrAudioNotFound         = errors.New("audio not found")
+	ErrMediaNotUploaded      = errors.New("media file not found in storage")
+	ErrUnreadableMedia       = errors.New("media file could not be read")
+	ErrMediaProbeUnavailable = errors.New("media probing is not available")
+)
+
+// DefaultMediaProbeTimeout bounds the download and probe of one file when MediaProbeConfig.Timeout is zero
+const DefaultMediaProbeTimeout = 5 * time.Minute
+
+// MediaProbeConfig controls how stored files are inspected
+type MediaProbeConfig struct {
+	TempDir string        // Directory for the temporary local copies; empty uses the system default
+	Timeout time.Duration // Maximum time to download and probe one file
+}
+
+// withDefaults fills zero fields with the package defaults
+func (c MediaProbeConfig) withDefaults() MediaProbeConfig {
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultMediaProbeTimeout
+	}
+	return c
+}
+
+// MediaProbeService measures stored video and audio files and records their duration and stream details,
+// replacing the values supplied by the client
+type MediaProbeService interface {
+	ProbeVideo(ctx context.Context, videoID uint64) (*entity.Video, error)
+	ProbeAudio(ctx context.Context, audioID uint64) (*entity.Audio, error)
+}
+
+type mediaProbeService struct {
+	videoRepo repo.VideoRepository
+	audioRepo repo.AudioRepository
+	store     storage.Storage
+	prober    media.Prober
+	config    MediaProbeConfig
+	now       func() time.Time
+}
+
+// NewMediaProbeService creates the service; a nil prober makes every probe fail with ErrMediaProbeUnavailable
+func NewMediaProbeService(videoRepo repo.VideoRepository, audioRepo repo.AudioRepository, store storage.Storage,
+	prober media.Prober, config MediaProbeConfig) MediaProbeService {
+	return &mediaProbeService{
+		videoRepo: videoRepo,
+		audioRepo: audioRepo,
+		store:     store,
+		prober:    prober,
+		config:    config.withDefaults(),
+		now:       time.Now,
+	}
+}
+
+// ProbeVideo inspects the file of a video, which must have a video stream, and stores what it finds
+func (s *mediaProbeService) ProbeVideo(ctx context.Context, videoID uint64) (*entity.Video, error) {
+	video, err := s.videoRepo.GetVideoByID(videoID)
+	if err != nil {
+		return nil, err
+	}
+	if video == nil {
+		return nil, ErrVideoNotFound
+	}
+
+	info, err := s.probeObject(ctx, video.Folder, video.FileName)
+	if err != nil {
+		return nil, err
+	}
+	if !info.HasVideo() {
+		return nil, fmt.Errorf("%w: %s has no video stream", ErrUnreadableMedia, video.FileName)
+	}
+
+	video.Duration, video.MediaInfo = s.toMediaInfo(info)
+	if err := s.videoRepo.UpdateVideoMediaInfo(videoID, video.Duration, video.MediaInfo); err != nil {
+		if errors.Is(err, repo.ErrVideoNotFound) {
+			return nil, ErrVideoNotFound // Deleted while it was being probed
+		}
+		return nil, err
+	}
+	return video, nil
+}
+
+// ProbeAudio inspects the file of an audio, which must have an audio stream, and stores what it finds
+func (s *mediaProbeService) ProbeAudio(ctx context.Context, audioID uint64) (*entity.Audio, error) {
+	audio, err := s.audioRepo.GetAudioByID(audioID)
+	if err != nil {
+		return nil, err
+	}
+	if audio == nil {
+		return nil, ErrAudioNotFound
+	}
+
+	info, err := s.probeObject(ctx, audio.Folder, audio.FileName)
+	if err != nil {
+		return nil, err
+	}
+	if !info.HasAudio() {
+		return nil, fmt.Errorf("%w: %s has no audio stream", ErrUnreadableMedia, audio.FileName)
+	}
+
+	audio.Duration, audio.MediaInfo = s.toMediaInfo(info)
+	if err := s.audioRepo.UpdateAudioMediaInfo(audioID, audio.Duration, audio.MediaInfo); err != nil {
+		return nil, err
+	}
+	return audio, nil
+}
+
+// probeNewVideo replaces the client-supplied duration of a video that was just stored with the one
+// measured from its file. A failed probe does not fail the creation; the video can be probed again later.
+func probeNewVideo(probeService MediaProbeService, video *entity.Video) {
+	probed, err := probeService.ProbeVideo(context.Background(), video.ID)
+	switch {
+	case err == nil:
+		video.Duration = probed.Duration
+		video.MediaInfo = probed.MediaInfo
+	case errors.Is(err, ErrMediaNotUploaded), errors.Is(err, ErrMediaProbeUnavailable):
+		// Keep the client-supplied duration until the file can be probed
+	default:
+		log.Warnf("Failed to probe video %d: %v", video.ID, err)
+	}
+}
+
+// probeObject copies a stored object to a temporary file, probes it and removes the copy
+func (s *mediaProbeService) probeObject(ctx context.Context, folder, fileName string) (*media.Info, error) {
+	if s.prober == nil {
+		return nil, ErrMediaProbeUnavailable
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	object, err := s.store.GetObject(folder, fileName)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrMediaNotUploaded, fileName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %v", fileName, err)
+	}
+	// Closing the object interrupts a download still running when the timeout fires
+	stop := context.AfterFunc(ctx, func() { object.Close() })
+	defer func() {
+		if stop() {
+			object.Close()
+		}
+	}()
+
+	// Keep the extension; it helps ffprobe with formats that have no reliable signature
+	file, err := os.CreateTemp(s.config.TempDir, "probe-*"+path.Ext(fileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer os.Remove(file.Name())
+
+	_, err = io.Copy(file, contextReader{ctx: ctx, r: object})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %v", fileName, err)
+	}
+
+	info, err := s.prober.Probe(ctx, file.Name())
+	if errors.Is(err, media.ErrInvalidMedia) {
+		return nil, fmt.Errorf("%w: %v", ErrUnreadableMedia, err)
+	}
+	return info, err
+}
+
+// contextReader stops reading once its context is done
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// toMediaInfo converts probe results into the whole-second duration and stream details stored on entities
+func (s *mediaProbeService) toMediaInfo(info *media.Info) (int, entity.MediaInfo) {
+	probedAt := s.now()
+	return int(math.Round(info.Duration.Seconds())), entity.MediaInfo{
+		VideoCodec:    info.VideoCodec,
+		AudioCodec:    info.AudioCodec,
+		Width:         info.Width,
+		Height:        info.Height,
+		Bitrate:       info.Bitrate,
+		AudioChannels: info.AudioChannels,
+		SampleRate:    info.SampleRate,
+		ProbedAt:      &probedAt,
+	}
+}
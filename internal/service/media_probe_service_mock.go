@@ -0,0 +1,25 @@
+package service
+
+import (
+	"context"
+	"mlvt/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockMediaProbeService is a mock implementation of the MediaProbeService interface
+type MockMediaProbeService struct {
+	mock.Mock
+}
+
+func (m *MockMediaProbeService) ProbeVideo(ctx context.Context, videoID uint64) (*entity.Video, error) {
+	args := m.Called(ctx, videoID)
+	video, _ := args.Get(0).(*entity.Video)
+	return video, args.Error(1)
+}
+
+func (m *MockMediaProbeService) ProbeAudio(ctx context.Context, audioID uint64) (*entity.Audio, error) {
+	args := m.Called(ctx, audioID)
+	audio, _ := args.Get(0).(*entity.Audio)
+	return audio, args.Error(1)
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/media"
+	"mlvt/internal/infra/storage"
+	"mlvt/internal/repo"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupMediaProbeService(t *testing.T) (MediaProbeService, *repo.MockVideoRepository, *repo.MockAudioRepository, *storage.MockStorage, *media.MockProber) {
+	videoRepo := new(repo.MockVideoRepository)
+	audioRepo := new(repo.MockAudioRepository)
+	store := new(storage.MockStorage)
+	prober := new(media.MockProber)
+	probeService := NewMediaProbeService(videoRepo, audioRepo, store, prober, MediaProbeConfig{TempDir: t.TempDir()})
+	return probeService, videoRepo, audioRepo, store, prober
+}
+
+func TestProbeVideo(t *testing.T) {
+	probeService, videoRepo, _, store, prober := setupMediaProbeService(t)
+
+	videoRepo.On("GetVideoByID", uint64(1)).Return(&entity.Video{ID: 1, Duration: 999, Folder: "videos", FileName: "a.mp4"}, nil)
+	store.On("GetObject", "videos", "a.mp4").Return(io.NopCloser(strings.NewReader("video bytes")), nil)
+
+	var probedPath string
+	prober.On("Probe", mock.Anything, mock.MatchedBy(func(filePath string) bool {
+		// The prober reads a local copy of the stored object
+		data, err := os.ReadFile(filePath)
+		probedPath = filePath
+		return err == nil && string(data) == "video bytes" && strings.HasSuffix(filePath, ".mp4")
+	})).Return(&media.Info{
+		Duration:      90*time.Second + 600*time.Millisecond,
+		Bitrate:       2500000,
+		VideoCodec:    "h264",
+		Width:         1280,
+		Height:        720,
+		AudioCodec:    "aac",
+		AudioChannels: 2,
+		SampleRate:    44100,
+	}, nil)
+	videoRepo.On("UpdateVideoMediaInfo", uint64(1), 91, mock.MatchedBy(func(info entity.MediaInfo) bool {
+		return info.VideoCodec == "h264" && info.Width == 1280 && info.SampleRate == 44100 && info.ProbedAt != nil
+	})).Return(nil)
+
+	video, err := probeService.ProbeVideo(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 91, video.Duration)
+	assert.Equal(t, int64(2500000), video.Bitrate)
+	videoRepo.AssertExpectations(t)
+
+	// The temporary copy is removed after probing
+	_, err = os.Stat(probedPath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestProbeVideo_Errors(t *testing.T) {
+	t.Run("video not found", func(t *testing.T) {
+		probeService, videoRepo, _, _, _ := setupMediaProbeService(t)
+		videoRepo.On("GetVideoByID", uint64(1)).Return((*entity.Video)(nil), nil)
+
+		_, err := probeService.ProbeVideo(context.Background(), 1)
+		assert.ErrorIs(t, err, ErrVideoNotFound)
+	})
+
+	t.Run("video deleted while probing", func(t *testing.T) {
+		probeService, videoRepo, _, store, prober := setupMediaProbeService(t)
+		videoRepo.On("GetVideoByID", uint64(1)).Return(&entity.Video{ID: 1, Folder: "videos", FileName: "a.mp4"}, nil)
+		store.On("GetObject", "videos", "a.mp4").Return(io.NopCloser(strings.NewReader("video bytes")), nil)
+		prober.On("Probe", mock.Anything, mock.Anything).Return(&media.Info{Duration: time.Second, VideoCodec: "h264"}, nil)
+		videoRepo.On("UpdateVideoMediaInfo", uint64(1), mock.Anything, mock.Anything).
+			Return(fmt.Errorf("%w with id %d", repo.ErrVideoNotFound, 1))
+
+		_, err := probeService.ProbeVideo(context.Background(), 1)
+		assert.ErrorIs(t, err, ErrVideoNotFound)
+	})
+
+	t.Run("file not uploaded", func(t *testing.T) {
+		probeService, videoRepo, _, store, prober := setupMediaProbeService(t)
+		videoRepo.On("GetVideoByID", uint64(1)).Return(&entity.Video{ID: 1, Folder: "videos", FileName: "a.mp4"}, nil)
+		store.On("GetObject", "videos", "a.mp4").Return(nil, storage.ErrObjectNotFound)
+
+		_, err := probeService.ProbeVideo(context.Background(), 1)
+		assert.ErrorIs(t, err, ErrMediaNotUploaded)
+		prober.AssertNotCalled(t, "Probe", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unreadable file", func(t *testing.T) {
+		probeService, videoRepo, _, store, prober := setupMediaProbeService(t)
+		videoRepo.On("GetVideoByID", uint64(1)).Return(&entity.Video{ID: 1, Folder: "videos", FileName: "a.mp4"}, nil)
+		store.On("GetObject", "videos", "a.mp4").Return(io.NopCloser(strings.NewReader("text")), nil)
+		prober.On("Probe", mock.Anything, mock.Anything).Return(nil, media.ErrInvalidMedia)
+
+		_, err := probeService.ProbeVideo(context.Background(), 1)
+		assert.ErrorIs(t, err, ErrUnreadableMedia)
+		videoRepo.AssertNotCalled(t, "UpdateVideoMediaInfo", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("audio only file", func(t *testing.T) {
+		probeService, videoRepo, _, store, prober := setupMediaProbeService(t)
+		videoRepo.On("GetVideoByID", uint64(1)).Return(&entity.Video{ID: 1, Folder: "videos", FileName: "a.mp4"}, nil)
+		store.On("GetObject", "videos", "a.mp4").Return(io.NopCloser(strings.NewReader("audio")), nil)
+		prober.On("Probe", mock.Anything, mock.Anything).Return(&media.Info{Duration: time.Minute, AudioCodec: "aac"}, nil)
+
+		_, err := probeService.ProbeVideo(context.Background(), 1)
+		assert.ErrorIs(t, err, ErrUnreadableMedia)
+	})
+
+	t.Run("download times out", func(t *testing.T) {
+		videoRepo := new(repo.MockVideoRepository)
+		store := new(storage.MockStorage)
+		prober := new(media.MockProber)
+		probeService := NewMediaProbeService(videoRepo, nil, store, prober, MediaProbeConfig{TempDir: t.TempDir(), Timeout: 50 * time.Millisecond})
+		videoRepo.On("GetVideoByID", uint64(1)).Return(&entity.Video{ID: 1, Folder: "videos", FileName: "a.mp4"}, nil)
+		// A download that never ends until the object is closed
+		reader, writer := io.Pipe()
+		go writer.Write([]byte("video bytes"))
+		store.On("GetObject", "videos", "a.mp4").Return(reader, nil)
+
+		_, err := probeService.ProbeVideo(context.Background(), 1)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		prober.AssertNotCalled(t, "Probe", mock.Anything, mock.Anything)
+	})
+
+	t.Run("no prober", func(t *testing.T) {
+		videoRepo := new(repo.MockVideoRepository)
+		videoRepo.On("GetVideoByID", uint64(1)).Return(&entity.Video{ID: 1, Folder: "videos", FileName: "a.mp4"}, nil)
+		probeService := NewMediaProbeService(videoRepo, nil, nil, nil, MediaProbeConfig{})
+
+		_, err := probeService.ProbeVideo(context.Background(), 1)
+		assert.ErrorIs(t, err, ErrMediaProbeUnavailable)
+	})
+}
+
+func TestProbeAudio(t *testing.T) {
+	probeService, _, audioRepo, store, prober := setupMediaProbeService(t)
+
+	audioRepo.On("GetAudioByID", uint64(3)).Return(&entity.Audio{ID: 3, Duration: 5, Folder: "audios", FileName: "a.mp3"}, nil)
+	store.On("GetObject", "audios", "a.mp3").Return(io.NopCloser(strings.NewReader("audio bytes")), nil)
+	prober.On("Probe", mock.Anything, mock.Anything).Return(&media.Info{
+		Duration:      42 * time.Second,
+		Bitrate:       128000,
+		AudioCodec:    "mp3",
+		AudioChannels: 1,
+		SampleRate:    22050,
+	}, nil)
+	audioRepo.On("UpdateAudioMediaInfo", uint64(3), 42, mock.MatchedBy(func(info entity.MediaInfo) bool {
+		return info.AudioCodec == "mp3" && info.VideoCodec == "" && info.AudioChannels == 1
+	})).Return(nil)
+
+	audio, err := probeService.ProbeAudio(context.Background(), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, audio.Duration)
+	assert.Equal(t, 22050, audio.SampleRate)
+	audioRepo.AssertExpectations(t)
+}
+
+func TestProbeAudio_NotFound(t *testing.T) {
+	probeService, _, audioRepo, _, _ := setupMediaProbeService(t)
+	audioRepo.On("GetAudioByID", uint64(3)).Return(nil, nil)
+
+	_, err := probeService.ProbeAudio(context.Background(), 3)
+	assert.ErrorIs(t, err, ErrAudioNotFound)
+}
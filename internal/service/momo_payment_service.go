@@ -1,44 +1,362 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/momo"
+	"mlvt/internal/infra/zap-logging/log"
 	"mlvt/internal/repo"
+	"time"
 
 	qrcode "github.com/skip2/go-qrcode"
 )
 
+var (
+	ErrPaymentsDisabled      = errors.New("MoMo payments are not configured")
+	ErrPaymentNotFound       = errors.New("payment not found")
+	ErrInvalidPaymentRequest = errors.New("invalid payment request")
+	ErrDuplicateOrder        = errors.New("the order already has a payment")
+	ErrPaymentNotRefundable  = errors.New("payment cannot be refunded")
+	ErrInvalidIPN            = errors.New("invalid MoMo notification")
+	ErrPaymentGateway        = errors.New("MoMo payment gateway error")
+)
+
+// MoMoPaymentService pays for orders through the MoMo gateway, keeps the payments in step with it
+// and has the orders delivered once paid. Every request to MoMo and every notification from it is
+// kept in the transaction log of its order.
 type MoMoPaymentService interface {
-	GeneratePaymentQRCode(orderID, amount string) ([]byte, error)
-	CheckPaymentStatus(orderID string) (bool, error)
-	RefundPayment(orderID, amount string) (string, error)
+	GeneratePaymentQRCode(ctx context.Context, userID uint64, orderID string) (*entity.MoMoPayment, []byte, error)
+	GetPayment(orderID string) (*entity.MoMoPayment, error)
+	CheckPaymentStatus(ctx context.Context, orderID string) (*entity.MoMoPayment, error)
+	RefundPayment(ctx context.Context, orderID string, amount int64) (*entity.MoMoPayment, error)
+	HandleIPN(ctx context.Context, ipn *momo.IPN) error
+	ListPaymentEvents(orderID string) ([]entity.TransactionLog, error)
 }
 
 type MoMopaymentService struct {
-	momoRepo repo.MoMoRepo
+	client             *momo.Client
+	paymentRepo        repo.MoMoPaymentRepository
+	transactionLogRepo repo.TransactionLogRepo
+	billing            BillingService
+	now                func() time.Time
+}
+
+// NewMoMoPaymentService creates the payment service; without a client every payment fails with ErrPaymentsDisabled
+func NewMoMoPaymentService(client *momo.Client, paymentRepo repo.MoMoPaymentRepository, transactionLogRepo repo.TransactionLogRepo, billing BillingService) MoMoPaymentService {
+	return &MoMopaymentService{client: client, paymentRepo: paymentRepo, transactionLogRepo: transactionLogRepo, billing: billing, now: time.Now}
+}
+
+// GeneratePaymentQRCode creates a MoMo payment for the user's pending order and returns it with a PNG
+// QR code the MoMo app scans to pay. An order can have one payment; if it fails, a new order is needed.
+func (p *MoMopaymentService) GeneratePaymentQRCode(ctx context.Context, userID uint64, orderID string) (*entity.MoMoPayment, []byte, error) {
+	if p.client == nil {
+		return nil, nil, ErrPaymentsDisabled
+	}
+	order, err := p.billing.GetOrder(orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if order.UserID != userID {
+		return nil, nil, ErrOrderNotFound
+	}
+	if order.Status != entity.OrderStatusPending {
+		return nil, nil, fmt.Errorf("%w: the order is %s", ErrOrderNotPayable, order.Status)
+	}
+	amount := order.Amount
+	if amount < momo.MinAmount || amount > momo.MaxAmount {
+		return nil, nil, fmt.Errorf("%w: MoMo takes payments of %d to %d VND", ErrInvalidPaymentRequest, momo.MinAmount, momo.MaxAmount)
+	}
+	existing, err := p.paymentRepo.GetPaymentByOrderID(orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if existing != nil {
+		return nil, nil, ErrDuplicateOrder
+	}
+
+	requestID, err := randomToken(12)
+	if err != nil {
+		return nil, nil, err
+	}
+	orderInfo := fmt.Sprintf("MLVT order %s (%s)", orderID, order.ProductID)
+	var exchange momo.Exchange
+	created, err := p.client.CreatePayment(momo.WithExchange(ctx, &exchange), momo.CreateRequest{
+		OrderID:   orderID,
+		RequestID: requestID,
+		Amount:    amount,
+		OrderInfo: orderInfo,
+	})
+	p.logEvent(orderID, entity.TransactionActionCreate, &exchange, err)
+	if err != nil {
+		return nil, nil, gatewayError(err)
+	}
+
+	payment := &entity.MoMoPayment{
+		UserID:    userID,
+		OrderID:   orderID,
+		RequestID: requestID,
+		Amount:    amount,
+		OrderInfo: orderInfo,
+		Status:    entity.PaymentStatusPending,
+		PayURL:    created.PayURL,
+	}
+	if err := p.paymentRepo.CreatePayment(payment); err != nil {
+		return nil, nil, err
+	}
+
+	// The QR code URL opens the payment in the MoMo app; the pay URL is a web page
+	content := created.QRCodeURL
+	if content == "" {
+		content = created.PayURL
+	}
+	png, err := qrcode.Encode(content, qrcode.Medium, 256)
+	if err != nil {
+		return nil, nil, err
+	}
+	return payment, png, nil
+}
+
+// GetPayment returns the stored payment of orderID
+func (p *MoMopaymentService) GetPayment(orderID string) (*entity.MoMoPayment, error) {
+	payment, err := p.paymentRepo.GetPaymentByOrderID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil {
+		return nil, ErrPaymentNotFound
+	}
+	return payment, nil
 }
 
-func NewMoMoPaymentService(momoRepo repo.MoMoRepo) MoMoPaymentService {
-	return &MoMopaymentService{momoRepo: momoRepo}
+// CheckPaymentStatus asks MoMo for the result of a pending payment and records it; completed
+// payments are returned as stored. A query MoMo rejects leaves the payment pending.
+func (p *MoMopaymentService) CheckPaymentStatus(ctx context.Context, orderID string) (*entity.MoMoPayment, error) {
+	if p.client == nil {
+		return nil, ErrPaymentsDisabled
+	}
+	payment, err := p.GetPayment(orderID)
+	if err != nil || payment.Status != entity.PaymentStatusPending {
+		return payment, err
+	}
+
+	requestID, err := randomToken(12)
+	if err != nil {
+		return nil, err
+	}
+	var exchange momo.Exchange
+	status, err := p.client.QueryPayment(momo.WithExchange(ctx, &exchange), orderID, requestID)
+	p.logEvent(orderID, entity.TransactionActionStatusCheck, &exchange, err)
+	if err != nil {
+		return nil, gatewayError(err)
+	}
+	if momo.Pending(status.ResultCode) {
+		return payment, nil
+	}
+	if !momo.Paid(status.ResultCode) && !momo.Failed(status.ResultCode) {
+		// MoMo could not answer the query; the payment stays pending until it can
+		return nil, gatewayError(&momo.Error{ResultCode: status.ResultCode, Message: status.Message})
+	}
+	if _, err := p.complete(payment, status.TransID, status.ResultCode, status.Message); err != nil {
+		return nil, err
+	}
+	return p.settle(orderID)
 }
 
-func (p *MoMopaymentService) GeneratePaymentQRCode(orderID, amount string) ([]byte, error) {
-	payURL, err := p.momoRepo.CreatePayment(orderID, amount)
+// RefundPayment returns amount VND of a paid payment to the user; zero refunds what is left. It fails
+// with ErrInsufficientCredits if the user has spent the minutes the amount paid for.
+func (p *MoMopaymentService) RefundPayment(ctx context.Context, orderID string, amount int64) (*entity.MoMoPayment, error) {
+	if p.client == nil {
+		return nil, ErrPaymentsDisabled
+	}
+	payment, err := p.GetPayment(orderID)
 	if err != nil {
 		return nil, err
 	}
+	refundable := payment.Refundable()
+	if amount == 0 {
+		amount = refundable
+	}
+	if refundable == 0 {
+		return nil, fmt.Errorf("%w: the payment is %s", ErrPaymentNotRefundable, payment.Status)
+	}
+	if amount < 0 || amount > refundable {
+		return nil, fmt.Errorf("%w: at most %d VND can be refunded", ErrInvalidPaymentRequest, refundable)
+	}
 
-	// Generate QR code from the payment URL
-	png, err := qrcode.Encode(payURL, qrcode.Medium, 256)
+	requestID, err := randomToken(12)
 	if err != nil {
 		return nil, err
 	}
+	// Each refund needs an order ID of its own
+	refundID := fmt.Sprintf("%s-R%d", orderID, p.now().UnixMilli())
 
-	return png, nil
+	// The minutes are taken back before the money goes back, so they cannot be spent in between.
+	// Minutes already spent cannot be taken back, so neither is the money that paid for them.
+	if err := p.billing.ReserveRefund(orderID, refundID, amount, amount == refundable); err != nil {
+		return nil, err
+	}
+	var exchange momo.Exchange
+	_, err = p.client.Refund(momo.WithExchange(ctx, &exchange), momo.RefundRequest{
+		OrderID:     refundID,
+		RequestID:   requestID,
+		Amount:      amount,
+		TransID:     payment.TransID,
+		Description: "Refund of MLVT order " + orderID,
+	})
+	p.logEvent(orderID, entity.TransactionActionRefund, &exchange, err)
+	if err != nil {
+		if cancelErr := p.billing.CancelRefund(refundID); cancelErr != nil {
+			log.Errorf("MoMo did not refund order %s, and the minutes taken back for it could not be returned: %v", orderID, cancelErr)
+		}
+		return nil, gatewayError(err)
+	}
+
+	recorded, err := p.paymentRepo.AddRefund(orderID, amount)
+	if err != nil {
+		return nil, err
+	}
+	if !recorded {
+		// MoMo refunded, so only a concurrent refund can have got here first
+		log.Errorf("MoMo refunded %d VND of order %s, but the refund could not be recorded", amount, orderID)
+	}
+	payment, err = p.GetPayment(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.billing.CompleteRefund(orderID, payment.Status == entity.PaymentStatusRefunded); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// HandleIPN records the result MoMo posts when a payment completes and delivers or closes its order.
+// Notifications are verified against our secret key, and MoMo may send one several times: only the
+// first result of a payment, from a notification or a status check, is recorded, and an order is
+// delivered once. MoMo retries notifications that fail, which completes an interrupted delivery.
+func (p *MoMopaymentService) HandleIPN(ctx context.Context, ipn *momo.IPN) error {
+	if p.client == nil {
+		return ErrPaymentsDisabled
+	}
+	err := p.handleIPN(ipn)
+
+	// Notifications are logged as received, including those that are turned away
+	var exchange *momo.Exchange
+	if payload, marshalErr := json.Marshal(ipn); marshalErr == nil {
+		exchange = &momo.Exchange{Request: momo.Redact(payload)}
+	}
+	p.logEvent(ipn.OrderID, entity.TransactionActionIPN, exchange, err)
+	return err
 }
 
-func (p *MoMopaymentService) CheckPaymentStatus(orderID string) (bool, error) {
-	return p.momoRepo.CheckPaymentStatus(orderID)
+func (p *MoMopaymentService) handleIPN(ipn *momo.IPN) error {
+	if err := p.client.VerifyIPN(ipn); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidIPN, err)
+	}
+	payment, err := p.GetPayment(ipn.OrderID)
+	if err != nil {
+		return err
+	}
+	if ipn.Amount != payment.Amount {
+		return fmt.Errorf("%w: amount %d does not match the payment of %d", ErrInvalidIPN, ipn.Amount, payment.Amount)
+	}
+	if !momo.Paid(ipn.ResultCode) && !momo.Failed(ipn.ResultCode) {
+		return nil
+	}
+
+	completed, err := p.complete(payment, ipn.TransID, ipn.ResultCode, ipn.Message)
+	if err != nil {
+		return err
+	}
+	if !completed {
+		log.Infof("Repeated MoMo notification for order %s", ipn.OrderID)
+	}
+	_, err = p.settle(ipn.OrderID)
+	return err
+}
+
+// ListPaymentEvents returns what was said to and heard from MoMo about the order, oldest first
+func (p *MoMopaymentService) ListPaymentEvents(orderID string) ([]entity.TransactionLog, error) {
+	events, err := p.transactionLogRepo.ListTransactionLogsByOrderID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, ErrPaymentNotFound
+	}
+	return events, nil
+}
+
+// logEvent appends a request to MoMo or a notification from it to the order's transaction log.
+// The log is a record of the payment, not part of it, so failing to write it only logs an error.
+func (p *MoMopaymentService) logEvent(orderID, action string, exchange *momo.Exchange, err error) {
+	status := entity.TransactionStatusSucceeded
+	switch {
+	case errors.Is(err, ErrInvalidIPN), errors.Is(err, ErrPaymentNotFound):
+		status = entity.TransactionStatusRejected
+	case err != nil:
+		status = entity.TransactionStatusFailed
+	}
+
+	details := struct {
+		*momo.Exchange
+		Error string `json:"error,omitempty"`
+	}{Exchange: exchange}
+	if err != nil {
+		details.Error = err.Error()
+	}
+	payload, _ := json.Marshal(details)
+
+	if err := p.transactionLogRepo.LogTransaction(&entity.TransactionLog{
+		OrderID:       orderID,
+		PaymentMethod: entity.PaymentMethodMoMo,
+		Action:        action,
+		Status:        status,
+		Details:       string(payload),
+	}); err != nil {
+		log.Errorf("Failed to log MoMo %s of order %s: %v", action, orderID, err)
+	}
+}
+
+// settle delivers the order of a paid payment or closes that of a failed one, and returns the payment.
+// Both are no-ops once done, so settling a payment again is safe.
+func (p *MoMopaymentService) settle(orderID string) (*entity.MoMoPayment, error) {
+	payment, err := p.GetPayment(orderID)
+	if err != nil {
+		return nil, err
+	}
+	switch payment.Status {
+	case entity.PaymentStatusPaid:
+		if _, err := p.billing.FulfillOrder(orderID); err != nil {
+			return nil, fmt.Errorf("failed to deliver order %s: %w", orderID, err)
+		}
+	case entity.PaymentStatusFailed:
+		if err := p.billing.FailOrder(orderID); err != nil {
+			return nil, fmt.Errorf("failed to close order %s: %w", orderID, err)
+		}
+	}
+	return payment, nil
+}
+
+// complete records the final result of a payment; it reports false if one was already recorded
+func (p *MoMopaymentService) complete(payment *entity.MoMoPayment, transID int64, resultCode int, message string) (bool, error) {
+	status := entity.PaymentStatusFailed
+	if momo.Paid(resultCode) {
+		status = entity.PaymentStatusPaid
+	}
+	completed, err := p.paymentRepo.CompletePayment(payment.OrderID, status, transID, resultCode, message, p.now())
+	if err != nil {
+		return false, err
+	}
+	if completed {
+		log.Infof("MoMo payment %s of %d VND is %s (result code %d)", payment.OrderID, payment.Amount, status, resultCode)
+	}
+	return completed, nil
 }
 
-func (p *MoMopaymentService) RefundPayment(orderID, amount string) (string, error) {
-	return p.momoRepo.RefundPayment(orderID, amount)
+// gatewayError wraps failures of MoMo requests in ErrPaymentGateway
+func gatewayError(err error) error {
+	return fmt.Errorf("%w: %v", ErrPaymentGateway, err)
 }
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/momo"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockMoMoPaymentService is a mock implementation of MoMoPaymentService
+type MockMoMoPaymentService struct {
+	mock.Mock
+}
+
+func (m *MockMoMoPaymentService) GeneratePaymentQRCode(ctx context.Context, userID uint64, orderID string) (*entity.MoMoPayment, []byte, error) {
+	args := m.Called(ctx, userID, orderID)
+	payment, _ := args.Get(0).(*entity.MoMoPayment)
+	png, _ := args.Get(1).([]byte)
+	return payment, png, args.Error(2)
+}
+
+func (m *MockMoMoPaymentService) GetPayment(orderID string) (*entity.MoMoPayment, error) {
+	args := m.Called(orderID)
+	payment, _ := args.Get(0).(*entity.MoMoPayment)
+	return payment, args.Error(1)
+}
+
+func (m *MockMoMoPaymentService) CheckPaymentStatus(ctx context.Context, orderID string) (*entity.MoMoPayment, error) {
+	args := m.Called(ctx, orderID)
+	payment, _ := args.Get(0).(*entity.MoMoPayment)
+	return payment, args.Error(1)
+}
+
+func (m *MockMoMoPaymentService) RefundPayment(ctx context.Context, orderID string, amount int64) (*entity.MoMoPayment, error) {
+	args := m.Called(ctx, orderID, amount)
+	payment, _ := args.Get(0).(*entity.MoMoPayment)
+	return payment, args.Error(1)
+}
+
+func (m *MockMoMoPaymentService) HandleIPN(ctx context.Context, ipn *momo.IPN) error {
+	args := m.Called(ctx, ipn)
+	return args.Error(0)
+}
+
+func (m *MockMoMoPaymentService) ListPaymentEvents(orderID string) ([]entity.TransactionLog, error) {
+	args := m.Called(orderID)
+	events, _ := args.Get(0).([]entity.TransactionLog)
+	return events, args.Error(1)
+}
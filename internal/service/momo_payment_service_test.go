@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/momo"
+	"mlvt/internal/infra/momo/momotest"
+	"mlvt/internal/repo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newTestMoMoPaymentService creates a service paying through a fake MoMo gateway
+func newTestMoMoPaymentService(t *testing.T) (*MoMopaymentService, *momotest.Server, *repo.MockMoMoPaymentRepository, *MockBillingService) {
+	server := momotest.NewServer()
+	t.Cleanup(server.Close)
+	client, err := momo.New(server.Config(), server.Client())
+	assert.NoError(t, err)
+
+	paymentRepo := new(repo.MockMoMoPaymentRepository)
+	transactionLogRepo := new(repo.MockTransactionLogRepo)
+	transactionLogRepo.On("LogTransaction", mock.Anything).Return(nil)
+	billing := new(MockBillingService)
+	service := NewMoMoPaymentService(client, paymentRepo, transactionLogRepo, billing).(*MoMopaymentService)
+	service.now = func() time.Time { return momoTestNow }
+	return service, server, paymentRepo, billing
+}
+
+// loggedEvents returns the transaction log entries the service has written so far
+func loggedEvents(service *MoMopaymentService) []entity.TransactionLog {
+	var events []entity.TransactionLog
+	for _, call := range service.transactionLogRepo.(*repo.MockTransactionLogRepo).Calls {
+		if call.Method == "LogTransaction" {
+			events = append(events, *call.Arguments.Get(0).(*entity.TransactionLog))
+		}
+	}
+	return events
+}
+
+var momoTestNow = time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+// createTestPayment pays user 7's order-1 of 50,000 VND and returns the payment as stored
+func createTestPayment(t *testing.T, service *MoMopaymentService, paymentRepo *repo.MockMoMoPaymentRepository, billing *MockBillingService) *entity.MoMoPayment {
+	var stored *entity.MoMoPayment
+	billing.On("GetOrder", "order-1").Return(&entity.Order{
+		OrderID: "order-1", UserID: 7, ProductID: "credits-60", Amount: 50000, Credits: 60, Status: entity.OrderStatusPending,
+	}, nil)
+	paymentRepo.On("GetPaymentByOrderID", "order-1").Return(nil, nil).Once()
+	paymentRepo.On("CreatePayment", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(*entity.MoMoPayment)
+	}).Return(nil).Once()
+
+	payment, png, err := service.GeneratePaymentQRCode(context.Background(), 7, "order-1")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, png)
+	assert.Same(t, stored, payment)
+	return payment
+}
+
+// withStatus returns a copy of the payment in another status
+func withStatus(payment *entity.MoMoPayment, status string) *entity.MoMoPayment {
+	copied := *payment
+	copied.Status = status
+	return &copied
+}
+
+func TestGeneratePaymentQRCode(t *testing.T) {
+	service, server, paymentRepo, billing := newTestMoMoPaymentService(t)
+	payment := createTestPayment(t, service, paymentRepo, billing)
+
+	assert.Equal(t, uint64(7), payment.UserID)
+	assert.Equal(t, int64(50000), payment.Amount)
+	assert.Equal(t, entity.PaymentStatusPending, payment.Status)
+	assert.Equal(t, server.URL+"/pay/order-1", payment.PayURL)
+	if sent := server.Payment("order-1"); assert.NotNil(t, sent) {
+		assert.Equal(t, int64(50000), sent.Amount)
+		assert.Equal(t, payment.RequestID, sent.RequestID)
+	}
+
+	// The request and MoMo's answer are logged without the signature
+	events := loggedEvents(service)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "order-1", events[0].OrderID)
+		assert.Equal(t, entity.PaymentMethodMoMo, events[0].PaymentMethod)
+		assert.Equal(t, entity.TransactionActionCreate, events[0].Action)
+		assert.Equal(t, entity.TransactionStatusSucceeded, events[0].Status)
+		assert.Contains(t, events[0].Details, `"request":{`)
+		assert.Contains(t, events[0].Details, `"payUrl":"`+server.URL+`/pay/order-1"`)
+		assert.Contains(t, events[0].Details, `"signature":"[REDACTED]"`)
+		assert.NotContains(t, events[0].Details, server.SecretKey)
+	}
+
+	// An order is paid once
+	paymentRepo.On("GetPaymentByOrderID", "order-1").Return(payment, nil)
+	_, _, err := service.GeneratePaymentQRCode(context.Background(), 7, "order-1")
+	assert.ErrorIs(t, err, ErrDuplicateOrder)
+}
+
+func TestGeneratePaymentQRCode_Rejected(t *testing.T) {
+	service, server, _, billing := newTestMoMoPaymentService(t)
+	billing.On("GetOrder", "order-1").Return(&entity.Order{OrderID: "order-1", UserID: 7, Amount: 50000, Status: entity.OrderStatusPending}, nil)
+	billing.On("GetOrder", "order-2").Return(&entity.Order{OrderID: "order-2", UserID: 7, Amount: 50000, Status: entity.OrderStatusPaid}, nil)
+	billing.On("GetOrder", "order-3").Return(&entity.Order{OrderID: "order-3", UserID: 7, Amount: 999, Status: entity.OrderStatusPending}, nil)
+	billing.On("GetOrder", "order-4").Return(nil, ErrOrderNotFound)
+
+	// Another user's order
+	_, _, err := service.GeneratePaymentQRCode(context.Background(), 8, "order-1")
+	assert.ErrorIs(t, err, ErrOrderNotFound)
+	_, _, err = service.GeneratePaymentQRCode(context.Background(), 7, "order-2")
+	assert.ErrorIs(t, err, ErrOrderNotPayable)
+	_, _, err = service.GeneratePaymentQRCode(context.Background(), 7, "order-3")
+	assert.ErrorIs(t, err, ErrInvalidPaymentRequest)
+	_, _, err = service.GeneratePaymentQRCode(context.Background(), 7, "order-4")
+	assert.ErrorIs(t, err, ErrOrderNotFound)
+	assert.Empty(t, server.Requests())
+
+	assert.Empty(t, loggedEvents(service))
+
+	disabled := NewMoMoPaymentService(nil, new(repo.MockMoMoPaymentRepository), new(repo.MockTransactionLogRepo), billing)
+	_, _, err = disabled.GeneratePaymentQRCode(context.Background(), 7, "order-1")
+	assert.ErrorIs(t, err, ErrPaymentsDisabled)
+}
+
+func TestHandleIPN_DeliversOrderOnce(t *testing.T) {
+	service, server, paymentRepo, billing := newTestMoMoPaymentService(t)
+	payment := createTestPayment(t, service, paymentRepo, billing)
+	paid := withStatus(payment, entity.PaymentStatusPaid)
+
+	ipn, err := server.Complete("order-1", momo.ResultSuccess)
+	assert.NoError(t, err)
+	paymentRepo.On("GetPaymentByOrderID", "order-1").Return(payment, nil).Once()
+	paymentRepo.On("CompletePayment", "order-1", entity.PaymentStatusPaid, ipn.TransID, momo.ResultSuccess, ipn.Message, momoTestNow).
+		Return(true, nil).Once()
+	paymentRepo.On("GetPaymentByOrderID", "order-1").Return(paid, nil)
+	billing.On("FulfillOrder", "order-1").Return(true, nil).Once()
+	assert.NoError(t, service.HandleIPN(context.Background(), ipn))
+
+	// MoMo retrying the notification is acknowledged without effect
+	paymentRepo.On("CompletePayment", "order-1", entity.PaymentStatusPaid, ipn.TransID, momo.ResultSuccess, ipn.Message, momoTestNow).
+		Return(false, nil).Once()
+	billing.On("FulfillOrder", "order-1").Return(false, nil).Once()
+	assert.NoError(t, service.HandleIPN(context.Background(), ipn))
+	paymentRepo.AssertExpectations(t)
+	billing.AssertExpectations(t)
+
+	// Both notifications are in the log
+	events := loggedEvents(service)[1:]
+	if assert.Len(t, events, 2) {
+		for _, event := range events {
+			assert.Equal(t, entity.TransactionActionIPN, event.Action)
+			assert.Equal(t, entity.TransactionStatusSucceeded, event.Status)
+			assert.Contains(t, event.Details, fmt.Sprintf(`"transId":%d`, ipn.TransID))
+			assert.NotContains(t, event.Details, ipn.Signature)
+		}
+	}
+}
+
+func TestHandleIPN_Rejects(t *testing.T) {
+	service, server, paymentRepo, billing := newTestMoMoPaymentService(t)
+	payment := createTestPayment(t, service, paymentRepo, billing)
+	paymentRepo.On("GetPaymentByOrderID", "order-1").Return(payment, nil)
+
+	forged, _ := server.Complete("order-1", momo.ResultSuccess)
+	forged.Signature = "00" + forged.Signature[2:]
+	assert.ErrorIs(t, service.HandleIPN(context.Background(), forged), ErrInvalidIPN)
+
+	// Correctly signed, but for less than the payment
+	underpaid, _ := server.Complete("order-1", momo.ResultSuccess)
+	underpaid.Amount = 1000
+	server.SignIPN(underpaid)
+	assert.ErrorIs(t, service.HandleIPN(context.Background(), underpaid), ErrInvalidIPN)
+
+	unknown, _ := server.Complete("order-1", momo.ResultSuccess)
+	unknown.OrderID = "order-2"
+	server.SignIPN(unknown)
+	paymentRepo.On("GetPaymentByOrderID", "order-2").Return(nil, nil)
+	assert.ErrorIs(t, service.HandleIPN(context.Background(), unknown), ErrPaymentNotFound)
+
+	paymentRepo.AssertNotCalled(t, "CompletePayment", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	billing.AssertNotCalled(t, "FulfillOrder", mock.Anything)
+
+	// Rejected notifications are logged too, under the order they claim
+	events := loggedEvents(service)[1:]
+	if assert.Len(t, events, 3) {
+		assert.Equal(t, []string{"order-1", "order-1", "order-2"}, []string{events[0].OrderID, events[1].OrderID, events[2].OrderID})
+		for _, event := range events {
+			assert.Equal(t, entity.TransactionActionIPN, event.Action)
+			assert.Equal(t, entity.TransactionStatusRejected, event.Status)
+			assert.Contains(t, event.Details, `"error":`)
+		}
+	}
+}
+
+func TestCheckPaymentStatus(t *testing.T) {
+	service, server, paymentRepo, billing := newTestMoMoPaymentService(t)
+	payment := createTestPayment(t, service, paymentRepo, billing)
+
+	// Still waiting for the user
+	paymentRepo.On("GetPaymentByOrderID", "order-1").Return(payment, nil).Once()
+	checked, err := service.CheckPaymentStatus(context.Background(), "order-1")
+	assert.NoError(t, err)
+	assert.Equal(t, entity.PaymentStatusPending, checked.Status)
+
+	// MoMo fails to answer the query
+	_, err = server.Complete("order-1", 99)
+	assert.NoError(t, err)
+	paymentRepo.On("GetPaymentByOrderID", "order-1").Return(payment, nil).Once()
+	_, err = service.CheckPaymentStatus(context.Background(), "order-1")
+	assert.ErrorIs(t, err, ErrPaymentGateway)
+	paymentRepo.AssertNotCalled(t, "CompletePayment", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	// Declined in the app, and the notification never arrived
+	_, err = server.Complete("order-1", 1006)
+	assert.NoError(t, err)
+	failed := withStatus(payment, entity.PaymentStatusFailed)
+	paymentRepo.On("GetPaymentByOrderID", "order-1").Return(payment, nil).Once()
+	paymentRepo.On("CompletePayment", "order-1", entity.PaymentStatusFailed, int64(0), 1006, mock.Anything, momoTestNow).Return(true, nil).Once()
+	paymentRepo.On("GetPaymentByOrderID", "order-1").Return(failed, nil).Once()
+	billing.On("FailOrder", "order-1").Return(nil).Once()
+	checked, err = service.CheckPaymentStatus(context.Background(), "order-1")
+	assert.NoError(t, err)
+	assert.Equal(t, entity.PaymentStatusFailed, checked.Status)
+
+	// Completed payments are not queried again
+	requests := len(server.Requests())
+	paymentRepo.On("GetPaymentByOrderID", "order-1").Return(failed, nil).Once()
+	_, err = service.CheckPaymentStatus(context.Background(), "order-1")
+	assert.NoError(t, err)
+	assert.Len(t, server.Requests(), requests)
+	paymentRepo.AssertExpectations(t)
+	billing.AssertExpectations(t)
+
+	// Only the three queries sent to MoMo are logged
+	events := loggedEvents(service)[1:]
+	if assert.Len(t, events, 3) {
+		assert.Equal(t, entity.TransactionActionStatusCheck, events[2].Action)
+		assert.Contains(t, events[1].Details, `"resultCode":99`)
+		assert.Contains(t, events[2].Details, `"resultCode":1006`)
+	}
+}
+
+func TestRefundPayment(t *testing.T) {
+	service, server, paymentRepo, billing := newTestMoMoPaymentService(t)
+	payment := createTestPayment(t, service, paymentRepo, billing)
+
+	paymentRepo.On("GetPaymentByOrderID", "order-1").Return(payment, nil).Once()
+	_, err := service.RefundPayment(context.Background(), "order-1", 0)
+	assert.ErrorIs(t, err, ErrPaymentNotRefundable)
+
+	ipn, _ := server.Complete("order-1", momo.ResultSuccess)
+	paid := withStatus(payment, entity.PaymentStatusPaid)
+	paid.TransID = ipn.TransID
+
+	paymentRepo.On("GetPaymentByOrderID", "order-1").Return(paid, nil).Once()
+	_, err = service.RefundPayment(context.Background(), "order-1", 60000)
+	assert.ErrorIs(t, err, ErrInvalidPaymentRequest)
+
+	// Minutes already spent are not refunded
+	paymentRepo.On("GetPaymentByOrderID", "order-1").Return(paid, nil).Once()
+	refundID := fmt.Sprintf("order-1-R%d", momoTestNow.UnixMilli())
+	billing.On("ReserveRefund", "order-1", refundID, int64(20000), false).Return(ErrInsufficientCredits).Once()
+	_, err = service.RefundPayment(context.Background(), "order-1", 20000)
+	assert.ErrorIs(t, err, ErrInsufficientCredits)
+	assert.Zero(t, server.Payment("order-1").Refunded)
+
+	// The minutes taken back are returned when MoMo turns the refund down
+	unknown := withStatus(paid, entity.PaymentStatusPaid)
+	unknown.TransID = paid.TransID + 1
+	paymentRepo.On("GetPaymentByOrderID", "order-1").Return(unknown, nil).Once()
+	billing.On("ReserveRefund", "order-1", refundID, int64(20000), false).Return(nil).Once()
+	billing.On("CancelRefund", refundID).Return(nil).Once()
+	_, err = service.RefundPayment(context.Background(), "order-1", 20000)
+	assert.ErrorIs(t, err, ErrPaymentGateway)
+	assert.Zero(t, server.Payment("order-1").Refunded)
+
+	// A full refund takes back all of the order's minutes
+	refunded := withStatus(paid, entity.PaymentStatusRefunded)
+	refunded.RefundedAmount = 50000
+	paymentRepo.On("GetPaymentByOrderID", "order-1").Return(paid, nil).Once()
+	billing.On("ReserveRefund", "order-1", refundID, int64(50000), true).Return(nil).Once()
+	paymentRepo.On("AddRefund", "order-1", int64(50000)).Return(true, nil).Once()
+	paymentRepo.On("GetPaymentByOrderID", "order-1").Return(refunded, nil).Once()
+	billing.On("CompleteRefund", "order-1", true).Return(nil).Once()
+	_, err = service.RefundPayment(context.Background(), "order-1", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(50000), server.Payment("order-1").Refunded)
+	paymentRepo.AssertExpectations(t)
+	billing.AssertExpectations(t)
+
+	// The refund is logged under the order it refunds
+	events := loggedEvents(service)
+	refund := events[len(events)-1]
+	assert.Equal(t, "order-1", refund.OrderID)
+	assert.Equal(t, entity.TransactionActionRefund, refund.Action)
+	assert.Equal(t, entity.TransactionStatusSucceeded, refund.Status)
+	assert.Contains(t, refund.Details, fmt.Sprintf(`"orderId":"order-1-R%d"`, momoTestNow.UnixMilli()))
+}
+
+func TestPaymentEvents_LogFailureDoesNotFailPayment(t *testing.T) {
+	service, _, paymentRepo, billing := newTestMoMoPaymentService(t)
+	failingLog := new(repo.MockTransactionLogRepo)
+	failingLog.On("LogTransaction", mock.Anything).Return(errors.New("disk full"))
+	service.transactionLogRepo = failingLog
+
+	billing.On("GetOrder", "order-1").Return(&entity.Order{OrderID: "order-1", UserID: 7, Amount: 50000, Status: entity.OrderStatusPending}, nil)
+	paymentRepo.On("GetPaymentByOrderID", "order-1").Return(nil, nil).Once()
+	paymentRepo.On("CreatePayment", mock.Anything).Return(nil).Once()
+	_, _, err := service.GeneratePaymentQRCode(context.Background(), 7, "order-1")
+	assert.NoError(t, err)
+	failingLog.AssertNumberOfCalls(t, "LogTransaction", 1)
+}
+
+func TestListPaymentEvents(t *testing.T) {
+	service, _, _, _ := newTestMoMoPaymentService(t)
+	transactionLogRepo := service.transactionLogRepo.(*repo.MockTransactionLogRepo)
+	transactionLogRepo.On("ListTransactionLogsByOrderID", "order-1").Return([]entity.TransactionLog{
+		{ID: 1, OrderID: "order-1", Action: entity.TransactionActionCreate},
+		{ID: 2, OrderID: "order-1", Action: entity.TransactionActionIPN},
+	}, nil)
+	transactionLogRepo.On("ListTransactionLogsByOrderID", "order-2").Return(nil, nil)
+
+	events, err := service.ListPaymentEvents("order-1")
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	_, err = service.ListPaymentEvents("order-2")
+	assert.ErrorIs(t, err, ErrPaymentNotFound)
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/oidc"
+	"mlvt/internal/infra/zap-logging/log"
+	"mlvt/internal/repo"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrUnknownIdentityProvider = errors.New("unknown identity provider")
+	ErrInvalidOIDCState        = errors.New("invalid or expired sign-in state")
+	ErrOIDCLoginFailed         = errors.New("sign-in with the identity provider failed")
+	ErrUnverifiedOIDCEmail     = errors.New("the identity provider has not verified the account's email address")
+)
+
+// DefaultOIDCStateTTL is how long a sign-in started at an identity provider can be completed
+const DefaultOIDCStateTTL = 10 * time.Minute
+
+// OIDCConfig controls sign-ins with external identity providers
+type OIDCConfig struct {
+	StateTTL time.Duration // How long the user has to sign in at the provider
+}
+
+// withDefaults fills zero fields with the package defaults
+func (c OIDCConfig) withDefaults() OIDCConfig {
+	if c.StateTTL <= 0 {
+		c.StateTTL = DefaultOIDCStateTTL
+	}
+	return c
+}
+
+// OIDCService signs users in with external identity providers using the authorization code flow with PKCE.
+// Each provider account is linked to a user the first time it is used: to the user with the same,
+// provider-verified email address, or to a new user.
+type OIDCService interface {
+	Providers() []string
+	AuthorizationURL(ctx context.Context, provider string) (string, error)
+	Login(ctx context.Context, provider, code, state string) (*entity.AuthTokens, error)
+}
+
+type oidcService struct {
+	providers oidc.Providers
+	userRepo  repo.UserRepository
+	oidcRepo  repo.OIDCRepository
+	auth      AuthServiceInterface
+	config    OIDCConfig
+	now       func() time.Time
+}
+
+func NewOIDCService(providers oidc.Providers, userRepo repo.UserRepository, oidcRepo repo.OIDCRepository,
+	auth AuthServiceInterface, config OIDCConfig) OIDCService {
+	return &oidcService{
+		providers: providers,
+		userRepo:  userRepo,
+		oidcRepo:  oidcRepo,
+		auth:      auth,
+		config:    config.withDefaults(),
+		now:       time.Now,
+	}
+}
+
+// Providers returns the names of the configured identity providers in alphabetical order
+func (s *oidcService) Providers() []string {
+	names := s.providers.Names()
+	sort.Strings(names)
+	return names
+}
+
+// AuthorizationURL starts a sign-in and returns the provider page to send the user to. The provider
+// redirects back with a code and the state included in the URL, which are passed to Login.
+func (s *oidcService) AuthorizationURL(ctx context.Context, provider string) (string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", ErrUnknownIdentityProvider
+	}
+
+	state, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := p.AuthCodeURL(ctx, state, oidc.CodeChallenge(verifier), nonce)
+	if err != nil {
+		log.Errorf("Failed to build the authorization URL of identity provider %s: %v", provider, err)
+		return "", ErrOIDCLoginFailed
+	}
+	err = s.oidcRepo.CreateLoginState(&entity.OIDCLoginState{
+		StateHash:    hashToken(state),
+		Provider:     provider,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    s.now().Add(s.config.StateTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return authURL, nil
+}
+
+// Login completes a sign-in started by AuthorizationURL and starts a session for the linked user.
+// Like a password login, it only returns a challenge token for users with two-factor authentication.
+func (s *oidcService) Login(ctx context.Context, provider, code, state string) (*entity.AuthTokens, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrUnknownIdentityProvider
+	}
+
+	loginState, err := s.oidcRepo.ConsumeLoginState(provider, hashToken(state))
+	if err != nil {
+		return nil, err
+	}
+	if loginState == nil {
+		return nil, ErrInvalidOIDCState
+	}
+
+	identity, err := p.Exchange(ctx, code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		log.Warnf("Sign-in with identity provider %s failed: %v", provider, err)
+		return nil, ErrOIDCLoginFailed
+	}
+
+	user, err := s.linkedUser(identity)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status == entity.UserStatusSuspended || user.Status == entity.UserStatusDeleted {
+		return nil, ErrAccountDisabled
+	}
+	return s.auth.IssueLoginTokens(user)
+}
+
+// linkedUser returns the user an identity is linked to, linking it first if it is new
+func (s *oidcService) linkedUser(identity *oidc.Identity) (*entity.User, error) {
+	linked, err := s.oidcRepo.GetIdentity(identity.Provider, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if linked != nil {
+		if err := s.oidcRepo.TouchIdentity(linked.ID); err != nil {
+			log.Warnf("Failed to record sign-in with identity %d: %v", linked.ID, err)
+		}
+		user, err := s.userRepo.GetUserByID(linked.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, ErrUserNotFound
+		}
+		return user, nil
+	}
+
+	// Matching by an address the provider has not verified would let anyone claim another user's account
+	email := normalizeEmail(identity.Email)
+	if email == "" || !identity.EmailVerified {
+		return nil, ErrUnverifiedOIDCEmail
+	}
+	user, err := s.userRepo.GetUserByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		user, err = s.createUser(identity, email)
+	} else {
+		err = s.claimUser(user)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.oidcRepo.CreateIdentity(&entity.UserIdentity{
+		UserID:   user.ID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    email,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Linked %s account %s to user %d", identity.Provider, identity.Subject, user.ID)
+	return user, nil
+}
+
+// createUser registers a user for a new identity. The user has no usable password until they reset it.
+func (s *oidcService) createUser(identity *oidc.Identity, email string) (*entity.User, error) {
+	password, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	suffix, err := randomToken(4)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	user := &entity.User{
+		FirstName: identity.FirstName,
+		LastName:  identity.LastName,
+		UserName:  usernameFromEmail(email) + "-" + suffix,
+		Email:     email,
+		Password:  string(hashedPassword),
+		Status:    entity.UserStatusAvailable,
+		Role:      entity.RoleUser,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.userRepo.CreateUser(user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %v", err)
+	}
+	if err := s.userRepo.MarkEmailVerified(user.ID); err != nil {
+		return nil, err
+	}
+	user.EmailVerifiedAt = &now
+	return user, nil
+}
+
+// claimUser prepares an existing user for linking. Until verified, the address may have been
+// registered by someone other than its owner, so their password and sessions stop working.
+func (s *oidcService) claimUser(user *entity.User) error {
+	if user.EmailVerified() {
+		return nil
+	}
+
+	password, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdateUserPassword(user.ID, string(hashedPassword)); err != nil {
+		return err
+	}
+	if err := s.auth.RevokeSessions(user.ID); err != nil {
+		return err
+	}
+	if err := s.userRepo.MarkEmailVerified(user.ID); err != nil {
+		return err
+	}
+
+	now := s.now()
+	user.EmailVerifiedAt = &now
+	user.TokenVersion++
+	return nil
+}
+
+// usernameFromEmail derives a username from the local part of an email address
+func usernameFromEmail(email string) string {
+	local, _, _ := strings.Cut(strings.ToLower(email), "@")
+	username := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '_' || r == '-' {
+			return r
+		}
+		return -1
+	}, local)
+	if username == "" {
+		return "user"
+	}
+	return username
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"mlvt/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockOIDCService is a mock implementation of the OIDCService interface
+type MockOIDCService struct {
+	mock.Mock
+}
+
+func (m *MockOIDCService) Providers() []string {
+	args := m.Called()
+	providers, _ := args.Get(0).([]string)
+	return providers
+}
+
+func (m *MockOIDCService) AuthorizationURL(ctx context.Context, provider string) (string, error) {
+	args := m.Called(ctx, provider)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockOIDCService) Login(ctx context.Context, provider, code, state string) (*entity.AuthTokens, error) {
+	args := m.Called(ctx, provider, code, state)
+	tokens, _ := args.Get(0).(*entity.AuthTokens)
+	return tokens, args.Error(1)
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/oidc"
+	"mlvt/internal/infra/oidc/oidctest"
+	"mlvt/internal/repo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type oidcServiceMocks struct {
+	server   *oidctest.Server
+	userRepo *repo.MockUserRepository
+	oidcRepo *repo.MockOIDCRepository
+	auth     *MockAuthService
+}
+
+// newTestOIDCService creates a service with a "corp" provider backed by a mock OIDC server
+func newTestOIDCService(t *testing.T) (OIDCService, oidcServiceMocks) {
+	server := oidctest.NewServer("mlvt")
+	t.Cleanup(server.Close)
+
+	providers, err := oidc.New([]oidc.Config{{
+		Name:        "corp",
+		Issuer:      server.Issuer(),
+		ClientID:    server.ClientID,
+		RedirectURL: "http://localhost:3000/auth/corp/callback",
+	}}, server.Client())
+	assert.NoError(t, err)
+
+	mocks := oidcServiceMocks{
+		server:   server,
+		userRepo: new(repo.MockUserRepository),
+		oidcRepo: new(repo.MockOIDCRepository),
+		auth:     new(MockAuthService),
+	}
+	service := NewOIDCService(providers, mocks.userRepo, mocks.oidcRepo, mocks.auth, OIDCConfig{})
+	return service, mocks
+}
+
+// signIn starts a sign-in, lets the mock server authorize it and returns the code and state of the redirect
+func signIn(t *testing.T, oidcService OIDCService, mocks oidcServiceMocks) (string, string) {
+	var stored *entity.OIDCLoginState
+	mocks.oidcRepo.On("CreateLoginState", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(*entity.OIDCLoginState)
+	}).Return(nil).Once()
+
+	authURL, err := oidcService.AuthorizationURL(context.Background(), "corp")
+	assert.NoError(t, err)
+	code, state, err := mocks.server.Authorize(authURL)
+	assert.NoError(t, err)
+
+	if assert.NotNil(t, stored) {
+		assert.Equal(t, hashToken(state), stored.StateHash)
+		mocks.oidcRepo.On("ConsumeLoginState", "corp", stored.StateHash).Return(stored, nil).Once()
+	}
+	return code, state
+}
+
+func TestOIDCLoginLinkedIdentity(t *testing.T) {
+	oidcService, mocks := newTestOIDCService(t)
+	code, state := signIn(t, oidcService, mocks)
+
+	user := &entity.User{ID: 4, Email: "jane@example.com", Status: entity.UserStatusAvailable}
+	tokens := &entity.AuthTokens{UserID: 4, AccessToken: "access"}
+	mocks.oidcRepo.On("GetIdentity", "corp", "1234567890").Return(&entity.UserIdentity{ID: 9, UserID: 4}, nil)
+	mocks.oidcRepo.On("TouchIdentity", uint64(9)).Return(nil)
+	mocks.userRepo.On("GetUserByID", uint64(4)).Return(user, nil)
+	mocks.auth.On("IssueLoginTokens", user).Return(tokens, nil)
+
+	result, err := oidcService.Login(context.Background(), "corp", code, state)
+	assert.NoError(t, err)
+	assert.Equal(t, tokens, result)
+	mocks.userRepo.AssertNotCalled(t, "GetUserByEmail", mock.Anything)
+}
+
+func TestOIDCLoginCreatesUser(t *testing.T) {
+	oidcService, mocks := newTestOIDCService(t)
+	code, state := signIn(t, oidcService, mocks)
+
+	mocks.oidcRepo.On("GetIdentity", "corp", "1234567890").Return(nil, nil)
+	mocks.userRepo.On("GetUserByEmail", "jane@example.com").Return(nil, nil)
+	var created *entity.User
+	mocks.userRepo.On("CreateUser", mock.Anything).Run(func(args mock.Arguments) {
+		created = args.Get(0).(*entity.User)
+		created.ID = 12
+	}).Return(nil)
+	mocks.userRepo.On("MarkEmailVerified", uint64(12)).Return(nil)
+	mocks.oidcRepo.On("CreateIdentity", mock.MatchedBy(func(identity *entity.UserIdentity) bool {
+		return identity.UserID == 12 && identity.Provider == "corp" && identity.Subject == "1234567890"
+	})).Return(nil)
+	mocks.auth.On("IssueLoginTokens", mock.Anything).Return(&entity.AuthTokens{UserID: 12}, nil)
+
+	result, err := oidcService.Login(context.Background(), "corp", code, state)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(12), result.UserID)
+	if assert.NotNil(t, created) {
+		assert.Equal(t, "Jane", created.FirstName)
+		assert.Equal(t, "Doe", created.LastName)
+		assert.Equal(t, entity.RoleUser, created.Role)
+		assert.Regexp(t, `^jane-`, created.UserName)
+		assert.True(t, created.EmailVerified())
+	}
+}
+
+func TestOIDCLoginLinksUserByEmail(t *testing.T) {
+	oidcService, mocks := newTestOIDCService(t)
+	code, state := signIn(t, oidcService, mocks)
+
+	verifiedAt := time.Now()
+	user := &entity.User{ID: 4, Email: "jane@example.com", Status: entity.UserStatusAvailable, Password: "hash",
+		EmailVerifiedAt: &verifiedAt}
+	mocks.oidcRepo.On("GetIdentity", "corp", "1234567890").Return(nil, nil)
+	mocks.userRepo.On("GetUserByEmail", "jane@example.com").Return(user, nil)
+	mocks.oidcRepo.On("CreateIdentity", mock.Anything).Return(nil)
+	mocks.auth.On("IssueLoginTokens", user).Return(&entity.AuthTokens{UserID: 4}, nil)
+
+	_, err := oidcService.Login(context.Background(), "corp", code, state)
+	assert.NoError(t, err)
+	// Verified accounts keep their password
+	mocks.userRepo.AssertNotCalled(t, "UpdateUserPassword", mock.Anything, mock.Anything)
+	mocks.auth.AssertNotCalled(t, "RevokeSessions", mock.Anything)
+}
+
+func TestOIDCLoginClaimsUnverifiedUser(t *testing.T) {
+	oidcService, mocks := newTestOIDCService(t)
+	code, state := signIn(t, oidcService, mocks)
+
+	user := &entity.User{ID: 4, Email: "jane@example.com", Status: entity.UserStatusAvailable, Password: "hash"}
+	mocks.oidcRepo.On("GetIdentity", "corp", "1234567890").Return(nil, nil)
+	mocks.userRepo.On("GetUserByEmail", "jane@example.com").Return(user, nil)
+	mocks.userRepo.On("UpdateUserPassword", uint64(4), mock.Anything).Return(nil)
+	mocks.auth.On("RevokeSessions", uint64(4)).Return(nil)
+	mocks.userRepo.On("MarkEmailVerified", uint64(4)).Return(nil)
+	mocks.oidcRepo.On("CreateIdentity", mock.Anything).Return(nil)
+	mocks.auth.On("IssueLoginTokens", user).Return(&entity.AuthTokens{UserID: 4}, nil)
+
+	_, err := oidcService.Login(context.Background(), "corp", code, state)
+	assert.NoError(t, err)
+	mocks.userRepo.AssertCalled(t, "UpdateUserPassword", uint64(4), mock.Anything)
+	mocks.auth.AssertCalled(t, "RevokeSessions", uint64(4))
+	assert.True(t, user.EmailVerified())
+}
+
+func TestOIDCLoginRejectsUnverifiedEmail(t *testing.T) {
+	oidcService, mocks := newTestOIDCService(t)
+	mocks.server.User.EmailVerified = false
+	code, state := signIn(t, oidcService, mocks)
+
+	mocks.oidcRepo.On("GetIdentity", "corp", "1234567890").Return(nil, nil)
+
+	_, err := oidcService.Login(context.Background(), "corp", code, state)
+	assert.ErrorIs(t, err, ErrUnverifiedOIDCEmail)
+	mocks.userRepo.AssertNotCalled(t, "GetUserByEmail", mock.Anything)
+}
+
+func TestOIDCLoginRejectsSuspendedUser(t *testing.T) {
+	oidcService, mocks := newTestOIDCService(t)
+	code, state := signIn(t, oidcService, mocks)
+
+	mocks.oidcRepo.On("GetIdentity", "corp", "1234567890").Return(&entity.UserIdentity{ID: 9, UserID: 4}, nil)
+	mocks.oidcRepo.On("TouchIdentity", uint64(9)).Return(nil)
+	mocks.userRepo.On("GetUserByID", uint64(4)).Return(&entity.User{ID: 4, Status: entity.UserStatusSuspended}, nil)
+
+	_, err := oidcService.Login(context.Background(), "corp", code, state)
+	assert.ErrorIs(t, err, ErrAccountDisabled)
+	mocks.auth.AssertNotCalled(t, "IssueLoginTokens", mock.Anything)
+}
+
+func TestOIDCLoginRejectsUnknownState(t *testing.T) {
+	oidcService, mocks := newTestOIDCService(t)
+	mocks.oidcRepo.On("ConsumeLoginState", "corp", hashToken("forged")).Return(nil, nil)
+
+	_, err := oidcService.Login(context.Background(), "corp", "code", "forged")
+	assert.ErrorIs(t, err, ErrInvalidOIDCState)
+
+	_, err = oidcService.Login(context.Background(), "other", "code", "forged")
+	assert.ErrorIs(t, err, ErrUnknownIdentityProvider)
+	_, err = oidcService.AuthorizationURL(context.Background(), "other")
+	assert.ErrorIs(t, err, ErrUnknownIdentityProvider)
+}
+
+func TestOIDCLoginRejectsReusedCode(t *testing.T) {
+	oidcService, mocks := newTestOIDCService(t)
+	code, _ := signIn(t, oidcService, mocks)
+
+	// A second sign-in with the first one's code fails at the provider, as it was issued for another verifier
+	_, state := signIn(t, oidcService, mocks)
+	_, err := oidcService.Login(context.Background(), "corp", code, state)
+	assert.ErrorIs(t, err, ErrOIDCLoginFailed)
+}
+
+func TestUsernameFromEmail(t *testing.T) {
+	assert.Equal(t, "jane.doe", usernameFromEmail("Jane.Doe@example.com"))
+	assert.Equal(t, "jd", usernameFromEmail("j+d@example.com"))
+	assert.Equal(t, "user", usernameFromEmail("@example.com"))
+}
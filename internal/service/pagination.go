@@ -0,0 +1,7 @@
+package service
+
+import "mlvt/internal/repo"
+
+// ErrInvalidListOptions is returned by list methods for an unknown sort field or order, a malformed cursor,
+// or a filter the list does not support
+var ErrInvalidListOptions = repo.ErrInvalidListOptions
@@ -8,13 +8,91 @@ import (
 
 var SecretKey = env.EnvConfig.JWTSecret
 
+// AuthSettings sets the lifetime of access and refresh tokens and the sign-in lockout; zero values fall back to the defaults
+var AuthSettings = AuthConfig{
+	AccessTokenTTL:     env.EnvConfig.AccessTokenTTL,
+	RefreshTokenTTL:    env.EnvConfig.RefreshTokenTTL,
+	MaxLoginFailures:   env.EnvConfig.LoginMaxFailures,
+	MaxIPLoginFailures: env.EnvConfig.LoginMaxIPFailures,
+	LoginLockout:       env.EnvConfig.LoginLockoutDuration,
+	TwoFactorTTL:       env.EnvConfig.TwoFactorTTL,
+}
+
+// TwoFactorSettings controls how accounts appear in authenticator apps; zero values fall back to the defaults
+var TwoFactorSettings = TwoFactorConfig{
+	Issuer: env.EnvConfig.TOTPIssuer,
+}
+
+// OIDCSettings controls sign-ins with external identity providers; zero values fall back to the defaults
+var OIDCSettings = OIDCConfig{
+	StateTTL: env.EnvConfig.OIDCStateTTL,
+}
+
+// APIKeySettings limits the API keys users can create; zero values fall back to the defaults
+var APIKeySettings = APIKeyConfig{
+	DefaultTTL: env.EnvConfig.APIKeyDefaultTTL,
+	MaxTTL:     env.EnvConfig.APIKeyMaxTTL,
+	MaxKeys:    env.EnvConfig.APIKeyMaxPerUser,
+}
+
+// AccountSettings controls the emailed password reset and verification links; zero values fall back to the defaults
+var AccountSettings = AccountConfig{
+	AppURL:               env.EnvConfig.AppURL,
+	PasswordResetTTL:     env.EnvConfig.PasswordResetTTL,
+	EmailVerificationTTL: env.EnvConfig.EmailVerificationTTL,
+}
+
+// UploadSettings limits direct video uploads; zero values fall back to the defaults
+var UploadSettings = UploadConfig{
+	MaxSize:    env.EnvConfig.UploadMaxSize,
+	PendingTTL: env.EnvConfig.UploadPendingTTL,
+}
+
+// StorageCleanupSettings controls the removal of deleted records' objects and the folders scanned for orphans
+var StorageCleanupSettings = StorageCleanupConfig{
+	MaxAttempts: env.EnvConfig.StorageDeleteAttempts,
+	Folders: []string{
+		env.EnvConfig.VideosFolder,
+		env.EnvConfig.AudioFolder,
+		env.EnvConfig.TranscriptionsFolder,
+		env.EnvConfig.VideoFramesFolder,
+		env.EnvConfig.AvatarFolder,
+	},
+}
+
+// MediaProbeSettings bounds the download and probe of uploaded video and audio files
+var MediaProbeSettings = MediaProbeConfig{
+	Timeout: env.EnvConfig.MediaProbeTimeout,
+}
+
 // ProviderSetService is providers.
 var ProviderSetService = wire.NewSet(
 	NewAuthService,
+	wire.Bind(new(AuthServiceInterface), new(*AuthService)),
 	NewUserService,
+	NewAccountService,
+	NewTwoFactorService,
+	NewOIDCService,
+	NewAPIKeyService,
 	NewVideoService,
 	NewAudioService,
 	NewTranscriptionService,
 	NewMoMoPaymentService,
+	NewBillingService,
+	NewAdminService,
+	NewTranslationService,
+	NewSearchService,
+	NewUploadService,
+	NewFrameService,
+	NewMediaProbeService,
+	NewStorageCleanupService,
 	wire.Value(SecretKey),
+	wire.Value(AuthSettings),
+	wire.Value(AccountSettings),
+	wire.Value(TwoFactorSettings),
+	wire.Value(OIDCSettings),
+	wire.Value(APIKeySettings),
+	wire.Value(UploadSettings),
+	wire.Value(MediaProbeSettings),
+	wire.Value(StorageCleanupSettings),
 )
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"html"
+	"mlvt/internal/entity"
+	"mlvt/internal/repo"
+	"strings"
+)
+
+// MaxSearchQueryLength caps the length of a search query in bytes
+const MaxSearchQueryLength = 256
+
+var ErrInvalidSearchQuery = errors.New("search query must contain at least one word")
+
+type SearchService interface {
+	// Search finds the user's videos and transcriptions containing every word of the query.
+	// A non-empty lang restricts the results to transcriptions in that language.
+	Search(userID uint64, query, lang string, limit, offset int) ([]entity.SearchResult, error)
+}
+
+type searchService struct {
+	repo repo.SearchRepository
+}
+
+func NewSearchService(repo repo.SearchRepository) SearchService {
+	return &searchService{repo: repo}
+}
+
+func (s *searchService) Search(userID uint64, query, lang string, limit, offset int) ([]entity.SearchResult, error) {
+	match := matchExpression(query)
+	if match == "" {
+		return nil, ErrInvalidSearchQuery
+	}
+	if limit <= 0 {
+		limit = repo.DefaultPageLimit
+	}
+	if limit > repo.MaxPageLimit {
+		limit = repo.MaxPageLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	results, err := s.repo.Search(userID, match, strings.TrimSpace(lang), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Snippet = highlightSnippet(results[i].Snippet)
+	}
+	return results, nil
+}
+
+// matchExpression turns free text into an FTS5 expression matching rows that contain every word.
+// Each word is quoted so FTS5 operators in user input are taken literally; the last word also
+// matches as a prefix so partially typed words find results.
+func matchExpression(query string) string {
+	if len(query) > MaxSearchQueryLength {
+		query = strings.ToValidUTF8(query[:MaxSearchQueryLength], "")
+	}
+
+	var terms []string
+	for _, word := range strings.Fields(query) {
+		word = strings.ReplaceAll(word, `"`, "")
+		if word != "" {
+			terms = append(terms, `"`+word+`"`)
+		}
+	}
+	if len(terms) == 0 {
+		return ""
+	}
+	terms[len(terms)-1] += "*"
+	return strings.Join(terms, " ")
+}
+
+// highlightSnippet HTML-escapes a snippet and wraps its matched terms in <mark> tags
+func highlightSnippet(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	snippet = strings.ReplaceAll(snippet, repo.SnippetMatchStart, "<mark>")
+	return strings.ReplaceAll(snippet, repo.SnippetMatchEnd, "</mark>")
+}
@@ -0,0 +1,18 @@
+package service
+
+import (
+	"mlvt/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSearchService is a mock implementation of the SearchService interface
+type MockSearchService struct {
+	mock.Mock
+}
+
+func (m *MockSearchService) Search(userID uint64, query, lang string, limit, offset int) ([]entity.SearchResult, error) {
+	args := m.Called(userID, query, lang, limit, offset)
+	results, _ := args.Get(0).([]entity.SearchResult)
+	return results, args.Error(1)
+}
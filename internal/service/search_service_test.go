@@ -0,0 +1,36 @@
+package service
+
+import (
+	"mlvt/internal/entity"
+	"mlvt/internal/repo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchExpression(t *testing.T) {
+	assert.Equal(t, `"pasta"*`, matchExpression("pasta"))
+	assert.Equal(t, `"cooked" "pasta"*`, matchExpression("  cooked   pasta "))
+	// FTS5 syntax in user input is taken literally
+	assert.Equal(t, `"NOT" "title:x" "(a"*`, matchExpression(`NOT title:x "(a"`))
+	assert.Equal(t, "", matchExpression(` "" `))
+}
+
+func TestSearch(t *testing.T) {
+	searchRepo := new(repo.MockSearchRepository)
+	searchService := NewSearchService(searchRepo)
+
+	searchRepo.On("Search", uint64(1), `"lake"*`, "en", repo.MaxPageLimit, 0).Return([]entity.SearchResult{
+		{Kind: entity.SearchKindTranscription, ID: 4, Snippet: "<b>by</b> the " + repo.SnippetMatchStart + "lake" + repo.SnippetMatchEnd},
+	}, nil)
+
+	results, err := searchService.Search(1, "lake", " en ", 500, -3)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, "&lt;b&gt;by&lt;/b&gt; the <mark>lake</mark>", results[0].Snippet)
+	}
+	searchRepo.AssertExpectations(t)
+
+	_, err = searchService.Search(1, "   ", "", 0, 0)
+	assert.ErrorIs(t, err, ErrInvalidSearchQuery)
+}
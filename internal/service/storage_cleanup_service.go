@@ -0,0 +1,221 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/storage"
+	"mlvt/internal/infra/zap-logging/log"
+	"mlvt/internal/repo"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Default cleanup settings used when a StorageCleanupConfig field is left at zero
+const (
+	DefaultStorageDeletionMaxAttempts = 10
+	DefaultStorageDeletionBackoffBase = time.Minute
+	DefaultStorageDeletionBackoffMax  = 6 * time.Hour
+)
+
+// StorageCleanupConfig controls how deleted records' objects are removed from storage
+type StorageCleanupConfig struct {
+	MaxAttempts int           // Attempts before a deletion is marked failed
+	BackoffBase time.Duration // Delay before the first retry; doubled after every failed attempt
+	BackoffMax  time.Duration // Longest delay between two attempts
+	Folders     []string      // Folders scanned for orphans, on top of the folders rows point into
+}
+
+// withDefaults fills zero fields with the package defaults
+func (c StorageCleanupConfig) withDefaults() StorageCleanupConfig {
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = DefaultStorageDeletionMaxAttempts
+	}
+	if c.BackoffBase <= 0 {
+		c.BackoffBase = DefaultStorageDeletionBackoffBase
+	}
+	if c.BackoffMax <= 0 {
+		c.BackoffMax = DefaultStorageDeletionBackoffMax
+	}
+	return c
+}
+
+// StorageCleanupService removes the objects of deleted records from storage and
+// reconciles storage with the database
+type StorageCleanupService interface {
+	// ProcessDeletions deletes up to limit queued objects that are due and returns how many it handled
+	ProcessDeletions(now time.Time, limit int) (int, error)
+	// Reconcile reports objects no row references that were last modified before olderThan,
+	// and rows whose object is missing. With deleteOrphans the orphans are queued for deletion.
+	Reconcile(olderThan time.Time, deleteOrphans bool) (*entity.ReconcileReport, error)
+}
+
+type storageCleanupService struct {
+	repo   repo.StorageObjectRepository
+	store  storage.Storage
+	config StorageCleanupConfig
+}
+
+func NewStorageCleanupService(repo repo.StorageObjectRepository, store storage.Storage, config StorageCleanupConfig) StorageCleanupService {
+	return &storageCleanupService{
+		repo:   repo,
+		store:  store,
+		config: config.withDefaults(),
+	}
+}
+
+// ProcessDeletions deletes the due objects one by one. A failed deletion is retried with exponential
+// backoff until its attempts are used up. Objects that a row points at again, for instance because a
+// new file was uploaded under the same name, are dropped from the queue without being deleted.
+func (s *storageCleanupService) ProcessDeletions(now time.Time, limit int) (int, error) {
+	deletions, err := s.repo.ListDueDeletions(now, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, deletion := range deletions {
+		referenced, err := s.repo.IsObjectReferenced(deletion.Folder, deletion.FileName)
+		if err != nil {
+			return 0, err
+		}
+		if referenced {
+			log.Warnf("Skipping deletion of %s queued by %s: it is referenced again", objectKey(deletion.Folder, deletion.FileName), deletion.Reason)
+		} else if err := s.store.DeleteObject(deletion.Folder, deletion.FileName); err != nil && !errors.Is(err, storage.ErrObjectNotFound) {
+			if err := s.handleFailure(&deletion, now, err); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		if err := s.repo.CompleteDeletion(deletion.ID); err != nil {
+			return 0, err
+		}
+	}
+	return len(deletions), nil
+}
+
+// handleFailure schedules a retry of a failed deletion, or gives up once the attempts are used up
+func (s *storageCleanupService) handleFailure(deletion *entity.StorageDeletion, now time.Time, cause error) error {
+	key := objectKey(deletion.Folder, deletion.FileName)
+	attempt := deletion.Attempts + 1
+	if attempt >= s.config.MaxAttempts {
+		log.Errorf("Giving up deleting %s after %d attempts: %v", key, attempt, cause)
+		return s.repo.FailDeletion(deletion.ID, cause.Error())
+	}
+
+	nextAttemptAt := now.Add(s.backoff(attempt))
+	log.Warnf("Failed to delete %s on attempt %d/%d, retrying at %s: %v",
+		key, attempt, s.config.MaxAttempts, nextAttemptAt.Format(time.RFC3339), cause)
+	return s.repo.RetryDeletion(deletion.ID, nextAttemptAt, cause.Error())
+}
+
+// backoff returns the delay before the retry that follows the given attempt
+func (s *storageCleanupService) backoff(attempt int) time.Duration {
+	delay := s.config.BackoffBase
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= s.config.BackoffMax {
+			return s.config.BackoffMax
+		}
+	}
+	if delay > s.config.BackoffMax {
+		return s.config.BackoffMax
+	}
+	return delay
+}
+
+// Reconcile lists the configured folders and every folder a row points into, then compares the objects
+// with the rows. Objects queued for deletion are not orphans, and pending uploads are not dangling
+// since their file may still be on its way.
+func (s *storageCleanupService) Reconcile(olderThan time.Time, deleteOrphans bool) (*entity.ReconcileReport, error) {
+	references, err := s.repo.ListObjectReferences()
+	if err != nil {
+		return nil, err
+	}
+	queued, err := s.repo.ListQueuedObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(references)+len(queued))
+	folders := append([]string{}, s.config.Folders...)
+	for _, reference := range references {
+		key := objectKey(reference.Folder, reference.FileName)
+		known[key] = true
+		if dir := path.Dir(key); dir != "." {
+			folders = append(folders, dir)
+		}
+	}
+	for _, object := range queued {
+		known[objectKey(object.Folder, object.FileName)] = true
+	}
+
+	report := &entity.ReconcileReport{Orphans: []entity.StoredObject{}, Dangling: []entity.ObjectReference{}}
+	listed := make(map[string]bool)
+	for _, folder := range scanFolders(folders) {
+		objects, err := s.store.ListObjects(folder)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects in %s: %v", folder, err)
+		}
+		for _, object := range objects {
+			key := objectKey(folder, object.FileName)
+			listed[key] = true
+			if !known[key] && !object.LastModified.After(olderThan) {
+				report.Orphans = append(report.Orphans, entity.StoredObject{Folder: folder, FileName: object.FileName})
+			}
+		}
+	}
+	report.ScannedObjects = len(listed)
+
+	for _, reference := range references {
+		if reference.Table == "video_uploads" || listed[objectKey(reference.Folder, reference.FileName)] {
+			continue
+		}
+		// Objects outside the scanned folders are looked up one by one
+		_, err := s.store.HeadObject(reference.Folder, reference.FileName)
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			report.Dangling = append(report.Dangling, reference)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	if deleteOrphans && len(report.Orphans) > 0 {
+		if err := s.repo.EnqueueDeletions(report.Orphans, "orphan"); err != nil {
+			return nil, err
+		}
+		report.Enqueued = len(report.Orphans)
+	}
+	return report, nil
+}
+
+// objectKey normalizes folder and fileName to the object key they address
+func objectKey(folder, fileName string) string {
+	return storage.FolderPrefix(folder) + fileName
+}
+
+// scanFolders returns the folders to list, leaving out folders inside another one since
+// listing a folder includes everything below it. The bucket root is never listed as a whole.
+func scanFolders(folders []string) []string {
+	var prefixes []string
+	for _, folder := range folders {
+		if prefix := storage.FolderPrefix(folder); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	sort.Strings(prefixes)
+
+	var scan []string
+	for _, prefix := range prefixes {
+		if len(scan) > 0 && strings.HasPrefix(prefix, scan[len(scan)-1]) {
+			continue
+		}
+		scan = append(scan, prefix)
+	}
+	for i, prefix := range scan {
+		scan[i] = strings.TrimSuffix(prefix, "/")
+	}
+	return scan
+}
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"mlvt/internal/entity"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockStorageCleanupService is a mock implementation of the StorageCleanupService interface
+type MockStorageCleanupService struct {
+	mock.Mock
+}
+
+func (m *MockStorageCleanupService) ProcessDeletions(now time.Time, limit int) (int, error) {
+	args := m.Called(now, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockStorageCleanupService) Reconcile(olderThan time.Time, deleteOrphans bool) (*entity.ReconcileReport, error) {
+	args := m.Called(olderThan, deleteOrphans)
+	report, _ := args.Get(0).(*entity.ReconcileReport)
+	return report, args.Error(1)
+}
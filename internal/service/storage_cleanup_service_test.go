@@ -0,0 +1,110 @@
+package service
+
+import (
+	"errors"
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/storage"
+	"mlvt/internal/repo"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var cleanupTestNow = time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func setupStorageCleanupService(folders ...string) (StorageCleanupService, *repo.MockStorageObjectRepository, *storage.MockStorage) {
+	mockRepo := new(repo.MockStorageObjectRepository)
+	mockStore := new(storage.MockStorage)
+	config := StorageCleanupConfig{MaxAttempts: 3, BackoffBase: time.Minute, BackoffMax: time.Hour, Folders: folders}
+	return NewStorageCleanupService(mockRepo, mockStore, config), mockRepo, mockStore
+}
+
+func TestProcessDeletions(t *testing.T) {
+	cleanupService, mockRepo, mockStore := setupStorageCleanupService()
+
+	mockRepo.On("ListDueDeletions", cleanupTestNow, 10).Return([]entity.StorageDeletion{
+		{ID: 1, Folder: "videos", FileName: "a.mp4"},
+		{ID: 2, Folder: "videos", FileName: "gone.mp4"},
+		{ID: 3, Folder: "videos", FileName: "flaky.mp4", Attempts: 1},
+		{ID: 4, Folder: "videos", FileName: "broken.mp4", Attempts: 2},
+		{ID: 5, Folder: "videos", FileName: "reused.mp4"},
+	}, nil)
+	mockRepo.On("IsObjectReferenced", "videos", "reused.mp4").Return(true, nil)
+	mockRepo.On("IsObjectReferenced", "videos", mock.AnythingOfType("string")).Return(false, nil)
+
+	mockStore.On("DeleteObject", "videos", "a.mp4").Return(nil)
+	mockStore.On("DeleteObject", "videos", "gone.mp4").Return(storage.ErrObjectNotFound)
+	mockStore.On("DeleteObject", "videos", "flaky.mp4").Return(errors.New("timeout"))
+	mockStore.On("DeleteObject", "videos", "broken.mp4").Return(errors.New("access denied"))
+
+	mockRepo.On("CompleteDeletion", uint64(1)).Return(nil)
+	mockRepo.On("CompleteDeletion", uint64(2)).Return(nil)
+	mockRepo.On("CompleteDeletion", uint64(5)).Return(nil)
+	mockRepo.On("RetryDeletion", uint64(3), cleanupTestNow.Add(2*time.Minute), "timeout").Return(nil)
+	mockRepo.On("FailDeletion", uint64(4), "access denied").Return(nil)
+
+	count, err := cleanupService.ProcessDeletions(cleanupTestNow, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, count)
+	mockRepo.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "DeleteObject", "videos", "reused.mp4")
+}
+
+func TestProcessDeletions_RepositoryError(t *testing.T) {
+	cleanupService, mockRepo, _ := setupStorageCleanupService()
+	mockRepo.On("ListDueDeletions", cleanupTestNow, 10).Return(nil, errors.New("database locked"))
+
+	_, err := cleanupService.ProcessDeletions(cleanupTestNow, 10)
+	assert.Error(t, err)
+}
+
+func TestReconcile(t *testing.T) {
+	cleanupService, mockRepo, mockStore := setupStorageCleanupService("videos", "videos/archive", "", "audios/")
+	old := cleanupTestNow.Add(-48 * time.Hour)
+
+	mockRepo.On("ListObjectReferences").Return([]entity.ObjectReference{
+		{Table: "videos", RowID: 1, Folder: "videos/", FileName: "a.mp4"},
+		{Table: "videos", RowID: 2, Folder: "videos", FileName: "missing.mp4"},
+		{Table: "frames", RowID: 1, FileName: "frames/a-1.jpg"},
+		{Table: "users", RowID: 1, FileName: "root.png"},
+		{Table: "video_uploads", RowID: 1, Folder: "videos", FileName: "uploading.mp4"},
+	}, nil)
+	mockRepo.On("ListQueuedObjects").Return([]entity.StoredObject{{Folder: "videos", FileName: "queued.mp4"}}, nil)
+
+	mockStore.On("ListObjects", "audios").Return([]storage.ObjectInfo{}, nil)
+	mockStore.On("ListObjects", "frames").Return([]storage.ObjectInfo{{FileName: "a-1.jpg", LastModified: old}}, nil)
+	mockStore.On("ListObjects", "videos").Return([]storage.ObjectInfo{
+		{FileName: "a.mp4", LastModified: old},
+		{FileName: "queued.mp4", LastModified: old},
+		{FileName: "archive/orphan.mp4", LastModified: old},
+		{FileName: "fresh.mp4", LastModified: cleanupTestNow},
+	}, nil)
+	mockStore.On("HeadObject", "videos", "missing.mp4").Return(nil, storage.ErrObjectNotFound)
+	mockStore.On("HeadObject", "", "root.png").Return(&storage.ObjectInfo{}, nil)
+	mockRepo.On("EnqueueDeletions", []entity.StoredObject{{Folder: "videos", FileName: "archive/orphan.mp4"}}, "orphan").Return(nil)
+
+	report, err := cleanupService.Reconcile(cleanupTestNow.Add(-24*time.Hour), true)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, report.ScannedObjects)
+	assert.Equal(t, []entity.StoredObject{{Folder: "videos", FileName: "archive/orphan.mp4"}}, report.Orphans)
+	assert.Equal(t, []entity.ObjectReference{{Table: "videos", RowID: 2, Folder: "videos", FileName: "missing.mp4"}}, report.Dangling)
+	assert.Equal(t, 1, report.Enqueued)
+	mockStore.AssertNumberOfCalls(t, "ListObjects", 3)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestReconcile_ReportOnly(t *testing.T) {
+	cleanupService, mockRepo, mockStore := setupStorageCleanupService("videos")
+
+	mockRepo.On("ListObjectReferences").Return(nil, nil)
+	mockRepo.On("ListQueuedObjects").Return(nil, nil)
+	mockStore.On("ListObjects", "videos").Return([]storage.ObjectInfo{{FileName: "orphan.mp4"}}, nil)
+
+	report, err := cleanupService.Reconcile(cleanupTestNow, false)
+	assert.NoError(t, err)
+	assert.Len(t, report.Orphans, 1)
+	assert.Zero(t, report.Enqueued)
+	mockRepo.AssertNotCalled(t, "EnqueueDeletions")
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"io"
+	"mlvt/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTranscriptionService is a mock implementation of the TranscriptionService interface
+type MockTranscriptionService struct {
+	mock.Mock
+}
+
+func (m *MockTranscriptionService) CreateTranscription(transcription *entity.Transcription) error {
+	args := m.Called(transcription)
+	return args.Error(0)
+}
+
+func (m *MockTranscriptionService) GetTranscriptionByID(transcriptionID uint64) (*entity.Transcription, string, error) {
+	args := m.Called(transcriptionID)
+	transcription, _ := args.Get(0).(*entity.Transcription)
+	return transcription, args.String(1), args.Error(2)
+}
+
+func (m *MockTranscriptionService) GetTranscriptionByIDAndUserID(transcriptionID, userID uint64) (*entity.Transcription, string, error) {
+	args := m.Called(transcriptionID, userID)
+	transcription, _ := args.Get(0).(*entity.Transcription)
+	return transcription, args.String(1), args.Error(2)
+}
+
+func (m *MockTranscriptionService) GetTranscriptionByIDAndVideoID(transcriptionID, videoID uint64) (*entity.Transcription, string, error) {
+	args := m.Called(transcriptionID, videoID)
+	transcription, _ := args.Get(0).(*entity.Transcription)
+	return transcription, args.String(1), args.Error(2)
+}
+
+func (m *MockTranscriptionService) ListTranscriptionsByUserID(userID uint64, opts entity.ListOptions) ([]entity.Transcription, string, error) {
+	args := m.Called(userID, opts)
+	transcriptions, _ := args.Get(0).([]entity.Transcription)
+	return transcriptions, args.String(1), args.Error(2)
+}
+
+func (m *MockTranscriptionService) ListTranscriptionsByVideoID(videoID uint64, opts entity.ListOptions) ([]entity.Transcription, string, error) {
+	args := m.Called(videoID, opts)
+	transcriptions, _ := args.Get(0).([]entity.Transcription)
+	return transcriptions, args.String(1), args.Error(2)
+}
+
+func (m *MockTranscriptionService) DeleteTranscription(transcriptionID uint64) error {
+	args := m.Called(transcriptionID)
+	return args.Error(0)
+}
+
+func (m *MockTranscriptionService) GeneratePresignedUploadURL(folder, fileName, fileType string) (string, error) {
+	args := m.Called(folder, fileName, fileType)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTranscriptionService) GeneratePresignedDownloadURL(transcriptionID uint64) (string, error) {
+	args := m.Called(transcriptionID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTranscriptionService) ListSegments(transcriptionID uint64) ([]entity.TranscriptionSegment, error) {
+	args := m.Called(transcriptionID)
+	segments, _ := args.Get(0).([]entity.TranscriptionSegment)
+	return segments, args.Error(1)
+}
+
+func (m *MockTranscriptionService) AppendSegments(transcriptionID uint64, segments []entity.TranscriptionSegment) ([]entity.TranscriptionSegment, error) {
+	args := m.Called(transcriptionID, segments)
+	all, _ := args.Get(0).([]entity.TranscriptionSegment)
+	return all, args.Error(1)
+}
+
+func (m *MockTranscriptionService) ReplaceSegments(transcriptionID uint64, segments []entity.TranscriptionSegment) ([]entity.TranscriptionSegment, error) {
+	args := m.Called(transcriptionID, segments)
+	all, _ := args.Get(0).([]entity.TranscriptionSegment)
+	return all, args.Error(1)
+}
+
+func (m *MockTranscriptionService) ExportTranscription(transcriptionID uint64, format string) ([]byte, string, error) {
+	args := m.Called(transcriptionID, format)
+	content, _ := args.Get(0).([]byte)
+	return content, args.String(1), args.Error(2)
+}
+
+func (m *MockTranscriptionService) ImportSubtitles(transcriptionID uint64, format string, r io.Reader) ([]entity.TranscriptionSegment, error) {
+	args := m.Called(transcriptionID, format, r)
+	segments, _ := args.Get(0).([]entity.TranscriptionSegment)
+	return segments, args.Error(1)
+}
@@ -1,33 +1,66 @@
 package service
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"mlvt/internal/entity"
-	"mlvt/internal/infra/aws"
+	"mlvt/internal/infra/storage"
+	"mlvt/internal/pkg/subtitle"
 	"mlvt/internal/repo"
+	"strings"
 )
 
+var (
+	ErrTranscriptionNotFound = errors.New("transcription not found")
+	ErrInvalidSegment        = errors.New("invalid transcription segment")
+	ErrUnsupportedFormat     = errors.New("unsupported transcription format")
+)
+
+// Export formats of a transcription's segments
+const (
+	TranscriptionFormatSRT  = subtitle.FormatSRT
+	TranscriptionFormatVTT  = subtitle.FormatVTT
+	TranscriptionFormatJSON = "json"
+)
+
+// transcriptionContentTypes maps each export format to its MIME type
+var transcriptionContentTypes = map[string]string{
+	TranscriptionFormatSRT:  "application/x-subrip",
+	TranscriptionFormatVTT:  "text/vtt; charset=utf-8",
+	TranscriptionFormatJSON: "application/json",
+}
+
 type TranscriptionService interface {
 	CreateTranscription(transcription *entity.Transcription) error
 	GetTranscriptionByID(transcriptionID uint64) (*entity.Transcription, string, error)
 	GetTranscriptionByIDAndUserID(transcriptionID, userID uint64) (*entity.Transcription, string, error)
 	GetTranscriptionByIDAndVideoID(transcriptionID, videoID uint64) (*entity.Transcription, string, error)
-	ListTranscriptionsByUserID(userID uint64) ([]entity.Transcription, error)
-	ListTranscriptionsByVideoID(videoID uint64) ([]entity.Transcription, error)
+	ListTranscriptionsByUserID(userID uint64, opts entity.ListOptions) ([]entity.Transcription, string, error)
+	ListTranscriptionsByVideoID(videoID uint64, opts entity.ListOptions) ([]entity.Transcription, string, error)
 	DeleteTranscription(transcriptionID uint64) error
 	GeneratePresignedUploadURL(folder, fileName, fileType string) (string, error)
 	GeneratePresignedDownloadURL(transcriptionID uint64) (string, error)
+	ListSegments(transcriptionID uint64) ([]entity.TranscriptionSegment, error)
+	AppendSegments(transcriptionID uint64, segments []entity.TranscriptionSegment) ([]entity.TranscriptionSegment, error)
+	ReplaceSegments(transcriptionID uint64, segments []entity.TranscriptionSegment) ([]entity.TranscriptionSegment, error)
+	ExportTranscription(transcriptionID uint64, format string) ([]byte, string, error)
+	ImportSubtitles(transcriptionID uint64, format string, r io.Reader) ([]entity.TranscriptionSegment, error)
 }
 
 type transcriptionService struct {
-	repo     repo.TranscriptionRepository
-	s3Client *aws.S3Client
+	repo        repo.TranscriptionRepository
+	segmentRepo repo.TranscriptionSegmentRepository
+	store       storage.Storage
 }
 
-func NewTranscriptionService(repo repo.TranscriptionRepository, s3Client *aws.S3Client) TranscriptionService {
+func NewTranscriptionService(repo repo.TranscriptionRepository, segmentRepo repo.TranscriptionSegmentRepository, store storage.Storage) TranscriptionService {
 	return &transcriptionService{
-		repo:     repo,
-		s3Client: s3Client,
+		repo:        repo,
+		segmentRepo: segmentRepo,
+		store:       store,
 	}
 }
 
@@ -45,7 +78,7 @@ func (s *transcriptionService) GetTranscriptionByID(transcriptionID uint64) (*en
 	}
 
 	// Generate presigned URL
-	presignedURL, err := s.s3Client.GeneratePresignedURL(transcription.Folder, transcription.FileName, "application/json")
+	presignedURL, err := s.store.GeneratePresignedDownloadURL(transcription.Folder, transcription.FileName, "application/json")
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to generate presigned download URL: %v", err)
 	}
@@ -63,7 +96,7 @@ func (s *transcriptionService) GetTranscriptionByIDAndUserID(transcriptionID, us
 	}
 
 	// Generate presigned URL
-	presignedURL, err := s.s3Client.GeneratePresignedURL(transcription.Folder, transcription.FileName, "application/json")
+	presignedURL, err := s.store.GeneratePresignedDownloadURL(transcription.Folder, transcription.FileName, "application/json")
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to generate presigned download URL: %v", err)
 	}
@@ -81,7 +114,7 @@ func (s *transcriptionService) GetTranscriptionByIDAndVideoID(transcriptionID, v
 	}
 
 	// Generate presigned URL
-	presignedURL, err := s.s3Client.GeneratePresignedURL(transcription.Folder, transcription.FileName, "application/json")
+	presignedURL, err := s.store.GeneratePresignedDownloadURL(transcription.Folder, transcription.FileName, "application/json")
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to generate presigned download URL: %v", err)
 	}
@@ -89,12 +122,12 @@ func (s *transcriptionService) GetTranscriptionByIDAndVideoID(transcriptionID, v
 	return transcription, presignedURL, nil
 }
 
-func (s *transcriptionService) ListTranscriptionsByUserID(userID uint64) ([]entity.Transcription, error) {
-	return s.repo.ListTranscriptionsByUserID(userID)
+func (s *transcriptionService) ListTranscriptionsByUserID(userID uint64, opts entity.ListOptions) ([]entity.Transcription, string, error) {
+	return s.repo.ListTranscriptionsByUserID(userID, opts)
 }
 
-func (s *transcriptionService) ListTranscriptionsByVideoID(videoID uint64) ([]entity.Transcription, error) {
-	return s.repo.ListTranscriptionsByVideoID(videoID)
+func (s *transcriptionService) ListTranscriptionsByVideoID(videoID uint64, opts entity.ListOptions) ([]entity.Transcription, string, error) {
+	return s.repo.ListTranscriptionsByVideoID(videoID, opts)
 }
 
 func (s *transcriptionService) DeleteTranscription(transcriptionID uint64) error {
@@ -102,7 +135,7 @@ func (s *transcriptionService) DeleteTranscription(transcriptionID uint64) error
 }
 
 func (s *transcriptionService) GeneratePresignedUploadURL(folder, fileName, fileType string) (string, error) {
-	return s.s3Client.GeneratePresignedURL(folder, fileName, fileType)
+	return s.store.GeneratePresignedUploadURL(folder, fileName, fileType)
 }
 
 func (s *transcriptionService) GeneratePresignedDownloadURL(transcriptionID uint64) (string, error) {
@@ -114,5 +147,105 @@ func (s *transcriptionService) GeneratePresignedDownloadURL(transcriptionID uint
 		return "", fmt.Errorf("transcription not found")
 	}
 
-	return s.s3Client.GeneratePresignedURL(transcription.Folder, transcription.FileName, "application/json")
+	return s.store.GeneratePresignedDownloadURL(transcription.Folder, transcription.FileName, "application/json", storage.AsAttachment(transcription.FileName))
+}
+
+// ListSegments returns the timed segments of a transcription in order
+func (s *transcriptionService) ListSegments(transcriptionID uint64) ([]entity.TranscriptionSegment, error) {
+	if err := s.ensureTranscription(transcriptionID); err != nil {
+		return nil, err
+	}
+	return s.segmentRepo.ListSegments(transcriptionID)
+}
+
+// AppendSegments adds segments after the existing ones and returns all segments of the transcription
+func (s *transcriptionService) AppendSegments(transcriptionID uint64, segments []entity.TranscriptionSegment) ([]entity.TranscriptionSegment, error) {
+	if err := validateSegments(segments); err != nil {
+		return nil, err
+	}
+	if err := s.ensureTranscription(transcriptionID); err != nil {
+		return nil, err
+	}
+	return s.segmentRepo.AppendSegments(transcriptionID, segments)
+}
+
+// ReplaceSegments replaces all segments of a transcription. The transcription's text becomes the new segment texts.
+func (s *transcriptionService) ReplaceSegments(transcriptionID uint64, segments []entity.TranscriptionSegment) ([]entity.TranscriptionSegment, error) {
+	if err := validateSegments(segments); err != nil {
+		return nil, err
+	}
+	if err := s.ensureTranscription(transcriptionID); err != nil {
+		return nil, err
+	}
+	return s.segmentRepo.ReplaceSegments(transcriptionID, segments)
+}
+
+// ExportTranscription renders the segments of a transcription as SRT, WebVTT or JSON and returns the content type
+func (s *transcriptionService) ExportTranscription(transcriptionID uint64, format string) ([]byte, string, error) {
+	format = strings.ToLower(format)
+	contentType, ok := transcriptionContentTypes[format]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+
+	segments, err := s.ListSegments(transcriptionID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var buf bytes.Buffer
+	if format == TranscriptionFormatJSON {
+		if segments == nil {
+			segments = []entity.TranscriptionSegment{}
+		}
+		err = json.NewEncoder(&buf).Encode(map[string][]entity.TranscriptionSegment{"segments": segments})
+	} else {
+		err = subtitle.Write(format, &buf, segments)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to export transcription: %v", err)
+	}
+	return buf.Bytes(), contentType, nil
+}
+
+// ImportSubtitles parses an SRT or WebVTT file and replaces the segments of the transcription with its cues
+func (s *transcriptionService) ImportSubtitles(transcriptionID uint64, format string, r io.Reader) ([]entity.TranscriptionSegment, error) {
+	format = strings.ToLower(format)
+	if format != TranscriptionFormatSRT && format != TranscriptionFormatVTT {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+
+	segments, err := subtitle.Parse(format, r)
+	if errors.Is(err, subtitle.ErrInvalidSubtitle) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSegment, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.ReplaceSegments(transcriptionID, segments)
+}
+
+func (s *transcriptionService) ensureTranscription(transcriptionID uint64) error {
+	transcription, err := s.repo.GetTranscriptionByID(transcriptionID)
+	if err != nil {
+		return err
+	}
+	if transcription == nil {
+		return ErrTranscriptionNotFound
+	}
+	return nil
+}
+
+func validateSegments(segments []entity.TranscriptionSegment) error {
+	for i, segment := range segments {
+		switch {
+		case segment.StartMs < 0:
+			return fmt.Errorf("%w: segment %d starts before zero", ErrInvalidSegment, i)
+		case segment.EndMs < segment.StartMs:
+			return fmt.Errorf("%w: segment %d ends before it starts", ErrInvalidSegment, i)
+		case strings.TrimSpace(segment.Text) == "":
+			return fmt.Errorf("%w: segment %d has no text", ErrInvalidSegment, i)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"errors"
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/storage"
+	"mlvt/internal/repo"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupTranscriptionService() (TranscriptionService, *repo.MockTranscriptionRepository, *repo.MockTranscriptionSegmentRepository) {
+	transcriptionRepo := new(repo.MockTranscriptionRepository)
+	segmentRepo := new(repo.MockTranscriptionSegmentRepository)
+	return NewTranscriptionService(transcriptionRepo, segmentRepo, new(storage.MockStorage)), transcriptionRepo, segmentRepo
+}
+
+func TestReplaceSegments(t *testing.T) {
+	transcriptionService, transcriptionRepo, segmentRepo := setupTranscriptionService()
+
+	segments := []entity.TranscriptionSegment{{StartMs: 0, EndMs: 1000, Text: "Hello"}}
+	transcriptionRepo.On("GetTranscriptionByID", uint64(1)).Return(&entity.Transcription{ID: 1}, nil)
+	segmentRepo.On("ReplaceSegments", uint64(1), segments).Return(segments, nil)
+
+	result, err := transcriptionService.ReplaceSegments(1, segments)
+	assert.NoError(t, err)
+	assert.Equal(t, segments, result)
+	segmentRepo.AssertExpectations(t)
+}
+
+func TestReplaceSegments_Rejected(t *testing.T) {
+	transcriptionService, transcriptionRepo, segmentRepo := setupTranscriptionService()
+
+	transcriptionRepo.On("GetTranscriptionByID", uint64(2)).Return(nil, nil)
+
+	for _, segment := range []entity.TranscriptionSegment{
+		{StartMs: -1, EndMs: 10, Text: "negative"},
+		{StartMs: 10, EndMs: 5, Text: "backwards"},
+		{StartMs: 0, EndMs: 5, Text: "  "},
+	} {
+		_, err := transcriptionService.ReplaceSegments(1, []entity.TranscriptionSegment{segment})
+		assert.True(t, errors.Is(err, ErrInvalidSegment), segment.Text)
+	}
+
+	_, err := transcriptionService.ReplaceSegments(2, []entity.TranscriptionSegment{{StartMs: 0, EndMs: 5, Text: "ok"}})
+	assert.True(t, errors.Is(err, ErrTranscriptionNotFound))
+	segmentRepo.AssertNotCalled(t, "ReplaceSegments", mock.Anything, mock.Anything)
+}
+
+func TestExportTranscription(t *testing.T) {
+	transcriptionService, transcriptionRepo, segmentRepo := setupTranscriptionService()
+
+	transcriptionRepo.On("GetTranscriptionByID", uint64(1)).Return(&entity.Transcription{ID: 1}, nil)
+	segmentRepo.On("ListSegments", uint64(1)).Return([]entity.TranscriptionSegment{
+		{StartMs: 1500, EndMs: 3000, Speaker: "Alice", Text: "Hello"},
+	}, nil)
+
+	content, contentType, err := transcriptionService.ExportTranscription(1, "SRT")
+	assert.NoError(t, err)
+	assert.Equal(t, "application/x-subrip", contentType)
+	assert.Equal(t, "1\n00:00:01,500 --> 00:00:03,000\nHello\n\n", string(content))
+
+	content, contentType, err = transcriptionService.ExportTranscription(1, TranscriptionFormatVTT)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(contentType, "text/vtt"))
+	assert.Contains(t, string(content), "<v Alice>Hello")
+
+	content, _, err = transcriptionService.ExportTranscription(1, TranscriptionFormatJSON)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `"start_ms":1500`)
+
+	_, _, err = transcriptionService.ExportTranscription(1, "docx")
+	assert.True(t, errors.Is(err, ErrUnsupportedFormat))
+}
+
+func TestImportSubtitles(t *testing.T) {
+	transcriptionService, transcriptionRepo, segmentRepo := setupTranscriptionService()
+
+	transcriptionRepo.On("GetTranscriptionByID", uint64(1)).Return(&entity.Transcription{ID: 1}, nil)
+	expected := []entity.TranscriptionSegment{{StartMs: 1000, EndMs: 2000, Speaker: "Bob", Text: "Hi there"}}
+	segmentRepo.On("ReplaceSegments", uint64(1), expected).Return(expected, nil)
+
+	segments, err := transcriptionService.ImportSubtitles(1, "vtt", strings.NewReader("WEBVTT\n\n00:01.000 --> 00:02.000\n<v Bob>Hi there\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, segments)
+
+	_, err = transcriptionService.ImportSubtitles(1, "srt", strings.NewReader("not a subtitle"))
+	assert.True(t, errors.Is(err, ErrInvalidSegment))
+
+	_, err = transcriptionService.ImportSubtitles(1, "json", strings.NewReader("{}"))
+	assert.True(t, errors.Is(err, ErrUnsupportedFormat))
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/storage"
+	"mlvt/internal/infra/zap-logging/log"
+	"mlvt/internal/repo"
+	"strings"
+)
+
+var (
+	ErrTranslationNotFound    = errors.New("translation not found")
+	ErrInvalidTranslation     = errors.New("invalid translation request")
+	ErrTranslationExists      = errors.New("a translation into this language already exists for the video")
+	ErrInvalidTranslationStep = errors.New("invalid translation status change")
+)
+
+// translationStatusTransitions lists the statuses each translation status may move to
+var translationStatusTransitions = map[entity.TranslationStatus][]entity.TranslationStatus{
+	entity.TranslationStatusPending:    {entity.TranslationStatusProcessing, entity.TranslationStatusFailed},
+	entity.TranslationStatusProcessing: {entity.TranslationStatusCompleted, entity.TranslationStatusFailed},
+	entity.TranslationStatusCompleted:  {},
+	entity.TranslationStatusFailed:     {},
+}
+
+// TranslationArtifacts holds the outputs attached to a translation as it progresses.
+// Nil fields are left unchanged.
+type TranslationArtifacts struct {
+	TranscriptionID *uint64                   `json:"transcription_id"`
+	AudioID         *uint64                   `json:"audio_id"`
+	OutputFolder    *string                   `json:"output_folder"` // Optional; must be translations/<id>
+	OutputFileName  *string                   `json:"output_file_name"`
+	Status          *entity.TranslationStatus `json:"status"`
+}
+
+type TranslationService interface {
+	RequestTranslations(userID, videoID uint64, sourceLang string, targetLangs []string) ([]entity.Translation, error)
+	GetTranslationByID(translationID uint64) (*entity.Translation, string, error) // Returns the translation and a presigned URL for its output video
+	ListTranslationsByVideoID(videoID uint64, opts entity.ListOptions) ([]entity.Translation, string, error)
+	ListTranslationsByUserID(userID uint64, opts entity.ListOptions) ([]entity.Translation, string, error)
+	UpdateTranslationArtifacts(translationID uint64, artifacts TranslationArtifacts) (*entity.Translation, error)
+	DeleteTranslation(translationID uint64) error
+}
+
+type translationService struct {
+	repo              repo.TranslationRepository
+	videoRepo         repo.VideoRepository
+	transcriptionRepo repo.TranscriptionRepository
+	audioRepo         repo.AudioRepository
+	store             storage.Storage
+	billing           BillingService
+	probeService      MediaProbeService
+}
+
+func NewTranslationService(repo repo.TranslationRepository, videoRepo repo.VideoRepository, transcriptionRepo repo.TranscriptionRepository,
+	audioRepo repo.AudioRepository, store storage.Storage, billing BillingService, probeService MediaProbeService) TranslationService {
+	return &translationService{
+		repo:              repo,
+		videoRepo:         videoRepo,
+		transcriptionRepo: transcriptionRepo,
+		audioRepo:         audioRepo,
+		store:             store,
+		billing:           billing,
+		probeService:      probeService,
+	}
+}
+
+// RequestTranslations creates one pending translation of the video per target language.
+// Languages that already have a pending, processing or completed translation are rejected.
+// Each translation costs the user a minute of their balance per started minute of the video, as measured
+// from its file; a video that has not been probed yet is probed first.
+func (s *translationService) RequestTranslations(userID, videoID uint64, sourceLang string, targetLangs []string) ([]entity.Translation, error) {
+	sourceLang = strings.TrimSpace(sourceLang)
+	if sourceLang == "" || len(targetLangs) == 0 {
+		return nil, ErrInvalidTranslation
+	}
+
+	video, err := s.videoRepo.GetVideoByID(videoID)
+	if err != nil {
+		return nil, err
+	}
+	if video == nil {
+		return nil, ErrVideoNotFound
+	}
+	if video.ProbedAt == nil {
+		// The duration sent by the client cannot be trusted to price the translation
+		video, err = s.probeService.ProbeVideo(context.Background(), videoID)
+		if errors.Is(err, ErrMediaNotUploaded) || errors.Is(err, ErrUnreadableMedia) {
+			return nil, fmt.Errorf("%w: the length of the video could not be measured: %v", ErrInvalidTranslation, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if video.Duration <= 0 {
+		return nil, fmt.Errorf("%w: the length of the video is not known yet", ErrInvalidTranslation)
+	}
+
+	activeLangs, err := s.repo.ListActiveTargetLangs(videoID)
+	if err != nil {
+		return nil, err
+	}
+	active := make(map[string]bool)
+	for _, lang := range activeLangs {
+		active[lang] = true
+	}
+
+	requested := make(map[string]bool)
+	var translations []*entity.Translation
+	for _, targetLang := range targetLangs {
+		targetLang = strings.TrimSpace(targetLang)
+		if targetLang == "" || targetLang == sourceLang {
+			return nil, fmt.Errorf("%w: target language %q", ErrInvalidTranslation, targetLang)
+		}
+		if requested[targetLang] {
+			continue
+		}
+		if active[targetLang] {
+			return nil, fmt.Errorf("%w: %s", ErrTranslationExists, targetLang)
+		}
+		requested[targetLang] = true
+
+		translations = append(translations, &entity.Translation{
+			VideoID:    videoID,
+			UserID:     userID,
+			SourceLang: sourceLang,
+			TargetLang: targetLang,
+			Status:     entity.TranslationStatusPending,
+		})
+	}
+
+	minutes := translationMinutes(video.Duration)
+	balance, err := s.billing.GetBalance(userID)
+	if err != nil {
+		return nil, err
+	}
+	if needed := minutes * int64(len(translations)); balance < needed {
+		return nil, fmt.Errorf("%w: %d needed, %d left", ErrInsufficientCredits, needed, balance)
+	}
+
+	if err := s.repo.CreateTranslations(translations); err != nil {
+		return nil, err
+	}
+
+	created := make([]entity.Translation, 0, len(translations))
+	for _, translation := range translations {
+		created = append(created, *translation)
+	}
+
+	// The translations need IDs to be charged for; if a concurrent request spent the balance, they are removed
+	if err := s.billing.ChargeTranslations(userID, created, minutes); err != nil {
+		for _, translation := range created {
+			if err := s.repo.DeleteTranslation(translation.ID); err != nil {
+				log.Errorf("Failed to remove translation %d that could not be charged for: %v", translation.ID, err)
+			}
+		}
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetTranslationByID retrieves a translation and, once its output video exists, a presigned download URL for it
+func (s *translationService) GetTranslationByID(translationID uint64) (*entity.Translation, string, error) {
+	translation, err := s.repo.GetTranslationByID(translationID)
+	if err != nil {
+		return nil, "", err
+	}
+	if translation == nil {
+		return nil, "", ErrTranslationNotFound
+	}
+
+	if translation.OutputFileName == "" || translation.OutputFolder != translationOutputFolder(translation.ID) {
+		return translation, "", nil
+	}
+
+	downloadURL, err := s.store.GeneratePresignedDownloadURL(translation.OutputFolder, translation.OutputFileName, "video/mp4")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate presigned download URL: %v", err)
+	}
+	return translation, downloadURL, nil
+}
+
+func (s *translationService) ListTranslationsByVideoID(videoID uint64, opts entity.ListOptions) ([]entity.Translation, string, error) {
+	return s.repo.ListTranslationsByVideoID(videoID, opts)
+}
+
+func (s *translationService) ListTranslationsByUserID(userID uint64, opts entity.ListOptions) ([]entity.Translation, string, error) {
+	return s.repo.ListTranslationsByUserID(userID, opts)
+}
+
+// UpdateTranslationArtifacts attaches produced artifacts to a translation and advances its status.
+// Transcriptions and audios must belong to the translation's video, and the audio must be in the target language.
+// The output video must be uploaded to the translation's folder, translations/<id>.
+func (s *translationService) UpdateTranslationArtifacts(translationID uint64, artifacts TranslationArtifacts) (*entity.Translation, error) {
+	translation, err := s.repo.GetTranslationByID(translationID)
+	if err != nil {
+		return nil, err
+	}
+	if translation == nil {
+		return nil, ErrTranslationNotFound
+	}
+
+	if artifacts.TranscriptionID != nil {
+		transcription, err := s.transcriptionRepo.GetTranscriptionByID(*artifacts.TranscriptionID)
+		if err != nil {
+			return nil, err
+		}
+		if transcription == nil || transcription.VideoID != translation.VideoID {
+			return nil, fmt.Errorf("%w: transcription %d does not belong to video %d", ErrInvalidTranslation, *artifacts.TranscriptionID, translation.VideoID)
+		}
+		translation.TranscriptionID = artifacts.TranscriptionID
+	}
+
+	if artifacts.AudioID != nil {
+		audio, err := s.audioRepo.GetAudioByID(*artifacts.AudioID)
+		if err != nil {
+			return nil, err
+		}
+		if audio == nil || audio.VideoID != translation.VideoID {
+			return nil, fmt.Errorf("%w: audio %d does not belong to video %d", ErrInvalidTranslation, *artifacts.AudioID, translation.VideoID)
+		}
+		if audio.Lang != translation.TargetLang {
+			return nil, fmt.Errorf("%w: audio %d is not in %s", ErrInvalidTranslation, *artifacts.AudioID, translation.TargetLang)
+		}
+		translation.AudioID = artifacts.AudioID
+	}
+
+	// The output video is kept under the translation's own folder, so no other object can be attached
+	folder := translationOutputFolder(translation.ID)
+	if artifacts.OutputFolder != nil && *artifacts.OutputFolder != folder {
+		return nil, fmt.Errorf("%w: the output video must be in %s", ErrInvalidTranslation, folder)
+	}
+	if artifacts.OutputFileName != nil {
+		if !isBaseName(*artifacts.OutputFileName) {
+			return nil, fmt.Errorf("%w: output_file_name must be a file name without a folder", ErrInvalidTranslation)
+		}
+		translation.OutputFolder = folder
+		translation.OutputFileName = *artifacts.OutputFileName
+	}
+
+	if artifacts.Status != nil && *artifacts.Status != translation.Status {
+		if !canTransitionTranslationStatus(translation.Status, *artifacts.Status) {
+			return nil, fmt.Errorf("%w: %s to %s", ErrInvalidTranslationStep, translation.Status, *artifacts.Status)
+		}
+		if *artifacts.Status == entity.TranslationStatusCompleted && translation.OutputFileName == "" {
+			return nil, fmt.Errorf("%w: a completed translation needs an output video", ErrInvalidTranslation)
+		}
+		translation.Status = *artifacts.Status
+
+		// Failed translations are not paid for; the refund is given once, so retrying the update is safe
+		if translation.Status == entity.TranslationStatusFailed {
+			if err := s.billing.RefundTranslation(translation); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := s.repo.UpdateTranslation(translation); err != nil {
+		return nil, err
+	}
+	return translation, nil
+}
+
+// DeleteTranslation deletes a translation; its output video is queued for removal from storage.
+// A translation that has not completed is refunded first, so cancelling it does not cost the user their minutes.
+func (s *translationService) DeleteTranslation(translationID uint64) error {
+	translation, err := s.repo.GetTranslationByID(translationID)
+	if err != nil {
+		return err
+	}
+	if translation == nil {
+		return ErrTranslationNotFound
+	}
+	if translation.Status != entity.TranslationStatusCompleted {
+		if err := s.billing.RefundTranslation(translation); err != nil {
+			return err
+		}
+	}
+
+	err = s.repo.DeleteTranslation(translationID)
+	if errors.Is(err, repo.ErrTranslationNotFound) {
+		return ErrTranslationNotFound
+	}
+	return err
+}
+
+// translationOutputFolder is the storage folder that holds the output video of the translation
+func translationOutputFolder(translationID uint64) string {
+	return fmt.Sprintf("translations/%d", translationID)
+}
+
+// translationMinutes is what a translation of a video lasting duration seconds costs: one minute per started minute
+func translationMinutes(duration int) int64 {
+	return int64((duration + 59) / 60)
+}
+
+func canTransitionTranslationStatus(from, to entity.TranslationStatus) bool {
+	for _, allowed := range translationStatusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"mlvt/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTranslationService is a mock implementation of the TranslationService interface
+type MockTranslationService struct {
+	mock.Mock
+}
+
+func (m *MockTranslationService) RequestTranslations(userID, videoID uint64, sourceLang string, targetLangs []string) ([]entity.Translation, error) {
+	args := m.Called(userID, videoID, sourceLang, targetLangs)
+	translations, _ := args.Get(0).([]entity.Translation)
+	return translations, args.Error(1)
+}
+
+func (m *MockTranslationService) GetTranslationByID(translationID uint64) (*entity.Translation, string, error) {
+	args := m.Called(translationID)
+	translation, _ := args.Get(0).(*entity.Translation)
+	return translation, args.String(1), args.Error(2)
+}
+
+func (m *MockTranslationService) ListTranslationsByVideoID(videoID uint64, opts entity.ListOptions) ([]entity.Translation, string, error) {
+	args := m.Called(videoID, opts)
+	translations, _ := args.Get(0).([]entity.Translation)
+	return translations, args.String(1), args.Error(2)
+}
+
+func (m *MockTranslationService) ListTranslationsByUserID(userID uint64, opts entity.ListOptions) ([]entity.Translation, string, error) {
+	args := m.Called(userID, opts)
+	translations, _ := args.Get(0).([]entity.Translation)
+	return translations, args.String(1), args.Error(2)
+}
+
+func (m *MockTranslationService) UpdateTranslationArtifacts(translationID uint64, artifacts TranslationArtifacts) (*entity.Translation, error) {
+	args := m.Called(translationID, artifacts)
+	translation, _ := args.Get(0).(*entity.Translation)
+	return translation, args.Error(1)
+}
+
+func (m *MockTranslationService) DeleteTranslation(translationID uint64) error {
+	args := m.Called(translationID)
+	return args.Error(0)
+}
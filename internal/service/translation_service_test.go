@@ -0,0 +1,268 @@
+package service
+
+import (
+	"errors"
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/storage"
+	"mlvt/internal/repo"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type translationTestDeps struct {
+	repo              *repo.MockTranslationRepository
+	videoRepo         *repo.MockVideoRepository
+	transcriptionRepo *repo.MockTranscriptionRepository
+	audioRepo         *repo.MockAudioRepository
+	s3Client          *storage.MockStorage
+	billing           *MockBillingService
+	probe             *MockMediaProbeService
+}
+
+var probedAt = time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+// probedMediaInfo marks a video as measured from its file
+var probedMediaInfo = entity.MediaInfo{VideoCodec: "h264", ProbedAt: &probedAt}
+
+func setupTranslationService() (TranslationService, translationTestDeps) {
+	deps := translationTestDeps{
+		repo:              new(repo.MockTranslationRepository),
+		videoRepo:         new(repo.MockVideoRepository),
+		transcriptionRepo: new(repo.MockTranscriptionRepository),
+		audioRepo:         new(repo.MockAudioRepository),
+		s3Client:          new(storage.MockStorage),
+		billing:           new(MockBillingService),
+		probe:             new(MockMediaProbeService),
+	}
+	return NewTranslationService(deps.repo, deps.videoRepo, deps.transcriptionRepo, deps.audioRepo, deps.s3Client, deps.billing, deps.probe), deps
+}
+
+func TestRequestTranslations(t *testing.T) {
+	translationService, deps := setupTranslationService()
+
+	deps.videoRepo.On("GetVideoByID", uint64(1)).Return(&entity.Video{ID: 1, UserID: 1, Duration: 90, MediaInfo: probedMediaInfo}, nil)
+	// Only failed translations exist, so every language may be requested
+	deps.repo.On("ListActiveTargetLangs", uint64(1)).Return([]string{}, nil)
+	deps.repo.On("CreateTranslations", mock.MatchedBy(func(translations []*entity.Translation) bool {
+		return len(translations) == 2 && translations[0].TargetLang == "vi" && translations[1].TargetLang == "fr"
+	})).Return(nil)
+	// A video of a minute and a half costs two minutes per language
+	deps.billing.On("GetBalance", uint64(1)).Return(int64(4), nil)
+	deps.billing.On("ChargeTranslations", uint64(1), mock.AnythingOfType("[]entity.Translation"), int64(2)).Return(nil)
+
+	// Duplicates are collapsed
+	translations, err := translationService.RequestTranslations(1, 1, "en", []string{"vi", "fr", "vi"})
+	assert.NoError(t, err)
+	assert.Len(t, translations, 2)
+	assert.Equal(t, entity.TranslationStatusPending, translations[0].Status)
+	deps.repo.AssertExpectations(t)
+	deps.billing.AssertExpectations(t)
+}
+
+func TestRequestTranslations_ProbesClientDuration(t *testing.T) {
+	translationService, deps := setupTranslationService()
+
+	// The client declared a one-second video that lasts ten minutes
+	deps.videoRepo.On("GetVideoByID", uint64(1)).Return(&entity.Video{ID: 1, UserID: 1, Duration: 1}, nil)
+	deps.probe.On("ProbeVideo", mock.Anything, uint64(1)).Return(&entity.Video{ID: 1, UserID: 1, Duration: 600, MediaInfo: probedMediaInfo}, nil)
+	deps.repo.On("ListActiveTargetLangs", uint64(1)).Return([]string{}, nil)
+	deps.billing.On("GetBalance", uint64(1)).Return(int64(5), nil)
+
+	_, err := translationService.RequestTranslations(1, 1, "en", []string{"vi"})
+	assert.ErrorIs(t, err, ErrInsufficientCredits)
+	deps.repo.AssertNotCalled(t, "CreateTranslations", mock.Anything)
+
+	// Without ffprobe nothing is charged on the client's word
+	deps.videoRepo.On("GetVideoByID", uint64(2)).Return(&entity.Video{ID: 2, UserID: 1, Duration: 1}, nil)
+	deps.probe.On("ProbeVideo", mock.Anything, uint64(2)).Return(nil, ErrMediaProbeUnavailable)
+	_, err = translationService.RequestTranslations(1, 2, "en", []string{"vi"})
+	assert.ErrorIs(t, err, ErrMediaProbeUnavailable)
+	deps.billing.AssertNotCalled(t, "ChargeTranslations", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRequestTranslations_InsufficientCredits(t *testing.T) {
+	translationService, deps := setupTranslationService()
+
+	deps.videoRepo.On("GetVideoByID", uint64(1)).Return(&entity.Video{ID: 1, UserID: 1, Duration: 90, MediaInfo: probedMediaInfo}, nil)
+	deps.repo.On("ListActiveTargetLangs", uint64(1)).Return([]string{}, nil)
+	deps.billing.On("GetBalance", uint64(1)).Return(int64(3), nil).Once()
+
+	_, err := translationService.RequestTranslations(1, 1, "en", []string{"vi", "fr"})
+	assert.ErrorIs(t, err, ErrInsufficientCredits)
+	deps.repo.AssertNotCalled(t, "CreateTranslations", mock.Anything)
+
+	// Another request spent the balance between the check and the charge
+	deps.billing.On("GetBalance", uint64(1)).Return(int64(4), nil).Once()
+	deps.repo.On("CreateTranslations", mock.Anything).Run(func(args mock.Arguments) {
+		for i, translation := range args.Get(0).([]*entity.Translation) {
+			translation.ID = uint64(i + 10)
+		}
+	}).Return(nil)
+	deps.billing.On("ChargeTranslations", uint64(1), mock.Anything, int64(2)).Return(ErrInsufficientCredits)
+	deps.repo.On("DeleteTranslation", uint64(10)).Return(nil)
+	deps.repo.On("DeleteTranslation", uint64(11)).Return(nil)
+
+	_, err = translationService.RequestTranslations(1, 1, "en", []string{"vi", "fr"})
+	assert.ErrorIs(t, err, ErrInsufficientCredits)
+	deps.repo.AssertExpectations(t)
+}
+
+func TestRequestTranslations_Rejected(t *testing.T) {
+	translationService, deps := setupTranslationService()
+
+	deps.videoRepo.On("GetVideoByID", uint64(1)).Return(&entity.Video{ID: 1, UserID: 1, Duration: 90, MediaInfo: probedMediaInfo}, nil)
+	deps.videoRepo.On("GetVideoByID", uint64(2)).Return((*entity.Video)(nil), nil)
+	deps.videoRepo.On("GetVideoByID", uint64(3)).Return(&entity.Video{ID: 3, UserID: 1, Duration: 90}, nil)
+	deps.probe.On("ProbeVideo", mock.Anything, uint64(3)).Return(nil, ErrMediaNotUploaded)
+	deps.repo.On("ListActiveTargetLangs", uint64(1)).Return([]string{"vi"}, nil)
+
+	_, err := translationService.RequestTranslations(1, 1, "en", []string{"vi"})
+	assert.True(t, errors.Is(err, ErrTranslationExists))
+
+	_, err = translationService.RequestTranslations(1, 1, "en", []string{"en"})
+	assert.True(t, errors.Is(err, ErrInvalidTranslation))
+
+	_, err = translationService.RequestTranslations(1, 2, "en", []string{"vi"})
+	assert.True(t, errors.Is(err, ErrVideoNotFound))
+
+	// The file of the video cannot be measured, so what the translation costs is unknown
+	_, err = translationService.RequestTranslations(1, 3, "en", []string{"vi"})
+	assert.True(t, errors.Is(err, ErrInvalidTranslation))
+
+	deps.repo.AssertNotCalled(t, "CreateTranslations", mock.Anything)
+}
+
+func TestUpdateTranslationArtifacts(t *testing.T) {
+	translationService, deps := setupTranslationService()
+
+	deps.repo.On("GetTranslationByID", uint64(5)).Return(&entity.Translation{
+		ID: 5, VideoID: 1, TargetLang: "vi", Status: entity.TranslationStatusPending,
+	}, nil)
+	deps.transcriptionRepo.On("GetTranscriptionByID", uint64(3)).Return(&entity.Transcription{ID: 3, VideoID: 1}, nil)
+	deps.audioRepo.On("GetAudioByID", uint64(4)).Return(&entity.Audio{ID: 4, VideoID: 1, Lang: "vi"}, nil)
+	deps.repo.On("UpdateTranslation", mock.AnythingOfType("*entity.Translation")).Return(nil)
+
+	transcriptionID, audioID := uint64(3), uint64(4)
+	status := entity.TranslationStatusProcessing
+	translation, err := translationService.UpdateTranslationArtifacts(5, TranslationArtifacts{
+		TranscriptionID: &transcriptionID,
+		AudioID:         &audioID,
+		Status:          &status,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, entity.TranslationStatusProcessing, translation.Status)
+	assert.Equal(t, transcriptionID, *translation.TranscriptionID)
+	assert.Equal(t, audioID, *translation.AudioID)
+
+	// The output video is stored under the translation's folder
+	fileName := "video_1_vi.mp4"
+	translation, err = translationService.UpdateTranslationArtifacts(5, TranslationArtifacts{OutputFileName: &fileName})
+	assert.NoError(t, err)
+	assert.Equal(t, "translations/5", translation.OutputFolder)
+	assert.Equal(t, fileName, translation.OutputFileName)
+	deps.repo.AssertExpectations(t)
+}
+
+func TestUpdateTranslationArtifacts_FailedIsRefunded(t *testing.T) {
+	translationService, deps := setupTranslationService()
+
+	deps.repo.On("GetTranslationByID", uint64(5)).Return(&entity.Translation{
+		ID: 5, VideoID: 1, TargetLang: "vi", Status: entity.TranslationStatusProcessing,
+	}, nil)
+	deps.billing.On("RefundTranslation", mock.MatchedBy(func(translation *entity.Translation) bool {
+		return translation.ID == 5
+	})).Return(nil).Once()
+	deps.repo.On("UpdateTranslation", mock.AnythingOfType("*entity.Translation")).Return(nil)
+
+	failed := entity.TranslationStatusFailed
+	translation, err := translationService.UpdateTranslationArtifacts(5, TranslationArtifacts{Status: &failed})
+	assert.NoError(t, err)
+	assert.Equal(t, entity.TranslationStatusFailed, translation.Status)
+	deps.billing.AssertExpectations(t)
+}
+
+func TestUpdateTranslationArtifacts_Rejected(t *testing.T) {
+	translationService, deps := setupTranslationService()
+
+	deps.repo.On("GetTranslationByID", uint64(5)).Return(&entity.Translation{
+		ID: 5, VideoID: 1, TargetLang: "vi", Status: entity.TranslationStatusPending,
+	}, nil)
+	deps.audioRepo.On("GetAudioByID", uint64(4)).Return(&entity.Audio{ID: 4, VideoID: 1, Lang: "fr"}, nil)
+
+	// Audio in the wrong language
+	audioID := uint64(4)
+	_, err := translationService.UpdateTranslationArtifacts(5, TranslationArtifacts{AudioID: &audioID})
+	assert.True(t, errors.Is(err, ErrInvalidTranslation))
+
+	// Output videos outside the translation's folder
+	otherFolder, otherFile, nested := "videos", "video_2.mp4", "../videos/video_2.mp4"
+	_, err = translationService.UpdateTranslationArtifacts(5, TranslationArtifacts{OutputFolder: &otherFolder, OutputFileName: &otherFile})
+	assert.True(t, errors.Is(err, ErrInvalidTranslation))
+	_, err = translationService.UpdateTranslationArtifacts(5, TranslationArtifacts{OutputFileName: &nested})
+	assert.True(t, errors.Is(err, ErrInvalidTranslation))
+
+	// Pending translations cannot skip processing
+	completed := entity.TranslationStatusCompleted
+	_, err = translationService.UpdateTranslationArtifacts(5, TranslationArtifacts{Status: &completed})
+	assert.True(t, errors.Is(err, ErrInvalidTranslationStep))
+
+	deps.repo.AssertNotCalled(t, "UpdateTranslation", mock.Anything)
+}
+
+func TestGetTranslationByID(t *testing.T) {
+	translationService, deps := setupTranslationService()
+
+	deps.repo.On("GetTranslationByID", uint64(5)).Return(&entity.Translation{
+		ID: 5, OutputFolder: "translations/5", OutputFileName: "out.mp4", Status: entity.TranslationStatusCompleted,
+	}, nil)
+	deps.repo.On("GetTranslationByID", uint64(6)).Return(nil, nil)
+	deps.s3Client.On("GeneratePresignedDownloadURL", "translations/5", "out.mp4", "video/mp4").Return("https://s3.amazonaws.com/out.mp4", nil)
+
+	translation, downloadURL, err := translationService.GetTranslationByID(5)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), translation.ID)
+	assert.Equal(t, "https://s3.amazonaws.com/out.mp4", downloadURL)
+
+	_, _, err = translationService.GetTranslationByID(6)
+	assert.True(t, errors.Is(err, ErrTranslationNotFound))
+}
+
+func TestDeleteTranslation(t *testing.T) {
+	translationService, deps := setupTranslationService()
+
+	completed := &entity.Translation{ID: 5, Status: entity.TranslationStatusCompleted}
+	deps.repo.On("GetTranslationByID", uint64(5)).Return(completed, nil)
+	deps.repo.On("GetTranslationByID", uint64(6)).Return((*entity.Translation)(nil), nil)
+	deps.repo.On("GetTranslationByID", uint64(7)).Return(completed, nil)
+	deps.repo.On("DeleteTranslation", uint64(5)).Return(nil)
+	deps.repo.On("DeleteTranslation", uint64(7)).Return(errors.New("database is locked"))
+
+	assert.NoError(t, translationService.DeleteTranslation(5))
+	assert.ErrorIs(t, translationService.DeleteTranslation(6), ErrTranslationNotFound)
+	err := translationService.DeleteTranslation(7)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrTranslationNotFound)
+	// Completed translations were delivered and keep their charge
+	deps.billing.AssertNotCalled(t, "RefundTranslation", mock.Anything)
+}
+
+func TestDeleteTranslation_RefundsUnfinishedTranslation(t *testing.T) {
+	translationService, deps := setupTranslationService()
+
+	pending := &entity.Translation{ID: 5, Status: entity.TranslationStatusPending}
+	deps.repo.On("GetTranslationByID", uint64(5)).Return(pending, nil)
+	deps.billing.On("RefundTranslation", pending).Return(nil).Once()
+	deps.repo.On("DeleteTranslation", uint64(5)).Return(nil)
+	assert.NoError(t, translationService.DeleteTranslation(5))
+
+	// The translation is kept when its refund fails, so deleting it can be retried
+	processing := &entity.Translation{ID: 6, Status: entity.TranslationStatusProcessing}
+	deps.repo.On("GetTranslationByID", uint64(6)).Return(processing, nil)
+	deps.billing.On("RefundTranslation", processing).Return(errors.New("database is locked")).Once()
+	assert.Error(t, translationService.DeleteTranslation(6))
+	deps.repo.AssertNotCalled(t, "DeleteTranslation", uint64(6))
+	deps.billing.AssertExpectations(t)
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"mlvt/internal/entity"
+	"mlvt/internal/pkg/totp"
+	"mlvt/internal/repo"
+	"strings"
+	"time"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled       = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotEnrolled      = errors.New("two-factor enrollment has not been started")
+	ErrInvalidTwoFactorCode      = errors.New("invalid two-factor code")
+	ErrInvalidTwoFactorChallenge = errors.New("invalid or expired sign-in challenge")
+)
+
+const (
+	DefaultTOTPIssuer = "MLVT" // Name authenticator apps show next to the account
+	RecoveryCodeCount = 10     // Recovery codes issued at a time; each works once
+	TOTPSkew          = 1      // Codes of the previous and next period are accepted too
+	TOTPQRCodeSize    = 256    // Width and height of the enrollment QR code in pixels
+)
+
+// TwoFactorConfig controls how accounts appear in authenticator apps
+type TwoFactorConfig struct {
+	Issuer string // Shown in authenticator apps; defaults to DefaultTOTPIssuer
+}
+
+// withDefaults fills zero fields with the package defaults
+func (c TwoFactorConfig) withDefaults() TwoFactorConfig {
+	if c.Issuer == "" {
+		c.Issuer = DefaultTOTPIssuer
+	}
+	return c
+}
+
+// TwoFactorService turns TOTP two-factor authentication on and off and manages recovery codes.
+// Signing in with a second factor is handled by AuthService.
+type TwoFactorService interface {
+	Enroll(userID uint64) (*entity.TOTPEnrollment, error)
+	Enable(userID uint64, code string) ([]string, error)
+	Disable(userID uint64, code string) error
+	RegenerateRecoveryCodes(userID uint64, code string) ([]string, error)
+	CountRecoveryCodes(userID uint64) (int, error)
+}
+
+type twoFactorService struct {
+	userRepo      repo.UserRepository
+	twoFactorRepo repo.TwoFactorRepository
+	config        TwoFactorConfig
+	now           func() time.Time
+}
+
+func NewTwoFactorService(userRepo repo.UserRepository, twoFactorRepo repo.TwoFactorRepository, config TwoFactorConfig) TwoFactorService {
+	return &twoFactorService{
+		userRepo:      userRepo,
+		twoFactorRepo: twoFactorRepo,
+		config:        config.withDefaults(),
+		now:           time.Now,
+	}
+}
+
+// Enroll creates a new secret for the user to scan. It is not needed to sign in until Enable
+// confirms a code from it; enrolling again replaces the pending secret.
+func (s *twoFactorService) Enroll(userID uint64) (*entity.TOTPEnrollment, error) {
+	user, err := s.enabledUser(userID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.SetPendingTOTPSecret(user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	uri := totp.URI(s.config.Issuer, user.Email, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, TOTPQRCodeSize)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.TOTPEnrollment{Secret: secret, URI: uri, QRCode: png}, nil
+}
+
+// Enable turns on two-factor authentication once the user proves their app produces the right codes.
+// It returns the recovery codes, which are shown this once.
+func (s *twoFactorService) Enable(userID uint64, code string) ([]string, error) {
+	user, err := s.enabledUser(userID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := s.twoFactorRepo.GetTOTPSecret(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	step, ok := totp.Validate(secret, code, s.now(), TOTPSkew)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.EnableTOTP(user.ID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable turns off two-factor authentication; code is a current TOTP code or an unused recovery code
+func (s *twoFactorService) Disable(userID uint64, code string) error {
+	if err := s.confirm(userID, code); err != nil {
+		return err
+	}
+	return s.twoFactorRepo.DisableTOTP(userID)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes; code is a current TOTP code or an unused recovery code
+func (s *twoFactorService) RegenerateRecoveryCodes(userID uint64, code string) ([]string, error) {
+	if err := s.confirm(userID, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// CountRecoveryCodes returns how many unused recovery codes the user has left
+func (s *twoFactorService) CountRecoveryCodes(userID uint64) (int, error) {
+	if _, err := s.enabledUser(userID, true); err != nil {
+		return 0, err
+	}
+	return s.twoFactorRepo.CountRecoveryCodes(userID)
+}
+
+// confirm checks a second factor of a user who has two-factor authentication on
+func (s *twoFactorService) confirm(userID uint64, code string) error {
+	if _, err := s.enabledUser(userID, true); err != nil {
+		return err
+	}
+	ok, err := verifySecondFactor(s.twoFactorRepo, userID, code, s.now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// enabledUser loads a user and checks whether two-factor authentication is on as expected
+func (s *twoFactorService) enabledUser(userID uint64, enabled bool) (*entity.User, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if enabled && !user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if !enabled && user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	return user, nil
+}
+
+// verifySecondFactor accepts a TOTP code that was not used before or an unused recovery code, using it up
+func verifySecondFactor(twoFactorRepo repo.TwoFactorRepository, userID uint64, code string, now time.Time) (bool, error) {
+	secret, err := twoFactorRepo.GetTOTPSecret(userID)
+	if err != nil {
+		return false, err
+	}
+	if secret == "" {
+		return false, nil
+	}
+	if step, ok := totp.Validate(secret, code, now, TOTPSkew); ok {
+		return twoFactorRepo.UseTOTPStep(userID, step)
+	}
+	return twoFactorRepo.ConsumeRecoveryCode(userID, hashToken(normalizeRecoveryCode(code)))
+}
+
+// newRecoveryCodes returns RecoveryCodeCount codes formatted like "k3v9q-7xw2m" and their hashes
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		secret, err := totp.GenerateSecret()
+		if err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(secret[:10])
+		codes[i] = fmt.Sprintf("%s-%s", code[:5], code[5:])
+		hashes[i] = hashToken(code)
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode drops the separator, spaces and case users may type a recovery code with
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"mlvt/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTwoFactorService is a mock implementation of the TwoFactorService interface
+type MockTwoFactorService struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorService) Enroll(userID uint64) (*entity.TOTPEnrollment, error) {
+	args := m.Called(userID)
+	enrollment, _ := args.Get(0).(*entity.TOTPEnrollment)
+	return enrollment, args.Error(1)
+}
+
+func (m *MockTwoFactorService) Enable(userID uint64, code string) ([]string, error) {
+	args := m.Called(userID, code)
+	codes, _ := args.Get(0).([]string)
+	return codes, args.Error(1)
+}
+
+func (m *MockTwoFactorService) Disable(userID uint64, code string) error {
+	args := m.Called(userID, code)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorService) RegenerateRecoveryCodes(userID uint64, code string) ([]string, error) {
+	args := m.Called(userID, code)
+	codes, _ := args.Get(0).([]string)
+	return codes, args.Error(1)
+}
+
+func (m *MockTwoFactorService) CountRecoveryCodes(userID uint64) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/pkg/totp"
+	"mlvt/internal/repo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTwoFactorEnrollAndEnable(t *testing.T) {
+	mockUserRepo := new(repo.MockUserRepository)
+	mockTwoFactorRepo := new(repo.MockTwoFactorRepository)
+	twoFactorService := NewTwoFactorService(mockUserRepo, mockTwoFactorRepo, TwoFactorConfig{})
+
+	mockUserRepo.On("GetUserByID", uint64(1)).Return(&entity.User{ID: 1, Email: "jane@example.com"}, nil)
+	var secret string
+	mockTwoFactorRepo.On("SetPendingTOTPSecret", uint64(1), mock.Anything).Run(func(args mock.Arguments) {
+		secret = args.String(1)
+	}).Return(nil)
+
+	enrollment, err := twoFactorService.Enroll(1)
+	assert.NoError(t, err)
+	assert.Equal(t, secret, enrollment.Secret)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/"+DefaultTOTPIssuer)
+	assert.Equal(t, "\x89PNG", string(enrollment.QRCode[:4]))
+
+	mockTwoFactorRepo.On("GetTOTPSecret", uint64(1)).Return(secret, nil)
+	_, err = twoFactorService.Enable(1, "000000")
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+
+	now := time.Now()
+	code, _ := totp.Code(secret, totp.Step(now))
+	var hashes []string
+	mockTwoFactorRepo.On("EnableTOTP", uint64(1), totp.Step(now), mock.Anything).Run(func(args mock.Arguments) {
+		hashes = args.Get(2).([]string)
+	}).Return(nil)
+
+	codes, err := twoFactorService.Enable(1, code)
+	assert.NoError(t, err)
+	if assert.Len(t, codes, RecoveryCodeCount) {
+		// Only hashes are stored, of the code as typed without the separator
+		assert.Len(t, codes[0], 11)
+		assert.Equal(t, hashToken(normalizeRecoveryCode(codes[0])), hashes[0])
+		assert.Equal(t, hashToken(normalizeRecoveryCode(" "+codes[0]+" ")), hashes[0])
+	}
+}
+
+func TestTwoFactorEnroll_AlreadyEnabled(t *testing.T) {
+	mockUserRepo := new(repo.MockUserRepository)
+	mockTwoFactorRepo := new(repo.MockTwoFactorRepository)
+	twoFactorService := NewTwoFactorService(mockUserRepo, mockTwoFactorRepo, TwoFactorConfig{})
+
+	enabledAt := time.Now()
+	mockUserRepo.On("GetUserByID", uint64(1)).Return(&entity.User{ID: 1, TOTPEnabledAt: &enabledAt}, nil)
+
+	_, err := twoFactorService.Enroll(1)
+	assert.ErrorIs(t, err, ErrTwoFactorAlreadyEnabled)
+	mockTwoFactorRepo.AssertNotCalled(t, "SetPendingTOTPSecret", mock.Anything, mock.Anything)
+}
+
+func TestTwoFactorDisable_WithRecoveryCode(t *testing.T) {
+	mockUserRepo := new(repo.MockUserRepository)
+	mockTwoFactorRepo := new(repo.MockTwoFactorRepository)
+	twoFactorService := NewTwoFactorService(mockUserRepo, mockTwoFactorRepo, TwoFactorConfig{})
+
+	enabledAt := time.Now()
+	mockUserRepo.On("GetUserByID", uint64(1)).Return(&entity.User{ID: 1, TOTPEnabledAt: &enabledAt}, nil)
+	mockTwoFactorRepo.On("GetTOTPSecret", uint64(1)).Return("JBSWY3DPEHPK3PXP", nil)
+	mockTwoFactorRepo.On("ConsumeRecoveryCode", uint64(1), hashToken("abcdefghij")).Return(true, nil)
+	mockTwoFactorRepo.On("ConsumeRecoveryCode", uint64(1), mock.Anything).Return(false, nil)
+	mockTwoFactorRepo.On("DisableTOTP", uint64(1)).Return(nil)
+
+	assert.ErrorIs(t, twoFactorService.Disable(1, "wrong-code"), ErrInvalidTwoFactorCode)
+	assert.NoError(t, twoFactorService.Disable(1, "ABCDE-FGHIJ"))
+	mockTwoFactorRepo.AssertCalled(t, "DisableTOTP", uint64(1))
+}
@@ -0,0 +1,508 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/storage"
+	"mlvt/internal/infra/zap-logging/log"
+	"mlvt/internal/repo"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUploadNotFound    = errors.New("upload not found")
+	ErrInvalidUpload     = errors.New("invalid upload request")
+	ErrUploadExpired     = errors.New("upload has expired")
+	ErrUploadNotReceived = errors.New("uploaded file not found in storage")
+	ErrUploadMismatch    = errors.New("uploaded file does not match the upload request")
+	ErrUploadNotPending  = errors.New("upload is no longer pending")
+)
+
+// Default upload settings used when an UploadConfig field is left at zero
+const (
+	DefaultMaxUploadSize    int64 = 50 << 30 // 50 GiB; files over 5 GiB, the S3 limit for a single PUT, must be sent in parts
+	DefaultUploadPendingTTL       = 24 * time.Hour
+)
+
+// Multipart upload settings
+const (
+	DefaultUploadPartSize int64 = 64 << 20 // Part size used when the client does not choose one
+	MaxPresignedParts           = 100      // Most part URLs handed out by a single PresignUploadParts call
+)
+
+// UploadConfig limits direct-to-storage video uploads
+type UploadConfig struct {
+	MaxSize    int64         // Largest accepted file in bytes
+	PendingTTL time.Duration // How long an upload may stay unfinalized before it is garbage-collected
+}
+
+// withDefaults fills zero fields with the package defaults
+func (c UploadConfig) withDefaults() UploadConfig {
+	if c.MaxSize <= 0 {
+		c.MaxSize = DefaultMaxUploadSize
+	}
+	if c.PendingTTL <= 0 {
+		c.PendingTTL = DefaultUploadPendingTTL
+	}
+	return c
+}
+
+// UploadService registers video uploads that go straight to storage and creates the video
+// only after the stored object has been verified
+type UploadService interface {
+	CreateUpload(upload *entity.VideoUpload) (string, error) // Returns the presigned upload URL
+	CreateMultipartUpload(upload *entity.VideoUpload) error
+	GetUpload(uploadID uint64) (*entity.VideoUpload, error)
+	PresignUploadParts(uploadID uint64, partNumbers []int) ([]entity.UploadPart, error)
+	ListUploadParts(uploadID uint64) ([]entity.UploadPart, error)
+	FinalizeUpload(uploadID uint64) (*entity.Video, error)
+	AbortUpload(uploadID uint64) error
+	CleanupStaleUploads(now time.Time, limit int) (int, error)
+}
+
+type uploadService struct {
+	repo         repo.VideoUploadRepository
+	videoRepo    repo.VideoRepository
+	store        storage.Storage
+	probeService MediaProbeService
+	config       UploadConfig
+	now          func() time.Time
+}
+
+func NewUploadService(repo repo.VideoUploadRepository, videoRepo repo.VideoRepository, store storage.Storage,
+	probeService MediaProbeService, config UploadConfig) UploadService {
+	return &uploadService{
+		repo:         repo,
+		videoRepo:    videoRepo,
+		store:        store,
+		probeService: probeService,
+		config:       config.withDefaults(),
+		now:          time.Now,
+	}
+}
+
+// CreateUpload validates the declared file, stores a pending upload under a server-generated object name
+// and returns a presigned URL bound to the declared size, type and checksum.
+// upload.FileName is the client's file name; only its base name is kept.
+func (s *uploadService) CreateUpload(upload *entity.VideoUpload) (string, error) {
+	if err := s.validateUpload(upload); err != nil {
+		return "", err
+	}
+	if upload.Size > storage.MaxPartSize {
+		return "", fmt.Errorf("%w: files over %d bytes must be uploaded in parts", ErrInvalidUpload, storage.MaxPartSize)
+	}
+
+	objectName, err := uploadObjectName(upload.FileName)
+	if err != nil {
+		return "", err
+	}
+	upload.FileName = objectName
+	upload.Status = entity.UploadStatusPending
+	upload.ExpiresAt = s.now().Add(s.config.PendingTTL)
+
+	if err := s.repo.CreateUpload(upload); err != nil {
+		return "", err
+	}
+
+	opts := []storage.PresignOption{storage.WithContentLength(upload.Size)}
+	if upload.ChecksumSHA256 != "" {
+		opts = append(opts, storage.WithChecksumSHA256(upload.ChecksumSHA256))
+	}
+	return s.store.GeneratePresignedUploadURL(upload.Folder, upload.FileName, upload.ContentType, opts...)
+}
+
+// CreateMultipartUpload validates the declared file, starts an S3 multipart upload and stores a pending upload
+// that records it. upload.PartSize may be set by the client; when zero a part size is chosen from upload.Size.
+// Part URLs are handed out afterwards by PresignUploadParts.
+func (s *uploadService) CreateMultipartUpload(upload *entity.VideoUpload) error {
+	if err := s.validateUpload(upload); err != nil {
+		return err
+	}
+	if upload.ChecksumSHA256 != "" {
+		// S3 only reports a checksum of the part checksums for multipart objects
+		return fmt.Errorf("%w: checksum_sha256 is not supported for multipart uploads", ErrInvalidUpload)
+	}
+	partSize, err := choosePartSize(upload.Size, upload.PartSize)
+	if err != nil {
+		return err
+	}
+
+	objectName, err := uploadObjectName(upload.FileName)
+	if err != nil {
+		return err
+	}
+	multipartID, err := s.store.CreateMultipartUpload(upload.Folder, objectName, upload.ContentType)
+	if err != nil {
+		return err
+	}
+
+	upload.FileName = objectName
+	upload.MultipartID = multipartID
+	upload.PartSize = partSize
+	upload.Status = entity.UploadStatusPending
+	upload.ExpiresAt = s.now().Add(s.config.PendingTTL)
+
+	if err := s.repo.CreateUpload(upload); err != nil {
+		if abortErr := s.store.AbortMultipartUpload(upload.Folder, objectName, multipartID); abortErr != nil {
+			log.Warnf("Failed to abort multipart upload of %s: %v", objectName, abortErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// GetUpload returns an upload by ID
+func (s *uploadService) GetUpload(uploadID uint64) (*entity.VideoUpload, error) {
+	upload, err := s.repo.GetUploadByID(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if upload == nil {
+		return nil, ErrUploadNotFound
+	}
+	return upload, nil
+}
+
+// PresignUploadParts returns presigned URLs for the given one-based parts of a pending multipart upload.
+// Each URL only accepts a body of exactly the part's size.
+func (s *uploadService) PresignUploadParts(uploadID uint64, partNumbers []int) ([]entity.UploadPart, error) {
+	upload, err := s.pendingMultipartUpload(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(partNumbers) == 0:
+		return nil, fmt.Errorf("%w: part_numbers is required", ErrInvalidUpload)
+	case len(partNumbers) > MaxPresignedParts:
+		return nil, fmt.Errorf("%w: at most %d parts can be presigned at once", ErrInvalidUpload, MaxPresignedParts)
+	}
+
+	parts := make([]entity.UploadPart, 0, len(partNumbers))
+	for _, partNumber := range partNumbers {
+		if partNumber < 1 || partNumber > upload.PartCount() {
+			return nil, fmt.Errorf("%w: part number must be between 1 and %d", ErrInvalidUpload, upload.PartCount())
+		}
+		size := upload.PartLength(partNumber)
+		url, err := s.store.GeneratePresignedUploadPartURL(upload.Folder, upload.FileName, upload.MultipartID,
+			int32(partNumber), storage.WithContentLength(size))
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, entity.UploadPart{PartNumber: partNumber, Size: size, UploadURL: url})
+	}
+	return parts, nil
+}
+
+// ListUploadParts returns the parts storage has received so far for a pending multipart upload,
+// so an interrupted client can resume with the missing ones
+func (s *uploadService) ListUploadParts(uploadID uint64) ([]entity.UploadPart, error) {
+	upload, err := s.pendingMultipartUpload(uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	uploaded, err := s.store.ListUploadedParts(upload.Folder, upload.FileName, upload.MultipartID)
+	if errors.Is(err, storage.ErrMultipartUploadNotFound) {
+		// Removed by a bucket lifecycle rule before the upload expired here
+		return nil, ErrUploadExpired
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make([]entity.UploadPart, 0, len(uploaded))
+	for _, part := range uploaded {
+		parts = append(parts, entity.UploadPart{PartNumber: int(part.PartNumber), Size: part.Size, ETag: part.ETag})
+	}
+	return parts, nil
+}
+
+// FinalizeUpload checks the stored object against the upload, creates the video and measures its file,
+// replacing the duration declared by the client. Finalizing a completed upload again returns the video
+// created the first time.
+func (s *uploadService) FinalizeUpload(uploadID uint64) (*entity.Video, error) {
+	upload, err := s.GetUpload(uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case upload.Status == entity.UploadStatusCompleted:
+		return s.uploadedVideo(upload)
+	case upload.Status == entity.UploadStatusAborted:
+		return nil, ErrUploadNotPending
+	case upload.Status == entity.UploadStatusExpired, !s.now().Before(upload.ExpiresAt):
+		return nil, ErrUploadExpired
+	}
+
+	if upload.IsMultipart() {
+		if err := s.completeMultipartUpload(upload); err != nil {
+			return nil, err
+		}
+	}
+
+	info, err := s.store.HeadObject(upload.Folder, upload.FileName)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil, ErrUploadNotReceived
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyUploadedObject(upload, info); err != nil {
+		return nil, err
+	}
+
+	video := &entity.Video{
+		Title:       upload.Title,
+		Duration:    upload.Duration,
+		Description: upload.Description,
+		FileName:    upload.FileName,
+		Folder:      upload.Folder,
+		Image:       upload.Image,
+		Status:      entity.StatusRaw,
+		UserID:      upload.UserID,
+	}
+	err = s.repo.CompleteUpload(upload.ID, video)
+	if errors.Is(err, repo.ErrUploadNotPending) {
+		// A concurrent request finalized or expired the upload first
+		return s.FinalizeUpload(uploadID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	probeNewVideo(s.probeService, video)
+	return video, nil
+}
+
+// AbortUpload cancels a pending upload and removes whatever storage has received for it
+func (s *uploadService) AbortUpload(uploadID uint64) error {
+	upload, err := s.GetUpload(uploadID)
+	if err != nil {
+		return err
+	}
+	if upload.Status != entity.UploadStatusPending {
+		return ErrUploadNotPending
+	}
+
+	if err := s.removeUploadedData(upload); err != nil {
+		return err
+	}
+	err = s.repo.CloseUpload(upload.ID, entity.UploadStatusAborted)
+	if errors.Is(err, repo.ErrUploadNotPending) {
+		log.Warnf("Upload %d was finalized while being aborted", upload.ID)
+		return ErrUploadNotPending
+	}
+	return err
+}
+
+// CleanupStaleUploads removes the objects of up to limit pending uploads that were never finalized
+// and marks them expired. It returns the number of uploads expired.
+func (s *uploadService) CleanupStaleUploads(now time.Time, limit int) (int, error) {
+	uploads, err := s.repo.ListExpiredUploads(now, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	var errs []error
+	for _, upload := range uploads {
+		if err := s.removeUploadedData(&upload); err != nil {
+			errs = append(errs, fmt.Errorf("upload %d: %w", upload.ID, err))
+			continue
+		}
+		err := s.repo.CloseUpload(upload.ID, entity.UploadStatusExpired)
+		if errors.Is(err, repo.ErrUploadNotPending) {
+			// Finalized while its object was being deleted; the video now points at a missing file
+			log.Warnf("Upload %d was finalized during cleanup", upload.ID)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("upload %d: %w", upload.ID, err))
+			continue
+		}
+		expired++
+	}
+	return expired, errors.Join(errs...)
+}
+
+// pendingMultipartUpload returns an upload that can still receive parts
+func (s *uploadService) pendingMultipartUpload(uploadID uint64) (*entity.VideoUpload, error) {
+	upload, err := s.GetUpload(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case !upload.IsMultipart():
+		return nil, fmt.Errorf("%w: upload %d is not a multipart upload", ErrInvalidUpload, uploadID)
+	case upload.Status == entity.UploadStatusExpired,
+		upload.Status == entity.UploadStatusPending && !s.now().Before(upload.ExpiresAt):
+		return nil, ErrUploadExpired
+	case upload.Status != entity.UploadStatusPending:
+		return nil, ErrUploadNotPending
+	}
+	return upload, nil
+}
+
+// completeMultipartUpload checks that every part arrived with the expected size and assembles the object.
+// If storage no longer knows the multipart upload, an earlier attempt already completed it and the
+// object check that follows decides.
+func (s *uploadService) completeMultipartUpload(upload *entity.VideoUpload) error {
+	uploaded, err := s.store.ListUploadedParts(upload.Folder, upload.FileName, upload.MultipartID)
+	if errors.Is(err, storage.ErrMultipartUploadNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	parts, err := verifyUploadedParts(upload, uploaded)
+	if err != nil {
+		return err
+	}
+	err = s.store.CompleteMultipartUpload(upload.Folder, upload.FileName, upload.MultipartID, parts)
+	if errors.Is(err, storage.ErrMultipartUploadNotFound) {
+		return nil
+	}
+	return err
+}
+
+// removeUploadedData discards the parts and the object of an upload
+func (s *uploadService) removeUploadedData(upload *entity.VideoUpload) error {
+	if upload.IsMultipart() {
+		if err := s.store.AbortMultipartUpload(upload.Folder, upload.FileName, upload.MultipartID); err != nil {
+			return err
+		}
+	}
+	// A multipart upload may have been assembled by a finalize attempt that failed afterwards
+	return s.store.DeleteObject(upload.Folder, upload.FileName)
+}
+
+func (s *uploadService) uploadedVideo(upload *entity.VideoUpload) (*entity.Video, error) {
+	if upload.VideoID == nil {
+		return nil, ErrVideoNotFound
+	}
+	video, err := s.videoRepo.GetVideoByID(*upload.VideoID)
+	if err != nil {
+		return nil, err
+	}
+	if video == nil {
+		return nil, ErrVideoNotFound
+	}
+	return video, nil
+}
+
+func (s *uploadService) validateUpload(upload *entity.VideoUpload) error {
+	switch {
+	case strings.TrimSpace(upload.Title) == "":
+		return fmt.Errorf("%w: title is required", ErrInvalidUpload)
+	case upload.Size <= 0:
+		return fmt.Errorf("%w: size must be positive", ErrInvalidUpload)
+	case upload.Size > s.config.MaxSize:
+		return fmt.Errorf("%w: size exceeds the limit of %d bytes", ErrInvalidUpload, s.config.MaxSize)
+	case !strings.HasPrefix(mediaType(upload.ContentType), "video/"):
+		return fmt.Errorf("%w: content type must be a video type", ErrInvalidUpload)
+	}
+	if upload.ChecksumSHA256 != "" {
+		sum, err := base64.StdEncoding.DecodeString(upload.ChecksumSHA256)
+		if err != nil || len(sum) != 32 {
+			return fmt.Errorf("%w: checksum_sha256 must be a base64 SHA-256 digest", ErrInvalidUpload)
+		}
+	}
+	return nil
+}
+
+// verifyUploadedParts checks that parts 1..PartCount all arrived with their expected sizes
+// and returns them in part number order
+func verifyUploadedParts(upload *entity.VideoUpload, uploaded []storage.UploadedPart) ([]storage.UploadedPart, error) {
+	byNumber := make(map[int]storage.UploadedPart, len(uploaded))
+	for _, part := range uploaded {
+		partNumber := int(part.PartNumber)
+		if partNumber < 1 || partNumber > upload.PartCount() {
+			return nil, fmt.Errorf("%w: unexpected part %d", ErrUploadMismatch, partNumber)
+		}
+		if part.Size != upload.PartLength(partNumber) {
+			return nil, fmt.Errorf("%w: part %d is %d bytes, expected %d", ErrUploadMismatch,
+				partNumber, part.Size, upload.PartLength(partNumber))
+		}
+		byNumber[partNumber] = part
+	}
+
+	var missing []int
+	parts := make([]storage.UploadedPart, 0, upload.PartCount())
+	for partNumber := 1; partNumber <= upload.PartCount(); partNumber++ {
+		part, ok := byNumber[partNumber]
+		if !ok {
+			missing = append(missing, partNumber)
+			continue
+		}
+		parts = append(parts, part)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %d of %d parts missing, first is part %d", ErrUploadNotReceived,
+			len(missing), upload.PartCount(), missing[0])
+	}
+	return parts, nil
+}
+
+// verifyUploadedObject compares the stored object with what the client declared
+func verifyUploadedObject(upload *entity.VideoUpload, info *storage.ObjectInfo) error {
+	if info.Size != upload.Size {
+		return fmt.Errorf("%w: size is %d bytes, expected %d", ErrUploadMismatch, info.Size, upload.Size)
+	}
+	if !strings.EqualFold(mediaType(info.ContentType), mediaType(upload.ContentType)) {
+		return fmt.Errorf("%w: content type is %q, expected %q", ErrUploadMismatch, info.ContentType, upload.ContentType)
+	}
+	if upload.ChecksumSHA256 != "" && info.ChecksumSHA256 != upload.ChecksumSHA256 {
+		return fmt.Errorf("%w: checksum does not match", ErrUploadMismatch)
+	}
+	return nil
+}
+
+// choosePartSize validates the part size requested for a file, or picks one when requested is zero.
+// The default doubles until the file fits in the S3 limit of 10,000 parts.
+func choosePartSize(size, requested int64) (int64, error) {
+	partSize := requested
+	if partSize == 0 {
+		partSize = DefaultUploadPartSize
+		for partSize < storage.MaxPartSize && size > partSize*storage.MaxParts {
+			partSize *= 2
+		}
+	}
+	switch {
+	case partSize < storage.MinPartSize || partSize > storage.MaxPartSize:
+		return 0, fmt.Errorf("%w: part_size must be between %d and %d bytes", ErrInvalidUpload, storage.MinPartSize, storage.MaxPartSize)
+	case size > partSize*storage.MaxParts:
+		return 0, fmt.Errorf("%w: part_size is too small to send the file in %d parts", ErrInvalidUpload, storage.MaxParts)
+	}
+	return partSize, nil
+}
+
+// mediaType strips parameters such as charset from a content type
+func mediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mediaType
+}
+
+// uploadObjectName makes an unguessable, collision-free object name that keeps the client's file name readable
+func uploadObjectName(fileName string) (string, error) {
+	base := path.Base(strings.ReplaceAll(fileName, "\\", "/"))
+	if base == "." || base == "/" || base == "" {
+		return "", fmt.Errorf("%w: file_name is required", ErrInvalidUpload)
+	}
+
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(random) + "-" + base, nil
+}
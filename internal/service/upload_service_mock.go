@@ -0,0 +1,57 @@
+package service
+
+import (
+	"mlvt/internal/entity"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockUploadService is a mock implementation of the UploadService interface
+type MockUploadService struct {
+	mock.Mock
+}
+
+func (m *MockUploadService) CreateUpload(upload *entity.VideoUpload) (string, error) {
+	args := m.Called(upload)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUploadService) CreateMultipartUpload(upload *entity.VideoUpload) error {
+	args := m.Called(upload)
+	return args.Error(0)
+}
+
+func (m *MockUploadService) GetUpload(uploadID uint64) (*entity.VideoUpload, error) {
+	args := m.Called(uploadID)
+	upload, _ := args.Get(0).(*entity.VideoUpload)
+	return upload, args.Error(1)
+}
+
+func (m *MockUploadService) PresignUploadParts(uploadID uint64, partNumbers []int) ([]entity.UploadPart, error) {
+	args := m.Called(uploadID, partNumbers)
+	parts, _ := args.Get(0).([]entity.UploadPart)
+	return parts, args.Error(1)
+}
+
+func (m *MockUploadService) ListUploadParts(uploadID uint64) ([]entity.UploadPart, error) {
+	args := m.Called(uploadID)
+	parts, _ := args.Get(0).([]entity.UploadPart)
+	return parts, args.Error(1)
+}
+
+func (m *MockUploadService) FinalizeUpload(uploadID uint64) (*entity.Video, error) {
+	args := m.Called(uploadID)
+	video, _ := args.Get(0).(*entity.Video)
+	return video, args.Error(1)
+}
+
+func (m *MockUploadService) AbortUpload(uploadID uint64) error {
+	args := m.Called(uploadID)
+	return args.Error(0)
+}
+
+func (m *MockUploadService) CleanupStaleUploads(now time.Time, limit int) (int, error) {
+	args := m.Called(now, limit)
+	return args.Int(0), args.Error(1)
+}
@@ -0,0 +1,370 @@
+package service
+
+import (
+	"errors"
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/storage"
+	"mlvt/internal/repo"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var uploadTestNow = time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+
+const testChecksum = "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+
+type uploadTestDeps struct {
+	repo      *repo.MockVideoUploadRepository
+	videoRepo *repo.MockVideoRepository
+	s3Client  *storage.MockStorage
+	probe     *MockMediaProbeService
+}
+
+func setupUploadService() (*uploadService, uploadTestDeps) {
+	deps := uploadTestDeps{
+		repo:      new(repo.MockVideoUploadRepository),
+		videoRepo: new(repo.MockVideoRepository),
+		s3Client:  new(storage.MockStorage),
+		probe:     new(MockMediaProbeService),
+	}
+	s := NewUploadService(deps.repo, deps.videoRepo, deps.s3Client, deps.probe, UploadConfig{MaxSize: 1 << 20}).(*uploadService)
+	s.now = func() time.Time { return uploadTestNow }
+	return s, deps
+}
+
+func pendingUpload() *entity.VideoUpload {
+	return &entity.VideoUpload{
+		ID: 1, UserID: 3, Title: "Clip", Folder: "videos", FileName: "abc-clip.mp4", ContentType: "video/mp4",
+		Size: 1024, ChecksumSHA256: testChecksum, Status: entity.UploadStatusPending, ExpiresAt: uploadTestNow.Add(time.Hour),
+	}
+}
+
+func TestCreateUpload(t *testing.T) {
+	uploadService, deps := setupUploadService()
+
+	deps.repo.On("CreateUpload", mock.AnythingOfType("*entity.VideoUpload")).Return(nil)
+	deps.s3Client.On("GeneratePresignedUploadURL", "videos", mock.MatchedBy(func(name string) bool {
+		return strings.HasSuffix(name, "-clip.mp4") && len(name) == 32+len("-clip.mp4")
+	}), "video/mp4").Return("https://upload", nil)
+
+	upload := &entity.VideoUpload{UserID: 3, Title: "Clip", Folder: "videos", FileName: "../../clip.mp4", ContentType: "video/mp4", Size: 1024}
+	url, err := uploadService.CreateUpload(upload)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://upload", url)
+	assert.Equal(t, entity.UploadStatusPending, upload.Status)
+	assert.Equal(t, uploadTestNow.Add(DefaultUploadPendingTTL), upload.ExpiresAt)
+	deps.s3Client.AssertExpectations(t)
+}
+
+func TestCreateUpload_Invalid(t *testing.T) {
+	uploadService, deps := setupUploadService()
+
+	for _, upload := range []entity.VideoUpload{
+		{FileName: "a.mp4", ContentType: "video/mp4", Size: 1},
+		{Title: "t", FileName: "a.mp4", ContentType: "video/mp4", Size: 0},
+		{Title: "t", FileName: "a.mp4", ContentType: "video/mp4", Size: 2 << 20},
+		{Title: "t", FileName: "a.pdf", ContentType: "application/pdf", Size: 1},
+		{Title: "t", FileName: "a.mp4", ContentType: "video/mp4", Size: 1, ChecksumSHA256: "not-base64"},
+		{Title: "t", FileName: "", ContentType: "video/mp4", Size: 1},
+	} {
+		_, err := uploadService.CreateUpload(&upload)
+		assert.True(t, errors.Is(err, ErrInvalidUpload), upload)
+	}
+	deps.repo.AssertNotCalled(t, "CreateUpload", mock.Anything)
+}
+
+func TestFinalizeUpload(t *testing.T) {
+	uploadService, deps := setupUploadService()
+
+	deps.repo.On("GetUploadByID", uint64(1)).Return(pendingUpload(), nil)
+	deps.s3Client.On("HeadObject", "videos", "abc-clip.mp4").Return(&storage.ObjectInfo{Size: 1024, ContentType: "video/mp4", ChecksumSHA256: testChecksum}, nil)
+	deps.repo.On("CompleteUpload", uint64(1), mock.MatchedBy(func(video *entity.Video) bool {
+		return video.FileName == "abc-clip.mp4" && video.UserID == 3 && video.Status == entity.StatusRaw
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*entity.Video).ID = 9
+	}).Return(nil)
+	deps.probe.On("ProbeVideo", mock.Anything, uint64(9)).
+		Return(&entity.Video{ID: 9, Duration: 61, MediaInfo: entity.MediaInfo{VideoCodec: "h264"}}, nil)
+
+	// The duration is measured from the file, whatever the client declared
+	video, err := uploadService.FinalizeUpload(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Clip", video.Title)
+	assert.Equal(t, 61, video.Duration)
+	assert.Equal(t, "h264", video.VideoCodec)
+	deps.repo.AssertExpectations(t)
+}
+
+func TestFinalizeUpload_ProbeFailureKeepsVideo(t *testing.T) {
+	uploadService, deps := setupUploadService()
+
+	upload := pendingUpload()
+	upload.Duration = 30
+	deps.repo.On("GetUploadByID", uint64(1)).Return(upload, nil)
+	deps.s3Client.On("HeadObject", "videos", "abc-clip.mp4").Return(&storage.ObjectInfo{Size: 1024, ContentType: "video/mp4", ChecksumSHA256: testChecksum}, nil)
+	deps.repo.On("CompleteUpload", uint64(1), mock.AnythingOfType("*entity.Video")).Return(nil)
+	deps.probe.On("ProbeVideo", mock.Anything, mock.Anything).Return(nil, ErrMediaProbeUnavailable)
+
+	video, err := uploadService.FinalizeUpload(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 30, video.Duration)
+	assert.Nil(t, video.ProbedAt)
+}
+
+func TestFinalizeUpload_Rejected(t *testing.T) {
+	cases := []struct {
+		name     string
+		info     *storage.ObjectInfo
+		headErr  error
+		expected error
+	}{
+		{"missing", nil, storage.ErrObjectNotFound, ErrUploadNotReceived},
+		{"size", &storage.ObjectInfo{Size: 10, ContentType: "video/mp4", ChecksumSHA256: testChecksum}, nil, ErrUploadMismatch},
+		{"type", &storage.ObjectInfo{Size: 1024, ContentType: "image/png", ChecksumSHA256: testChecksum}, nil, ErrUploadMismatch},
+		{"checksum", &storage.ObjectInfo{Size: 1024, ContentType: "video/mp4"}, nil, ErrUploadMismatch},
+	}
+	for _, tc := range cases {
+		uploadService, deps := setupUploadService()
+		deps.repo.On("GetUploadByID", uint64(1)).Return(pendingUpload(), nil)
+		deps.s3Client.On("HeadObject", "videos", "abc-clip.mp4").Return(tc.info, tc.headErr)
+
+		_, err := uploadService.FinalizeUpload(1)
+		assert.True(t, errors.Is(err, tc.expected), tc.name)
+		deps.repo.AssertNotCalled(t, "CompleteUpload", mock.Anything, mock.Anything)
+	}
+}
+
+func TestFinalizeUpload_ExpiredAndCompleted(t *testing.T) {
+	uploadService, deps := setupUploadService()
+
+	expired := pendingUpload()
+	expired.ExpiresAt = uploadTestNow.Add(-time.Second)
+	deps.repo.On("GetUploadByID", uint64(1)).Return(expired, nil)
+
+	_, err := uploadService.FinalizeUpload(1)
+	assert.True(t, errors.Is(err, ErrUploadExpired))
+
+	videoID := uint64(9)
+	completed := pendingUpload()
+	completed.ID = 2
+	completed.Status = entity.UploadStatusCompleted
+	completed.VideoID = &videoID
+	deps.repo.On("GetUploadByID", uint64(2)).Return(completed, nil)
+	deps.videoRepo.On("GetVideoByID", uint64(9)).Return(&entity.Video{ID: 9}, nil)
+
+	// Finalizing twice returns the same video without another storage check
+	video, err := uploadService.FinalizeUpload(2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(9), video.ID)
+	deps.s3Client.AssertNotCalled(t, "HeadObject", mock.Anything, mock.Anything)
+
+	deps.repo.On("GetUploadByID", uint64(3)).Return(nil, nil)
+	_, err = uploadService.FinalizeUpload(3)
+	assert.True(t, errors.Is(err, ErrUploadNotFound))
+}
+
+func TestCleanupStaleUploads(t *testing.T) {
+	uploadService, deps := setupUploadService()
+
+	deps.repo.On("ListExpiredUploads", uploadTestNow, 10).Return([]entity.VideoUpload{
+		{ID: 1, Folder: "videos", FileName: "a.mp4"},
+		{ID: 2, Folder: "videos", FileName: "b.mp4"},
+		{ID: 3, Folder: "videos", FileName: "c.mp4"},
+	}, nil)
+	deps.s3Client.On("DeleteObject", "videos", "a.mp4").Return(nil)
+	deps.s3Client.On("DeleteObject", "videos", "b.mp4").Return(errors.New("storage down"))
+	deps.s3Client.On("DeleteObject", "videos", "c.mp4").Return(nil)
+	deps.repo.On("CloseUpload", uint64(1), entity.UploadStatusExpired).Return(nil)
+	deps.repo.On("CloseUpload", uint64(3), entity.UploadStatusExpired).Return(repo.ErrUploadNotPending)
+
+	count, err := uploadService.CleanupStaleUploads(uploadTestNow, 10)
+	assert.Equal(t, 1, count)
+	assert.ErrorContains(t, err, "upload 2")
+	deps.repo.AssertNotCalled(t, "CloseUpload", uint64(2), mock.Anything)
+}
+
+func pendingMultipartUpload() *entity.VideoUpload {
+	upload := pendingUpload()
+	upload.ChecksumSHA256 = ""
+	upload.Size = 12 << 20
+	upload.MultipartID = "mp-1"
+	upload.PartSize = 5 << 20
+	return upload
+}
+
+func TestCreateUpload_TooLargeForSinglePut(t *testing.T) {
+	uploadService, _ := setupUploadService()
+	uploadService.config.MaxSize = 10 << 30
+
+	upload := &entity.VideoUpload{Title: "t", FileName: "a.mp4", ContentType: "video/mp4", Size: 6 << 30}
+	_, err := uploadService.CreateUpload(upload)
+	assert.True(t, errors.Is(err, ErrInvalidUpload))
+}
+
+func TestCreateMultipartUpload(t *testing.T) {
+	uploadService, deps := setupUploadService()
+	uploadService.config.MaxSize = 1 << 40
+
+	deps.s3Client.On("CreateMultipartUpload", "videos", mock.AnythingOfType("string"), "video/mp4").Return("mp-1", nil)
+	deps.repo.On("CreateUpload", mock.AnythingOfType("*entity.VideoUpload")).Return(nil)
+
+	// 1 TiB does not fit in 10,000 parts of the default size, so the part size grows
+	upload := &entity.VideoUpload{UserID: 3, Title: "Clip", Folder: "videos", FileName: "clip.mp4", ContentType: "video/mp4", Size: 1 << 40}
+	assert.NoError(t, uploadService.CreateMultipartUpload(upload))
+	assert.Equal(t, "mp-1", upload.MultipartID)
+	assert.Equal(t, int64(128<<20), upload.PartSize)
+	assert.Equal(t, 8192, upload.PartCount())
+	assert.Equal(t, entity.UploadStatusPending, upload.Status)
+}
+
+func TestCreateMultipartUpload_Invalid(t *testing.T) {
+	uploadService, deps := setupUploadService()
+
+	for _, upload := range []entity.VideoUpload{
+		{Title: "t", FileName: "a.mp4", ContentType: "video/mp4", Size: 1, ChecksumSHA256: testChecksum},
+		{Title: "t", FileName: "a.mp4", ContentType: "video/mp4", Size: 1, PartSize: 1 << 20},
+		{Title: "t", FileName: "a.mp4", ContentType: "video/mp4", Size: 1, PartSize: 6 << 30},
+	} {
+		assert.True(t, errors.Is(uploadService.CreateMultipartUpload(&upload), ErrInvalidUpload), upload)
+	}
+	deps.s3Client.AssertNotCalled(t, "CreateMultipartUpload", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateMultipartUpload_AbortsWhenNotStored(t *testing.T) {
+	uploadService, deps := setupUploadService()
+
+	deps.s3Client.On("CreateMultipartUpload", "videos", mock.AnythingOfType("string"), "video/mp4").Return("mp-1", nil)
+	deps.repo.On("CreateUpload", mock.AnythingOfType("*entity.VideoUpload")).Return(errors.New("db down"))
+	deps.s3Client.On("AbortMultipartUpload", "videos", mock.AnythingOfType("string"), "mp-1").Return(nil)
+
+	upload := &entity.VideoUpload{Title: "t", Folder: "videos", FileName: "a.mp4", ContentType: "video/mp4", Size: 1}
+	assert.Error(t, uploadService.CreateMultipartUpload(upload))
+	deps.s3Client.AssertExpectations(t)
+}
+
+func TestPresignUploadParts(t *testing.T) {
+	uploadService, deps := setupUploadService()
+
+	deps.repo.On("GetUploadByID", uint64(1)).Return(pendingMultipartUpload(), nil)
+	deps.s3Client.On("GeneratePresignedUploadPartURL", "videos", "abc-clip.mp4", "mp-1", int32(1)).Return("https://part1", nil)
+	deps.s3Client.On("GeneratePresignedUploadPartURL", "videos", "abc-clip.mp4", "mp-1", int32(3)).Return("https://part3", nil)
+
+	parts, err := uploadService.PresignUploadParts(1, []int{1, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.UploadPart{
+		{PartNumber: 1, Size: 5 << 20, UploadURL: "https://part1"},
+		{PartNumber: 3, Size: 2 << 20, UploadURL: "https://part3"},
+	}, parts)
+
+	for _, partNumbers := range [][]int{nil, {0}, {4}, make([]int, MaxPresignedParts+1)} {
+		_, err = uploadService.PresignUploadParts(1, partNumbers)
+		assert.True(t, errors.Is(err, ErrInvalidUpload), partNumbers)
+	}
+
+	// Single-part uploads have no parts to presign
+	deps.repo.On("GetUploadByID", uint64(2)).Return(pendingUpload(), nil)
+	_, err = uploadService.PresignUploadParts(2, []int{1})
+	assert.True(t, errors.Is(err, ErrInvalidUpload))
+}
+
+func TestListUploadParts(t *testing.T) {
+	uploadService, deps := setupUploadService()
+
+	deps.repo.On("GetUploadByID", uint64(1)).Return(pendingMultipartUpload(), nil)
+	deps.s3Client.On("ListUploadedParts", "videos", "abc-clip.mp4", "mp-1").Return([]storage.UploadedPart{
+		{PartNumber: 2, ETag: `"b"`, Size: 5 << 20},
+	}, nil)
+
+	parts, err := uploadService.ListUploadParts(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.UploadPart{{PartNumber: 2, Size: 5 << 20, ETag: `"b"`}}, parts)
+
+	completed := pendingMultipartUpload()
+	completed.Status = entity.UploadStatusCompleted
+	deps.repo.On("GetUploadByID", uint64(2)).Return(completed, nil)
+	_, err = uploadService.ListUploadParts(2)
+	assert.True(t, errors.Is(err, ErrUploadNotPending))
+}
+
+func TestFinalizeMultipartUpload(t *testing.T) {
+	uploadService, deps := setupUploadService()
+
+	uploaded := []storage.UploadedPart{
+		{PartNumber: 1, ETag: `"a"`, Size: 5 << 20},
+		{PartNumber: 2, ETag: `"b"`, Size: 5 << 20},
+		{PartNumber: 3, ETag: `"c"`, Size: 2 << 20},
+	}
+	deps.repo.On("GetUploadByID", uint64(1)).Return(pendingMultipartUpload(), nil)
+	deps.s3Client.On("ListUploadedParts", "videos", "abc-clip.mp4", "mp-1").Return(uploaded, nil)
+	deps.s3Client.On("CompleteMultipartUpload", "videos", "abc-clip.mp4", "mp-1", uploaded).Return(nil)
+	deps.s3Client.On("HeadObject", "videos", "abc-clip.mp4").Return(&storage.ObjectInfo{Size: 12 << 20, ContentType: "video/mp4"}, nil)
+	deps.repo.On("CompleteUpload", uint64(1), mock.AnythingOfType("*entity.Video")).Return(nil)
+	deps.probe.On("ProbeVideo", mock.Anything, mock.Anything).Return(nil, ErrMediaProbeUnavailable)
+
+	video, err := uploadService.FinalizeUpload(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc-clip.mp4", video.FileName)
+	deps.s3Client.AssertExpectations(t)
+}
+
+func TestFinalizeMultipartUpload_Rejected(t *testing.T) {
+	cases := []struct {
+		name     string
+		uploaded []storage.UploadedPart
+		expected error
+	}{
+		{"missing", []storage.UploadedPart{{PartNumber: 1, Size: 5 << 20}, {PartNumber: 3, Size: 2 << 20}}, ErrUploadNotReceived},
+		{"size", []storage.UploadedPart{{PartNumber: 1, Size: 5 << 20}, {PartNumber: 2, Size: 1}, {PartNumber: 3, Size: 2 << 20}}, ErrUploadMismatch},
+		{"extra", []storage.UploadedPart{{PartNumber: 4, Size: 1}}, ErrUploadMismatch},
+	}
+	for _, tc := range cases {
+		uploadService, deps := setupUploadService()
+		deps.repo.On("GetUploadByID", uint64(1)).Return(pendingMultipartUpload(), nil)
+		deps.s3Client.On("ListUploadedParts", "videos", "abc-clip.mp4", "mp-1").Return(tc.uploaded, nil)
+
+		_, err := uploadService.FinalizeUpload(1)
+		assert.True(t, errors.Is(err, tc.expected), tc.name)
+		deps.s3Client.AssertNotCalled(t, "CompleteMultipartUpload", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	}
+}
+
+func TestFinalizeMultipartUpload_AlreadyAssembled(t *testing.T) {
+	uploadService, deps := setupUploadService()
+
+	// An earlier attempt completed the multipart upload but failed before creating the video
+	deps.repo.On("GetUploadByID", uint64(1)).Return(pendingMultipartUpload(), nil)
+	deps.s3Client.On("ListUploadedParts", "videos", "abc-clip.mp4", "mp-1").Return(nil, storage.ErrMultipartUploadNotFound)
+	deps.s3Client.On("HeadObject", "videos", "abc-clip.mp4").Return(&storage.ObjectInfo{Size: 12 << 20, ContentType: "video/mp4"}, nil)
+	deps.repo.On("CompleteUpload", uint64(1), mock.AnythingOfType("*entity.Video")).Return(nil)
+	deps.probe.On("ProbeVideo", mock.Anything, mock.Anything).Return(nil, ErrMediaProbeUnavailable)
+
+	_, err := uploadService.FinalizeUpload(1)
+	assert.NoError(t, err)
+}
+
+func TestAbortUpload(t *testing.T) {
+	uploadService, deps := setupUploadService()
+
+	deps.repo.On("GetUploadByID", uint64(1)).Return(pendingMultipartUpload(), nil)
+	deps.s3Client.On("AbortMultipartUpload", "videos", "abc-clip.mp4", "mp-1").Return(nil)
+	deps.s3Client.On("DeleteObject", "videos", "abc-clip.mp4").Return(nil)
+	deps.repo.On("CloseUpload", uint64(1), entity.UploadStatusAborted).Return(nil)
+
+	assert.NoError(t, uploadService.AbortUpload(1))
+	deps.repo.AssertExpectations(t)
+
+	completed := pendingUpload()
+	completed.Status = entity.UploadStatusCompleted
+	deps.repo.On("GetUploadByID", uint64(2)).Return(completed, nil)
+	assert.True(t, errors.Is(uploadService.AbortUpload(2), ErrUploadNotPending))
+
+	aborted := pendingUpload()
+	aborted.Status = entity.UploadStatusAborted
+	deps.repo.On("GetUploadByID", uint64(3)).Return(aborted, nil)
+	_, err := uploadService.FinalizeUpload(3)
+	assert.True(t, errors.Is(err, ErrUploadNotPending))
+}
@@ -3,7 +3,7 @@ package service
 import (
 	"errors"
 	"mlvt/internal/entity"
-	"mlvt/internal/infra/aws"
+	"mlvt/internal/infra/storage"
 	"mlvt/internal/repo"
 	"time"
 
@@ -12,9 +12,12 @@ import (
 
 type UserService interface {
 	RegisterUser(user *entity.User) error
-	Login(email, password string) (string, uint64, error)
+	Login(email, password, clientIP string) (*entity.AuthTokens, error)
+	CompleteTwoFactorLogin(challengeToken, code, clientIP string) (*entity.AuthTokens, error)
+	RefreshToken(refreshToken string) (*entity.AuthTokens, error)
+	Logout(refreshToken string, allSessions bool) error
 	ChangePassword(userID uint64, oldPassword, newPassword string) error
-	UpdateUser(user *entity.User) error
+	UpdateUser(user *entity.User) (bool, error)
 	UpdateAvatar(userID uint64, avatarPath, avatarFolder string) error
 	GetUserByID(userID uint64) (*entity.User, error)
 	GetAllUsers() ([]entity.User, error)
@@ -24,16 +27,16 @@ type UserService interface {
 }
 
 type userService struct {
-	repo     repo.UserRepository
-	s3Client aws.S3ClientInterface
-	auth     AuthServiceInterface
+	repo  repo.UserRepository
+	store storage.Storage
+	auth  AuthServiceInterface
 }
 
-func NewUserService(repo repo.UserRepository, s3Client aws.S3ClientInterface, auth AuthServiceInterface) UserService {
+func NewUserService(repo repo.UserRepository, store storage.Storage, auth AuthServiceInterface) UserService {
 	return &userService{
-		repo:     repo,
-		s3Client: s3Client,
-		auth:     auth,
+		repo:  repo,
+		store: store,
+		auth:  auth,
 	}
 }
 
@@ -44,19 +47,37 @@ func (s *userService) RegisterUser(user *entity.User) error {
 		return err
 	}
 	user.Password = string(hashedPassword)
+	user.Email = normalizeEmail(user.Email) // Stored in one form so an address can only be registered once
 	user.Status = entity.UserStatusAvailable
+	user.Role = entity.RoleUser // Roles and premium are never self-assigned
+	user.Premium = false
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 
 	return s.repo.CreateUser(user)
 }
 
-// Login handles user login
-func (s *userService) Login(email, password string) (string, uint64, error) {
-	return s.auth.Login(email, password)
+// Login handles user login; failed attempts are counted against the account and the client IP
+func (s *userService) Login(email, password, clientIP string) (*entity.AuthTokens, error) {
+	return s.auth.Login(email, password, clientIP)
 }
 
-// ChangePassword changes a user's password
+// CompleteTwoFactorLogin confirms the second factor of a login that returned a challenge token
+func (s *userService) CompleteTwoFactorLogin(challengeToken, code, clientIP string) (*entity.AuthTokens, error) {
+	return s.auth.CompleteTwoFactorLogin(challengeToken, code, clientIP)
+}
+
+// RefreshToken exchanges a refresh token for new tokens
+func (s *userService) RefreshToken(refreshToken string) (*entity.AuthTokens, error) {
+	return s.auth.Refresh(refreshToken)
+}
+
+// Logout ends the session of a refresh token, or every session of its user
+func (s *userService) Logout(refreshToken string, allSessions bool) error {
+	return s.auth.Logout(refreshToken, allSessions)
+}
+
+// ChangePassword changes a user's password and signs the user out everywhere
 func (s *userService) ChangePassword(userID uint64, oldPassword, newPassword string) error {
 	user, err := s.repo.GetUserByID(userID)
 	if err != nil {
@@ -75,11 +96,16 @@ func (s *userService) ChangePassword(userID uint64, oldPassword, newPassword str
 		return err
 	}
 
-	return s.repo.UpdateUserPassword(userID, string(hashedPassword))
+	if err := s.repo.UpdateUserPassword(userID, string(hashedPassword)); err != nil {
+		return err
+	}
+	return s.auth.RevokeSessions(userID)
 }
 
-// UpdateUser updates user information (except avatar)
-func (s *userService) UpdateUser(user *entity.User) error {
+// UpdateUser updates user information (except avatar) and reports whether the email address changed;
+// a new address has to be verified again
+func (s *userService) UpdateUser(user *entity.User) (bool, error) {
+	user.Email = normalizeEmail(user.Email)
 	user.UpdatedAt = time.Now()
 	return s.repo.UpdateUser(user)
 }
@@ -106,7 +132,7 @@ func (s *userService) DeleteUser(userID uint64) error {
 
 // GeneratePresignedAvatarUploadURL generates a presigned URL for uploading an avatar
 func (s *userService) GeneratePresignedAvatarUploadURL(folder, fileName, fileType string) (string, error) {
-	return s.s3Client.GeneratePresignedURL(folder, fileName, fileType)
+	return s.store.GeneratePresignedUploadURL(folder, fileName, fileType)
 }
 
 // GeneratePresignedAvatarDownloadURL generates a presigned URL for downloading the user's avatar
@@ -123,7 +149,7 @@ func (s *userService) GeneratePresignedAvatarDownloadURL(userID uint64) (string,
 	}
 
 	// Generate the presigned URL for the avatar image
-	url, err := s.s3Client.GeneratePresignedURL(user.AvatarFolder, user.Avatar, "image/jpeg")
+	url, err := s.store.GeneratePresignedDownloadURL(user.AvatarFolder, user.Avatar, "image/jpeg")
 	if err != nil {
 		return "", err
 	}
@@ -15,9 +15,27 @@ func (m *MockUserService) RegisterUser(user *entity.User) error {
 	return args.Error(0)
 }
 
-func (m *MockUserService) Login(email, password string) (string, error) {
-	args := m.Called(email, password)
-	return args.String(0), args.Error(1)
+func (m *MockUserService) Login(email, password, clientIP string) (*entity.AuthTokens, error) {
+	args := m.Called(email, password, clientIP)
+	tokens, _ := args.Get(0).(*entity.AuthTokens)
+	return tokens, args.Error(1)
+}
+
+func (m *MockUserService) CompleteTwoFactorLogin(challengeToken, code, clientIP string) (*entity.AuthTokens, error) {
+	args := m.Called(challengeToken, code, clientIP)
+	tokens, _ := args.Get(0).(*entity.AuthTokens)
+	return tokens, args.Error(1)
+}
+
+func (m *MockUserService) RefreshToken(refreshToken string) (*entity.AuthTokens, error) {
+	args := m.Called(refreshToken)
+	tokens, _ := args.Get(0).(*entity.AuthTokens)
+	return tokens, args.Error(1)
+}
+
+func (m *MockUserService) Logout(refreshToken string, allSessions bool) error {
+	args := m.Called(refreshToken, allSessions)
+	return args.Error(0)
 }
 
 func (m *MockUserService) ChangePassword(userID uint64, oldPassword, newPassword string) error {
@@ -25,9 +43,9 @@ func (m *MockUserService) ChangePassword(userID uint64, oldPassword, newPassword
 	return args.Error(0)
 }
 
-func (m *MockUserService) UpdateUser(user *entity.User) error {
+func (m *MockUserService) UpdateUser(user *entity.User) (bool, error) {
 	args := m.Called(user)
-	return args.Error(0)
+	return args.Bool(0), args.Error(1)
 }
 
 func (m *MockUserService) UpdateAvatar(userID uint64, avatarPath, avatarFolder string) error {
@@ -6,7 +6,7 @@ import (
 	"time"
 
 	"mlvt/internal/entity"
-	"mlvt/internal/infra/aws"
+	"mlvt/internal/infra/storage"
 	"mlvt/internal/repo"
 
 	"github.com/stretchr/testify/assert"
@@ -16,7 +16,7 @@ import (
 
 func TestRegisterUser_Success(t *testing.T) {
 	mockRepo := new(repo.MockUserRepository)
-	mockS3 := new(aws.MockS3Client)
+	mockS3 := new(storage.MockStorage)
 	mockAuth := new(MockAuthService)
 
 	userService := NewUserService(mockRepo, mockS3, mockAuth)
@@ -43,13 +43,27 @@ func TestRegisterUser_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestRegisterUser_NormalizesEmail(t *testing.T) {
+	mockRepo := new(repo.MockUserRepository)
+	userService := NewUserService(mockRepo, new(storage.MockStorage), new(MockAuthService))
+
+	user := &entity.User{UserName: "johndoe", Email: " John@Example.com ", Password: "password123"}
+	mockRepo.On("CreateUser", mock.MatchedBy(func(u *entity.User) bool {
+		return u.Email == "john@example.com"
+	})).Return(nil)
+
+	err := userService.RegisterUser(user)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestRegisterUser_Failure_HashPassword(t *testing.T) {
 	// Note: bcrypt.GenerateFromPassword is not easily mockable without additional interfaces.
 	// As an alternative, you can skip this test or refactor your code to allow mocking bcrypt.
 
 	// For demonstration, we'll simulate a failure in CreateUser
 	mockRepo := new(repo.MockUserRepository)
-	mockS3 := new(aws.MockS3Client)
+	mockS3 := new(storage.MockStorage)
 	mockAuth := new(MockAuthService)
 
 	userService := NewUserService(mockRepo, mockS3, mockAuth)
@@ -73,7 +87,7 @@ func TestRegisterUser_Failure_HashPassword(t *testing.T) {
 
 func TestLogin_Success(t *testing.T) {
 	mockRepo := new(repo.MockUserRepository)
-	mockS3 := new(aws.MockS3Client)
+	mockS3 := new(storage.MockStorage)
 	mockAuth := new(MockAuthService)
 
 	userService := NewUserService(mockRepo, mockS3, mockAuth)
@@ -82,18 +96,19 @@ func TestLogin_Success(t *testing.T) {
 	password := "password123"
 	token := "jwt.token.here"
 
-	mockAuth.On("Login", email, password).Return(token, nil)
+	mockAuth.On("Login", email, password, "203.0.113.7").Return(&entity.AuthTokens{UserID: 1, AccessToken: token}, nil)
 
-	returnedToken, err := userService.Login(email, password)
+	tokens, err := userService.Login(email, password, "203.0.113.7")
 	assert.NoError(t, err)
-	assert.Equal(t, token, returnedToken)
+	assert.Equal(t, token, tokens.AccessToken)
+	assert.Equal(t, uint64(1), tokens.UserID)
 
 	mockAuth.AssertExpectations(t)
 }
 
 func TestLogin_Failure_InvalidCredentials(t *testing.T) {
 	mockRepo := new(repo.MockUserRepository)
-	mockS3 := new(aws.MockS3Client)
+	mockS3 := new(storage.MockStorage)
 	mockAuth := new(MockAuthService)
 
 	userService := NewUserService(mockRepo, mockS3, mockAuth)
@@ -101,11 +116,11 @@ func TestLogin_Failure_InvalidCredentials(t *testing.T) {
 	email := "john@example.com"
 	password := "wrongpassword"
 
-	mockAuth.On("Login", email, password).Return("", errors.New("invalid credentials"))
+	mockAuth.On("Login", email, password, "203.0.113.7").Return(nil, errors.New("invalid credentials"))
 
-	returnedToken, err := userService.Login(email, password)
+	tokens, err := userService.Login(email, password, "203.0.113.7")
 	assert.Error(t, err)
-	assert.Equal(t, "", returnedToken)
+	assert.Nil(t, tokens)
 	assert.Equal(t, "invalid credentials", err.Error())
 
 	mockAuth.AssertExpectations(t)
@@ -113,7 +128,7 @@ func TestLogin_Failure_InvalidCredentials(t *testing.T) {
 
 func TestChangePassword_Success(t *testing.T) {
 	mockRepo := new(repo.MockUserRepository)
-	mockS3 := new(aws.MockS3Client)
+	mockS3 := new(storage.MockStorage)
 	mockAuth := new(MockAuthService)
 
 	userService := NewUserService(mockRepo, mockS3, mockAuth)
@@ -132,9 +147,11 @@ func TestChangePassword_Success(t *testing.T) {
 
 	mockRepo.On("GetUserByID", userID).Return(user, nil)
 	mockRepo.On("UpdateUserPassword", userID, mock.AnythingOfType("string")).Return(nil)
+	mockAuth.On("RevokeSessions", userID).Return(nil)
 
 	err := userService.ChangePassword(userID, oldPassword, newPassword)
 	assert.NoError(t, err)
+	mockAuth.AssertExpectations(t)
 
 	// Verify the new password is hashed and updated
 	mockRepo.AssertCalled(t, "UpdateUserPassword", userID, mock.MatchedBy(func(hashed string) bool {
@@ -146,7 +163,7 @@ func TestChangePassword_Success(t *testing.T) {
 
 func TestChangePassword_Failure_WrongOldPassword(t *testing.T) {
 	mockRepo := new(repo.MockUserRepository)
-	mockS3 := new(aws.MockS3Client)
+	mockS3 := new(storage.MockStorage)
 	mockAuth := new(MockAuthService)
 
 	userService := NewUserService(mockRepo, mockS3, mockAuth)
@@ -175,7 +192,7 @@ func TestChangePassword_Failure_WrongOldPassword(t *testing.T) {
 
 func TestChangePassword_Failure_UserNotFound(t *testing.T) {
 	mockRepo := new(repo.MockUserRepository)
-	mockS3 := new(aws.MockS3Client)
+	mockS3 := new(storage.MockStorage)
 	mockAuth := new(MockAuthService)
 
 	userService := NewUserService(mockRepo, mockS3, mockAuth)
@@ -195,7 +212,7 @@ func TestChangePassword_Failure_UserNotFound(t *testing.T) {
 
 func TestUpdateUser_Success(t *testing.T) {
 	mockRepo := new(repo.MockUserRepository)
-	mockS3 := new(aws.MockS3Client)
+	mockS3 := new(storage.MockStorage)
 	mockAuth := new(MockAuthService)
 
 	userService := NewUserService(mockRepo, mockS3, mockAuth)
@@ -212,17 +229,18 @@ func TestUpdateUser_Success(t *testing.T) {
 		UpdatedAt: time.Now(),
 	}
 
-	mockRepo.On("UpdateUser", user).Return(nil)
+	mockRepo.On("UpdateUser", user).Return(true, nil)
 
-	err := userService.UpdateUser(user)
+	emailChanged, err := userService.UpdateUser(user)
 	assert.NoError(t, err)
+	assert.True(t, emailChanged)
 
 	mockRepo.AssertExpectations(t)
 }
 
 func TestUpdateUser_Failure(t *testing.T) {
 	mockRepo := new(repo.MockUserRepository)
-	mockS3 := new(aws.MockS3Client)
+	mockS3 := new(storage.MockStorage)
 	mockAuth := new(MockAuthService)
 
 	userService := NewUserService(mockRepo, mockS3, mockAuth)
@@ -239,9 +257,9 @@ func TestUpdateUser_Failure(t *testing.T) {
 		UpdatedAt: time.Now(),
 	}
 
-	mockRepo.On("UpdateUser", user).Return(errors.New("update error"))
+	mockRepo.On("UpdateUser", user).Return(false, errors.New("update error"))
 
-	err := userService.UpdateUser(user)
+	_, err := userService.UpdateUser(user)
 	assert.Error(t, err)
 	assert.Equal(t, "update error", err.Error())
 
@@ -250,7 +268,7 @@ func TestUpdateUser_Failure(t *testing.T) {
 
 func TestUpdateAvatar_Success(t *testing.T) {
 	mockRepo := new(repo.MockUserRepository)
-	mockS3 := new(aws.MockS3Client)
+	mockS3 := new(storage.MockStorage)
 	mockAuth := new(MockAuthService)
 
 	userService := NewUserService(mockRepo, mockS3, mockAuth)
@@ -269,7 +287,7 @@ func TestUpdateAvatar_Success(t *testing.T) {
 
 func TestGetUserByID_Success(t *testing.T) {
 	mockRepo := new(repo.MockUserRepository)
-	mockS3 := new(aws.MockS3Client)
+	mockS3 := new(storage.MockStorage)
 	mockAuth := new(MockAuthService)
 
 	userService := NewUserService(mockRepo, mockS3, mockAuth)
@@ -292,7 +310,7 @@ func TestGetUserByID_Success(t *testing.T) {
 
 func TestGetUserByID_Failure(t *testing.T) {
 	mockRepo := new(repo.MockUserRepository)
-	mockS3 := new(aws.MockS3Client)
+	mockS3 := new(storage.MockStorage)
 	mockAuth := new(MockAuthService)
 
 	userService := NewUserService(mockRepo, mockS3, mockAuth)
@@ -311,7 +329,7 @@ func TestGetUserByID_Failure(t *testing.T) {
 
 func TestGetAllUsers_Success(t *testing.T) {
 	mockRepo := new(repo.MockUserRepository)
-	mockS3 := new(aws.MockS3Client)
+	mockS3 := new(storage.MockStorage)
 	mockAuth := new(MockAuthService)
 
 	userService := NewUserService(mockRepo, mockS3, mockAuth)
@@ -340,7 +358,7 @@ func TestGetAllUsers_Success(t *testing.T) {
 
 func TestGetAllUsers_Failure(t *testing.T) {
 	mockRepo := new(repo.MockUserRepository)
-	mockS3 := new(aws.MockS3Client)
+	mockS3 := new(storage.MockStorage)
 	mockAuth := new(MockAuthService)
 
 	userService := NewUserService(mockRepo, mockS3, mockAuth)
@@ -357,7 +375,7 @@ func TestGetAllUsers_Failure(t *testing.T) {
 
 func TestDeleteUser_Success(t *testing.T) {
 	mockRepo := new(repo.MockUserRepository)
-	mockS3 := new(aws.MockS3Client)
+	mockS3 := new(storage.MockStorage)
 	mockAuth := new(MockAuthService)
 
 	userService := NewUserService(mockRepo, mockS3, mockAuth)
@@ -374,7 +392,7 @@ func TestDeleteUser_Success(t *testing.T) {
 
 func TestDeleteUser_Failure(t *testing.T) {
 	mockRepo := new(repo.MockUserRepository)
-	mockS3 := new(aws.MockS3Client)
+	mockS3 := new(storage.MockStorage)
 	mockAuth := new(MockAuthService)
 
 	userService := NewUserService(mockRepo, mockS3, mockAuth)
@@ -392,7 +410,7 @@ func TestDeleteUser_Failure(t *testing.T) {
 
 func TestGeneratePresignedAvatarUploadURL_Success(t *testing.T) {
 	mockRepo := new(repo.MockUserRepository)
-	mockS3 := new(aws.MockS3Client)
+	mockS3 := new(storage.MockStorage)
 	mockAuth := new(MockAuthService)
 
 	userService := NewUserService(mockRepo, mockS3, mockAuth)
@@ -402,7 +420,7 @@ func TestGeneratePresignedAvatarUploadURL_Success(t *testing.T) {
 	fileType := "image/jpeg"
 	expectedURL := "https://s3.amazonaws.com/bucket/avatars/avatar_new.jpg?presigned"
 
-	mockS3.On("GeneratePresignedURL", folder, fileName, fileType).Return(expectedURL, nil)
+	mockS3.On("GeneratePresignedUploadURL", folder, fileName, fileType).Return(expectedURL, nil)
 
 	url, err := userService.GeneratePresignedAvatarUploadURL(folder, fileName, fileType)
 	assert.NoError(t, err)
@@ -413,7 +431,7 @@ func TestGeneratePresignedAvatarUploadURL_Success(t *testing.T) {
 
 func TestGeneratePresignedAvatarDownloadURL_Success(t *testing.T) {
 	mockRepo := new(repo.MockUserRepository)
-	mockS3 := new(aws.MockS3Client)
+	mockS3 := new(storage.MockStorage)
 	mockAuth := new(MockAuthService)
 
 	userService := NewUserService(mockRepo, mockS3, mockAuth)
@@ -428,7 +446,7 @@ func TestGeneratePresignedAvatarDownloadURL_Success(t *testing.T) {
 	expectedURL := "https://s3.amazonaws.com/bucket/avatars/avatar.jpg?presigned"
 
 	mockRepo.On("GetUserByID", userID).Return(user, nil)
-	mockS3.On("GeneratePresignedURL", user.AvatarFolder, user.Avatar, "image/jpeg").Return(expectedURL, nil)
+	mockS3.On("GeneratePresignedDownloadURL", user.AvatarFolder, user.Avatar, "image/jpeg").Return(expectedURL, nil)
 
 	url, err := userService.GeneratePresignedAvatarDownloadURL(userID)
 	assert.NoError(t, err)
@@ -440,7 +458,7 @@ func TestGeneratePresignedAvatarDownloadURL_Success(t *testing.T) {
 
 func TestGeneratePresignedAvatarDownloadURL_Failure_UserNotFound(t *testing.T) {
 	mockRepo := new(repo.MockUserRepository)
-	mockS3 := new(aws.MockS3Client)
+	mockS3 := new(storage.MockStorage)
 	mockAuth := new(MockAuthService)
 
 	userService := NewUserService(mockRepo, mockS3, mockAuth)
@@ -459,7 +477,7 @@ func TestGeneratePresignedAvatarDownloadURL_Failure_UserNotFound(t *testing.T) {
 
 func TestGeneratePresignedAvatarDownloadURL_Failure_AvatarNotFound(t *testing.T) {
 	mockRepo := new(repo.MockUserRepository)
-	mockS3 := new(aws.MockS3Client)
+	mockS3 := new(storage.MockStorage)
 	mockAuth := new(MockAuthService)
 
 	userService := NewUserService(mockRepo, mockS3, mockAuth)
@@ -483,7 +501,7 @@ func TestGeneratePresignedAvatarDownloadURL_Failure_AvatarNotFound(t *testing.T)
 
 func TestGeneratePresignedAvatarDownloadURL_Failure_S3Error(t *testing.T) {
 	mockRepo := new(repo.MockUserRepository)
-	mockS3 := new(aws.MockS3Client)
+	mockS3 := new(storage.MockStorage)
 	mockAuth := new(MockAuthService)
 
 	userService := NewUserService(mockRepo, mockS3, mockAuth)
@@ -496,7 +514,7 @@ func TestGeneratePresignedAvatarDownloadURL_Failure_S3Error(t *testing.T) {
 	}
 
 	mockRepo.On("GetUserByID", userID).Return(user, nil)
-	mockS3.On("GeneratePresignedURL", user.AvatarFolder, user.Avatar, "image/jpeg").Return("", errors.New("s3 error"))
+	mockS3.On("GeneratePresignedDownloadURL", user.AvatarFolder, user.Avatar, "image/jpeg").Return("", errors.New("s3 error"))
 
 	url, err := userService.GeneratePresignedAvatarDownloadURL(userID)
 	assert.Error(t, err)
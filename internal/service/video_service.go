@@ -1,57 +1,55 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"mlvt/internal/entity"
-	"mlvt/internal/infra/aws"
+	"mlvt/internal/infra/storage"
 	"mlvt/internal/repo"
 )
 
 type VideoService interface {
-	CreateVideo(video *entity.Video) error
 	GetVideoByID(videoID uint64) (*entity.Video, string, string, error) // Returns the video record and presigned URLs for video and image
-	ListVideosByUserID(userID uint64) ([]entity.Video, []entity.Frame, error)
+	ListVideosByUserID(userID uint64, opts entity.ListOptions) ([]entity.Video, []entity.Frame, string, error)
 	DeleteVideo(videoID uint64) error
 	UpdateVideo(video *entity.Video) error
-	UpdateVideoStatus(videoID uint64, status entity.VideoStatus) error
+	UpdateVideoStatus(videoID uint64, status entity.VideoStatus, actorID uint64, reason string) error
 	GetVideoStatus(videoID uint64) (entity.VideoStatus, error)
-	GeneratePresignedUploadURLForVideo(folder, fileName, fileType string) (string, error)
+	ListVideoStatusHistory(videoID uint64) ([]entity.VideoStatusHistory, error)
 	GeneratePresignedUploadURLForImage(folder, fileName, fileType string) (string, error)
 	GeneratePresignedDownloadURLForVideo(videoID uint64) (string, error)
 	GeneratePresignedDownloadURLForImage(videoID uint64) (string, error)
 }
 
 type videoService struct {
-	repo     repo.VideoRepository
-	s3Client aws.S3ClientInterface
+	repo      repo.VideoRepository
+	frameRepo repo.FrameRepository
+	store     storage.Storage
 }
 
-func NewVideoService(repo repo.VideoRepository, s3Client aws.S3ClientInterface) VideoService {
+func NewVideoService(repo repo.VideoRepository, frameRepo repo.FrameRepository, store storage.Storage) VideoService {
 	return &videoService{
-		repo:     repo,
-		s3Client: s3Client,
+		repo:      repo,
+		frameRepo: frameRepo,
+		store:     store,
 	}
 }
 
-func (s *videoService) CreateVideo(video *entity.Video) error {
-	return s.repo.CreateVideo(video)
-}
-
 func (s *videoService) GetVideoByID(videoID uint64) (*entity.Video, string, string, error) {
 	video, err := s.repo.GetVideoByID(videoID)
 	if err != nil {
 		return nil, "", "", err
 	}
 	if video == nil {
-		return nil, "", "", fmt.Errorf("video not found")
+		return nil, "", "", ErrVideoNotFound
 	}
 
 	// Generate presigned URLs for video and image
-	videoURL, err := s.s3Client.GeneratePresignedURL(video.Folder, video.FileName, "video/mp4")
+	videoURL, err := s.store.GeneratePresignedDownloadURL(video.Folder, video.FileName, "video/mp4")
 	if err != nil {
 		return nil, "", "", fmt.Errorf("failed to generate presigned video URL: %v", err)
 	}
-	imageURL, err := s.s3Client.GeneratePresignedURL(video.Folder, video.Image, "image/jpeg")
+	imageURL, err := s.thumbnailURL(video)
 	if err != nil {
 		return nil, "", "", fmt.Errorf("failed to generate presigned image URL: %v", err)
 	}
@@ -59,56 +57,133 @@ func (s *videoService) GetVideoByID(videoID uint64) (*entity.Video, string, stri
 	return video, videoURL, imageURL, nil
 }
 
-func (s *videoService) ListVideosByUserID(userID uint64) ([]entity.Video, []entity.Frame, error) {
-	// Fetch the videos for the user
-	videos, err := s.repo.ListVideosByUserID(userID)
+func (s *videoService) ListVideosByUserID(userID uint64, opts entity.ListOptions) ([]entity.Video, []entity.Frame, string, error) {
+	// Fetch one page of videos for the user; only that page is presigned
+	videos, nextCursor, err := s.repo.ListVideosByUserID(userID, opts)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	// Look up the thumbnail frame of every video on the page at once
+	videoIDs := make([]uint64, len(videos))
+	for i, video := range videos {
+		videoIDs[i] = video.ID
+	}
+	thumbnails, err := s.frameRepo.ListThumbnails(videoIDs)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, "", err
+	}
+	thumbnailByVideo := make(map[uint64]entity.Frame, len(thumbnails))
+	for _, thumbnail := range thumbnails {
+		thumbnailByVideo[thumbnail.VideoID] = thumbnail
 	}
 
-	// Prepare a list of Frame objects containing presigned URLs for images
 	var frames []entity.Frame
 	for _, video := range videos {
-		// Generate the presigned URL for the video's image
-		imageURL, err := s.s3Client.GeneratePresignedURL(video.Folder, video.Image, "image/jpeg")
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to generate presigned URL for image: %v", err)
+		if frame, ok := thumbnailByVideo[video.ID]; ok {
+			if err := presignFrame(s.store, &frame); err != nil {
+				return nil, nil, "", err
+			}
+			frames = append(frames, frame)
+			continue
+		}
+		if video.Image == "" {
+			continue
 		}
 
-		// Create a new Frame object with the video ID and the image presigned URL
-		frame := entity.Frame{
-			VideoID: video.ID,
-			Link:    imageURL,
+		// Videos without registered frames fall back to the image uploaded with them
+		imageURL, err := s.store.GeneratePresignedDownloadURL(video.Folder, video.Image, "image/jpeg")
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to generate presigned URL for image: %v", err)
 		}
-		frames = append(frames, frame)
+		frames = append(frames, entity.Frame{VideoID: video.ID, Link: imageURL})
 	}
 
-	return videos, frames, nil
+	return videos, frames, nextCursor, nil
+}
+
+// thumbnailURL presigns the primary or earliest frame of a video, or the image uploaded with it
+// when it has no frames
+func (s *videoService) thumbnailURL(video *entity.Video) (string, error) {
+	thumbnails, err := s.frameRepo.ListThumbnails([]uint64{video.ID})
+	if err != nil {
+		return "", err
+	}
+	if len(thumbnails) > 0 {
+		if err := presignFrame(s.store, &thumbnails[0]); err != nil {
+			return "", err
+		}
+		return thumbnails[0].Link, nil
+	}
+	return s.store.GeneratePresignedDownloadURL(video.Folder, video.Image, "image/jpeg")
 }
 
 func (s *videoService) DeleteVideo(videoID uint64) error {
-	return s.repo.DeleteVideo(videoID)
+	err := s.repo.DeleteVideo(videoID)
+	if errors.Is(err, repo.ErrVideoNotFound) {
+		return ErrVideoNotFound
+	}
+	return err
 }
 
 func (s *videoService) UpdateVideo(video *entity.Video) error {
 	return s.repo.UpdateVideo(video)
 }
 
-func (s *videoService) UpdateVideoStatus(videoID uint64, status entity.VideoStatus) error {
-	return s.repo.UpdateVideoStatus(videoID, status)
+// UpdateVideoStatus moves a video to a new status if the transition table allows it and records who made the change.
+// Setting the status a video already has is a no-op.
+func (s *videoService) UpdateVideoStatus(videoID uint64, status entity.VideoStatus, actorID uint64, reason string) error {
+	if !IsValidVideoStatus(status) {
+		return ErrInvalidVideoStatus
+	}
+
+	video, err := s.repo.GetVideoByID(videoID)
+	if err != nil {
+		return err
+	}
+	if video == nil {
+		return ErrVideoNotFound
+	}
+
+	if video.Status == status {
+		return nil
+	}
+	if !CanTransitionVideoStatus(video.Status, status) {
+		return &InvalidStatusTransitionError{From: video.Status, To: status}
+	}
+
+	err = s.repo.TransitionVideoStatus(&entity.VideoStatusHistory{
+		VideoID:    videoID,
+		FromStatus: video.Status,
+		ToStatus:   status,
+		ActorID:    actorID,
+		Reason:     reason,
+	})
+	if errors.Is(err, repo.ErrVideoStatusChanged) {
+		return ErrVideoStatusConflict
+	}
+	return err
+}
+
+// ListVideoStatusHistory returns the status transitions of a video, oldest first
+func (s *videoService) ListVideoStatusHistory(videoID uint64) ([]entity.VideoStatusHistory, error) {
+	video, err := s.repo.GetVideoByID(videoID)
+	if err != nil {
+		return nil, err
+	}
+	if video == nil {
+		return nil, ErrVideoNotFound
+	}
+	return s.repo.ListVideoStatusHistory(videoID)
 }
+
 func (s *videoService) GetVideoStatus(videoID uint64) (entity.VideoStatus, error) {
 	return s.repo.GetVideoStatus(videoID)
 }
 
-// GeneratePresignedUploadURLForVideo generates a presigned URL for uploading a video file
-func (s *videoService) GeneratePresignedUploadURLForVideo(folder, fileName, fileType string) (string, error) {
-	return s.s3Client.GeneratePresignedURL(folder, fileName, fileType)
-}
-
 // GeneratePresignedUploadURLForImage generates a presigned URL for uploading an image file
 func (s *videoService) GeneratePresignedUploadURLForImage(folder, fileName, fileType string) (string, error) {
-	return s.s3Client.GeneratePresignedURL(folder, fileName, fileType)
+	return s.store.GeneratePresignedUploadURL(folder, fileName, fileType)
 }
 
 // GeneratePresignedDownloadURLForVideo generates a presigned URL for downloading a video file
@@ -118,21 +193,21 @@ func (s *videoService) GeneratePresignedDownloadURLForVideo(videoID uint64) (str
 		return "", err
 	}
 	if video == nil {
-		return "", fmt.Errorf("video not found")
+		return "", ErrVideoNotFound
 	}
 
-	return s.s3Client.GeneratePresignedURL(video.Folder, video.FileName, "video/mp4")
+	return s.store.GeneratePresignedDownloadURL(video.Folder, video.FileName, "video/mp4", storage.AsAttachment(video.FileName))
 }
 
-// GeneratePresignedDownloadURLForImage generates a presigned URL for downloading an image file
+// GeneratePresignedDownloadURLForImage generates a presigned URL for downloading the thumbnail of a video
 func (s *videoService) GeneratePresignedDownloadURLForImage(videoID uint64) (string, error) {
 	video, err := s.repo.GetVideoByID(videoID)
 	if err != nil {
 		return "", err
 	}
 	if video == nil {
-		return "", fmt.Errorf("video not found")
+		return "", ErrVideoNotFound
 	}
 
-	return s.s3Client.GeneratePresignedURL(video.Folder, video.Image, "image/jpeg")
+	return s.thumbnailURL(video)
 }
@@ -11,20 +11,15 @@ type MockVideoService struct {
 	mock.Mock
 }
 
-func (m *MockVideoService) CreateVideo(video *entity.Video) error {
-	args := m.Called(video)
-	return args.Error(0)
-}
-
 func (m *MockVideoService) GetVideoByID(videoID uint64) (*entity.Video, string, string, error) {
 	args := m.Called(videoID)
 	video, _ := args.Get(0).(*entity.Video)
 	return video, args.String(1), args.String(2), args.Error(3)
 }
 
-func (m *MockVideoService) ListVideosByUserID(userID uint64) ([]entity.Video, []entity.Frame, error) {
-	args := m.Called(userID)
-	return args.Get(0).([]entity.Video), args.Get(1).([]entity.Frame), args.Error(2)
+func (m *MockVideoService) ListVideosByUserID(userID uint64, opts entity.ListOptions) ([]entity.Video, []entity.Frame, string, error) {
+	args := m.Called(userID, opts)
+	return args.Get(0).([]entity.Video), args.Get(1).([]entity.Frame), args.String(2), args.Error(3)
 }
 
 func (m *MockVideoService) DeleteVideo(videoID uint64) error {
@@ -37,19 +32,20 @@ func (m *MockVideoService) UpdateVideo(video *entity.Video) error {
 	return args.Error(0)
 }
 
-func (m *MockVideoService) UpdateVideoStatus(videoID uint64, status entity.VideoStatus) error {
-	args := m.Called(videoID, status)
+func (m *MockVideoService) UpdateVideoStatus(videoID uint64, status entity.VideoStatus, actorID uint64, reason string) error {
+	args := m.Called(videoID, status, actorID, reason)
 	return args.Error(0)
 }
 
-func (m *MockVideoService) GetVideoStatus(videoID uint64) (entity.VideoStatus, error) {
+func (m *MockVideoService) ListVideoStatusHistory(videoID uint64) ([]entity.VideoStatusHistory, error) {
 	args := m.Called(videoID)
-	return args.Get(0).(entity.VideoStatus), args.Error(1)
+	history, _ := args.Get(0).([]entity.VideoStatusHistory)
+	return history, args.Error(1)
 }
 
-func (m *MockVideoService) GeneratePresignedUploadURLForVideo(folder, fileName, fileType string) (string, error) {
-	args := m.Called(folder, fileName, fileType)
-	return args.String(0), args.Error(1)
+func (m *MockVideoService) GetVideoStatus(videoID uint64) (entity.VideoStatus, error) {
+	args := m.Called(videoID)
+	return args.Get(0).(entity.VideoStatus), args.Error(1)
 }
 
 func (m *MockVideoService) GeneratePresignedUploadURLForImage(folder, fileName, fileType string) (string, error) {
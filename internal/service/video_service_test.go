@@ -1,8 +1,9 @@
 package service
 
 import (
+	"fmt"
 	"mlvt/internal/entity"
-	"mlvt/internal/infra/aws"
+	"mlvt/internal/infra/storage"
 	"mlvt/internal/repo"
 	"testing"
 	"time"
@@ -10,36 +11,16 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func setupTestRepoAndS3Client() (*repo.MockVideoRepository, *aws.MockS3Client) {
+func setupTestRepoAndS3Client() (*repo.MockVideoRepository, *storage.MockStorage) {
 	repo := new(repo.MockVideoRepository)
-	s3Client := new(aws.MockS3Client)
+	s3Client := new(storage.MockStorage)
 	return repo, s3Client
 }
 
-func TestCreateVideoService(t *testing.T) {
-	videoRepo, s3Client := setupTestRepoAndS3Client()
-	videoService := NewVideoService(videoRepo, s3Client)
-
-	video := &entity.Video{
-		Title:       "Test Video",
-		Duration:    120,
-		Description: "Test Description",
-		FileName:    "test.mp4",
-		Folder:      "test_folder",
-		Image:       "test_image.jpg",
-		Status:      entity.StatusRaw,
-		UserID:      1,
-	}
-
-	videoRepo.On("CreateVideo", video).Return(nil)
-	err := videoService.CreateVideo(video)
-	assert.NoError(t, err)
-	videoRepo.AssertExpectations(t)
-}
-
 func TestGetVideoByIDService(t *testing.T) {
 	videoRepo, s3Client := setupTestRepoAndS3Client()
-	videoService := NewVideoService(videoRepo, s3Client)
+	frameRepo := new(repo.MockFrameRepository)
+	videoService := NewVideoService(videoRepo, frameRepo, s3Client)
 
 	video := &entity.Video{
 		ID:          1,
@@ -56,8 +37,9 @@ func TestGetVideoByIDService(t *testing.T) {
 	}
 
 	videoRepo.On("GetVideoByID", uint64(1)).Return(video, nil)
-	s3Client.On("GeneratePresignedURL", video.Folder, video.FileName, "video/mp4").Return("https://s3.amazonaws.com/test_video.mp4", nil)
-	s3Client.On("GeneratePresignedURL", video.Folder, video.Image, "image/jpeg").Return("https://s3.amazonaws.com/test_image.jpg", nil)
+	frameRepo.On("ListThumbnails", []uint64{1}).Return(nil, nil)
+	s3Client.On("GeneratePresignedDownloadURL", video.Folder, video.FileName, "video/mp4").Return("https://s3.amazonaws.com/test_video.mp4", nil)
+	s3Client.On("GeneratePresignedDownloadURL", video.Folder, video.Image, "image/jpeg").Return("https://s3.amazonaws.com/test_image.jpg", nil)
 
 	result, videoURL, imageURL, err := videoService.GetVideoByID(1)
 	assert.NoError(t, err)
@@ -70,7 +52,8 @@ func TestGetVideoByIDService(t *testing.T) {
 
 func TestListVideosByUserIDService(t *testing.T) {
 	videoRepo, s3Client := setupTestRepoAndS3Client()
-	videoService := NewVideoService(videoRepo, s3Client)
+	frameRepo := new(repo.MockFrameRepository)
+	videoService := NewVideoService(videoRepo, frameRepo, s3Client)
 
 	video1 := entity.Video{
 		ID:          1,
@@ -96,26 +79,42 @@ func TestListVideosByUserIDService(t *testing.T) {
 	}
 
 	videos := []entity.Video{video1, video2}
-	videoRepo.On("ListVideosByUserID", uint64(1)).Return(videos, nil)
-	s3Client.On("GeneratePresignedURL", video1.Folder, video1.Image, "image/jpeg").Return("https://s3.amazonaws.com/test_image_1.jpg", nil)
-	s3Client.On("GeneratePresignedURL", video2.Folder, video2.Image, "image/jpeg").Return("https://s3.amazonaws.com/test_image_2.jpg", nil)
-
-	resultVideos, frames, err := videoService.ListVideosByUserID(1)
+	videoRepo.On("ListVideosByUserID", uint64(1), entity.ListOptions{Limit: 2}).Return(videos, "cursor", nil)
+	// Video 1 has a primary frame; video 2 has no frames and falls back to its image
+	frameRepo.On("ListThumbnails", []uint64{1, 2}).Return([]entity.Frame{
+		{ID: 7, VideoID: 1, Folder: "frames", FileName: "frame_1.png", IsPrimary: true},
+	}, nil)
+	s3Client.On("GeneratePresignedDownloadURL", "frames", "frame_1.png", "image/png").Return("https://s3.amazonaws.com/frame_1.png", nil)
+	s3Client.On("GeneratePresignedDownloadURL", video2.Folder, video2.Image, "image/jpeg").Return("https://s3.amazonaws.com/test_image_2.jpg", nil)
+
+	resultVideos, frames, nextCursor, err := videoService.ListVideosByUserID(1, entity.ListOptions{Limit: 2})
 	assert.NoError(t, err)
+	assert.Equal(t, "cursor", nextCursor)
 	assert.Len(t, resultVideos, 2)
 	assert.Len(t, frames, 2)
-	assert.Equal(t, "https://s3.amazonaws.com/test_image_1.jpg", frames[0].Link)
+	assert.Equal(t, uint64(7), frames[0].ID)
+	assert.Equal(t, "https://s3.amazonaws.com/frame_1.png", frames[0].Link)
+	assert.Equal(t, uint64(2), frames[1].VideoID)
 	assert.Equal(t, "https://s3.amazonaws.com/test_image_2.jpg", frames[1].Link)
 	videoRepo.AssertExpectations(t)
+	frameRepo.AssertExpectations(t)
 	s3Client.AssertExpectations(t)
 }
 
 func TestDeleteVideoService(t *testing.T) {
 	videoRepo, s3Client := setupTestRepoAndS3Client()
-	videoService := NewVideoService(videoRepo, s3Client)
+	videoService := NewVideoService(videoRepo, new(repo.MockFrameRepository), s3Client)
 
 	videoRepo.On("DeleteVideo", uint64(1)).Return(nil)
 	err := videoService.DeleteVideo(1)
 	assert.NoError(t, err)
 	videoRepo.AssertExpectations(t)
 }
+
+func TestDeleteVideoService_NotFound(t *testing.T) {
+	videoRepo, s3Client := setupTestRepoAndS3Client()
+	videoService := NewVideoService(videoRepo, new(repo.MockFrameRepository), s3Client)
+
+	videoRepo.On("DeleteVideo", uint64(1)).Return(fmt.Errorf("%w with id %d", repo.ErrVideoNotFound, 1))
+	assert.ErrorIs(t, videoService.DeleteVideo(1), ErrVideoNotFound)
+}
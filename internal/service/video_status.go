@@ -0,0 +1,48 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"mlvt/internal/entity"
+)
+
+var (
+	ErrVideoNotFound       = errors.New("video not found")
+	ErrInvalidVideoStatus  = errors.New("invalid video status")
+	ErrVideoStatusConflict = errors.New("video status was changed by another request")
+)
+
+// InvalidStatusTransitionError is returned when a video cannot move from its current status to the requested one
+type InvalidStatusTransitionError struct {
+	From entity.VideoStatus
+	To   entity.VideoStatus
+}
+
+func (e *InvalidStatusTransitionError) Error() string {
+	return fmt.Sprintf("cannot change video status from %s to %s", e.From, e.To)
+}
+
+// videoStatusTransitions lists the statuses each status may move to.
+// failed and success are terminal.
+var videoStatusTransitions = map[entity.VideoStatus][]entity.VideoStatus{
+	entity.StatusRaw:        {entity.StatusProcessing, entity.StatusFailed},
+	entity.StatusProcessing: {entity.StatusSuccess, entity.StatusFailed},
+	entity.StatusFailed:     {},
+	entity.StatusSuccess:    {},
+}
+
+// IsValidVideoStatus reports whether status is one of the VideoStatus constants
+func IsValidVideoStatus(status entity.VideoStatus) bool {
+	_, ok := videoStatusTransitions[status]
+	return ok
+}
+
+// CanTransitionVideoStatus reports whether a video may move from one status to another
+func CanTransitionVideoStatus(from, to entity.VideoStatus) bool {
+	for _, allowed := range videoStatusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
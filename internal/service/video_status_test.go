@@ -0,0 +1,90 @@
+package service
+
+import (
+	"testing"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/repo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCanTransitionVideoStatus(t *testing.T) {
+	assert.True(t, CanTransitionVideoStatus(entity.StatusRaw, entity.StatusProcessing))
+	assert.True(t, CanTransitionVideoStatus(entity.StatusProcessing, entity.StatusSuccess))
+	assert.True(t, CanTransitionVideoStatus(entity.StatusProcessing, entity.StatusFailed))
+	assert.False(t, CanTransitionVideoStatus(entity.StatusFailed, entity.StatusRaw))
+	assert.False(t, CanTransitionVideoStatus(entity.StatusSuccess, entity.StatusProcessing))
+	assert.False(t, CanTransitionVideoStatus(entity.StatusRaw, entity.StatusSuccess))
+}
+
+func TestUpdateVideoStatusService_Success(t *testing.T) {
+	videoRepo, s3Client := setupTestRepoAndS3Client()
+	videoService := NewVideoService(videoRepo, new(repo.MockFrameRepository), s3Client)
+
+	videoRepo.On("GetVideoByID", uint64(1)).Return(&entity.Video{ID: 1, Status: entity.StatusRaw}, nil)
+	videoRepo.On("TransitionVideoStatus", mock.MatchedBy(func(history *entity.VideoStatusHistory) bool {
+		return history.VideoID == 1 && history.FromStatus == entity.StatusRaw && history.ToStatus == entity.StatusProcessing &&
+			history.ActorID == 5 && history.Reason == "manual"
+	})).Return(nil)
+
+	err := videoService.UpdateVideoStatus(1, entity.StatusProcessing, 5, "manual")
+	assert.NoError(t, err)
+	videoRepo.AssertExpectations(t)
+}
+
+func TestUpdateVideoStatusService_IllegalTransition(t *testing.T) {
+	videoRepo, s3Client := setupTestRepoAndS3Client()
+	videoService := NewVideoService(videoRepo, new(repo.MockFrameRepository), s3Client)
+
+	videoRepo.On("GetVideoByID", uint64(1)).Return(&entity.Video{ID: 1, Status: entity.StatusFailed}, nil)
+
+	err := videoService.UpdateVideoStatus(1, entity.StatusRaw, 5, "")
+	var transitionErr *InvalidStatusTransitionError
+	assert.ErrorAs(t, err, &transitionErr)
+	assert.Equal(t, entity.StatusFailed, transitionErr.From)
+	assert.Equal(t, entity.StatusRaw, transitionErr.To)
+	videoRepo.AssertNotCalled(t, "TransitionVideoStatus", mock.Anything)
+}
+
+func TestUpdateVideoStatusService_SameStatusIsNoop(t *testing.T) {
+	videoRepo, s3Client := setupTestRepoAndS3Client()
+	videoService := NewVideoService(videoRepo, new(repo.MockFrameRepository), s3Client)
+
+	videoRepo.On("GetVideoByID", uint64(1)).Return(&entity.Video{ID: 1, Status: entity.StatusProcessing}, nil)
+
+	err := videoService.UpdateVideoStatus(1, entity.StatusProcessing, entity.SystemActorID, "")
+	assert.NoError(t, err)
+	videoRepo.AssertNotCalled(t, "TransitionVideoStatus", mock.Anything)
+}
+
+func TestUpdateVideoStatusService_UnknownStatus(t *testing.T) {
+	videoRepo, s3Client := setupTestRepoAndS3Client()
+	videoService := NewVideoService(videoRepo, new(repo.MockFrameRepository), s3Client)
+
+	err := videoService.UpdateVideoStatus(1, entity.VideoStatus("archived"), 5, "")
+	assert.ErrorIs(t, err, ErrInvalidVideoStatus)
+	videoRepo.AssertNotCalled(t, "GetVideoByID", mock.Anything)
+}
+
+func TestUpdateVideoStatusService_NotFound(t *testing.T) {
+	videoRepo, s3Client := setupTestRepoAndS3Client()
+	videoService := NewVideoService(videoRepo, new(repo.MockFrameRepository), s3Client)
+
+	videoRepo.On("GetVideoByID", uint64(1)).Return((*entity.Video)(nil), nil)
+
+	err := videoService.UpdateVideoStatus(1, entity.StatusProcessing, 5, "")
+	assert.ErrorIs(t, err, ErrVideoNotFound)
+}
+
+func TestUpdateVideoStatusService_ConcurrentChange(t *testing.T) {
+	videoRepo, s3Client := setupTestRepoAndS3Client()
+	videoService := NewVideoService(videoRepo, new(repo.MockFrameRepository), s3Client)
+
+	videoRepo.On("GetVideoByID", uint64(1)).Return(&entity.Video{ID: 1, Status: entity.StatusRaw}, nil)
+	videoRepo.On("TransitionVideoStatus", mock.Anything).Return(repo.ErrVideoStatusChanged)
+
+	err := videoService.UpdateVideoStatus(1, entity.StatusProcessing, 5, "")
+	assert.ErrorIs(t, err, ErrVideoStatusConflict)
+}
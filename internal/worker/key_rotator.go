@@ -0,0 +1,96 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"mlvt/internal/infra/jwtkeys"
+	"mlvt/internal/infra/zap-logging/log"
+)
+
+// DefaultKeyCheckInterval is how often the key rotator runs when no interval is given
+const DefaultKeyCheckInterval = time.Hour
+
+// SigningKeys is the key set the rotator maintains; *jwtkeys.KeySet satisfies it
+type SigningKeys interface {
+	Reload() error
+	RotateIfDue() (*jwtkeys.Key, error)
+	Prune() (int, error)
+}
+
+// KeyRotator periodically rotates the JWT signing key once it is due and deletes the replaced keys
+// no unexpired token was signed with. Each pass first rereads the key directory, so servers sharing
+// it sign with the same key.
+type KeyRotator struct {
+	keys     SigningKeys
+	interval time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewKeyRotator creates a rotator that checks the keys every interval
+func NewKeyRotator(keys SigningKeys, interval time.Duration) *KeyRotator {
+	if interval <= 0 {
+		interval = DefaultKeyCheckInterval
+	}
+	return &KeyRotator{keys: keys, interval: interval}
+}
+
+// Start launches the rotation loop; the first pass runs immediately
+func (r *KeyRotator) Start(ctx context.Context) {
+	ctx, r.cancel = context.WithCancel(ctx)
+
+	r.wg.Add(1)
+	go r.loop(ctx)
+}
+
+// Stop signals the rotation loop to finish and waits for it to return
+func (r *KeyRotator) Stop() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+}
+
+func (r *KeyRotator) loop(ctx context.Context) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.runOnce()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOnce reloads the keys, rotates the signing key if due and prunes retired keys
+func (r *KeyRotator) runOnce() {
+	if err := r.keys.Reload(); err != nil {
+		log.Errorf("Failed to reload JWT signing keys: %v", err)
+		return
+	}
+	key, err := r.keys.RotateIfDue()
+	if err != nil {
+		log.Errorf("Failed to rotate the JWT signing key: %v", err)
+		return
+	}
+	if key != nil {
+		log.Infof("Rotated the JWT signing key; now signing with %s", key.ID)
+	}
+	pruned, err := r.keys.Prune()
+	if err != nil {
+		log.Errorf("Failed to prune JWT signing keys: %v", err)
+		return
+	}
+	if pruned > 0 {
+		log.Infof("Deleted %d retired JWT signing keys", pruned)
+	}
+}
@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"errors"
+	"testing"
+
+	"mlvt/internal/infra/jwtkeys"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSigningKeys struct {
+	reloadErr error
+	rotated   *jwtkeys.Key
+	calls     []string
+}
+
+func (f *fakeSigningKeys) Reload() error {
+	f.calls = append(f.calls, "Reload")
+	return f.reloadErr
+}
+
+func (f *fakeSigningKeys) RotateIfDue() (*jwtkeys.Key, error) {
+	f.calls = append(f.calls, "RotateIfDue")
+	return f.rotated, nil
+}
+
+func (f *fakeSigningKeys) Prune() (int, error) {
+	f.calls = append(f.calls, "Prune")
+	return 1, nil
+}
+
+func TestKeyRotator_RunOnce(t *testing.T) {
+	keys := &fakeSigningKeys{rotated: &jwtkeys.Key{ID: "new"}}
+	rotator := NewKeyRotator(keys, 0)
+	assert.Equal(t, DefaultKeyCheckInterval, rotator.interval)
+
+	rotator.runOnce()
+	assert.Equal(t, []string{"Reload", "RotateIfDue", "Prune"}, keys.calls)
+}
+
+func TestKeyRotator_RunOnceStopsWhenReloadFails(t *testing.T) {
+	keys := &fakeSigningKeys{reloadErr: errors.New("permission denied")}
+	rotator := NewKeyRotator(keys, 0)
+
+	rotator.runOnce()
+	assert.Equal(t, []string{"Reload"}, keys.calls)
+}
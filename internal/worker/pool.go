@@ -0,0 +1,297 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/zap-logging/log"
+	"mlvt/internal/repo"
+	"mlvt/internal/service"
+)
+
+// Default pool settings used when a Config field is left at zero
+const (
+	DefaultWorkers      = 2
+	DefaultPollInterval = 5 * time.Second
+	DefaultStageTimeout = 10 * time.Minute
+	DefaultMaxAttempts  = 3
+	DefaultBackoffBase  = 30 * time.Second
+	DefaultBackoffMax   = 30 * time.Minute
+	DefaultEnqueueBatch = 50
+)
+
+// Config controls how the pool claims and runs jobs
+type Config struct {
+	Workers      int           // Number of jobs processed concurrently
+	PollInterval time.Duration // How often idle workers look for due jobs
+	StageTimeout time.Duration // Maximum run time of a single stage
+	MaxAttempts  int           // Attempts before a job is failed
+	BackoffBase  time.Duration // Delay before the first retry; doubles on each further attempt
+	BackoffMax   time.Duration // Upper bound for the retry delay
+	EnqueueBatch int           // Raw videos turned into jobs per poll
+}
+
+// withDefaults fills zero fields with the package defaults
+func (c Config) withDefaults() Config {
+	if c.Workers <= 0 {
+		c.Workers = DefaultWorkers
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = DefaultPollInterval
+	}
+	if c.StageTimeout <= 0 {
+		c.StageTimeout = DefaultStageTimeout
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = DefaultMaxAttempts
+	}
+	if c.BackoffBase <= 0 {
+		c.BackoffBase = DefaultBackoffBase
+	}
+	if c.BackoffMax <= 0 {
+		c.BackoffMax = DefaultBackoffMax
+	}
+	if c.EnqueueBatch <= 0 {
+		c.EnqueueBatch = DefaultEnqueueBatch
+	}
+	return c
+}
+
+// VideoStatusUpdater applies video status transitions; service.VideoService satisfies it
+type VideoStatusUpdater interface {
+	UpdateVideoStatus(videoID uint64, status entity.VideoStatus, actorID uint64, reason string) error
+}
+
+// Pool is an in-process worker pool that moves raw videos through the pipeline stages.
+// Job state lives in the jobs table, so work survives restarts.
+type Pool struct {
+	jobRepo       repo.JobRepository
+	videoRepo     repo.VideoRepository
+	statusUpdater VideoStatusUpdater
+	stages        []Stage
+	config        Config
+	now           func() time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPool creates a worker pool running stages in order for every job.
+// Status changes go through statusUpdater so they follow the video status state machine.
+func NewPool(jobRepo repo.JobRepository, videoRepo repo.VideoRepository, statusUpdater VideoStatusUpdater, stages []Stage, config Config) *Pool {
+	return &Pool{
+		jobRepo:       jobRepo,
+		videoRepo:     videoRepo,
+		statusUpdater: statusUpdater,
+		stages:        stages,
+		config:        config.withDefaults(),
+		now:           time.Now,
+	}
+}
+
+// Start recovers jobs abandoned by a previous process and launches the workers
+func (p *Pool) Start(ctx context.Context) {
+	ctx, p.cancel = context.WithCancel(ctx)
+
+	// Anything still running was claimed before the last shutdown or crash
+	if count, err := p.jobRepo.RequeueStaleJobs(p.now()); err != nil {
+		log.Errorf("Failed to requeue stale jobs: %v", err)
+	} else if count > 0 {
+		log.Infof("Requeued %d stale jobs", count)
+	}
+
+	p.wg.Add(1)
+	go p.enqueueLoop(ctx)
+
+	for i := 0; i < p.config.Workers; i++ {
+		p.wg.Add(1)
+		go p.workLoop(ctx)
+	}
+	log.Infof("Started %d video processing workers", p.config.Workers)
+}
+
+// Stop signals the workers to finish and waits for them to return.
+// A job interrupted mid-stage is put back in the queue.
+func (p *Pool) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.wg.Wait()
+}
+
+// enqueueLoop periodically creates jobs for raw videos
+func (p *Pool) enqueueLoop(ctx context.Context) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.jobRepo.EnqueueRawVideos(p.config.MaxAttempts, p.config.EnqueueBatch); err != nil {
+			log.Errorf("Failed to enqueue raw videos: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// workLoop claims and processes jobs until ctx is cancelled, sleeping when the queue is empty
+func (p *Pool) workLoop(ctx context.Context) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil && p.runNext(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runNext claims one due job and processes it. It reports whether a job was found.
+func (p *Pool) runNext(ctx context.Context) bool {
+	job, err := p.jobRepo.ClaimNextJob(p.now())
+	if err != nil {
+		log.Errorf("Failed to claim job: %v", err)
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	p.process(ctx, job)
+	return true
+}
+
+// process runs the remaining stages of a claimed job and records the outcome
+func (p *Pool) process(ctx context.Context, job *entity.Job) {
+	video, err := p.videoRepo.GetVideoByID(job.VideoID)
+	if err != nil {
+		p.handleFailure(ctx, job, fmt.Errorf("failed to load video %d: %v", job.VideoID, err))
+		return
+	}
+	if video == nil {
+		// The video was deleted after the job was queued; there is nothing left to update
+		if err := p.jobRepo.FailJob(job.ID, fmt.Sprintf("video %d not found", job.VideoID)); err != nil {
+			log.Errorf("Failed to mark job %d as failed: %v", job.ID, err)
+		}
+		return
+	}
+
+	reason := fmt.Sprintf("job %d attempt %d started", job.ID, job.Attempts)
+	if err := p.statusUpdater.UpdateVideoStatus(video.ID, entity.StatusProcessing, entity.SystemActorID, reason); err != nil {
+		var transitionErr *service.InvalidStatusTransitionError
+		if errors.As(err, &transitionErr) {
+			// The video already reached a terminal status some other way; retrying cannot help
+			if err := p.jobRepo.FailJob(job.ID, err.Error()); err != nil {
+				log.Errorf("Failed to mark job %d as failed: %v", job.ID, err)
+			}
+			return
+		}
+		p.handleFailure(ctx, job, err)
+		return
+	}
+
+	for _, stage := range p.remainingStages(job.Stage) {
+		if err := p.jobRepo.UpdateJobStage(job.ID, stage.Name()); err != nil {
+			p.handleFailure(ctx, job, err)
+			return
+		}
+
+		if err := p.runStage(ctx, stage, video); err != nil {
+			p.handleFailure(ctx, job, fmt.Errorf("stage %s: %w", stage.Name(), err))
+			return
+		}
+	}
+
+	if err := p.jobRepo.CompleteJob(job.ID); err != nil {
+		log.Errorf("Failed to mark job %d as succeeded: %v", job.ID, err)
+		return
+	}
+	reason = fmt.Sprintf("job %d completed", job.ID)
+	if err := p.statusUpdater.UpdateVideoStatus(video.ID, entity.StatusSuccess, entity.SystemActorID, reason); err != nil {
+		log.Errorf("Failed to mark video %d as processed: %v", video.ID, err)
+	}
+}
+
+// runStage runs a single stage under the configured timeout
+func (p *Pool) runStage(ctx context.Context, stage Stage, video *entity.Video) error {
+	stageCtx, cancel := context.WithTimeout(ctx, p.config.StageTimeout)
+	defer cancel()
+
+	err := stage.Run(stageCtx, video)
+	if err == nil && errors.Is(stageCtx.Err(), context.DeadlineExceeded) {
+		err = stageCtx.Err()
+	}
+	return err
+}
+
+// remainingStages returns the stages from the named one onwards, so a retry resumes where it failed
+func (p *Pool) remainingStages(from string) []Stage {
+	for i, stage := range p.stages {
+		if stage.Name() == from {
+			return p.stages[i:]
+		}
+	}
+	return p.stages
+}
+
+// handleFailure schedules a retry with exponential backoff, or fails the job and its video
+// once the attempts are used up or the error is permanent
+func (p *Pool) handleFailure(ctx context.Context, job *entity.Job, cause error) {
+	if ctx.Err() != nil {
+		// Shutting down: hand the job back without waiting for a backoff
+		if err := p.jobRepo.RetryJob(job.ID, p.now(), "interrupted by shutdown"); err != nil {
+			log.Errorf("Failed to requeue job %d: %v", job.ID, err)
+		}
+		return
+	}
+
+	if !IsPermanent(cause) && job.Attempts < job.MaxAttempts {
+		runAt := p.now().Add(p.backoff(job.Attempts))
+		log.Warnf("Job %d for video %d failed on attempt %d/%d, retrying at %s: %v",
+			job.ID, job.VideoID, job.Attempts, job.MaxAttempts, runAt.Format(time.RFC3339), cause)
+		if err := p.jobRepo.RetryJob(job.ID, runAt, cause.Error()); err != nil {
+			log.Errorf("Failed to schedule retry for job %d: %v", job.ID, err)
+		}
+		return
+	}
+
+	log.Errorf("Job %d for video %d failed after %d attempts: %v", job.ID, job.VideoID, job.Attempts, cause)
+	if err := p.jobRepo.FailJob(job.ID, cause.Error()); err != nil {
+		log.Errorf("Failed to mark job %d as failed: %v", job.ID, err)
+	}
+	reason := fmt.Sprintf("job %d failed after %d attempts: %v", job.ID, job.Attempts, cause)
+	if err := p.statusUpdater.UpdateVideoStatus(job.VideoID, entity.StatusFailed, entity.SystemActorID, reason); err != nil {
+		log.Errorf("Failed to mark video %d as failed: %v", job.VideoID, err)
+	}
+}
+
+// backoff returns the delay before the retry that follows the given attempt
+func (p *Pool) backoff(attempt int) time.Duration {
+	delay := p.config.BackoffBase
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= p.config.BackoffMax {
+			return p.config.BackoffMax
+		}
+	}
+	if delay > p.config.BackoffMax {
+		return p.config.BackoffMax
+	}
+	return delay
+}
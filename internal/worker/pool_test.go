@@ -0,0 +1,219 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mlvt/internal/entity"
+	"mlvt/internal/repo"
+	"mlvt/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var fixedNow = time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+
+// recordingStage records that it ran and returns err
+func recordingStage(name string, ran *[]string, err error) Stage {
+	return NewStage(name, func(ctx context.Context, video *entity.Video) error {
+		*ran = append(*ran, name)
+		return err
+	})
+}
+
+func newTestPool(jobRepo *repo.MockJobRepository, videoRepo *repo.MockVideoRepository, videoService *service.MockVideoService, stages []Stage) *Pool {
+	pool := NewPool(jobRepo, videoRepo, videoService, stages, Config{
+		StageTimeout: 50 * time.Millisecond,
+		BackoffBase:  time.Minute,
+		BackoffMax:   5 * time.Minute,
+	})
+	pool.now = func() time.Time { return fixedNow }
+	return pool
+}
+
+func TestProcess_Success(t *testing.T) {
+	jobRepo := new(repo.MockJobRepository)
+	videoRepo := new(repo.MockVideoRepository)
+	videoService := new(service.MockVideoService)
+
+	var ran []string
+	pool := newTestPool(jobRepo, videoRepo, videoService, []Stage{
+		recordingStage(StageTranscribe, &ran, nil),
+		recordingStage(StageTranslate, &ran, nil),
+		recordingStage(StageSynthesize, &ran, nil),
+	})
+
+	job := &entity.Job{ID: 1, VideoID: 7, Attempts: 1, MaxAttempts: 3}
+	videoRepo.On("GetVideoByID", uint64(7)).Return(&entity.Video{ID: 7}, nil)
+	videoService.On("UpdateVideoStatus", uint64(7), entity.StatusProcessing, entity.SystemActorID, mock.Anything).Return(nil)
+	jobRepo.On("UpdateJobStage", uint64(1), mock.Anything).Return(nil)
+	jobRepo.On("CompleteJob", uint64(1)).Return(nil)
+	videoService.On("UpdateVideoStatus", uint64(7), entity.StatusSuccess, entity.SystemActorID, mock.Anything).Return(nil)
+
+	pool.process(context.Background(), job)
+
+	assert.Equal(t, []string{StageTranscribe, StageTranslate, StageSynthesize}, ran)
+	jobRepo.AssertExpectations(t)
+	videoService.AssertExpectations(t)
+}
+
+func TestProcess_ResumesFromFailedStage(t *testing.T) {
+	jobRepo := new(repo.MockJobRepository)
+	videoRepo := new(repo.MockVideoRepository)
+	videoService := new(service.MockVideoService)
+
+	var ran []string
+	pool := newTestPool(jobRepo, videoRepo, videoService, []Stage{
+		recordingStage(StageTranscribe, &ran, nil),
+		recordingStage(StageTranslate, &ran, nil),
+		recordingStage(StageSynthesize, &ran, nil),
+	})
+
+	job := &entity.Job{ID: 1, VideoID: 7, Stage: StageTranslate, Attempts: 2, MaxAttempts: 3}
+	videoRepo.On("GetVideoByID", uint64(7)).Return(&entity.Video{ID: 7}, nil)
+	videoService.On("UpdateVideoStatus", uint64(7), mock.Anything, entity.SystemActorID, mock.Anything).Return(nil)
+	jobRepo.On("UpdateJobStage", uint64(1), mock.Anything).Return(nil)
+	jobRepo.On("CompleteJob", uint64(1)).Return(nil)
+
+	pool.process(context.Background(), job)
+
+	assert.Equal(t, []string{StageTranslate, StageSynthesize}, ran)
+}
+
+func TestProcess_RetriesWithBackoff(t *testing.T) {
+	jobRepo := new(repo.MockJobRepository)
+	videoRepo := new(repo.MockVideoRepository)
+	videoService := new(service.MockVideoService)
+
+	var ran []string
+	pool := newTestPool(jobRepo, videoRepo, videoService, []Stage{
+		recordingStage(StageTranscribe, &ran, errors.New("model unavailable")),
+		recordingStage(StageTranslate, &ran, nil),
+	})
+
+	job := &entity.Job{ID: 1, VideoID: 7, Attempts: 2, MaxAttempts: 3}
+	videoRepo.On("GetVideoByID", uint64(7)).Return(&entity.Video{ID: 7}, nil)
+	videoService.On("UpdateVideoStatus", uint64(7), entity.StatusProcessing, entity.SystemActorID, mock.Anything).Return(nil)
+	jobRepo.On("UpdateJobStage", uint64(1), StageTranscribe).Return(nil)
+	jobRepo.On("RetryJob", uint64(1), fixedNow.Add(2*time.Minute), "stage transcribe: model unavailable").Return(nil)
+
+	pool.process(context.Background(), job)
+
+	assert.Equal(t, []string{StageTranscribe}, ran)
+	jobRepo.AssertExpectations(t)
+	videoService.AssertNotCalled(t, "UpdateVideoStatus", uint64(7), entity.StatusFailed, entity.SystemActorID, mock.Anything)
+}
+
+func TestProcess_FailsAfterLastAttempt(t *testing.T) {
+	jobRepo := new(repo.MockJobRepository)
+	videoRepo := new(repo.MockVideoRepository)
+	videoService := new(service.MockVideoService)
+
+	var ran []string
+	pool := newTestPool(jobRepo, videoRepo, videoService, []Stage{
+		recordingStage(StageTranscribe, &ran, errors.New("model unavailable")),
+	})
+
+	job := &entity.Job{ID: 1, VideoID: 7, Attempts: 3, MaxAttempts: 3}
+	videoRepo.On("GetVideoByID", uint64(7)).Return(&entity.Video{ID: 7}, nil)
+	videoService.On("UpdateVideoStatus", uint64(7), entity.StatusProcessing, entity.SystemActorID, mock.Anything).Return(nil)
+	jobRepo.On("UpdateJobStage", uint64(1), StageTranscribe).Return(nil)
+	jobRepo.On("FailJob", uint64(1), "stage transcribe: model unavailable").Return(nil)
+	videoService.On("UpdateVideoStatus", uint64(7), entity.StatusFailed, entity.SystemActorID, mock.Anything).Return(nil)
+
+	pool.process(context.Background(), job)
+
+	jobRepo.AssertExpectations(t)
+	videoService.AssertExpectations(t)
+}
+
+func TestProcess_PermanentErrorSkipsRetries(t *testing.T) {
+	jobRepo := new(repo.MockJobRepository)
+	videoRepo := new(repo.MockVideoRepository)
+	videoService := new(service.MockVideoService)
+
+	var ran []string
+	pool := newTestPool(jobRepo, videoRepo, videoService, []Stage{
+		recordingStage(StageTranscribe, &ran, Permanent(errors.New("unsupported codec"))),
+	})
+
+	job := &entity.Job{ID: 1, VideoID: 7, Attempts: 1, MaxAttempts: 3}
+	videoRepo.On("GetVideoByID", uint64(7)).Return(&entity.Video{ID: 7}, nil)
+	videoService.On("UpdateVideoStatus", uint64(7), mock.Anything, entity.SystemActorID, mock.Anything).Return(nil)
+	jobRepo.On("UpdateJobStage", uint64(1), StageTranscribe).Return(nil)
+	jobRepo.On("FailJob", uint64(1), "stage transcribe: unsupported codec").Return(nil)
+
+	pool.process(context.Background(), job)
+
+	jobRepo.AssertExpectations(t)
+	videoService.AssertCalled(t, "UpdateVideoStatus", uint64(7), entity.StatusFailed, entity.SystemActorID, mock.Anything)
+}
+
+func TestProcess_StageTimeout(t *testing.T) {
+	jobRepo := new(repo.MockJobRepository)
+	videoRepo := new(repo.MockVideoRepository)
+	videoService := new(service.MockVideoService)
+
+	slow := NewStage(StageTranscribe, func(ctx context.Context, video *entity.Video) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	pool := newTestPool(jobRepo, videoRepo, videoService, []Stage{slow})
+
+	job := &entity.Job{ID: 1, VideoID: 7, Attempts: 1, MaxAttempts: 3}
+	videoRepo.On("GetVideoByID", uint64(7)).Return(&entity.Video{ID: 7}, nil)
+	videoService.On("UpdateVideoStatus", uint64(7), entity.StatusProcessing, entity.SystemActorID, mock.Anything).Return(nil)
+	jobRepo.On("UpdateJobStage", uint64(1), StageTranscribe).Return(nil)
+	jobRepo.On("RetryJob", uint64(1), fixedNow.Add(time.Minute), "stage transcribe: context deadline exceeded").Return(nil)
+
+	pool.process(context.Background(), job)
+
+	jobRepo.AssertExpectations(t)
+}
+
+func TestProcess_VideoDeleted(t *testing.T) {
+	jobRepo := new(repo.MockJobRepository)
+	videoRepo := new(repo.MockVideoRepository)
+	videoService := new(service.MockVideoService)
+	pool := newTestPool(jobRepo, videoRepo, videoService, DefaultStages())
+
+	job := &entity.Job{ID: 1, VideoID: 7, Attempts: 1, MaxAttempts: 3}
+	videoRepo.On("GetVideoByID", uint64(7)).Return((*entity.Video)(nil), nil)
+	jobRepo.On("FailJob", uint64(1), "video 7 not found").Return(nil)
+
+	pool.process(context.Background(), job)
+
+	jobRepo.AssertExpectations(t)
+	videoService.AssertNotCalled(t, "UpdateVideoStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBackoff(t *testing.T) {
+	pool := newTestPool(nil, nil, nil, nil)
+
+	assert.Equal(t, time.Minute, pool.backoff(1))
+	assert.Equal(t, 2*time.Minute, pool.backoff(2))
+	assert.Equal(t, 4*time.Minute, pool.backoff(3))
+	assert.Equal(t, 5*time.Minute, pool.backoff(4))
+	assert.Equal(t, 5*time.Minute, pool.backoff(20))
+}
+
+func TestProcess_TerminalVideoFailsJob(t *testing.T) {
+	jobRepo := new(repo.MockJobRepository)
+	videoRepo := new(repo.MockVideoRepository)
+	videoService := new(service.MockVideoService)
+	pool := newTestPool(jobRepo, videoRepo, videoService, DefaultStages())
+
+	job := &entity.Job{ID: 1, VideoID: 7, Attempts: 1, MaxAttempts: 3}
+	transitionErr := &service.InvalidStatusTransitionError{From: entity.StatusSuccess, To: entity.StatusProcessing}
+	videoRepo.On("GetVideoByID", uint64(7)).Return(&entity.Video{ID: 7, Status: entity.StatusSuccess}, nil)
+	videoService.On("UpdateVideoStatus", uint64(7), entity.StatusProcessing, entity.SystemActorID, mock.Anything).Return(transitionErr)
+	jobRepo.On("FailJob", uint64(1), transitionErr.Error()).Return(nil)
+
+	pool.process(context.Background(), job)
+
+	jobRepo.AssertExpectations(t)
+	jobRepo.AssertNotCalled(t, "RetryJob", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,99 @@
+package worker
+
+import (
+	"context"
+
+	"mlvt/internal/infra/env"
+	"mlvt/internal/infra/jwtkeys"
+	"mlvt/internal/repo"
+	"mlvt/internal/service"
+
+	"github.com/google/wire"
+)
+
+// PoolEnabled turns the video processing pool on. Its transcribe, translate and synthesize stages are stubs,
+// and would mark every video processed without output, so the pool stays off unless WORKER_ENABLED is set.
+var PoolEnabled = env.EnvConfig.WorkerEnabled
+
+// PoolSettings controls how the pool claims and runs jobs; zero values fall back to the defaults
+var PoolSettings = Config{
+	Workers:      env.EnvConfig.WorkerCount,
+	PollInterval: env.EnvConfig.WorkerPollInterval,
+	StageTimeout: env.EnvConfig.JobStageTimeout,
+	MaxAttempts:  env.EnvConfig.JobMaxAttempts,
+	BackoffBase:  env.EnvConfig.JobBackoffBase,
+	BackoffMax:   env.EnvConfig.JobBackoffMax,
+}
+
+// Workers are the background jobs the server runs next to the API
+type Workers struct {
+	Pool           *Pool // Nil unless PoolEnabled
+	UploadJanitor  *UploadJanitor
+	StorageJanitor *StorageJanitor
+	KeyRotator     *KeyRotator // Nil when access tokens are signed with JWT_SECRET
+}
+
+func NewWorkers(pool *Pool, uploadJanitor *UploadJanitor, storageJanitor *StorageJanitor, keyRotator *KeyRotator) *Workers {
+	return &Workers{Pool: pool, UploadJanitor: uploadJanitor, StorageJanitor: storageJanitor, KeyRotator: keyRotator}
+}
+
+// Start launches every configured worker
+func (w *Workers) Start(ctx context.Context) {
+	if w.Pool != nil {
+		w.Pool.Start(ctx)
+	}
+	w.UploadJanitor.Start(ctx)
+	w.StorageJanitor.Start(ctx)
+	if w.KeyRotator != nil {
+		w.KeyRotator.Start(ctx)
+	}
+}
+
+// Stop stops the workers and waits for their current passes to finish
+func (w *Workers) Stop() {
+	if w.Pool != nil {
+		w.Pool.Stop()
+	}
+	w.UploadJanitor.Stop()
+	w.StorageJanitor.Stop()
+	if w.KeyRotator != nil {
+		w.KeyRotator.Stop()
+	}
+}
+
+// ProvidePool creates the video processing pool, or returns nil unless PoolEnabled
+func ProvidePool(jobRepo repo.JobRepository, videoRepo repo.VideoRepository, videoService service.VideoService, stages []Stage, config Config) *Pool {
+	if !PoolEnabled {
+		return nil
+	}
+	return NewPool(jobRepo, videoRepo, videoService, stages, config)
+}
+
+// ProvideUploadJanitor creates the janitor of unfinalized uploads, running every UPLOAD_CLEANUP_INTERVAL
+func ProvideUploadJanitor(uploadService service.UploadService) *UploadJanitor {
+	return NewUploadJanitor(uploadService, env.EnvConfig.UploadCleanupInterval, 0)
+}
+
+// ProvideStorageJanitor creates the janitor of deleted records' objects, running every STORAGE_CLEANUP_INTERVAL
+func ProvideStorageJanitor(cleanupService service.StorageCleanupService) *StorageJanitor {
+	return NewStorageJanitor(cleanupService, env.EnvConfig.StorageCleanupInterval, 0)
+}
+
+// ProvideKeyRotator creates the rotator of the JWT signing keys, or returns nil without keys
+func ProvideKeyRotator(keys *jwtkeys.KeySet) *KeyRotator {
+	if keys == nil {
+		return nil
+	}
+	return NewKeyRotator(keys, 0)
+}
+
+// ProviderSetWorker is providers.
+var ProviderSetWorker = wire.NewSet(
+	NewWorkers,
+	DefaultStages,
+	ProvidePool,
+	ProvideUploadJanitor,
+	ProvideStorageJanitor,
+	ProvideKeyRotator,
+	wire.Value(PoolSettings),
+)
@@ -0,0 +1,21 @@
+package worker
+
+import (
+	"testing"
+
+	"mlvt/internal/repo"
+	"mlvt/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvidePool(t *testing.T) {
+	defer func(enabled bool) { PoolEnabled = enabled }(PoolEnabled)
+
+	// The placeholder stages would mark every video processed, so the pool needs WORKER_ENABLED
+	PoolEnabled = false
+	assert.Nil(t, ProvidePool(new(repo.MockJobRepository), new(repo.MockVideoRepository), new(service.MockVideoService), DefaultStages(), Config{}))
+
+	PoolEnabled = true
+	assert.NotNil(t, ProvidePool(new(repo.MockJobRepository), new(repo.MockVideoRepository), new(service.MockVideoService), DefaultStages(), Config{}))
+}
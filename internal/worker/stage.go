@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"mlvt/internal/entity"
+	"mlvt/internal/infra/zap-logging/log"
+)
+
+// Names of the built-in pipeline stages
+const (
+	StageTranscribe = "transcribe"
+	StageTranslate  = "translate"
+	StageSynthesize = "synthesize"
+)
+
+// Stage is one step of the video processing pipeline.
+// Run must honour ctx cancellation; the pool cancels it when the stage timeout expires.
+type Stage interface {
+	Name() string
+	Run(ctx context.Context, video *entity.Video) error
+}
+
+// StageFunc adapts a plain function into a Stage
+type StageFunc struct {
+	name string
+	fn   func(ctx context.Context, video *entity.Video) error
+}
+
+// NewStage creates a Stage that runs fn
+func NewStage(name string, fn func(ctx context.Context, video *entity.Video) error) *StageFunc {
+	return &StageFunc{name: name, fn: fn}
+}
+
+func (s *StageFunc) Name() string {
+	return s.name
+}
+
+func (s *StageFunc) Run(ctx context.Context, video *entity.Video) error {
+	return s.fn(ctx, video)
+}
+
+// DefaultStages returns the transcribe, translate and synthesize stages. They are stubs that only log
+// until the model services are connected, so the pool runs them only when WORKER_ENABLED is set.
+// Nothing else runs in the pool; replace them with real implementations through NewPool.
+func DefaultStages() []Stage {
+	return []Stage{
+		NewStage(StageTranscribe, logStage(StageTranscribe)),
+		NewStage(StageTranslate, logStage(StageTranslate)),
+		NewStage(StageSynthesize, logStage(StageSynthesize)),
+	}
+}
+
+func logStage(name string) func(ctx context.Context, video *entity.Video) error {
+	return func(ctx context.Context, video *entity.Video) error {
+		log.Infof("Running stage %s for video %d", name, video.ID)
+		return ctx.Err()
+	}
+}
+
+// permanentError marks a stage failure that retrying will not fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the pool fails the job immediately instead of retrying
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"mlvt/internal/infra/zap-logging/log"
+)
+
+// Default storage janitor settings used when a field is left at zero
+const (
+	DefaultStorageCleanupInterval = time.Minute
+	DefaultStorageCleanupBatch    = 100
+)
+
+// DeletionProcessor deletes queued storage objects; service.StorageCleanupService satisfies it
+type DeletionProcessor interface {
+	ProcessDeletions(now time.Time, limit int) (int, error)
+}
+
+// StorageJanitor periodically drains the queue of storage objects whose records were deleted
+type StorageJanitor struct {
+	processor DeletionProcessor
+	interval  time.Duration
+	batch     int
+	now       func() time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewStorageJanitor creates a janitor that runs every interval and deletes up to batch objects per pass
+func NewStorageJanitor(processor DeletionProcessor, interval time.Duration, batch int) *StorageJanitor {
+	if interval <= 0 {
+		interval = DefaultStorageCleanupInterval
+	}
+	if batch <= 0 {
+		batch = DefaultStorageCleanupBatch
+	}
+	return &StorageJanitor{
+		processor: processor,
+		interval:  interval,
+		batch:     batch,
+		now:       time.Now,
+	}
+}
+
+// Start launches the cleanup loop; the first pass runs immediately
+func (j *StorageJanitor) Start(ctx context.Context) {
+	ctx, j.cancel = context.WithCancel(ctx)
+
+	j.wg.Add(1)
+	go j.loop(ctx)
+}
+
+// Stop signals the cleanup loop to finish and waits for it to return
+func (j *StorageJanitor) Stop() {
+	if j.cancel != nil {
+		j.cancel()
+	}
+	j.wg.Wait()
+}
+
+func (j *StorageJanitor) loop(ctx context.Context) {
+	defer j.wg.Done()
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.runOnce()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOnce processes due deletions in batches until a batch comes back short or fails.
+// Failed deletions are rescheduled in the future, so they do not keep a pass going.
+func (j *StorageJanitor) runOnce() {
+	for {
+		count, err := j.processor.ProcessDeletions(j.now(), j.batch)
+		if err != nil {
+			log.Errorf("Failed to process storage deletions: %v", err)
+			return
+		}
+		if count > 0 {
+			log.Infof("Processed %d storage deletions", count)
+		}
+		if count < j.batch {
+			return
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package worker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"mlvt/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorageJanitor_RunOnceDrainsFullBatches(t *testing.T) {
+	processor := new(service.MockStorageCleanupService)
+	janitor := NewStorageJanitor(processor, time.Minute, 2)
+	janitor.now = func() time.Time { return fixedNow }
+
+	processor.On("ProcessDeletions", fixedNow, 2).Return(2, nil).Once()
+	processor.On("ProcessDeletions", fixedNow, 2).Return(0, nil).Once()
+
+	janitor.runOnce()
+	processor.AssertNumberOfCalls(t, "ProcessDeletions", 2)
+}
+
+func TestStorageJanitor_RunOnceStopsOnError(t *testing.T) {
+	processor := new(service.MockStorageCleanupService)
+	janitor := NewStorageJanitor(processor, 0, 0)
+	janitor.now = func() time.Time { return fixedNow }
+	assert.Equal(t, DefaultStorageCleanupInterval, janitor.interval)
+
+	processor.On("ProcessDeletions", fixedNow, DefaultStorageCleanupBatch).Return(0, errors.New("database locked"))
+
+	janitor.runOnce()
+	processor.AssertNumberOfCalls(t, "ProcessDeletions", 1)
+}
@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"mlvt/internal/infra/zap-logging/log"
+)
+
+// Default janitor settings used when a field is left at zero
+const (
+	DefaultUploadCleanupInterval = time.Hour
+	DefaultUploadCleanupBatch    = 100
+)
+
+// UploadCleaner expires stale uploads; service.UploadService satisfies it
+type UploadCleaner interface {
+	CleanupStaleUploads(now time.Time, limit int) (int, error)
+}
+
+// UploadJanitor periodically garbage-collects video uploads that were never finalized
+type UploadJanitor struct {
+	cleaner  UploadCleaner
+	interval time.Duration
+	batch    int
+	now      func() time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewUploadJanitor creates a janitor that runs every interval and expires up to batch uploads per pass
+func NewUploadJanitor(cleaner UploadCleaner, interval time.Duration, batch int) *UploadJanitor {
+	if interval <= 0 {
+		interval = DefaultUploadCleanupInterval
+	}
+	if batch <= 0 {
+		batch = DefaultUploadCleanupBatch
+	}
+	return &UploadJanitor{
+		cleaner:  cleaner,
+		interval: interval,
+		batch:    batch,
+		now:      time.Now,
+	}
+}
+
+// Start launches the cleanup loop; the first pass runs immediately
+func (j *UploadJanitor) Start(ctx context.Context) {
+	ctx, j.cancel = context.WithCancel(ctx)
+
+	j.wg.Add(1)
+	go j.loop(ctx)
+}
+
+// Stop signals the cleanup loop to finish and waits for it to return
+func (j *UploadJanitor) Stop() {
+	if j.cancel != nil {
+		j.cancel()
+	}
+	j.wg.Wait()
+}
+
+func (j *UploadJanitor) loop(ctx context.Context) {
+	defer j.wg.Done()
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.runOnce()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOnce expires stale uploads in batches until a batch comes back short or fails
+func (j *UploadJanitor) runOnce() {
+	for {
+		count, err := j.cleaner.CleanupStaleUploads(j.now(), j.batch)
+		if err != nil {
+			log.Errorf("Failed to clean up stale uploads: %v", err)
+			return
+		}
+		if count > 0 {
+			log.Infof("Expired %d stale uploads", count)
+		}
+		if count < j.batch {
+			return
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package worker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"mlvt/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadJanitor_RunOnceDrainsFullBatches(t *testing.T) {
+	cleaner := new(service.MockUploadService)
+	janitor := NewUploadJanitor(cleaner, time.Minute, 2)
+	janitor.now = func() time.Time { return fixedNow }
+
+	cleaner.On("CleanupStaleUploads", fixedNow, 2).Return(2, nil).Twice()
+	cleaner.On("CleanupStaleUploads", fixedNow, 2).Return(1, nil).Once()
+
+	janitor.runOnce()
+	cleaner.AssertNumberOfCalls(t, "CleanupStaleUploads", 3)
+}
+
+func TestUploadJanitor_RunOnceStopsOnError(t *testing.T) {
+	cleaner := new(service.MockUploadService)
+	janitor := NewUploadJanitor(cleaner, 0, 0)
+	janitor.now = func() time.Time { return fixedNow }
+	assert.Equal(t, DefaultUploadCleanupInterval, janitor.interval)
+
+	cleaner.On("CleanupStaleUploads", fixedNow, DefaultUploadCleanupBatch).Return(DefaultUploadCleanupBatch, errors.New("storage down"))
+
+	janitor.runOnce()
+	cleaner.AssertNumberOfCalls(t, "CleanupStaleUploads", 1)
+}
@@ -3,6 +3,6 @@
 echo "Building the application..."
 
 # Compile the Go application
-go build -o bin/mlvt cmd/server/main.go
+go build -tags sqlite_fts5 -o bin/mlvt cmd/server/main.go
 
 echo "Build complete! Executable created in the bin/ directory."
@@ -28,7 +28,7 @@ cd - # Go back to the root directory
 
 # Step 3: Build the application
 log_info "Step 3: Building the application..."
-cd $CMD_DIR && go build -o $APP_NAME
+cd $CMD_DIR && go build -tags sqlite_fts5 -o $APP_NAME
 cd - # Go back to the root directory
 
 # Step 4: Generate Swagger documentation